@@ -30,6 +30,17 @@ func AutoCompleteContainers(ctx context.Context, args []string, cmd string, opts
 	return containers, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
 }
 
+func AutoCompleteJobs(ctx context.Context, args []string, cmd string, opts *GlobalCmdOptions) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+	jobs, err := jobsOnly(ctx, cmd, opts)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return jobs, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
 func AutoCompleteNetworks(ctx context.Context, args []string, cmd string, opts *GlobalCmdOptions) ([]string, cobra.ShellCompDirective) {
 	if len(args) != 0 {
 		return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
@@ -62,6 +73,14 @@ func containersOnly(ctx context.Context, cmd string, opts *GlobalCmdOptions) ([]
 	return h.ListContainers(), nil
 }
 
+func jobsOnly(ctx context.Context, cmd string, opts *GlobalCmdOptions) ([]string, error) {
+	h, err := buildHomelabContainersOnly(ctx, cmd, opts)
+	if err != nil {
+		return nil, err
+	}
+	return h.ListJobs(), nil
+}
+
 func networksOnly(ctx context.Context, cmd string, opts *GlobalCmdOptions) ([]string, error) {
 	h, err := buildHomelabNetworksOnly(ctx, cmd, opts)
 	if err != nil {
@@ -76,7 +95,7 @@ func networksOnly(ctx context.Context, cmd string, opts *GlobalCmdOptions) ([]st
 }
 
 func buildHomelabGroupsOnly(ctx context.Context, cmd string, opts *GlobalCmdOptions) (*config.HomelabGroupsOnly, error) {
-	path, err := configsPath(ctx, cmd, opts)
+	path, err := ConfigsPath(ctx, cmd, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +115,7 @@ func buildHomelabGroupsOnly(ctx context.Context, cmd string, opts *GlobalCmdOpti
 }
 
 func buildHomelabContainersOnly(ctx context.Context, cmd string, opts *GlobalCmdOptions) (*config.HomelabContainersOnly, error) {
-	path, err := configsPath(ctx, cmd, opts)
+	path, err := ConfigsPath(ctx, cmd, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +135,7 @@ func buildHomelabContainersOnly(ctx context.Context, cmd string, opts *GlobalCmd
 }
 
 func buildHomelabNetworksOnly(ctx context.Context, cmd string, opts *GlobalCmdOptions) (*config.HomelabNetworksOnly, error) {
-	path, err := configsPath(ctx, cmd, opts)
+	path, err := ConfigsPath(ctx, cmd, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -4,4 +4,6 @@ const (
 	ConfigCmdGroupID     = "config"
 	ContainersCmdGroupID = "containers"
 	NetworksCmdGroupID   = "networks"
+	ExportCmdGroupID     = "export"
+	DaemonCmdGroupID     = "daemon"
 )
@@ -3,20 +3,119 @@ package clicommon
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
+	"github.com/tuxdudehomelab/homelab/internal/config"
 	"github.com/tuxdudehomelab/homelab/internal/deployment"
 )
 
-func BuildDeployment(ctx context.Context, cmd string, opts *GlobalCmdOptions) (*deployment.Deployment, error) {
-	path, err := configsPath(ctx, cmd, opts)
+// BuildDeployment builds the deployment for commands that modify the
+// homelab deployment. The returned context must be used for managing the
+// deployment, since it refers to the docker daemon of the host specified
+// using --target-host if any. The returned function must be called once
+// done, to close the connection to the target host.
+func BuildDeployment(ctx context.Context, cmd string, opts *GlobalCmdOptions) (context.Context, *deployment.Deployment, func(), error) {
+	if err := ValidateNotAsHost(cmd, opts); err != nil {
+		return nil, nil, nil, err
+	}
+	return buildDeployment(ctx, cmd, opts)
+}
+
+// BuildReadOnlyDeployment builds the deployment for read-only commands,
+// evaluated as the host specified using --as-host or --target-host if
+// any.
+func BuildReadOnlyDeployment(ctx context.Context, cmd string, opts *GlobalCmdOptions) (*deployment.Deployment, error) {
+	ctx, err := WithAsHostInfo(ctx, cmd, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, dep, closer, err := buildDeployment(ctx, cmd, opts)
 	if err != nil {
 		return nil, err
 	}
+	// The deployment is evaluated without connecting to the target host
+	// any further.
+	closer()
+	return dep, nil
+}
 
-	dep, err := deployment.FromConfigsPath(ctx, path)
+// RebuildDeployment builds the deployment again from the homelab configs
+// for the long running commands, using the context returned by
+// BuildDeployment. The connection to the target host and the host info
+// from the context are reused, rather than connecting to the target host
+// once again.
+func RebuildDeployment(ctx context.Context, cmd string, opts *GlobalCmdOptions) (*deployment.Deployment, error) {
+	ctx, path, conf, err := parseConfigs(ctx, cmd, opts)
 	if err != nil {
-		return nil, fmt.Errorf("%s failed while parsing the configs, reason: %w", cmd, err)
+		return nil, err
 	}
+	return deploymentFromConfig(ctx, cmd, path, conf)
+}
 
+func buildDeployment(ctx context.Context, cmd string, opts *GlobalCmdOptions) (context.Context, *deployment.Deployment, func(), error) {
+	ctx, path, conf, err := parseConfigs(ctx, cmd, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctx, closer, err := WithTargetHost(ctx, cmd, opts, conf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Close the connection to the target host if the deployment cannot
+	// be built.
+	built := false
+	defer func() {
+		if !built {
+			closer()
+		}
+	}()
+
+	ctx, err = WithHostIPSelector(ctx, cmd, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dep, err := deploymentFromConfig(ctx, cmd, path, conf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	built = true
+	return ctx, dep, closer, nil
+}
+
+func parseConfigs(ctx context.Context, cmd string, opts *GlobalCmdOptions) (context.Context, string, *config.Homelab, error) {
+	path, err := ConfigsPath(ctx, cmd, opts)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	ctx, err = WithSecretsKeyFile(ctx, cmd, opts)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	r, err := config.MergedConfigsReader(ctx, path)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%s failed while parsing the configs, reason: %w", cmd, err)
+	}
+	conf := &config.Homelab{}
+	if err := conf.Parse(ctx, r); err != nil {
+		return nil, "", nil, fmt.Errorf("%s failed while parsing the configs, reason: %w", cmd, err)
+	}
+	return ctx, path, conf, nil
+}
+
+func deploymentFromConfig(ctx context.Context, cmd string, path string, conf *config.Homelab) (*deployment.Deployment, error) {
+	lock, err := deployment.ReadImageLock(filepath.Join(path, deployment.ImageLockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("%s failed while reading the image lock, reason: %w", cmd, err)
+	}
+	ctx = deployment.WithImageLock(ctx, lock)
+
+	dep, err := deployment.FromConfig(ctx, conf)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed while parsing the configs, reason: %w", cmd, err)
+	}
 	return dep, nil
 }
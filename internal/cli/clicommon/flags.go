@@ -3,6 +3,7 @@ package clicommon
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/tuxdudehomelab/homelab/internal/cli/cliconfig"
@@ -11,14 +12,24 @@ import (
 const (
 	cliConfigFlagStr  = "cli-config"
 	configsDirFlagStr = "configs-dir"
+	asHostFlagStr     = "as-host"
+	asArchFlagStr     = "as-arch"
+	asHostIPFlagStr   = "as-host-ip"
+	targetHostFlagStr = "target-host"
 )
 
 type GlobalCmdOptions struct {
 	cliConfig  string
 	configsDir string
+	asHost     string
+	asArch     string
+	asHostIP   string
+	targetHost string
 }
 
-func configsPath(ctx context.Context, cmd string, opts *GlobalCmdOptions) (string, error) {
+// ConfigsPath returns the path to the directory containing the homelab
+// configs.
+func ConfigsPath(ctx context.Context, cmd string, opts *GlobalCmdOptions) (string, error) {
 	configsPath, err := cliconfig.ConfigsPath(ctx, opts.cliConfig, opts.configsDir)
 	if err != nil {
 		return "", fmt.Errorf("%s failed while determining the configs path, reason: %w", cmd, err)
@@ -26,6 +37,38 @@ func configsPath(ctx context.Context, cmd string, opts *GlobalCmdOptions) (strin
 	return configsPath, nil
 }
 
+func secretsKeyFile(ctx context.Context, cmd string, opts *GlobalCmdOptions) (string, error) {
+	path, err := cliconfig.SecretsKeyFile(ctx, opts.cliConfig)
+	if err != nil {
+		return "", fmt.Errorf("%s failed while determining the secrets key file, reason: %w", cmd, err)
+	}
+	return path, nil
+}
+
+// ConfigsPathArgs returns the global flags specifying the homelab CLI
+// config or the configs dir if any, for passing them through while
+// invoking the homelab CLI later.
+func ConfigsPathArgs(opts *GlobalCmdOptions) ([]string, error) {
+	var res []string
+	for _, f := range []struct {
+		flag string
+		path string
+	}{
+		{cliConfigFlagStr, opts.cliConfig},
+		{configsDirFlagStr, opts.configsDir},
+	} {
+		if len(f.path) == 0 {
+			continue
+		}
+		p, err := filepath.Abs(f.path)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, "--"+f.flag, p)
+	}
+	return res, nil
+}
+
 func AddHomelabFlags(ctx context.Context, cmd *cobra.Command, opts *GlobalCmdOptions) {
 	cmd.PersistentFlags().StringVar(
 		&opts.cliConfig, cliConfigFlagStr, "", "The path to the Homelab CLI config")
@@ -38,4 +81,13 @@ func AddHomelabFlags(ctx context.Context, cmd *cobra.Command, opts *GlobalCmdOpt
 		log(ctx).Fatalf("failed to mark --%s flag as dirname flag", configsDirFlagStr)
 	}
 	cmd.MarkFlagsMutuallyExclusive(cliConfigFlagStr, configsDirFlagStr)
+	cmd.PersistentFlags().StringVar(
+		&opts.asHost, asHostFlagStr, "", "Evaluate the homelab config as the specified host (only for read-only commands)")
+	cmd.PersistentFlags().StringVar(
+		&opts.asArch, asArchFlagStr, "", "The arch of the host specified using --"+asHostFlagStr)
+	cmd.PersistentFlags().StringVar(
+		&opts.asHostIP, asHostIPFlagStr, "", "The IP of the host specified using --"+asHostFlagStr)
+	cmd.PersistentFlags().StringVar(
+		&opts.targetHost, targetHostFlagStr, "", "The host from the hosts config whose remote docker daemon is managed")
+	cmd.MarkFlagsMutuallyExclusive(asHostFlagStr, targetHostFlagStr)
 }
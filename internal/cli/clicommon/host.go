@@ -0,0 +1,126 @@
+package clicommon
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/cli/cliconfig"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+)
+
+// WithAsHostInfo returns a context with a synthetic host info when the
+// command is run with --as-host, to allow evaluating the homelab config
+// as though it were running on another host.
+func WithAsHostInfo(ctx context.Context, cmd string, opts *GlobalCmdOptions) (context.Context, error) {
+	if len(opts.asHost) == 0 {
+		if len(opts.asArch) > 0 {
+			return nil, fmt.Errorf("%s failed since --%s requires --%s to be specified", cmd, asArchFlagStr, asHostFlagStr)
+		}
+		if len(opts.asHostIP) > 0 {
+			return nil, fmt.Errorf("%s failed since --%s requires --%s to be specified", cmd, asHostIPFlagStr, asHostFlagStr)
+		}
+		return ctx, nil
+	}
+
+	if len(opts.asArch) > 0 && !host.IsSupportedArch(opts.asArch) {
+		return nil, fmt.Errorf("%s failed since --%s %s is not a supported arch", cmd, asArchFlagStr, opts.asArch)
+	}
+	var ip netip.Addr
+	if len(opts.asHostIP) > 0 {
+		var err error
+		ip, err = netip.ParseAddr(opts.asHostIP)
+		if err != nil {
+			return nil, fmt.Errorf("%s failed while parsing --%s, reason: %w", cmd, asHostIPFlagStr, err)
+		}
+	}
+	h := host.NewSyntheticHostInfo(host.MustHostInfo(ctx), opts.asHost, opts.asArch, ip)
+	log(ctx).Debugf("Evaluating the homelab config as host %s (arch: %s, IP: %s)", h.HostName, h.Arch, h.IP)
+	return host.WithHostInfo(ctx, h), nil
+}
+
+// ValidateNotAsHost returns an error if the command which modifies the
+// homelab deployment is run with --as-host.
+func ValidateNotAsHost(cmd string, opts *GlobalCmdOptions) error {
+	if len(opts.asHost) > 0 || len(opts.asArch) > 0 || len(opts.asHostIP) > 0 {
+		return fmt.Errorf("%s cannot be run with --%s, --%s or --%s since it is not a read-only command", cmd, asHostFlagStr, asArchFlagStr, asHostIPFlagStr)
+	}
+	return nil
+}
+
+// ValidateNoTargetHost returns an error if the command which operates
+// only on the local host is run with --target-host, where reason
+// describes why the command is limited to the local host.
+func ValidateNoTargetHost(cmd string, reason string, opts *GlobalCmdOptions) error {
+	if len(opts.targetHost) > 0 {
+		return fmt.Errorf("%s cannot be run with --%s since %s", cmd, targetHostFlagStr, reason)
+	}
+	return nil
+}
+
+// WithTargetHost returns a context with the docker API client and the
+// host info of the remote host specified using --target-host, if any,
+// along with a function closing the docker API client.
+func WithTargetHost(ctx context.Context, cmd string, opts *GlobalCmdOptions, conf *config.Homelab) (context.Context, func(), error) {
+	if len(opts.targetHost) == 0 {
+		return ctx, func() {}, nil
+	}
+
+	var target *config.Host
+	for i, h := range conf.Hosts {
+		if strings.EqualFold(h.Name, opts.targetHost) && len(h.HostGroup) == 0 && len(h.Arch) == 0 {
+			target = &conf.Hosts[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, nil, fmt.Errorf("%s failed since target host %s is not defined in the hosts config", cmd, opts.targetHost)
+	}
+	if len(target.Docker.Endpoint) == 0 {
+		return nil, nil, fmt.Errorf("%s failed since target host %s does not specify a docker endpoint in the hosts config", cmd, opts.targetHost)
+	}
+
+	ep := target.Docker.RemoteEndpoint()
+	client, err := docker.NewRemoteAPIClient(ctx, ep)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s failed while connecting to the target host %s, reason: %w", cmd, opts.targetHost, err)
+	}
+	h, err := docker.NewRemoteHostInfo(ctx, client, ep)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("%s failed while connecting to the target host %s, reason: %w", cmd, opts.targetHost, err)
+	}
+	if !strings.EqualFold(h.HostName, opts.targetHost) {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("%s failed since the docker daemon at %s reports the host name %s instead of the target host %s", cmd, ep.Endpoint, h.HostName, opts.targetHost)
+	}
+
+	log(ctx).Debugf("Managing the target host %s (arch: %s, IP: %s) using docker endpoint %s", h.HostName, h.Arch, h.IP, ep.Endpoint)
+	ctx = host.WithHostInfo(ctx, h)
+	closer := func() {
+		if err := client.Close(); err != nil {
+			log(ctx).Warnf("Failed to close the connection to the target host %s, reason: %v", opts.targetHost, err)
+		}
+	}
+	return docker.WithAPIClient(ctx, client), closer, nil
+}
+
+// WithHostIPSelector returns a context with the host info updated using
+// the host IP selector from the homelab CLI config, if any.
+func WithHostIPSelector(ctx context.Context, cmd string, opts *GlobalCmdOptions) (context.Context, error) {
+	sel, err := cliconfig.HostIPSelector(ctx, opts.cliConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed while determining the host IP selector, reason: %w", cmd, err)
+	}
+	if sel == nil {
+		return ctx, nil
+	}
+	h, err := host.MustHostInfo(ctx).WithSelectedIP(ctx, sel)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed while selecting the host IP, reason: %w", cmd, err)
+	}
+	return host.WithHostInfo(ctx, h), nil
+}
@@ -0,0 +1,50 @@
+package clicommon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
+)
+
+// WithSecretsKeyFile returns a context with the secrets key file from the
+// homelab CLI config, unless the context already specifies one.
+func WithSecretsKeyFile(ctx context.Context, cmd string, opts *GlobalCmdOptions) (context.Context, error) {
+	if _, found := secrets.KeyFileFromContext(ctx); found {
+		return ctx, nil
+	}
+	path, err := secretsKeyFile(ctx, cmd, opts)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.WithKeyFile(ctx, path), nil
+}
+
+// SecretsKey reads the secrets key from the key file configured in the
+// homelab CLI config.
+func SecretsKey(ctx context.Context, cmd string, opts *GlobalCmdOptions) (*secrets.Key, error) {
+	ctx, err := WithSecretsKeyFile(ctx, cmd, opts)
+	if err != nil {
+		return nil, err
+	}
+	key, err := secrets.KeyFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed while reading the secrets key, reason: %w", cmd, err)
+	}
+	return key, nil
+}
+
+// ConfigFiles returns the list of homelab config files under the configs
+// path.
+func ConfigFiles(ctx context.Context, cmd string, opts *GlobalCmdOptions) ([]string, error) {
+	path, err := ConfigsPath(ctx, cmd, opts)
+	if err != nil {
+		return nil, err
+	}
+	files, err := config.ListConfigFiles(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed while listing the config files, reason: %w", cmd, err)
+	}
+	return files, nil
+}
@@ -2,17 +2,22 @@ package cliconfig
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/tuxdudehomelab/homelab/internal/host"
 	"github.com/tuxdudehomelab/homelab/internal/utils"
 )
 
 type CLIConfig struct {
 	HomelabCLIConfig struct {
-		ConfigsPath string `yaml:"configsPath,omitempty"`
+		ConfigsPath    string          `yaml:"configsPath,omitempty"`
+		SecretsKeyFile string          `yaml:"secretsKeyFile,omitempty"`
+		HostIP         host.IPSelector `yaml:"hostIP,omitempty"`
 	} `yaml:"homelab,omitempty"`
 }
 
@@ -33,3 +38,29 @@ func (c *CLIConfig) parse(ctx context.Context, path string) error {
 	log(ctx).Tracef("Homelab CLI Config:\n%s\n", utils.PrettyPrintYAML(c))
 	return nil
 }
+
+// optionalConfig parses the homelab CLI config for looking up an optional
+// setting. Nil is returned if the default CLI config cannot be found.
+func optionalConfig(ctx context.Context, cliConfigFlag string, setting string) (*CLIConfig, error) {
+	var path string
+	if len(cliConfigFlag) > 0 {
+		path = cliConfigFlag
+	} else {
+		p, err := defaultPath(ctx)
+		if err != nil {
+			log(ctx).Debugf("Skipping %s lookup, reason: %s", setting, err)
+			return nil, nil
+		}
+		if _, err := os.Stat(p); errors.Is(err, fs.ErrNotExist) {
+			log(ctx).Debugf("Skipping %s lookup since the default Homelab CLI config does not exist", setting)
+			return nil, nil
+		}
+		path = p
+	}
+
+	config := CLIConfig{}
+	if err := config.parse(ctx, path); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
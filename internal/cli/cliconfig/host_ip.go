@@ -0,0 +1,27 @@
+package cliconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tuxdudehomelab/homelab/internal/host"
+)
+
+// HostIPSelector returns the host IP selector as configured in the homelab
+// CLI config. Nil is returned if the selector is not configured, or if the
+// default CLI config cannot be found.
+func HostIPSelector(ctx context.Context, cliConfigFlag string) (*host.IPSelector, error) {
+	config, err := optionalConfig(ctx, cliConfigFlag, "host IP selector")
+	if err != nil || config == nil {
+		return nil, err
+	}
+	sel := config.HomelabCLIConfig.HostIP
+	if sel.IsEmpty() {
+		return nil, nil
+	}
+	if err := sel.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid homelab.hostIP setting in the homelab CLI config, reason: %w", err)
+	}
+	log(ctx).Debugf("Using Homelab host IP selector from CLI config: %+v", sel)
+	return &sel, nil
+}
@@ -9,6 +9,7 @@ import (
 
 const (
 	defaultCLIConfigPathFormat = "%s/.homelab/config.yaml"
+	defaultStatePathFormat     = "%s/.homelab/state/%s"
 )
 
 func ConfigsPath(ctx context.Context, cliConfigFlag string, configsDirFlag string) (string, error) {
@@ -59,6 +60,24 @@ func defaultPath(ctx context.Context) (string, error) {
 	return path, nil
 }
 
+// DefaultStatePath returns the default path of the named file retaining
+// the homelab state across the CLI invocations, i.e.
+// "~/.homelab/state/<name>".
+func DefaultStatePath(ctx context.Context, name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain the user's home directory for the homelab state, reason: %w", err)
+	}
+
+	path, err := filepath.Abs(fmt.Sprintf(defaultStatePathFormat, homeDir, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to determine absolute path of the homelab state, reason: %w", err)
+	}
+
+	log(ctx).Debugf("Using default Homelab state path: %s", path)
+	return path, nil
+}
+
 func configPath(ctx context.Context, cliConfigFlag string) (string, error) {
 	// Use the flag from the command line if present.
 	if len(cliConfigFlag) > 0 {
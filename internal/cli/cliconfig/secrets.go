@@ -0,0 +1,20 @@
+package cliconfig
+
+import (
+	"context"
+)
+
+// SecretsKeyFile returns the path to the secrets key file as configured in
+// the homelab CLI config. An empty path is returned if the key file is not
+// configured, or if the default CLI config cannot be found.
+func SecretsKeyFile(ctx context.Context, cliConfigFlag string) (string, error) {
+	config, err := optionalConfig(ctx, cliConfigFlag, "secrets key file")
+	if err != nil || config == nil {
+		return "", err
+	}
+	p := config.HomelabCLIConfig.SecretsKeyFile
+	if len(p) > 0 {
+		log(ctx).Debugf("Using Homelab secrets key file from CLI config: %s", p)
+	}
+	return p, nil
+}
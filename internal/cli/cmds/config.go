@@ -12,6 +12,8 @@ import (
 func ConfigCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
 	cmd := buildConfigCmd(ctx)
 	cmd.AddCommand(config.ShowConfigCmd(ctx, opts))
+	cmd.AddCommand(config.ImportComposeCmd(ctx, opts))
+	cmd.AddCommand(config.ImportContainerCmd(ctx, opts))
 	return cmd
 }
 
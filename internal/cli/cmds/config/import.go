@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	groupFlagStr     = "group"
+	containerFlagStr = "container"
+	outputFlagStr    = "output"
+)
+
+// outputImportedConfig displays the imported config, or writes it to a
+// new file if an output path is specified, followed by the list of the
+// unsupported settings that were not imported.
+func outputImportedConfig(ctx context.Context, cmd string, output string, conf string, unsupportedDesc string, unsupported []string) error {
+	if len(output) > 0 {
+		if err := writeImportedConfig(output, conf, unsupportedDesc, unsupported); err != nil {
+			return fmt.Errorf("%s failed while writing the imported config, reason: %w", cmd, err)
+		}
+		log(ctx).Infof("Wrote the imported config to %s", output)
+	} else {
+		log(ctx).Infof("Imported config:\n%s", conf)
+	}
+
+	if len(unsupported) > 0 {
+		var sb strings.Builder
+		for _, u := range unsupported {
+			sb.WriteString(fmt.Sprintf("\n  - %s", u))
+		}
+		log(ctx).Warnf("Unsupported %s that were not imported:%s", unsupportedDesc, sb.String())
+	}
+	return nil
+}
+
+// writeImportedConfig writes the imported config to a new file, with the
+// unsupported settings listed as comments at the top.
+func writeImportedConfig(path string, conf string, unsupportedDesc string, unsupported []string) error {
+	var sb strings.Builder
+	if len(unsupported) > 0 {
+		sb.WriteString(fmt.Sprintf("# Unsupported %s that were not imported:\n", unsupportedDesc))
+		for _, u := range unsupported {
+			sb.WriteString(fmt.Sprintf("#   - %s\n", u))
+		}
+	}
+	sb.WriteString(conf)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
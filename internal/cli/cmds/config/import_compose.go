@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/compose"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	importComposeCmdStr = "config import-compose"
+)
+
+type importComposeCmdOptions struct {
+	group  string
+	output string
+}
+
+func ImportComposeCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	importOpts := importComposeCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "import-compose compose-file",
+		Short: "Imports a docker compose file into the homelab config",
+		Long: `Converts the services in a docker compose file into homelab containers config, all within a single group.
+
+The group defaults to the compose project name, falling back to the name of the directory containing the compose file. The containers are ordered based on depends_on, and IPAM entries are suggested for the compose networks. Relative bind mount sources are imported relative to CONTAINER_BASE_DIR and named volumes are imported as directories within CONTAINER_DATA_DIR. All the compose settings that could not be imported are listed at the end.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execImportComposeCmd(clicontext.HomelabContext(ctx), args[0], &importOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(
+		&importOpts.group, groupFlagStr, "", "Name of the group for the imported containers")
+	cmd.Flags().StringVar(
+		&importOpts.output, outputFlagStr, "", "Path to the homelab config file to write, instead of displaying the imported config")
+	return cmd
+}
+
+func execImportComposeCmd(ctx context.Context, path string, importOpts *importComposeCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	if err := clicommon.ValidateNotAsHost(importComposeCmdStr, opts); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s failed while opening the compose file, reason: %w", importComposeCmdStr, err)
+	}
+	defer f.Close()
+
+	res, err := compose.Import(f, &compose.ImportOptions{
+		Group:      importOpts.group,
+		ProjectDir: filepath.Dir(path),
+	})
+	if err != nil {
+		return fmt.Errorf("%s failed while importing %s, reason: %w", importComposeCmdStr, path, err)
+	}
+
+	return outputImportedConfig(ctx, importComposeCmdStr, importOpts.output, utils.PrettyPrintYAML(res.Config), "compose settings", res.Unsupported)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	importContainerCmdStr = "config import-container"
+)
+
+type importContainerCmdOptions struct {
+	group     string
+	container string
+	output    string
+}
+
+func ImportContainerCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	importOpts := importContainerCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "import-container docker-container-name",
+		Short: "Imports an existing docker container into the homelab config",
+		Long: `Inspects an existing docker container and converts it into homelab container config, along with the IPAM entries for the networks it is connected to.
+
+The group and the container names default to the parts of the docker container name before and after the first '-'. The settings matching the defaults from the container image are omitted to keep the imported config minimal. All the container settings that could not be imported are listed at the end.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execImportContainerCmd(clicontext.HomelabContext(ctx), args[0], &importOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(
+		&importOpts.group, groupFlagStr, "", "Name of the group for the imported container")
+	cmd.Flags().StringVar(
+		&importOpts.container, containerFlagStr, "", "Name of the imported container within the group")
+	cmd.Flags().StringVar(
+		&importOpts.output, outputFlagStr, "", "Path to the homelab config file to write, instead of displaying the imported config")
+	return cmd
+}
+
+func execImportContainerCmd(ctx context.Context, dockerName string, importOpts *importContainerCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	if err := clicommon.ValidateNotAsHost(importContainerCmdStr, opts); err != nil {
+		return err
+	}
+	if err := clicommon.ValidateNoTargetHost(importContainerCmdStr, "it imports the container from the local docker daemon", opts); err != nil {
+		return err
+	}
+
+	ref, _ := deployment.ContainerReferenceFromDockerName(dockerName)
+	if len(importOpts.group) > 0 {
+		ref.Group = importOpts.group
+	}
+	if len(importOpts.container) > 0 {
+		ref.Container = importOpts.container
+	}
+	if len(ref.Group) == 0 || len(ref.Container) == 0 {
+		return fmt.Errorf("%s requires the --%s and --%s flags since the group and the container cannot be determined from the docker container name %s", importContainerCmdStr, groupFlagStr, containerFlagStr, dockerName)
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	res, err := deployment.ImportContainer(ctx, dc, dockerName, ref)
+	if err != nil {
+		return fmt.Errorf("%s failed while importing the container %s, reason: %w", importContainerCmdStr, dockerName, err)
+	}
+
+	return outputImportedConfig(ctx, importContainerCmdStr, importOpts.output, utils.PrettyPrintYAML(res.Config), "container settings", res.Unsupported)
+}
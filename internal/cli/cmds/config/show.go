@@ -7,32 +7,56 @@ import (
 	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
 	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
 	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
 	"github.com/tuxdudehomelab/homelab/internal/utils"
 )
 
+const (
+	resolvedFlagStr = "resolved"
+	revealFlagStr   = "reveal"
+)
+
+type showConfigCmdOptions struct {
+	resolved bool
+	reveal   bool
+}
+
 func ShowConfigCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
-	return &cobra.Command{
+	showOpts := showConfigCmdOptions{}
+	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Shows the homelab config",
 		Long:  `Displays the homelab configuration.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
 			cmd.SilenceErrors = true
-			err := execShowConfigCmd(clicontext.HomelabContext(ctx), opts)
+			err := execShowConfigCmd(clicontext.HomelabContext(ctx), &showOpts, opts)
 			if err != nil {
 				return errors.NewHomelabRuntimeError(err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(
+		&showOpts.resolved, resolvedFlagStr, false, "Display the effective containers with the container templates resolved")
+	cmd.Flags().BoolVar(
+		&showOpts.reveal, revealFlagStr, false, "Display the decrypted values of the secrets instead of masking them")
+	return cmd
 }
 
-func execShowConfigCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) error {
-	dep, err := clicommon.BuildDeployment(ctx, "config show", opts)
+func execShowConfigCmd(ctx context.Context, showOpts *showConfigCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	if !showOpts.reveal {
+		ctx = secrets.WithMasked(ctx)
+	}
+	dep, err := clicommon.BuildReadOnlyDeployment(ctx, "config show", opts)
 	if err != nil {
 		return err
 	}
 
+	if showOpts.resolved {
+		log(ctx).Infof("Homelab config:\n%s", utils.PrettyPrintYAML(dep.ResolvedConfig()))
+		return nil
+	}
 	log(ctx).Infof("Homelab config:\n%s", utils.PrettyPrintYAML(dep.Config))
 	return nil
 }
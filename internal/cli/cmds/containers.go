@@ -14,6 +14,9 @@ func ContainersCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra
 	cmd.AddCommand(containers.StartCmd(ctx, opts))
 	cmd.AddCommand(containers.StopCmd(ctx, opts))
 	cmd.AddCommand(containers.PurgeCmd(ctx, opts))
+	cmd.AddCommand(containers.HealCmd(ctx, opts))
+	cmd.AddCommand(containers.BackupCmd(ctx, opts))
+	cmd.AddCommand(containers.RestoreCmd(ctx, opts))
 	return cmd
 }
 
@@ -0,0 +1,76 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+)
+
+const (
+	backupCmdStr = "containers backup"
+)
+
+func BackupCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "backup container",
+		Short: "Backs up the data of the container",
+		Long: `Archives the CONTAINER_DATA_DIR and the named volumes of the container specified in the group/container format into the backup destination, as per the backup section of the container in the homelab configuration.
+
+The container is stopped while being backed up and the same container is started again afterwards, unless backup.keepRunning is set. The archives are named after the container and the time of the backup, and are accompanied by a sha256 checksum file. Only the most recent archives as per backup.retention are retained.
+
+The command operates on the files of the local host, and hence cannot be run with --target-host.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Expected exactly one container name argument to be specified, but found %d instead", len(args))
+			}
+			_, _, err := validateContainerName(args[0])
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execContainerBackupCmd(clicontext.HomelabContext(ctx), args[0], opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return clicommon.AutoCompleteContainers(ctx, args, "containers backup autocomplete", opts)
+		},
+	}
+}
+
+func execContainerBackupCmd(ctx context.Context, containerArg string, opts *clicommon.GlobalCmdOptions) error {
+	g, ct := mustContainerName(containerArg)
+	// The data directory is archived from the local host, and hence the
+	// container must be managed by the local docker daemon.
+	if err := clicommon.ValidateNoTargetHost(backupCmdStr, "it operates on the files of the local host", opts); err != nil {
+		return err
+	}
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, backupCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	return clicommon.ExecContainerGroupCmd(
+		ctx,
+		backupCmdStr,
+		fmt.Sprintf("Backing up container %s in group %s", ct, g),
+		g,
+		ct,
+		dep,
+		func(ctx context.Context, c *deployment.Container, h *host.HostInfo, dc *docker.Client) error {
+			_, err := c.Backup(ctx, dc)
+			return err
+		},
+	)
+}
@@ -0,0 +1,117 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/cliconfig"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+)
+
+const (
+	healCmdStr           = "containers heal"
+	healStateFlagStr     = "state-file"
+	defaultHealStateFile = "heal.json"
+)
+
+type healCmdOptions struct {
+	stateFile string
+}
+
+func HealCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	healOpts := healCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "heal [container]",
+		Short: "Heals the unhealthy containers",
+		Long: `Restarts or recreates the containers reported as unhealthy by their health check, as per the health.onUnhealthy policy in the homelab configuration. All the containers are healed unless a container name is specified in the group/container format.
+
+The heal attempts are tracked in the state file across the invocations, backing off exponentially between the attempts, which makes the command suitable for running periodically (for instance using cron). The containers that turn unhealthy again after the maximum heal attempts are considered crash looping, and are no longer healed until they stay healthy or are started again.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("Expected at most one container name argument to be specified, but found %d instead", len(args))
+			}
+			if len(args) == 1 {
+				_, _, err := validateContainerName(args[0])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			containerArg := ""
+			if len(args) == 1 {
+				containerArg = args[0]
+			}
+			err := execContainerHealCmd(clicontext.HomelabContext(ctx), containerArg, &healOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return clicommon.AutoCompleteContainers(ctx, args, "containers heal autocomplete", opts)
+		},
+	}
+	cmd.Flags().StringVar(
+		&healOpts.stateFile, healStateFlagStr, "", "Path to the file tracking the heal attempts, defaults to ~/.homelab/state/heal.json")
+	return cmd
+}
+
+func execContainerHealCmd(ctx context.Context, containerArg string, healOpts *healCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	g, ct := clicommon.AllGroups, ""
+	action := "Healing all containers"
+	if len(containerArg) > 0 {
+		g, ct = mustContainerName(containerArg)
+		action = fmt.Sprintf("Healing container %s in group %s", ct, g)
+	}
+
+	stateFile := healOpts.stateFile
+	if len(stateFile) == 0 {
+		var err error
+		stateFile, err = cliconfig.DefaultStatePath(ctx, defaultHealStateFile)
+		if err != nil {
+			return fmt.Errorf("%s failed while determining the heal state file, reason: %w", healCmdStr, err)
+		}
+	}
+	state, err := deployment.ReadHealState(stateFile)
+	if err != nil {
+		return fmt.Errorf("%s failed while reading the heal state, reason: %w", healCmdStr, err)
+	}
+
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, healCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	now := time.Now()
+	healErr := clicommon.ExecContainerGroupCmd(
+		ctx,
+		healCmdStr,
+		action,
+		g,
+		ct,
+		dep,
+		func(ctx context.Context, c *deployment.Container, h *host.HostInfo, dc *docker.Client) error {
+			_, err := c.Heal(ctx, dc, state, now)
+			return err
+		},
+	)
+
+	// The heal attempts are recorded even if healing failed for some of
+	// the containers, since those attempts count towards the backoff.
+	if err := state.Write(stateFile); err != nil {
+		return fmt.Errorf("%s failed while writing the heal state, reason: %w", healCmdStr, err)
+	}
+	return healErr
+}
@@ -42,10 +42,11 @@ func PurgeCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Comm
 
 func execContainerPurgeCmd(ctx context.Context, containerArg string, opts *clicommon.GlobalCmdOptions) error {
 	g, ct := mustContainerName(containerArg)
-	dep, err := clicommon.BuildDeployment(ctx, "containers purge", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "containers purge", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	return clicommon.ExecContainerGroupCmd(
 		ctx,
@@ -0,0 +1,85 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+)
+
+const (
+	restoreCmdStr         = "containers restore"
+	restoreArchiveFlagStr = "archive"
+)
+
+type restoreCmdOptions struct {
+	archive string
+}
+
+func RestoreCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	restoreOpts := restoreCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "restore container",
+		Short: "Restores the data of the container from a backup",
+		Long: `Restores the CONTAINER_DATA_DIR and the named volumes of the container specified in the group/container format from a backup archive created using the backup command. The most recent archive in the backup destination is restored unless an archive is specified.
+
+The checksum of the archive is verified before restoring anything. A running container is stopped while being restored and the same container is started again afterwards. The included paths of the CONTAINER_DATA_DIR, or the whole of it if there are no includes, are replaced with the ones in the archive, removing any files created after the backup within them.
+
+The command operates on the files of the local host, and hence cannot be run with --target-host.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Expected exactly one container name argument to be specified, but found %d instead", len(args))
+			}
+			_, _, err := validateContainerName(args[0])
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execContainerRestoreCmd(clicontext.HomelabContext(ctx), args[0], &restoreOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return clicommon.AutoCompleteContainers(ctx, args, "containers restore autocomplete", opts)
+		},
+	}
+	cmd.Flags().StringVar(
+		&restoreOpts.archive, restoreArchiveFlagStr, "", "Path to the backup archive to restore, defaults to the most recent archive in the backup destination")
+	return cmd
+}
+
+func execContainerRestoreCmd(ctx context.Context, containerArg string, restoreOpts *restoreCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	g, ct := mustContainerName(containerArg)
+	// The data directory is restored on the local host, and hence the
+	// container must be managed by the local docker daemon.
+	if err := clicommon.ValidateNoTargetHost(restoreCmdStr, "it operates on the files of the local host", opts); err != nil {
+		return err
+	}
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, restoreCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	return clicommon.ExecContainerGroupCmd(
+		ctx,
+		restoreCmdStr,
+		fmt.Sprintf("Restoring container %s in group %s", ct, g),
+		g,
+		ct,
+		dep,
+		func(ctx context.Context, c *deployment.Container, h *host.HostInfo, dc *docker.Client) error {
+			_, err := c.Restore(ctx, dc, restoreOpts.archive)
+			return err
+		},
+	)
+}
@@ -42,10 +42,11 @@ func StartCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Comm
 
 func execContainerStartCmd(ctx context.Context, containerArg string, opts *clicommon.GlobalCmdOptions) error {
 	g, ct := mustContainerName(containerArg)
-	dep, err := clicommon.BuildDeployment(ctx, "containers start", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "containers start", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	// TODO: Identify dependent containers which are potentially using this
 	// container's networking stack, and if they are running already, start
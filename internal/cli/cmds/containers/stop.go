@@ -42,10 +42,11 @@ func StopCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Comma
 
 func execContainerStopCmd(ctx context.Context, containerArg string, opts *clicommon.GlobalCmdOptions) error {
 	g, ct := mustContainerName(containerArg)
-	dep, err := clicommon.BuildDeployment(ctx, "containers stop", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "containers stop", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	return clicommon.ExecContainerGroupCmd(
 		ctx,
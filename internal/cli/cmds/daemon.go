@@ -0,0 +1,13 @@
+package cmds
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/cmds/daemon"
+)
+
+func DaemonCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	return daemon.DaemonCmd(ctx, opts)
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/cliconfig"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	homelabdaemon "github.com/tuxdudehomelab/homelab/internal/daemon"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	daemonCmdStr            = "daemon"
+	restartUnhealthyFlagStr = "restart-unhealthy"
+	settleDelayFlagStr      = "settle-delay"
+	resyncIntervalFlagStr   = "resync-interval"
+	onceFlagStr             = "once"
+	jobsStateFileFlagStr    = "jobs-state-file"
+	defaultJobsStateFile    = "jobs.json"
+
+	defaultSettleDelay    = 2 * time.Second
+	defaultResyncInterval = 5 * time.Minute
+)
+
+type daemonCmdOptions struct {
+	restartUnhealthy bool
+	settleDelay      time.Duration
+	resyncInterval   time.Duration
+	once             bool
+	jobsStateFile    string
+}
+
+func DaemonCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	daemonOpts := daemonCmdOptions{}
+	cmd := &cobra.Command{
+		Use:     "daemon",
+		GroupID: clicommon.DaemonCmdGroupID,
+		Short:   "Runs the homelab daemon repairing any drift from the deployment",
+		Long: `Runs until interrupted, repairing any drift of the containers allowed to run on the host from the homelab deployment.
+
+The docker events are watched to recreate the containers that were removed, restart the containers that exited on their own outside of their restart policy, heal the unhealthy containers as per their health.onUnhealthy policy (or restart them using --restart-unhealthy when they have none) and create the missing networks again. The containers stopped intentionally (for instance using 'homelab containers stop') are left alone until they are started again.
+
+The configs directory is watched for changes, starting the newly added containers and recreating the running containers whose config changed. The scheduled jobs are run once they are due, tracking their runs in the same state file as 'homelab jobs due'. Use --once to reconcile all the containers and run the due jobs once and exit.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execDaemonCmd(clicontext.HomelabContext(ctx), &daemonOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(
+		&daemonOpts.restartUnhealthy, restartUnhealthyFlagStr, true, "Restart the containers reported as unhealthy by their health check, if they have no unhealthy policy")
+	cmd.Flags().DurationVar(
+		&daemonOpts.settleDelay, settleDelayFlagStr, defaultSettleDelay, "Time to wait for further events and config changes before reconciling")
+	cmd.Flags().DurationVar(
+		&daemonOpts.resyncInterval, resyncIntervalFlagStr, defaultResyncInterval, "Interval between the periodic reconciles of all the containers, 0 to disable")
+	cmd.Flags().BoolVar(
+		&daemonOpts.once, onceFlagStr, false, "Reconcile all the containers once and exit")
+	cmd.Flags().StringVar(
+		&daemonOpts.jobsStateFile, jobsStateFileFlagStr, "", "Path to the file tracking the job runs, defaults to ~/.homelab/state/jobs.json")
+	return cmd
+}
+
+func execDaemonCmd(ctx context.Context, daemonOpts *daemonCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	if daemonOpts.settleDelay < 0 {
+		return fmt.Errorf("%s failed while validating the --%s flag, reason: the delay cannot be negative", daemonCmdStr, settleDelayFlagStr)
+	}
+	if daemonOpts.resyncInterval < 0 {
+		return fmt.Errorf("%s failed while validating the --%s flag, reason: the interval cannot be negative", daemonCmdStr, resyncIntervalFlagStr)
+	}
+
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, daemonCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	path, err := clicommon.ConfigsPath(ctx, daemonCmdStr, opts)
+	if err != nil {
+		return err
+	}
+
+	jobsStateFile := daemonOpts.jobsStateFile
+	if len(jobsStateFile) == 0 {
+		jobsStateFile, err = cliconfig.DefaultStatePath(ctx, defaultJobsStateFile)
+		if err != nil {
+			return fmt.Errorf("%s failed while determining the jobs state file, reason: %w", daemonCmdStr, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, unix.SIGTERM)
+	defer stop()
+
+	homelabdaemon.Run(ctx, &homelabdaemon.Options{
+		Deployment:  dep,
+		ConfigsPath: path,
+		// The connection to the target host, if any, is reused across
+		// the reloads.
+		Reload: func(ctx context.Context) (*deployment.Deployment, error) {
+			return clicommon.RebuildDeployment(ctx, daemonCmdStr, opts)
+		},
+		RestartUnhealthy: daemonOpts.restartUnhealthy,
+		SettleDelay:      daemonOpts.settleDelay,
+		ResyncInterval:   daemonOpts.resyncInterval,
+		Once:             daemonOpts.once,
+		JobsStateFile:    jobsStateFile,
+	})
+	return nil
+}
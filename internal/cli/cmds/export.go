@@ -0,0 +1,15 @@
+package cmds
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/cmds/export"
+)
+
+func ExportCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	cmd := export.ExportCmd(ctx, opts)
+	cmd.AddCommand(export.SystemdCmd(ctx, opts))
+	return cmd
+}
@@ -0,0 +1,83 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+)
+
+const (
+	exportCmdStr  = "export"
+	formatFlagStr = "format"
+	outputFlagStr = "output"
+)
+
+type exportCmdOptions struct {
+	format string
+	output string
+}
+
+func ExportCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	exportOpts := exportCmdOptions{}
+	cmd := &cobra.Command{
+		Use:     "export",
+		GroupID: clicommon.ExportCmdGroupID,
+		Short:   "Exports the homelab deployment",
+		Long: `Exports the docker configs of all the containers allowed to run on the host, along with the networks they are connected to, allowing the deployment to be recreated without homelab.
+
+The supported formats are compose (a docker compose file), docker-run (a shell script using the docker CLI) and json (the docker API payloads). Use --as-host to export the deployment of another host.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execExportCmd(clicontext.HomelabContext(ctx), &exportOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(
+		&exportOpts.format, formatFlagStr, string(deployment.ExportFormatCompose), "The export format, one of compose, docker-run or json")
+	cmd.Flags().StringVar(
+		&exportOpts.output, outputFlagStr, "", "Path to the file to write, instead of displaying the exported deployment")
+	return cmd
+}
+
+func execExportCmd(ctx context.Context, exportOpts *exportCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	format, err := deployment.ParseExportFormat(exportOpts.format)
+	if err != nil {
+		return fmt.Errorf("%s failed while validating the --%s flag, reason: %w", exportCmdStr, formatFlagStr, err)
+	}
+
+	dep, err := clicommon.BuildReadOnlyDeployment(ctx, exportCmdStr, opts)
+	if err != nil {
+		return err
+	}
+
+	res, err := dep.Export(ctx, format)
+	if err != nil {
+		return fmt.Errorf("%s failed while exporting the deployment, reason: %w", exportCmdStr, err)
+	}
+
+	if len(exportOpts.output) == 0 {
+		log(ctx).Printf("%s", res)
+		return nil
+	}
+
+	perm := os.FileMode(0o644)
+	if format == deployment.ExportFormatDockerRun {
+		perm = 0o755
+	}
+	if err := os.WriteFile(exportOpts.output, []byte(res), perm); err != nil {
+		return fmt.Errorf("%s failed while writing the exported deployment, reason: %w", exportCmdStr, err)
+	}
+	log(ctx).Infof("Exported the deployment to %s", exportOpts.output)
+	return nil
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+)
+
+const (
+	exportSystemdCmdStr = "export systemd"
+	styleFlagStr        = "style"
+	scopeFlagStr        = "scope"
+	installFlagStr      = "install"
+	homelabBinFlagStr   = "homelab-bin"
+)
+
+type exportSystemdCmdOptions struct {
+	style      string
+	scope      string
+	install    string
+	homelabBin string
+}
+
+func SystemdCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	systemdOpts := exportSystemdCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "systemd",
+		Short: "Exports the systemd units for the homelab deployment",
+		Long: `Exports the systemd units for all the containers allowed to run on the host, ordered based on the group and the container order.
+
+The service style units invoke the homelab CLI for starting and stopping either the individual containers or the groups. The quadlet style units run the containers directly using podman, one unit per container along with the units for the networks. The paths listed under systemd.requiresMountsFor within the hosts config (e.g. NFS mounts) are required by all the units for the host.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execExportSystemdCmd(clicontext.HomelabContext(ctx), &systemdOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(
+		&systemdOpts.style, styleFlagStr, string(deployment.SystemdUnitStyleService), "The style of the units, one of service or quadlet")
+	cmd.Flags().StringVar(
+		&systemdOpts.scope, scopeFlagStr, string(deployment.SystemdUnitScopeContainer), "Whether to export one service unit per container or per group")
+	cmd.Flags().StringVar(
+		&systemdOpts.install, installFlagStr, "", "Path to the directory to install the units in, instead of displaying them")
+	if cmd.MarkFlagDirname(installFlagStr) != nil {
+		log(ctx).Fatalf("failed to mark --%s flag as dirname flag", installFlagStr)
+	}
+	cmd.Flags().StringVar(
+		&systemdOpts.homelabBin, homelabBinFlagStr, "", "Path to the homelab binary invoked by the service units, defaults to the currently running binary, and is required with --target-host")
+	return cmd
+}
+
+func execExportSystemdCmd(ctx context.Context, systemdOpts *exportSystemdCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	style, err := deployment.ParseSystemdUnitStyle(systemdOpts.style)
+	if err != nil {
+		return fmt.Errorf("%s failed while validating the --%s flag, reason: %w", exportSystemdCmdStr, styleFlagStr, err)
+	}
+	scope, err := deployment.ParseSystemdUnitScope(systemdOpts.scope)
+	if err != nil {
+		return fmt.Errorf("%s failed while validating the --%s flag, reason: %w", exportSystemdCmdStr, scopeFlagStr, err)
+	}
+	// The service units for the target host are run on the target host,
+	// where the currently running binary need not exist.
+	if style == deployment.SystemdUnitStyleService && len(systemdOpts.homelabBin) == 0 {
+		reason := fmt.Sprintf("the service units would invoke the local homelab binary, specify the homelab binary on the target host using --%s", homelabBinFlagStr)
+		if err := clicommon.ValidateNoTargetHost(exportSystemdCmdStr, reason, opts); err != nil {
+			return err
+		}
+	}
+
+	homelabCmd, err := homelabCmdLine(systemdOpts, opts)
+	if err != nil {
+		return fmt.Errorf("%s failed while determining the homelab command, reason: %w", exportSystemdCmdStr, err)
+	}
+
+	dep, err := clicommon.BuildReadOnlyDeployment(ctx, exportSystemdCmdStr, opts)
+	if err != nil {
+		return err
+	}
+
+	units, err := dep.ExportSystemdUnits(&deployment.SystemdExportOptions{
+		Style:      style,
+		Scope:      scope,
+		HomelabCmd: homelabCmd,
+	})
+	if err != nil {
+		return fmt.Errorf("%s failed while exporting the systemd units, reason: %w", exportSystemdCmdStr, err)
+	}
+	if len(units) == 0 {
+		log(ctx).Warnf("%s is a no-op since no containers are allowed to run on the host", exportSystemdCmdStr)
+		return nil
+	}
+
+	if len(systemdOpts.install) == 0 {
+		for i, u := range units {
+			if i > 0 {
+				log(ctx).Printf("\n")
+			}
+			log(ctx).Printf("# %s\n%s", u.FileName, u.Content)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(systemdOpts.install, 0o755); err != nil {
+		return fmt.Errorf("%s failed while creating the install directory, reason: %w", exportSystemdCmdStr, err)
+	}
+	for _, u := range units {
+		path := filepath.Join(systemdOpts.install, u.FileName)
+		if err := os.WriteFile(path, []byte(u.Content), 0o644); err != nil {
+			return fmt.Errorf("%s failed while installing the unit %s, reason: %w", exportSystemdCmdStr, u.FileName, err)
+		}
+		log(ctx).Infof("Installed the systemd unit %s", path)
+	}
+	log(ctx).Infof("Run 'systemctl daemon-reload' to load the installed units")
+	return nil
+}
+
+func homelabCmdLine(systemdOpts *exportSystemdCmdOptions, opts *clicommon.GlobalCmdOptions) ([]string, error) {
+	bin := systemdOpts.homelabBin
+	if len(bin) == 0 {
+		var err error
+		if bin, err = os.Executable(); err != nil {
+			return nil, err
+		}
+	}
+	bin, err := filepath.Abs(bin)
+	if err != nil {
+		return nil, err
+	}
+	args, err := clicommon.ConfigsPathArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{bin}, args...), nil
+}
@@ -37,10 +37,11 @@ func PurgeCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Comm
 }
 
 func execGroupPurgeCmd(ctx context.Context, group string, opts *clicommon.GlobalCmdOptions) error {
-	dep, err := clicommon.BuildDeployment(ctx, "groups purge", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "groups purge", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	var action string
 	if group == clicommon.AllGroups {
@@ -37,10 +37,11 @@ func StartCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Comm
 }
 
 func execGroupStartCmd(ctx context.Context, group string, opts *clicommon.GlobalCmdOptions) error {
-	dep, err := clicommon.BuildDeployment(ctx, "groups start", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "groups start", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	var action string
 	if group == clicommon.AllGroups {
@@ -37,10 +37,11 @@ func StopCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Comma
 }
 
 func execGroupStopCmd(ctx context.Context, group string, opts *clicommon.GlobalCmdOptions) error {
-	dep, err := clicommon.BuildDeployment(ctx, "groups stop", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "groups stop", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	var action string
 	if group == clicommon.AllGroups {
@@ -0,0 +1,29 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/cmds/images"
+)
+
+func ImagesCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	cmd := buildImagesCmd(ctx)
+	cmd.AddCommand(images.LockCmd(ctx, opts))
+	cmd.AddCommand(images.UpdateCmd(ctx, opts))
+	return cmd
+}
+
+func buildImagesCmd(ctx context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:     "images",
+		GroupID: clicommon.ContainersCmdGroupID,
+		Short:   "Homelab deployment image related commands",
+		Long:    `Pin the images of the containers and the jobs to their digests using the image lock.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("homelab images sub-command is required")
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+)
+
+func validateContainerName(name string) (string, string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Container name must be specified in the form 'group/container'")
+	}
+	return parts[0], parts[1], nil
+}
+
+func mustContainerName(name string) (string, string) {
+	g, c, err := validateContainerName(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return g, c
+}
+
+func imageLockFile(ctx context.Context, cmd string, opts *clicommon.GlobalCmdOptions) (string, error) {
+	path, err := clicommon.ConfigsPath(ctx, cmd, opts)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(path, deployment.ImageLockFileName), nil
+}
@@ -0,0 +1,64 @@
+package images
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+)
+
+const (
+	lockCmdStr = "images lock"
+)
+
+func LockCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lock",
+		Short: "Locks the images to their digests",
+		Long: `Resolves the images of all the containers and the jobs to their digests in the registry, and records them in the homelab.lock file in the configs directory.
+
+The images already in the lock are left untouched, while the ones no longer used are removed from the lock. The containers and the jobs are then started using the locked image digests instead of the tags, across all the hosts sharing the configs. The images which are not pulled, and the ones already specified using a digest are not locked.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execImagesLockCmd(clicontext.HomelabContext(ctx), opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+}
+
+func execImagesLockCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) error {
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, lockCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	lockFile, err := imageLockFile(ctx, lockCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	lock, err := deployment.ReadImageLock(lockFile)
+	if err != nil {
+		return fmt.Errorf("%s failed while reading the image lock, reason: %w", lockCmdStr, err)
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	if err := dep.LockImages(ctx, dc, lock); err != nil {
+		return fmt.Errorf("%s failed while locking the images, reason: %w", lockCmdStr, err)
+	}
+	if err := lock.Write(lockFile); err != nil {
+		return fmt.Errorf("%s failed while writing the image lock, reason: %w", lockCmdStr, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package images
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+)
+
+const (
+	updateCmdStr = "images update"
+)
+
+func UpdateCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [container]",
+		Short: "Updates the locked image digests",
+		Long: `Resolves the images to their current digests in the registry again, and updates the homelab.lock file in the configs directory.
+
+All the locked images are updated, unless a container or a job is specified in the group/container format, in which case only its image is updated. The containers are not restarted, and pick up the updated digests the next time they are started.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("Expected at most one container name argument to be specified, but found %d instead", len(args))
+			}
+			if len(args) == 1 {
+				_, _, err := validateContainerName(args[0])
+				return err
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execImagesUpdateCmd(clicontext.HomelabContext(ctx), args, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return clicommon.AutoCompleteContainers(ctx, args, "images update autocomplete", opts)
+		},
+	}
+}
+
+func execImagesUpdateCmd(ctx context.Context, args []string, opts *clicommon.GlobalCmdOptions) error {
+	var cRef *config.ContainerReference
+	if len(args) == 1 {
+		g, ct := mustContainerName(args[0])
+		cRef = &config.ContainerReference{Group: g, Container: ct}
+	}
+
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, updateCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	lockFile, err := imageLockFile(ctx, updateCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	lock, err := deployment.ReadImageLock(lockFile)
+	if err != nil {
+		return fmt.Errorf("%s failed while reading the image lock, reason: %w", updateCmdStr, err)
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	if err := dep.UpdateImageLock(ctx, dc, lock, cRef); err != nil {
+		return fmt.Errorf("%s failed while updating the image lock, reason: %w", updateCmdStr, err)
+	}
+	if err := lock.Write(lockFile); err != nil {
+		return fmt.Errorf("%s failed while writing the image lock, reason: %w", updateCmdStr, err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/cmds/jobs"
+)
+
+func JobsCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	cmd := buildJobsCmd(ctx)
+	cmd.AddCommand(jobs.RunCmd(ctx, opts))
+	cmd.AddCommand(jobs.DueCmd(ctx, opts))
+	return cmd
+}
+
+func buildJobsCmd(ctx context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:     "jobs",
+		GroupID: clicommon.ContainersCmdGroupID,
+		Short:   "Homelab deployment job related commands",
+		Long:    `Run the one-shot jobs on demand or as per their schedule.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("homelab jobs sub-command is required")
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/cli/cliconfig"
+)
+
+const (
+	stateFileFlagStr     = "state-file"
+	defaultJobsStateFile = "jobs.json"
+)
+
+func validateJobName(name string) (string, string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Job name must be specified in the form 'group/container'")
+	}
+	return parts[0], parts[1], nil
+}
+
+func mustJobName(name string) (string, string) {
+	g, c, err := validateJobName(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return g, c
+}
+
+func jobsStateFile(ctx context.Context, cmd, stateFile string) (string, error) {
+	if len(stateFile) > 0 {
+		return stateFile, nil
+	}
+	path, err := cliconfig.DefaultStatePath(ctx, defaultJobsStateFile)
+	if err != nil {
+		return "", fmt.Errorf("%s failed while determining the jobs state file, reason: %w", cmd, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+)
+
+const (
+	dueCmdStr = "jobs due"
+)
+
+type dueCmdOptions struct {
+	stateFile string
+}
+
+func DueCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	dueOpts := dueCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "due",
+		Short: "Runs the jobs due as per their schedule",
+		Long: `Runs the scheduled jobs allowed to run on the host that are due as per their schedule and their previous runs recorded in the state file, which makes the command suitable for running every minute (for instance using cron).
+
+The scheduled jobs that never ran before are due right away, while the runs missed since the previous run are caught up with a single run. The jobs without a schedule are run only using 'homelab jobs run'.
+
+Every job is claimed in the state file before running it, so that the other invocations sharing the state file (for instance the homelab daemon) don't run the same job at the same time.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execJobsDueCmd(clicontext.HomelabContext(ctx), &dueOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(
+		&dueOpts.stateFile, stateFileFlagStr, "", "Path to the file tracking the job runs, defaults to ~/.homelab/state/jobs.json")
+	return cmd
+}
+
+func execJobsDueCmd(ctx context.Context, dueOpts *dueCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	stateFile, err := jobsStateFile(ctx, dueCmdStr, dueOpts.stateFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, dueCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	jobs, err := dep.QueryAllJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed while querying the jobs, reason: %w", dueCmdStr, err)
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	now := time.Now()
+	var errList []error
+	for _, j := range jobs {
+		if !j.IsAllowedOnCurrentHost() {
+			continue
+		}
+		// The job is claimed prior to every run, to account for the
+		// runs recorded meanwhile by the other invocations sharing the
+		// state file, and to prevent them from running the job at the
+		// same time.
+		due, err := deployment.ClaimDueJob(stateFile, j, now)
+		if err != nil {
+			return fmt.Errorf("%s failed while reading the jobs state, reason: %w", dueCmdStr, err)
+		}
+		if !due {
+			continue
+		}
+		res, runErr := j.Run(ctx, dc)
+		// The run is recorded right away, so that the completed runs
+		// are retained even if the command is interrupted.
+		if err := deployment.RecordJobRun(stateFile, j, res); err != nil {
+			return fmt.Errorf("%s failed while writing the jobs state, reason: %w", dueCmdStr, err)
+		}
+		if runErr != nil {
+			errList = append(errList, runErr)
+		}
+	}
+
+	if len(errList) > 0 {
+		var sb strings.Builder
+		for i, e := range errList {
+			sb.WriteString(fmt.Sprintf("\n%d - %s", i+1, e))
+		}
+		return fmt.Errorf("%s failed for %d jobs, reason(s):%s", dueCmdStr, len(errList), sb.String())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+)
+
+const (
+	runCmdStr = "jobs run"
+)
+
+type runCmdOptions struct {
+	stateFile string
+}
+
+func RunCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	runOpts := runCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "run job",
+		Short: "Runs the job",
+		Long: `Runs the job specified in the group/container format, waiting for the job container to exit before removing it.
+
+The exit code and the last lines of the logs of the job are recorded in the state file, and the command fails unless the job container exits with a zero exit code within the job timeout.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Expected exactly one job name argument to be specified, but found %d instead", len(args))
+			}
+			_, _, err := validateJobName(args[0])
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execJobRunCmd(clicontext.HomelabContext(ctx), args[0], &runOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return clicommon.AutoCompleteJobs(ctx, args, "jobs run autocomplete", opts)
+		},
+	}
+	cmd.Flags().StringVar(
+		&runOpts.stateFile, stateFileFlagStr, "", "Path to the file tracking the job runs, defaults to ~/.homelab/state/jobs.json")
+	return cmd
+}
+
+func execJobRunCmd(ctx context.Context, jobArg string, runOpts *runCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	g, ct := mustJobName(jobArg)
+
+	stateFile, err := jobsStateFile(ctx, runCmdStr, runOpts.stateFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, runCmdStr, opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	job, err := dep.QueryJob(ctx, g, ct)
+	if err != nil {
+		return fmt.Errorf("%s failed while querying the job, reason: %w", runCmdStr, err)
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	res, runErr := job.Run(ctx, dc)
+	// The run is recorded even if the job failed.
+	if res != nil {
+		if err := deployment.RecordJobRun(stateFile, job, res); err != nil {
+			return fmt.Errorf("%s failed while writing the jobs state, reason: %w", runCmdStr, err)
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("%s failed, reason: %w", runCmdStr, runErr)
+	}
+	return nil
+}
@@ -37,10 +37,11 @@ func CreateCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Com
 }
 
 func execNetworksCreateCmd(ctx context.Context, network string, opts *clicommon.GlobalCmdOptions) error {
-	dep, err := clicommon.BuildDeployment(ctx, "networks create", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "networks create", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	return clicommon.ExecNetworksCmd(
 		ctx,
@@ -37,10 +37,11 @@ func DeleteCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Com
 }
 
 func execNetworksDeleteCmd(ctx context.Context, network string, opts *clicommon.GlobalCmdOptions) error {
-	dep, err := clicommon.BuildDeployment(ctx, "networks delete", opts)
+	ctx, dep, closer, err := clicommon.BuildDeployment(ctx, "networks delete", opts)
 	if err != nil {
 		return err
 	}
+	defer closer()
 
 	return clicommon.ExecNetworksCmd(
 		ctx,
@@ -0,0 +1,33 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/cmds/secrets"
+)
+
+func SecretsCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	cmd := buildSecretsCmd(ctx)
+	cmd.AddCommand(secrets.GenerateKeyCmd(ctx, opts))
+	cmd.AddCommand(secrets.EncryptCmd(ctx, opts))
+	cmd.AddCommand(secrets.DecryptCmd(ctx, opts))
+	cmd.AddCommand(secrets.RotateKeyCmd(ctx, opts))
+	return cmd
+}
+
+func buildSecretsCmd(ctx context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:     "secrets",
+		GroupID: clicommon.ConfigCmdGroupID,
+		Short:   "Homelab config secrets related commands",
+		Long: `Manage the encrypted secrets within the homelab configuration.
+
+Values tagged with !secret are encrypted in place and tagged with !encrypted. Values with an enc: prefix are also treated as encrypted. The configs are rejected while they still contain values tagged with !secret. The secrets key file is specified using homelab.secretsKeyFile in the homelab CLI config.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("homelab secrets sub-command is required")
+		},
+	}
+}
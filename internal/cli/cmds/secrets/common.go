@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+)
+
+type fileTransformer func(path string) (bool, error)
+
+func execSecretsFilesCmd(ctx context.Context, cmd string, files []string, opts *clicommon.GlobalCmdOptions, fn fileTransformer) error {
+	if len(files) == 0 {
+		var err error
+		files, err = clicommon.ConfigFiles(ctx, cmd, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	var errList []error
+	updated := 0
+	for _, f := range files {
+		// We ignore the errors to keep moving forward even if the action
+		// fails on one or more files.
+		changed, err := fn(f)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		if changed {
+			log(ctx).Infof("Updated %s", f)
+			updated++
+		}
+	}
+
+	if len(errList) > 0 {
+		var sb strings.Builder
+		for i, e := range errList {
+			sb.WriteString(fmt.Sprintf("\n%d - %s", i+1, e))
+		}
+		return fmt.Errorf("%s failed for %d config files, reason(s):%s", cmd, len(errList), sb.String())
+	}
+	if updated == 0 {
+		log(ctx).Warnf("%s is a no-op since no config files required any updates", cmd)
+	}
+	return nil
+}
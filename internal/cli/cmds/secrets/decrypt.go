@@ -0,0 +1,43 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
+)
+
+func DecryptCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt [config-file]...",
+		Short: "Decrypts the secrets in the homelab configs",
+		Long:  `Decrypts all the encrypted values in the specified config files, or all the config files under the homelab configs path if none are specified. The config files are rewritten in place with the plaintext values tagged with !secret.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execSecretsDecryptCmd(clicontext.HomelabContext(ctx), args, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+}
+
+func execSecretsDecryptCmd(ctx context.Context, files []string, opts *clicommon.GlobalCmdOptions) error {
+	if err := clicommon.ValidateNotAsHost("secrets decrypt", opts); err != nil {
+		return err
+	}
+
+	key, err := clicommon.SecretsKey(ctx, "secrets decrypt", opts)
+	if err != nil {
+		return err
+	}
+
+	return execSecretsFilesCmd(ctx, "secrets decrypt", files, opts, func(path string) (bool, error) {
+		return secrets.DecryptFile(path, key)
+	})
+}
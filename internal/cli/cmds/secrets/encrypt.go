@@ -0,0 +1,43 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
+)
+
+func EncryptCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt [config-file]...",
+		Short: "Encrypts the secrets in the homelab configs",
+		Long:  `Encrypts all the values tagged with !secret in the specified config files, or all the config files under the homelab configs path if none are specified. The config files are rewritten in place.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execSecretsEncryptCmd(clicontext.HomelabContext(ctx), args, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+}
+
+func execSecretsEncryptCmd(ctx context.Context, files []string, opts *clicommon.GlobalCmdOptions) error {
+	if err := clicommon.ValidateNotAsHost("secrets encrypt", opts); err != nil {
+		return err
+	}
+
+	key, err := clicommon.SecretsKey(ctx, "secrets encrypt", opts)
+	if err != nil {
+		return err
+	}
+
+	return execSecretsFilesCmd(ctx, "secrets encrypt", files, opts, func(path string) (bool, error) {
+		return secrets.EncryptFile(path, key)
+	})
+}
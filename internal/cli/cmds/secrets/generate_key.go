@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
+)
+
+func GenerateKeyCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "generate-key [key-file]",
+		Short: "Generates a new secrets key",
+		Long:  `Generates a new random secrets key and writes it to the specified key file. An existing key file is never overwritten.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Expected exactly one key file argument to be specified, but found %d instead", len(args))
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execSecretsGenerateKeyCmd(clicontext.HomelabContext(ctx), args[0], opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+}
+
+func execSecretsGenerateKeyCmd(ctx context.Context, path string, opts *clicommon.GlobalCmdOptions) error {
+	if err := clicommon.ValidateNotAsHost("secrets generate-key", opts); err != nil {
+		return err
+	}
+
+	if _, err := secrets.WriteNewKeyFile(path); err != nil {
+		return fmt.Errorf("secrets generate-key failed, reason: %w", err)
+	}
+	log(ctx).Infof("Generated secrets key file %s", path)
+	return nil
+}
@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicommon"
+	"github.com/tuxdudehomelab/homelab/internal/cli/clicontext"
+	"github.com/tuxdudehomelab/homelab/internal/cli/errors"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
+)
+
+const (
+	newKeyFileFlagStr = "new-key-file"
+)
+
+type rotateKeyCmdOptions struct {
+	newKeyFile string
+}
+
+func RotateKeyCmd(ctx context.Context, opts *clicommon.GlobalCmdOptions) *cobra.Command {
+	rotateOpts := rotateKeyCmdOptions{}
+	cmd := &cobra.Command{
+		Use:   "rotate-key [config-file]...",
+		Short: "Rotates the secrets key used in the homelab configs",
+		Long:  `Re-encrypts all the encrypted values in the specified config files, or all the config files under the homelab configs path if none are specified, using the new secrets key. The homelab CLI config must be updated to point to the new key file afterwards.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := execSecretsRotateKeyCmd(clicontext.HomelabContext(ctx), args, &rotateOpts, opts)
+			if err != nil {
+				return errors.NewHomelabRuntimeError(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(
+		&rotateOpts.newKeyFile, newKeyFileFlagStr, "", "The path to the new secrets key file")
+	if cmd.MarkFlagFilename(newKeyFileFlagStr) != nil {
+		log(ctx).Fatalf("failed to mark --%s flag as filename flag", newKeyFileFlagStr)
+	}
+	if cmd.MarkFlagRequired(newKeyFileFlagStr) != nil {
+		log(ctx).Fatalf("failed to mark --%s flag as required", newKeyFileFlagStr)
+	}
+	return cmd
+}
+
+func execSecretsRotateKeyCmd(ctx context.Context, files []string, rotateOpts *rotateKeyCmdOptions, opts *clicommon.GlobalCmdOptions) error {
+	if err := clicommon.ValidateNotAsHost("secrets rotate-key", opts); err != nil {
+		return err
+	}
+
+	oldKey, err := clicommon.SecretsKey(ctx, "secrets rotate-key", opts)
+	if err != nil {
+		return err
+	}
+	newKey, err := secrets.ReadKeyFile(rotateOpts.newKeyFile)
+	if err != nil {
+		return fmt.Errorf("secrets rotate-key failed while reading the new secrets key, reason: %w", err)
+	}
+
+	err = execSecretsFilesCmd(ctx, "secrets rotate-key", files, opts, func(path string) (bool, error) {
+		return secrets.RotateFile(path, oldKey, newKey)
+	})
+	if err != nil {
+		return err
+	}
+	log(ctx).Infof("Update homelab.secretsKeyFile in the homelab CLI config to %s", rotateOpts.newKeyFile)
+	return nil
+}
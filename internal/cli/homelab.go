@@ -55,6 +55,14 @@ The configuration is managed using a yaml file. The configuration specifies the
 			ID:    clicommon.NetworksCmdGroupID,
 			Title: "Networks:",
 		},
+		&cobra.Group{
+			ID:    clicommon.ExportCmdGroupID,
+			Title: "Export:",
+		},
+		&cobra.Group{
+			ID:    clicommon.DaemonCmdGroupID,
+			Title: "Daemon:",
+		},
 	)
 	cmd.CompletionOptions.DisableDescriptions = true
 
@@ -65,9 +73,14 @@ func initHomelabCmd(ctx context.Context) *cobra.Command {
 	globalOpts := clicommon.GlobalCmdOptions{}
 	homelabCmd := buildHomelabCmd(ctx, &globalOpts)
 	homelabCmd.AddCommand(cmds.ConfigCmd(ctx, &globalOpts))
+	homelabCmd.AddCommand(cmds.SecretsCmd(ctx, &globalOpts))
 	homelabCmd.AddCommand(cmds.GroupsCmd(ctx, &globalOpts))
 	homelabCmd.AddCommand(cmds.ContainersCmd(ctx, &globalOpts))
+	homelabCmd.AddCommand(cmds.JobsCmd(ctx, &globalOpts))
+	homelabCmd.AddCommand(cmds.ImagesCmd(ctx, &globalOpts))
 	homelabCmd.AddCommand(cmds.NetworksCmd(ctx, &globalOpts))
+	homelabCmd.AddCommand(cmds.ExportCmd(ctx, &globalOpts))
+	homelabCmd.AddCommand(cmds.DaemonCmd(ctx, &globalOpts))
 	return homelabCmd
 }
 
@@ -3,11 +3,15 @@ package cli
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	dcontainer "github.com/docker/docker/api/types/container"
 	"github.com/tuxdude/zzzlog"
 	"github.com/tuxdudehomelab/homelab/internal/cli/version"
 	"github.com/tuxdudehomelab/homelab/internal/cmdexec/fakecmdexec"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
 	"github.com/tuxdudehomelab/homelab/internal/docker"
 	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
 	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
@@ -118,6 +122,303 @@ containers:
       image: abc/xyz3
     lifecycle:
       order: 1`,
+	},
+	{
+		name: "Homelab Command - Config Import Compose",
+		args: []string{
+			"config",
+			"import-compose",
+			fmt.Sprintf("%s/testdata/import-compose-cmd/my-stack/compose.yml", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Imported config:
+ipam:
+  networks:
+    bridgeModeNetworks:
+      - name: my-stack
+        hostInterfaceName: docker-my-stack
+        cidr: 172\.30\.0\.0/24
+        priority: 1
+        containers:
+          - ip: 172\.30\.0\.2
+            container:
+              group: my-stack
+              container: app
+          - ip: 172\.30\.0\.3
+            container:
+              group: my-stack
+              container: db
+groups:
+  - name: my-stack
+    order: 1
+containers:
+  - info:
+      group: my-stack
+      container: app
+    image:
+      image: foo/app:1\.0
+    lifecycle:
+      order: 2
+    network:
+      publishedPorts:
+        - containerPort: "80"
+          proto: tcp
+          hostIp: 0\.0\.0\.0
+          hostPort: "8080"
+  - info:
+      group: my-stack
+      container: db
+    image:
+      image: foo/db:2\.0
+    lifecycle:
+      order: 1
+    fs:
+      mounts:
+        - name: var-lib-db
+          type: bind
+          src: \$\$CONTAINER_DATA_DIR\$\$/db-data
+          dst: /var/lib/db
+Unsupported compose settings that were not imported:
+  - services\.app\.container_name: not supported, the container name is derived from the group and the container`,
+	},
+	{
+		name: "Homelab Command - Config Import Container",
+		args: []string{
+			"config",
+			"import-container",
+			"g1-c1",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				Containers: []*fakedocker.FakeContainerInitInfo{
+					{
+						Name:  "g1-c1",
+						Image: "abc/xyz",
+						State: docker.ContainerStateRunning,
+						Config: &dcontainer.Config{
+							Env: []string{
+								"PATH=/usr/bin:/bin",
+								"FOO=bar",
+							},
+							WorkingDir: "/app",
+						},
+						HostConfig: &dcontainer.HostConfig{
+							Binds: []string{
+								"/data/g1/c1:/data",
+							},
+						},
+					},
+				},
+				ImageConfigs: map[string]*dcontainer.Config{
+					"abc/xyz": {
+						Env: []string{
+							"PATH=/usr/bin:/bin",
+						},
+					},
+				},
+			}),
+		},
+		want: `Imported config:
+groups:
+  - name: g1
+    order: 1
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: abc/xyz
+    lifecycle:
+      order: 1
+    fs:
+      mounts:
+        - name: data
+          type: bind
+          src: /data/g1/c1
+          dst: /data
+    runtime:
+      env:
+        - var: FOO
+          value: bar
+Unsupported container settings that were not imported:
+  - working dir: /app`,
+	},
+	{
+		name: "Homelab Command - Export - Docker Run",
+		args: []string{
+			"export",
+			"--format",
+			"docker-run",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `#!/bin/sh
+# Homelab deployment for host fakehost\.
+set -eu
+# Network net1\.
+docker network inspect net1 >/dev/null 2>&1 \|\| docker network create \\
+  --driver bridge \\
+  --subnet 172\.18\.100\.0/24 \\
+  --gateway 172\.18\.100\.1 \\
+  --opt com\.docker\.network\.bridge\.enable_icc=true \\
+  --opt com\.docker\.network\.bridge\.enable_ip_masquerade=true \\
+  --opt com\.docker\.network\.bridge\.host_binding_ipv4=172\.18\.100\.1 \\
+  --opt com\.docker\.network\.bridge\.mtu=1500 \\
+  --opt com\.docker\.network\.bridge\.name=docker-net1 \\
+  net1
+# Network net2\.
+docker network inspect net2 >/dev/null 2>&1 \|\| docker network create \\
+  --driver bridge \\
+  --subnet 172\.18\.101\.0/24 \\
+  --gateway 172\.18\.101\.1 \\
+  --opt com\.docker\.network\.bridge\.enable_icc=true \\
+  --opt com\.docker\.network\.bridge\.enable_ip_masquerade=true \\
+  --opt com\.docker\.network\.bridge\.host_binding_ipv4=172\.18\.101\.1 \\
+  --opt com\.docker\.network\.bridge\.mtu=1500 \\
+  --opt com\.docker\.network\.bridge\.name=docker-net2 \\
+  net2
+# Container g1-c1\.
+docker container rm --force g1-c1 >/dev/null 2>&1 \|\| true
+docker container create \\
+  --name g1-c1 \\
+  --network net1 \\
+  --ip 172\.18\.100\.11 \\
+  abc/xyz
+docker container start g1-c1
+# Container g2-c3\.
+docker container rm --force g2-c3 >/dev/null 2>&1 \|\| true
+docker container create \\
+  --name g2-c3 \\
+  --network net2 \\
+  --ip 172\.18\.101\.21 \\
+  abc/xyz3
+docker container start g2-c3`,
+	},
+	{
+		name: "Homelab Command - Export - Systemd On Target Host",
+		args: []string{
+			"export",
+			"systemd",
+			"--homelab-bin",
+			"/usr/local/bin/homelab",
+			"--target-host",
+			"host1",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/target-host-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+			RemoteDockerHosts: map[string]docker.APIClient{
+				"tcp://10.0.0.1:2375": fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+					HostName: "host1",
+				}),
+			},
+		},
+		want: `# homelab-g1-c1\.service
+# Homelab container g1-c1 for host host1, generated by homelab\.
+\[Unit\]
+Description=Homelab container g1-c1
+Requires=docker\.service
+After=docker\.service network-online\.target
+Wants=network-online\.target
+\[Service\]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab --configs-dir .+/testdata/target-host-cmds containers start g1/c1
+ExecStop=/usr/local/bin/homelab --configs-dir .+/testdata/target-host-cmds containers stop g1/c1
+\[Install\]
+WantedBy=multi-user\.target`,
+	},
+	{
+		name: "Homelab Command - Export - Compose As Another Host",
+		args: []string{
+			"export",
+			"--as-host",
+			"host2",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `# Homelab deployment for host host2\.
+name: homelab-host2
+services: \{\}`,
+	},
+	{
+		name: "Homelab Command - Export - Systemd",
+		args: []string{
+			"export",
+			"systemd",
+			"--homelab-bin",
+			"/usr/local/bin/homelab",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `# homelab-g1-c1\.service
+# Homelab container g1-c1 for host fakehost, generated by homelab\.
+\[Unit\]
+Description=Homelab container g1-c1
+Requires=docker\.service
+After=docker\.service network-online\.target
+Wants=network-online\.target
+\[Service\]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab --configs-dir .+/testdata/containers-and-groups-cmds containers start g1/c1
+ExecStop=/usr/local/bin/homelab --configs-dir .+/testdata/containers-and-groups-cmds containers stop g1/c1
+\[Install\]
+WantedBy=multi-user\.target
+# homelab-g2-c3\.service
+# Homelab container g2-c3 for host fakehost, generated by homelab\.
+\[Unit\]
+Description=Homelab container g2-c3
+Requires=docker\.service
+After=docker\.service network-online\.target
+Wants=network-online\.target
+After=homelab-g1-c1\.service
+\[Service\]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab --configs-dir .+/testdata/containers-and-groups-cmds containers start g2/c3
+ExecStop=/usr/local/bin/homelab --configs-dir .+/testdata/containers-and-groups-cmds containers stop g2/c3
+\[Install\]
+WantedBy=multi-user\.target`,
+	},
+	{
+		name: "Homelab Command - Daemon - Once",
+		args: []string{
+			"daemon",
+			"--once",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				ValidImagesForPull: utils.StringSet{
+					"abc/xyz":  {},
+					"abc/xyz3": {},
+				},
+			}),
+		},
+		want: `Created network net1
+Created network net2
+Starting container g1-c1 since it is missing
+Pulling image: abc/xyz
+Creating container g1-c1
+Starting container g1-c1
+Starting container g2-c3 since it is missing
+Pulling image: abc/xyz3
+Creating container g2-c3
+Starting container g2-c3`,
 	},
 	{
 		name: "Homelab Command - Show Config - Custom CLI Config Path",
@@ -162,6 +463,169 @@ containers:
     lifecycle:
       order: 10`,
 	},
+	{
+		name: "Homelab Command - Show Config - With Secrets",
+		args: []string{
+			"config",
+			"show",
+			"--cli-config",
+			fmt.Sprintf("%s/testdata/cli-configs/show-config-cmd-with-secrets/config.yaml", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Homelab config:
+(?s).+
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: <masked>
+    lifecycle:
+      order: 10
+    runtime:
+      env:
+        - var: MY_PASSWORD
+          value: <masked>`,
+	},
+	{
+		name: "Homelab Command - Show Config - With Secrets Revealed",
+		args: []string{
+			"config",
+			"show",
+			"--reveal",
+			"--cli-config",
+			fmt.Sprintf("%s/testdata/cli-configs/show-config-cmd-with-secrets/config.yaml", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Homelab config:
+(?s).+
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: abc/xyz
+    lifecycle:
+      order: 10
+    runtime:
+      env:
+        - var: MY_PASSWORD
+          value: my-secret-password`,
+	},
+	{
+		name: "Homelab Command - Show Config - With Container Templates",
+		args: []string{
+			"config",
+			"show",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-with-templates", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Homelab config:
+(?s).+
+containerTemplates:
+  - name: base
+    metadata:
+      labels:
+        - name: managed-by
+          value: homelab
+        - name: tier
+          value: base
+    lifecycle:
+      order: 10
+containers:
+  - info:
+      group: g1
+      container: c1
+    extends:
+      - base
+    image:
+      image: abc/xyz
+    metadata:
+      labels:
+        - name: tier
+          value: frontend`,
+	},
+	{
+		name: "Homelab Command - Show Config - Resolved Container Templates",
+		args: []string{
+			"config",
+			"show",
+			"--resolved",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-with-templates", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Homelab config:
+global:
+  baseDir: testdata/dummy-base-dir
+hosts:
+  - name: fakehost
+    allowedContainers:
+      - group: g1
+        container: c1
+groups:
+  - name: g1
+    order: 1
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: abc/xyz
+    metadata:
+      labels:
+        - name: managed-by
+          value: homelab
+        - name: tier
+          value: frontend
+    lifecycle:
+      order: 10`,
+	},
+	{
+		name: "Homelab Command - Show Config - Resolved As Another Host",
+		args: []string{
+			"config",
+			"show",
+			"--resolved",
+			"--as-host",
+			"Pi2",
+			"--as-arch",
+			"arm64",
+			"--as-host-ip",
+			"10.11.12.13",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-as-host", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `(?s)Homelab config:
+.+
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: abc/xyz
+    lifecycle:
+      order: 1
+    network:
+      hostName: c1-pi-Pi2
+      publishedPorts:
+        - containerPort: "80"
+          proto: tcp
+          hostIp: 10\.11\.12\.13
+          hostPort: "8080"`,
+	},
 	{
 		name: "Homelab Command - Groups Start - All Groups With Real Host Info",
 		args: []string{
@@ -1120,149 +1584,720 @@ var executeHomelabCmdErrorTests = []struct {
 		want: `homelab sub-command is required`,
 	},
 	{
-		name: "Homelab Config Command - Missing Subcommand",
+		name: "Homelab Command - Config Import Compose - Compose File Not Found",
 		args: []string{
 			"config",
+			"import-compose",
+			"/foo/bar/compose.yml",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `homelab config sub-command is required`,
+		want: `config import-compose failed while opening the compose file, reason: open /foo/bar/compose\.yml: no such file or directory`,
 	},
 	{
-		name: "Homelab Groups Command - Missing Subcommand",
+		name: "Homelab Command - Config Import Compose - Invalid Compose File",
 		args: []string{
-			"groups",
+			"config",
+			"import-compose",
+			fmt.Sprintf("%s/testdata/import-compose-cmd/invalid/compose.yml", testhelpers.Pwd()),
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `homelab group sub-command is required`,
+		want: `config import-compose failed while importing .+/compose\.yml, reason: failed to import compose file, reason: service app depends on the service db which is not defined`,
 	},
 	{
-		name: "Homelab Containers Command - Missing Subcommand",
+		name: "Homelab Command - Config Import Container - Non Homelab Container Name",
 		args: []string{
-			"containers",
+			"config",
+			"import-container",
+			"mycontainer",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `homelab container sub-command is required`,
+		want: `config import-container requires the --group and --container flags since the group and the container cannot be determined from the docker container name mycontainer`,
 	},
 	{
-		name: "Homelab Networks Command - Missing Subcommand",
+		name: "Homelab Command - Config Import Container - Target Host",
 		args: []string{
-			"networks",
+			"config",
+			"import-container",
+			"g1-c1",
+			"--target-host",
+			"FakeHost",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `homelab networks sub-command is required`,
+		want: `config import-container cannot be run with --target-host since it imports the container from the local docker daemon`,
 	},
 	{
-		name: "Homelab Command - Groups Start - Zero Group Name Args",
+		name: "Homelab Command - Config Import Container - Container Not Found",
 		args: []string{
-			"groups",
-			"start",
+			"config",
+			"import-container",
+			"mycontainer",
+			"--group",
+			"g1",
+			"--container",
+			"c1",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one group name argument to be specified, but found 0 instead`,
+		want: `config import-container failed while importing the container mycontainer, reason: failed to inspect the container mycontainer, reason: container mycontainer not found on the fake docker host`,
 	},
 	{
-		name: "Homelab Command - Groups Stop - Zero Group Name Args",
+		name: "Homelab Command - Export - Invalid Format",
 		args: []string{
-			"groups",
-			"stop",
+			"export",
+			"--format",
+			"yaml",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one group name argument to be specified, but found 0 instead`,
+		want: `export failed while validating the --format flag, reason: unsupported export format yaml, must be one of: compose, docker-run, json`,
 	},
 	{
-		name: "Homelab Command - Groups Purge - Zero Group Name Args",
+		name: "Homelab Command - Export Systemd - Invalid Style",
 		args: []string{
-			"groups",
-			"purge",
+			"export",
+			"systemd",
+			"--style",
+			"upstart",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one group name argument to be specified, but found 0 instead`,
+		want: `export systemd failed while validating the --style flag, reason: unsupported systemd unit style upstart, must be one of: service, quadlet`,
 	},
 	{
-		name: "Homelab Command - Groups Start - Multiple Group Name Args",
+		name: "Homelab Command - Export Systemd - Target Host Without Homelab Bin",
 		args: []string{
-			"groups",
-			"start",
-			"g1",
-			"g2",
+			"export",
+			"systemd",
+			"--target-host",
+			"host2",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one group name argument to be specified, but found 2 instead`,
+		want: `export systemd cannot be run with --target-host since the service units would invoke the local homelab binary, specify the homelab binary on the target host using --homelab-bin`,
 	},
 	{
-		name: "Homelab Command - Groups Stop - Multiple Group Name Args",
+		name: "Homelab Command - Daemon - Negative Settle Delay",
 		args: []string{
-			"groups",
+			"daemon",
+			"--settle-delay",
+			"-1s",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `daemon failed while validating the --settle-delay flag, reason: the delay cannot be negative`,
+	},
+	{
+		name: "Homelab Config Command - Missing Subcommand",
+		args: []string{
+			"config",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `homelab config sub-command is required`,
+	},
+	{
+		name: "Homelab Secrets Command - Missing Subcommand",
+		args: []string{
+			"secrets",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `homelab secrets sub-command is required`,
+	},
+	{
+		name: "Homelab Command - Show Config - Encrypted Values Without Secrets Key",
+		args: []string{
+			"config",
+			"show",
+			"--reveal",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-with-secrets", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `config show failed while parsing the configs, reason: failed to decrypt homelab config, reason: homelab config contains encrypted values, reason: no secrets key file has been configured in the homelab CLI config`,
+	},
+	{
+		name: "Homelab Command - Show Config - Encrypted Values With Wrong Secrets Key",
+		args: []string{
+			"config",
+			"show",
+			"--reveal",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-with-secrets", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost:     fakedocker.NewEmptyFakeDockerHost(),
+			SecretsKeyFile: "testdata/secrets/other-key",
+		},
+		want: `config show failed while parsing the configs, reason: failed to decrypt homelab config, reason: failed to decrypt the value at line \d+, reason: failed to decrypt value, possibly due to the wrong secrets key, reason: cipher: message authentication failed`,
+	},
+	{
+		name: "Homelab Command - Show Config - Plaintext Secrets",
+		args: []string{
+			"config",
+			"show",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-with-plaintext-secrets", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `config show failed while parsing the configs, reason: failed to normalize secrets in config file .+/g1/c1\.yaml, reason: the value at line 12 is tagged with !secret but is not encrypted, encrypt it using the secrets encrypt command first`,
+	},
+	{
+		name: "Homelab Secrets Command - Encrypt - Without Secrets Key",
+		args: []string{
+			"secrets",
+			"encrypt",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-with-secrets", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `secrets encrypt failed while reading the secrets key, reason: no secrets key file has been configured in the homelab CLI config`,
+	},
+	{
+		name: "Homelab Secrets Command - Rotate Key - Missing New Key File",
+		args: []string{
+			"secrets",
+			"rotate-key",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `required flag\(s\) "new-key-file" not set`,
+	},
+	{
+		name: "Homelab Secrets Command - Generate Key - Missing Key File",
+		args: []string{
+			"secrets",
+			"generate-key",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one key file argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Secrets Command - Generate Key - Existing Key File",
+		args: []string{
+			"secrets",
+			"generate-key",
+			"testdata/secrets/key",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `secrets generate-key failed, reason: secrets key file testdata/secrets/key already exists`,
+	},
+	{
+		name: "Homelab Command - Show Config - As Arch Without As Host",
+		args: []string{
+			"config",
+			"show",
+			"--as-arch",
+			"arm64",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `config show failed since --as-arch requires --as-host to be specified`,
+	},
+	{
+		name: "Homelab Command - Show Config - As Host With Unsupported Arch",
+		args: []string{
+			"config",
+			"show",
+			"--as-host",
+			"pi2",
+			"--as-arch",
+			"mips",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `config show failed since --as-arch mips is not a supported arch`,
+	},
+	{
+		name: "Homelab Command - Show Config - As Host With Invalid IP",
+		args: []string{
+			"config",
+			"show",
+			"--as-host",
+			"pi2",
+			"--as-host-ip",
+			"foo",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `config show failed while parsing --as-host-ip, reason: ParseAddr\("foo"\): unable to parse IP`,
+	},
+	{
+		name: "Homelab Command - Groups Start - As Host",
+		args: []string{
+			"groups",
+			"start",
+			"all",
+			"--as-host",
+			"pi2",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `groups start cannot be run with --as-host, --as-arch or --as-host-ip since it is not a read-only command`,
+	},
+	{
+		name: "Homelab Secrets Command - Encrypt - As Host",
+		args: []string{
+			"secrets",
+			"encrypt",
+			"--as-host",
+			"pi2",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `secrets encrypt cannot be run with --as-host, --as-arch or --as-host-ip since it is not a read-only command`,
+	},
+	{
+		name: "Homelab Command - Groups Start - Undefined Target Host",
+		args: []string{
+			"groups",
+			"start",
+			"all",
+			"--target-host",
+			"nas",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-as-host", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `groups start failed since target host nas is not defined in the hosts config`,
+	},
+	{
+		name: "Homelab Command - Groups Start - Target Host Without Docker Endpoint",
+		args: []string{
+			"groups",
+			"start",
+			"all",
+			"--target-host",
+			"FakeHost",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-as-host", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `groups start failed since target host FakeHost does not specify a docker endpoint in the hosts config`,
+	},
+	{
+		name: "Homelab Command - Containers Backup - Target Host",
+		args: []string{
+			"containers",
+			"backup",
+			"g1/c1",
+			"--target-host",
+			"FakeHost",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-as-host", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `containers backup cannot be run with --target-host since it operates on the files of the local host`,
+	},
+	{
+		name: "Homelab Command - Containers Restore - Target Host",
+		args: []string{
+			"containers",
+			"restore",
+			"g1/c1",
+			"--target-host",
+			"FakeHost",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/show-config-cmd-as-host", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `containers restore cannot be run with --target-host since it operates on the files of the local host`,
+	},
+	{
+		name: "Homelab Command - Show Config - As Host And Target Host",
+		args: []string{
+			"config",
+			"show",
+			"--as-host",
+			"pi2",
+			"--target-host",
+			"pi2",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `if any flags in the group \[as-host target-host\] are set none of the others can be; \[as-host target-host\] were all set`,
+	},
+	{
+		name: "Homelab Groups Command - Missing Subcommand",
+		args: []string{
+			"groups",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `homelab group sub-command is required`,
+	},
+	{
+		name: "Homelab Containers Command - Missing Subcommand",
+		args: []string{
+			"containers",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `homelab container sub-command is required`,
+	},
+	{
+		name: "Homelab Networks Command - Missing Subcommand",
+		args: []string{
+			"networks",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `homelab networks sub-command is required`,
+	},
+	{
+		name: "Homelab Command - Groups Start - Zero Group Name Args",
+		args: []string{
+			"groups",
+			"start",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one group name argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Command - Groups Stop - Zero Group Name Args",
+		args: []string{
+			"groups",
 			"stop",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one group name argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Command - Groups Purge - Zero Group Name Args",
+		args: []string{
+			"groups",
+			"purge",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one group name argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Command - Groups Start - Multiple Group Name Args",
+		args: []string{
+			"groups",
+			"start",
 			"g1",
 			"g2",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one group name argument to be specified, but found 2 instead`,
+		want: `Expected exactly one group name argument to be specified, but found 2 instead`,
+	},
+	{
+		name: "Homelab Command - Groups Stop - Multiple Group Name Args",
+		args: []string{
+			"groups",
+			"stop",
+			"g1",
+			"g2",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one group name argument to be specified, but found 2 instead`,
+	},
+	{
+		name: "Homelab Command - Groups Purge - Multiple Group Name Args",
+		args: []string{
+			"groups",
+			"purge",
+			"g1",
+			"g2",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one group name argument to be specified, but found 2 instead`,
+	},
+	{
+		name: "Homelab Command - Containers Start - Zero Container Name Args",
+		args: []string{
+			"containers",
+			"start",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one container name argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Command - Containers Stop - Zero Container Name Args",
+		args: []string{
+			"containers",
+			"stop",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one container name argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Command - Containers Purge - Zero Container Name Args",
+		args: []string{
+			"containers",
+			"purge",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one container name argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Command - Containers Heal - Multiple Container Name Args",
+		args: []string{
+			"containers",
+			"heal",
+			"g1/c1",
+			"g2/c3",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected at most one container name argument to be specified, but found 2 instead`,
+	},
+	{
+		name: "Homelab Command - Containers Heal - Invalid Container Name",
+		args: []string{
+			"containers",
+			"heal",
+			"foobar",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Container name must be specified in the form 'group/container'`,
+	},
+	{
+		name: "Homelab Command - Containers Heal - Invalid State File",
+		args: []string{
+			"containers",
+			"heal",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-heal-cmd", testhelpers.Pwd()),
+			"--state-file",
+			fmt.Sprintf("%s/testdata/containers-heal-cmd/g1/c1.yaml", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `containers heal failed while reading the heal state, reason: failed to parse the heal state file .+/testdata/containers-heal-cmd/g1/c1\.yaml, reason: invalid character 'c' looking for beginning of value`,
+	},
+	{
+		name: "Homelab Command - Containers Backup - Zero Container Name Args",
+		args: []string{
+			"containers",
+			"backup",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected exactly one container name argument to be specified, but found 0 instead`,
 	},
 	{
-		name: "Homelab Command - Groups Purge - Multiple Group Name Args",
+		name: "Homelab Command - Containers Backup - Invalid Container Name",
 		args: []string{
-			"groups",
-			"purge",
-			"g1",
-			"g2",
+			"containers",
+			"backup",
+			"foobar",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one group name argument to be specified, but found 2 instead`,
+		want: `Container name must be specified in the form 'group/container'`,
 	},
 	{
-		name: "Homelab Command - Containers Start - Zero Container Name Args",
+		name: "Homelab Command - Containers Restore - Multiple Container Name Args",
 		args: []string{
 			"containers",
-			"start",
+			"restore",
+			"g1/c1",
+			"g2/c3",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one container name argument to be specified, but found 0 instead`,
+		want: `Expected exactly one container name argument to be specified, but found 2 instead`,
 	},
 	{
-		name: "Homelab Command - Containers Stop - Zero Container Name Args",
+		name: "Homelab Command - Containers Restore - Missing Archive",
 		args: []string{
 			"containers",
-			"stop",
+			"restore",
+			"g1/c1",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+			"--archive",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds/missing.tar.gz", testhelpers.Pwd()),
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one container name argument to be specified, but found 0 instead`,
+		want: `containers restore failed for 1 containers, reason\(s\):
+1 - Failed to restore container g1-c1, reason:failed to read the checksum of the backup archive .+/testdata/containers-and-groups-cmds/missing\.tar\.gz, reason: .+`,
 	},
 	{
-		name: "Homelab Command - Containers Purge - Zero Container Name Args",
+		name: "Homelab Command - Jobs Run - Zero Job Name Args",
 		args: []string{
-			"containers",
-			"purge",
+			"jobs",
+			"run",
 		},
 		ctxInfo: &testutils.TestContextInfo{
 			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
 		},
-		want: `Expected exactly one container name argument to be specified, but found 0 instead`,
+		want: `Expected exactly one job name argument to be specified, but found 0 instead`,
+	},
+	{
+		name: "Homelab Command - Jobs Run - Invalid Job Name",
+		args: []string{
+			"jobs",
+			"run",
+			"foobar",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Job name must be specified in the form 'group/container'`,
+	},
+	{
+		name: "Homelab Command - Jobs Run - Job Not Found",
+		args: []string{
+			"jobs",
+			"run",
+			"g1/c1",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/jobs-cmd", testhelpers.Pwd()),
+			"--state-file",
+			fmt.Sprintf("%s/testdata/jobs-cmd/missing/jobs.json", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `jobs run failed while querying the job, reason: job g1/c1 not found`,
+	},
+	{
+		name: "Homelab Command - Images Update - Invalid Container Name",
+		args: []string{
+			"images",
+			"update",
+			"g1",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Container name must be specified in the form 'group/container'`,
+	},
+	{
+		name: "Homelab Command - Images Update - Too Many Containers",
+		args: []string{
+			"images",
+			"update",
+			"g1/c1",
+			"g1/c2",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `Expected at most one container name argument to be specified, but found 2 instead`,
+	},
+	{
+		name: "Homelab Command - Images Update - Container Not Found",
+		args: []string{
+			"images",
+			"update",
+			"g1/c9",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `images update failed while updating the image lock, reason: container \{g1 c9\} not found`,
+	},
+	{
+		name: "Homelab Command - Images Lock - Image Not In Registry",
+		args: []string{
+			"images",
+			"lock",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/containers-and-groups-cmds", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `images lock failed while locking the images, reason: failed to resolve the digest of the image .+, reason: image .+ not found in the registry on the fake docker host`,
+	},
+	{
+		name: "Homelab Command - Jobs Due - Invalid State File",
+		args: []string{
+			"jobs",
+			"due",
+			"--configs-dir",
+			fmt.Sprintf("%s/testdata/jobs-cmd", testhelpers.Pwd()),
+			"--state-file",
+			fmt.Sprintf("%s/testdata/jobs-cmd/g1/jobs.yaml", testhelpers.Pwd()),
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `jobs due failed while reading the jobs state, reason: failed to parse the jobs state file .+/testdata/jobs-cmd/g1/jobs\.yaml, reason: invalid character 'j' looking for beginning of value`,
 	},
 	{
 		name: "Homelab Command - Containers Start - Multiple Container Name Args",
@@ -1883,6 +2918,552 @@ func TestExecHomelabConfigCmdErrors(t *testing.T) {
 	}
 }
 
+func TestExecHomelabSecretsCmd(t *testing.T) {
+	t.Parallel()
+
+	tc := "Homelab Secrets Command - Encrypt And Decrypt"
+	config := `containers:
+  - info:
+      group: g1
+      container: c1
+    runtime:
+      env:
+        # The database password.
+        - var: DB_PASSWORD
+          value: !secret my-db-password
+`
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "c1.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		testhelpers.LogErrorNotNil(t, "os.WriteFile()", tc, err)
+		return
+	}
+
+	ctxInfo := func() *testutils.TestContextInfo {
+		return &testutils.TestContextInfo{
+			DockerHost:     fakedocker.NewEmptyFakeDockerHost(),
+			SecretsKeyFile: "testdata/secrets/key",
+		}
+	}
+
+	out, gotErr := execHomelabCmdTest(ctxInfo(), nil, "secrets", "encrypt", "--configs-dir", dir)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+		return
+	}
+	if !testhelpers.RegexMatchJoinNewLines(t, "Exec()", tc, "command output", `Updated .+/c1\.yaml`, out.String()) {
+		return
+	}
+
+	encrypted, err := os.ReadFile(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "os.ReadFile()", tc, err)
+		return
+	}
+	wantEncrypted := `(?s).+# The database password\.
+\s+- var: DB_PASSWORD
+\s+value: !encrypted \S+
+`
+	if !testhelpers.RegexMatch(t, "Exec()", tc, "encrypted config", wantEncrypted, string(encrypted)) {
+		return
+	}
+
+	out, gotErr = execHomelabCmdTest(ctxInfo(), nil, "secrets", "decrypt", path)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+		return
+	}
+
+	decrypted, err := os.ReadFile(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "os.ReadFile()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "Exec()", tc, "decrypted config", config, string(decrypted)) {
+		return
+	}
+}
+
+func TestExecHomelabContainersHealCmd(t *testing.T) {
+	t.Parallel()
+
+	tc := "Homelab Containers Heal Command - Restart Until Crash Looping"
+	fakeDocker := fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+		Containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:   "g1-c1",
+				Image:  "abc/xyz",
+				State:  docker.ContainerStateRunning,
+				Health: "unhealthy",
+			},
+			{
+				Name:   "g2-c3",
+				Image:  "abc/xyz3",
+				State:  docker.ContainerStateRunning,
+				Health: "unhealthy",
+			},
+		},
+	})
+	stateFile := filepath.Join(t.TempDir(), "heal.json")
+	args := []string{
+		"containers",
+		"heal",
+		"--configs-dir",
+		fmt.Sprintf("%s/testdata/containers-heal-cmd", testhelpers.Pwd()),
+		"--state-file",
+		stateFile,
+	}
+
+	for _, step := range []struct {
+		desc string
+		want string
+	}{
+		{
+			desc: "first heal",
+			want: `Restarting container g1-c1 since it is unhealthy, heal attempt 1 of 1`,
+		},
+		{
+			desc: "unhealthy again after the heal",
+			want: `Container g1-c1 is crash looping since it is still unhealthy after 1 heal attempt\(s\), no longer healing it`,
+		},
+		{
+			desc: "crash looping",
+			want: ``,
+		},
+	} {
+		if err := fakeDocker.SetContainerHealth("g1-c1", "unhealthy"); err != nil {
+			testhelpers.LogErrorNotNil(t, "SetContainerHealth()", tc, err)
+			return
+		}
+		out, gotErr := execHomelabCmdTest(&testutils.TestContextInfo{DockerHost: fakeDocker}, nil, args...)
+		if gotErr != nil {
+			testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+			return
+		}
+		if !testhelpers.RegexMatchJoinNewLines(t, "Exec()", tc, fmt.Sprintf("command output on %s", step.desc), step.want, out.String()) {
+			return
+		}
+	}
+
+	if !testhelpers.CmpDiff(t, "Exec()", tc, "g1-c1 restart count", 1, fakeDocker.ContainerRestartCount("g1-c1")) {
+		return
+	}
+	if !testhelpers.CmpDiff(t, "Exec()", tc, "g2-c3 restart count", 0, fakeDocker.ContainerRestartCount("g2-c3")) {
+		return
+	}
+
+	state, err := os.ReadFile(stateFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "os.ReadFile()", tc, err)
+		return
+	}
+	wantState := `(?s)\{
+  "g1-c1": \{
+    "containerId": "[0-9a-f]+",
+    "attempts": 1,
+    "lastAttempt": "[^"]+",
+    "crashLooping": true
+  \}
+\}`
+	if !testhelpers.RegexMatch(t, "Exec()", tc, "heal state", wantState, string(state)) {
+		return
+	}
+}
+
+func TestExecHomelabContainersBackupRestoreCmds(t *testing.T) {
+	t.Parallel()
+
+	tc := "Homelab Containers Backup Restore Commands - Round Trip"
+	baseDir := t.TempDir()
+	backupsDir := filepath.Join(t.TempDir(), "backups")
+	configsDir := t.TempDir()
+	config := fmt.Sprintf(`global:
+  baseDir: %s
+groups:
+  - name: g1
+    order: 1
+hosts:
+  - name: fakehost
+    allowedContainers:
+      - group: g1
+        container: c1
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: abc/xyz
+    lifecycle:
+      order: 1
+    backup:
+      destination: %s
+      excludes:
+        - "*.log"
+`, baseDir, backupsDir)
+	if err := os.WriteFile(filepath.Join(configsDir, "config.yaml"), []byte(config), 0o600); err != nil {
+		testhelpers.LogErrorNotNil(t, "os.WriteFile()", tc, err)
+		return
+	}
+	dataFile := filepath.Join(baseDir, "g1", "c1", "data", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(dataFile), 0o750); err != nil {
+		testhelpers.LogErrorNotNil(t, "os.MkdirAll()", tc, err)
+		return
+	}
+	for name, content := range map[string]string{"settings.json": `{"theme": "dark"}`, "debug.log": "noise"} {
+		if err := os.WriteFile(filepath.Join(filepath.Dir(dataFile), name), []byte(content), 0o600); err != nil {
+			testhelpers.LogErrorNotNil(t, "os.WriteFile()", tc, err)
+			return
+		}
+	}
+
+	fakeDocker := fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+		Containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:  "g1-c1",
+				Image: "abc/xyz",
+				State: docker.ContainerStateRunning,
+			},
+		},
+		ValidImagesForPull: utils.StringSet{
+			"abc/xyz": {},
+		},
+	})
+	ctxInfo := &testutils.TestContextInfo{DockerHost: fakeDocker}
+
+	out, gotErr := execHomelabCmdTest(ctxInfo, nil, "containers", "backup", "g1/c1", "--configs-dir", configsDir)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+		return
+	}
+	want := `(?s)Backing up container g1-c1
+Stopping container g1-c1
+Writing backup archive .+/backups/g1-c1-\d{8}T\d{6}Z\.tar\.gz
+.*Starting container g1-c1
+Backed up container g1-c1 to .+/backups/g1-c1-\d{8}T\d{6}Z\.tar\.gz`
+	if !testhelpers.RegexMatchJoinNewLines(t, "Exec()", tc, "backup command output", want, out.String()) {
+		return
+	}
+
+	if err := os.WriteFile(dataFile, []byte(`{"theme": "light"}`), 0o600); err != nil {
+		testhelpers.LogErrorNotNil(t, "os.WriteFile()", tc, err)
+		return
+	}
+	out, gotErr = execHomelabCmdTest(ctxInfo, nil, "containers", "restore", "g1/c1", "--configs-dir", configsDir)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+		return
+	}
+	want = `(?s)Restoring container g1-c1 from .+/backups/g1-c1-\d{8}T\d{6}Z\.tar\.gz
+.*Stopping container g1-c1
+.*Starting container g1-c1
+Restored container g1-c1 from .+/backups/g1-c1-\d{8}T\d{6}Z\.tar\.gz`
+	if !testhelpers.RegexMatchJoinNewLines(t, "Exec()", tc, "restore command output", want, out.String()) {
+		return
+	}
+
+	restored, err := os.ReadFile(dataFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "os.ReadFile()", tc, err)
+		return
+	}
+	testhelpers.CmpDiff(t, "Exec()", tc, "restored data file", `{"theme": "dark"}`, string(restored))
+}
+
+func TestExecHomelabImagesCmds(t *testing.T) {
+	t.Parallel()
+
+	tc := "Homelab Images Commands - Lock And Update"
+	digest1 := "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	digest2 := "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	configsDir := t.TempDir()
+	config := fmt.Sprintf(`global:
+  baseDir: %s
+groups:
+  - name: g1
+    order: 1
+hosts:
+  - name: fakehost
+    allowedContainers:
+      - group: g1
+        container: c1
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: abc/xyz:1.0
+    lifecycle:
+      order: 1
+  - info:
+      group: g1
+      container: c2
+    image:
+      image: abc/local
+      skipImagePull: true
+    lifecycle:
+      order: 2
+`, t.TempDir())
+	if err := os.WriteFile(filepath.Join(configsDir, "config.yaml"), []byte(config), 0o600); err != nil {
+		testhelpers.LogErrorNotNil(t, "os.WriteFile()", tc, err)
+		return
+	}
+
+	fakeDocker := fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+		ValidImagesForPull: utils.StringSet{
+			fmt.Sprintf("abc/xyz@%s", digest1): {},
+			fmt.Sprintf("abc/xyz@%s", digest2): {},
+		},
+		ImageDigests: map[string]string{
+			"abc/xyz:1.0": digest1,
+		},
+	})
+	ctxInfo := &testutils.TestContextInfo{DockerHost: fakeDocker}
+	lockFile := filepath.Join(configsDir, "homelab.lock")
+
+	out, gotErr := execHomelabCmdTest(ctxInfo, nil, "images", "lock", "--configs-dir", configsDir)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+		return
+	}
+	want := fmt.Sprintf(`Locked image abc/xyz:1\.0 to %s`, digest1)
+	if !testhelpers.RegexMatchJoinNewLines(t, "Exec()", tc, "lock command output", want, out.String()) {
+		return
+	}
+	lock, err := deployment.ReadImageLock(lockFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "deployment.ReadImageLock()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "Exec()", tc, "locked images", map[string]string{"abc/xyz:1.0": digest1}, lock.Images) {
+		return
+	}
+
+	// The container is started using the locked digest.
+	out, gotErr = execHomelabCmdTest(ctxInfo, nil, "containers", "start", "g1/c1", "--configs-dir", configsDir)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "Exec()", tc, "container state", docker.ContainerStateRunning, fakeDocker.GetContainerState("g1-c1")) {
+		return
+	}
+
+	fakeDocker.SetImageDigest("abc/xyz:1.0", digest2)
+	out, gotErr = execHomelabCmdTest(ctxInfo, nil, "images", "update", "g1/c1", "--configs-dir", configsDir)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+		return
+	}
+	want = fmt.Sprintf(`Updated image abc/xyz:1\.0 from %s to %s`, digest1, digest2)
+	if !testhelpers.RegexMatchJoinNewLines(t, "Exec()", tc, "update command output", want, out.String()) {
+		return
+	}
+	lock, err = deployment.ReadImageLock(lockFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "deployment.ReadImageLock()", tc, err)
+		return
+	}
+	testhelpers.CmpDiff(t, "Exec()", tc, "updated images", map[string]string{"abc/xyz:1.0": digest2}, lock.Images)
+}
+
+func TestExecHomelabJobsCmds(t *testing.T) {
+	t.Parallel()
+
+	tc := "Homelab Jobs Commands - Due And Run"
+	fakeDocker := fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+		ValidImagesForPull: utils.StringSet{
+			"abc/backup":  {},
+			"abc/cleanup": {},
+			"abc/report":  {},
+		},
+		ExitOnStart: map[string]*fakedocker.FakeContainerExit{
+			"g1-backup": {
+				Logs: "backup complete\n",
+			},
+			"g1-cleanup": {
+				ExitCode: 3,
+				Logs:     "permission denied\n",
+			},
+			"g1-report": {
+				Logs: "report sent\n",
+			},
+		},
+	})
+	stateFile := filepath.Join(t.TempDir(), "jobs.json")
+	configsDir := fmt.Sprintf("%s/testdata/jobs-cmd", testhelpers.Pwd())
+
+	for _, step := range []struct {
+		desc    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{
+			desc: "first jobs due",
+			args: []string{"jobs", "due"},
+			want: `(?s)Running job g1-backup
+.*backup complete
+.*Job g1-backup succeeded in .+
+Running job g1-cleanup
+.*permission denied
+.*Failed to run job g1-cleanup, reason:exited with code 3
+
+`,
+			wantErr: `jobs due failed for 1 jobs, reason\(s\):
+1 - Failed to run job g1-cleanup, reason:exited with code 3`,
+		},
+		{
+			desc: "jobs due again",
+			args: []string{"jobs", "due"},
+			want: ``,
+		},
+		{
+			desc: "jobs run",
+			args: []string{"jobs", "run", "g1/report"},
+			want: `(?s)Running job g1-report
+.*report sent
+.*Job g1-report succeeded in .+
+
+`,
+		},
+		{
+			desc:    "jobs run not allowed on the host",
+			args:    []string{"jobs", "run", "g1/other"},
+			want:    `.*Failed to run job g1-other, reason:job is not allowed to run on the current host\n\n`,
+			wantErr: `jobs run failed, reason: Failed to run job g1-other, reason:job is not allowed to run on the current host`,
+		},
+	} {
+		args := append(step.args, "--configs-dir", configsDir, "--state-file", stateFile)
+		out, gotErr := execHomelabCmdTest(&testutils.TestContextInfo{DockerHost: fakeDocker}, nil, args...)
+		if len(step.wantErr) == 0 && gotErr != nil {
+			testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+			return
+		}
+		if len(step.wantErr) > 0 {
+			if gotErr == nil {
+				testhelpers.LogErrorNilWithOutput(t, "Exec()", tc, out, step.wantErr)
+				return
+			}
+			if !testhelpers.RegexMatchWithOutput(t, "Exec()", tc, out, fmt.Sprintf("error on %s", step.desc), step.wantErr, gotErr.Error()) {
+				return
+			}
+		}
+		if !testhelpers.RegexMatch(t, "Exec()", tc, fmt.Sprintf("command output on %s", step.desc), step.want, out.String()) {
+			return
+		}
+	}
+
+	state, err := deployment.ReadJobsState(stateFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "deployment.ReadJobsState()", tc, err)
+		return
+	}
+	for _, want := range []struct {
+		job    string
+		status deployment.JobRunStatus
+	}{
+		{job: "g1-backup", status: deployment.JobRunStatusSucceeded},
+		{job: "g1-cleanup", status: deployment.JobRunStatusFailed},
+		{job: "g1-report", status: deployment.JobRunStatusSucceeded},
+	} {
+		st, found := state["fakehost/"+want.job]
+		if !testhelpers.CmpDiff(t, "Exec()", tc, fmt.Sprintf("%s recorded", want.job), true, found && len(st.Runs) == 1) {
+			return
+		}
+		if !testhelpers.CmpDiff(t, "Exec()", tc, fmt.Sprintf("%s status", want.job), want.status, st.Runs[0].Status) {
+			return
+		}
+	}
+	testhelpers.CmpDiff(t, "Exec()", tc, "g1-other recorded", false, state["fakehost/g1-other"] != nil)
+}
+
+func TestExecHomelabJobsDueTargetHosts(t *testing.T) {
+	t.Parallel()
+
+	tc := "Homelab Jobs Commands - Due On Target Hosts"
+	newRemoteHost := func(name string) *fakedocker.FakeDockerHost {
+		return fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+			HostName: name,
+			ValidImagesForPull: utils.StringSet{
+				"abc/sync": {},
+			},
+			ExitOnStart: map[string]*fakedocker.FakeContainerExit{
+				"g1-sync": {
+					Logs: fmt.Sprintf("synced %s\n", name),
+				},
+			},
+		})
+	}
+	ctxInfo := &testutils.TestContextInfo{
+		DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		RemoteDockerHosts: map[string]docker.APIClient{
+			"tcp://10.0.0.1:2375": newRemoteHost("host1"),
+			"tcp://10.0.0.2:2375": newRemoteHost("host2"),
+		},
+	}
+	stateFile := filepath.Join(t.TempDir(), "jobs.json")
+	configsDir := fmt.Sprintf("%s/testdata/target-host-cmds", testhelpers.Pwd())
+
+	// The runs of the same job on the different hosts sharing the state
+	// file are tracked independently.
+	for _, step := range []struct {
+		desc string
+		host string
+		want string
+	}{
+		{
+			desc: "first jobs due on host1",
+			host: "host1",
+			want: `(?s)Running job g1-sync
+.*synced host1
+.*Job g1-sync succeeded in .+
+
+`,
+		},
+		{
+			desc: "jobs due again on host1",
+			host: "host1",
+			want: ``,
+		},
+		{
+			desc: "first jobs due on host2",
+			host: "host2",
+			want: `(?s)Running job g1-sync
+.*synced host2
+.*Job g1-sync succeeded in .+
+
+`,
+		},
+		{
+			desc: "jobs due again on host2",
+			host: "host2",
+			want: ``,
+		},
+	} {
+		out, gotErr := execHomelabCmdTest(ctxInfo, nil, "jobs", "due", "--target-host", step.host, "--configs-dir", configsDir, "--state-file", stateFile)
+		if gotErr != nil {
+			testhelpers.LogErrorNotNilWithOutput(t, "Exec()", tc, out, gotErr)
+			return
+		}
+		if !testhelpers.RegexMatch(t, "Exec()", tc, fmt.Sprintf("command output on %s", step.desc), step.want, out.String()) {
+			return
+		}
+	}
+
+	state, err := deployment.ReadJobsState(stateFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "deployment.ReadJobsState()", tc, err)
+		return
+	}
+	for _, key := range []string{"host1/g1-sync", "host2/g1-sync"} {
+		st, found := state[key]
+		if !testhelpers.CmpDiff(t, "Exec()", tc, fmt.Sprintf("%s recorded", key), true, found && len(st.Runs) == 1) {
+			return
+		}
+	}
+}
+
 var executeHomelabGroupsCmds = []struct {
 	cmdArgs        []string
 	cmdNameInError string
@@ -2043,6 +3624,22 @@ var executeHomelabContainerCmds = []struct {
 		cmdNameInError: "containers purge",
 		cmdDesc:        "Containers Purge",
 	},
+	{
+		cmdArgs: []string{
+			"containers",
+			"backup",
+		},
+		cmdNameInError: "containers backup",
+		cmdDesc:        "Containers Backup",
+	},
+	{
+		cmdArgs: []string{
+			"containers",
+			"restore",
+		},
+		cmdNameInError: "containers restore",
+		cmdDesc:        "Containers Restore",
+	},
 }
 
 var executeHomelabContainerCmdErrorTests = []struct {
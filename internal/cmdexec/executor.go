@@ -1,7 +1,32 @@
 package cmdexec
 
+import (
+	"context"
+	"io"
+)
+
+// RunOptions represents the options for running a command using the
+// executor.
+type RunOptions struct {
+	// Env lists the env variables in the KEY=VALUE format, overriding
+	// the env inherited from the homelab process.
+	Env []string
+	// Dir is the working directory of the command, defaulting to the
+	// working directory of the homelab process.
+	Dir string
+	// Stdin is the standard input of the command if set.
+	Stdin io.Reader
+	// StreamOutput logs the stdout and stderr lines of the command as
+	// they are written, rather than only once the command exits.
+	StreamOutput bool
+}
+
 type Executor interface {
 	Run(bin string, args ...string) (string, error)
+	// RunWithOptions runs the command with the options, killing the
+	// command once the context is done, and returns the stdout of the
+	// command.
+	RunWithOptions(ctx context.Context, opts *RunOptions, bin string, args ...string) (string, error)
 }
 
 func NewExecutor() Executor {
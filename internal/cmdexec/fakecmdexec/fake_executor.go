@@ -3,19 +3,29 @@ package fakecmdexec
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tuxdudehomelab/homelab/internal/cmdexec"
+	l "github.com/tuxdudehomelab/homelab/internal/log"
+)
+
+var (
+	log = l.Log
 )
 
 type FakeExecutor struct {
+	mu        sync.Mutex
 	validCmds cmdOutputMap
 	errorCmds cmdErrorMap
+	runs      []*FakeRun
 }
 
 type cmdOutputMap map[string]argsOutputMap
 
-type argsOutputMap map[string]string
+type argsOutputMap map[string]*FakeValidCmdInfo
 
 type cmdErrorMap map[string]argsErrorMap
 
@@ -26,7 +36,6 @@ func newValidCmdsMap(cmds []FakeValidCmdInfo) cmdOutputMap {
 	for _, cmd := range cmds {
 		bin := cmd.Cmd[0]
 		args := cmd.Cmd[1:]
-		out := cmd.Output
 		var a argsOutputMap
 
 		if val, found := res[bin]; found {
@@ -35,7 +44,7 @@ func newValidCmdsMap(cmds []FakeValidCmdInfo) cmdOutputMap {
 			a = argsOutputMap{}
 			res[bin] = a
 		}
-		a[argsToStr(args...)] = out
+		a[argsToStr(args...)] = &cmd
 	}
 	return res
 }
@@ -59,14 +68,14 @@ func newErrorCmdsMap(cmds []FakeErrorCmdInfo) cmdErrorMap {
 	return res
 }
 
-func (c cmdOutputMap) output(bin string, args ...string) (string, bool) {
+func (c cmdOutputMap) output(bin string, args ...string) (*FakeValidCmdInfo, bool) {
 	if argsMap, found := c[bin]; found {
 		return argsMap.output(args...)
 	}
-	return "", false
+	return nil, false
 }
 
-func (a argsOutputMap) output(args ...string) (string, bool) {
+func (a argsOutputMap) output(args ...string) (*FakeValidCmdInfo, bool) {
 	res, found := a[argsToStr(args...)]
 	return res, found
 }
@@ -91,6 +100,18 @@ type FakeExecutorInitInfo struct {
 type FakeValidCmdInfo struct {
 	Cmd    []string
 	Output string
+	// Delay is the duration the command takes to run, unless the context
+	// is done before that.
+	Delay time.Duration
+}
+
+// FakeRun represents a command run using the fake executor along with
+// its options, and the contents read from its stdin.
+type FakeRun struct {
+	Cmd   []string
+	Env   []string
+	Dir   string
+	Stdin string
 }
 
 type FakeErrorCmdInfo struct {
@@ -117,13 +138,53 @@ func NewFakeExecutor(initInfo *FakeExecutorInitInfo) *FakeExecutor {
 }
 
 func (f *FakeExecutor) Run(bin string, args ...string) (string, error) {
+	return f.RunWithOptions(context.Background(), &cmdexec.RunOptions{}, bin, args...)
+}
+
+func (f *FakeExecutor) RunWithOptions(ctx context.Context, opts *cmdexec.RunOptions, bin string, args ...string) (string, error) {
+	run := &FakeRun{
+		Cmd: append([]string{bin}, args...),
+		Env: opts.Env,
+		Dir: opts.Dir,
+	}
+	if opts.Stdin != nil {
+		in, err := io.ReadAll(opts.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the stdin of the fake executor command %s %q, reason: %w", bin, args, err)
+		}
+		run.Stdin = string(in)
+	}
+	f.mu.Lock()
+	f.runs = append(f.runs, run)
+	f.mu.Unlock()
+
 	if err, found := f.errorCmds.err(bin, args...); found {
 		return "", err
 	}
-	if out, found := f.validCmds.output(bin, args...); found {
-		return out, nil
+	cmd, found := f.validCmds.output(bin, args...)
+	if !found {
+		return "", fmt.Errorf("invalid fake executor command %s %q", bin, args)
+	}
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("command %s %q did not complete, reason: %w", bin, args, ctx.Err())
+	case <-time.After(cmd.Delay):
+	}
+	if opts.StreamOutput && len(cmd.Output) > 0 {
+		for _, line := range strings.Split(strings.TrimSuffix(cmd.Output, "\n"), "\n") {
+			log(ctx).Printf("%s", line)
+		}
 	}
-	return "", fmt.Errorf("invalid fake executor command %s %q", bin, args)
+	return cmd.Output, nil
+}
+
+// Runs returns the commands run using the fake executor so far, oldest
+// first.
+func (f *FakeExecutor) Runs() []*FakeRun {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.runs
 }
 
 func argsToStr(args ...string) string {
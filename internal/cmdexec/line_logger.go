@@ -0,0 +1,37 @@
+package cmdexec
+
+import (
+	"bytes"
+)
+
+// lineLogger is a writer logging every line written to it, buffering
+// the trailing partial line until it is complete or flushed.
+type lineLogger struct {
+	logLine func(line string)
+	partial []byte
+}
+
+func newLineLogger(logLine func(line string)) *lineLogger {
+	return &lineLogger{logLine: logLine}
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		l.logLine(string(bytes.TrimSuffix(l.partial[:i], []byte("\r"))))
+		l.partial = l.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush logs the trailing partial line if any.
+func (l *lineLogger) flush() {
+	if len(l.partial) > 0 {
+		l.logLine(string(l.partial))
+		l.partial = nil
+	}
+}
@@ -1,22 +1,74 @@
 package cmdexec
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// Once the command is killed, the output of the command (including
+	// any of its child processes holding on to the stdout and stderr)
+	// is waited upon only for this duration.
+	cmdWaitDelay = 5 * time.Second
 )
 
 type executor struct{}
 
 func (e *executor) Run(bin string, args ...string) (string, error) {
-	cmd := exec.Command(bin, args...)
-	out, err := cmd.Output()
+	return e.RunWithOptions(context.Background(), &RunOptions{}, bin, args...)
+}
+
+func (e *executor) RunWithOptions(ctx context.Context, opts *RunOptions, bin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.WaitDelay = cmdWaitDelay
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	cmd.Dir = opts.Dir
+	cmd.Stdin = opts.Stdin
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	var outLog, errLog *lineLogger
+	if opts.StreamOutput {
+		// The stdout and stderr are copied from separate goroutines.
+		var mu sync.Mutex
+		outLog = newLineLogger(func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			log(ctx).Printf("%s", line)
+		})
+		errLog = newLineLogger(func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			log(ctx).Warnf("%s", line)
+		})
+		cmd.Stdout = io.MultiWriter(&stdout, outLog)
+		cmd.Stderr = io.MultiWriter(&stderr, errLog)
+	}
+
+	err := cmd.Run()
+	if opts.StreamOutput {
+		outLog.flush()
+		errLog.flush()
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return stdout.String(), fmt.Errorf("command %s %q did not complete, reason: %w", bin, args, ctxErr)
+	}
 	if err != nil {
 		var ee *exec.ExitError
 		if errors.As(err, &ee) {
-			return "", fmt.Errorf("command failed %s %q, reason: %w, stderr: %s", bin, args, err, ee.Stderr)
+			return stdout.String(), fmt.Errorf("command failed %s %q, reason: %w, stderr: %s", bin, args, err, stderr.String())
 		}
-		return "", fmt.Errorf("command failed %s %q, reason: %w", bin, args, err)
+		return stdout.String(), fmt.Errorf("command failed %s %q, reason: %w", bin, args, err)
 	}
-	return string(out), nil
+	return stdout.String(), nil
 }
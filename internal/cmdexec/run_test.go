@@ -0,0 +1,157 @@
+package cmdexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdude/zzzlogi"
+	l "github.com/tuxdudehomelab/homelab/internal/log"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+var executorRunWithOptionsTests = []struct {
+	name    string
+	opts    func(dir string) *RunOptions
+	cmd     string
+	want    func(dir string) string
+	wantLog string
+}{
+	{
+		name: "Executor Run With Options - Env",
+		opts: func(dir string) *RunOptions {
+			return &RunOptions{
+				Env: []string{
+					"HOMELAB_TEST_VAR=foo",
+				},
+			}
+		},
+		cmd: `echo "var: ${HOMELAB_TEST_VAR}"`,
+		want: func(dir string) string {
+			return "var: foo\n"
+		},
+	},
+	{
+		name: "Executor Run With Options - Working Dir",
+		opts: func(dir string) *RunOptions {
+			return &RunOptions{
+				Dir: dir,
+			}
+		},
+		cmd: `pwd`,
+		want: func(dir string) string {
+			return fmt.Sprintf("%s\n", dir)
+		},
+	},
+	{
+		name: "Executor Run With Options - Stdin",
+		opts: func(dir string) *RunOptions {
+			return &RunOptions{
+				Stdin: strings.NewReader("line1\nline2\n"),
+			}
+		},
+		cmd: `wc -l | tr -d ' '`,
+		want: func(dir string) string {
+			return "2\n"
+		},
+	},
+	{
+		name: "Executor Run With Options - Stream Output",
+		opts: func(dir string) *RunOptions {
+			return &RunOptions{
+				StreamOutput: true,
+			}
+		},
+		cmd: `echo out1; sleep 0.1; echo err1 >&2; sleep 0.1; printf out2`,
+		want: func(dir string) string {
+			return "out1\nout2"
+		},
+		wantLog: `(?s)out1\n.*WARN.*err1\nout2\n`,
+	},
+}
+
+func TestExecutorRunWithOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range executorRunWithOptionsTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			ctx := l.WithLogger(context.Background(), newCapturingTestLogger(buf))
+			dir := t.TempDir()
+
+			got, gotErr := NewExecutor().RunWithOptions(ctx, tc.opts(dir), "sh", "-c", tc.cmd)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "Executor.RunWithOptions()", tc.name, gotErr)
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Executor.RunWithOptions()", tc.name, "output", tc.want(dir), got) {
+				return
+			}
+			if len(tc.wantLog) > 0 && !testhelpers.RegexMatch(t, "Executor.RunWithOptions()", tc.name, "log output", tc.wantLog, buf.String()) {
+				return
+			}
+		})
+	}
+}
+
+var executorRunWithOptionsErrorTests = []struct {
+	name    string
+	timeout time.Duration
+	cmd     string
+	want    string
+}{
+	{
+		name: "Executor Run With Options - Non-Zero Exit Code",
+		cmd:  `echo some error >&2; exit 3`,
+		want: `command failed sh \["-c" "echo some error >&2; exit 3"\], reason: exit status 3, stderr: some error
+`,
+	},
+	{
+		name:    "Executor Run With Options - Timeout",
+		timeout: 50 * time.Millisecond,
+		cmd:     `exec sleep 30`,
+		want:    `command sh \["-c" "exec sleep 30"\] did not complete, reason: context deadline exceeded`,
+	},
+}
+
+func TestExecutorRunWithOptionsErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range executorRunWithOptionsErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := l.WithLogger(context.Background(), newTestLogger())
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			_, gotErr := NewExecutor().RunWithOptions(ctx, &RunOptions{}, "sh", "-c", tc.cmd)
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "Executor.RunWithOptions()", tc.name, tc.want)
+				return
+			}
+			if !testhelpers.RegexMatch(t, "Executor.RunWithOptions()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
+
+func newCapturingTestLogger(buf *bytes.Buffer) zzzlogi.Logger {
+	config := zzzlog.NewConsoleLoggerConfig()
+	config.SkipCallerInfo = true
+	config.PanicInFatal = true
+	config.Dest = buf
+	return zzzlog.NewLogger(config)
+}
@@ -0,0 +1,903 @@
+package compose
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultNetworkName     = "default"
+	defaultHostIP          = "0.0.0.0"
+	defaultProtocol        = "tcp"
+	hostInterfacePrefix    = "docker-"
+	maxHostInterfaceLength = 15
+	serviceNetworkPrefix   = "service:"
+)
+
+// ImportOptions represents the options for importing a docker compose
+// file.
+type ImportOptions struct {
+	// Group is the name of the homelab group the imported containers
+	// belong to. When empty, the compose project name is used, falling
+	// back to the base name of ProjectDir.
+	Group string
+	// ProjectDir is the directory containing the compose file.
+	ProjectDir string
+}
+
+// ImportResult represents the homelab config imported from a docker
+// compose file.
+type ImportResult struct {
+	// Config contains the imported group, the containers (one per compose
+	// service) and the suggested IPAM entries for the compose networks.
+	Config config.Homelab
+	// Unsupported lists the compose settings that could not be imported.
+	Unsupported []string
+}
+
+type importer struct {
+	group        string
+	networks     map[string]*network
+	networkOrder []string
+	services     []*service
+	unsupported  []string
+}
+
+type network struct {
+	name     string
+	subnet   string
+	external bool
+	members  []networkMember
+}
+
+type networkMember struct {
+	container config.ContainerReference
+	ip        string
+}
+
+type service struct {
+	name        string
+	config      *config.Container
+	dependsOn   []string
+	networkMode string
+	mountNames  map[string]struct{}
+}
+
+// Import converts the services within the docker compose file into the
+// homelab containers config.
+func Import(r io.Reader, opts *ImportOptions) (*ImportResult, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file, reason: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("compose file is empty")
+	}
+
+	im := &importer{networks: map[string]*network{}}
+	if err := im.importDoc(doc.Content[0], opts); err != nil {
+		return nil, fmt.Errorf("failed to import compose file, reason: %w", err)
+	}
+	return im.result(), nil
+}
+
+func (im *importer) importDoc(doc *yaml.Node, opts *ImportOptions) error {
+	pairs, err := mappingPairs(doc)
+	if err != nil {
+		return err
+	}
+
+	var projectName string
+	var services, networks *yaml.Node
+	for _, p := range pairs {
+		switch {
+		case p.key == "name":
+			if projectName, err = scalar(p.value); err != nil {
+				return err
+			}
+		case p.key == "services":
+			services = p.value
+		case p.key == "networks":
+			networks = p.value
+		case p.key == "version", p.key == "volumes", strings.HasPrefix(p.key, "x-"):
+			// The compose file version is obsolete, the named volumes
+			// are imported as bind mounts within the container data
+			// directory and the extensions are meant to be reused
+			// using anchors and aliases.
+		default:
+			im.addUnsupported(p.key, "not supported")
+		}
+	}
+
+	im.group = opts.Group
+	if len(im.group) == 0 {
+		im.group = projectName
+	}
+	if len(im.group) == 0 && len(opts.ProjectDir) > 0 {
+		im.group = filepath.Base(opts.ProjectDir)
+	}
+	if len(im.group) == 0 || im.group == "." || im.group == "/" {
+		return fmt.Errorf("unable to determine the group name, specify the group explicitly")
+	}
+
+	if networks != nil && !isNull(networks) {
+		if err := im.importNetworks(networks); err != nil {
+			return err
+		}
+	}
+	if services == nil || isNull(services) {
+		return fmt.Errorf("no services found")
+	}
+	svcs, err := mappingPairs(services)
+	if err != nil {
+		return err
+	}
+	for _, s := range svcs {
+		svc, err := im.importService(s.key, s.value)
+		if err != nil {
+			return fmt.Errorf("failed to import service %s, reason: %w", s.key, err)
+		}
+		im.services = append(im.services, svc)
+	}
+	if err := im.reportInterpolation(networks, "networks"); err != nil {
+		return err
+	}
+	if err := im.reportInterpolation(services, "services"); err != nil {
+		return err
+	}
+	if err := im.assignOrder(); err != nil {
+		return err
+	}
+	return im.assignIPs()
+}
+
+func (im *importer) importNetworks(n *yaml.Node) error {
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		net := &network{name: im.homelabNetworkName(p.key)}
+		im.networks[p.key] = net
+		if isNull(p.value) {
+			continue
+		}
+		props, err := mappingPairs(p.value)
+		if err != nil {
+			return err
+		}
+		for _, prop := range props {
+			field := fmt.Sprintf("networks.%s.%s", p.key, prop.key)
+			switch prop.key {
+			case "name":
+				if net.name, err = scalar(prop.value); err != nil {
+					return err
+				}
+			case "external":
+				if net.external, err = boolValue(prop.value); err != nil {
+					return err
+				}
+			case "driver":
+				d, err := scalar(prop.value)
+				if err != nil {
+					return err
+				}
+				if d != "bridge" {
+					im.addUnsupported(field, fmt.Sprintf("network driver %s is not supported, only bridge networks are supported", d))
+				}
+			case "ipam":
+				subnet, err := ipamSubnet(prop.value)
+				if err != nil {
+					return fmt.Errorf("invalid ipam in network %s, reason: %w", p.key, err)
+				}
+				net.subnet = subnet
+			default:
+				im.addUnsupported(field, "not supported")
+			}
+		}
+	}
+	return nil
+}
+
+func ipamSubnet(n *yaml.Node) (string, error) {
+	configs, err := mappingValue(n, "config")
+	if err != nil || configs == nil {
+		return "", err
+	}
+	items, err := sequence(configs)
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		subnet, err := mappingValue(item, "subnet")
+		if err != nil {
+			return "", err
+		}
+		if subnet == nil {
+			continue
+		}
+		s, err := scalar(subnet)
+		if err != nil {
+			return "", err
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return "", err
+		}
+		if prefix.Addr().Is4() {
+			return prefix.Masked().String(), nil
+		}
+	}
+	return "", nil
+}
+
+func (im *importer) importService(name string, n *yaml.Node) (*service, error) {
+	svc := &service{
+		name: name,
+		config: &config.Container{
+			Info: config.ContainerReference{
+				Group:     im.group,
+				Container: name,
+			},
+		},
+		mountNames: map[string]struct{}{},
+	}
+	var pairs []nodePair
+	if !isNull(n) {
+		var err error
+		if pairs, err = mappingPairs(n); err != nil {
+			return nil, err
+		}
+	}
+
+	ct := svc.config
+	hasNetworks := false
+	for _, p := range pairs {
+		field := fmt.Sprintf("services.%s.%s", name, p.key)
+		var err error
+		switch p.key {
+		case "image":
+			ct.Image.Image, err = stringValue(p.value)
+		case "hostname":
+			ct.Network.HostName, err = stringValue(p.value)
+		case "domainname":
+			ct.Network.DomainName, err = stringValue(p.value)
+		case "dns":
+			ct.Network.DNSServers, err = stringOrList(p.value)
+		case "dns_opt":
+			ct.Network.DNSOptions, err = stringOrList(p.value)
+		case "dns_search":
+			ct.Network.DNSSearch, err = stringOrList(p.value)
+		case "extra_hosts":
+			err = im.importExtraHosts(svc, p.value)
+		case "environment":
+			err = im.importEnv(svc, p.value)
+		case "env_file":
+			err = im.importEnvFiles(svc, p.value)
+		case "ports":
+			err = im.importPorts(svc, field, p.value)
+		case "volumes":
+			err = im.importVolumes(svc, field, p.value)
+		case "tmpfs":
+			err = im.importTmpfs(svc, field, p.value)
+		case "networks":
+			hasNetworks = true
+			err = im.importServiceNetworks(svc, p.value)
+		case "network_mode":
+			err = im.importNetworkMode(svc, field, p.value)
+		case "healthcheck":
+			err = im.importHealthCheck(svc, field, p.value)
+		case "restart":
+			err = im.importRestart(svc, field, p.value)
+		case "labels":
+			err = im.importLabels(svc, p.value)
+		case "cap_add":
+			ct.Security.CapAdd, err = stringList(p.value)
+		case "cap_drop":
+			ct.Security.CapDrop, err = stringList(p.value)
+		case "privileged":
+			ct.Security.Privileged, err = boolValue(p.value)
+		case "sysctls":
+			err = im.importSysctls(svc, p.value)
+		case "devices":
+			err = im.importDevices(svc, field, p.value)
+		case "read_only":
+			ct.Filesystem.ReadOnlyRootfs, err = boolValue(p.value)
+		case "depends_on":
+			err = im.importDependsOn(svc, field, p.value)
+		case "user":
+			err = im.importUser(svc, p.value)
+		case "group_add":
+			ct.User.AdditionalGroups, err = stringList(p.value)
+		case "entrypoint":
+			ct.Runtime.Entrypoint, err = command(p.value)
+		case "command":
+			ct.Runtime.Args, err = command(p.value)
+		case "tty":
+			ct.Runtime.AttachToTty, err = boolValue(p.value)
+		case "shm_size":
+			ct.Runtime.ShmSize, err = scalar(p.value)
+		case "stop_signal":
+			ct.Lifecycle.StopSignal, err = scalar(p.value)
+		case "stop_grace_period":
+			ct.Lifecycle.StopTimeout, err = durationSeconds(p.value)
+		case "container_name":
+			im.addUnsupported(field, "not supported, the container name is derived from the group and the container")
+		case "build":
+			im.addUnsupported(field, "building images is not supported, specify a pre-built image")
+		default:
+			im.addUnsupported(field, "not supported")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s, reason: %w", p.key, err)
+		}
+	}
+
+	if !hasNetworks && len(svc.networkMode) == 0 {
+		im.addMember(defaultNetworkName, networkMember{container: ct.Info})
+	}
+	return svc, nil
+}
+
+func (im *importer) importExtraHosts(svc *service, n *yaml.Node) error {
+	n = resolveAlias(n)
+	sep := ":"
+	if n.Kind == yaml.SequenceNode {
+		// The list entries can use either host=ip or host:ip, while the
+		// mapping entries are converted using the mapping values.
+		items, err := stringList(n)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if k, v, found := strings.Cut(item, "="); found {
+				item = k + sep + v
+			}
+			svc.config.Network.ExtraHosts = append(svc.config.Network.ExtraHosts, item)
+		}
+		return nil
+	}
+	kvs, err := keyValues(n, sep)
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		svc.config.Network.ExtraHosts = append(svc.config.Network.ExtraHosts, kv.key+sep+kv.value)
+	}
+	return nil
+}
+
+func (im *importer) importEnv(svc *service, n *yaml.Node) error {
+	kvs, err := keyValues(n, "=")
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		e := config.ContainerEnv{Var: kv.key}
+		switch {
+		case !kv.hasValue:
+			// Similar to compose, env vars without a value are passed
+			// through from the host.
+			e.FromHost = true
+		case len(kv.value) == 0:
+			e.Empty = true
+		default:
+			e.Value = kv.value
+		}
+		svc.config.Runtime.Env = append(svc.config.Runtime.Env, e)
+	}
+	return nil
+}
+
+func (im *importer) importEnvFiles(svc *service, n *yaml.Node) error {
+	n = resolveAlias(n)
+	var items []*yaml.Node
+	if n.Kind == yaml.ScalarNode {
+		items = []*yaml.Node{n}
+	} else {
+		var err error
+		if items, err = sequence(n); err != nil {
+			return err
+		}
+	}
+	for _, item := range items {
+		pathNode := item
+		if item.Kind == yaml.MappingNode {
+			var err error
+			if pathNode, err = mappingValue(item, "path"); err != nil {
+				return err
+			}
+			if pathNode == nil {
+				return fmt.Errorf("line %d: env file path is missing", item.Line)
+			}
+		}
+		p, err := stringValue(pathNode)
+		if err != nil {
+			return err
+		}
+		svc.config.Runtime.EnvFiles = append(svc.config.Runtime.EnvFiles, filepath.Clean(p))
+	}
+	return nil
+}
+
+func (im *importer) importLabels(svc *service, n *yaml.Node) error {
+	kvs, err := keyValues(n, "=")
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		svc.config.Metadata.Labels = append(svc.config.Metadata.Labels, config.Label{Name: kv.key, Value: kv.value})
+	}
+	return nil
+}
+
+func (im *importer) importSysctls(svc *service, n *yaml.Node) error {
+	kvs, err := keyValues(n, "=")
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		svc.config.Security.Sysctls = append(svc.config.Security.Sysctls, config.Sysctl{Key: kv.key, Value: kv.value})
+	}
+	return nil
+}
+
+func (im *importer) importUser(svc *service, n *yaml.Node) error {
+	u, err := scalar(n)
+	if err != nil {
+		return err
+	}
+	user, group, _ := strings.Cut(u, ":")
+	svc.config.User.User = user
+	svc.config.User.PrimaryGroup = group
+	return nil
+}
+
+func (im *importer) importDependsOn(svc *service, field string, n *yaml.Node) error {
+	n = resolveAlias(n)
+	if n.Kind == yaml.SequenceNode {
+		deps, err := stringList(n)
+		if err != nil {
+			return err
+		}
+		svc.dependsOn = append(svc.dependsOn, deps...)
+		return nil
+	}
+
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		svc.dependsOn = append(svc.dependsOn, p.key)
+		if isNull(p.value) {
+			continue
+		}
+		cond, err := mappingValue(p.value, "condition")
+		if err != nil {
+			return err
+		}
+		if cond == nil {
+			continue
+		}
+		c, err := scalar(cond)
+		if err != nil {
+			return err
+		}
+		if c != "service_started" {
+			im.addUnsupported(fmt.Sprintf("%s.%s.condition", field, p.key), fmt.Sprintf("condition %s is not supported, only the start order is imported", c))
+		}
+	}
+	return nil
+}
+
+func (im *importer) importRestart(svc *service, field string, n *yaml.Node) error {
+	r, err := scalar(n)
+	if err != nil {
+		return err
+	}
+	mode, retries, found := strings.Cut(r, ":")
+	switch mode {
+	case "no", "always", "unless-stopped":
+		if found {
+			im.addUnsupported(field, fmt.Sprintf("max retry count is not supported for the restart policy %s", mode))
+		}
+	case "on-failure":
+		if found {
+			if _, err := fmt.Sscanf(retries, "%d", &svc.config.Lifecycle.RestartPolicy.MaxRetryCount); err != nil {
+				return fmt.Errorf("invalid max retry count %s", retries)
+			}
+		}
+	default:
+		im.addUnsupported(field, fmt.Sprintf("restart policy %s is not supported", r))
+		return nil
+	}
+	svc.config.Lifecycle.RestartPolicy.Mode = mode
+	return nil
+}
+
+func (im *importer) importHealthCheck(svc *service, field string, n *yaml.Node) error {
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return err
+	}
+	h := &svc.config.Health
+	for _, p := range pairs {
+		var err error
+		switch p.key {
+		case "test":
+			h.Cmd, err = im.healthCheckCmd(field+".test", p.value)
+		case "interval":
+			h.Interval, err = scalar(p.value)
+		case "timeout":
+			h.Timeout, err = scalar(p.value)
+		case "start_period":
+			h.StartPeriod, err = scalar(p.value)
+		case "start_interval":
+			h.StartInterval, err = scalar(p.value)
+		case "retries":
+			err = resolveAlias(p.value).Decode(&h.Retries)
+		default:
+			im.addUnsupported(fmt.Sprintf("%s.%s", field, p.key), "not supported")
+		}
+		if err != nil {
+			return fmt.Errorf("invalid healthcheck %s, reason: %w", p.key, err)
+		}
+	}
+	return nil
+}
+
+func (im *importer) healthCheckCmd(field string, n *yaml.Node) ([]string, error) {
+	n = resolveAlias(n)
+	if n.Kind == yaml.ScalarNode {
+		s, err := stringValue(n)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"/bin/sh", "-c", s}, nil
+	}
+	test, err := stringList(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(test) == 0 {
+		return nil, nil
+	}
+	switch test[0] {
+	case "CMD":
+		return test[1:], nil
+	case "CMD-SHELL":
+		return []string{"/bin/sh", "-c", strings.Join(test[1:], " ")}, nil
+	default:
+		im.addUnsupported(field, fmt.Sprintf("health check test type %s is not supported", test[0]))
+		return nil, nil
+	}
+}
+
+func (im *importer) importDevices(svc *service, field string, n *yaml.Node) error {
+	items, err := stringList(n)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		parts := strings.Split(item, ":")
+		if strings.Contains(parts[0], "=") || !strings.HasPrefix(parts[0], "/") || len(parts) > 3 {
+			im.addUnsupported(field, fmt.Sprintf("device %s is not supported", item))
+			continue
+		}
+		d := config.Device{Src: parts[0]}
+		if len(parts) > 1 {
+			d.Dst = parts[1]
+		}
+		if len(parts) > 2 {
+			perms := parts[2]
+			d.DisallowRead = !strings.Contains(perms, "r")
+			d.DisallowWrite = !strings.Contains(perms, "w")
+			d.DisallowMknod = !strings.Contains(perms, "m")
+		}
+		svc.config.Filesystem.Devices.Static = append(svc.config.Filesystem.Devices.Static, d)
+	}
+	return nil
+}
+
+func (im *importer) importServiceNetworks(svc *service, n *yaml.Node) error {
+	n = resolveAlias(n)
+	if n.Kind == yaml.SequenceNode {
+		names, err := stringList(n)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			im.addMember(name, networkMember{container: svc.config.Info})
+		}
+		return nil
+	}
+
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		member := networkMember{container: svc.config.Info}
+		if !isNull(p.value) {
+			props, err := mappingPairs(p.value)
+			if err != nil {
+				return err
+			}
+			for _, prop := range props {
+				field := fmt.Sprintf("services.%s.networks.%s.%s", svc.name, p.key, prop.key)
+				switch prop.key {
+				case "ipv4_address":
+					if member.ip, err = scalar(prop.value); err != nil {
+						return err
+					}
+				default:
+					im.addUnsupported(field, "not supported")
+				}
+			}
+		}
+		im.addMember(p.key, member)
+	}
+	return nil
+}
+
+func (im *importer) importNetworkMode(svc *service, field string, n *yaml.Node) error {
+	mode, err := scalar(n)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(mode, serviceNetworkPrefix) {
+		im.addUnsupported(field, fmt.Sprintf("network mode %s is not supported", mode))
+		// Avoid attaching the container to the default network.
+		svc.networkMode = mode
+		return nil
+	}
+	svc.networkMode = mode
+	svc.dependsOn = append(svc.dependsOn, strings.TrimPrefix(mode, serviceNetworkPrefix))
+	return nil
+}
+
+// addMember adds the container to the specified compose network. The
+// networks are suggested in the IPAM config in the order of their first
+// use by the services.
+func (im *importer) addMember(name string, member networkMember) {
+	if !slices.Contains(im.networkOrder, name) {
+		im.networkOrder = append(im.networkOrder, name)
+	}
+	net, found := im.networks[name]
+	if !found {
+		net = &network{name: im.homelabNetworkName(name)}
+		im.networks[name] = net
+	}
+	net.members = append(net.members, member)
+}
+
+func (im *importer) homelabNetworkName(name string) string {
+	if name == defaultNetworkName {
+		return im.group
+	}
+	return fmt.Sprintf("%s-%s", im.group, name)
+}
+
+// reportInterpolation reports the fields with scalar values using the
+// compose variable interpolation as unsupported, since such values are
+// imported as is.
+func (im *importer) reportInterpolation(n *yaml.Node, field string) error {
+	n = resolveAlias(n)
+	if n == nil {
+		return nil
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		pairs, err := mappingPairs(n)
+		if err != nil {
+			return err
+		}
+		for _, p := range pairs {
+			if err := im.reportInterpolation(p.value, fmt.Sprintf("%s.%s", field, p.key)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			if err := im.reportInterpolation(c, field); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if hasInterpolation(n.Value) {
+			im.addUnsupported(field, fmt.Sprintf("variable interpolation in %q is not supported, the value is imported as is", n.Value))
+		}
+	}
+	return nil
+}
+
+func (im *importer) addUnsupported(field string, reason string) {
+	im.unsupported = append(im.unsupported, fmt.Sprintf("%s: %s", field, reason))
+}
+
+func command(n *yaml.Node) ([]string, error) {
+	n = resolveAlias(n)
+	if n.Kind == yaml.ScalarNode {
+		s, err := stringValue(n)
+		if err != nil {
+			return nil, err
+		}
+		return splitCommand(s)
+	}
+	return stringList(n)
+}
+
+func durationSeconds(n *yaml.Node) (int, error) {
+	s, err := scalar(n)
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return int((d + time.Second - 1) / time.Second), nil
+}
+
+// assignOrder assigns the container start order based on the service
+// dependencies, where every container starts after all the containers
+// it depends on.
+func (im *importer) assignOrder() error {
+	services := map[string]*service{}
+	for _, svc := range im.services {
+		services[svc.name] = svc
+	}
+
+	const visiting = -1
+	orders := map[string]int{}
+	var visit func(svc *service) (int, error)
+	visit = func(svc *service) (int, error) {
+		switch orders[svc.name] {
+		case 0:
+		case visiting:
+			return 0, fmt.Errorf("service %s has a circular dependency", svc.name)
+		default:
+			return orders[svc.name], nil
+		}
+
+		orders[svc.name] = visiting
+		order := 1
+		for _, d := range svc.dependsOn {
+			dep, found := services[d]
+			if !found {
+				return 0, fmt.Errorf("service %s depends on the service %s which is not defined", svc.name, d)
+			}
+			o, err := visit(dep)
+			if err != nil {
+				return 0, err
+			}
+			order = max(order, o+1)
+		}
+		orders[svc.name] = order
+		svc.config.Lifecycle.Order = order
+		return order, nil
+	}
+
+	for _, svc := range im.services {
+		if _, err := visit(svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignIPs assigns the IPs for the containers without an explicit IP
+// within the networks with a known subnet, skipping the network address
+// and the first host address used as the gateway.
+func (im *importer) assignIPs() error {
+	for _, name := range im.networkOrder {
+		net := im.networks[name]
+		if len(net.subnet) == 0 {
+			continue
+		}
+		prefix := netip.MustParsePrefix(net.subnet)
+
+		used := map[netip.Addr]struct{}{}
+		for _, m := range net.members {
+			if len(m.ip) == 0 {
+				continue
+			}
+			ip, err := netip.ParseAddr(m.ip)
+			if err != nil {
+				return fmt.Errorf("invalid IP %s for container %s in network %s, reason: %w", m.ip, m.container.Container, name, err)
+			}
+			used[ip] = struct{}{}
+		}
+
+		next := prefix.Addr().Next().Next()
+		for i := range net.members {
+			if len(net.members[i].ip) > 0 {
+				continue
+			}
+			for _, found := used[next]; found; _, found = used[next] {
+				next = next.Next()
+			}
+			if !prefix.Contains(next) {
+				return fmt.Errorf("network %s with subnet %s has no free IPs left", name, net.subnet)
+			}
+			net.members[i].ip = next.String()
+			next = next.Next()
+		}
+	}
+	return nil
+}
+
+func (im *importer) result() *ImportResult {
+	res := &ImportResult{}
+	res.Config.Groups = []config.ContainerGroup{
+		{
+			Name:  im.group,
+			Order: 1,
+		},
+	}
+	for _, svc := range im.services {
+		res.Config.Containers = append(res.Config.Containers, *svc.config)
+	}
+
+	for _, name := range im.networkOrder {
+		net := im.networks[name]
+		if net.external {
+			im.addUnsupported(fmt.Sprintf("networks.%s", name), "external networks are not supported, the suggested IPAM entry creates the network")
+		}
+		if len(net.subnet) == 0 {
+			im.addUnsupported(fmt.Sprintf("networks.%s", name), "no IPv4 subnet specified, pick a CIDR and the container IPs for the suggested IPAM entry")
+		}
+		bn := config.BridgeModeNetwork{
+			Name:              net.name,
+			HostInterfaceName: hostInterfaceName(net.name),
+			CIDR:              net.subnet,
+			Priority:          1,
+		}
+		for _, m := range net.members {
+			bn.Containers = append(bn.Containers, config.ContainerIP{IP: m.ip, Container: m.container})
+		}
+		res.Config.IPAM.Networks.BridgeModeNetworks = append(res.Config.IPAM.Networks.BridgeModeNetworks, bn)
+	}
+
+	for _, svc := range im.services {
+		target, found := strings.CutPrefix(svc.networkMode, serviceNetworkPrefix)
+		if !found {
+			continue
+		}
+		ref := config.ContainerReference{Group: im.group, Container: target}
+		res.Config.IPAM.Networks.ContainerModeNetworks = addAttachingContainer(res.Config.IPAM.Networks.ContainerModeNetworks, fmt.Sprintf("%s-%s", im.group, target), ref, svc.config.Info)
+	}
+	res.Unsupported = im.unsupported
+	return res
+}
+
+func addAttachingContainer(networks []config.ContainerModeNetwork, name string, ct, attaching config.ContainerReference) []config.ContainerModeNetwork {
+	for i := range networks {
+		if networks[i].Container == ct {
+			networks[i].AttachingContainers = append(networks[i].AttachingContainers, attaching)
+			return networks
+		}
+	}
+	return append(networks, config.ContainerModeNetwork{
+		Name:                name,
+		Container:           ct,
+		AttachingContainers: []config.ContainerReference{attaching},
+	})
+}
+
+func hostInterfaceName(network string) string {
+	name := hostInterfacePrefix + network
+	if len(name) > maxHostInterfaceLength {
+		name = name[:maxHostInterfaceLength]
+	}
+	return name
+}
@@ -0,0 +1,802 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+var importTests = []struct {
+	name    string
+	compose string
+	opts    *ImportOptions
+	want    *ImportResult
+}{
+	{
+		name: "Import - Minimal Service Using The Project Dir",
+		compose: `
+services:
+  app:
+    image: foo/app`,
+		opts: &ImportOptions{
+			ProjectDir: "/stacks/my-app",
+		},
+		want: &ImportResult{
+			Config: config.Homelab{
+				IPAM: config.IPAM{
+					Networks: config.Networks{
+						BridgeModeNetworks: []config.BridgeModeNetwork{
+							{
+								Name:              "my-app",
+								HostInterfaceName: "docker-my-app",
+								Priority:          1,
+								Containers: []config.ContainerIP{
+									{
+										Container: config.ContainerReference{
+											Group:     "my-app",
+											Container: "app",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Groups: []config.ContainerGroup{
+					{
+						Name:  "my-app",
+						Order: 1,
+					},
+				},
+				Containers: []config.Container{
+					{
+						Info: config.ContainerReference{
+							Group:     "my-app",
+							Container: "app",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/app",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							Order: 1,
+						},
+					},
+				},
+			},
+			Unsupported: []string{
+				"networks.default: no IPv4 subnet specified, pick a CIDR and the container IPs for the suggested IPAM entry",
+			},
+		},
+	},
+	{
+		name: "Import - All Supported Settings",
+		compose: `
+name: media
+x-common: &common
+  restart: unless-stopped
+  environment:
+    TZ: America/Los_Angeles
+services:
+  web:
+    <<: *common
+    image: foo/web:1.2
+    hostname: web
+    domainname: example.com
+    dns: 1.1.1.1
+    dns_opt: [ndots:1]
+    dns_search: [example.com]
+    extra_hosts:
+      - host1=10.1.1.1
+      - host2:10.1.1.2
+    env_file:
+      - ./web.env
+      - path: common.env
+        required: false
+    labels:
+      foo.bar: baz
+    ports:
+      - 8080:80
+      - 127.0.0.1:8443:443/tcp
+      - "[::1]:5353:53/udp"
+      - 9000-9001:9000-9001
+      - target: 22
+        published: 2222
+        host_ip: 10.0.0.1
+    volumes:
+      - ./config:/config
+      - data:/data:ro
+      - /srv/media:/media
+      - type: tmpfs
+        target: /cache
+        tmpfs:
+          size: 1m
+    tmpfs:
+      - /run:size=64m
+    cap_add: [NET_ADMIN]
+    cap_drop: [ALL]
+    privileged: true
+    read_only: true
+    sysctls:
+      - net.core.somaxconn=1024
+    devices:
+      - /dev/dri:/dev/dri:r
+      - /dev/ttyUSB0
+    user: "1000:1001"
+    group_add: [video]
+    entrypoint: /entrypoint.sh
+    command: ["serve", "--port", "80"]
+    tty: true
+    shm_size: 64m
+    stop_signal: SIGINT
+    stop_grace_period: 1m30s
+    healthcheck:
+      test: curl -f http://localhost || exit 1
+      interval: 30s
+      timeout: 5s
+      start_period: 10s
+      start_interval: 1s
+      retries: 3
+    depends_on: [db]
+    networks:
+      frontend:
+      backend:
+        ipv4_address: 172.30.1.5
+  db:
+    image: postgres:16
+    restart: on-failure:3
+    environment:
+      - POSTGRES_PASSWORD=pa$$word
+      - EMPTY=
+      - FROM_HOST
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+    command: postgres -c 'max_connections=200'
+    networks: [backend]
+  vpn:
+    image: foo/vpn
+    network_mode: service:web
+networks:
+  frontend:
+    name: my-frontend
+    ipam:
+      config:
+        - subnet: 172.30.0.0/24
+  backend:
+    driver: bridge
+    ipam:
+      config:
+        - subnet: fd00::/64
+        - subnet: 172.30.1.0/24`,
+		opts: &ImportOptions{
+			ProjectDir: "/stacks/other",
+		},
+		want: &ImportResult{
+			Config: config.Homelab{
+				IPAM: config.IPAM{
+					Networks: config.Networks{
+						BridgeModeNetworks: []config.BridgeModeNetwork{
+							{
+								Name:              "my-frontend",
+								HostInterfaceName: "docker-my-front",
+								CIDR:              "172.30.0.0/24",
+								Priority:          1,
+								Containers: []config.ContainerIP{
+									{
+										IP: "172.30.0.2",
+										Container: config.ContainerReference{
+											Group:     "media",
+											Container: "web",
+										},
+									},
+								},
+							},
+							{
+								Name:              "media-backend",
+								HostInterfaceName: "docker-media-ba",
+								CIDR:              "172.30.1.0/24",
+								Priority:          1,
+								Containers: []config.ContainerIP{
+									{
+										IP: "172.30.1.5",
+										Container: config.ContainerReference{
+											Group:     "media",
+											Container: "web",
+										},
+									},
+									{
+										IP: "172.30.1.2",
+										Container: config.ContainerReference{
+											Group:     "media",
+											Container: "db",
+										},
+									},
+								},
+							},
+						},
+						ContainerModeNetworks: []config.ContainerModeNetwork{
+							{
+								Name: "media-web",
+								Container: config.ContainerReference{
+									Group:     "media",
+									Container: "web",
+								},
+								AttachingContainers: []config.ContainerReference{
+									{
+										Group:     "media",
+										Container: "vpn",
+									},
+								},
+							},
+						},
+					},
+				},
+				Groups: []config.ContainerGroup{
+					{
+						Name:  "media",
+						Order: 1,
+					},
+				},
+				Containers: []config.Container{
+					{
+						Info: config.ContainerReference{
+							Group:     "media",
+							Container: "web",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/web:1.2",
+						},
+						Metadata: config.ContainerMetadata{
+							Labels: []config.Label{
+								{
+									Name:  "foo.bar",
+									Value: "baz",
+								},
+							},
+						},
+						Lifecycle: config.ContainerLifecycle{
+							Order: 2,
+							RestartPolicy: config.ContainerRestartPolicy{
+								Mode: "unless-stopped",
+							},
+							StopSignal:  "SIGINT",
+							StopTimeout: 90,
+						},
+						User: config.ContainerUser{
+							User:             "1000",
+							PrimaryGroup:     "1001",
+							AdditionalGroups: []string{"video"},
+						},
+						Filesystem: config.ContainerFilesystem{
+							ReadOnlyRootfs: true,
+							Mounts: []config.Mount{
+								{
+									Name: "config",
+									Type: "bind",
+									Src:  "$$CONTAINER_BASE_DIR$$/config",
+									Dst:  "/config",
+								},
+								{
+									Name:     "data",
+									Type:     "bind",
+									Src:      "$$CONTAINER_DATA_DIR$$/data",
+									Dst:      "/data",
+									ReadOnly: true,
+								},
+								{
+									Name: "media",
+									Type: "bind",
+									Src:  "/srv/media",
+									Dst:  "/media",
+								},
+								{
+									Name:      "cache",
+									Type:      "tmpfs",
+									Dst:       "/cache",
+									TmpfsSize: 1048576,
+								},
+								{
+									Name:      "run",
+									Type:      "tmpfs",
+									Dst:       "/run",
+									TmpfsSize: 67108864,
+								},
+							},
+							Devices: config.ContainerDevice{
+								Static: []config.Device{
+									{
+										Src:           "/dev/dri",
+										Dst:           "/dev/dri",
+										DisallowWrite: true,
+										DisallowMknod: true,
+									},
+									{
+										Src: "/dev/ttyUSB0",
+									},
+								},
+							},
+						},
+						Network: config.ContainerNetwork{
+							HostName:   "web",
+							DomainName: "example.com",
+							DNSServers: []string{"1.1.1.1"},
+							DNSOptions: []string{"ndots:1"},
+							DNSSearch:  []string{"example.com"},
+							ExtraHosts: []string{"host1:10.1.1.1", "host2:10.1.1.2"},
+							PublishedPorts: []config.PublishedPort{
+								{
+									ContainerPort: "80",
+									Protocol:      "tcp",
+									HostIP:        "0.0.0.0",
+									HostPort:      "8080",
+								},
+								{
+									ContainerPort: "443",
+									Protocol:      "tcp",
+									HostIP:        "127.0.0.1",
+									HostPort:      "8443",
+								},
+								{
+									ContainerPort: "53",
+									Protocol:      "udp",
+									HostIP:        "::1",
+									HostPort:      "5353",
+								},
+								{
+									ContainerPort: "9000",
+									Protocol:      "tcp",
+									HostIP:        "0.0.0.0",
+									HostPort:      "9000",
+								},
+								{
+									ContainerPort: "9001",
+									Protocol:      "tcp",
+									HostIP:        "0.0.0.0",
+									HostPort:      "9001",
+								},
+								{
+									ContainerPort: "22",
+									Protocol:      "tcp",
+									HostIP:        "10.0.0.1",
+									HostPort:      "2222",
+								},
+							},
+						},
+						Security: config.ContainerSecurity{
+							Privileged: true,
+							Sysctls: []config.Sysctl{
+								{
+									Key:   "net.core.somaxconn",
+									Value: "1024",
+								},
+							},
+							CapAdd:  []string{"NET_ADMIN"},
+							CapDrop: []string{"ALL"},
+						},
+						Health: config.ContainerHealth{
+							Cmd:           []string{"/bin/sh", "-c", "curl -f http://localhost || exit 1"},
+							Retries:       3,
+							Interval:      "30s",
+							Timeout:       "5s",
+							StartPeriod:   "10s",
+							StartInterval: "1s",
+						},
+						Runtime: config.ContainerRuntime{
+							AttachToTty: true,
+							ShmSize:     "64m",
+							EnvFiles:    []string{"web.env", "common.env"},
+							Env: []config.ContainerEnv{
+								{
+									Var:   "TZ",
+									Value: "America/Los_Angeles",
+								},
+							},
+							Entrypoint: []string{"/entrypoint.sh"},
+							Args:       []string{"serve", "--port", "80"},
+						},
+					},
+					{
+						Info: config.ContainerReference{
+							Group:     "media",
+							Container: "db",
+						},
+						Image: config.ContainerImage{
+							Image: "postgres:16",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							Order: 1,
+							RestartPolicy: config.ContainerRestartPolicy{
+								Mode:          "on-failure",
+								MaxRetryCount: 3,
+							},
+						},
+						Health: config.ContainerHealth{
+							Cmd: []string{"pg_isready"},
+						},
+						Runtime: config.ContainerRuntime{
+							Env: []config.ContainerEnv{
+								{
+									Var:   "POSTGRES_PASSWORD",
+									Value: "pa$word",
+								},
+								{
+									Var:   "EMPTY",
+									Empty: true,
+								},
+								{
+									Var:      "FROM_HOST",
+									FromHost: true,
+								},
+							},
+							Args: []string{"postgres", "-c", "max_connections=200"},
+						},
+					},
+					{
+						Info: config.ContainerReference{
+							Group:     "media",
+							Container: "vpn",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/vpn",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							Order: 3,
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name: "Import - Variable Interpolation",
+		compose: `
+services:
+  app:
+    image: foo/app:${TAG:-latest}
+    network_mode: none
+    hostname: app
+    environment:
+      HOME_DIR: $$HOME
+      HOST_NAME: $HOSTNAME
+    command: ["echo", "$${ESCAPED}", "price: 5$"]`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: &ImportResult{
+			Config: config.Homelab{
+				Groups: []config.ContainerGroup{
+					{
+						Name:  "g1",
+						Order: 1,
+					},
+				},
+				Containers: []config.Container{
+					{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "app",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/app:${TAG:-latest}",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							Order: 1,
+						},
+						Network: config.ContainerNetwork{
+							HostName: "app",
+						},
+						Runtime: config.ContainerRuntime{
+							Env: []config.ContainerEnv{
+								{
+									Var:   "HOME_DIR",
+									Value: "$HOME",
+								},
+								{
+									Var:   "HOST_NAME",
+									Value: "$HOSTNAME",
+								},
+							},
+							Args: []string{
+								"echo",
+								"${ESCAPED}",
+								"price: 5$",
+							},
+						},
+					},
+				},
+			},
+			Unsupported: []string{
+				"services.app.network_mode: network mode none is not supported",
+				`services.app.image: variable interpolation in "foo/app:${TAG:-latest}" is not supported, the value is imported as is`,
+				`services.app.environment.HOST_NAME: variable interpolation in "$HOSTNAME" is not supported, the value is imported as is`,
+			},
+		},
+	},
+	{
+		name: "Import - Unsupported Settings",
+		compose: `
+version: "3.8"
+services:
+  app:
+    build: .
+    container_name: my-app
+    network_mode: host
+    deploy:
+      replicas: 2
+    restart: always:3
+    ports:
+      - "80"
+      - 8000-8001:80
+    volumes:
+      - /data
+      - ~/config:/config
+      - ./data:/data:z
+      - type: npipe
+        source: foo
+        target: /pipe
+    tmpfs: /run:mode=755
+    devices:
+      - nvidia.com/gpu=all
+    healthcheck:
+      test: ["NONE"]
+      disable: true
+secrets:
+  foo:
+    file: ./foo`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: &ImportResult{
+			Config: config.Homelab{
+				Groups: []config.ContainerGroup{
+					{
+						Name:  "g1",
+						Order: 1,
+					},
+				},
+				Containers: []config.Container{
+					{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "app",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							Order: 1,
+							RestartPolicy: config.ContainerRestartPolicy{
+								Mode: "always",
+							},
+						},
+						Filesystem: config.ContainerFilesystem{
+							Mounts: []config.Mount{
+								{
+									Name: "data",
+									Type: "bind",
+									Src:  "$$CONTAINER_BASE_DIR$$/data",
+									Dst:  "/data",
+								},
+								{
+									Name: "run",
+									Type: "tmpfs",
+									Dst:  "/run",
+								},
+							},
+						},
+					},
+				},
+			},
+			Unsupported: []string{
+				"secrets: not supported",
+				"services.app.build: building images is not supported, specify a pre-built image",
+				"services.app.container_name: not supported, the container name is derived from the group and the container",
+				"services.app.network_mode: network mode host is not supported",
+				"services.app.deploy: not supported",
+				"services.app.restart: max retry count is not supported for the restart policy always",
+				"services.app.ports: publishing container port 80 on an ephemeral host port is not supported",
+				"services.app.ports: publishing container ports 80 on host ports 8000-8001 of a different range size is not supported",
+				"services.app.volumes: anonymous volume /data is not supported",
+				"services.app.volumes: volume source ~/config outside the compose project directory is not supported",
+				"services.app.volumes: mount option z for /data is not supported",
+				"services.app.volumes: volume type npipe for /pipe is not supported",
+				"services.app.tmpfs: tmpfs option mode=755 for /run is not supported",
+				"services.app.devices: device nvidia.com/gpu=all is not supported",
+				"services.app.healthcheck.test: health check test type NONE is not supported",
+				"services.app.healthcheck.disable: not supported",
+			},
+		},
+	},
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range importTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := Import(strings.NewReader(tc.compose), tc.opts)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "Import()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "Import()", tc.name, "import result", tc.want, got) {
+				return
+			}
+		})
+	}
+}
+
+var importErrorTests = []struct {
+	name    string
+	compose string
+	opts    *ImportOptions
+	want    string
+}{
+	{
+		name:    "Import - Empty Compose File",
+		compose: ``,
+		opts:    &ImportOptions{},
+		want:    `failed to parse compose file, reason: EOF`,
+	},
+	{
+		name: "Import - No Group",
+		compose: `
+services:
+  app:
+    image: foo/app`,
+		opts: &ImportOptions{},
+		want: `failed to import compose file, reason: unable to determine the group name, specify the group explicitly`,
+	},
+	{
+		name: "Import - No Services",
+		compose: `
+name: g1`,
+		opts: &ImportOptions{},
+		want: `failed to import compose file, reason: no services found`,
+	},
+	{
+		name: "Import - Unknown Dependency",
+		compose: `
+services:
+  app:
+    image: foo/app
+    depends_on: [db]`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: service app depends on the service db which is not defined`,
+	},
+	{
+		name: "Import - Circular Dependency",
+		compose: `
+services:
+  app:
+    image: foo/app
+    depends_on: [db]
+  db:
+    image: foo/db
+    depends_on: [app]`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: service app has a circular dependency`,
+	},
+	{
+		name: "Import - Invalid Port",
+		compose: `
+services:
+  app:
+    image: foo/app
+    ports:
+      - 80:http`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: failed to import service app, reason: invalid ports, reason: invalid port http`,
+	},
+	{
+		name: "Import - Invalid Stop Grace Period",
+		compose: `
+services:
+  app:
+    image: foo/app
+    stop_grace_period: forever`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: failed to import service app, reason: invalid stop_grace_period, reason: time: invalid duration "forever"`,
+	},
+	{
+		name: "Import - Invalid Command",
+		compose: `
+services:
+  app:
+    image: foo/app
+    command: echo 'foo`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: failed to import service app, reason: invalid command, reason: unterminated quote or escape in command "echo 'foo"`,
+	},
+	{
+		name: "Import - Invalid Subnet",
+		compose: `
+services:
+  app:
+    image: foo/app
+networks:
+  net1:
+    ipam:
+      config:
+        - subnet: foo`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: invalid ipam in network net1, reason: netip.ParsePrefix\("foo"\): no '/'`,
+	},
+	{
+		name: "Import - Invalid IP",
+		compose: `
+services:
+  app:
+    image: foo/app
+    networks:
+      net1:
+        ipv4_address: foo
+networks:
+  net1:
+    ipam:
+      config:
+        - subnet: 172.30.0.0/24`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: invalid IP foo for container app in network net1, reason: ParseAddr\("foo"\): unable to parse IP`,
+	},
+	{
+		name: "Import - No Free IPs",
+		compose: `
+services:
+  app1:
+    image: foo/app
+    networks: [net1]
+  app2:
+    image: foo/app
+    networks: [net1]
+  app3:
+    image: foo/app
+    networks: [net1]
+networks:
+  net1:
+    ipam:
+      config:
+        - subnet: 172.30.0.0/30`,
+		opts: &ImportOptions{
+			Group: "g1",
+		},
+		want: `failed to import compose file, reason: network net1 with subnet 172\.30\.0\.0/30 has no free IPs left`,
+	},
+}
+
+func TestImportErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range importErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := Import(strings.NewReader(tc.compose), tc.opts)
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "Import()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "Import()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package compose
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	containerBaseDirEnv = "$$CONTAINER_BASE_DIR$$"
+	containerDataDirEnv = "$$CONTAINER_DATA_DIR$$"
+)
+
+func (im *importer) importVolumes(svc *service, field string, n *yaml.Node) error {
+	items, err := sequence(n)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Kind == yaml.MappingNode {
+			err = im.longVolume(svc, field, item)
+		} else {
+			var spec string
+			if spec, err = stringValue(item); err == nil {
+				im.shortVolume(svc, field, spec)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shortVolume imports the compose short syntax for the volumes, i.e.
+// [source:]target[:options].
+func (im *importer) shortVolume(svc *service, field string, spec string) {
+	parts := strings.Split(spec, ":")
+	if len(parts) == 1 {
+		im.addUnsupported(field, fmt.Sprintf("anonymous volume %s is not supported", spec))
+		return
+	}
+	src, ok := im.mountSource(field, parts[0])
+	if !ok {
+		return
+	}
+
+	m := config.Mount{
+		Type: "bind",
+		Src:  src,
+		Dst:  parts[1],
+	}
+	if len(parts) > 2 {
+		for _, opt := range strings.Split(parts[2], ",") {
+			switch opt {
+			case "ro":
+				m.ReadOnly = true
+			case "rw":
+			default:
+				im.addUnsupported(field, fmt.Sprintf("mount option %s for %s is not supported", opt, m.Dst))
+			}
+		}
+	}
+	im.addMount(svc, m)
+}
+
+func (im *importer) longVolume(svc *service, field string, n *yaml.Node) error {
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return err
+	}
+
+	var volType, src string
+	m := config.Mount{}
+	for _, p := range pairs {
+		var err error
+		switch p.key {
+		case "type":
+			volType, err = scalar(p.value)
+		case "source":
+			src, err = stringValue(p.value)
+		case "target":
+			m.Dst, err = stringValue(p.value)
+		case "read_only":
+			m.ReadOnly, err = boolValue(p.value)
+		case "tmpfs":
+			var size *yaml.Node
+			if size, err = mappingValue(p.value, "size"); err == nil && size != nil {
+				m.TmpfsSize, err = tmpfsSize(size)
+			}
+		default:
+			im.addUnsupported(fmt.Sprintf("%s.%s", field, p.key), "not supported")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(m.Dst) == 0 {
+		return fmt.Errorf("line %d: volume target is missing", n.Line)
+	}
+
+	switch volType {
+	case "bind", "volume":
+		if len(src) == 0 {
+			im.addUnsupported(field, fmt.Sprintf("anonymous volume %s is not supported", m.Dst))
+			return nil
+		}
+		var ok bool
+		if m.Src, ok = im.mountSource(field, src); !ok {
+			return nil
+		}
+		m.Type = "bind"
+	case "tmpfs":
+		m.Type = "tmpfs"
+	default:
+		im.addUnsupported(field, fmt.Sprintf("volume type %s for %s is not supported", volType, m.Dst))
+		return nil
+	}
+	im.addMount(svc, m)
+	return nil
+}
+
+// mountSource returns the bind mount source for the compose volume
+// source. The relative paths are imported relative to the container base
+// directory and the named volumes are imported as directories within the
+// container data directory.
+func (im *importer) mountSource(field string, src string) (string, bool) {
+	switch {
+	case strings.HasPrefix(src, "/"):
+		return src, true
+	case src == "." || strings.HasPrefix(src, "./"):
+		return path.Join(containerBaseDirEnv, src), true
+	case strings.HasPrefix(src, "~") || strings.HasPrefix(src, ".."):
+		im.addUnsupported(field, fmt.Sprintf("volume source %s outside the compose project directory is not supported", src))
+		return "", false
+	default:
+		return path.Join(containerDataDirEnv, src), true
+	}
+}
+
+func (im *importer) importTmpfs(svc *service, field string, n *yaml.Node) error {
+	items, err := stringOrList(n)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		dst, opts, _ := strings.Cut(item, ":")
+		m := config.Mount{
+			Type: "tmpfs",
+			Dst:  dst,
+		}
+		if len(opts) > 0 {
+			for _, opt := range strings.Split(opts, ",") {
+				k, v, _ := strings.Cut(opt, "=")
+				if k != "size" {
+					im.addUnsupported(field, fmt.Sprintf("tmpfs option %s for %s is not supported", opt, dst))
+					continue
+				}
+				if m.TmpfsSize, err = units.RAMInBytes(v); err != nil {
+					return fmt.Errorf("invalid tmpfs size %s for %s", v, dst)
+				}
+			}
+		}
+		im.addMount(svc, m)
+	}
+	return nil
+}
+
+func tmpfsSize(n *yaml.Node) (int64, error) {
+	s, err := scalar(n)
+	if err != nil {
+		return 0, err
+	}
+	size, err := units.RAMInBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tmpfs size %s", s)
+	}
+	return size, nil
+}
+
+// addMount adds the mount to the container, naming the mount after the
+// destination path.
+func (im *importer) addMount(svc *service, m config.Mount) {
+	name := strings.ReplaceAll(strings.Trim(path.Clean(m.Dst), "/"), "/", "-")
+	if len(name) == 0 {
+		name = "root"
+	}
+	m.Name = name
+	for i := 2; ; i++ {
+		if _, found := svc.mountNames[m.Name]; !found {
+			break
+		}
+		m.Name = fmt.Sprintf("%s-%d", name, i)
+	}
+	svc.mountNames[m.Name] = struct{}{}
+	svc.config.Filesystem.Mounts = append(svc.config.Filesystem.Mounts, m)
+}
@@ -0,0 +1,274 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	mergeKey = "<<"
+)
+
+// keyValue is a key and an optional value from a compose field that can
+// be specified either as a mapping or as a list of key=value strings.
+type keyValue struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+// nodePair is a key and value node pair within a mapping node.
+type nodePair struct {
+	key   string
+	value *yaml.Node
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+func isNull(n *yaml.Node) bool {
+	n = resolveAlias(n)
+	return n == nil || (n.Kind == yaml.ScalarNode && n.Tag == "!!null")
+}
+
+// mappingPairs returns the key and value pairs within the mapping node
+// in the specified order, with the merge keys resolved. The keys
+// explicitly specified within the mapping take precedence over the keys
+// from the merged mappings.
+func mappingPairs(n *yaml.Node) ([]nodePair, error) {
+	n = resolveAlias(n)
+	if n.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping", n.Line)
+	}
+
+	var pairs []nodePair
+	var merged []nodePair
+	keys := map[string]struct{}{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Value == mergeKey {
+			m, err := mergedPairs(v)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, m...)
+			continue
+		}
+		keys[k.Value] = struct{}{}
+		pairs = append(pairs, nodePair{key: k.Value, value: resolveAlias(v)})
+	}
+	for _, p := range merged {
+		if _, found := keys[p.key]; found {
+			continue
+		}
+		keys[p.key] = struct{}{}
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+func mergedPairs(n *yaml.Node) ([]nodePair, error) {
+	n = resolveAlias(n)
+	if n.Kind != yaml.SequenceNode {
+		return mappingPairs(n)
+	}
+	var res []nodePair
+	for _, m := range n.Content {
+		pairs, err := mappingPairs(m)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, pairs...)
+	}
+	return res, nil
+}
+
+// mappingValue returns the value node for the specified key within the
+// mapping node, or nil if the key is not present.
+func mappingValue(n *yaml.Node, key string) (*yaml.Node, error) {
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pairs {
+		if p.key == key {
+			return p.value, nil
+		}
+	}
+	return nil, nil
+}
+
+func sequence(n *yaml.Node) ([]*yaml.Node, error) {
+	n = resolveAlias(n)
+	if n.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list", n.Line)
+	}
+	var res []*yaml.Node
+	for _, c := range n.Content {
+		res = append(res, resolveAlias(c))
+	}
+	return res, nil
+}
+
+func scalar(n *yaml.Node) (string, error) {
+	n = resolveAlias(n)
+	if n.Kind != yaml.ScalarNode {
+		return "", fmt.Errorf("line %d: expected a scalar value", n.Line)
+	}
+	return n.Value, nil
+}
+
+// stringValue returns the scalar value with the compose escaping of $
+// (i.e. $$) converted to the homelab config escaping (i.e. $$$$).
+func stringValue(n *yaml.Node) (string, error) {
+	s, err := scalar(n)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "$$", "$"), "$$", "$$$$"), nil
+}
+
+// hasInterpolation returns true if the value contains a compose variable
+// interpolation, i.e. ${VAR} or $VAR not escaped as $$.
+func hasInterpolation(s string) bool {
+	for i := 0; i+1 < len(s); i++ {
+		if s[i] != '$' {
+			continue
+		}
+		c := s[i+1]
+		if c == '$' {
+			i++
+			continue
+		}
+		if c == '{' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			return true
+		}
+	}
+	return false
+}
+
+func boolValue(n *yaml.Node) (bool, error) {
+	var b bool
+	if err := resolveAlias(n).Decode(&b); err != nil {
+		return false, fmt.Errorf("line %d: expected a boolean value", n.Line)
+	}
+	return b, nil
+}
+
+// stringOrList returns the values from a node that is either a single
+// string or a list of strings.
+func stringOrList(n *yaml.Node) ([]string, error) {
+	n = resolveAlias(n)
+	if n.Kind == yaml.ScalarNode {
+		s, err := stringValue(n)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+	return stringList(n)
+}
+
+func stringList(n *yaml.Node) ([]string, error) {
+	items, err := sequence(n)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, item := range items {
+		s, err := stringValue(item)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// keyValues returns the entries from a node that is either a mapping or
+// a list of strings, where each string is a key and a value separated by
+// sep, or just a key.
+func keyValues(n *yaml.Node, sep string) ([]keyValue, error) {
+	n = resolveAlias(n)
+	var res []keyValue
+	if n.Kind == yaml.MappingNode {
+		pairs, err := mappingPairs(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			if isNull(p.value) {
+				res = append(res, keyValue{key: p.key})
+				continue
+			}
+			v, err := stringValue(p.value)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, keyValue{key: p.key, value: v, hasValue: true})
+		}
+		return res, nil
+	}
+
+	items, err := stringList(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		k, v, found := strings.Cut(item, sep)
+		res = append(res, keyValue{key: k, value: v, hasValue: found})
+	}
+	return res, nil
+}
+
+// splitCommand splits a command specified as a string into the
+// arguments, honoring the single quotes, double quotes and backslash
+// escapes similar to a shell.
+func splitCommand(cmd string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range cmd {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in command %q", cmd)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,136 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func (im *importer) importPorts(svc *service, field string, n *yaml.Node) error {
+	items, err := sequence(n)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var hostIP, hostPort, ctPort, proto string
+		if item.Kind == yaml.MappingNode {
+			hostIP, hostPort, ctPort, proto, err = longPort(item)
+		} else {
+			var spec string
+			if spec, err = scalar(item); err == nil {
+				hostIP, hostPort, ctPort, proto = shortPort(spec)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(hostPort) == 0 {
+			im.addUnsupported(field, fmt.Sprintf("publishing container port %s on an ephemeral host port is not supported", ctPort))
+			continue
+		}
+		if len(hostIP) == 0 {
+			hostIP = defaultHostIP
+		}
+		if len(proto) == 0 {
+			proto = defaultProtocol
+		}
+
+		hostPorts, err := portRange(hostPort)
+		if err != nil {
+			return err
+		}
+		ctPorts, err := portRange(ctPort)
+		if err != nil {
+			return err
+		}
+		if len(hostPorts) != len(ctPorts) {
+			im.addUnsupported(field, fmt.Sprintf("publishing container ports %s on host ports %s of a different range size is not supported", ctPort, hostPort))
+			continue
+		}
+		for i := range ctPorts {
+			svc.config.Network.PublishedPorts = append(svc.config.Network.PublishedPorts, config.PublishedPort{
+				ContainerPort: strconv.Itoa(ctPorts[i]),
+				Protocol:      proto,
+				HostIP:        hostIP,
+				HostPort:      strconv.Itoa(hostPorts[i]),
+			})
+		}
+	}
+	return nil
+}
+
+// shortPort parses the compose short syntax for the ports, i.e.
+// [[host_ip:]host_port:]container_port[/protocol].
+func shortPort(spec string) (hostIP, hostPort, ctPort, proto string) {
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		spec, proto = spec[:i], spec[i+1:]
+	}
+	if strings.HasPrefix(spec, "[") {
+		if i := strings.Index(spec, "]:"); i >= 0 {
+			hostIP, spec = spec[1:i], spec[i+2:]
+		}
+	}
+
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+		ctPort = parts[0]
+	case 2:
+		hostPort, ctPort = parts[0], parts[1]
+	default:
+		hostIP = strings.Join(parts[:len(parts)-2], ":")
+		hostPort, ctPort = parts[len(parts)-2], parts[len(parts)-1]
+	}
+	return hostIP, hostPort, ctPort, proto
+}
+
+func longPort(n *yaml.Node) (hostIP, hostPort, ctPort, proto string, err error) {
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	for _, p := range pairs {
+		var v string
+		if v, err = scalar(p.value); err != nil {
+			return "", "", "", "", err
+		}
+		switch p.key {
+		case "target":
+			ctPort = v
+		case "published":
+			hostPort = v
+		case "host_ip":
+			hostIP = v
+		case "protocol":
+			proto = v
+		}
+	}
+	if len(ctPort) == 0 {
+		return "", "", "", "", fmt.Errorf("line %d: port target is missing", n.Line)
+	}
+	return hostIP, hostPort, ctPort, proto, nil
+}
+
+func portRange(r string) ([]int, error) {
+	start, end, isRange := strings.Cut(r, "-")
+	first, err := strconv.Atoi(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %s", r)
+	}
+	last := first
+	if isRange {
+		if last, err = strconv.Atoi(end); err != nil || last < first {
+			return nil, fmt.Errorf("invalid port range %s", r)
+		}
+	}
+
+	var res []int
+	for p := first; p <= last; p++ {
+		res = append(res, p)
+	}
+	return res, nil
+}
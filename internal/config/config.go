@@ -1,26 +1,34 @@
 package config
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"reflect"
 	"slices"
 	"strings"
 
 	"github.com/tuxdudehomelab/homelab/internal/cmdexec"
 	"github.com/tuxdudehomelab/homelab/internal/config/env"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
 	"github.com/tuxdudehomelab/homelab/internal/utils"
 	"gopkg.in/yaml.v3"
 )
 
 // Homelab represents the entire homelab deployment configuration.
 type Homelab struct {
-	Global     Global           `yaml:"global,omitempty" json:"global,omitempty"`
-	IPAM       IPAM             `yaml:"ipam,omitempty" json:"ipam,omitempty"`
-	Hosts      []Host           `yaml:"hosts,omitempty" json:"hosts,omitempty"`
-	Groups     []ContainerGroup `yaml:"groups,omitempty" json:"groups,omitempty"`
-	Containers []Container      `yaml:"containers,omitempty" json:"containers,omitempty"`
-	Ignore     []IgnoredConfig  `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+	Global             Global              `yaml:"global,omitempty" json:"global,omitempty"`
+	IPAM               IPAM                `yaml:"ipam,omitempty" json:"ipam,omitempty"`
+	HostGroups         []HostGroup         `yaml:"hostGroups,omitempty" json:"hostGroups,omitempty"`
+	Hosts              []Host              `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+	Groups             []ContainerGroup    `yaml:"groups,omitempty" json:"groups,omitempty"`
+	ContainerTemplates []ContainerTemplate `yaml:"containerTemplates,omitempty" json:"containerTemplates,omitempty"`
+	Containers         []Container         `yaml:"containers,omitempty" json:"containers,omitempty"`
+	Jobs               []Job               `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+	Ignore             []IgnoredConfig     `yaml:"ignore,omitempty" json:"ignore,omitempty"`
 }
 
 // HomelabGroupsOnly represents a minimal group name information only version
@@ -33,6 +41,7 @@ type HomelabGroupsOnly struct {
 // version of the homelab deployment configuration.
 type HomelabContainersOnly struct {
 	Containers []ContainerNameOnly `yaml:"containers,omitempty" json:"containers,omitempty"`
+	Jobs       []ContainerNameOnly `yaml:"jobs,omitempty" json:"jobs,omitempty"`
 }
 
 // HomelabNetworksOnly represents a minimal network name information only version
@@ -69,10 +78,13 @@ type ContainerModeNetworkNameOnly struct {
 // Global represents the configuration that will be applied
 // across the entire homelab deployment.
 type Global struct {
-	BaseDir   string          `yaml:"baseDir,omitempty" json:"baseDir,omitempty"`
-	Env       []ConfigEnv     `yaml:"env,omitempty" json:"env,omitempty"`
-	MountDefs []Mount         `yaml:"mountDefs,omitempty" json:"mountDefs,omitempty"`
-	Container GlobalContainer `yaml:"container,omitempty" json:"container,omitempty"`
+	BaseDir string      `yaml:"baseDir,omitempty" json:"baseDir,omitempty" configenv:"skip"`
+	Env     []ConfigEnv `yaml:"env,omitempty" json:"env,omitempty" configenv:"skip"`
+	// AllowOSEnv allows substituting $$ENV:NAME$$ with the value of the
+	// environment variable NAME of the homelab process.
+	AllowOSEnv bool            `yaml:"allowOSEnv,omitempty" json:"allowOSEnv,omitempty"`
+	MountDefs  []Mount         `yaml:"mountDefs,omitempty" json:"mountDefs,omitempty"`
+	Container  GlobalContainer `yaml:"container,omitempty" json:"container,omitempty"`
 }
 
 // GlobalContainer represents container related configuration that
@@ -92,7 +104,7 @@ type GlobalContainer struct {
 // substituted in all string field values read from the homelab
 // configuration file.
 type ConfigEnv struct {
-	Var          string   `yaml:"var,omitempty" json:"var,omitempty"`
+	Var          string   `yaml:"var,omitempty" json:"var,omitempty" merge:"key"`
 	Value        string   `yaml:"value,omitempty" json:"value,omitempty"`
 	ValueCommand []string `yaml:"valueCommand,omitempty" json:"valueCommand,omitempty"`
 }
@@ -133,10 +145,78 @@ type ContainerIP struct {
 	Container ContainerReference `yaml:"container,omitempty" json:"container,omitempty"`
 }
 
+// HostGroup represents a named group of hosts. Each entry in Hosts is
+// either a host name or a glob pattern matching the host names.
+type HostGroup struct {
+	Name  string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Hosts []string `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+}
+
+// HostSelector selects a set of hosts. Name is either a host name or a
+// glob pattern matching the host names (i.e. "*" matches all the hosts),
+// HostGroup is the name of a host group and Arch is the host
+// architecture. A host is selected only if it matches all of the
+// specified fields.
+type HostSelector struct {
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	HostGroup string `yaml:"hostGroup,omitempty" json:"hostGroup,omitempty"`
+	Arch      string `yaml:"arch,omitempty" json:"arch,omitempty"`
+}
+
 // Host represents the host specific information.
+//
+// Name, HostGroup and Arch select the hosts the config applies to, with
+// the same semantics as HostSelector. When more than one host config
+// applies to a host, all of them are applied in the order they are
+// specified.
+//
+// HostIP selects the host IP (i.e. HOST_IP) using an interface name, a
+// CIDR or a literal IP, overriding the selection in the homelab CLI config.
+//
+// Env is merged on top of the global config env (replacing the entries
+// with the same var name) when deploying on the host. Containers lists
+// the container config patches that are merged on top of the matching
+// containers (after resolving any container templates) when deploying
+// on the host, using the same merge semantics as Container.Extends.
+//
+// Systemd customizes the systemd units exported for the host.
 type Host struct {
 	Name              string               `yaml:"name,omitempty" json:"name,omitempty"`
+	HostGroup         string               `yaml:"hostGroup,omitempty" json:"hostGroup,omitempty"`
+	Arch              string               `yaml:"arch,omitempty" json:"arch,omitempty"`
+	Env               []ConfigEnv          `yaml:"env,omitempty" json:"env,omitempty"`
+	HostIP            host.IPSelector      `yaml:"hostIP,omitempty" json:"hostIP,omitempty"`
+	Docker            HostDocker           `yaml:"docker,omitempty" json:"docker,omitempty"`
 	AllowedContainers []ContainerReference `yaml:"allowedContainers,omitempty" json:"allowedContainers,omitempty"`
+	Containers        []Container          `yaml:"containers,omitempty" json:"containers,omitempty"`
+	Systemd           HostSystemd          `yaml:"systemd,omitempty" json:"systemd,omitempty"`
+}
+
+// HostSystemd represents the host specific settings for the exported
+// systemd units. RequiresMountsFor lists the paths (e.g. NFS mounts)
+// which must be mounted prior to starting the containers.
+type HostSystemd struct {
+	RequiresMountsFor []string `yaml:"requiresMountsFor,omitempty" json:"requiresMountsFor,omitempty"`
+}
+
+// HostDocker represents the docker daemon endpoint of a remote host.
+// Endpoint is either tcp://<host>:<port> (optionally using TLS) or
+// ssh://[user@]<host>[:port].
+type HostDocker struct {
+	Endpoint  string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	TLSCACert string `yaml:"tlsCACert,omitempty" json:"tlsCACert,omitempty"`
+	TLSCert   string `yaml:"tlsCert,omitempty" json:"tlsCert,omitempty"`
+	TLSKey    string `yaml:"tlsKey,omitempty" json:"tlsKey,omitempty"`
+}
+
+// RemoteEndpoint returns the remote docker daemon endpoint.
+func (h *HostDocker) RemoteEndpoint() *docker.RemoteEndpoint {
+	return &docker.RemoteEndpoint{
+		Endpoint:  h.Endpoint,
+		TLSCACert: h.TLSCACert,
+		TLSCert:   h.TLSCert,
+		TLSKey:    h.TLSKey,
+	}
 }
 
 // ContainerReference identifies a specific container part of a group.
@@ -148,8 +228,25 @@ type ContainerReference struct {
 // ContainerGroup represents a single logical container group, which is
 // basically a collection of containers within.
 type ContainerGroup struct {
-	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
-	Order int    `yaml:"order,omitempty" json:"order,omitempty"`
+	Name      string                 `yaml:"name,omitempty" json:"name,omitempty" configenv:"skip"`
+	Order     int                    `yaml:"order,omitempty" json:"order,omitempty"`
+	Config    ContainerConfigOptions `yaml:"config,omitempty" json:"config,omitempty" configenv:"skip"`
+	Container GroupContainer         `yaml:"container,omitempty" json:"container,omitempty"`
+}
+
+// GroupContainer represents container related configuration that will be
+// applied to all the containers within a group. These take precedence
+// over the global container config, and the container config in turn
+// takes precedence over these.
+type GroupContainer struct {
+	RestartPolicy ContainerRestartPolicy `yaml:"restartPolicy,omitempty" json:"restartPolicy,omitempty"`
+	DomainName    string                 `yaml:"domainName,omitempty" json:"domainName,omitempty"`
+	DNSServers    []string               `yaml:"dnsServers,omitempty" json:"dnsServers,omitempty"`
+	DNSOptions    []string               `yaml:"dnsOptions,omitempty" json:"dnsOptions,omitempty"`
+	DNSSearch     []string               `yaml:"dnsSearch,omitempty" json:"dnsSearch,omitempty"`
+	Env           []ContainerEnv         `yaml:"env,omitempty" json:"env,omitempty"`
+	Mounts        []Mount                `yaml:"mounts,omitempty" json:"mounts,omitempty"`
+	Labels        []Label                `yaml:"labels,omitempty" json:"labels,omitempty"`
 }
 
 // ContainerGroupNameOnly represents a minimal single logical container group that
@@ -158,10 +255,37 @@ type ContainerGroupNameOnly struct {
 	Name string `yaml:"name,omitempty" json:"name,omitempty"`
 }
 
+// ContainerTemplate represents a reusable named container configuration
+// that containers (or other templates) can extend from.
+type ContainerTemplate struct {
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	Container `yaml:",inline" json:",inline"`
+}
+
 // Container represents a single docker container.
+//
+// Extends lists the names of the container templates that are merged (in
+// the specified order) prior to merging the container's own config. While
+// merging, non-empty scalar values override the previous values, lists of
+// named entries (env, labels, mounts, sysctls and config env) replace the
+// entries with the same name and append the rest, and all other non-empty
+// lists replace the previous list entirely.
+//
+// Unset lists the fields (as their dotted paths, for instance
+// lifecycle.autoRemove) reset to their empty values prior to merging the
+// config, since the empty values do not override the previous values
+// otherwise. It can be used only along with Extends, or in the container
+// config patches of the hosts config.
+//
+// Placement lists the host selectors the container is allowed to run on,
+// in addition to the hosts allowing the container through the hosts
+// config.
 type Container struct {
-	Info       ContainerReference     `yaml:"info,omitempty" json:"info,omitempty"`
-	Config     ContainerConfigOptions `yaml:"config,omitempty" json:"config,omitempty"`
+	Info       ContainerReference     `yaml:"info,omitempty" json:"info,omitempty" configenv:"skip"`
+	Extends    []string               `yaml:"extends,omitempty" json:"extends,omitempty" configenv:"skip"`
+	Unset      []string               `yaml:"unset,omitempty" json:"unset,omitempty" configenv:"skip"`
+	Placement  []HostSelector         `yaml:"placement,omitempty" json:"placement,omitempty" configenv:"skip"`
+	Config     ContainerConfigOptions `yaml:"config,omitempty" json:"config,omitempty" configenv:"skip"`
 	Image      ContainerImage         `yaml:"image,omitempty" json:"image,omitempty"`
 	Metadata   ContainerMetadata      `yaml:"metadata,omitempty" json:"metadata,omitempty"`
 	Lifecycle  ContainerLifecycle     `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`
@@ -171,6 +295,24 @@ type Container struct {
 	Security   ContainerSecurity      `yaml:"security,omitempty" json:"security,omitempty"`
 	Health     ContainerHealth        `yaml:"health,omitempty" json:"health,omitempty"`
 	Runtime    ContainerRuntime       `yaml:"runtime,omitempty" json:"runtime,omitempty"`
+	Backup     ContainerBackup        `yaml:"backup,omitempty" json:"backup,omitempty"`
+}
+
+// Job represents a one-shot docker container run on a schedule, which is
+// removed once it exits. The job is named after its container reference
+// in the group/container format.
+//
+// Schedule is either a cron expression (minute, hour, day of month, month
+// and day of week), one of @hourly, @daily, @weekly, @monthly or @yearly,
+// or @every followed by a duration. Jobs without a schedule are run only
+// on demand. Timeout defaults to 1h, after which the job container is
+// killed. Retention is the number of most recent runs of the job retained
+// in the jobs state, and defaults to 10.
+type Job struct {
+	Container `yaml:",inline"`
+	Schedule  string `yaml:"schedule,omitempty" json:"schedule,omitempty" configenv:"skip"`
+	Timeout   string `yaml:"timeout,omitempty" json:"timeout,omitempty" configenv:"skip"`
+	Retention int    `yaml:"retention,omitempty" json:"retention,omitempty"`
 }
 
 // ContainerNameOnly represents a single docker container with just the
@@ -208,6 +350,47 @@ type ContainerLifecycle struct {
 	AutoRemove    bool                   `yaml:"autoRemove,omitempty" json:"autoRemove,omitempty"`
 	StopSignal    string                 `yaml:"stopSignal,omitempty" json:"stopSignal,omitempty"`
 	StopTimeout   int                    `yaml:"stopTimeout,omitempty" json:"stopTimeout,omitempty"`
+	// WaitFor is the readiness condition the start of the container
+	// blocks on, prior to starting the containers in the next order.
+	WaitFor ContainerWaitFor `yaml:"waitFor,omitempty" json:"waitFor,omitempty"`
+	// StartPostHook runs once the container is started and ready.
+	StartPostHook ContainerHook `yaml:"startPostHook,omitempty" json:"startPostHook,omitempty"`
+	// StopPreHook runs prior to stopping the running container.
+	StopPreHook ContainerHook `yaml:"stopPreHook,omitempty" json:"stopPreHook,omitempty"`
+	// StopPostHook runs once the container is stopped.
+	StopPostHook ContainerHook `yaml:"stopPostHook,omitempty" json:"stopPostHook,omitempty"`
+}
+
+// ContainerHook represents the command run at a stage of the docker
+// container lifecycle, either on the host or within the container when
+// InContainer is set. The command is passed the env variables describing
+// the container, and the commands run on the host use the
+// CONTAINER_SCRIPTS_DIR as the working directory if it exists.
+//
+// Timeout defaults to 60s and OnFailure is one of fail (the default,
+// failing the lifecycle operation) or ignore.
+type ContainerHook struct {
+	Command     []string `yaml:"command,omitempty" json:"command,omitempty"`
+	InContainer bool     `yaml:"inContainer,omitempty" json:"inContainer,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	OnFailure   string   `yaml:"onFailure,omitempty" json:"onFailure,omitempty"`
+}
+
+// ContainerWaitFor represents the readiness condition of the docker
+// container, i.e. one of the container turning healthy as per its health
+// check, the TCP Port accepting connections on the container IP, or the
+// File existing within the container. The Port is probed from the local
+// host, and hence requires the container to be connected to a bridge mode
+// network and is not supported while managing a remote host.
+//
+// Timeout defaults to 60s and Interval (between the readiness checks)
+// defaults to 1s.
+type ContainerWaitFor struct {
+	Healthy  bool   `yaml:"healthy,omitempty" json:"healthy,omitempty"`
+	Port     int    `yaml:"port,omitempty" json:"port,omitempty"`
+	File     string `yaml:"file,omitempty" json:"file,omitempty"`
+	Timeout  string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
 }
 
 // ContainerUser represents the user and group information for the
@@ -256,13 +439,65 @@ type ContainerHealth struct {
 	Timeout       string   `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 	StartPeriod   string   `yaml:"startPeriod,omitempty" json:"startPeriod,omitempty"`
 	StartInterval string   `yaml:"startInterval,omitempty" json:"startInterval,omitempty"`
+	// OnUnhealthy is the policy for healing the container once its
+	// health check reports it as unhealthy. Docker's restart policy
+	// applies only when the container exits, and ignores the health
+	// status altogether.
+	OnUnhealthy ContainerUnhealthyPolicy `yaml:"onUnhealthy,omitempty" json:"onUnhealthy,omitempty"`
+}
+
+// ContainerUnhealthyPolicy represents the action taken when the health
+// check of the docker container reports it as unhealthy.
+//
+// Action is one of none, restart or recreate, defaulting to none.
+// Backoff is the delay after a heal attempt before the next one, doubled
+// after every subsequent attempt. MaxAttempts is the number of heal
+// attempts made without the container turning healthy in between, after
+// which the container is considered crash looping and is no longer
+// healed.
+type ContainerUnhealthyPolicy struct {
+	Action      string `yaml:"action,omitempty" json:"action,omitempty"`
+	Backoff     string `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	MaxAttempts int    `yaml:"maxAttempts,omitempty" json:"maxAttempts,omitempty"`
+}
+
+// ContainerBackup represents the backup options for the docker
+// container. The backups archive the CONTAINER_DATA_DIR of the container
+// along with the named volumes mounted by the container.
+//
+// Destination is the directory holding the timestamped backup archives,
+// and must be set for backing up the container. Compression is either
+// gzip or zstd, defaulting to gzip. Includes lists the paths relative to
+// CONTAINER_DATA_DIR that are backed up, defaulting to the entire
+// directory, while Excludes lists the glob patterns matched against the
+// relative paths and the names of the files skipped, which are left
+// untouched while restoring the backups. Retention is the
+// number of most recent backup archives retained, defaulting to 7.
+//
+// The container is stopped while being backed up and started again
+// afterwards, unless KeepRunning is set, typically along with a
+// PreBackupHook that brings the data to a consistent state.
+type ContainerBackup struct {
+	Destination   string        `yaml:"destination,omitempty" json:"destination,omitempty"`
+	Compression   string        `yaml:"compression,omitempty" json:"compression,omitempty" configenv:"skip"`
+	Includes      []string      `yaml:"includes,omitempty" json:"includes,omitempty"`
+	Excludes      []string      `yaml:"excludes,omitempty" json:"excludes,omitempty"`
+	Retention     int           `yaml:"retention,omitempty" json:"retention,omitempty"`
+	KeepRunning   bool          `yaml:"keepRunning,omitempty" json:"keepRunning,omitempty"`
+	PreBackupHook ContainerHook `yaml:"preBackupHook,omitempty" json:"preBackupHook,omitempty"`
 }
 
 // ContainerRuntime represents the execution and runtime information
 // for the docker container.
+//
+// EnvFiles lists the env files in the dotenv format, with the relative
+// paths resolved against CONTAINER_CONFIGS_DIR. The env vars read from
+// the env files (in the listed order) take precedence over the global and
+// group container env, while Env takes precedence over all of them.
 type ContainerRuntime struct {
 	AttachToTty bool           `yaml:"tty,omitempty" json:"tty,omitempty"`
 	ShmSize     string         `yaml:"shmSize,omitempty" json:"shmSize,omitempty"`
+	EnvFiles    []string       `yaml:"envFiles,omitempty" json:"envFiles,omitempty"`
 	Env         []ContainerEnv `yaml:"env,omitempty" json:"env,omitempty"`
 	Entrypoint  []string       `yaml:"entrypoint,omitempty" json:"entrypoint,omitempty"`
 	Args        []string       `yaml:"args,omitempty" json:"args,omitempty"`
@@ -270,7 +505,7 @@ type ContainerRuntime struct {
 
 // Mount represents a filesystem mount.
 type Mount struct {
-	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	Name      string `yaml:"name,omitempty" json:"name,omitempty" merge:"key"`
 	Type      string `yaml:"type,omitempty" json:"type,omitempty"`
 	Src       string `yaml:"src,omitempty" json:"src,omitempty"`
 	Dst       string `yaml:"dst,omitempty" json:"dst,omitempty"`
@@ -296,15 +531,23 @@ type Device struct {
 
 // Sysctl represents a sysctl config to apply to a container.
 type Sysctl struct {
-	Key   string `yaml:"key,omitempty" json:"key,omitempty"`
+	Key   string `yaml:"key,omitempty" json:"key,omitempty" merge:"key"`
 	Value string `yaml:"value,omitempty" json:"value,omitempty"`
 }
 
 // ContainerEnv represents an environment variable and value pair that will be set
 // on the specified container.
+//
+// Exactly one of Value, Empty (to set an explicitly empty value) or
+// FromHost (to pass through the value of the env var with the same name
+// from the environment of the homelab process, if set) must be specified.
+// FromHost is not supported while managing a remote host, since the
+// homelab process runs on the local host instead.
 type ContainerEnv struct {
-	Var   string `yaml:"var,omitempty" json:"var,omitempty"`
-	Value string `yaml:"value,omitempty" json:"value,omitempty"`
+	Var      string `yaml:"var,omitempty" json:"var,omitempty" merge:"key"`
+	Value    string `yaml:"value,omitempty" json:"value,omitempty"`
+	Empty    bool   `yaml:"empty,omitempty" json:"empty,omitempty"`
+	FromHost bool   `yaml:"fromHost,omitempty" json:"fromHost,omitempty"`
 }
 
 // PublishedPort represents a port published from a container.
@@ -317,7 +560,7 @@ type PublishedPort struct {
 
 // Label represents a label set on a container.
 type Label struct {
-	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Name  string `yaml:"name,omitempty" json:"name,omitempty" merge:"key"`
 	Value string `yaml:"value,omitempty" json:"value,omitempty"`
 }
 
@@ -334,9 +577,18 @@ type ContainerRestartPolicy struct {
 type IgnoredConfig interface{}
 
 func (h *Homelab) Parse(ctx context.Context, r io.Reader) error {
-	dec := yaml.NewDecoder(r)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read homelab config, reason: %w", err)
+	}
+	data, err = secrets.DecryptConfig(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt homelab config, reason: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
 	dec.KnownFields(true)
-	err := dec.Decode(h)
+	err = dec.Decode(h)
 	if err != nil {
 		return fmt.Errorf("failed to parse homelab config, reason: %w", err)
 	}
@@ -384,6 +636,15 @@ func (h *HomelabContainersOnly) ListContainers() []string {
 	return containers
 }
 
+func (h *HomelabContainersOnly) ListJobs() []string {
+	var jobs []string
+	for _, j := range h.Jobs {
+		jobs = append(jobs, fmt.Sprintf("%s/%s", j.Info.Group, j.Info.Container))
+	}
+	slices.Sort(jobs)
+	return jobs
+}
+
 func (h *HomelabNetworksOnly) Parse(ctx context.Context, r io.Reader) error {
 	dec := yaml.NewDecoder(r)
 	dec.KnownFields(false)
@@ -405,72 +666,22 @@ func (h *HomelabNetworksOnly) ListNetworks() []string {
 	return networks
 }
 
+// ApplyConfigEnv applies the config env on all the string fields of the
+// global config, except the ones evaluated prior to the config env.
 func (g *Global) ApplyConfigEnv(env *env.ConfigEnvManager) {
-	for i, m := range g.MountDefs {
-		g.MountDefs[i].Src = env.Apply(m.Src)
-		g.MountDefs[i].Dst = env.Apply(m.Dst)
-	}
-	g.Container.DomainName = env.Apply(g.Container.DomainName)
-	for i, d := range g.Container.DNSSearch {
-		g.Container.DNSSearch[i] = env.Apply(d)
-	}
-	for i, e := range g.Container.Env {
-		g.Container.Env[i].Var = env.Apply(e.Var)
-		g.Container.Env[i].Value = env.Apply(e.Value)
-	}
-	for i, m := range g.Container.Mounts {
-		g.Container.Mounts[i].Src = env.Apply(m.Src)
-		g.Container.Mounts[i].Dst = env.Apply(m.Dst)
-	}
+	applyConfigEnv(env, reflect.ValueOf(g).Elem(), "")
 }
 
+// ApplyConfigEnv applies the config env on all the string fields of the
+// group config, except the ones evaluated prior to the config env.
+func (g *ContainerGroup) ApplyConfigEnv(env *env.ConfigEnvManager) {
+	applyConfigEnv(env, reflect.ValueOf(g).Elem(), "")
+}
+
+// ApplyConfigEnv applies the config env on all the string fields of the
+// container config, except the ones evaluated prior to the config env.
 func (c *Container) ApplyConfigEnv(env *env.ConfigEnvManager) {
-	for i, cmdArg := range c.Lifecycle.StartPreHook {
-		c.Lifecycle.StartPreHook[i] = env.Apply(cmdArg)
-	}
-	c.User.User = env.Apply(c.User.User)
-	c.User.PrimaryGroup = env.Apply(c.User.PrimaryGroup)
-	for i, g := range c.User.AdditionalGroups {
-		c.User.AdditionalGroups[i] = env.Apply(g)
-	}
-	for i, m := range c.Filesystem.Mounts {
-		c.Filesystem.Mounts[i].Src = env.Apply(m.Src)
-		c.Filesystem.Mounts[i].Dst = env.Apply(m.Dst)
-	}
-	for i, d := range c.Filesystem.Devices.Static {
-		c.Filesystem.Devices.Static[i].Src = env.Apply(d.Src)
-		c.Filesystem.Devices.Static[i].Dst = env.Apply(d.Dst)
-	}
-	for i, cmdArg := range c.Filesystem.Devices.DynamicCommand {
-		c.Filesystem.Devices.DynamicCommand[i] = env.Apply(cmdArg)
-	}
-	c.Network.HostName = env.Apply(c.Network.HostName)
-	c.Network.DomainName = env.Apply(c.Network.DomainName)
-	for i, d := range c.Network.DNSServers {
-		c.Network.DNSServers[i] = env.Apply(d)
-	}
-	for i, d := range c.Network.DNSOptions {
-		c.Network.DNSOptions[i] = env.Apply(d)
-	}
-	for i, d := range c.Network.DNSSearch {
-		c.Network.DNSSearch[i] = env.Apply(d)
-	}
-	for i, e := range c.Network.ExtraHosts {
-		c.Network.ExtraHosts[i] = env.Apply(e)
-	}
-	for i, p := range c.Network.PublishedPorts {
-		c.Network.PublishedPorts[i].ContainerPort = env.Apply(p.ContainerPort)
-		c.Network.PublishedPorts[i].Protocol = env.Apply(p.Protocol)
-		c.Network.PublishedPorts[i].HostIP = env.Apply(p.HostIP)
-		c.Network.PublishedPorts[i].HostPort = env.Apply(p.HostPort)
-	}
-	for i, e := range c.Runtime.Env {
-		c.Runtime.Env[i].Var = env.Apply(e.Var)
-		c.Runtime.Env[i].Value = env.Apply(e.Value)
-	}
-	for i, a := range c.Runtime.Args {
-		c.Runtime.Args[i] = env.Apply(a)
-	}
+	applyConfigEnv(env, reflect.ValueOf(c).Elem(), "")
 }
 
 func (c *Container) ApplyCmdExecutor(exec cmdexec.Executor) error {
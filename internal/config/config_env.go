@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/config/env"
+)
+
+const (
+	configEnvTag     = "configenv"
+	configEnvSkipTag = "skip"
+	yamlTag          = "yaml"
+)
+
+// applyConfigEnv applies the config env on every string within the value
+// (i.e. within structs, lists, maps and pointers), except for the struct
+// fields tagged with `configenv:"skip"` and the ones not read from the
+// config (i.e. tagged with `yaml:"-"`). The fields are identified by
+// their yaml paths while reporting the placeholders that could not be
+// resolved.
+func applyConfigEnv(env *env.ConfigEnvManager, val reflect.Value, path string) {
+	switch val.Kind() {
+	case reflect.String:
+		val.SetString(env.ApplyField(path, val.String()))
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			if !field.IsExported() || field.Tag.Get(configEnvTag) == configEnvSkipTag {
+				continue
+			}
+			name, inline, skip := yamlFieldName(field)
+			if skip {
+				continue
+			}
+			fieldPath := path
+			if !inline {
+				fieldPath = joinFieldPath(path, name)
+			}
+			applyConfigEnv(env, val.Field(i), fieldPath)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			applyConfigEnv(env, val.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		if val.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, k := range val.MapKeys() {
+			v := env.ApplyField(fmt.Sprintf("%s[%v]", path, k), val.MapIndex(k).String())
+			val.SetMapIndex(k, reflect.ValueOf(v).Convert(val.Type().Elem()))
+		}
+	case reflect.Pointer:
+		if !val.IsNil() {
+			applyConfigEnv(env, val.Elem(), path)
+		}
+	}
+}
+
+func yamlFieldName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get(yamlTag)
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if len(name) == 0 {
+		name = strings.ToLower(field.Name[:1]) + field.Name[1:]
+	}
+	return name, opts == "inline", false
+}
+
+func joinFieldPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", path, name)
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tuxdudehomelab/homelab/internal/config/env"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+)
+
+const (
+	configEnvTestPlaceholder = "$$MY_ENV$$"
+	configEnvTestValue       = "my-env"
+)
+
+var applyConfigEnvTests = []struct {
+	name  string
+	apply func(*env.ConfigEnvManager) any
+}{
+	{
+		name: "Apply Config Env - Global",
+		apply: func(e *env.ConfigEnvManager) any {
+			g := Global{}
+			fillStrings(reflect.ValueOf(&g).Elem())
+			g.ApplyConfigEnv(e)
+			return g
+		},
+	},
+	{
+		name: "Apply Config Env - Container Group",
+		apply: func(e *env.ConfigEnvManager) any {
+			g := ContainerGroup{}
+			fillStrings(reflect.ValueOf(&g).Elem())
+			g.ApplyConfigEnv(e)
+			return g
+		},
+	},
+	{
+		name: "Apply Config Env - Container",
+		apply: func(e *env.ConfigEnvManager) any {
+			c := Container{}
+			fillStrings(reflect.ValueOf(&c).Elem())
+			c.ApplyConfigEnv(e)
+			return c
+		},
+	},
+}
+
+// TestApplyConfigEnv verifies the config env is applied on every string
+// field reachable from the config, other than the ones opted out using the
+// configenv struct tag. It fails when a field of a kind unsupported by the
+// config env walker is added to the config.
+func TestApplyConfigEnv(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range applyConfigEnvTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutils.NewTestContext(&testutils.TestContextInfo{})
+			e := env.NewSystemConfigEnvManager(ctx).NewGlobalConfigEnvManager(ctx, "/base", env.EnvMap{"MY_ENV": configEnvTestValue}, env.EnvOrder{"MY_ENV"})
+			got := tc.apply(e)
+			if err := e.Err(); err != nil {
+				testhelpers.LogErrorNotNil(t, "ApplyConfigEnv()", tc.name, err)
+				return
+			}
+
+			for _, err := range checkStrings(reflect.ValueOf(got), "", false) {
+				testhelpers.LogCustom(t, "ApplyConfigEnv()", tc.name, err)
+			}
+		})
+	}
+}
+
+func fillStrings(val reflect.Value) {
+	switch val.Kind() {
+	case reflect.String:
+		val.SetString(configEnvTestPlaceholder)
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			if val.Type().Field(i).IsExported() {
+				fillStrings(val.Field(i))
+			}
+		}
+	case reflect.Slice:
+		val.Set(reflect.MakeSlice(val.Type(), 1, 1))
+		fillStrings(val.Index(0))
+	case reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			fillStrings(val.Index(i))
+		}
+	case reflect.Map:
+		val.Set(reflect.MakeMap(val.Type()))
+		elem := reflect.New(val.Type().Elem()).Elem()
+		fillStrings(elem)
+		val.SetMapIndex(reflect.ValueOf(configEnvTestValue).Convert(val.Type().Key()), elem)
+	case reflect.Pointer:
+		val.Set(reflect.New(val.Type().Elem()))
+		fillStrings(val.Elem())
+	}
+}
+
+func checkStrings(val reflect.Value, path string, skipped bool) []string {
+	var errs []string
+	switch val.Kind() {
+	case reflect.String:
+		want := configEnvTestValue
+		if skipped {
+			want = configEnvTestPlaceholder
+		}
+		if val.String() != want {
+			errs = append(errs, fmt.Sprintf("field %s got %q != want %q", path, val.String(), want))
+		}
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			skip := skipped || field.Tag.Get(configEnvTag) == configEnvSkipTag || field.Tag.Get(yamlTag) == "-"
+			errs = append(errs, checkStrings(val.Field(i), joinFieldPath(path, field.Name), skip)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			errs = append(errs, checkStrings(val.Index(i), fmt.Sprintf("%s[%d]", path, i), skipped)...)
+		}
+	case reflect.Map:
+		for _, k := range val.MapKeys() {
+			errs = append(errs, checkStrings(val.MapIndex(k), fmt.Sprintf("%s[%v]", path, k), skipped)...)
+		}
+	case reflect.Pointer:
+		if !val.IsNil() {
+			errs = append(errs, checkStrings(val.Elem(), path, skipped)...)
+		}
+	case reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		errs = append(errs, fmt.Sprintf("field %s has kind %s unsupported by the config env walker", path, val.Kind()))
+	}
+	return errs
+}
@@ -0,0 +1,51 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	configEnvRefIP            = "IP"
+	configEnvRefContainerName = "CONTAINER_NAME"
+	configEnvRefHostName      = "HOSTNAME"
+)
+
+// ContainerRefs resolves the references to other containers within the
+// config env. Containers are referenced as group/container.
+type ContainerRefs interface {
+	ContainerIP(network, container string) (string, error)
+	ContainerName(container string) (string, error)
+	ContainerHostName(container string) (string, error)
+}
+
+// WithContainerRefs returns a config env manager which additionally
+// resolves the references to other containers, i.e.
+// $$IP:<network>:<group>/<container>$$,
+// $$CONTAINER_NAME:<group>/<container>$$ and
+// $$HOSTNAME:<group>/<container>$$.
+func (c *ConfigEnvManager) WithContainerRefs(refs ContainerRefs) *ConfigEnvManager {
+	return &ConfigEnvManager{
+		env:  c.env,
+		refs: refs,
+	}
+}
+
+func isContainerRef(name string) bool {
+	return name == configEnvRefIP || name == configEnvRefContainerName || name == configEnvRefHostName
+}
+
+func (c *ConfigEnvManager) resolveContainerRef(kind, ref string) (string, error) {
+	switch kind {
+	case configEnvRefIP:
+		network, ct, found := strings.Cut(ref, ":")
+		if !found || len(network) == 0 {
+			return "", fmt.Errorf("IP reference %s must be of the form <network>:<group>/<container>", ref)
+		}
+		return c.refs.ContainerIP(network, ct)
+	case configEnvRefContainerName:
+		return c.refs.ContainerName(ref)
+	default:
+		return c.refs.ContainerHostName(ref)
+	}
+}
@@ -3,15 +3,47 @@ package env
 import (
 	"context"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 )
 
+const (
+	// A literal $$ is written as $$$$.
+	configEnvEscapedDelim = "$$$$"
+	configEnvDelim        = "$$"
+	// Separates the default value in $$VAR:-default$$.
+	configEnvDefaultSep = ":-"
+	// Separates the argument in $$NAME:arg$$.
+	configEnvArgSep = ":"
+	// The charset of the names that can be used within the placeholders.
+	configEnvVarNamePattern = `[A-Za-z_][A-Za-z0-9_.-]*`
+)
+
+var (
+	configEnvOSEnvPrefix = "ENV"
+
+	configEnvPlaceholderRegex = regexp.MustCompile(`^\$\$(` + configEnvVarNamePattern + `)(:[^$]*)?\$\$`)
+	configEnvVarNameRegex     = regexp.MustCompile(`^` + configEnvVarNamePattern + `$`)
+)
+
+// IsValidVarName returns true if the config env var name can be
+// referenced using a placeholder.
+func IsValidVarName(name string) bool {
+	return configEnvVarNameRegex.MatchString(name)
+}
+
 type configEnv struct {
 	env         EnvMap
 	envKeyOrder EnvOrder
-	replacer    *strings.Replacer
+	facts       *envFacts
+	osEnv       bool
 }
 
+// configEnvResolver resolves the placeholder with the specified name and
+// the optional argument, returning false if it is unknown.
+type configEnvResolver func(name, arg string) (string, bool)
+
 func newConfigEnv(ctx context.Context, env EnvMap, order EnvOrder) *configEnv {
 	c := configEnv{}
 	return c.override(ctx, env, order)
@@ -24,6 +56,8 @@ func (c *configEnv) override(ctx context.Context, override EnvMap, order EnvOrde
 	res := configEnv{
 		env:         EnvMap{},
 		envKeyOrder: EnvOrder{},
+		facts:       c.facts,
+		osEnv:       c.osEnv,
 	}
 	for _, k := range c.envKeyOrder {
 		v := c.env[k]
@@ -41,16 +75,77 @@ func (c *configEnv) override(ctx context.Context, override EnvMap, order EnvOrde
 		}
 		res.env[sk] = newVal
 	}
-	var repl []string
-	for _, k := range res.envKeyOrder {
-		repl = append(repl, k, res.env[k])
-	}
-	res.replacer = strings.NewReplacer(repl...)
 	return &res
 }
 
 func (c *configEnv) apply(input string) string {
-	return c.replacer.Replace(input)
+	res, _ := expandConfigEnv(input, c.resolve)
+	return res
+}
+
+func (c *configEnv) resolve(name, arg string) (string, bool) {
+	if len(arg) == 0 {
+		if v, found := c.env[configEnvSearchKey(name)]; found {
+			return v, true
+		}
+	}
+	if name == configEnvOSEnvPrefix && c.osEnv && len(arg) > 0 {
+		return os.LookupEnv(arg)
+	}
+	return c.facts.resolve(name, arg)
+}
+
+// expandConfigEnv substitutes the placeholders of the form $$NAME$$,
+// $$NAME:arg$$ and $$NAME:-default$$ within the input using the resolver,
+// and replaces $$$$ with a literal $$. The values substituted are not
+// expanded any further. Placeholders which cannot be resolved and do not
+// specify a default are retained as-is, and are also returned.
+func expandConfigEnv(input string, resolve configEnvResolver) (string, []string) {
+	var res strings.Builder
+	var unresolved []string
+	for {
+		idx := strings.Index(input, configEnvDelim)
+		if idx < 0 {
+			res.WriteString(input)
+			break
+		}
+		res.WriteString(input[:idx])
+		input = input[idx:]
+
+		if m := configEnvPlaceholderRegex.FindStringSubmatch(input); m != nil {
+			input = input[len(m[0]):]
+			if v, found := resolvePlaceholder(m[1], strings.TrimPrefix(m[2], configEnvArgSep), resolve); found {
+				res.WriteString(v)
+			} else {
+				res.WriteString(m[0])
+				unresolved = append(unresolved, m[0])
+			}
+			continue
+		}
+		if strings.HasPrefix(input, configEnvEscapedDelim) {
+			res.WriteString(configEnvDelim)
+			input = input[len(configEnvEscapedDelim):]
+			continue
+		}
+		res.WriteString(input[:1])
+		input = input[1:]
+	}
+	return res.String(), unresolved
+}
+
+func resolvePlaceholder(name, arg string, resolve configEnvResolver) (string, bool) {
+	def, hasDef := "", false
+	if strings.HasPrefix(arg, "-") {
+		// $$NAME:-default$$
+		def, hasDef, arg = arg[1:], true, ""
+	} else if a, d, found := strings.Cut(arg, configEnvDefaultSep); found {
+		// $$NAME:arg:-default$$
+		def, hasDef, arg = d, true, a
+	}
+	if v, found := resolve(name, arg); found {
+		return v, true
+	}
+	return def, hasDef
 }
 
 func configEnvSearchKey(env string) string {
@@ -3,6 +3,9 @@ package env
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
 
 	"github.com/tuxdudehomelab/homelab/internal/host"
 	"github.com/tuxdudehomelab/homelab/internal/user"
@@ -10,8 +13,16 @@ import (
 
 var (
 	configEnvHostIP                = "HOST_IP"
+	configEnvHostIPv6              = "HOST_IPV6"
 	configEnvHostName              = "HOST_NAME"
 	configEnvHumanFriendlyHostName = "HUMAN_FRIENDLY_HOST_NAME"
+	configEnvHostArch              = "HOST_ARCH"
+	configEnvHostNumCPUs           = "HOST_NUM_CPUS"
+	configEnvHostTimezone          = "HOST_TIMEZONE"
+	configEnvHostKernel            = "HOST_KERNEL"
+	configEnvDockerVersion         = "DOCKER_VERSION"
+	configEnvDockerGroupID         = "DOCKER_GROUP_ID"
+	configEnvGroupIDPrefix         = "GROUP_ID"
 	configEnvUserName              = "USER_NAME"
 	configEnvUserID                = "USER_ID"
 	configEnvUserPrimaryGroupName  = "USER_PRIMARY_GROUP_NAME"
@@ -22,10 +33,14 @@ var (
 	configEnvContainerConfigsDir   = "CONTAINER_CONFIGS_DIR"
 	configEnvContainerDatasDir     = "CONTAINER_DATA_DIR"
 	configEnvContainerScriptsDir   = "CONTAINER_SCRIPTS_DIR"
+
+	dockerGroupName = "docker"
 )
 
 type ConfigEnvManager struct {
-	env *configEnv
+	env  *configEnv
+	refs ContainerRefs
+	err  error
 }
 
 type EnvMap map[string]string
@@ -33,8 +48,10 @@ type EnvOrder []string
 
 func NewSystemConfigEnvManager(ctx context.Context) *ConfigEnvManager {
 	envMap, envOrder := defaultEnv(ctx)
+	e := newConfigEnv(ctx, envMap, envOrder)
+	e.facts = newEnvFacts(ctx)
 	return &ConfigEnvManager{
-		env: newConfigEnv(ctx, envMap, envOrder),
+		env: e,
 	}
 }
 
@@ -56,6 +73,24 @@ func (c *ConfigEnvManager) NewGlobalConfigEnvManager(ctx context.Context, homela
 	}
 }
 
+func (c *ConfigEnvManager) NewGroupConfigEnvManager(ctx context.Context, containerGroupBaseDir string, env EnvMap, order EnvOrder) *ConfigEnvManager {
+	// Apply env variables specific to this group that are relevant
+	// within the group config.
+	newEnv := c.env.override(
+		ctx,
+		EnvMap{
+			configEnvContainerGroupBaseDir: containerGroupBaseDir,
+		},
+		EnvOrder{
+			configEnvContainerGroupBaseDir,
+		},
+	)
+	// Apply other env variables which were read from the group config.
+	return &ConfigEnvManager{
+		env: newEnv.override(ctx, env, order),
+	}
+}
+
 func (c *ConfigEnvManager) NewContainerConfigEnvManager(ctx context.Context, containerGroupBaseDir, containerBaseDir string, env EnvMap, order EnvOrder) *ConfigEnvManager {
 	// Apply env variables specific to this container that are relevant
 	// within the container config.
@@ -64,9 +99,9 @@ func (c *ConfigEnvManager) NewContainerConfigEnvManager(ctx context.Context, con
 		EnvMap{
 			configEnvContainerGroupBaseDir: containerGroupBaseDir,
 			configEnvContainerBaseDir:      containerBaseDir,
-			configEnvContainerConfigsDir:   containerConfigsDir(containerBaseDir),
-			configEnvContainerDatasDir:     containerDataDir(containerBaseDir),
-			configEnvContainerScriptsDir:   containerScriptsDir(containerBaseDir),
+			configEnvContainerConfigsDir:   ContainerConfigsDir(containerBaseDir),
+			configEnvContainerDatasDir:     ContainerDataDir(containerBaseDir),
+			configEnvContainerScriptsDir:   ContainerScriptsDir(containerBaseDir),
 		},
 		EnvOrder{
 			configEnvContainerGroupBaseDir,
@@ -81,40 +116,140 @@ func (c *ConfigEnvManager) NewContainerConfigEnvManager(ctx context.Context, con
 	}
 }
 
+// WithOSEnv returns a config env manager which additionally substitutes
+// $$ENV:NAME$$ with the value of the environment variable NAME of the
+// homelab process.
+func (c *ConfigEnvManager) WithOSEnv() *ConfigEnvManager {
+	e := *c.env
+	e.osEnv = true
+	return &ConfigEnvManager{
+		env:  &e,
+		refs: c.refs,
+	}
+}
+
+// Apply applies the config env on the input.
 func (c *ConfigEnvManager) Apply(input string) string {
-	return c.env.apply(input)
+	res, _ := c.apply(input)
+	return res
+}
+
+// ApplyField applies the config env on the value of the specified field,
+// recording the first failure to resolve a placeholder which is then
+// reported by Err().
+func (c *ConfigEnvManager) ApplyField(field string, input string) string {
+	res, err := c.apply(input)
+	if err != nil && c.err == nil {
+		c.err = fmt.Errorf("%w in field %s", err, field)
+	}
+	return res
+}
+
+// Err returns the error encountered while applying the config env on the
+// first field with a placeholder which could not be resolved, if any.
+func (c *ConfigEnvManager) Err() error {
+	return c.err
+}
+
+func (c *ConfigEnvManager) apply(input string) (string, error) {
+	res, unresolved := expandConfigEnv(input, func(name, arg string) (string, bool) {
+		if v, found := c.env.resolve(name, arg); found {
+			return v, true
+		}
+		if c.refs != nil && isContainerRef(name) {
+			if v, err := c.resolveContainerRef(name, arg); err == nil {
+				return v, true
+			}
+		}
+		return "", false
+	})
+	if len(unresolved) == 0 {
+		return res, nil
+	}
+
+	// Report the reason for the failure to resolve a container reference
+	// or a fact about the host.
+	m := configEnvPlaceholderRegex.FindStringSubmatch(unresolved[0])
+	if err := c.env.facts.unresolvedReason(m[1]); err != nil {
+		return res, fmt.Errorf("failed to resolve %s, reason: %w", unresolved[0], err)
+	}
+	if c.refs != nil && isContainerRef(m[1]) {
+		if _, err := c.resolveContainerRef(m[1], strings.TrimPrefix(m[2], configEnvArgSep)); err != nil {
+			return res, fmt.Errorf("failed to resolve %s, reason: %w", unresolved[0], err)
+		}
+	}
+	return res, fmt.Errorf("unresolved config env placeholder %s", unresolved[0])
 }
 
 func defaultEnv(ctx context.Context) (EnvMap, EnvOrder) {
 	h := host.MustHostInfo(ctx)
 	u := user.MustUserInfo(ctx)
-	return EnvMap{
-			configEnvHostIP:                h.IP.String(),
-			configEnvHostName:              h.HostName,
-			configEnvHumanFriendlyHostName: h.HumanFriendlyHostName,
-			configEnvUserName:              u.User.Username,
-			configEnvUserID:                u.User.Uid,
-			configEnvUserPrimaryGroupName:  u.PrimaryGroup.Name,
-			configEnvUserPrimaryGroupID:    u.PrimaryGroup.Gid,
-		}, EnvOrder{
-			configEnvHostIP,
-			configEnvHostName,
-			configEnvHumanFriendlyHostName,
-			configEnvUserName,
-			configEnvUserID,
-			configEnvUserPrimaryGroupName,
-			configEnvUserPrimaryGroupID,
+	envMap := EnvMap{
+		configEnvHostIP:                h.IP.String(),
+		configEnvHostName:              h.HostName,
+		configEnvHumanFriendlyHostName: h.HumanFriendlyHostName,
+		configEnvHostArch:              h.Arch,
+		configEnvHostNumCPUs:           strconv.Itoa(h.NumCPUs),
+		configEnvUserName:              u.User.Username,
+		configEnvUserID:                u.User.Uid,
+		configEnvUserPrimaryGroupName:  u.PrimaryGroup.Name,
+		configEnvUserPrimaryGroupID:    u.PrimaryGroup.Gid,
+	}
+	envOrder := EnvOrder{
+		configEnvHostIP,
+		configEnvHostName,
+		configEnvHumanFriendlyHostName,
+		configEnvHostArch,
+		configEnvHostNumCPUs,
+		configEnvUserName,
+		configEnvUserID,
+		configEnvUserPrimaryGroupName,
+		configEnvUserPrimaryGroupID,
+	}
+
+	add := func(name string, val string) {
+		if len(val) > 0 {
+			envMap[name] = val
+			envOrder = append(envOrder, name)
+		}
+	}
+	add(configEnvHostTimezone, h.Timezone)
+	add(configEnvHostKernel, h.Kernel)
+	// The group of the local host doesn't apply to any other host.
+	if !h.Synthetic {
+		if gid, found := lookupGroupID(ctx, u, dockerGroupName); found {
+			add(configEnvDockerGroupID, gid)
 		}
+	}
+
+	addIP := func(name string, ip netip.Addr) {
+		if ip.IsValid() {
+			envMap[name] = ip.String()
+			envOrder = append(envOrder, name)
+		}
+	}
+	addIP(configEnvHostIPv6, h.IPv6)
+	for _, iface := range h.Interfaces {
+		addIP(fmt.Sprintf("%s_%s", configEnvHostIP, iface.Name), iface.IPv4)
+		addIP(fmt.Sprintf("%s_%s", configEnvHostIPv6, iface.Name), iface.IPv6)
+	}
+	return envMap, envOrder
 }
 
-func containerConfigsDir(containerBaseDir string) string {
+// ContainerConfigsDir returns the CONTAINER_CONFIGS_DIR of the container
+// with the specified base directory.
+func ContainerConfigsDir(containerBaseDir string) string {
 	return fmt.Sprintf("%s/configs", containerBaseDir)
 }
 
-func containerDataDir(containerBaseDir string) string {
+// ContainerDataDir returns the CONTAINER_DATA_DIR of the container with
+// the specified base directory.
+func ContainerDataDir(containerBaseDir string) string {
 	return fmt.Sprintf("%s/data", containerBaseDir)
 }
 
-func containerScriptsDir(containerBaseDir string) string {
+// ContainerScriptsDir returns the CONTAINER_SCRIPTS_DIR of the container
+// with the specified base directory.
+func ContainerScriptsDir(containerBaseDir string) string {
 	return fmt.Sprintf("%s/scripts", containerBaseDir)
 }
@@ -3,24 +3,39 @@ package env
 import (
 	"bytes"
 	"fmt"
+	"net/netip"
+	"os"
 	"testing"
 
 	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
 	logger "github.com/tuxdudehomelab/homelab/internal/log"
 	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
 	"github.com/tuxdudehomelab/homelab/internal/testutils"
 )
 
 var systemConfigEnvManagerApplyTests = []struct {
-	name  string
-	input string
-	want  string
+	name   string
+	docker bool
+	input  string
+	want   string
 }{
 	{
 		name:  "System Config Env Manager - Apply - HOST_IP",
 		input: "foo-$$HOST_IP$$-bar",
 		want:  "foo-10.76.77.78-bar",
 	},
+	{
+		name:  "System Config Env Manager - Apply - HOST_IPV6",
+		input: "foo-$$HOST_IPV6$$-bar",
+		want:  "foo-fd00:76:77::78-bar",
+	},
+	{
+		name:  "System Config Env Manager - Apply - Interface IPs",
+		input: "$$HOST_IP_eth0$$,$$HOST_IPV6_eth0$$,$$HOST_IP_wlan0$$,$$HOST_IPV6_wlan0$$",
+		want:  "10.76.77.78,fd00:76:77::78,192.168.76.78,$$HOST_IPV6_wlan0$$",
+	},
 	{
 		name:  "System Config Env Manager - Apply - HOST_NAME",
 		input: "$$HOST_NAME$$",
@@ -51,6 +66,52 @@ var systemConfigEnvManagerApplyTests = []struct {
 		input: "foo123-$$USER_PRIMARY_GROUP_ID$$-bar123",
 		want:  "foo123-44444-bar123",
 	},
+	{
+		name:  "System Config Env Manager - Apply - HOST_ARCH",
+		input: "foo-$$HOST_ARCH$$-bar",
+		want:  "foo-amd64-bar",
+	},
+	{
+		name:  "System Config Env Manager - Apply - HOST_NUM_CPUS",
+		input: "--cpus=$$HOST_NUM_CPUS$$",
+		want:  "--cpus=32",
+	},
+	{
+		name:  "System Config Env Manager - Apply - HOST_TIMEZONE",
+		input: "TZ=$$HOST_TIMEZONE$$",
+		want:  "TZ=America/Los_Angeles",
+	},
+	{
+		name:  "System Config Env Manager - Apply - HOST_KERNEL",
+		input: "$$HOST_KERNEL$$",
+		want:  "6.1.0-76-fake",
+	},
+	{
+		name:   "System Config Env Manager - Apply - DOCKER_VERSION",
+		docker: true,
+		input:  "docker-$$DOCKER_VERSION$$",
+		want:   "docker-27.3.1",
+	},
+	{
+		name:  "System Config Env Manager - Apply - DOCKER_VERSION Without Docker Client",
+		input: "docker-$$DOCKER_VERSION$$",
+		want:  "docker-$$DOCKER_VERSION$$",
+	},
+	{
+		name:  "System Config Env Manager - Apply - DOCKER_GROUP_ID",
+		input: "$$DOCKER_GROUP_ID$$",
+		want:  "44447",
+	},
+	{
+		name:  "System Config Env Manager - Apply - GROUP_ID",
+		input: "$$GROUP_ID:render$$,$$GROUP_ID:fakegroup2$$,$$GROUP_ID:docker$$",
+		want:  "44448,44445,44447",
+	},
+	{
+		name:  "System Config Env Manager - Apply - Unknown GROUP_ID",
+		input: "$$GROUP_ID:video$$",
+		want:  "$$GROUP_ID:video$$",
+	},
 	{
 		name:  "System Config Env Manager - Apply - Multiple",
 		input: "foo-$$HOST_NAME$$-$$HOST_IP$$-$$USER_NAME$$-$$USER_PRIMARY_GROUP_NAME$$",
@@ -67,7 +128,11 @@ func TestSystemConfigEnvManagerApply(t *testing.T) {
 			t.Parallel()
 
 			l := testutils.NewCapturingTestLogger(zzzlog.LvlInfo, new(bytes.Buffer))
-			ctx := testutils.NewTestContext(&testutils.TestContextInfo{})
+			ctxInfo := &testutils.TestContextInfo{}
+			if tc.docker {
+				ctxInfo.DockerHost = fakedocker.NewEmptyFakeDockerHost()
+			}
+			ctx := testutils.NewTestContext(ctxInfo)
 			ctx = logger.WithLogger(ctx, l)
 
 			env := NewSystemConfigEnvManager(ctx)
@@ -216,6 +281,81 @@ func TestGlobalConfigEnvManagerApply(t *testing.T) {
 	}
 }
 
+var groupConfigEnvManagerApplyTests = []struct {
+	name           string
+	globalEnvMap   EnvMap
+	globalEnvOrder EnvOrder
+	groupEnvMap    EnvMap
+	groupEnvOrder  EnvOrder
+	input          string
+	want           string
+}{
+	{
+		name: "Group Config Env Manager - Apply - CONTAINER_GROUP_BASE_DIR",
+		globalEnvMap: EnvMap{
+			"MY_ENV_1": "my-env-1",
+		},
+		globalEnvOrder: EnvOrder{
+			"MY_ENV_1",
+		},
+		groupEnvMap: EnvMap{
+			"MY_GROUP_ENV_1": "my-group-env-1",
+		},
+		groupEnvOrder: EnvOrder{
+			"MY_GROUP_ENV_1",
+		},
+		input: "$$CONTAINER_GROUP_BASE_DIR$$/foo/bar/baz",
+		want:  "/home/foobar/dummy-base-dir/g1/foo/bar/baz",
+	},
+	{
+		name: "Group Config Env Manager - Apply - Override Global Env",
+		globalEnvMap: EnvMap{
+			"MY_ENV_1": "my-env-1",
+			"MY_ENV_2": "my-env-2",
+		},
+		globalEnvOrder: EnvOrder{
+			"MY_ENV_1",
+			"MY_ENV_2",
+		},
+		groupEnvMap: EnvMap{
+			"MY_ENV_2": "my-group-env-2",
+		},
+		groupEnvOrder: EnvOrder{
+			"MY_ENV_2",
+		},
+		input: "$$MY_ENV_1$$-$$MY_ENV_2$$-$$HOST_NAME$$",
+		want:  "my-env-1-my-group-env-2-fakehost",
+	},
+	{
+		name:  "Group Config Env Manager - Apply - Container Env Unavailable",
+		input: "$$CONTAINER_BASE_DIR$$/foo",
+		want:  "$$CONTAINER_BASE_DIR$$/foo",
+	},
+}
+
+func TestGroupConfigEnvManagerApply(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range groupConfigEnvManagerApplyTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := testutils.NewCapturingTestLogger(zzzlog.LvlInfo, new(bytes.Buffer))
+			ctx := testutils.NewTestContext(&testutils.TestContextInfo{})
+			ctx = logger.WithLogger(ctx, l)
+
+			env := NewSystemConfigEnvManager(ctx)
+			env = env.NewGlobalConfigEnvManager(ctx, "/home/foobar/dummy-base-dir", tc.globalEnvMap, tc.globalEnvOrder)
+			env = env.NewGroupConfigEnvManager(ctx, "/home/foobar/dummy-base-dir/g1", tc.groupEnvMap, tc.groupEnvOrder)
+			got := env.Apply(tc.input)
+			if got != tc.want {
+				testhelpers.LogCustom(t, "GroupConfigEnvManager.Apply()", tc.name, fmt.Sprintf("got '%s' != want '%s'", got, tc.want))
+			}
+		})
+	}
+}
+
 var containerConfigEnvManagerApplyTests = []struct {
 	name              string
 	globalEnvMap      EnvMap
@@ -525,3 +665,86 @@ func TestContainerConfigEnvManagerApply(t *testing.T) {
 		})
 	}
 }
+
+var configEnvManagerApplyFieldErrorTests = []struct {
+	name      string
+	input     string
+	synthetic bool
+	want      string
+}{
+	{
+		name:  "Config Env Manager - Apply Field - Misspelled Env",
+		input: "$$CONTAINR_DATA_DIR$$/foo",
+		want:  `unresolved config env placeholder \$\$CONTAINR_DATA_DIR\$\$ in field my.field`,
+	},
+	{
+		name:  "Config Env Manager - Apply Field - OS Env Without Opt In",
+		input: "$$ENV:HOME$$",
+		want:  `unresolved config env placeholder \$\$ENV:HOME\$\$ in field my.field`,
+	},
+	{
+		name:  "Config Env Manager - Apply Field - Unknown Group",
+		input: "$$GROUP_ID:video$$",
+		want:  `unresolved config env placeholder \$\$GROUP_ID:video\$\$ in field my.field`,
+	},
+	{
+		name:      "Config Env Manager - Apply Field - Group ID On Synthetic Host",
+		input:     "$$GROUP_ID:docker$$",
+		synthetic: true,
+		want:      `failed to resolve \$\$GROUP_ID:docker\$\$, reason: the group IDs of the host h2 cannot be looked up on the local host, set them explicitly in the config env instead in field my.field`,
+	},
+	{
+		name:      "Config Env Manager - Apply Field - Docker Group ID On Synthetic Host",
+		input:     "$$DOCKER_GROUP_ID$$",
+		synthetic: true,
+		want:      `failed to resolve \$\$DOCKER_GROUP_ID\$\$, reason: the group IDs of the host h2 cannot be looked up on the local host, set them explicitly in the config env instead in field my.field`,
+	},
+}
+
+func TestConfigEnvManagerApplyFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range configEnvManagerApplyFieldErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutils.NewTestContext(&testutils.TestContextInfo{})
+			if tc.synthetic {
+				ctx = host.WithHostInfo(ctx, host.NewSyntheticHostInfo(host.MustHostInfo(ctx), "h2", "", netip.Addr{}))
+			}
+			env := NewSystemConfigEnvManager(ctx)
+			got := env.ApplyField("my.field", tc.input)
+			if !testhelpers.CmpDiff(t, "ConfigEnvManager.ApplyField()", tc.name, "apply result", tc.input, got) {
+				return
+			}
+
+			gotErr := env.Err()
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "ConfigEnvManager.ApplyField()", tc.name, tc.want)
+				return
+			}
+			if !testhelpers.RegexMatch(t, "ConfigEnvManager.ApplyField()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
+
+func TestConfigEnvManagerWithOSEnv(t *testing.T) {
+	t.Parallel()
+
+	tc := "Config Env Manager - With OS Env"
+	ctx := testutils.NewTestContext(&testutils.TestContextInfo{})
+	env := NewSystemConfigEnvManager(ctx).WithOSEnv()
+
+	input := "$$ENV:PATH$$;$$ENV:HOMELAB_UNDEFINED_ENV:-foo$$;$$HOST_NAME$$"
+	want := fmt.Sprintf("%s;foo;fakehost", os.Getenv("PATH"))
+	got := env.ApplyField("my.field", input)
+	if !testhelpers.CmpDiff(t, "ConfigEnvManager.ApplyField()", tc, "apply result", want, got) {
+		return
+	}
+	if gotErr := env.Err(); gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "ConfigEnvManager.ApplyField()", tc, gotErr)
+	}
+}
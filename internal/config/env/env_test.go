@@ -178,6 +178,46 @@ var configEnvTests = []struct {
 		},
 	},
 
+	{
+		name: "Config Env - apply - Defaults",
+		test: func(t *testing.T, ctx context.Context, env *configEnv, tc string) {
+			input := "$$ENV1:-foo$$;$$ENV4:-bar$$;$$ENV5:-$$;$$ENV6:-a:b-c$$"
+			want := "my-env-1;bar;;a:b-c"
+
+			got := env.apply(input)
+			if !testhelpers.CmpDiff(t, "configEnv.apply()", tc, "apply result", want, got) {
+				return
+			}
+		},
+	},
+	{
+		name: "Config Env - apply - Escaped",
+		test: func(t *testing.T, ctx context.Context, env *configEnv, tc string) {
+			input := "$$$$ENV1$$$$;$$ENV1$$$$$$$$ENV2$$;pa$$$$word;$$ENV2$$$$"
+			want := "$$ENV1$$;my-env-1$$my-env-2;pa$$word;my-env-2$$"
+
+			got := env.apply(input)
+			if !testhelpers.CmpDiff(t, "configEnv.apply()", tc, "apply result", want, got) {
+				return
+			}
+		},
+	},
+	{
+		name: "Config Env - apply - Unresolved",
+		test: func(t *testing.T, ctx context.Context, env *configEnv, tc string) {
+			input := "$$ENV1$$;$$ENV4$$;$$ENV:HOME$$;$ENV2$;$$ENV2"
+			want := "my-env-1;$$ENV4$$;$$ENV:HOME$$;$ENV2$;$$ENV2"
+			wantUnresolved := []string{"$$ENV4$$", "$$ENV:HOME$$"}
+
+			got, gotUnresolved := expandConfigEnv(input, env.resolve)
+			if !testhelpers.CmpDiff(t, "expandConfigEnv()", tc, "apply result", want, got) {
+				return
+			}
+			if !testhelpers.CmpDiff(t, "expandConfigEnv()", tc, "unresolved placeholders", wantUnresolved, gotUnresolved) {
+				return
+			}
+		},
+	},
 	{
 		name: "Config Env - override - Unequal Lengths Between Override Map And Order",
 		test: func(t *testing.T, ctx context.Context, env *configEnv, tc string) {
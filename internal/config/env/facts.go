@@ -0,0 +1,107 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+	"github.com/tuxdudehomelab/homelab/internal/user"
+)
+
+// envFacts resolves the env variables whose values are expensive to
+// determine, only when they are actually referenced.
+type envFacts struct {
+	lookupGroup       func(name string) (string, bool)
+	lookupDockerVer   func() (string, bool)
+	dockerVersionOnce sync.Once
+	dockerVersion     string
+	dockerVersionOk   bool
+	// groupLookupErr is the reason the groups cannot be looked up, if
+	// any.
+	groupLookupErr error
+}
+
+func newEnvFacts(ctx context.Context) *envFacts {
+	u := user.MustUserInfo(ctx)
+	f := envFacts{
+		lookupGroup: func(name string) (string, bool) {
+			return lookupGroupID(ctx, u, name)
+		},
+		lookupDockerVer: func() (string, bool) {
+			return lookupDockerVersion(ctx)
+		},
+		groupLookupErr: groupLookupError(ctx),
+	}
+	return &f
+}
+
+func (f *envFacts) resolve(name, arg string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+
+	switch {
+	case name == configEnvDockerVersion && len(arg) == 0:
+		f.dockerVersionOnce.Do(func() {
+			f.dockerVersion, f.dockerVersionOk = f.lookupDockerVer()
+		})
+		return f.dockerVersion, f.dockerVersionOk
+	case name == configEnvGroupIDPrefix && len(arg) > 0:
+		if f.groupLookupErr != nil {
+			return "", false
+		}
+		return f.lookupGroup(arg)
+	default:
+		return "", false
+	}
+}
+
+// unresolvedReason returns the reason the env variable could not be
+// resolved, if known.
+func (f *envFacts) unresolvedReason(name string) error {
+	if f == nil {
+		return nil
+	}
+	if name == configEnvGroupIDPrefix || name == configEnvDockerGroupID {
+		return f.groupLookupErr
+	}
+	return nil
+}
+
+// groupLookupError returns an error if the groups of the host cannot be
+// looked up, since the host info doesn't describe the local host (for
+// instance with --as-host or --target-host).
+func groupLookupError(ctx context.Context) error {
+	h := host.MustHostInfo(ctx)
+	if !h.Synthetic {
+		return nil
+	}
+	return fmt.Errorf("the group IDs of the host %s cannot be looked up on the local host, set them explicitly in the config env instead", h.HostName)
+}
+
+func lookupGroupID(ctx context.Context, u *user.UserInfo, name string) (string, bool) {
+	if u.LookupGroup == nil {
+		return "", false
+	}
+	g, err := u.LookupGroup(name)
+	if err != nil {
+		log(ctx).Debugf("Unable to look up the group %s on the host, reason: %v", name, err)
+		return "", false
+	}
+	return g.Gid, true
+}
+
+func lookupDockerVersion(ctx context.Context) (string, bool) {
+	client, found := docker.APIClientFromContext(ctx)
+	if !found {
+		return "", false
+	}
+	info, err := client.Info(ctx)
+	if err != nil {
+		log(ctx).Warnf("Unable to determine the docker version, reason: %v", err)
+		return "", false
+	}
+	return info.ServerVersion, len(info.ServerVersion) > 0
+}
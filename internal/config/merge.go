@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/deepcopy"
+)
+
+const (
+	mergeTag    = "merge"
+	mergeKeyTag = "key"
+)
+
+// The fields that cannot be unset, since they identify the container or
+// drive the merge itself.
+var nonUnsettableFields = map[string]bool{
+	"info":    true,
+	"extends": true,
+	"unset":   true,
+}
+
+// MergeContainer merges the src container config on top of the dst
+// container config. Non-empty scalar values in src override the values
+// in dst. Lists of structs with a field tagged as the merge key are merged
+// by replacing the entries in dst that have the same key as the ones in
+// src and appending the remaining entries. All other non-empty lists in
+// src replace the corresponding lists in dst. The fields listed in
+// src.Unset are reset in dst prior to merging, and the merged config
+// doesn't retain the fields to unset.
+func MergeContainer(dst *Container, src *Container) {
+	d := reflect.ValueOf(dst).Elem()
+	for _, path := range src.Unset {
+		if f, found := fieldByPath(d, path); found {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+	mergeValue(d, reflect.ValueOf(deepcopy.MustCopy(*src)))
+	dst.Unset = nil
+}
+
+// ValidateUnset returns an error if any of the fields to unset is not a
+// field of the container config which can be unset.
+func ValidateUnset(paths []string) error {
+	c := reflect.ValueOf(&Container{}).Elem()
+	for _, path := range paths {
+		if nonUnsettableFields[path] {
+			return fmt.Errorf("field %s cannot be unset", path)
+		}
+		if _, found := fieldByPath(c, path); !found {
+			return fmt.Errorf("unset field %s is not a field of the container config", path)
+		}
+	}
+	return nil
+}
+
+// fieldByPath returns the field of the struct with the dotted path made
+// of the yaml names of the fields.
+func fieldByPath(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.IsExported() && strings.Split(f.Tag.Get("yaml"), ",")[0] == name {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+func mergeValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if !src.Type().Field(i).IsExported() {
+				continue
+			}
+			mergeValue(dst.Field(i), src.Field(i))
+		}
+	case reflect.Slice:
+		if src.Len() == 0 {
+			return
+		}
+		keyIdx, hasKey := mergeKeyField(src.Type().Elem())
+		if !hasKey {
+			dst.Set(src)
+			return
+		}
+		mergeKeyedSlice(dst, src, keyIdx)
+	default:
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
+
+func mergeKeyedSlice(dst, src reflect.Value, keyIdx int) {
+	result := reflect.MakeSlice(dst.Type(), dst.Len(), dst.Len()+src.Len())
+	reflect.Copy(result, dst)
+	for i := 0; i < src.Len(); i++ {
+		elem := src.Index(i)
+		replaced := false
+		for j := 0; j < result.Len(); j++ {
+			if result.Index(j).Field(keyIdx).Interface() == elem.Field(keyIdx).Interface() {
+				result.Index(j).Set(elem)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = reflect.Append(result, elem)
+		}
+	}
+	dst.Set(result)
+}
+
+func mergeKeyField(t reflect.Type) (int, bool) {
+	if t.Kind() != reflect.Struct {
+		return 0, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get(mergeTag) == mergeKeyTag {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+var mergeContainerTests = []struct {
+	name string
+	dst  Container
+	src  Container
+	want Container
+}{
+	{
+		name: "Merge Container - Scalars",
+		dst: Container{
+			Image: ContainerImage{
+				Image:         "foo/bar",
+				SkipImagePull: true,
+			},
+			Lifecycle: ContainerLifecycle{
+				Order:      3,
+				StopSignal: "SIGTERM",
+			},
+		},
+		src: Container{
+			Image: ContainerImage{
+				Image: "foo/baz",
+			},
+			Lifecycle: ContainerLifecycle{
+				StopTimeout: 10,
+			},
+		},
+		want: Container{
+			Image: ContainerImage{
+				Image:         "foo/baz",
+				SkipImagePull: true,
+			},
+			Lifecycle: ContainerLifecycle{
+				Order:       3,
+				StopSignal:  "SIGTERM",
+				StopTimeout: 10,
+			},
+		},
+	},
+	{
+		name: "Merge Container - Keyed Lists",
+		dst: Container{
+			Config: ContainerConfigOptions{
+				Env: []ConfigEnv{
+					{
+						Var:   "FOO",
+						Value: "foo",
+					},
+				},
+			},
+			Metadata: ContainerMetadata{
+				Labels: []Label{
+					{
+						Name:  "l1",
+						Value: "v1",
+					},
+					{
+						Name:  "l2",
+						Value: "v2",
+					},
+				},
+			},
+			Security: ContainerSecurity{
+				Sysctls: []Sysctl{
+					{
+						Key:   "net.ipv4.ip_forward",
+						Value: "1",
+					},
+				},
+			},
+		},
+		src: Container{
+			Config: ContainerConfigOptions{
+				Env: []ConfigEnv{
+					{
+						Var:          "FOO",
+						ValueCommand: []string{"/foo"},
+					},
+				},
+			},
+			Metadata: ContainerMetadata{
+				Labels: []Label{
+					{
+						Name:  "l3",
+						Value: "v3",
+					},
+					{
+						Name:  "l1",
+						Value: "v1-new",
+					},
+				},
+			},
+		},
+		want: Container{
+			Config: ContainerConfigOptions{
+				Env: []ConfigEnv{
+					{
+						Var:          "FOO",
+						ValueCommand: []string{"/foo"},
+					},
+				},
+			},
+			Metadata: ContainerMetadata{
+				Labels: []Label{
+					{
+						Name:  "l1",
+						Value: "v1-new",
+					},
+					{
+						Name:  "l2",
+						Value: "v2",
+					},
+					{
+						Name:  "l3",
+						Value: "v3",
+					},
+				},
+			},
+			Security: ContainerSecurity{
+				Sysctls: []Sysctl{
+					{
+						Key:   "net.ipv4.ip_forward",
+						Value: "1",
+					},
+				},
+			},
+		},
+	},
+	{
+		name: "Merge Container - Unkeyed Lists",
+		dst: Container{
+			Network: ContainerNetwork{
+				DNSServers: []string{"1.1.1.1"},
+				PublishedPorts: []PublishedPort{
+					{
+						ContainerPort: "80",
+						HostPort:      "8080",
+					},
+				},
+			},
+			Security: ContainerSecurity{
+				CapAdd: []string{"NET_ADMIN"},
+			},
+		},
+		src: Container{
+			Network: ContainerNetwork{
+				DNSServers: []string{"8.8.8.8", "8.8.4.4"},
+			},
+			Security: ContainerSecurity{
+				CapAdd: []string{},
+			},
+		},
+		want: Container{
+			Network: ContainerNetwork{
+				DNSServers: []string{"8.8.8.8", "8.8.4.4"},
+				PublishedPorts: []PublishedPort{
+					{
+						ContainerPort: "80",
+						HostPort:      "8080",
+					},
+				},
+			},
+			Security: ContainerSecurity{
+				CapAdd: []string{"NET_ADMIN"},
+			},
+		},
+	},
+	{
+		name: "Merge Container - Unset",
+		dst: Container{
+			Image: ContainerImage{
+				Image:         "foo/bar",
+				SkipImagePull: true,
+			},
+			Lifecycle: ContainerLifecycle{
+				Order:       3,
+				AutoRemove:  true,
+				StopTimeout: 10,
+			},
+			Network: ContainerNetwork{
+				DNSServers: []string{"8.8.8.8"},
+			},
+		},
+		src: Container{
+			Unset: []string{
+				"image.skipImagePull",
+				"lifecycle.autoRemove",
+				"lifecycle.stopTimeout",
+				"network.dnsServers",
+			},
+			Lifecycle: ContainerLifecycle{
+				StopTimeout: 20,
+			},
+		},
+		want: Container{
+			Image: ContainerImage{
+				Image: "foo/bar",
+			},
+			Lifecycle: ContainerLifecycle{
+				Order:       3,
+				StopTimeout: 20,
+			},
+		},
+	},
+}
+
+func TestMergeContainer(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range mergeContainerTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.dst
+			MergeContainer(&got, &tc.src)
+			if !testhelpers.CmpDiff(t, "MergeContainer()", tc.name, "merged container", tc.want, got) {
+				return
+			}
+		})
+	}
+}
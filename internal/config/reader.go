@@ -10,9 +10,43 @@ import (
 	"path/filepath"
 
 	"github.com/TwiN/deepmerge"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
 )
 
 func MergedConfigsReader(ctx context.Context, path string) (io.Reader, error) {
+	files, err := ListConfigFiles(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []byte
+	for _, p := range files {
+		configFile, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read homelab config file %s, reason: %w", p, err)
+		}
+		// Custom yaml tags are lost while deep merging, hence normalize
+		// the secrets prior to merging.
+		configFile, err = secrets.NormalizeConfig(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize secrets in config file %s, reason: %w", p, err)
+		}
+		result, err = deepmerge.YAML(result, configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deep merge config file %s, reason: %w", p, err)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no homelab configs found in %s", path)
+	}
+
+	return bytes.NewReader(result), nil
+}
+
+// ListConfigFiles returns the list of homelab config files found under
+// the specified configs path, in the order they are merged.
+func ListConfigFiles(ctx context.Context, path string) ([]string, error) {
 	pathStat, err := os.Stat(path)
 	if err != nil {
 		return nil, fmt.Errorf("os.Stat() failed on homelab configs path, reason: %w", err)
@@ -21,7 +55,7 @@ func MergedConfigsReader(ctx context.Context, path string) (io.Reader, error) {
 		return nil, fmt.Errorf("homelab configs path %s must be a directory", path)
 	}
 
-	var result []byte
+	var files []string
 	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("failed to read contents of directory %s, reason: %w", path, err)
@@ -34,14 +68,7 @@ func MergedConfigsReader(ctx context.Context, path string) (io.Reader, error) {
 		}
 
 		log(ctx).Debugf("Picked up homelab config: %s", p)
-		configFile, err := os.ReadFile(p)
-		if err != nil {
-			return fmt.Errorf("failed to read homelab config file %s, reason: %w", p, err)
-		}
-		result, err = deepmerge.YAML(result, configFile)
-		if err != nil {
-			return fmt.Errorf("failed to deep merge config file %s, reason: %w", p, err)
-		}
+		files = append(files, p)
 		return nil
 	})
 	log(ctx).DebugEmpty()
@@ -49,10 +76,5 @@ func MergedConfigsReader(ctx context.Context, path string) (io.Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	if len(result) == 0 {
-		return nil, fmt.Errorf("no homelab configs found in %s", path)
-	}
-
-	return bytes.NewReader(result), nil
+	return files, nil
 }
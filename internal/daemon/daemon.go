@@ -0,0 +1,451 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	devents "github.com/docker/docker/api/types/events"
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	// Delay before subscribing to the docker events again after the
+	// subscription ends, for instance when the docker daemon restarts.
+	eventsRetryDelay = 5 * time.Second
+	// Interval between the checks for the scheduled jobs due to run.
+	jobsCheckInterval = time.Minute
+)
+
+// Options customizes the daemon.
+type Options struct {
+	// Deployment is the deployment initially reconciled by the daemon.
+	Deployment *deployment.Deployment
+	// ConfigsPath is the homelab configs directory watched for changes.
+	ConfigsPath string
+	// Reload builds the deployment again from the homelab configs, and
+	// is invoked whenever the configs change.
+	Reload func(ctx context.Context) (*deployment.Deployment, error)
+	// RestartUnhealthy restarts the containers reported as unhealthy by
+	// their health check, unless they have an unhealthy policy which is
+	// always honored.
+	RestartUnhealthy bool
+	// SettleDelay is the time to wait for further events and config
+	// changes before reconciling.
+	SettleDelay time.Duration
+	// ResyncInterval is the interval between the periodic reconciles of
+	// all the containers, and disables them when zero.
+	ResyncInterval time.Duration
+	// Once reconciles all the containers once and returns, instead of
+	// watching for the docker events and the config changes.
+	Once bool
+	// JobsStateFile is the file tracking the job runs, used to run the
+	// scheduled jobs once they are due. The jobs aren't run when empty.
+	JobsStateFile string
+}
+
+type daemon struct {
+	opts       *Options
+	dc         *docker.Client
+	dep        *deployment.Deployment
+	containers map[string]*deployment.Container
+	// stopped tracks the containers that were stopped intentionally,
+	// which are not repaired until they are started again.
+	stopped utils.StringSet
+	// died tracks the containers that exited on their own.
+	died utils.StringSet
+	// restarted tracks the running containers restarted by the daemon,
+	// whose stop event must not be treated as an intentional stop.
+	restarted utils.StringSet
+	// heal tracks the heal attempts of the containers with an unhealthy
+	// policy.
+	heal deployment.HealState
+	// pending tracks the containers to reconcile after the settle delay.
+	pending         utils.StringSet
+	pendingNetworks bool
+	pendingAll      bool
+	pendingReload   bool
+	// jobsRunning indicates the due jobs are being run by a goroutine.
+	jobsRunning bool
+}
+
+// Run keeps repairing the drift of the containers allowed to run on the
+// host from the deployment until the context is canceled. The containers
+// that were removed are recreated, the containers that exited on their
+// own are restarted, the unhealthy containers are healed as per their
+// unhealthy policy or restarted if requested, and the missing networks
+// are created again. The containers stopped
+// intentionally are left alone until they are started again. The
+// deployment is reloaded whenever the configs change, starting the newly
+// added containers and recreating the running containers whose config
+// changed. The scheduled jobs are run once they are due, if the jobs
+// state file is specified.
+func Run(ctx context.Context, opts *Options) {
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	d := &daemon{
+		opts:      opts,
+		dc:        dc,
+		stopped:   utils.StringSet{},
+		died:      utils.StringSet{},
+		restarted: utils.StringSet{},
+		heal:      deployment.HealState{},
+		pending:   utils.StringSet{},
+	}
+	d.setDeployment(opts.Deployment)
+
+	if opts.Once {
+		d.pendingAll = true
+		d.reconcile(ctx)
+		d.runJobs(ctx, d.dueJobs(ctx, time.Now()))
+		return
+	}
+
+	// Subscribe to the events prior to the initial reconcile, to avoid
+	// missing any drift in between.
+	events, errs := dc.WatchEvents(ctx)
+	configChanges, err := watchConfigs(ctx, opts.ConfigsPath)
+	if err != nil {
+		log(ctx).Warnf("Not reloading the configs on changes, reason: %v", err)
+	}
+
+	log(ctx).Infof("Homelab daemon started, reconciling %d containers", len(d.containers))
+	d.pendingAll = true
+	d.reconcile(ctx)
+
+	var resync <-chan time.Time
+	if opts.ResyncInterval > 0 {
+		t := time.NewTicker(opts.ResyncInterval)
+		defer t.Stop()
+		resync = t.C
+	}
+	var settle, retry, healRetry <-chan time.Time
+	healRetry = d.nextHealRetry()
+
+	var jobsCheck <-chan time.Time
+	jobsDone := make(chan struct{})
+	if len(opts.JobsStateFile) > 0 {
+		t := time.NewTicker(jobsCheckInterval)
+		defer t.Stop()
+		jobsCheck = t.C
+		d.startDueJobs(ctx, jobsDone)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if d.jobsRunning {
+				// The interrupted jobs still remove their containers, wait
+				// for them to be removed before exiting.
+				<-jobsDone
+			}
+			log(ctx).Infof("Homelab daemon stopped")
+			return
+		case msg := <-events:
+			if d.handleEvent(ctx, msg) {
+				settle = time.After(opts.SettleDelay)
+			}
+		case err := <-errs:
+			if ctx.Err() != nil {
+				continue
+			}
+			log(ctx).Warnf("Docker events subscription ended, subscribing again in %s, reason: %v", eventsRetryDelay, err)
+			events, errs = nil, nil
+			retry = time.After(eventsRetryDelay)
+		case <-retry:
+			retry = nil
+			events, errs = dc.WatchEvents(ctx)
+			// The events might have been missed while not subscribed.
+			d.pendingAll = true
+			settle = time.After(opts.SettleDelay)
+		case <-configChanges:
+			d.pendingReload = true
+			settle = time.After(opts.SettleDelay)
+		case <-resync:
+			d.pendingAll = true
+			settle = time.After(opts.SettleDelay)
+		case <-settle:
+			settle = nil
+			if d.pendingReload {
+				d.reload(ctx)
+			}
+			d.reconcile(ctx)
+			healRetry = d.nextHealRetry()
+		case <-healRetry:
+			healRetry = nil
+			d.pendingAll = true
+			d.reconcile(ctx)
+			healRetry = d.nextHealRetry()
+		case <-jobsCheck:
+			d.startDueJobs(ctx, jobsDone)
+		case <-jobsDone:
+			d.jobsRunning = false
+		}
+	}
+}
+
+func (d *daemon) setDeployment(dep *deployment.Deployment) {
+	d.dep = dep
+	d.containers = map[string]*deployment.Container{}
+	for _, ct := range dep.AllowedContainers() {
+		d.containers[ct.Name()] = ct
+	}
+	for _, set := range []utils.StringSet{d.stopped, d.died, d.restarted, d.pending} {
+		for name := range set {
+			if _, found := d.containers[name]; !found {
+				delete(set, name)
+			}
+		}
+	}
+	for name := range d.heal {
+		if _, found := d.containers[name]; !found {
+			delete(d.heal, name)
+		}
+	}
+}
+
+// handleEvent records the drift indicated by the docker event, and
+// returns true if a reconcile is required.
+func (d *daemon) handleEvent(ctx context.Context, msg devents.Message) bool {
+	name := msg.Actor.Attributes["name"]
+	switch msg.Type {
+	case devents.ContainerEventType:
+		if _, found := d.containers[name]; !found {
+			return false
+		}
+		switch msg.Action {
+		case devents.ActionStart:
+			delete(d.stopped, name)
+			delete(d.died, name)
+			delete(d.restarted, name)
+		case devents.ActionStop:
+			if _, found := d.restarted[name]; found {
+				delete(d.restarted, name)
+			} else {
+				d.stopped[name] = struct{}{}
+			}
+			delete(d.died, name)
+		case devents.ActionDie:
+			d.died[name] = struct{}{}
+		case devents.ActionDestroy, devents.ActionHealthStatusUnhealthy, devents.ActionHealthStatusHealthy:
+		default:
+			return false
+		}
+		log(ctx).Debugf("Received event %s for container %s", msg.Action, name)
+		d.pending[name] = struct{}{}
+		return true
+	case devents.NetworkEventType:
+		if _, found := d.dep.Networks[name]; !found || msg.Action != devents.ActionDestroy {
+			return false
+		}
+		log(ctx).Debugf("Received event %s for network %s", msg.Action, name)
+		d.pendingNetworks = true
+		return true
+	}
+	return false
+}
+
+// reload builds the deployment again from the configs, and recreates the
+// running containers whose config changed. The newly added containers
+// are started by the reconcile that follows.
+func (d *daemon) reload(ctx context.Context) {
+	d.pendingReload = false
+	dep, err := d.opts.Reload(ctx)
+	if err != nil {
+		log(ctx).Errorf("Failed to reload the configs, continuing with the previous deployment, reason: %v", err)
+		return
+	}
+	log(ctx).Infof("Reloaded the configs")
+
+	prev := d.containers
+	changed := dep.ChangedContainers(d.dep)
+	d.setDeployment(dep)
+	d.pendingAll = true
+
+	for name := range prev {
+		if _, found := d.containers[name]; !found {
+			log(ctx).Warnf("Container %s is no longer part of the deployment, leaving it untouched", name)
+		}
+	}
+	for _, ct := range changed {
+		drift, err := ct.Drift(ctx, d.dc)
+		if err != nil {
+			log(ctx).Errorf("Failed to determine the state of container %s, reason: %v", ct.Name(), err)
+			continue
+		}
+		if drift == deployment.ContainerDriftNone || drift == deployment.ContainerDriftUnhealthy {
+			log(ctx).Infof("Recreating container %s since its config changed", ct.Name())
+			d.restart(ctx, ct)
+		}
+	}
+}
+
+// reconcile repairs the drift of the pending containers and networks,
+// or all of them if requested.
+func (d *daemon) reconcile(ctx context.Context) {
+	if d.pendingAll || d.pendingNetworks {
+		if _, err := d.dep.CreateMissingNetworks(ctx, d.dc); err != nil {
+			log(ctx).Errorf("Failed to create the missing networks, reason: %v", err)
+		}
+	}
+	for _, ct := range d.dep.AllowedContainers() {
+		if _, found := d.pending[ct.Name()]; found || d.pendingAll {
+			d.reconcileContainer(ctx, ct)
+		}
+	}
+	d.pending = utils.StringSet{}
+	d.pendingNetworks = false
+	d.pendingAll = false
+}
+
+func (d *daemon) reconcileContainer(ctx context.Context, ct *deployment.Container) {
+	name := ct.Name()
+	if _, found := d.stopped[name]; found {
+		log(ctx).Debugf("Not reconciling container %s since it was stopped intentionally", name)
+		return
+	}
+
+	drift, err := ct.Drift(ctx, d.dc)
+	if err != nil {
+		log(ctx).Errorf("Failed to determine the state of container %s, reason: %v", name, err)
+		return
+	}
+
+	// The containers with an unhealthy policy are healed as per the
+	// policy, which also tracks them turning healthy again.
+	if ct.HealAction() != deployment.HealActionNone {
+		if drift == deployment.ContainerDriftNone || drift == deployment.ContainerDriftUnhealthy {
+			d.healContainer(ctx, ct)
+			return
+		}
+	}
+
+	switch drift {
+	case deployment.ContainerDriftMissing:
+		log(ctx).Infof("Starting container %s since it is missing", name)
+	case deployment.ContainerDriftStopped:
+		if _, found := d.died[name]; !found {
+			return
+		}
+		log(ctx).Infof("Restarting container %s since it exited", name)
+	case deployment.ContainerDriftUnhealthy:
+		if !d.opts.RestartUnhealthy {
+			log(ctx).Warnf("Container %s is unhealthy", name)
+			return
+		}
+		log(ctx).Infof("Restarting container %s since it is unhealthy", name)
+	default:
+		return
+	}
+	d.restart(ctx, ct)
+}
+
+// restart starts the container afresh, which purges any existing
+// container under the same name.
+func (d *daemon) restart(ctx context.Context, ct *deployment.Container) {
+	name := ct.Name()
+	delete(d.died, name)
+	if st, err := d.dc.GetContainerState(ctx, name); err == nil && st == docker.ContainerStateRunning {
+		d.restarted[name] = struct{}{}
+	}
+	// Start logs the failures already, and the container will be
+	// repaired again on any further events or the next resync.
+	_, _ = ct.Start(ctx, d.dc)
+}
+
+// healContainer heals the container as per its unhealthy policy.
+func (d *daemon) healContainer(ctx context.Context, ct *deployment.Container) {
+	// The container is running, hence its stop event while being healed
+	// must not be treated as an intentional stop.
+	d.restarted[ct.Name()] = struct{}{}
+	res, _ := ct.Heal(ctx, d.dc, d.heal, time.Now())
+	if res != deployment.HealResultHealed {
+		delete(d.restarted, ct.Name())
+	}
+	// Heal logs the failures already, and the container will be healed
+	// again on any further events or the next resync.
+}
+
+// nextHealRetry returns the channel notified once the backoff of the
+// earliest pending heal attempt elapses, or nil if there are none.
+func (d *daemon) nextHealRetry() <-chan time.Time {
+	var next time.Time
+	for _, ct := range d.containers {
+		if t, found := d.heal.NextHealAttempt(ct); found && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	if next.IsZero() {
+		return nil
+	}
+	return time.After(time.Until(next))
+}
+
+// dueJobs returns the scheduled jobs allowed to run on the host that are
+// due at the specified time. The jobs state is read from the file every
+// time, since it is shared with the other invocations of the jobs.
+func (d *daemon) dueJobs(ctx context.Context, now time.Time) deployment.JobList {
+	if len(d.opts.JobsStateFile) == 0 {
+		return nil
+	}
+	state, err := deployment.ReadJobsState(d.opts.JobsStateFile)
+	if err != nil {
+		log(ctx).Errorf("Not running the scheduled jobs, reason: %v", err)
+		return nil
+	}
+	jobs, _ := d.dep.QueryAllJobs(context.Background())
+	var res deployment.JobList
+	for _, j := range jobs {
+		if j.IsAllowedOnCurrentHost() && j.IsDue(state, now) {
+			res = append(res, j)
+		}
+	}
+	return res
+}
+
+// startDueJobs runs the jobs that are due in the background, unless the
+// jobs from the previous check are still running. The completion is
+// notified on the done channel.
+func (d *daemon) startDueJobs(ctx context.Context, done chan<- struct{}) {
+	if d.jobsRunning {
+		return
+	}
+	jobs := d.dueJobs(ctx, time.Now())
+	if len(jobs) == 0 {
+		return
+	}
+	d.jobsRunning = true
+	go func() {
+		d.runJobs(ctx, jobs)
+		done <- struct{}{}
+	}()
+}
+
+// runJobs runs the jobs one after another, recording their runs in the
+// jobs state file.
+func (d *daemon) runJobs(ctx context.Context, jobs deployment.JobList) {
+	for _, j := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		// The job is claimed right before running it, since the other
+		// invocations sharing the state file might have run it
+		// meanwhile.
+		due, err := deployment.ClaimDueJob(d.opts.JobsStateFile, j, time.Now())
+		if err != nil {
+			log(ctx).Errorf("Not running the job %s, reason: %v", j.Name(), err)
+			continue
+		}
+		if !due {
+			continue
+		}
+		// Run logs the failures already, and the job is run again once
+		// it is due next.
+		res, _ := j.Run(ctx, d.dc)
+		if err := deployment.RecordJobRun(d.opts.JobsStateFile, j, res); err != nil {
+			log(ctx).Errorf("Failed to record the run of job %s, reason: %v", j.Name(), err)
+		}
+	}
+}
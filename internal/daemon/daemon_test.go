@@ -0,0 +1,444 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	daemonTestSettleDelay = 10 * time.Millisecond
+	daemonTestWaitTimeout = 5 * time.Second
+	// Time to wait while verifying the daemon leaves a container alone.
+	daemonTestQuietPeriod = 200 * time.Millisecond
+)
+
+const daemonTestConfig = `
+global:
+  baseDir: %s
+ipam:
+  networks:
+    bridgeModeNetworks:
+      - name: net1
+        hostInterfaceName: docker-net1
+        cidr: 172.18.10.0/24
+        priority: 1
+        containers:
+          - ip: 172.18.10.11
+            container:
+              group: g1
+              container: c1
+          - ip: 172.18.10.12
+            container:
+              group: g1
+              container: c2
+hosts:
+  - name: fakehost
+    allowedContainers:
+      - group: g1
+        container: c1
+%s
+groups:
+  - name: g1
+    order: 1
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: %s
+    lifecycle:
+      order: 1
+  - info:
+      group: g1
+      container: c2
+    image:
+      image: abc/c2
+    lifecycle:
+      order: 2
+`
+
+const daemonTestAllowC2 = `      - group: g1
+        container: c2`
+
+const daemonTestJobsConfig = `
+jobs:
+  - info:
+      group: g1
+      container: j1
+    image:
+      image: abc/j1
+    schedule: "@hourly"
+    placement:
+      - name: fakehost
+`
+
+func daemonTestConfigsDir(t *testing.T, c1Image string, allowC2 bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeDaemonTestConfig(t, dir, c1Image, allowC2)
+	return dir
+}
+
+func writeDaemonTestConfig(t *testing.T, dir string, c1Image string, allowC2 bool) {
+	t.Helper()
+	allowed := ""
+	if allowC2 {
+		allowed = daemonTestAllowC2
+	}
+	baseDir := filepath.Join(testhelpers.Pwd(), testhelpers.HomelabBaseDir())
+	conf := fmt.Sprintf(daemonTestConfig, baseDir, allowed, c1Image)
+	if err := os.WriteFile(filepath.Join(dir, "homelab.yaml"), []byte(conf), 0o644); err != nil {
+		t.Fatalf("failed to write the homelab config, reason: %v", err)
+	}
+}
+
+func newDaemonTestContext() context.Context {
+	return testutils.NewTestContext(&testutils.TestContextInfo{
+		DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+			ValidImagesForPull: utils.StringSet{
+				"abc/c1":     {},
+				"abc/c1:2.0": {},
+				"abc/c2":     {},
+				"abc/j1":     {},
+			},
+			ExitOnStart: map[string]*fakedocker.FakeContainerExit{
+				"g1-j1": {
+					Logs: "job done\n",
+				},
+			},
+		}),
+		ContainerPurgeKillAttempts: 5,
+	})
+}
+
+func newDaemonTestOptions(ctx context.Context, t *testing.T, dir string) *Options {
+	t.Helper()
+	dep, err := deployment.FromConfigsPath(ctx, dir)
+	if err != nil {
+		t.Fatalf("deployment.FromConfigsPath() failed, reason: %v", err)
+	}
+	return &Options{
+		Deployment:  dep,
+		ConfigsPath: dir,
+		Reload: func(ctx context.Context) (*deployment.Deployment, error) {
+			return deployment.FromConfigsPath(ctx, dir)
+		},
+		RestartUnhealthy: true,
+		SettleDelay:      daemonTestSettleDelay,
+	}
+}
+
+// startDaemon runs the daemon in the background until the test ends.
+func startDaemon(ctx context.Context, t *testing.T, opts *Options) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(ctx, opts)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitFor(t *testing.T, desc string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(daemonTestWaitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(daemonTestSettleDelay)
+	}
+}
+
+func waitForRunning(t *testing.T, f *fakedocker.FakeDockerHost, containerName string) {
+	t.Helper()
+	waitFor(t, fmt.Sprintf("container %s to be running", containerName), func() bool {
+		return f.GetContainerState(containerName) == docker.ContainerStateRunning
+	})
+}
+
+func waitForRecreated(t *testing.T, f *fakedocker.FakeDockerHost, containerName string, prevID string) {
+	t.Helper()
+	waitFor(t, fmt.Sprintf("container %s to be recreated", containerName), func() bool {
+		id := f.ContainerID(containerName)
+		return id != "" && id != prevID && f.GetContainerState(containerName) == docker.ContainerStateRunning
+	})
+}
+
+func TestDaemonOnce(t *testing.T) {
+	t.Parallel()
+
+	ctx := newDaemonTestContext()
+	opts := newDaemonTestOptions(ctx, t, daemonTestConfigsDir(t, "abc/c1", true))
+	opts.Once = true
+	Run(ctx, opts)
+
+	f := fakedocker.FakeDockerHostFromContext(ctx)
+	for _, ct := range []string{"g1-c1", "g1-c2"} {
+		if got := f.GetContainerState(ct); got != docker.ContainerStateRunning {
+			t.Errorf("container %s state %s after Run() once, want %s", ct, got, docker.ContainerStateRunning)
+		}
+	}
+}
+
+func newDaemonJobsTestOptions(ctx context.Context, t *testing.T) *Options {
+	t.Helper()
+	dir := daemonTestConfigsDir(t, "abc/c1", true)
+	if err := os.WriteFile(filepath.Join(dir, "jobs.yaml"), []byte(daemonTestJobsConfig), 0o644); err != nil {
+		t.Fatalf("failed to write the jobs config, reason: %v", err)
+	}
+	opts := newDaemonTestOptions(ctx, t, dir)
+	opts.JobsStateFile = filepath.Join(t.TempDir(), "jobs.json")
+	return opts
+}
+
+func jobRunsRecorded(t *testing.T, opts *Options, job string) int {
+	t.Helper()
+	state, err := deployment.ReadJobsState(opts.JobsStateFile)
+	if err != nil {
+		t.Fatalf("deployment.ReadJobsState() failed, reason: %v", err)
+	}
+	if st, found := state[job]; found {
+		return len(st.Runs)
+	}
+	return 0
+}
+
+func TestDaemonOnceRunsDueJobs(t *testing.T) {
+	t.Parallel()
+
+	ctx := newDaemonTestContext()
+	opts := newDaemonJobsTestOptions(ctx, t)
+	opts.Once = true
+	Run(ctx, opts)
+
+	if got := jobRunsRecorded(t, opts, "fakehost/g1-j1"); got != 1 {
+		t.Errorf("job g1-j1 recorded %d runs after Run() once, want 1", got)
+	}
+	f := fakedocker.FakeDockerHostFromContext(ctx)
+	if got := f.GetContainerState("g1-j1"); got != docker.ContainerStateNotFound {
+		t.Errorf("job container g1-j1 state %s after Run() once, want %s", got, docker.ContainerStateNotFound)
+	}
+
+	// The job isn't due again until an hour later.
+	Run(ctx, opts)
+	if got := jobRunsRecorded(t, opts, "fakehost/g1-j1"); got != 1 {
+		t.Errorf("job g1-j1 recorded %d runs after Run() once again, want 1", got)
+	}
+}
+
+func TestDaemonRunsDueJobs(t *testing.T) {
+	t.Parallel()
+
+	ctx := newDaemonTestContext()
+	opts := newDaemonJobsTestOptions(ctx, t)
+	startDaemon(ctx, t, opts)
+
+	waitFor(t, "job g1-j1 to run", func() bool {
+		_, err := os.Stat(opts.JobsStateFile)
+		return err == nil && jobRunsRecorded(t, opts, "fakehost/g1-j1") == 1
+	})
+}
+
+func TestDaemonRepairsDrift(t *testing.T) {
+	t.Parallel()
+
+	ctx := newDaemonTestContext()
+	f := fakedocker.FakeDockerHostFromContext(ctx)
+	opts := newDaemonTestOptions(ctx, t, daemonTestConfigsDir(t, "abc/c1", true))
+	startDaemon(ctx, t, opts)
+	waitForRunning(t, f, "g1-c1")
+	waitForRunning(t, f, "g1-c2")
+
+	// Removed container.
+	id := f.ContainerID("g1-c1")
+	if err := f.ForceRemoveContainer("g1-c1"); err != nil {
+		t.Fatalf("ForceRemoveContainer() failed, reason: %v", err)
+	}
+	waitForRecreated(t, f, "g1-c1", id)
+
+	// Container exiting on its own.
+	id = f.ContainerID("g1-c2")
+	if err := f.ExitContainer("g1-c2"); err != nil {
+		t.Fatalf("ExitContainer() failed, reason: %v", err)
+	}
+	waitForRecreated(t, f, "g1-c2", id)
+
+	// Unhealthy container.
+	id = f.ContainerID("g1-c1")
+	if err := f.SetContainerHealth("g1-c1", "unhealthy"); err != nil {
+		t.Fatalf("SetContainerHealth() failed, reason: %v", err)
+	}
+	waitForRecreated(t, f, "g1-c1", id)
+
+	// Missing network.
+	if err := f.NetworkRemove(ctx, "net1"); err != nil {
+		t.Fatalf("NetworkRemove() failed, reason: %v", err)
+	}
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+	waitFor(t, "network net1 to be created", func() bool {
+		return dc.NetworkExists(ctx, "net1")
+	})
+
+	// Container stopped intentionally.
+	if err := dc.StopContainer(ctx, "g1-c2"); err != nil {
+		t.Fatalf("StopContainer() failed, reason: %v", err)
+	}
+	time.Sleep(daemonTestQuietPeriod)
+	if got := f.GetContainerState("g1-c2"); got != docker.ContainerStateExited {
+		t.Errorf("container g1-c2 state %s after being stopped intentionally, want %s", got, docker.ContainerStateExited)
+	}
+}
+
+func TestDaemonUnhealthyWithoutRestart(t *testing.T) {
+	t.Parallel()
+
+	ctx := newDaemonTestContext()
+	f := fakedocker.FakeDockerHostFromContext(ctx)
+	opts := newDaemonTestOptions(ctx, t, daemonTestConfigsDir(t, "abc/c1", false))
+	opts.RestartUnhealthy = false
+	startDaemon(ctx, t, opts)
+	waitForRunning(t, f, "g1-c1")
+
+	id := f.ContainerID("g1-c1")
+	if err := f.SetContainerHealth("g1-c1", "unhealthy"); err != nil {
+		t.Fatalf("SetContainerHealth() failed, reason: %v", err)
+	}
+	time.Sleep(daemonTestQuietPeriod)
+	if got := f.ContainerID("g1-c1"); got != id {
+		t.Errorf("unhealthy container g1-c1 was restarted even though restarting unhealthy containers is disabled")
+	}
+}
+
+const daemonTestC1UnhealthyPolicy = `    lifecycle:
+      order: 1
+    health:
+      onUnhealthy:
+        action: restart
+        backoff: 50ms
+        maxAttempts: 2
+`
+
+func TestDaemonHealsUnhealthy(t *testing.T) {
+	t.Parallel()
+
+	ctx := newDaemonTestContext()
+	f := fakedocker.FakeDockerHostFromContext(ctx)
+	dir := daemonTestConfigsDir(t, "abc/c1", false)
+	path := filepath.Join(dir, "homelab.yaml")
+	conf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the homelab config, reason: %v", err)
+	}
+	conf = []byte(strings.Replace(string(conf), "    lifecycle:\n      order: 1\n", daemonTestC1UnhealthyPolicy, 1))
+	if err := os.WriteFile(path, conf, 0o644); err != nil {
+		t.Fatalf("failed to write the homelab config, reason: %v", err)
+	}
+
+	opts := newDaemonTestOptions(ctx, t, dir)
+	opts.RestartUnhealthy = false
+	startDaemon(ctx, t, opts)
+	waitForRunning(t, f, "g1-c1")
+	id := f.ContainerID("g1-c1")
+
+	// The container is restarted as per the policy, even though
+	// restarting the unhealthy containers is disabled otherwise, and
+	// healed again after the backoff.
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := f.SetContainerHealth("g1-c1", "unhealthy"); err != nil {
+			t.Fatalf("SetContainerHealth() failed, reason: %v", err)
+		}
+		waitFor(t, fmt.Sprintf("container g1-c1 to be restarted %d time(s)", attempt), func() bool {
+			return f.ContainerRestartCount("g1-c1") == attempt
+		})
+		waitForRunning(t, f, "g1-c1")
+	}
+	if got := f.ContainerID("g1-c1"); got != id {
+		t.Errorf("unhealthy container g1-c1 was recreated instead of being restarted")
+	}
+
+	// The container turning unhealthy again after the max attempts is
+	// crash looping, and is no longer healed.
+	if err := f.SetContainerHealth("g1-c1", "unhealthy"); err != nil {
+		t.Fatalf("SetContainerHealth() failed, reason: %v", err)
+	}
+	time.Sleep(daemonTestQuietPeriod)
+	if got := f.ContainerRestartCount("g1-c1"); got != 2 {
+		t.Errorf("crash looping container g1-c1 restarted %d times, want 2", got)
+	}
+}
+
+func TestDaemonReloadsConfigs(t *testing.T) {
+	t.Parallel()
+
+	ctx := newDaemonTestContext()
+	f := fakedocker.FakeDockerHostFromContext(ctx)
+	dir := daemonTestConfigsDir(t, "abc/c1", false)
+	opts := newDaemonTestOptions(ctx, t, dir)
+	startDaemon(ctx, t, opts)
+	waitForRunning(t, f, "g1-c1")
+	if got := f.GetContainerState("g1-c2"); got != docker.ContainerStateNotFound {
+		t.Fatalf("container g1-c2 state %s prior to being allowed on the host, want %s", got, docker.ContainerStateNotFound)
+	}
+
+	// Update the image of c1 and allow c2 to run on the host.
+	id := f.ContainerID("g1-c1")
+	writeDaemonTestConfig(t, dir, "abc/c1:2.0", true)
+	waitForRecreated(t, f, "g1-c1", id)
+	waitForRunning(t, f, "g1-c2")
+}
+
+func TestWatchConfigsImageLock(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(newDaemonTestContext())
+	t.Cleanup(cancel)
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "g1")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("failed to create the configs sub-directory, reason: %v", err)
+	}
+	changes, err := watchConfigs(ctx, dir)
+	if err != nil {
+		t.Fatalf("watchConfigs() failed, reason: %v", err)
+	}
+
+	// Neither the unrelated files nor an image lock file within the
+	// sub-directories are watched.
+	for _, p := range []string{filepath.Join(dir, "notes.txt"), filepath.Join(sub, deployment.ImageLockFileName)} {
+		if err := os.WriteFile(p, []byte("foo"), 0o644); err != nil {
+			t.Fatalf("failed to write %s, reason: %v", p, err)
+		}
+	}
+	select {
+	case <-changes:
+		t.Fatalf("change signaled for the files other than the configs and the image lock file")
+	case <-time.After(daemonTestQuietPeriod):
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, deployment.ImageLockFileName), []byte("foo"), 0o644); err != nil {
+		t.Fatalf("failed to write the image lock file, reason: %v", err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(daemonTestWaitTimeout):
+		t.Fatalf("timed out waiting for the change in the image lock file to be signaled")
+	}
+}
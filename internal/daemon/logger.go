@@ -0,0 +1,7 @@
+package daemon
+
+import l "github.com/tuxdudehomelab/homelab/internal/log"
+
+var (
+	log = l.Log
+)
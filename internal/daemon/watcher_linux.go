@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/tuxdudehomelab/homelab/internal/deployment"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	configsWatchEvents = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+	// Timeout in milliseconds while polling for the inotify events, after
+	// which the watcher checks whether the context was canceled.
+	configsWatchPollTimeout = 500
+)
+
+type configsWatcher struct {
+	fd   int
+	root string
+	dirs map[int]string
+}
+
+// watchConfigs watches the configs directory along with all its
+// sub-directories using inotify, and signals on the returned channel
+// whenever any of the config files, the image lock file or the
+// directories within change, until the context is canceled.
+func watchConfigs(ctx context.Context, path string) (<-chan struct{}, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify, reason: %w", err)
+	}
+
+	w := &configsWatcher{
+		fd:   fd,
+		root: path,
+		dirs: map[int]string{},
+	}
+	if err := w.addDirs(path); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	go w.run(ctx, changes)
+	return changes, nil
+}
+
+// addDirs watches the directory along with all its sub-directories.
+func (w *configsWatcher) addDirs(root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read contents of directory %s, reason: %w", root, err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, p, configsWatchEvents)
+		if err != nil {
+			return fmt.Errorf("failed to watch directory %s, reason: %w", p, err)
+		}
+		w.dirs[wd] = p
+		return nil
+	})
+}
+
+func (w *configsWatcher) run(ctx context.Context, changes chan<- struct{}) {
+	defer unix.Close(w.fd)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	fds := []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+	for ctx.Err() == nil {
+		n, err := unix.Poll(fds, configsWatchPollTimeout)
+		if errors.Is(err, unix.EINTR) || (err == nil && n == 0) {
+			continue
+		}
+		if err == nil {
+			n, err = unix.Read(w.fd, buf)
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+		}
+		if err != nil {
+			log(ctx).Errorf("Stopped watching the configs for changes, reason: %v", err)
+			return
+		}
+
+		if w.handleEvents(ctx, buf[:n]) {
+			select {
+			case changes <- struct{}{}:
+			default:
+				// A change is already pending.
+			}
+		}
+	}
+}
+
+// handleEvents processes the inotify events, watching any newly created
+// sub-directories as well. Returns true if any of the config files, the
+// image lock file or the directories changed.
+func (w *configsWatcher) handleEvents(ctx context.Context, buf []byte) bool {
+	changed := false
+	for off := 0; off+unix.SizeofInotifyEvent <= len(buf); {
+		ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+		start := off + unix.SizeofInotifyEvent
+		off = start + int(ev.Len)
+		name := strings.TrimRight(string(buf[start:off]), "\x00")
+
+		if ev.Mask&unix.IN_Q_OVERFLOW != 0 {
+			changed = true
+			continue
+		}
+		if ev.Mask&unix.IN_IGNORED != 0 {
+			delete(w.dirs, int(ev.Wd))
+			continue
+		}
+		dir, found := w.dirs[int(ev.Wd)]
+		if !found {
+			continue
+		}
+
+		p := filepath.Join(dir, name)
+		if ev.Mask&unix.IN_ISDIR != 0 {
+			if ev.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+				if err := w.addDirs(p); err != nil {
+					log(ctx).Warnf("Not watching the configs directory %s for changes, reason: %v", p, err)
+				}
+			}
+		} else if !w.isWatchedFile(dir, name) {
+			continue
+		}
+		log(ctx).Debugf("Detected a change in the configs at %s", p)
+		changed = true
+	}
+	return changed
+}
+
+// isWatchedFile returns true if the file is either a config file or the
+// image lock file at the root of the configs directory.
+func (w *configsWatcher) isWatchedFile(dir string, name string) bool {
+	if ext := filepath.Ext(name); ext == ".yml" || ext == ".yaml" {
+		return true
+	}
+	return dir == w.root && name == deployment.ImageLockFileName
+}
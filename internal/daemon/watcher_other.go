@@ -0,0 +1,14 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// watchConfigs is unsupported on the platforms other than linux.
+func watchConfigs(ctx context.Context, path string) (<-chan struct{}, error) {
+	return nil, fmt.Errorf("watching the configs for changes is unsupported on %s", runtime.GOOS)
+}
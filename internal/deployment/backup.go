@@ -0,0 +1,1032 @@
+package deployment
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuxdudehomelab/homelab/internal/cmdexec"
+	"github.com/tuxdudehomelab/homelab/internal/config/env"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	defaultBackupRetention = 7
+	backupTimestampFormat  = "20060102T150405Z"
+	backupChecksumSuffix   = ".sha256"
+	// The top level directories within the backup archive holding the
+	// CONTAINER_DATA_DIR and the named volumes of the container.
+	backupArchiveDataDir    = "data"
+	backupArchiveVolumesDir = "volumes"
+)
+
+// backupCompression is the compression applied to the backup archives.
+type backupCompression uint8
+
+const (
+	backupCompressionGzip backupCompression = iota
+	backupCompressionZstd
+)
+
+func backupCompressionFromString(compression string) (backupCompression, error) {
+	switch compression {
+	case "", "gzip":
+		return backupCompressionGzip, nil
+	case "zstd":
+		return backupCompressionZstd, nil
+	default:
+		return backupCompressionGzip, fmt.Errorf("invalid backup compression string: %s", compression)
+	}
+}
+
+func backupCompressionValidValues() string {
+	return "[ 'gzip', 'zstd' ]"
+}
+
+func backupCompressionFromArchive(archive string) (backupCompression, error) {
+	switch {
+	case strings.HasSuffix(archive, ".tar.gz"):
+		return backupCompressionGzip, nil
+	case strings.HasSuffix(archive, ".tar.zst"):
+		return backupCompressionZstd, nil
+	default:
+		return backupCompressionGzip, fmt.Errorf("unsupported backup archive %s, expected a .tar.gz or a .tar.zst archive", archive)
+	}
+}
+
+func (b backupCompression) extension() string {
+	switch b {
+	case backupCompressionGzip:
+		return ".tar.gz"
+	case backupCompressionZstd:
+		return ".tar.zst"
+	default:
+		panic("Invalid scenario in backupCompression extension, possibly indicating a bug in the code")
+	}
+}
+
+// namedVolume is a docker named volume mounted within the container.
+type namedVolume struct {
+	name string
+	dst  string
+}
+
+// Backup archives the CONTAINER_DATA_DIR and the named volumes of the
+// container into the backup destination, and returns the path of the
+// archive. The container is stopped while being backed up unless
+// requested otherwise, and started again once the backup completes
+// irrespective of the outcome. Only the most recent archives as per the
+// retention are retained.
+func (c *Container) Backup(ctx context.Context, dc *docker.Client) (string, error) {
+	name := c.Name()
+	if !c.isAllowedOnCurrentHost() {
+		return "", utils.LogToErrorAndReturn(ctx, "Failed to back up container %s, reason:container is not allowed to run on the current host", name)
+	}
+
+	log(ctx).Infof("Backing up container %s", name)
+	archive, err := c.backup(ctx, dc, time.Now())
+	if err != nil {
+		return "", utils.LogToErrorAndReturn(ctx, "Failed to back up container %s, reason:%v", name, err)
+	}
+	log(ctx).Infof("Backed up container %s to %s", name, archive)
+	log(ctx).InfoEmpty()
+	return archive, nil
+}
+
+// Restore restores the CONTAINER_DATA_DIR and the named volumes of the
+// container from the backup archive, or the most recent archive in the
+// backup destination if no archive is specified, and returns the path
+// of the archive. The checksum of the archive is verified prior to
+// restoring it. A running container is stopped while being restored and
+// started again afterwards.
+func (c *Container) Restore(ctx context.Context, dc *docker.Client, archive string) (string, error) {
+	name := c.Name()
+	if !c.isAllowedOnCurrentHost() {
+		return "", utils.LogToErrorAndReturn(ctx, "Failed to restore container %s, reason:container is not allowed to run on the current host", name)
+	}
+
+	if len(archive) == 0 {
+		archives, err := c.listBackups()
+		if err != nil {
+			return "", utils.LogToErrorAndReturn(ctx, "Failed to restore container %s, reason:%v", name, err)
+		}
+		if len(archives) == 0 {
+			return "", utils.LogToErrorAndReturn(ctx, "Failed to restore container %s, reason:no backups found in %s", name, c.config.Backup.Destination)
+		}
+		archive = archives[len(archives)-1]
+	}
+
+	log(ctx).Infof("Restoring container %s from %s", name, archive)
+	if err := c.restore(ctx, dc, archive); err != nil {
+		return "", utils.LogToErrorAndReturn(ctx, "Failed to restore container %s, reason:%v", name, err)
+	}
+	log(ctx).Infof("Restored container %s from %s", name, archive)
+	log(ctx).InfoEmpty()
+	return archive, nil
+}
+
+func (c *Container) backup(ctx context.Context, dc *docker.Client, now time.Time) (archive string, err error) {
+	conf := &c.config.Backup
+	if len(conf.Destination) == 0 {
+		return "", fmt.Errorf("backup destination is not configured")
+	}
+	comp, err := backupCompressionFromString(conf.Compression)
+	if err != nil {
+		panic(fmt.Sprintf("unable to convert backup compression %s setting, reason: %v, possibly indicating a bug in the code", conf.Compression, err))
+	}
+	if err := os.MkdirAll(conf.Destination, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create the backup destination %s, reason: %w", conf.Destination, err)
+	}
+
+	st, err := dc.GetContainerState(ctx, c.Name())
+	if err != nil {
+		return "", err
+	}
+	running := st == docker.ContainerStateRunning || st == docker.ContainerStatePaused || st == docker.ContainerStateRestarting
+
+	// 1. Execute the pre-backup hook if specified. The hooks within the
+	// container can only run while the container is running.
+	hook := c.preBackupHook()
+	if hook.config.InContainer && !running {
+		if len(hook.config.Command) > 0 {
+			log(ctx).Warnf("Skipping the %s for container %s since it is not running", hook.name, c.Name())
+		}
+	} else if err := c.runHook(ctx, dc, hook); err != nil {
+		return "", err
+	}
+
+	// 2. Stop the container unless requested otherwise, and start it
+	// again once the backup completes, retaining the error which failed
+	// the backup.
+	if running && !conf.KeepRunning {
+		restart, serr := c.stopTemporarily(ctx, dc)
+		if serr != nil {
+			return "", serr
+		}
+		defer func() {
+			if serr := restart(); serr != nil && err == nil {
+				archive, err = "", serr
+			}
+		}()
+	}
+
+	// 3. Write the archive along with its checksum.
+	archive = filepath.Join(conf.Destination, c.backupArchivePrefix()+now.UTC().Format(backupTimestampFormat)+comp.extension())
+	log(ctx).Infof("Writing backup archive %s", archive)
+	if err := c.writeBackupArchive(ctx, dc, st, archive, comp); err != nil {
+		return "", err
+	}
+
+	// 4. Remove the archives beyond the retention.
+	if err := c.pruneBackups(ctx); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
+
+func (c *Container) writeBackupArchive(ctx context.Context, dc *docker.Client, st docker.ContainerState, archive string, comp backupCompression) error {
+	tmpTar := archive + ".tar.tmp"
+	tmpArchive := archive + ".tmp"
+	defer os.Remove(tmpTar)
+	defer os.Remove(tmpArchive)
+
+	out := tmpArchive
+	if comp == backupCompressionZstd {
+		out = tmpTar
+	}
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create the backup archive %s, reason: %w", archive, err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var gw *gzip.Writer
+	if comp == backupCompressionGzip {
+		gw = gzip.NewWriter(f)
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	if err := c.archiveDataDir(ctx, tw); err != nil {
+		return err
+	}
+	if err := c.archiveVolumes(ctx, dc, st, tw); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write the backup archive %s, reason: %w", archive, err)
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			return fmt.Errorf("failed to write the backup archive %s, reason: %w", archive, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write the backup archive %s, reason: %w", archive, err)
+	}
+
+	if comp == backupCompressionZstd {
+		if _, err := cmdexec.MustExecutor(ctx).Run("zstd", "-q", "-f", "--rm", "-o", tmpArchive, tmpTar); err != nil {
+			return fmt.Errorf("failed to compress the backup archive %s using zstd, reason: %w", archive, err)
+		}
+	}
+
+	sum, err := fileChecksum(tmpArchive)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpArchive, archive); err != nil {
+		return fmt.Errorf("failed to write the backup archive %s, reason: %w", archive, err)
+	}
+	// The checksum file uses the same format as sha256sum.
+	checksum := fmt.Sprintf("%s  %s\n", sum, filepath.Base(archive))
+	if err := os.WriteFile(archive+backupChecksumSuffix, []byte(checksum), 0o640); err != nil {
+		return fmt.Errorf("failed to write the checksum of the backup archive %s, reason: %w", archive, err)
+	}
+	return nil
+}
+
+// archiveDataDir adds the included paths under the CONTAINER_DATA_DIR of
+// the container to the archive, skipping the excluded ones.
+func (c *Container) archiveDataDir(ctx context.Context, tw *tar.Writer) error {
+	dataDir := c.dataDir()
+	includes := c.config.Backup.Includes
+	if len(includes) == 0 {
+		if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+			log(ctx).Debugf("Skipping the backup of the data directory %s for container %s since it doesn't exist", dataDir, c.Name())
+			return nil
+		}
+		includes = []string{"."}
+	}
+
+	added := utils.StringSet{}
+	for _, inc := range includes {
+		root := filepath.Join(dataDir, inc)
+		if _, err := os.Lstat(root); err != nil {
+			return fmt.Errorf("backup include path %s not found, reason: %w", root, err)
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dataDir, path)
+			if err != nil {
+				return err
+			}
+			if rel != "." && c.isExcludedFromBackup(rel) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// Overlapping includes are archived just once.
+			if _, found := added[rel]; found {
+				return nil
+			}
+			added[rel] = struct{}{}
+			return addFileToArchive(tw, path, filepath.ToSlash(filepath.Join(backupArchiveDataDir, rel)))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to back up the data directory %s, reason: %w", dataDir, err)
+		}
+	}
+	return nil
+}
+
+// archiveVolumes adds the contents of the named volumes of the container
+// to the archive, copying them out of the container.
+func (c *Container) archiveVolumes(ctx context.Context, dc *docker.Client, st docker.ContainerState, tw *tar.Writer) error {
+	for _, v := range c.namedVolumes() {
+		if st == docker.ContainerStateNotFound {
+			log(ctx).Warnf("Skipping the backup of volume %s for container %s since the container was not found", v.name, c.Name())
+			continue
+		}
+		r, err := dc.CopyFromContainer(ctx, c.Name(), v.dst)
+		if err != nil {
+			return err
+		}
+		err = copyVolumeToArchive(tw, r, v.name)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to back up the volume %s, reason: %w", v.name, err)
+		}
+	}
+	return nil
+}
+
+// copyVolumeToArchive rewrites the entries of the tar archive copied out
+// of the container, which are prefixed with the base name of the volume
+// mount point, to live under the directory of the volume in the backup
+// archive.
+func copyVolumeToArchive(tw *tar.Writer, r io.Reader, volume string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, rel, _ := strings.Cut(strings.TrimPrefix(hdr.Name, "/"), "/")
+		hdr.Name = backupArchiveVolumesDir + "/" + volume + "/" + rel
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func addFileToArchive(tw *tar.Writer, path string, name string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	link := ""
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	case fi.IsDir(), fi.Mode().IsRegular():
+	default:
+		// Sockets, pipes and devices are not backed up.
+		return nil
+	}
+
+	hdr, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if fi.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func (c *Container) restore(ctx context.Context, dc *docker.Client, archive string) (err error) {
+	// 1. Verify the checksum prior to touching anything.
+	if err := verifyBackupChecksum(archive); err != nil {
+		return err
+	}
+	comp, err := backupCompressionFromArchive(archive)
+	if err != nil {
+		return err
+	}
+
+	tarPath := archive
+	if comp == backupCompressionZstd {
+		tmpDir, err := os.MkdirTemp("", "homelab-restore-")
+		if err != nil {
+			return fmt.Errorf("failed to create a temporary directory for decompressing the backup archive %s, reason: %w", archive, err)
+		}
+		defer os.RemoveAll(tmpDir)
+		tarPath = filepath.Join(tmpDir, "backup.tar")
+		if _, err := cmdexec.MustExecutor(ctx).Run("zstd", "-d", "-q", "-f", "-o", tarPath, archive); err != nil {
+			return fmt.Errorf("failed to decompress the backup archive %s using zstd, reason: %w", archive, err)
+		}
+	}
+	open := func() (*tar.Reader, func(), error) {
+		f, err := os.Open(tarPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open the backup archive %s, reason: %w", archive, err)
+		}
+		if comp != backupCompressionGzip {
+			return tar.NewReader(f), func() { f.Close() }, nil
+		}
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to read the backup archive %s, reason: %w", archive, err)
+		}
+		return tar.NewReader(gr), func() { gr.Close(); f.Close() }, nil
+	}
+
+	// 2. Validate the entries within the archive, and determine the
+	// volumes it holds.
+	tr, closer, err := open()
+	if err != nil {
+		return err
+	}
+	volumes, err := scanBackupArchive(tr)
+	closer()
+	if err != nil {
+		return fmt.Errorf("failed to read the backup archive %s, reason: %w", archive, err)
+	}
+	volumeDsts := map[string]string{}
+	for _, v := range c.namedVolumes() {
+		volumeDsts[v.name] = v.dst
+	}
+
+	st, err := dc.GetContainerState(ctx, c.Name())
+	if err != nil {
+		return err
+	}
+	if len(volumes) > 0 && st == docker.ContainerStateNotFound {
+		return fmt.Errorf("container %s must exist for restoring its volumes", c.Name())
+	}
+
+	// 3. Stop the container if it is running, and start it again once
+	// the restore completes.
+	if st == docker.ContainerStateRunning || st == docker.ContainerStatePaused || st == docker.ContainerStateRestarting {
+		restart, serr := c.stopTemporarily(ctx, dc)
+		if serr != nil {
+			return serr
+		}
+		defer func() {
+			if serr := restart(); serr != nil && err == nil {
+				err = serr
+			}
+		}()
+	}
+	for _, volume := range volumes {
+		if _, found := volumeDsts[volume]; !found {
+			log(ctx).Warnf("Skipping the restore of volume %s for container %s since it is no longer mounted", volume, c.Name())
+		}
+	}
+
+	// 4. Extract the data directory into a staging directory, and stream
+	// the volumes into the container one at a time.
+	tr, closer, err = open()
+	if err != nil {
+		return err
+	}
+	defer closer()
+	dataDir := c.dataDir()
+	if err := os.MkdirAll(filepath.Dir(dataDir), 0o750); err != nil {
+		return fmt.Errorf("failed to create the parent directory of the data directory %s, reason: %w", dataDir, err)
+	}
+	// The staging directory lives alongside the data directory, so that
+	// it can be renamed into place.
+	staging, err := os.MkdirTemp(filepath.Dir(dataDir), ".restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create a staging directory for restoring the data directory %s, reason: %w", dataDir, err)
+	}
+	defer os.RemoveAll(staging)
+
+	hasData := false
+	var vol *volumeRestore
+	defer func() {
+		if vol != nil {
+			_ = vol.abort(fmt.Errorf("restore of the volume %s was aborted", vol.name))
+		}
+	}()
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the backup archive %s, reason: %w", archive, err)
+		}
+		top, rel := splitBackupEntry(hdr.Name)
+		switch top {
+		case backupArchiveDataDir:
+			hasData = true
+			if err := extractFromArchive(tr, hdr, staging, rel); err != nil {
+				return fmt.Errorf("failed to restore %s in the data directory %s, reason: %w", rel, dataDir, err)
+			}
+		case backupArchiveVolumesDir:
+			volume, vrel, _ := strings.Cut(rel, "/")
+			dst, found := volumeDsts[volume]
+			if !found {
+				continue
+			}
+			if vol == nil || vol.name != volume {
+				if err := vol.finish(); err != nil {
+					return fmt.Errorf("failed to restore the volume %s, reason: %w", vol.name, err)
+				}
+				vol = newVolumeRestore(ctx, dc, c.Name(), volume, dst)
+			}
+			if vrel == "" {
+				continue
+			}
+			hdr.Name = vrel
+			if err := vol.write(hdr, tr); err != nil {
+				return fmt.Errorf("failed to restore the volume %s, reason: %w", volume, err)
+			}
+		}
+	}
+	if err := vol.finish(); err != nil {
+		return fmt.Errorf("failed to restore the volume %s, reason: %w", vol.name, err)
+	}
+
+	// 5. Replace the data directory with the restored one, leaving it
+	// untouched if the archive doesn't hold the data directory.
+	if !hasData {
+		return nil
+	}
+	return c.replaceDataDir(ctx, staging, dataDir)
+}
+
+// stopTemporarily stops the running container, and returns a function
+// to start the same container again. Unlike startInternal, the container
+// is started as is instead of pulling its image and recreating it, which
+// retains the image and the writable layer of the container.
+func (c *Container) stopTemporarily(ctx context.Context, dc *docker.Client) (func() error, error) {
+	if _, _, err := c.stopInternal(ctx, dc); err != nil {
+		return nil, err
+	}
+	return func() error {
+		log(ctx).Infof("Starting container %s", c.Name())
+		return dc.StartContainer(ctx, c.Name())
+	}, nil
+}
+
+// replaceDataDir replaces the included paths within the data directory,
+// or the whole data directory if there are no includes, with the ones
+// restored into the staging directory. Any files created after the
+// backup within these paths are thereby removed rather than being mixed
+// with the restored ones, while the paths excluded from the backup are
+// carried over as is.
+func (c *Container) replaceDataDir(ctx context.Context, staging, dataDir string) error {
+	for _, inc := range c.backupIncludes() {
+		src := filepath.Join(staging, inc)
+		dst := filepath.Join(dataDir, inc)
+		if _, err := os.Lstat(src); os.IsNotExist(err) {
+			log(ctx).Warnf("Skipping the restore of %s for container %s since it is not in the backup archive", dst, c.Name())
+			continue
+		}
+		undo, err := c.carryOverExcluded(staging, dataDir, dst)
+		if err != nil {
+			return fmt.Errorf("failed to carry over the paths excluded from the backup within %s, reason: %w", dst, err)
+		}
+		if err := replacePath(src, dst); err != nil {
+			undo()
+			return fmt.Errorf("failed to replace %s with the restored contents, reason: %w", dst, err)
+		}
+	}
+	return nil
+}
+
+// carryOverExcluded moves the paths excluded from the backup within dst,
+// which are never part of the backup archive, from the data directory
+// into the staging directory so that they survive replacing dst. Returns
+// a function moving them back, in case dst cannot be replaced.
+func (c *Container) carryOverExcluded(staging, dataDir, dst string) (func(), error) {
+	var moved []string
+	undo := func() {
+		for _, rel := range moved {
+			_ = os.Rename(filepath.Join(staging, rel), filepath.Join(dataDir, rel))
+		}
+	}
+	if _, err := os.Lstat(dst); os.IsNotExist(err) {
+		return undo, nil
+	}
+
+	err := filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || !c.isExcludedFromBackup(rel) {
+			return nil
+		}
+		to := filepath.Join(staging, rel)
+		if err := os.RemoveAll(to); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0o750); err != nil {
+			return err
+		}
+		if err := os.Rename(path, to); err != nil {
+			return err
+		}
+		moved = append(moved, rel)
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		undo()
+		return nil, err
+	}
+	return undo, nil
+}
+
+// backupIncludes returns the cleaned paths included in the backup
+// relative to the data directory, leaving out the ones nested within
+// another included path.
+func (c *Container) backupIncludes() []string {
+	var incs []string
+	for _, inc := range c.config.Backup.Includes {
+		inc = filepath.Clean(inc)
+		if inc == "." {
+			return []string{"."}
+		}
+		incs = append(incs, inc)
+	}
+	if len(incs) == 0 {
+		return []string{"."}
+	}
+	sort.Strings(incs)
+
+	var res []string
+	for _, inc := range incs {
+		nested := false
+		for _, r := range res {
+			if inc == r || strings.HasPrefix(inc, r+string(filepath.Separator)) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			res = append(res, inc)
+		}
+	}
+	return res
+}
+
+// replacePath atomically replaces dst with src by renaming, restoring the
+// original dst if the replacement fails.
+func replacePath(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	old := dst + ".restore-old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	_, err := os.Lstat(dst)
+	exists := err == nil
+	if exists {
+		if err := os.Rename(dst, old); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if exists {
+			_ = os.Rename(old, dst)
+		}
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// volumeRestore streams the entries of a volume within the backup
+// archive into the container, without buffering the whole volume.
+type volumeRestore struct {
+	name string
+	pw   *io.PipeWriter
+	tw   *tar.Writer
+	done chan error
+	// Whether the copy into the container completed, along with its
+	// result.
+	copied  bool
+	copyErr error
+}
+
+func newVolumeRestore(ctx context.Context, dc *docker.Client, containerName, volume, dst string) *volumeRestore {
+	pr, pw := io.Pipe()
+	v := &volumeRestore{
+		name: volume,
+		pw:   pw,
+		tw:   tar.NewWriter(pw),
+		done: make(chan error, 1),
+	}
+	go func() {
+		err := dc.CopyToContainer(ctx, containerName, dst, pr)
+		// Unblock any pending writes if the copy returned early.
+		pr.CloseWithError(err)
+		v.done <- err
+	}()
+	return v
+}
+
+func (v *volumeRestore) write(hdr *tar.Header, r io.Reader) error {
+	if err := v.tw.WriteHeader(hdr); err != nil {
+		return v.abort(err)
+	}
+	if _, err := io.Copy(v.tw, r); err != nil {
+		return v.abort(err)
+	}
+	return nil
+}
+
+// finish completes the tar stream of the volume and waits for the copy
+// into the container to complete.
+func (v *volumeRestore) finish() error {
+	if v == nil {
+		return nil
+	}
+	if err := v.tw.Close(); err != nil {
+		return v.abort(err)
+	}
+	if err := v.pw.Close(); err != nil {
+		return v.abort(err)
+	}
+	return v.wait()
+}
+
+// abort stops the copy into the container, and returns the error which
+// failed the copy, if any, or else the specified error.
+func (v *volumeRestore) abort(err error) error {
+	v.pw.CloseWithError(err)
+	if cerr := v.wait(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func (v *volumeRestore) wait() error {
+	if !v.copied {
+		v.copyErr = <-v.done
+		v.copied = true
+	}
+	return v.copyErr
+}
+
+// scanBackupArchive validates the entries within the backup archive, and
+// returns the sorted names of the volumes within the archive.
+func scanBackupArchive(tr *tar.Reader) ([]string, error) {
+	volumes := utils.StringSet{}
+	last := ""
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top, rel := splitBackupEntry(hdr.Name)
+		if rel != "." && !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("invalid path %s in the backup archive", hdr.Name)
+		}
+		switch top {
+		case backupArchiveDataDir:
+		case backupArchiveVolumesDir:
+			volume, _, _ := strings.Cut(rel, "/")
+			// The volumes are streamed into the container one at a
+			// time, which requires the entries of each volume to be
+			// contiguous.
+			if _, found := volumes[volume]; found && volume != last {
+				return nil, fmt.Errorf("entries of the volume %s are not contiguous in the backup archive", volume)
+			}
+			volumes[volume] = struct{}{}
+			last = volume
+		default:
+			return nil, fmt.Errorf("unexpected path %s in the backup archive", hdr.Name)
+		}
+	}
+	res := make([]string, 0, len(volumes))
+	for v := range volumes {
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// splitBackupEntry splits the name of the entry in the backup archive
+// into the top level directory and the path relative to it.
+func splitBackupEntry(name string) (string, string) {
+	top, rel, _ := strings.Cut(strings.TrimSuffix(name, "/"), "/")
+	if rel == "" {
+		rel = "."
+	}
+	return top, rel
+}
+
+// extractFromArchive extracts the entry of the archive at the path
+// relative to the root, overwriting any existing file. The entries are
+// never written through a symlink, which could otherwise be used by an
+// earlier entry to escape the root.
+func extractFromArchive(tr *tar.Reader, hdr *tar.Header, root, rel string) error {
+	if err := validateNoSymlinkInPath(root, rel); err != nil {
+		return err
+	}
+	path := filepath.Join(root, rel)
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	mode := fs.FileMode(hdr.Mode).Perm()
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(path, mode); err != nil {
+			return err
+		}
+		return os.Chmod(path, mode)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return os.Chmod(path, mode)
+	case tar.TypeSymlink:
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.Symlink(hdr.Linkname, path)
+	default:
+		return nil
+	}
+}
+
+// validateNoSymlinkInPath returns an error if any of the parent
+// directories of the path relative to the root is a symlink.
+func validateNoSymlinkInPath(root, rel string) error {
+	dir := root
+	for _, part := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		dir = filepath.Join(dir, part)
+		fi, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to write %s through the symlink %s", rel, dir)
+		}
+	}
+	return nil
+}
+
+// verifyBackupChecksum verifies the checksum of the backup archive
+// against the checksum recorded alongside it.
+func verifyBackupChecksum(archive string) error {
+	data, err := os.ReadFile(archive + backupChecksumSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to read the checksum of the backup archive %s, reason: %w", archive, err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file %s%s is empty", archive, backupChecksumSuffix)
+	}
+	sum, err := fileChecksum(archive)
+	if err != nil {
+		return err
+	}
+	if sum != fields[0] {
+		return fmt.Errorf("checksum mismatch for the backup archive %s, expected %s but found %s", archive, fields[0], sum)
+	}
+	return nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s for computing its checksum, reason: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to compute the checksum of %s, reason: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// listBackups returns the backup archives of the container in the
+// backup destination, oldest first.
+func (c *Container) listBackups() ([]string, error) {
+	dest := c.config.Backup.Destination
+	if len(dest) == 0 {
+		return nil, fmt.Errorf("backup destination is not configured")
+	}
+	entries, err := os.ReadDir(dest)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the backup destination %s, reason: %w", dest, err)
+	}
+
+	prefix := c.backupArchivePrefix()
+	var res []string
+	for _, e := range entries {
+		ts, found := strings.CutPrefix(e.Name(), prefix)
+		if !found || e.IsDir() {
+			continue
+		}
+		comp, err := backupCompressionFromArchive(ts)
+		if err != nil {
+			continue
+		}
+		// The prefix could match the archives of another container whose
+		// name has this container's name as the prefix, which are ruled
+		// out by requiring just the timestamp to follow.
+		if _, err := time.Parse(backupTimestampFormat, strings.TrimSuffix(ts, comp.extension())); err != nil {
+			continue
+		}
+		res = append(res, filepath.Join(dest, e.Name()))
+	}
+	// The timestamps sort in the chronological order.
+	sort.Slice(res, func(i, j int) bool {
+		return strings.TrimPrefix(filepath.Base(res[i]), prefix) < strings.TrimPrefix(filepath.Base(res[j]), prefix)
+	})
+	return res, nil
+}
+
+// pruneBackups removes the oldest backup archives of the container along
+// with their checksums beyond the retention.
+func (c *Container) pruneBackups(ctx context.Context) error {
+	retention := defaultBackupRetention
+	if c.config.Backup.Retention > 0 {
+		retention = c.config.Backup.Retention
+	}
+	archives, err := c.listBackups()
+	if err != nil {
+		return err
+	}
+	if len(archives) <= retention {
+		return nil
+	}
+	for _, archive := range archives[:len(archives)-retention] {
+		log(ctx).Infof("Removing backup archive %s", archive)
+		if err := os.Remove(archive); err != nil {
+			return fmt.Errorf("failed to remove the backup archive %s, reason: %w", archive, err)
+		}
+		if err := os.Remove(archive + backupChecksumSuffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove the checksum of the backup archive %s, reason: %w", archive, err)
+		}
+	}
+	return nil
+}
+
+func (c *Container) backupArchivePrefix() string {
+	return fmt.Sprintf("%s-%s-", c.config.Info.Group, c.config.Info.Container)
+}
+
+func (c *Container) isExcludedFromBackup(rel string) bool {
+	for _, pattern := range c.config.Backup.Excludes {
+		if m, _ := filepath.Match(pattern, rel); m {
+			return true
+		}
+		if m, _ := filepath.Match(pattern, filepath.Base(rel)); m {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Container) preBackupHook() *containerHook {
+	return newContainerHook("pre-backup hook", "preBackupHook", &c.config.Backup.PreBackupHook)
+}
+
+// dataDir returns the CONTAINER_DATA_DIR of the container.
+func (c *Container) dataDir() string {
+	return env.ContainerDataDir(containerBaseDir(c.globalConfig.BaseDir, c.config.Info))
+}
+
+// namedVolumes returns the docker named volumes mounted within the
+// container, i.e. the bind mounts whose source isn't an absolute path.
+func (c *Container) namedVolumes() []namedVolume {
+	var res []namedVolume
+	for _, m := range c.mountsOfType("bind") {
+		parts := strings.Split(m.spec, ":")
+		if len(parts) < 2 || filepath.IsAbs(parts[0]) {
+			continue
+		}
+		res = append(res, namedVolume{name: parts[0], dst: parts[1]})
+	}
+	return res
+}
@@ -0,0 +1,556 @@
+package deployment
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/cmdexec"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/config/env"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+var backupTestContainer = config.ContainerReference{
+	Group:     "g1",
+	Container: "c1",
+}
+
+var backupTestDataFiles = map[string]string{
+	"config.yml":       "listen: 8080\n",
+	"db/app.db":        "some data",
+	"db/app.db-wal":    "write ahead log",
+	"cache/index.json": "{}",
+}
+
+func backupTestRunningContainer() *fakedocker.FakeDockerHostInitInfo {
+	return &fakedocker.FakeDockerHostInitInfo{
+		Containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:  "g1-c1",
+				Image: "abc/xyz",
+				State: docker.ContainerStateRunning,
+			},
+		},
+	}
+}
+
+var containerBackupRestoreTests = []struct {
+	name         string
+	backup       config.ContainerBackup
+	realExecutor bool
+	// volumeFiles are the files within the named volume of the
+	// container being backed up.
+	volumeFiles  utils.StringSet
+	wantOutput   string
+	wantFiles    map[string]string
+	wantVolFiles []string
+}{
+	{
+		name: "Container Backup Restore - Gzip",
+		backup: config.ContainerBackup{
+			Excludes: []string{"cache", "*-wal"},
+		},
+		// The excluded paths are not in the backup archive, and are
+		// carried over from the data directory as is.
+		wantFiles: map[string]string{
+			"config.yml":       "listen: 8080\n",
+			"db/app.db":        "some data",
+			"db/app.db-wal":    "newer write ahead log",
+			"cache/index.json": "{}",
+		},
+		wantOutput: `Backing up container g1-c1
+.*Stopping container g1-c1
+Writing backup archive .+/g1-c1-\d{8}T\d{6}Z\.tar\.gz
+.*Starting container g1-c1
+Backed up container g1-c1 to .+\.tar\.gz`,
+		volumeFiles: utils.StringSet{
+			"/var/lib/app/blobs/1": {},
+			"/var/lib/app/blobs/2": {},
+		},
+		wantVolFiles: []string{
+			"/var/lib/app/blobs/1",
+			"/var/lib/app/blobs/2",
+		},
+	},
+	{
+		name: "Container Backup Restore - Includes Keep Running",
+		backup: config.ContainerBackup{
+			Includes:    []string{"db"},
+			KeepRunning: true,
+		},
+		// Only the included paths are replaced, leaving the rest of the
+		// data directory untouched.
+		wantFiles: backupTestDataFiles,
+		wantOutput: `Backing up container g1-c1
+Writing backup archive .+/g1-c1-\d{8}T\d{6}Z\.tar\.gz
+Backed up container g1-c1 to .+\.tar\.gz`,
+		volumeFiles: utils.StringSet{
+			"/var/lib/app/blobs/1": {},
+		},
+		wantVolFiles: []string{
+			"/var/lib/app/blobs/1",
+		},
+	},
+	{
+		name: "Container Backup Restore - Zstd",
+		backup: config.ContainerBackup{
+			Compression: "zstd",
+		},
+		realExecutor: true,
+		wantFiles:    backupTestDataFiles,
+		wantOutput: `Backing up container g1-c1
+.*Stopping container g1-c1
+Writing backup archive .+/g1-c1-\d{8}T\d{6}Z\.tar\.zst
+.*Starting container g1-c1
+Backed up container g1-c1 to .+\.tar\.zst`,
+	},
+}
+
+func TestContainerBackupRestore(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerBackupRestoreTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.realExecutor {
+				if _, err := exec.LookPath("zstd"); err != nil {
+					t.Skip("zstd is not available on the host")
+				}
+			}
+
+			baseDir := t.TempDir()
+			dataDir := backupTestDataDir(baseDir)
+			writeBackupTestFiles(t, dataDir, backupTestDataFiles)
+			tc.backup.Destination = filepath.Join(t.TempDir(), "backups")
+
+			initInfo := backupTestRunningContainer()
+			if len(tc.volumeFiles) > 0 {
+				initInfo.ContainerFiles = map[string]utils.StringSet{
+					"g1-c1": tc.volumeFiles,
+				}
+			}
+			buf := new(bytes.Buffer)
+			_, ct, dc, ctx := newSingleTestContainer(t, tc.name, backupTestContainer, backupTestContextInfo(tc.realExecutor, buf), initInfo, backupTestConfig(baseDir, tc.backup))
+			if ct == nil {
+				return
+			}
+			defer dc.Close()
+
+			wantID := fakedocker.FakeDockerHostFromContext(ctx).ContainerID("g1-c1")
+			archive, gotErr := ct.Backup(ctx, dc)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNilWithOutput(t, "Container.Backup()", tc.name, buf, gotErr)
+				return
+			}
+			want := fmt.Sprintf(`(?s)%s\n\n$`, tc.wantOutput)
+			if !testhelpers.RegexMatch(t, "Container.Backup()", tc.name, "log output", want, buf.String()) {
+				return
+			}
+			if err := verifyBackupChecksum(archive); err != nil {
+				testhelpers.LogErrorNotNil(t, "verifyBackupChecksum()", tc.name, err)
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Container.Backup()", tc.name, "container state after backup", docker.ContainerStateRunning, fakedocker.FakeDockerHostFromContext(ctx).GetContainerState("g1-c1")) {
+				return
+			}
+			// The same container is started again rather than recreating
+			// it.
+			if !testhelpers.CmpDiff(t, "Container.Backup()", tc.name, "container ID after backup", wantID, fakedocker.FakeDockerHostFromContext(ctx).ContainerID("g1-c1")) {
+				return
+			}
+
+			// Modify the data directory after the backup, and restore
+			// into a fresh container which doesn't have any of the
+			// volume contents. The files created after the backup must
+			// not survive the restore.
+			writeBackupTestFiles(t, dataDir, map[string]string{
+				"db/app.db":     "newer data",
+				"db/app.db-wal": "newer write ahead log",
+				"db/stale.db":   "created after the backup",
+			})
+			buf = new(bytes.Buffer)
+			_, ct, dc2, ctx := newSingleTestContainer(t, tc.name, backupTestContainer, backupTestContextInfo(tc.realExecutor, buf), backupTestRunningContainer(), backupTestConfig(baseDir, tc.backup))
+			if ct == nil {
+				return
+			}
+			defer dc2.Close()
+
+			restored, gotErr := ct.Restore(ctx, dc2, "")
+			if gotErr != nil {
+				testhelpers.LogErrorNotNilWithOutput(t, "Container.Restore()", tc.name, buf, gotErr)
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Container.Restore()", tc.name, "restored archive", archive, restored) {
+				return
+			}
+			want = fmt.Sprintf(`(?s)Restoring container g1-c1 from %s
+.*Stopping container g1-c1
+.*Starting container g1-c1
+Restored container g1-c1 from %s\n\n$`, archive, archive)
+			if !testhelpers.RegexMatch(t, "Container.Restore()", tc.name, "log output", want, buf.String()) {
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Container.Restore()", tc.name, "restored data files", tc.wantFiles, readBackupTestFiles(t, dataDir)) {
+				return
+			}
+			testhelpers.CmpDiff(t, "Container.Restore()", tc.name, "restored volume files", tc.wantVolFiles, fakedocker.FakeDockerHostFromContext(ctx).ContainerFiles("g1-c1"))
+		})
+	}
+}
+
+func TestContainerBackupRetention(t *testing.T) {
+	t.Parallel()
+
+	tc := "Container Backup - Retention"
+	baseDir := t.TempDir()
+	writeBackupTestFiles(t, backupTestDataDir(baseDir), backupTestDataFiles)
+	dest := filepath.Join(t.TempDir(), "backups")
+	// The archives of other containers are left untouched.
+	writeBackupTestFiles(t, dest, map[string]string{
+		"g1-c1x-20240101T000000Z.tar.gz": "",
+		"notes.txt":                      "",
+	})
+
+	_, ct, dc, ctx := newSingleTestContainer(t, tc, backupTestContainer, backupTestContextInfo(false, new(bytes.Buffer)), &fakedocker.FakeDockerHostInitInfo{}, backupTestConfig(baseDir, config.ContainerBackup{Destination: dest, Retention: 2}))
+	if ct == nil {
+		return
+	}
+	defer dc.Close()
+
+	now := time.Date(2024, 5, 1, 2, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if _, err := ct.backup(ctx, dc, now.Add(time.Duration(i)*time.Hour)); err != nil {
+			testhelpers.LogErrorNotNil(t, "Container.backup()", tc, err)
+			return
+		}
+	}
+
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "os.ReadDir()", tc, err)
+		return
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := []string{
+		"g1-c1-20240501T033000Z.tar.gz",
+		"g1-c1-20240501T033000Z.tar.gz.sha256",
+		"g1-c1-20240501T043000Z.tar.gz",
+		"g1-c1-20240501T043000Z.tar.gz.sha256",
+		"g1-c1x-20240101T000000Z.tar.gz",
+		"notes.txt",
+	}
+	testhelpers.CmpDiff(t, "Container.backup()", tc, "backup destination contents", want, got)
+}
+
+var containerBackupRestoreErrorTests = []struct {
+	name          string
+	backup        bool
+	restore       bool
+	noDestination bool
+	conf          config.ContainerBackup
+	// tamper modifies the backup archive or its checksum prior to the
+	// restore.
+	tamper  func(archive string) error
+	archive string
+	want    string
+}{
+	{
+		name:          "Container Backup - Destination Not Configured",
+		backup:        true,
+		noDestination: true,
+		want:          `Failed to back up container g1-c1, reason:backup destination is not configured`,
+	},
+	{
+		name:   "Container Backup - Missing Include",
+		backup: true,
+		conf: config.ContainerBackup{
+			Includes: []string{"missing"},
+		},
+		want: `Failed to back up container g1-c1, reason:backup include path .+/data/missing not found, reason: .+`,
+	},
+	{
+		name:    "Container Restore - No Backups",
+		restore: true,
+		want:    `Failed to restore container g1-c1, reason:no backups found in .+/backups`,
+	},
+	{
+		name:    "Container Restore - Checksum Mismatch",
+		backup:  true,
+		restore: true,
+		tamper: func(archive string) error {
+			return os.WriteFile(archive, []byte("corrupted"), 0o640)
+		},
+		want: `Failed to restore container g1-c1, reason:checksum mismatch for the backup archive .+\.tar\.gz, expected [0-9a-f]{64} but found [0-9a-f]{64}`,
+	},
+	{
+		name:    "Container Restore - Missing Checksum",
+		backup:  true,
+		restore: true,
+		tamper: func(archive string) error {
+			return os.Remove(archive + backupChecksumSuffix)
+		},
+		want: `Failed to restore container g1-c1, reason:failed to read the checksum of the backup archive .+\.tar\.gz, reason: .+`,
+	},
+	{
+		name:    "Container Restore - Unsupported Archive",
+		restore: true,
+		archive: "backup.zip",
+		want:    `Failed to restore container g1-c1, reason:unsupported backup archive .+/backup\.zip, expected a \.tar\.gz or a \.tar\.zst archive`,
+	},
+}
+
+func TestContainerBackupRestoreErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerBackupRestoreErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			baseDir := t.TempDir()
+			writeBackupTestFiles(t, backupTestDataDir(baseDir), backupTestDataFiles)
+			conf := tc.conf
+			dest := filepath.Join(t.TempDir(), "backups")
+			if !tc.noDestination {
+				conf.Destination = dest
+			}
+
+			buf := new(bytes.Buffer)
+			_, ct, dc, ctx := newSingleTestContainer(t, tc.name, backupTestContainer, backupTestContextInfo(false, buf), backupTestRunningContainer(), backupTestConfig(baseDir, conf))
+			if ct == nil {
+				return
+			}
+			defer dc.Close()
+
+			var gotErr error
+			archive := ""
+			if tc.backup {
+				archive, gotErr = ct.Backup(ctx, dc)
+			}
+			if gotErr == nil && tc.restore {
+				if tc.tamper != nil {
+					if err := tc.tamper(archive); err != nil {
+						testhelpers.LogErrorNotNil(t, "tamper()", tc.name, err)
+						return
+					}
+				}
+				if len(tc.archive) > 0 {
+					archive = filepath.Join(dest, tc.archive)
+					writeBackupTestFiles(t, dest, map[string]string{tc.archive: ""})
+					writeBackupTestFiles(t, dest, map[string]string{tc.archive + backupChecksumSuffix: fmt.Sprintf("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  %s\n", tc.archive)})
+				}
+				_, gotErr = ct.Restore(ctx, dc, archive)
+			}
+			if gotErr == nil {
+				testhelpers.LogErrorNilWithOutput(t, "Container.Backup/Restore()", tc.name, buf, tc.want)
+				return
+			}
+			if !testhelpers.RegexMatchWithOutput(t, "Container.Backup/Restore()", tc.name, buf, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+			// The container is left running irrespective of the failure.
+			testhelpers.CmpDiff(t, "Container.Backup/Restore()", tc.name, "container state", docker.ContainerStateRunning, fakedocker.FakeDockerHostFromContext(ctx).GetContainerState("g1-c1"))
+		})
+	}
+}
+
+// backupTestEntry is an entry within a hand crafted backup archive.
+type backupTestEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+}
+
+var containerRestoreCraftedArchiveTests = []struct {
+	name    string
+	entries func(outside string) []backupTestEntry
+	want    string
+}{
+	{
+		name: "Container Restore - Write Through Symlink",
+		entries: func(outside string) []backupTestEntry {
+			return []backupTestEntry{
+				{name: "data/", typeflag: tar.TypeDir},
+				{name: "data/x", typeflag: tar.TypeSymlink, linkname: outside},
+				{name: "data/x/passwd", typeflag: tar.TypeReg, content: "pwned"},
+			}
+		},
+		want: `Failed to restore container g1-c1, reason:failed to restore x/passwd in the data directory .+, reason: refusing to write x/passwd through the symlink .+/x`,
+	},
+	{
+		name: "Container Restore - Non Contiguous Volume",
+		entries: func(outside string) []backupTestEntry {
+			return []backupTestEntry{
+				{name: "volumes/app-data-vol/", typeflag: tar.TypeDir},
+				{name: "volumes/other-vol/", typeflag: tar.TypeDir},
+				{name: "volumes/app-data-vol/blob", typeflag: tar.TypeReg, content: "blob"},
+			}
+		},
+		want: `Failed to restore container g1-c1, reason:failed to read the backup archive .+, reason: entries of the volume app-data-vol are not contiguous in the backup archive`,
+	},
+}
+
+func TestContainerRestoreCraftedArchive(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerRestoreCraftedArchiveTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			baseDir := t.TempDir()
+			outside := t.TempDir()
+			dest := filepath.Join(t.TempDir(), "backups")
+			archive := filepath.Join(dest, "g1-c1-20240501T023000Z.tar.gz")
+			writeBackupTestArchive(t, archive, tc.entries(outside))
+
+			buf := new(bytes.Buffer)
+			_, ct, dc, ctx := newSingleTestContainer(t, tc.name, backupTestContainer, backupTestContextInfo(false, buf), backupTestRunningContainer(), backupTestConfig(baseDir, config.ContainerBackup{Destination: dest}))
+			if ct == nil {
+				return
+			}
+			defer dc.Close()
+
+			_, gotErr := ct.Restore(ctx, dc, archive)
+			if gotErr == nil {
+				testhelpers.LogErrorNilWithOutput(t, "Container.Restore()", tc.name, buf, tc.want)
+				return
+			}
+			if !testhelpers.RegexMatchWithOutput(t, "Container.Restore()", tc.name, buf, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Container.Restore()", tc.name, "files outside the data directory", map[string]string{}, readBackupTestFiles(t, outside)) {
+				return
+			}
+			testhelpers.CmpDiff(t, "Container.Restore()", tc.name, "container state", docker.ContainerStateRunning, fakedocker.FakeDockerHostFromContext(ctx).GetContainerState("g1-c1"))
+		})
+	}
+}
+
+// writeBackupTestArchive writes the gzip compressed backup archive with
+// the entries, along with its checksum.
+func writeBackupTestArchive(t *testing.T, archive string, entries []backupTestEntry) {
+	t.Helper()
+
+	var data bytes.Buffer
+	gw := gzip.NewWriter(&data)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Linkname: e.linkname,
+			Mode:     0o750,
+			Size:     int64(len(e.content)),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write the header of %s, reason: %v", e.name, err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatalf("failed to write %s, reason: %v", e.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close the tar writer, reason: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to close the gzip writer, reason: %v", err)
+	}
+
+	sum := sha256.Sum256(data.Bytes())
+	writeBackupTestFiles(t, filepath.Dir(archive), map[string]string{
+		filepath.Base(archive):                        data.String(),
+		filepath.Base(archive) + backupChecksumSuffix: fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(archive)),
+	})
+}
+
+func backupTestDataDir(baseDir string) string {
+	return env.ContainerDataDir(containerBaseDir(baseDir, backupTestContainer))
+}
+
+func writeBackupTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("failed to create directory for %s, reason: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o640); err != nil {
+			t.Fatalf("failed to write %s, reason: %v", path, err)
+		}
+	}
+}
+
+func readBackupTestFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	res := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		res[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read the files under %s, reason: %v", dir, err)
+	}
+	return res
+}
+
+func backupTestContextInfo(realExecutor bool, buf *bytes.Buffer) *testutils.TestContextInfo {
+	ctxInfo := &testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+	}
+	if realExecutor {
+		ctxInfo.Executor = cmdexec.NewExecutor()
+	}
+	return ctxInfo
+}
+
+// backupTestConfig returns the update for the single container config
+// using the base directory and the backup config, along with the data
+// volume mounted within the container.
+func backupTestConfig(baseDir string, backup config.ContainerBackup) func(*config.Homelab) {
+	return func(conf *config.Homelab) {
+		conf.Global.BaseDir = baseDir
+		conf.Containers[0].Backup = backup
+		conf.Containers[0].Filesystem.Mounts = []config.Mount{
+			{
+				Name: "app-data",
+				Type: "bind",
+				Src:  "app-data-vol",
+				Dst:  "/var/lib/app",
+			},
+		}
+	}
+}
@@ -3,6 +3,7 @@ package deployment
 import (
 	"context"
 	"fmt"
+	"os"
 	"slices"
 	"sort"
 	"strings"
@@ -12,9 +13,9 @@ import (
 	dmount "github.com/docker/docker/api/types/mount"
 	dnetwork "github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
-	"github.com/tuxdudehomelab/homelab/internal/cmdexec"
 	"github.com/tuxdudehomelab/homelab/internal/config"
 	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
 	"github.com/tuxdudehomelab/homelab/internal/utils"
 )
 
@@ -28,7 +29,10 @@ type Container struct {
 	globalConfig  *config.Global
 	group         *ContainerGroup
 	endpoints     networkEndpointList
+	envFileEnv    []config.ContainerEnv
 	allowedOnHost bool
+	// Digest the image is pinned to by the image lock, if any.
+	imageDigest string
 }
 
 type containerNetworkEndpoint struct {
@@ -48,12 +52,13 @@ type containerSet map[config.ContainerReference]bool
 type containerMap map[config.ContainerReference]*Container
 type containerDockerConfigMap map[config.ContainerReference]*containerDockerConfigs
 
-func newContainer(group *ContainerGroup, config *config.Container, globalConfig *config.Global, endpoints networkEndpointList, allowedOnHost bool) *Container {
+func newContainer(group *ContainerGroup, config *config.Container, globalConfig *config.Global, endpoints networkEndpointList, envFileEnv []config.ContainerEnv, allowedOnHost bool) *Container {
 	return &Container{
 		config:        config,
 		globalConfig:  globalConfig,
 		group:         group,
 		endpoints:     endpoints,
+		envFileEnv:    envFileEnv,
 		allowedOnHost: allowedOnHost,
 	}
 }
@@ -123,16 +128,21 @@ func (c *Container) Purge(ctx context.Context, dc *docker.Client) (bool, error)
 }
 
 func (c *Container) startInternal(ctx context.Context, dc *docker.Client) error {
+	// Validate the hooks and the wait for condition upfront rather than
+	// failing midway.
+	if err := c.validateHooksOnHost(ctx, c.startPreHook(), c.startPostHook()); err != nil {
+		return err
+	}
+	if err := c.validateWaitForOnHost(ctx); err != nil {
+		return err
+	}
+	if err := c.validateEnvOnHost(ctx); err != nil {
+		return err
+	}
+
 	// 1. Execute start pre-hook command if specified.
-	if len(c.config.Lifecycle.StartPreHook) > 0 {
-		log(ctx).Infof("Output from start pre-hook for container %s >>>", c.Name())
-		cmd := c.config.Lifecycle.StartPreHook
-		exec := cmdexec.MustExecutor(ctx)
-		out, err := exec.Run(cmd[0], cmd[1:]...)
-		log(ctx).Printf("%s", strings.TrimSpace(out))
-		if err != nil {
-			return fmt.Errorf("encountered error while running the start pre-hook for container %s, reason: %w", c.Name(), err)
-		}
+	if err := c.runHook(ctx, dc, c.startPreHook()); err != nil {
+		return err
 	}
 
 	// 2. Pull the container image.
@@ -200,10 +210,26 @@ func (c *Container) startInternal(ctx context.Context, dc *docker.Client) error
 	// 7. Start the created container.
 	log(ctx).Infof("Starting container %s", c.Name())
 	err = dc.StartContainer(ctx, c.Name())
-	return err
+	if err != nil {
+		return err
+	}
+
+	// 8. Wait for the container to be ready if requested, prior to
+	// starting any containers that follow.
+	if err := c.waitForReady(ctx, dc); err != nil {
+		return err
+	}
+
+	// 9. Execute start post-hook if specified.
+	return c.runHook(ctx, dc, c.startPostHook())
 }
 
 func (c *Container) stopInternal(ctx context.Context, dc *docker.Client) (bool, docker.ContainerState, error) {
+	// Validate the hooks upfront rather than failing midway.
+	if err := c.validateHooksOnHost(ctx, c.stopPreHook(), c.stopPostHook()); err != nil {
+		return false, docker.ContainerStateUnknown, err
+	}
+
 	st, err := dc.GetContainerState(ctx, c.Name())
 	if err != nil {
 		return false, docker.ContainerStateUnknown, err
@@ -226,11 +252,21 @@ func (c *Container) stopInternal(ctx context.Context, dc *docker.Client) (bool,
 			}
 		}
 
+		// Execute stop pre-hook if specified.
+		if err := c.runHook(ctx, dc, c.stopPreHook()); err != nil {
+			return false, st, err
+		}
+
 		// Stop the container.
 		log(ctx).Infof("Stopping container %s", c.Name())
 		if err := dc.StopContainer(ctx, c.Name()); err != nil {
 			return false, st, err
 		}
+
+		// Execute stop post-hook if specified.
+		if err := c.runHook(ctx, dc, c.stopPostHook()); err != nil {
+			return false, st, err
+		}
 		return true, st, nil
 	case docker.ContainerStateCreated, docker.ContainerStateExited, docker.ContainerStateDead, docker.ContainerStateRemoving:
 		// Container is already stopped in this state.
@@ -378,6 +414,10 @@ func (c *Container) dockerNetworkConfig() *dnetwork.NetworkingConfig {
 	}
 }
 
+func (c *Container) groupConfig() *config.GroupContainer {
+	return &c.group.config.Container
+}
+
 func (c *Container) Name() string {
 	return containerName(&c.config.Info)
 }
@@ -388,6 +428,9 @@ func (c *Container) hostName() string {
 
 func (c *Container) domainName() string {
 	d := c.config.Network.DomainName
+	if len(d) == 0 {
+		d = c.groupConfig().DomainName
+	}
 	if len(d) == 0 {
 		d = c.globalConfig.Container.DomainName
 	}
@@ -410,15 +453,26 @@ func (c *Container) attachToTty() bool {
 func (c *Container) envVars() []string {
 	env := make(map[string]string, 0)
 	envKeys := make([]string, 0)
-	for _, e := range c.globalConfig.Container.Env {
-		env[e.Var] = e.Value
-		envKeys = append(envKeys, e.Var)
-	}
-	for _, e := range c.config.Runtime.Env {
-		if _, found := env[e.Var]; !found {
-			envKeys = append(envKeys, e.Var)
+	// Apply the env vars in the order of precedence - global, group,
+	// the container env files and finally the container. The env vars
+	// retain the position where they were first set.
+	for _, envs := range [][]config.ContainerEnv{c.globalConfig.Container.Env, c.groupConfig().Env, c.envFileEnv, c.config.Runtime.Env} {
+		for _, e := range envs {
+			val := e.Value
+			if e.FromHost {
+				// Similar to docker, env vars passed through from the
+				// host are skipped when not set.
+				v, found := os.LookupEnv(e.Var)
+				if !found {
+					continue
+				}
+				val = v
+			}
+			if _, found := env[e.Var]; !found {
+				envKeys = append(envKeys, e.Var)
+			}
+			env[e.Var] = val
 		}
-		env[e.Var] = e.Value
 	}
 
 	res := make([]string, 0)
@@ -432,6 +486,24 @@ func (c *Container) envVars() []string {
 	return res
 }
 
+// validateEnvOnHost returns an error if any of the env vars is passed
+// through from the host while the container is managed on a remote host,
+// since the env vars would otherwise be read from the local host instead.
+func (c *Container) validateEnvOnHost(ctx context.Context) error {
+	h := host.MustHostInfo(ctx)
+	if !h.Remote {
+		return nil
+	}
+	for _, envs := range [][]config.ContainerEnv{c.globalConfig.Container.Env, c.groupConfig().Env, c.config.Runtime.Env} {
+		for _, e := range envs {
+			if e.FromHost {
+				return fmt.Errorf("env var %s for container %s is passed through from the host, which is not supported while managing the remote host %s", e.Var, c.Name(), h.HostName)
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Container) args() []string {
 	return c.config.Runtime.Args
 }
@@ -484,8 +556,12 @@ func (c *Container) isNetworkDisabled() bool {
 
 func (c *Container) labels() map[string]string {
 	res := make(map[string]string, 0)
-	for _, l := range c.config.Metadata.Labels {
-		res[l.Name] = l.Value
+	// Apply the labels in the order of precedence - global, group
+	// and finally the container.
+	for _, labels := range [][]config.Label{c.globalConfig.Container.Labels, c.groupConfig().Labels, c.config.Metadata.Labels} {
+		for _, l := range labels {
+			res[l.Name] = l.Value
+		}
 	}
 	if len(res) == 0 {
 		return nil
@@ -509,7 +585,16 @@ func (c *Container) stopTimeout() *int {
 }
 
 func (c *Container) imageReference() string {
-	return c.config.Image.Image
+	img := c.config.Image.Image
+	if len(c.imageDigest) == 0 {
+		return img
+	}
+	// Strip the tag, if any, from the last path component alone since
+	// the registry host may include a port.
+	if i := strings.LastIndex(img, ":"); i > strings.LastIndex(img, "/") {
+		img = img[:i]
+	}
+	return fmt.Sprintf("%s@%s", img, c.imageDigest)
 }
 
 func (c *Container) bindMounts() []string {
@@ -556,6 +641,10 @@ func (c *Container) publishedPorts() (nat.PortMap, nat.PortSet) {
 func (c *Container) restartPolicy() dcontainer.RestartPolicy {
 	mode := c.config.Lifecycle.RestartPolicy.Mode
 	maxRetry := c.config.Lifecycle.RestartPolicy.MaxRetryCount
+	if len(mode) == 0 {
+		mode = c.groupConfig().RestartPolicy.Mode
+		maxRetry = c.groupConfig().RestartPolicy.MaxRetryCount
+	}
 	if len(mode) == 0 {
 		mode = c.globalConfig.Container.RestartPolicy.Mode
 		maxRetry = c.globalConfig.Container.RestartPolicy.MaxRetryCount
@@ -583,15 +672,26 @@ func (c *Container) capDropList() []string {
 }
 
 func (c *Container) dnsServers() []string {
-	return c.config.Network.DNSServers
+	d := c.config.Network.DNSServers
+	if len(d) == 0 {
+		d = c.groupConfig().DNSServers
+	}
+	return d
 }
 
 func (c *Container) dnsOptions() []string {
-	return c.config.Network.DNSOptions
+	d := c.config.Network.DNSOptions
+	if len(d) == 0 {
+		d = c.groupConfig().DNSOptions
+	}
+	return d
 }
 
 func (c *Container) dnsSearch() []string {
 	d := c.config.Network.DNSSearch
+	if len(d) == 0 {
+		d = c.groupConfig().DNSSearch
+	}
 	if len(d) == 0 {
 		d = c.globalConfig.Container.DNSSearch
 	}
@@ -755,9 +855,10 @@ func (c *Container) mountsOfType(mountType string) []*mountSpec {
 			containerMountNames = append(containerMountNames, mount.Name)
 		}
 	}
-	// Get all the container specific mount configs and apply
-	// them as overrides for the global.
-	for _, mount := range c.config.Filesystem.Mounts {
+	// Get all the group container config mounts and the container
+	// specific mount configs, and apply them as overrides for the
+	// global.
+	for _, mount := range slices.Concat(c.groupConfig().Mounts, c.config.Filesystem.Mounts) {
 		if val, found := globalMountDefs[mount.Name]; found {
 			containerMounts[mount.Name] = val
 			containerMountNames = append(containerMountNames, mount.Name)
@@ -6,10 +6,12 @@ import (
 	"strings"
 
 	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/config/env"
 )
 
 type ContainerGroup struct {
 	config          *config.ContainerGroup
+	configEnv       *env.ConfigEnvManager
 	containers      containerMap
 	containersOrder []config.ContainerReference
 }
@@ -23,8 +25,8 @@ func NewContainerGroup(groupConfig *config.ContainerGroup) *ContainerGroup {
 	}
 }
 
-func (c *ContainerGroup) addContainer(config *config.Container, globalConfig *config.Global, endpoints networkEndpointList, isAllowedOnCurrentHost bool) {
-	ct := newContainer(c, config, globalConfig, endpoints, isAllowedOnCurrentHost)
+func (c *ContainerGroup) addContainer(config *config.Container, globalConfig *config.Global, endpoints networkEndpointList, envFileEnv []config.ContainerEnv, isAllowedOnCurrentHost bool) {
+	ct := newContainer(c, config, globalConfig, endpoints, envFileEnv, isAllowedOnCurrentHost)
 	c.containers[config.Info] = ct
 }
 
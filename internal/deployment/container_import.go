@@ -0,0 +1,515 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	dcontainer "github.com/docker/docker/api/types/container"
+	dmount "github.com/docker/docker/api/types/mount"
+	dnetwork "github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+)
+
+const (
+	// Default size of /dev/shm used by docker.
+	defaultShmSize = 64 * units.MiB
+	// Length of the container ID prefix used by docker as the default
+	// host name.
+	shortIDLength = 12
+	// Prefix used by docker for the default bridge interface names of
+	// the user defined networks.
+	bridgeInterfacePrefix = "br-"
+	bridgeNameOption      = "com.docker.network.bridge.name"
+	composeLabelPrefix    = "com.docker.compose."
+	containerModePrefix   = "container:"
+)
+
+// ImportedContainer represents the homelab config imported from an
+// existing docker container.
+type ImportedContainer struct {
+	// Config contains the group, the container and the IPAM entries for
+	// the networks the container is connected to.
+	Config config.Homelab
+	// Unsupported lists the docker container settings that could not be
+	// imported.
+	Unsupported []string
+}
+
+type containerImporter struct {
+	ctx         context.Context
+	dc          *docker.Client
+	ref         config.ContainerReference
+	unsupported []string
+}
+
+// ContainerReferenceFromDockerName returns the group and the container
+// for the docker container name, i.e. the reverse of the naming used
+// for the homelab containers.
+func ContainerReferenceFromDockerName(name string) (config.ContainerReference, bool) {
+	g, c, found := strings.Cut(strings.TrimPrefix(name, "/"), "-")
+	if !found || len(g) == 0 || len(c) == 0 {
+		return config.ContainerReference{}, false
+	}
+	return config.ContainerReference{Group: g, Container: c}, true
+}
+
+// ImportContainer imports the existing docker container as the specified
+// homelab container, i.e. the reverse of the docker configs generated
+// for the homelab containers. The settings matching the defaults from
+// the container image are omitted.
+func ImportContainer(ctx context.Context, dc *docker.Client, dockerName string, ref config.ContainerReference) (*ImportedContainer, error) {
+	ct, err := dc.InspectContainer(ctx, dockerName)
+	if err != nil {
+		return nil, err
+	}
+	if ct.ContainerJSONBase == nil || ct.Config == nil || ct.HostConfig == nil {
+		return nil, fmt.Errorf("incomplete inspect info for the container %s", dockerName)
+	}
+	imgConfig, err := dc.ImageConfig(ctx, ct.Config.Image)
+	if err != nil {
+		// Fall back to the image ID of the container in case the image
+		// reference has been updated or removed since.
+		imgConfig, err = dc.ImageConfig(ctx, ct.Image)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	im := &containerImporter{ctx: ctx, dc: dc, ref: ref}
+	res := &ImportedContainer{}
+	res.Config.Groups = []config.ContainerGroup{
+		{
+			Name:  ref.Group,
+			Order: 1,
+		},
+	}
+	res.Config.Containers = []config.Container{im.container(&ct, imgConfig)}
+	if res.Config.IPAM.Networks, err = im.networks(&ct); err != nil {
+		return nil, err
+	}
+	res.Unsupported = im.unsupported
+	return res, nil
+}
+
+func (im *containerImporter) container(ct *dtypes.ContainerJSON, img *dcontainer.Config) config.Container {
+	cConfig := ct.Config
+	hConfig := ct.HostConfig
+	res := config.Container{
+		Info: im.ref,
+		Image: config.ContainerImage{
+			Image: cConfig.Image,
+		},
+	}
+	res.Lifecycle.Order = 1
+
+	if cConfig.Hostname != shortID(ct.ID) {
+		res.Network.HostName = cConfig.Hostname
+	}
+	res.Network.DomainName = cConfig.Domainname
+	if cConfig.User != img.User {
+		res.User.User, res.User.PrimaryGroup, _ = strings.Cut(cConfig.User, ":")
+	}
+	res.Runtime.AttachToTty = cConfig.Tty
+	res.Runtime.Env = importEnv(cConfig.Env, img.Env)
+	if !slices.Equal(cConfig.Entrypoint, img.Entrypoint) {
+		res.Runtime.Entrypoint = cConfig.Entrypoint
+	}
+	if !slices.Equal(cConfig.Cmd, img.Cmd) {
+		res.Runtime.Args = cConfig.Cmd
+	}
+	if !reflect.DeepEqual(cConfig.Healthcheck, img.Healthcheck) {
+		res.Health = im.health(cConfig.Healthcheck)
+	}
+	res.Metadata.Labels = importLabels(cConfig.Labels, img.Labels)
+	if cConfig.StopSignal != img.StopSignal {
+		res.Lifecycle.StopSignal = cConfig.StopSignal
+	}
+	if cConfig.StopTimeout != nil {
+		res.Lifecycle.StopTimeout = *cConfig.StopTimeout
+	}
+	if cConfig.WorkingDir != img.WorkingDir {
+		im.addUnsupported("working dir", cConfig.WorkingDir)
+	}
+
+	res.Filesystem.Mounts = im.mounts(hConfig)
+	res.Filesystem.ReadOnlyRootfs = hConfig.ReadonlyRootfs
+	res.Filesystem.Devices.Static = importDevices(hConfig.Devices)
+	res.Network.PublishedPorts = im.publishedPorts(hConfig)
+	res.Network.DNSServers = hConfig.DNS
+	res.Network.DNSOptions = hConfig.DNSOptions
+	res.Network.DNSSearch = hConfig.DNSSearch
+	res.Network.ExtraHosts = hConfig.ExtraHosts
+	res.User.AdditionalGroups = hConfig.GroupAdd
+	res.Security.Privileged = hConfig.Privileged
+	res.Security.CapAdd = hConfig.CapAdd
+	res.Security.CapDrop = hConfig.CapDrop
+	res.Security.Sysctls = importSysctls(hConfig.Sysctls)
+	res.Lifecycle.AutoRemove = hConfig.AutoRemove
+	if rp := hConfig.RestartPolicy; len(rp.Name) > 0 && rp.Name != dcontainer.RestartPolicyDisabled {
+		res.Lifecycle.RestartPolicy = config.ContainerRestartPolicy{
+			Mode:          string(rp.Name),
+			MaxRetryCount: rp.MaximumRetryCount,
+		}
+	}
+	if hConfig.ShmSize != 0 && hConfig.ShmSize != defaultShmSize {
+		res.Runtime.ShmSize = strconv.FormatInt(hConfig.ShmSize, 10)
+	}
+
+	im.checkUnsupportedHostConfig(hConfig)
+	return res
+}
+
+func (im *containerImporter) health(h *dcontainer.HealthConfig) config.ContainerHealth {
+	res := config.ContainerHealth{}
+	if h == nil {
+		return res
+	}
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "CMD":
+			res.Cmd = h.Test[1:]
+		case "CMD-SHELL":
+			res.Cmd = append([]string{"/bin/sh", "-c"}, h.Test[1:]...)
+		default:
+			im.addUnsupported("health check test", strings.Join(h.Test, " "))
+		}
+	}
+	res.Retries = h.Retries
+	res.Interval = durationString(h.Interval)
+	res.Timeout = durationString(h.Timeout)
+	res.StartPeriod = durationString(h.StartPeriod)
+	res.StartInterval = durationString(h.StartInterval)
+	return res
+}
+
+func (im *containerImporter) mounts(h *dcontainer.HostConfig) []config.Mount {
+	var res []config.Mount
+	names := map[string]struct{}{}
+	add := func(m config.Mount) {
+		m.Name = importedMountName(m.Dst, names)
+		res = append(res, m)
+	}
+
+	for _, b := range h.Binds {
+		parts := strings.Split(b, ":")
+		if len(parts) < 2 {
+			im.addUnsupported("volume", b)
+			continue
+		}
+		if !strings.HasPrefix(parts[0], "/") {
+			im.addUnsupported("named volume", b)
+			continue
+		}
+		m := config.Mount{Type: "bind", Src: parts[0], Dst: parts[1]}
+		if len(parts) > 2 {
+			for _, opt := range strings.Split(parts[2], ",") {
+				switch opt {
+				case "ro":
+					m.ReadOnly = true
+				case "rw":
+				default:
+					im.addUnsupported("bind mount option", fmt.Sprintf("%s for %s", opt, m.Dst))
+				}
+			}
+		}
+		add(m)
+	}
+
+	for _, mt := range h.Mounts {
+		switch mt.Type {
+		case dmount.TypeBind:
+			add(config.Mount{Type: "bind", Src: mt.Source, Dst: mt.Target, ReadOnly: mt.ReadOnly})
+		case dmount.TypeTmpfs:
+			m := config.Mount{Type: "tmpfs", Dst: mt.Target}
+			if mt.TmpfsOptions != nil {
+				m.TmpfsSize = mt.TmpfsOptions.SizeBytes
+			}
+			add(m)
+		default:
+			im.addUnsupported(fmt.Sprintf("%s mount", mt.Type), mt.Target)
+		}
+	}
+
+	tmpfs := make([]string, 0, len(h.Tmpfs))
+	for dst := range h.Tmpfs {
+		tmpfs = append(tmpfs, dst)
+	}
+	sort.Strings(tmpfs)
+	for _, dst := range tmpfs {
+		m := config.Mount{Type: "tmpfs", Dst: dst}
+		for _, opt := range strings.Split(h.Tmpfs[dst], ",") {
+			k, v, _ := strings.Cut(opt, "=")
+			size, err := units.RAMInBytes(v)
+			if k == "size" && err == nil {
+				m.TmpfsSize = size
+			} else if len(opt) > 0 {
+				im.addUnsupported("tmpfs option", fmt.Sprintf("%s for %s", opt, dst))
+			}
+		}
+		add(m)
+	}
+	return res
+}
+
+func (im *containerImporter) publishedPorts(h *dcontainer.HostConfig) []config.PublishedPort {
+	ports := make([]nat.Port, 0, len(h.PortBindings))
+	for p := range h.PortBindings {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i] < ports[j]
+	})
+
+	var res []config.PublishedPort
+	for _, p := range ports {
+		ctPort, proto := p.Port(), p.Proto()
+		for _, b := range h.PortBindings[p] {
+			if len(b.HostPort) == 0 {
+				im.addUnsupported("ephemeral host port for container port", string(p))
+				continue
+			}
+			hostIP := b.HostIP
+			if len(hostIP) == 0 {
+				hostIP = "0.0.0.0"
+			}
+			res = append(res, config.PublishedPort{
+				ContainerPort: ctPort,
+				Protocol:      proto,
+				HostIP:        hostIP,
+				HostPort:      b.HostPort,
+			})
+		}
+	}
+	return res
+}
+
+func (im *containerImporter) checkUnsupportedHostConfig(h *dcontainer.HostConfig) {
+	if h.Memory != 0 || h.NanoCPUs != 0 || h.CPUShares != 0 || len(h.CpusetCpus) > 0 || h.PidsLimit != nil {
+		im.addUnsupported("resource limits", "memory, CPU and pids limits")
+	}
+	for _, s := range []struct {
+		desc string
+		val  string
+	}{
+		{"pid mode", string(h.PidMode)},
+		{"ipc mode", string(h.IpcMode)},
+		{"uts mode", string(h.UTSMode)},
+		{"userns mode", string(h.UsernsMode)},
+		{"cgroupns mode", string(h.CgroupnsMode)},
+		{"runtime", h.Runtime},
+	} {
+		// The private IPC mode, the private cgroupns mode and the runc
+		// runtime are the docker defaults.
+		if len(s.val) > 0 && s.val != "private" && s.val != "runc" {
+			im.addUnsupported(s.desc, s.val)
+		}
+	}
+	for _, s := range h.SecurityOpt {
+		im.addUnsupported("security option", s)
+	}
+	for _, u := range h.Ulimits {
+		im.addUnsupported("ulimit", u.Name)
+	}
+	for _, v := range h.VolumesFrom {
+		im.addUnsupported("volumes from", v)
+	}
+	for _, l := range h.Links {
+		im.addUnsupported("link", l)
+	}
+}
+
+func (im *containerImporter) networks(ct *dtypes.ContainerJSON) (config.Networks, error) {
+	res := config.Networks{}
+	mode := string(ct.HostConfig.NetworkMode)
+	if target, found := strings.CutPrefix(mode, containerModePrefix); found {
+		other, err := im.dc.InspectContainer(im.ctx, target)
+		if err != nil {
+			return res, err
+		}
+		otherRef, ok := ContainerReferenceFromDockerName(other.Name)
+		if !ok {
+			im.addUnsupported("network mode", mode)
+			return res, nil
+		}
+		res.ContainerModeNetworks = []config.ContainerModeNetwork{
+			{
+				Name:                containerName(&otherRef),
+				Container:           otherRef,
+				AttachingContainers: []config.ContainerReference{im.ref},
+			},
+		}
+		return res, nil
+	}
+
+	var names []string
+	if ct.NetworkSettings != nil {
+		for n := range ct.NetworkSettings.Networks {
+			names = append(names, n)
+		}
+	}
+	// The network in the network mode is the primary network and hence
+	// is assigned the lowest priority, followed by the rest in the order
+	// of their names.
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == mode) != (names[j] == mode) {
+			return names[i] == mode
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		if name == "bridge" || name == "host" || name == "none" {
+			im.addUnsupported("network", name)
+			continue
+		}
+		n, err := im.dc.InspectNetwork(im.ctx, name)
+		if err != nil {
+			return res, err
+		}
+		bn := config.BridgeModeNetwork{
+			Name:              name,
+			HostInterfaceName: n.Options[bridgeNameOption],
+			Priority:          len(res.BridgeModeNetworks) + 1,
+		}
+		if len(bn.HostInterfaceName) == 0 {
+			bn.HostInterfaceName = bridgeInterfacePrefix + shortID(n.ID)
+		}
+		for _, c := range n.IPAM.Config {
+			if !strings.Contains(c.Subnet, ":") {
+				bn.CIDR = c.Subnet
+				break
+			}
+		}
+		if n.Driver != "bridge" {
+			im.addUnsupported("network driver", fmt.Sprintf("%s for network %s", n.Driver, name))
+		}
+
+		ip := ""
+		if e := ct.NetworkSettings.Networks[name]; e != nil {
+			ip = endpointIP(e)
+		}
+		bn.Containers = []config.ContainerIP{
+			{
+				IP:        ip,
+				Container: im.ref,
+			},
+		}
+		res.BridgeModeNetworks = append(res.BridgeModeNetworks, bn)
+	}
+	return res, nil
+}
+
+func (im *containerImporter) addUnsupported(desc string, val string) {
+	im.unsupported = append(im.unsupported, fmt.Sprintf("%s: %s", desc, val))
+}
+
+func endpointIP(e *dnetwork.EndpointSettings) string {
+	if e.IPAMConfig != nil && len(e.IPAMConfig.IPv4Address) > 0 {
+		return e.IPAMConfig.IPv4Address
+	}
+	return e.IPAddress
+}
+
+func importEnv(env, imgEnv []string) []config.ContainerEnv {
+	var res []config.ContainerEnv
+	for _, e := range env {
+		if slices.Contains(imgEnv, e) {
+			continue
+		}
+		k, v, _ := strings.Cut(e, "=")
+		ce := config.ContainerEnv{Var: k, Value: v}
+		if len(v) == 0 {
+			ce.Empty = true
+		}
+		res = append(res, ce)
+	}
+	return res
+}
+
+func importLabels(labels, imgLabels map[string]string) []config.Label {
+	var res []config.Label
+	for k, v := range labels {
+		if iv, found := imgLabels[k]; found && iv == v {
+			continue
+		}
+		// The labels set by docker compose are only meaningful to
+		// docker compose.
+		if strings.HasPrefix(k, composeLabelPrefix) {
+			continue
+		}
+		res = append(res, config.Label{Name: k, Value: v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func importSysctls(sysctls map[string]string) []config.Sysctl {
+	var res []config.Sysctl
+	for k, v := range sysctls {
+		res = append(res, config.Sysctl{Key: k, Value: v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
+
+func importDevices(devices []dcontainer.DeviceMapping) []config.Device {
+	var res []config.Device
+	for _, d := range devices {
+		dev := config.Device{
+			Src:           d.PathOnHost,
+			DisallowRead:  !strings.Contains(d.CgroupPermissions, "r"),
+			DisallowWrite: !strings.Contains(d.CgroupPermissions, "w"),
+			DisallowMknod: !strings.Contains(d.CgroupPermissions, "m"),
+		}
+		if d.PathInContainer != d.PathOnHost {
+			dev.Dst = d.PathInContainer
+		}
+		res = append(res, dev)
+	}
+	return res
+}
+
+func importedMountName(dst string, names map[string]struct{}) string {
+	base := strings.ReplaceAll(strings.Trim(dst, "/"), "/", "-")
+	if len(base) == 0 {
+		base = "root"
+	}
+	name := base
+	for i := 2; ; i++ {
+		if _, found := names[name]; !found {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	names[name] = struct{}{}
+	return name
+}
+
+// shortID returns the short form of the docker object ID.
+func shortID(id string) string {
+	if len(id) > shortIDLength {
+		return id[:shortIDLength]
+	}
+	return id
+}
+
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
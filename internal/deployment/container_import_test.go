@@ -0,0 +1,570 @@
+package deployment
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	dmount "github.com/docker/docker/api/types/mount"
+	dnetwork "github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+var importContainerTests = []struct {
+	name            string
+	dockerName      string
+	cRef            config.ContainerReference
+	ctxInfo         *testutils.TestContextInfo
+	wantConfig      config.Homelab
+	wantUnsupported []string
+}{
+	{
+		name:       "Import Container - Minimal",
+		dockerName: "g1-c1",
+		cRef: config.ContainerReference{
+			Group:     "g1",
+			Container: "c1",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				Containers: []*fakedocker.FakeContainerInitInfo{
+					{
+						Name:  "g1-c1",
+						Image: "abc/xyz",
+						State: docker.ContainerStateRunning,
+						Config: &dcontainer.Config{
+							Env: []string{
+								"PATH=/usr/bin:/bin",
+							},
+							Cmd: []string{"serve"},
+						},
+					},
+				},
+				ImageConfigs: map[string]*dcontainer.Config{
+					"abc/xyz": {
+						Env: []string{
+							"PATH=/usr/bin:/bin",
+						},
+						Cmd: []string{"serve"},
+					},
+				},
+			}),
+		},
+		wantConfig: config.Homelab{
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "Import Container - Everything",
+		dockerName: "my-app",
+		cRef: config.ContainerReference{
+			Group:     "my",
+			Container: "app",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				Containers: []*fakedocker.FakeContainerInitInfo{
+					{
+						Name:  "my-app",
+						Image: "abc/app:1.2",
+						State: docker.ContainerStateRunning,
+						Config: &dcontainer.Config{
+							Hostname:   "app-host",
+							Domainname: "example.tld",
+							User:       "1000:1000",
+							Env: []string{
+								"PATH=/usr/bin:/bin",
+								"APP_MODE=prod",
+								"APP_EMPTY=",
+							},
+							Entrypoint: []string{"/entry.sh"},
+							Cmd:        []string{"--verbose"},
+							Healthcheck: &dcontainer.HealthConfig{
+								Test:     []string{"CMD-SHELL", "curl -f http://localhost"},
+								Interval: 30 * time.Second,
+								Retries:  3,
+							},
+							Labels: map[string]string{
+								"maintainer":                 "abc",
+								"app.role":                   "frontend",
+								"com.docker.compose.project": "my",
+							},
+							StopSignal: "SIGINT",
+							WorkingDir: "/srv",
+						},
+						HostConfig: &dcontainer.HostConfig{
+							NetworkMode: "my-net",
+							Binds: []string{
+								"/data/app/config:/config:ro",
+								"app-cache:/cache",
+							},
+							Mounts: []dmount.Mount{
+								{
+									Type:   dmount.TypeBind,
+									Source: "/etc/localtime",
+									Target: "/etc/localtime",
+								},
+							},
+							Tmpfs: map[string]string{
+								"/tmp": "size=10m",
+							},
+							PortBindings: nat.PortMap{
+								"80/tcp": []nat.PortBinding{
+									{
+										HostIP:   "127.0.0.1",
+										HostPort: "8080",
+									},
+								},
+								"53/udp": []nat.PortBinding{
+									{
+										HostPort: "5353",
+									},
+								},
+							},
+							DNS:     []string{"1.1.1.1"},
+							CapAdd:  []string{"NET_ADMIN"},
+							Sysctls: map[string]string{"net.ipv4.ip_forward": "1"},
+							RestartPolicy: dcontainer.RestartPolicy{
+								Name:              dcontainer.RestartPolicyOnFailure,
+								MaximumRetryCount: 5,
+							},
+							ShmSize: 64 * 1024 * 1024,
+							IpcMode: "private",
+							Resources: dcontainer.Resources{
+								Memory: 1024 * 1024 * 1024,
+							},
+							SecurityOpt: []string{"no-new-privileges"},
+						},
+						NetworkConfig: &dnetwork.NetworkingConfig{
+							EndpointsConfig: map[string]*dnetwork.EndpointSettings{
+								"my-net": {
+									IPAMConfig: &dnetwork.EndpointIPAMConfig{
+										IPv4Address: "172.18.10.5",
+									},
+								},
+								"another-net": {
+									IPAddress: "172.18.20.7",
+								},
+								"bridge": {
+									IPAddress: "172.17.0.2",
+								},
+							},
+						},
+					},
+				},
+				Networks: []*fakedocker.FakeNetworkInitInfo{
+					{
+						Name: "my-net",
+						Options: &dnetwork.CreateOptions{
+							Driver: "bridge",
+							Options: map[string]string{
+								"com.docker.network.bridge.name": "br-my-net",
+							},
+							IPAM: &dnetwork.IPAM{
+								Config: []dnetwork.IPAMConfig{
+									{
+										Subnet: "fd00::/64",
+									},
+									{
+										Subnet: "172.18.10.0/24",
+									},
+								},
+							},
+						},
+					},
+					{
+						Name: "another-net",
+						Options: &dnetwork.CreateOptions{
+							Driver: "macvlan",
+							Options: map[string]string{
+								"com.docker.network.bridge.name": "docker-another",
+							},
+							IPAM: &dnetwork.IPAM{
+								Config: []dnetwork.IPAMConfig{
+									{
+										Subnet: "172.18.20.0/24",
+									},
+								},
+							},
+						},
+					},
+				},
+				ImageConfigs: map[string]*dcontainer.Config{
+					"abc/app:1.2": {
+						Env: []string{
+							"PATH=/usr/bin:/bin",
+						},
+						Cmd: []string{"--quiet"},
+						Labels: map[string]string{
+							"maintainer": "abc",
+						},
+					},
+				},
+			}),
+		},
+		wantConfig: config.Homelab{
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "my-net",
+							HostInterfaceName: "br-my-net",
+							CIDR:              "172.18.10.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.10.5",
+									Container: config.ContainerReference{
+										Group:     "my",
+										Container: "app",
+									},
+								},
+							},
+						},
+						{
+							Name:              "another-net",
+							HostInterfaceName: "docker-another",
+							CIDR:              "172.18.20.0/24",
+							Priority:          2,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.20.7",
+									Container: config.ContainerReference{
+										Group:     "my",
+										Container: "app",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "my",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "my",
+						Container: "app",
+					},
+					Image: config.ContainerImage{
+						Image: "abc/app:1.2",
+					},
+					Metadata: config.ContainerMetadata{
+						Labels: []config.Label{
+							{
+								Name:  "app.role",
+								Value: "frontend",
+							},
+						},
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order:      1,
+						StopSignal: "SIGINT",
+						RestartPolicy: config.ContainerRestartPolicy{
+							Mode:          "on-failure",
+							MaxRetryCount: 5,
+						},
+					},
+					User: config.ContainerUser{
+						User:         "1000",
+						PrimaryGroup: "1000",
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name:     "config",
+								Type:     "bind",
+								Src:      "/data/app/config",
+								Dst:      "/config",
+								ReadOnly: true,
+							},
+							{
+								Name: "etc-localtime",
+								Type: "bind",
+								Src:  "/etc/localtime",
+								Dst:  "/etc/localtime",
+							},
+							{
+								Name:      "tmp",
+								Type:      "tmpfs",
+								Dst:       "/tmp",
+								TmpfsSize: 10 * 1024 * 1024,
+							},
+						},
+					},
+					Network: config.ContainerNetwork{
+						HostName:   "app-host",
+						DomainName: "example.tld",
+						DNSServers: []string{"1.1.1.1"},
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "53",
+								Protocol:      "udp",
+								HostIP:        "0.0.0.0",
+								HostPort:      "5353",
+							},
+							{
+								ContainerPort: "80",
+								Protocol:      "tcp",
+								HostIP:        "127.0.0.1",
+								HostPort:      "8080",
+							},
+						},
+					},
+					Security: config.ContainerSecurity{
+						CapAdd: []string{"NET_ADMIN"},
+						Sysctls: []config.Sysctl{
+							{
+								Key:   "net.ipv4.ip_forward",
+								Value: "1",
+							},
+						},
+					},
+					Health: config.ContainerHealth{
+						Cmd:      []string{"/bin/sh", "-c", "curl -f http://localhost"},
+						Retries:  3,
+						Interval: "30s",
+					},
+					Runtime: config.ContainerRuntime{
+						Entrypoint: []string{"/entry.sh"},
+						Args:       []string{"--verbose"},
+						Env: []config.ContainerEnv{
+							{
+								Var:   "APP_MODE",
+								Value: "prod",
+							},
+							{
+								Var:   "APP_EMPTY",
+								Empty: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		wantUnsupported: []string{
+			"working dir: /srv",
+			"named volume: app-cache:/cache",
+			"resource limits: memory, CPU and pids limits",
+			"security option: no-new-privileges",
+			"network driver: macvlan for network another-net",
+			"network: bridge",
+		},
+	},
+	{
+		name:       "Import Container - Container Mode Network",
+		dockerName: "g1-c2",
+		cRef: config.ContainerReference{
+			Group:     "g1",
+			Container: "c2",
+		},
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				Containers: []*fakedocker.FakeContainerInitInfo{
+					{
+						Name:  "g1-c1",
+						Image: "abc/xyz",
+						State: docker.ContainerStateRunning,
+					},
+					{
+						Name:  "g1-c2",
+						Image: "abc/xyz",
+						State: docker.ContainerStateRunning,
+						HostConfig: &dcontainer.HostConfig{
+							NetworkMode: "container:g1-c1",
+						},
+					},
+				},
+				ExistingImages: utils.StringSet{
+					"abc/xyz": {},
+				},
+			}),
+		},
+		wantConfig: config.Homelab{
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "g1-c1",
+							Container: config.ContainerReference{
+								Group:     "g1",
+								Container: "c1",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Group:     "g1",
+									Container: "c2",
+								},
+							},
+						},
+					},
+				},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c2",
+					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestImportContainer(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range importContainerTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			tc.ctxInfo.Logger = testutils.NewCapturingTestLogger(zzzlog.LvlDebug, buf)
+			ctx := testutils.NewTestContext(tc.ctxInfo)
+			dc := docker.NewClient(ctx)
+			defer dc.Close()
+
+			got, gotErr := ImportContainer(ctx, dc, tc.dockerName, tc.cRef)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "ImportContainer()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "ImportContainer()", tc.name, "imported config", tc.wantConfig, got.Config) {
+				return
+			}
+			if !testhelpers.CmpDiff(t, "ImportContainer()", tc.name, "unsupported settings", tc.wantUnsupported, got.Unsupported) {
+				return
+			}
+		})
+	}
+}
+
+var importContainerErrorTests = []struct {
+	name       string
+	dockerName string
+	ctxInfo    *testutils.TestContextInfo
+	want       string
+}{
+	{
+		name:       "Import Container - Container Not Found",
+		dockerName: "g1-c1",
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewEmptyFakeDockerHost(),
+		},
+		want: `failed to inspect the container g1-c1, reason: container g1-c1 not found on the fake docker host`,
+	},
+	{
+		name:       "Import Container - Image Not Found",
+		dockerName: "g1-c1",
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				Containers: []*fakedocker.FakeContainerInitInfo{
+					{
+						Name:  "g1-c1",
+						Image: "abc/xyz",
+						State: docker.ContainerStateRunning,
+					},
+				},
+			}),
+		},
+		want: `failed to inspect the image abc/xyz, reason: image abc/xyz not found on the fake docker host`,
+	},
+	{
+		name:       "Import Container - Network Not Found",
+		dockerName: "g1-c1",
+		ctxInfo: &testutils.TestContextInfo{
+			DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				Containers: []*fakedocker.FakeContainerInitInfo{
+					{
+						Name:  "g1-c1",
+						Image: "abc/xyz",
+						State: docker.ContainerStateRunning,
+						NetworkConfig: &dnetwork.NetworkingConfig{
+							EndpointsConfig: map[string]*dnetwork.EndpointSettings{
+								"net1": {},
+							},
+						},
+					},
+				},
+				ExistingImages: utils.StringSet{
+					"abc/xyz": {},
+				},
+			}),
+		},
+		want: `failed to inspect the network net1, reason: network net1 not found on the fake docker host`,
+	},
+}
+
+func TestImportContainerErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range importContainerErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			tc.ctxInfo.Logger = testutils.NewCapturingTestLogger(zzzlog.LvlDebug, buf)
+			ctx := testutils.NewTestContext(tc.ctxInfo)
+			dc := docker.NewClient(ctx)
+			defer dc.Close()
+
+			_, gotErr := ImportContainer(ctx, dc, tc.dockerName, config.ContainerReference{Group: "g1", Container: "c1"})
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "ImportContainer()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "ImportContainer()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
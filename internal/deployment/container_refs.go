@@ -0,0 +1,99 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/config/env"
+)
+
+// containerRefs resolves the references to other containers within the
+// config env of a container, using the validated IPAM config and the
+// containers config as written prior to applying the config env.
+type containerRefs struct {
+	ctx          context.Context
+	containers   map[config.ContainerReference]config.Container
+	groups       ContainerGroupMap
+	globalConfig *config.Global
+	networks     NetworkMap
+	endpoints    map[config.ContainerReference]networkEndpointList
+}
+
+var _ env.ContainerRefs = (*containerRefs)(nil)
+
+func newContainerRefs(ctx context.Context, containersConfig []config.Container, groups ContainerGroupMap, globalConfig *config.Global, networks NetworkMap, endpoints map[config.ContainerReference]networkEndpointList) *containerRefs {
+	containers := make(map[config.ContainerReference]config.Container)
+	for _, ct := range containersConfig {
+		if _, found := containers[ct.Info]; !found {
+			containers[ct.Info] = ct
+		}
+	}
+	return &containerRefs{
+		ctx:          ctx,
+		containers:   containers,
+		groups:       groups,
+		globalConfig: globalConfig,
+		networks:     networks,
+		endpoints:    endpoints,
+	}
+}
+
+func (r *containerRefs) ContainerIP(network, container string) (string, error) {
+	ref, err := r.lookup(container)
+	if err != nil {
+		return "", err
+	}
+	if _, found := r.networks[network]; !found {
+		return "", fmt.Errorf("network %s not found", network)
+	}
+	for _, e := range r.endpoints[ref] {
+		if e.network.Name() == network && len(e.ip) > 0 {
+			return e.ip, nil
+		}
+	}
+	return "", fmt.Errorf("container %s has no IP in network %s", container, network)
+}
+
+func (r *containerRefs) ContainerName(container string) (string, error) {
+	ref, err := r.lookup(container)
+	if err != nil {
+		return "", err
+	}
+	return containerName(&ref), nil
+}
+
+// ContainerHostName returns the host name of the referenced container,
+// falling back to the container name (which is resolvable by the other
+// containers in the same network) when unspecified.
+func (r *containerRefs) ContainerHostName(container string) (string, error) {
+	ref, err := r.lookup(container)
+	if err != nil {
+		return "", err
+	}
+	ct := r.containers[ref]
+	if len(ct.Network.HostName) == 0 {
+		return containerName(&ref), nil
+	}
+	ctEnv, err := containerConfigEnv(r.ctx, r.groups[ref.Group], &ct, r.globalConfig)
+	if err != nil {
+		return "", err
+	}
+	return ctEnv.Apply(ct.Network.HostName), nil
+}
+
+func (r *containerRefs) lookup(container string) (config.ContainerReference, error) {
+	group, ct, found := strings.Cut(container, "/")
+	if !found || len(group) == 0 || len(ct) == 0 || strings.Contains(ct, "/") {
+		return config.ContainerReference{}, fmt.Errorf("container reference %s must be of the form <group>/<container>", container)
+	}
+	ref := config.ContainerReference{Group: group, Container: ct}
+	if _, found := r.containers[ref]; !found {
+		return config.ContainerReference{}, fmt.Errorf("container %s not found in the containers config", container)
+	}
+	if _, found := r.groups[ref.Group]; !found {
+		return config.ContainerReference{}, fmt.Errorf("group definition missing in groups config for the container %s", container)
+	}
+	return ref, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/tuxdudehomelab/homelab/internal/config"
 	"github.com/tuxdudehomelab/homelab/internal/docker"
 	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
 	"github.com/tuxdudehomelab/homelab/internal/inspect"
 	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
 	"github.com/tuxdudehomelab/homelab/internal/testutils"
@@ -1024,6 +1025,45 @@ func TestContainerStartErrors(t *testing.T) {
 	}
 }
 
+func TestContainerStartEnvFromHostOnRemoteHost(t *testing.T) {
+	t.Parallel()
+
+	tc := "Container Start - Env From Host On Remote Host"
+	buf := new(bytes.Buffer)
+	cRef := config.ContainerReference{
+		Group:     "g1",
+		Container: "c1",
+	}
+	_, ct, dc, ctx := newSingleTestContainer(t, tc, cRef, &testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+	}, &fakedocker.FakeDockerHostInitInfo{}, func(conf *config.Homelab) {
+		conf.Groups[0].Container.Env = []config.ContainerEnv{
+			{
+				Var:      "MY_HOST_ENV",
+				FromHost: true,
+			},
+		}
+	})
+	if ct == nil {
+		return
+	}
+	defer dc.Close()
+	h := *host.MustHostInfo(ctx)
+	h.Remote = true
+	ctx = host.WithHostInfo(ctx, &h)
+
+	want := `Failed to start container g1-c1, reason:env var MY_HOST_ENV for container g1-c1 is passed through from the host, which is not supported while managing the remote host fakehost`
+	_, gotErr := ct.Start(ctx, dc)
+	if gotErr == nil {
+		testhelpers.LogErrorNilWithOutput(t, "Container.Start()", tc, buf, want)
+		return
+	}
+	if !testhelpers.RegexMatchWithOutput(t, "Container.Start()", tc, buf, "gotErr error string", want, gotErr.Error()) {
+		return
+	}
+	testhelpers.CmpDiff(t, "Container.Start()", tc, "container state", docker.ContainerStateNotFound, fakedocker.FakeDockerHostFromContext(ctx).GetContainerState(ct.Name()))
+}
+
 var containerStopTests = []struct {
 	name                    string
 	config                  config.Homelab
@@ -2355,6 +2395,37 @@ func buildSingleContainerConfig(ct config.ContainerReference, image string) conf
 	return conf
 }
 
+// newSingleTestContainer builds the deployment from the single container
+// config for the container using the abc/xyz image, after customizing the
+// config using update if specified. Returns the deployment along with the
+// container, which belongs to the job if update converts the container
+// into a job.
+func newSingleTestContainer(t *testing.T, tc string, cRef config.ContainerReference, ctxInfo *testutils.TestContextInfo, initInfo *fakedocker.FakeDockerHostInitInfo, update func(*config.Homelab)) (*Deployment, *Container, *docker.Client, context.Context) {
+	t.Helper()
+
+	initInfo.ValidImagesForPull = utils.StringSet{
+		"abc/xyz": {},
+	}
+	ctxInfo.DockerHost = fakedocker.NewFakeDockerHost(initInfo)
+	ctx := testutils.NewTestContext(ctxInfo)
+	conf := buildSingleContainerConfig(cRef, "abc/xyz")
+	if update != nil {
+		update(&conf)
+	}
+	dep, gotErr := FromConfig(ctx, &conf)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "FromConfig()", tc, gotErr)
+		return nil, nil, nil, nil
+	}
+
+	ct, gotErr := dep.queryContainerOrJob(cRef)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "deployment.queryContainerOrJob()", tc, gotErr)
+		return nil, nil, nil, nil
+	}
+	return dep, ct, docker.NewClient(ctx), ctx
+}
+
 func buildSingleContainerWithContainerModeNetworkConfig(ct config.ContainerReference, image string, connectTo config.ContainerReference) config.Homelab {
 	conf := buildSingleContainerNoNetworkConfig(ct, image)
 	conf.IPAM = config.IPAM{
@@ -9,16 +9,22 @@ import (
 
 	"github.com/tuxdudehomelab/homelab/internal/config"
 	"github.com/tuxdudehomelab/homelab/internal/config/env"
+	"github.com/tuxdudehomelab/homelab/internal/host"
 )
 
 type Deployment struct {
-	Config            *config.Homelab
-	Groups            ContainerGroupMap
-	GroupsOrder       []string
-	Networks          NetworkMap
-	NetworksOrder     []string
-	allowedContainers containerSet
-	dockerConfigs     containerDockerConfigMap
+	Config             *config.Homelab
+	Groups             ContainerGroupMap
+	GroupsOrder        []string
+	Networks           NetworkMap
+	NetworksOrder      []string
+	jobs               jobMap
+	allowedContainers  containerSet
+	dockerConfigs      containerDockerConfigMap
+	resolvedContainers []config.Container
+	resolvedJobs       []config.Job
+	hostName           string
+	hostSystemd        config.HostSystemd
 }
 
 func FromConfigsPath(ctx context.Context, configsPath string) (*Deployment, error) {
@@ -43,15 +49,27 @@ func FromConfig(ctx context.Context, conf *config.Homelab) (*Deployment, error)
 	d := Deployment{
 		Config:        conf,
 		dockerConfigs: containerDockerConfigMap{},
+		hostName:      host.MustHostInfo(ctx).HostName,
 	}
 
-	systemEnv := env.NewSystemConfigEnvManager(ctx)
-	envWithGlobal, err := validateGlobalConfig(ctx, systemEnv, &conf.Global)
+	hostsMatcher, err := validateHostGroupsConfig(ctx, conf.HostGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	var hostConfigs []*config.Host
+	d.allowedContainers, hostConfigs, err = validateHostsConfig(hostsMatcher, conf.Hosts, conf.Containers)
 	if err != nil {
 		return nil, err
 	}
+	ctx, err = withSelectedHostIP(ctx, hostConfigs)
+	if err != nil {
+		return nil, err
+	}
+	d.hostSystemd = mergedHostSystemdConfig(hostConfigs)
 
-	d.allowedContainers, err = validateHostsConfig(ctx, conf.Hosts)
+	systemEnv := env.NewSystemConfigEnvManager(ctx)
+	envWithGlobal, err := validateGlobalConfig(ctx, systemEnv, &conf.Global, hostConfigs)
 	if err != nil {
 		return nil, err
 	}
@@ -65,17 +83,59 @@ func FromConfig(ctx context.Context, conf *config.Homelab) (*Deployment, error)
 	}
 	d.updateNetworksOrder()
 
-	d.Groups, err = validateGroupsConfig(conf.Groups)
+	d.Groups, err = validateGroupsConfig(ctx, envWithGlobal, conf.Groups, &conf.Global)
 	if err != nil {
 		return nil, err
 	}
 	d.updateGroupsOrder()
 
-	err = validateContainersConfig(ctx, envWithGlobal, conf.Containers, d.Groups, &conf.Global, containerEndpoints, d.allowedContainers)
+	d.resolvedContainers, err = resolveContainersConfig(conf.ContainerTemplates, conf.Containers, hostConfigs)
 	if err != nil {
 		return nil, err
 	}
 
+	// Containers extending templates or patched by the host config are
+	// retained as written in the config with the same config env applied,
+	// while the rest are updated with the validated config.
+	writtenContainers := make([]*config.Container, len(conf.Containers))
+	for i, ct := range conf.Containers {
+		if len(ct.Extends) > 0 || len(hostContainerPatches(hostConfigs, ct.Info)) > 0 {
+			writtenContainers[i] = &conf.Containers[i]
+		}
+	}
+	err = validateContainersConfig(ctx, d.resolvedContainers, writtenContainers, d.Groups, &conf.Global, d.Networks, containerEndpoints, hostsMatcher, d.allowedContainers)
+	if err != nil {
+		return nil, err
+	}
+	for i := range conf.Containers {
+		if writtenContainers[i] == nil {
+			conf.Containers[i] = d.resolvedContainers[i]
+		}
+	}
+
+	d.resolvedJobs, err = resolveJobsConfig(conf.ContainerTemplates, conf.Jobs)
+	if err != nil {
+		return nil, err
+	}
+	writtenJobs := make([]*config.Container, len(conf.Jobs))
+	for i, j := range conf.Jobs {
+		if len(j.Extends) > 0 {
+			writtenJobs[i] = &conf.Jobs[i].Container
+		}
+	}
+	d.jobs, err = validateJobsConfig(ctx, d.resolvedJobs, writtenJobs, d.resolvedContainers, d.Groups, &conf.Global, d.Networks, containerEndpoints, hostsMatcher, d.allowedContainers)
+	if err != nil {
+		return nil, err
+	}
+	for i := range conf.Jobs {
+		if writtenJobs[i] == nil {
+			conf.Jobs[i] = d.resolvedJobs[i]
+		}
+	}
+
+	// Pin the images before generating the docker configs which refer
+	// to them.
+	d.applyImageLock(ctx)
 	for _, g := range d.Groups {
 		g.updateContainersOrder()
 		for _, ct := range g.containers {
@@ -86,6 +146,19 @@ func FromConfig(ctx context.Context, conf *config.Homelab) (*Deployment, error)
 	return &d, nil
 }
 
+// ResolvedConfig returns the homelab config with all the container
+// templates resolved into the containers and the jobs extending them,
+// and the host config container patches applied.
+func (d *Deployment) ResolvedConfig() *config.Homelab {
+	conf := *d.Config
+	conf.ContainerTemplates = nil
+	conf.Containers = d.resolvedContainers
+	if len(d.resolvedJobs) > 0 {
+		conf.Jobs = d.resolvedJobs
+	}
+	return &conf
+}
+
 func (d *Deployment) queryAllContainers() containerMap {
 	result := make(containerMap)
 	for _, g := range d.Groups {
@@ -147,6 +220,20 @@ func (d *Deployment) QueryContainer(ctx context.Context, group, container string
 	return ContainerList{ct}, nil
 }
 
+// QueryAllJobs returns all the jobs ordered by their names.
+func (d *Deployment) QueryAllJobs(ctx context.Context) (JobList, error) {
+	return jobMapToList(d.jobs), nil
+}
+
+// QueryJob returns the job with the specified group and container name.
+func (d *Deployment) QueryJob(ctx context.Context, group, container string) (*Job, error) {
+	j, found := d.jobs[config.ContainerReference{Group: group, Container: container}]
+	if !found {
+		return nil, fmt.Errorf("job %s/%s not found", group, container)
+	}
+	return j, nil
+}
+
 func (d *Deployment) QueryNetwork(ctx context.Context, network string) (NetworkList, error) {
 	net, err := d.queryNetwork(network)
 	if err != nil {
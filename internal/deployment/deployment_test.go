@@ -2,6 +2,8 @@ package deployment
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"testing"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/tuxdudehomelab/homelab/internal/cmdexec/fakecmdexec"
 	"github.com/tuxdudehomelab/homelab/internal/config"
 	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
 	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
 	"github.com/tuxdudehomelab/homelab/internal/testutils"
 	"github.com/tuxdudehomelab/homelab/internal/utils"
@@ -220,6 +223,25 @@ containers:
       autoRemove: true
       stopSignal: SIGHUP
       stopTimeout: 10
+      waitFor:
+        port: 8080
+        timeout: 2m
+        interval: 5s
+      startPostHook:
+        command:
+          - $$CONTAINER_SCRIPTS_DIR$$/my-start-posthook.sh
+        timeout: 30s
+      stopPreHook:
+        command:
+          - pg_dumpall
+          - -f
+          - /backup/dump.sql
+        inContainer: true
+        timeout: 5m
+      stopPostHook:
+        command:
+          - $$CONTAINER_SCRIPTS_DIR$$/my-stop-posthook.sh
+        onFailure: ignore
     user:
       user: $$USER_ID$$
       primaryGroup: $$USER_PRIMARY_GROUP_ID$$
@@ -316,6 +338,10 @@ containers:
       timeout: 10s
       startPeriod: 3m
       startInterval: 10s
+      onUnhealthy:
+        action: restart
+        backoff: 1m
+        maxAttempts: 5
     runtime:
       tty: true
       shmSize: 1g
@@ -334,6 +360,23 @@ containers:
         - foo
         - bar-$$HUMAN_FRIENDLY_HOST_NAME$$
         - baz
+    backup:
+      destination: /mnt/backups/$$HUMAN_FRIENDLY_HOST_NAME$$
+      compression: zstd
+      includes:
+        - db
+        - uploads
+      excludes:
+        - "*.tmp"
+      retention: 14
+      keepRunning: true
+      preBackupHook:
+        command:
+          - my-db-dump
+          - --out
+          - /data/db/dump.sql
+        inContainer: true
+        timeout: 5m
   - info:
       group: group1
       container: ct2
@@ -378,6 +421,19 @@ containers:
       image: abc123/xyz128
     lifecycle:
       order: 1
+jobs:
+  - info:
+      group: group1
+      container: backup
+    image:
+      image: abc123/backup
+    runtime:
+      args:
+        - --target
+        - /backups
+    schedule: "30 2 * * 1-5"
+    timeout: 30m
+    retention: 7
 ignore:
   - foo
   - 4567
@@ -747,6 +803,32 @@ ignore:
 						AutoRemove:  true,
 						StopSignal:  "SIGHUP",
 						StopTimeout: 10,
+						WaitFor: config.ContainerWaitFor{
+							Port:     8080,
+							Timeout:  "2m",
+							Interval: "5s",
+						},
+						StartPostHook: config.ContainerHook{
+							Command: []string{
+								"testdata/dummy-base-dir/group1/ct1/scripts/my-start-posthook.sh",
+							},
+							Timeout: "30s",
+						},
+						StopPreHook: config.ContainerHook{
+							Command: []string{
+								"pg_dumpall",
+								"-f",
+								"/backup/dump.sql",
+							},
+							InContainer: true,
+							Timeout:     "5m",
+						},
+						StopPostHook: config.ContainerHook{
+							Command: []string{
+								"testdata/dummy-base-dir/group1/ct1/scripts/my-stop-posthook.sh",
+							},
+							OnFailure: "ignore",
+						},
 					},
 					User: config.ContainerUser{
 						User:         "55555",
@@ -905,6 +987,11 @@ ignore:
 						Timeout:       "10s",
 						StartPeriod:   "3m",
 						StartInterval: "10s",
+						OnUnhealthy: config.ContainerUnhealthyPolicy{
+							Action:      "restart",
+							Backoff:     "1m",
+							MaxAttempts: 5,
+						},
 					},
 					Runtime: config.ContainerRuntime{
 						AttachToTty: true,
@@ -934,6 +1021,28 @@ ignore:
 							"baz",
 						},
 					},
+					Backup: config.ContainerBackup{
+						Destination: "/mnt/backups/FakeHost",
+						Compression: "zstd",
+						Includes: []string{
+							"db",
+							"uploads",
+						},
+						Excludes: []string{
+							"*.tmp",
+						},
+						Retention:   14,
+						KeepRunning: true,
+						PreBackupHook: config.ContainerHook{
+							Command: []string{
+								"my-db-dump",
+								"--out",
+								"/data/db/dump.sql",
+							},
+							InContainer: true,
+							Timeout:     "5m",
+						},
+					},
 				},
 				{
 					Info: config.ContainerReference{
@@ -1011,6 +1120,34 @@ ignore:
 					},
 				},
 			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "group1",
+							Container: "backup",
+						},
+						Image: config.ContainerImage{
+							Image: "abc123/backup",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							Order: 1,
+							RestartPolicy: config.ContainerRestartPolicy{
+								Mode: "no",
+							},
+						},
+						Runtime: config.ContainerRuntime{
+							Args: []string{
+								"--target",
+								"/backups",
+							},
+						},
+					},
+					Schedule:  "30 2 * * 1-5",
+					Timeout:   "30m",
+					Retention: 7,
+				},
+			},
 		},
 		wantDockerConfigs: containerDockerConfigMap{
 			config.ContainerReference{
@@ -1059,6 +1196,8 @@ ignore:
 						"ep-arg2",
 					},
 					Labels: map[string]string{
+						"my-label-1":          "my-label-1-value",
+						"my-label-2":          "my-label-2-value",
 						"my.ct1.label.name.1": "my.ct1.label.value.1",
 						"my.ct1.label.name.2": "my.ct1.label.value.2",
 					},
@@ -1199,7 +1338,11 @@ ignore:
 						"MY_CONTAINER_ENV_VAR_2=MY_CONTAINER_ENV_VAR_2_VALUE",
 						"MY_CONTAINER_ENV_VAR_3=/foo2/bar2/some-other-env-var-cmd",
 					},
-					Image:       "abc123/xyz123",
+					Image: "abc123/xyz123",
+					Labels: map[string]string{
+						"my-label-1": "my-label-1-value",
+						"my-label-2": "my-label-2-value",
+					},
 					StopTimeout: testhelpers.NewInt(8),
 				},
 				HostConfig: &dcontainer.HostConfig{
@@ -1238,7 +1381,11 @@ ignore:
 						"MY_CONTAINER_ENV_VAR_2=MY_CONTAINER_ENV_VAR_2_VALUE",
 						"MY_CONTAINER_ENV_VAR_3=/foo2/bar2/some-other-env-var-cmd",
 					},
-					Image:       "abc123/xyz124",
+					Image: "abc123/xyz124",
+					Labels: map[string]string{
+						"my-label-1": "my-label-1-value",
+						"my-label-2": "my-label-2-value",
+					},
 					StopTimeout: testhelpers.NewInt(8),
 				},
 				HostConfig: &dcontainer.HostConfig{
@@ -1277,7 +1424,11 @@ ignore:
 						"MY_CONTAINER_ENV_VAR_2=MY_CONTAINER_ENV_VAR_2_VALUE",
 						"MY_CONTAINER_ENV_VAR_3=/foo2/bar2/some-other-env-var-cmd",
 					},
-					Image:       "abc123/xyz125",
+					Image: "abc123/xyz125",
+					Labels: map[string]string{
+						"my-label-1": "my-label-1-value",
+						"my-label-2": "my-label-2-value",
+					},
 					StopTimeout: testhelpers.NewInt(8),
 				},
 				HostConfig: &dcontainer.HostConfig{
@@ -1316,7 +1467,11 @@ ignore:
 						"MY_CONTAINER_ENV_VAR_2=MY_CONTAINER_ENV_VAR_2_VALUE",
 						"MY_CONTAINER_ENV_VAR_3=/foo2/bar2/some-other-env-var-cmd",
 					},
-					Image:       "abc123/xyz126",
+					Image: "abc123/xyz126",
+					Labels: map[string]string{
+						"my-label-1": "my-label-1-value",
+						"my-label-2": "my-label-2-value",
+					},
 					StopTimeout: testhelpers.NewInt(8),
 				},
 				HostConfig: &dcontainer.HostConfig{
@@ -1346,7 +1501,11 @@ ignore:
 						"MY_CONTAINER_ENV_VAR_2=MY_CONTAINER_ENV_VAR_2_VALUE",
 						"MY_CONTAINER_ENV_VAR_3=/foo2/bar2/some-other-env-var-cmd",
 					},
-					Image:       "abc123/xyz127",
+					Image: "abc123/xyz127",
+					Labels: map[string]string{
+						"my-label-1": "my-label-1-value",
+						"my-label-2": "my-label-2-value",
+					},
 					StopTimeout: testhelpers.NewInt(8),
 				},
 				HostConfig: &dcontainer.HostConfig{
@@ -1376,7 +1535,11 @@ ignore:
 						"MY_CONTAINER_ENV_VAR_2=MY_CONTAINER_ENV_VAR_2_VALUE",
 						"MY_CONTAINER_ENV_VAR_3=/foo2/bar2/some-other-env-var-cmd",
 					},
-					Image:       "abc123/xyz128",
+					Image: "abc123/xyz128",
+					Labels: map[string]string{
+						"my-label-1": "my-label-1-value",
+						"my-label-2": "my-label-2-value",
+					},
 					StopTimeout: testhelpers.NewInt(8),
 				},
 				HostConfig: &dcontainer.HostConfig{
@@ -1398,189 +1561,152 @@ ignore:
 		},
 	},
 	{
-		name: "Valid Groups Only config",
+		name: "Valid config with group defaults",
 		config: `
 global:
   baseDir: testdata/dummy-base-dir
+  env:
+    - var: MEDIA_DIR
+      value: /mnt/media
+  container:
+    restartPolicy:
+      mode: always
+    domainName: global.tld
+    dnsSearch:
+      - global-search
+    env:
+      - var: TZ
+        value: UTC
+      - var: LEVEL
+        value: global
+    mounts:
+      - name: localtime
+        type: bind
+        src: /etc/localtime
+        dst: /etc/localtime
+        readOnly: true
+    labels:
+      - name: level
+        value: global
+      - name: managed-by
+        value: homelab
+hosts:
+  - name: fakehost
+    allowedContainers:
+      - group: media
+        container: ct1
+      - group: media
+        container: ct2
 groups:
-  - name: group1
+  - name: media
     order: 1
-  - name: group2
-    order: 3
-  - name: group3
-    order: 2`,
+    config:
+      env:
+        - var: MEDIA_SUBDIR
+          value: movies
+    container:
+      restartPolicy:
+        mode: unless-stopped
+      domainName: media.tld
+      dnsServers:
+        - 10.10.10.10
+      dnsOptions:
+        - ndots:1
+      env:
+        - var: LEVEL
+          value: group
+        - var: GROUP_DIR
+          value: $$CONTAINER_GROUP_BASE_DIR$$
+      mounts:
+        - name: movies
+          type: bind
+          src: $$MEDIA_DIR$$/$$MEDIA_SUBDIR$$
+          dst: /movies
+      labels:
+        - name: level
+          value: group
+containers:
+  - info:
+      group: media
+      container: ct1
+    image:
+      image: foo/ct1
+    lifecycle:
+      order: 1
+  - info:
+      group: media
+      container: ct2
+    image:
+      image: foo/ct2
+    lifecycle:
+      order: 2
+      restartPolicy:
+        mode: on-failure
+        maxRetryCount: 3
+    network:
+      domainName: ct2.tld
+      dnsServers:
+        - 10.20.20.20
+      dnsSearch:
+        - ct2-search
+    fs:
+      mounts:
+        - name: ct2-data
+          type: bind
+          src: $$CONTAINER_DATA_DIR$$
+          dst: /data
+    runtime:
+      env:
+        - var: LEVEL
+          value: container
+    metadata:
+      labels:
+        - name: level
+          value: container`,
 		want: &config.Homelab{
 			Global: config.Global{
 				BaseDir: "testdata/dummy-base-dir",
-			},
-			Groups: []config.ContainerGroup{
-				{
-					Name:  "group1",
-					Order: 1,
-				},
-				{
-					Name:  "group2",
-					Order: 3,
-				},
-				{
-					Name:  "group3",
-					Order: 2,
-				},
-			},
-		},
-		wantDockerConfigs: containerDockerConfigMap{},
-	},
-}
-
-func TestBuildDeploymentUsingReader(t *testing.T) {
-	t.Parallel()
-
-	for _, test := range buildDeploymentUsingReaderTests {
-		tc := test
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-
-			input := strings.NewReader(tc.config)
-			ctx := testutils.NewVanillaTestContext()
-			if tc.ctxInfo != nil {
-				ctx = testutils.NewTestContext(tc.ctxInfo)
-			}
-			got, gotErr := FromReader(ctx, input)
-			if gotErr != nil {
-				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
-				return
-			}
-
-			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "config", tc.want, got.Config) {
-				return
-			}
-
-			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "docker configs", tc.wantDockerConfigs, got.dockerConfigs) {
-				return
-			}
-		})
-	}
-}
-
-var buildDeploymentFromConfigsPathTests = []struct {
-	name              string
-	configsPath       string
-	want              *config.Homelab
-	wantDockerConfigs containerDockerConfigMap
-}{
-	{
-		name:        "Valid Multi File Config",
-		configsPath: "parse-configs-valid",
-		want: &config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
 				Env: []config.ConfigEnv{
 					{
-						Var:   "MY_GLOBAL_FOO",
-						Value: "MY_GLOBAL_BAR",
+						Var:   "MEDIA_DIR",
+						Value: "/mnt/media",
 					},
 				},
 				Container: config.GlobalContainer{
-					StopSignal:  "SIGTERM",
-					StopTimeout: 5,
 					RestartPolicy: config.ContainerRestartPolicy{
-						Mode:          "on-failure",
-						MaxRetryCount: 5,
+						Mode: "always",
 					},
-					DomainName: "somedomain",
-				},
-			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
+					DomainName: "global.tld",
+					DNSSearch: []string{
+						"global-search",
+					},
+					Env: []config.ContainerEnv{
 						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.11",
-									Container: config.ContainerReference{
-										Group:     "g1",
-										Container: "c1",
-									},
-								},
-								{
-									IP: "172.18.100.12",
-									Container: config.ContainerReference{
-										Group:     "g1",
-										Container: "c2",
-									},
-								},
-							},
+							Var:   "TZ",
+							Value: "UTC",
 						},
 						{
-							Name:              "net2",
-							HostInterfaceName: "docker-net2",
-							CIDR:              "172.18.101.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.101.21",
-									Container: config.ContainerReference{
-										Group:     "g2",
-										Container: "c3",
-									},
-								},
-							},
+							Var:   "LEVEL",
+							Value: "global",
 						},
+					},
+					Mounts: []config.Mount{
 						{
-							Name:              "net-common",
-							HostInterfaceName: "docker-cmn",
-							CIDR:              "172.19.200.0/24",
-							Priority:          2,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.19.200.201",
-									Container: config.ContainerReference{
-										Group:     "g1",
-										Container: "c1",
-									},
-								},
-								{
-									IP: "172.19.200.202",
-									Container: config.ContainerReference{
-										Group:     "g1",
-										Container: "c2",
-									},
-								},
-								{
-									IP: "172.19.200.203",
-									Container: config.ContainerReference{
-										Group:     "g2",
-										Container: "c3",
-									},
-								},
-							},
+							Name:     "localtime",
+							Type:     "bind",
+							Src:      "/etc/localtime",
+							Dst:      "/etc/localtime",
+							ReadOnly: true,
 						},
 					},
-					ContainerModeNetworks: []config.ContainerModeNetwork{
+					Labels: []config.Label{
 						{
-							Name: "g3-c4",
-							Container: config.ContainerReference{
-								Group:     "g3",
-								Container: "c4",
-							},
-							AttachingContainers: []config.ContainerReference{
-								{
-									Group:     "g3",
-									Container: "c5",
-								},
-								{
-									Group:     "g3",
-									Container: "c6",
-								},
-								{
-									Group:     "g3",
-									Container: "c7",
-								},
-							},
+							Name:  "level",
+							Value: "global",
+						},
+						{
+							Name:  "managed-by",
+							Value: "homelab",
 						},
 					},
 				},
@@ -1590,50 +1716,74 @@ var buildDeploymentFromConfigsPathTests = []struct {
 					Name: "fakehost",
 					AllowedContainers: []config.ContainerReference{
 						{
-							Group:     "g1",
-							Container: "c1",
-						},
-						{
-							Group:     "g3",
-							Container: "c4",
+							Group:     "media",
+							Container: "ct1",
 						},
-					},
-				},
-				{
-					Name: "host2",
-				},
-				{
-					Name: "host3",
-					AllowedContainers: []config.ContainerReference{
 						{
-							Group:     "g2",
-							Container: "c3",
+							Group:     "media",
+							Container: "ct2",
 						},
 					},
 				},
 			},
 			Groups: []config.ContainerGroup{
 				{
-					Name:  "g1",
+					Name:  "media",
 					Order: 1,
-				},
-				{
-					Name:  "g2",
-					Order: 2,
-				},
-				{
-					Name:  "g3",
-					Order: 3,
+					Config: config.ContainerConfigOptions{
+						Env: []config.ConfigEnv{
+							{
+								Var:   "MEDIA_SUBDIR",
+								Value: "movies",
+							},
+						},
+					},
+					Container: config.GroupContainer{
+						RestartPolicy: config.ContainerRestartPolicy{
+							Mode: "unless-stopped",
+						},
+						DomainName: "media.tld",
+						DNSServers: []string{
+							"10.10.10.10",
+						},
+						DNSOptions: []string{
+							"ndots:1",
+						},
+						Env: []config.ContainerEnv{
+							{
+								Var:   "LEVEL",
+								Value: "group",
+							},
+							{
+								Var:   "GROUP_DIR",
+								Value: "testdata/dummy-base-dir/media",
+							},
+						},
+						Mounts: []config.Mount{
+							{
+								Name: "movies",
+								Type: "bind",
+								Src:  "/mnt/media/movies",
+								Dst:  "/movies",
+							},
+						},
+						Labels: []config.Label{
+							{
+								Name:  "level",
+								Value: "group",
+							},
+						},
+					},
 				},
 			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c1",
+						Group:     "media",
+						Container: "ct1",
 					},
 					Image: config.ContainerImage{
-						Image: "abc/xyz",
+						Image: "foo/ct1",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
@@ -1641,252 +1791,3381 @@ var buildDeploymentFromConfigsPathTests = []struct {
 				},
 				{
 					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c2",
+						Group:     "media",
+						Container: "ct2",
 					},
 					Image: config.ContainerImage{
-						Image: "abc/xyz2",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 2,
-					},
-				},
-				{
-					Info: config.ContainerReference{
-						Group:     "g2",
-						Container: "c3",
+						Image: "foo/ct2",
 					},
-					Image: config.ContainerImage{
-						Image: "abc/xyz3",
+					Metadata: config.ContainerMetadata{
+						Labels: []config.Label{
+							{
+								Name:  "level",
+								Value: "container",
+							},
+						},
 					},
 					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+						Order: 2,
+						RestartPolicy: config.ContainerRestartPolicy{
+							Mode:          "on-failure",
+							MaxRetryCount: 3,
+						},
 					},
-				},
-				{
-					Info: config.ContainerReference{
-						Group:     "g3",
-						Container: "c4",
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "ct2-data",
+								Type: "bind",
+								Src:  "testdata/dummy-base-dir/media/ct2/data",
+								Dst:  "/data",
+							},
+						},
 					},
-					Image: config.ContainerImage{
-						Image: "abc/xyz4",
+					Network: config.ContainerNetwork{
+						DomainName: "ct2.tld",
+						DNSServers: []string{
+							"10.20.20.20",
+						},
+						DNSSearch: []string{
+							"ct2-search",
+						},
 					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
+							{
+								Var:   "LEVEL",
+								Value: "container",
+							},
+						},
 					},
 				},
 			},
 		},
 		wantDockerConfigs: containerDockerConfigMap{
 			config.ContainerReference{
-				Group:     "g1",
-				Container: "c1",
+				Group:     "media",
+				Container: "ct1",
 			}: &containerDockerConfigs{
 				ContainerConfig: &dcontainer.Config{
-					Domainname:  "somedomain",
-					Image:       "abc/xyz",
-					StopTimeout: testhelpers.NewInt(5),
-				},
-				HostConfig: &dcontainer.HostConfig{
-					NetworkMode: "net1",
-					RestartPolicy: dcontainer.RestartPolicy{
-						Name:              "on-failure",
-						MaximumRetryCount: 5,
+					Domainname: "media.tld",
+					Env: []string{
+						"TZ=UTC",
+						"LEVEL=group",
+						"GROUP_DIR=testdata/dummy-base-dir/media",
 					},
-				},
-				NetworkConfig: &dnetwork.NetworkingConfig{
-					EndpointsConfig: map[string]*dnetwork.EndpointSettings{
-						"net1": {
-							IPAMConfig: &dnetwork.EndpointIPAMConfig{
-								IPv4Address: "172.18.100.11",
-							},
-						},
+					Image: "foo/ct1",
+					Labels: map[string]string{
+						"level":      "group",
+						"managed-by": "homelab",
 					},
 				},
-			},
-			config.ContainerReference{
-				Group:     "g1",
-				Container: "c2",
-			}: &containerDockerConfigs{
-				ContainerConfig: &dcontainer.Config{
-					Domainname:  "somedomain",
-					Image:       "abc/xyz2",
-					StopTimeout: testhelpers.NewInt(5),
-				},
 				HostConfig: &dcontainer.HostConfig{
-					NetworkMode: "net1",
+					Binds: []string{
+						"/etc/localtime:/etc/localtime:ro",
+						"/mnt/media/movies:/movies",
+					},
+					NetworkMode: "none",
 					RestartPolicy: dcontainer.RestartPolicy{
-						Name:              "on-failure",
-						MaximumRetryCount: 5,
+						Name: "unless-stopped",
 					},
-				},
-				NetworkConfig: &dnetwork.NetworkingConfig{
-					EndpointsConfig: map[string]*dnetwork.EndpointSettings{
-						"net1": {
-							IPAMConfig: &dnetwork.EndpointIPAMConfig{
-								IPv4Address: "172.18.100.12",
-							},
-						},
+					DNS: []string{
+						"10.10.10.10",
+					},
+					DNSOptions: []string{
+						"ndots:1",
+					},
+					DNSSearch: []string{
+						"global-search",
 					},
 				},
 			},
 			config.ContainerReference{
-				Group:     "g2",
-				Container: "c3",
+				Group:     "media",
+				Container: "ct2",
 			}: &containerDockerConfigs{
 				ContainerConfig: &dcontainer.Config{
-					Domainname:  "somedomain",
-					Image:       "abc/xyz3",
-					StopTimeout: testhelpers.NewInt(5),
+					Domainname: "ct2.tld",
+					Env: []string{
+						"TZ=UTC",
+						"LEVEL=container",
+						"GROUP_DIR=testdata/dummy-base-dir/media",
+					},
+					Image: "foo/ct2",
+					Labels: map[string]string{
+						"level":      "container",
+						"managed-by": "homelab",
+					},
 				},
 				HostConfig: &dcontainer.HostConfig{
-					NetworkMode: "net2",
+					Binds: []string{
+						"/etc/localtime:/etc/localtime:ro",
+						"/mnt/media/movies:/movies",
+						"testdata/dummy-base-dir/media/ct2/data:/data",
+					},
+					NetworkMode: "none",
 					RestartPolicy: dcontainer.RestartPolicy{
 						Name:              "on-failure",
-						MaximumRetryCount: 5,
+						MaximumRetryCount: 3,
 					},
-				},
-				NetworkConfig: &dnetwork.NetworkingConfig{
-					EndpointsConfig: map[string]*dnetwork.EndpointSettings{
-						"net2": {
-							IPAMConfig: &dnetwork.EndpointIPAMConfig{
-								IPv4Address: "172.18.101.21",
-							},
-						},
+					DNS: []string{
+						"10.20.20.20",
+					},
+					DNSOptions: []string{
+						"ndots:1",
+					},
+					DNSSearch: []string{
+						"ct2-search",
 					},
 				},
 			},
-			config.ContainerReference{
-				Group:     "g3",
-				Container: "c4",
-			}: &containerDockerConfigs{
-				ContainerConfig: &dcontainer.Config{
-					Domainname:  "somedomain",
-					Image:       "abc/xyz4",
-					StopTimeout: testhelpers.NewInt(5),
+		},
+	},
+	{
+		name: "Valid Groups Only config",
+		config: `
+global:
+  baseDir: testdata/dummy-base-dir
+groups:
+  - name: group1
+    order: 1
+  - name: group2
+    order: 3
+  - name: group3
+    order: 2`,
+		want: &config.Homelab{
+			Global: config.Global{
+				BaseDir: "testdata/dummy-base-dir",
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "group1",
+					Order: 1,
 				},
-				HostConfig: &dcontainer.HostConfig{
-					NetworkMode: "none",
-					RestartPolicy: dcontainer.RestartPolicy{
-						Name:              "on-failure",
-						MaximumRetryCount: 5,
-					},
+				{
+					Name:  "group2",
+					Order: 3,
+				},
+				{
+					Name:  "group3",
+					Order: 2,
 				},
 			},
 		},
+		wantDockerConfigs: containerDockerConfigMap{},
 	},
 }
 
-func TestBuildDeploymentFromConfigsPath(t *testing.T) {
+func TestBuildDeploymentUsingReader(t *testing.T) {
 	t.Parallel()
 
-	for _, test := range buildDeploymentFromConfigsPathTests {
+	for _, test := range buildDeploymentUsingReaderTests {
 		tc := test
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			p := fmt.Sprintf("%s/testdata/%s", testhelpers.Pwd(), tc.configsPath)
-			got, gotErr := FromConfigsPath(testutils.NewVanillaTestContext(), p)
+			input := strings.NewReader(tc.config)
+			ctx := testutils.NewVanillaTestContext()
+			if tc.ctxInfo != nil {
+				ctx = testutils.NewTestContext(tc.ctxInfo)
+			}
+			got, gotErr := FromReader(ctx, input)
 			if gotErr != nil {
-				testhelpers.LogErrorNotNil(t, "FromConfigsPath()", tc.name, gotErr)
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
 				return
 			}
 
-			if !testhelpers.CmpDiff(t, "FromConfigsPath()", tc.name, "config", tc.want, got.Config) {
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "config", tc.want, got.Config) {
 				return
 			}
 
-			if !testhelpers.CmpDiff(t, "FromConfigsPath()", tc.name, "docker configs", tc.wantDockerConfigs, got.dockerConfigs) {
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "docker configs", tc.wantDockerConfigs, got.dockerConfigs) {
 				return
 			}
 		})
 	}
 }
 
-var buildDeploymentFromConfigsPathErrorTests = []struct {
-	name        string
-	configsPath string
-	want        string
-}{
-	{
-		name:        "Non-existing configs dir path",
-		configsPath: "foo-bar",
-		want:        `os.Stat\(\) failed on homelab configs path, reason: stat [^ ]+foo-bar: no such file or directory`,
-	},
-	{
-		name:        "No configs",
-		configsPath: "parse-configs-invalid-empty-dir",
-		want:        `no homelab configs found in [^ ]+/testdata/parse-configs-invalid-empty-dir`,
-	},
-	{
-		name:        "File configs dir path",
-		configsPath: "parse-configs-invalid-with-empty-file/.empty",
-		want:        `homelab configs path [^ ]+/testdata/parse-configs-invalid-with-empty-file/.empty must be a directory`,
-	},
-	{
-		name:        "Unreadable config dir",
-		configsPath: "/root",
-		want:        `failed to read contents of directory /root, reason: open /root: permission denied`,
-	},
-	{
-		name:        "Unreadable config file",
-		configsPath: "parse-configs-invalid-unreadable-config",
-		want:        `failed to read homelab config file [^ ]+/testdata/parse-configs-invalid-unreadable-config/invalid-symlink.yaml, reason: open [^ ]+/testdata/parse-configs-invalid-unreadable-config/invalid-symlink.yaml: no such file or directory`,
-	},
+var buildDeploymentWithContainerTemplatesTests = []struct {
+	name              string
+	config            string
+	wantContainers    []config.Container
+	wantResolved      []config.Container
+	wantDockerConfigs containerDockerConfigMap
+}{
 	{
-		name:        "Deep merge configs fail",
-		configsPath: "parse-configs-invalid-deepmerge-fail",
-		want:        `failed to deep merge config file [^ ]+parse-configs-invalid-deepmerge-fail/config2.yaml, reason: error due to parameter with value of primitive type: only maps and slices/arrays can be merged, which means you cannot have define the same key twice for parameters that are not maps or slices/arrays`,
+		name: "Container Templates - Ordered Merge",
+		config: `
+global:
+  baseDir: testdata/dummy-base-dir
+hosts:
+  - name: fakehost
+    allowedContainers:
+      - group: g1
+        container: c1
+groups:
+  - name: g1
+    order: 1
+containerTemplates:
+  - name: base
+    image:
+      image: foo/base
+    lifecycle:
+      order: 5
+    runtime:
+      env:
+        - var: TZ
+          value: UTC
+        - var: BASE_DIR
+          value: $$CONTAINER_BASE_DIR$$
+      args:
+        - --base
+  - name: media
+    extends:
+      - base
+    fs:
+      mounts:
+        - name: media
+          type: bind
+          src: /mnt/media
+          dst: /media
+          readOnly: true
+    runtime:
+      env:
+        - var: TZ
+          value: America/Los_Angeles
+        - var: MEDIA
+          value: "1"
+containers:
+  - info:
+      group: g1
+      container: c1
+    extends:
+      - media
+    image:
+      image: foo/c1
+    fs:
+      mounts:
+        - name: media
+          type: bind
+          src: /mnt/media
+          dst: /media
+    runtime:
+      env:
+        - var: C1
+          value: c1-value
+      args:
+        - --c1
+  - info:
+      group: g1
+      container: c2
+    image:
+      image: foo/c2
+    lifecycle:
+      order: 2`,
+		wantContainers: []config.Container{
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c1",
+				},
+				Extends: []string{
+					"media",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c1",
+				},
+				Filesystem: config.ContainerFilesystem{
+					Mounts: []config.Mount{
+						{
+							Name: "media",
+							Type: "bind",
+							Src:  "/mnt/media",
+							Dst:  "/media",
+						},
+					},
+				},
+				Runtime: config.ContainerRuntime{
+					Env: []config.ContainerEnv{
+						{
+							Var:   "C1",
+							Value: "c1-value",
+						},
+					},
+					Args: []string{
+						"--c1",
+					},
+				},
+			},
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c2",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c2",
+				},
+				Lifecycle: config.ContainerLifecycle{
+					Order: 2,
+				},
+			},
+		},
+		wantResolved: []config.Container{
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c1",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c1",
+				},
+				Lifecycle: config.ContainerLifecycle{
+					Order: 5,
+				},
+				Filesystem: config.ContainerFilesystem{
+					Mounts: []config.Mount{
+						{
+							Name: "media",
+							Type: "bind",
+							Src:  "/mnt/media",
+							Dst:  "/media",
+						},
+					},
+				},
+				Runtime: config.ContainerRuntime{
+					Env: []config.ContainerEnv{
+						{
+							Var:   "TZ",
+							Value: "America/Los_Angeles",
+						},
+						{
+							Var:   "BASE_DIR",
+							Value: "testdata/dummy-base-dir/g1/c1",
+						},
+						{
+							Var:   "MEDIA",
+							Value: "1",
+						},
+						{
+							Var:   "C1",
+							Value: "c1-value",
+						},
+					},
+					Args: []string{
+						"--c1",
+					},
+				},
+			},
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c2",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c2",
+				},
+				Lifecycle: config.ContainerLifecycle{
+					Order: 2,
+				},
+			},
+		},
+		wantDockerConfigs: containerDockerConfigMap{
+			config.ContainerReference{
+				Group:     "g1",
+				Container: "c1",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					Env: []string{
+						"TZ=America/Los_Angeles",
+						"BASE_DIR=testdata/dummy-base-dir/g1/c1",
+						"MEDIA=1",
+						"C1=c1-value",
+					},
+					Cmd: []string{
+						"--c1",
+					},
+					Image: "foo/c1",
+				},
+				HostConfig: &dcontainer.HostConfig{
+					Binds: []string{
+						"/mnt/media:/media",
+					},
+					NetworkMode: "none",
+				},
+			},
+			config.ContainerReference{
+				Group:     "g1",
+				Container: "c2",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					Image: "foo/c2",
+				},
+				HostConfig: &dcontainer.HostConfig{
+					NetworkMode: "none",
+				},
+			},
+		},
 	},
 	{
-		name:        "Invalid config key",
-		configsPath: "parse-configs-invalid-config-key",
-		want:        `(?s)failed to parse homelab config, reason: yaml: unmarshal errors:.+: field someInvalidKey not found in type config\.GlobalContainer`,
+		name: "Container Templates - Unset",
+		config: `
+global:
+  baseDir: testdata/dummy-base-dir
+hosts:
+  - name: fakehost
+    allowedContainers:
+      - group: g1
+        container: c1
+groups:
+  - name: g1
+    order: 1
+containerTemplates:
+  - name: base
+    image:
+      image: foo/base
+    lifecycle:
+      order: 5
+      autoRemove: true
+      stopSignal: SIGHUP
+      stopTimeout: 30
+containers:
+  - info:
+      group: g1
+      container: c1
+    extends:
+      - base
+    unset:
+      - lifecycle.autoRemove
+      - lifecycle.stopTimeout
+    image:
+      image: foo/c1
+    runtime:
+      env:
+        - var: DATA_DIR
+          value: $$CONTAINER_DATA_DIR$$`,
+		wantContainers: []config.Container{
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c1",
+				},
+				Extends: []string{
+					"base",
+				},
+				Unset: []string{
+					"lifecycle.autoRemove",
+					"lifecycle.stopTimeout",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c1",
+				},
+				Runtime: config.ContainerRuntime{
+					Env: []config.ContainerEnv{
+						{
+							Var:   "DATA_DIR",
+							Value: "testdata/dummy-base-dir/g1/c1/data",
+						},
+					},
+				},
+			},
+		},
+		wantResolved: []config.Container{
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c1",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c1",
+				},
+				Lifecycle: config.ContainerLifecycle{
+					Order:      5,
+					StopSignal: "SIGHUP",
+				},
+				Runtime: config.ContainerRuntime{
+					Env: []config.ContainerEnv{
+						{
+							Var:   "DATA_DIR",
+							Value: "testdata/dummy-base-dir/g1/c1/data",
+						},
+					},
+				},
+			},
+		},
+		wantDockerConfigs: containerDockerConfigMap{
+			config.ContainerReference{
+				Group:     "g1",
+				Container: "c1",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					Env: []string{
+						"DATA_DIR=testdata/dummy-base-dir/g1/c1/data",
+					},
+					Image:      "foo/c1",
+					StopSignal: "SIGHUP",
+				},
+				HostConfig: &dcontainer.HostConfig{
+					NetworkMode: "none",
+				},
+			},
+		},
 	},
 }
 
-func TestBuildDeploymentFromConfigsPathErrors(t *testing.T) {
+func TestBuildDeploymentWithContainerTemplates(t *testing.T) {
 	t.Parallel()
 
-	for _, test := range buildDeploymentFromConfigsPathErrorTests {
+	for _, test := range buildDeploymentWithContainerTemplatesTests {
 		tc := test
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			p := tc.configsPath
-			if !strings.HasPrefix(tc.configsPath, "/") {
-				p = fmt.Sprintf("%s/testdata/%s", testhelpers.Pwd(), tc.configsPath)
+			input := strings.NewReader(tc.config)
+			got, gotErr := FromReader(testutils.NewVanillaTestContext(), input)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
+				return
 			}
 
-			_, gotErr := FromConfigsPath(testutils.NewVanillaTestContext(), p)
-			if gotErr == nil {
-				testhelpers.LogErrorNil(t, "FromConfigsPath()", tc.name, tc.want)
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "containers config", tc.wantContainers, got.Config.Containers) {
 				return
 			}
 
-			if !testhelpers.RegexMatch(t, "FromConfigsPath()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "resolved containers config", tc.wantResolved, got.ResolvedConfig().Containers) {
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "docker configs", tc.wantDockerConfigs, got.dockerConfigs) {
 				return
 			}
 		})
 	}
 }
 
-var buildDeploymentFromConfigStringerTests = []struct {
-	name   string
-	config config.Homelab
-	want   string
+var buildDeploymentWithHostOverlaysTests = []struct {
+	name              string
+	config            string
+	wantGlobalEnv     []config.ConfigEnv
+	wantContainers    []config.Container
+	wantResolved      []config.Container
+	wantDockerConfigs containerDockerConfigMap
 }{
 	{
-		name: "Valid Empty Config",
+		name: "Host Overlays - Env And Container Patches",
+		config: `
+global:
+  baseDir: testdata/dummy-base-dir
+  env:
+    - var: FOO
+      value: global-foo
+    - var: BAR
+      value: global-bar
+hosts:
+  - name: fakehost
+    env:
+      - var: BAR
+        value: host-bar
+      - var: BAZ
+        value: host-baz
+    allowedContainers:
+      - group: g1
+        container: c1
+    containers:
+      - info:
+          group: g1
+          container: c1
+        image:
+          image: foo/c1:arm64
+        fs:
+          devices:
+            static:
+              - src: /dev/dri
+        network:
+          publishedPorts:
+            - containerPort: 80
+              proto: tcp
+              hostIp: $$HOST_IP$$
+              hostPort: 8080
+        runtime:
+          shmSize: 1g
+  - name: otherhost
+    env:
+      - var: BAR
+        value: other-bar
+    containers:
+      - info:
+          group: g1
+          container: c1
+        image:
+          image: foo/c1:other
+groups:
+  - name: g1
+    order: 1
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: foo/c1:latest
+    lifecycle:
+      order: 1
+    network:
+      publishedPorts:
+        - containerPort: 80
+          proto: tcp
+          hostIp: 127.0.0.1
+          hostPort: 8080
+    runtime:
+      env:
+        - var: MY_ENV
+          value: $$FOO$$-$$BAR$$-$$BAZ$$`,
+		wantGlobalEnv: []config.ConfigEnv{
+			{
+				Var:   "FOO",
+				Value: "global-foo",
+			},
+			{
+				Var:   "BAR",
+				Value: "global-bar",
+			},
+		},
+		wantContainers: []config.Container{
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c1",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c1:latest",
+				},
+				Lifecycle: config.ContainerLifecycle{
+					Order: 1,
+				},
+				Network: config.ContainerNetwork{
+					PublishedPorts: []config.PublishedPort{
+						{
+							ContainerPort: "80",
+							Protocol:      "tcp",
+							HostIP:        "127.0.0.1",
+							HostPort:      "8080",
+						},
+					},
+				},
+				Runtime: config.ContainerRuntime{
+					Env: []config.ContainerEnv{
+						{
+							Var:   "MY_ENV",
+							Value: "global-foo-host-bar-host-baz",
+						},
+					},
+				},
+			},
+		},
+		wantResolved: []config.Container{
+			{
+				Info: config.ContainerReference{
+					Group:     "g1",
+					Container: "c1",
+				},
+				Image: config.ContainerImage{
+					Image: "foo/c1:arm64",
+				},
+				Lifecycle: config.ContainerLifecycle{
+					Order: 1,
+				},
+				Filesystem: config.ContainerFilesystem{
+					Devices: config.ContainerDevice{
+						Static: []config.Device{
+							{
+								Src: "/dev/dri",
+							},
+						},
+					},
+				},
+				Network: config.ContainerNetwork{
+					PublishedPorts: []config.PublishedPort{
+						{
+							ContainerPort: "80",
+							Protocol:      "tcp",
+							HostIP:        "10.76.77.78",
+							HostPort:      "8080",
+						},
+					},
+				},
+				Runtime: config.ContainerRuntime{
+					ShmSize: "1g",
+					Env: []config.ContainerEnv{
+						{
+							Var:   "MY_ENV",
+							Value: "global-foo-host-bar-host-baz",
+						},
+					},
+				},
+			},
+		},
+		wantDockerConfigs: containerDockerConfigMap{
+			config.ContainerReference{
+				Group:     "g1",
+				Container: "c1",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					ExposedPorts: nat.PortSet{
+						"80/tcp": struct{}{},
+					},
+					Env: []string{
+						"MY_ENV=global-foo-host-bar-host-baz",
+					},
+					Image: "foo/c1:arm64",
+				},
+				HostConfig: &dcontainer.HostConfig{
+					NetworkMode: "none",
+					PortBindings: nat.PortMap{
+						"80/tcp": []nat.PortBinding{
+							{
+								HostIP:   "10.76.77.78",
+								HostPort: "8080",
+							},
+						},
+					},
+					ShmSize: 1073741824,
+					Resources: dcontainer.Resources{
+						Devices: []dcontainer.DeviceMapping{
+							{
+								PathOnHost:        "/dev/dri",
+								PathInContainer:   "/dev/dri",
+								CgroupPermissions: "rwm",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestBuildDeploymentWithHostOverlays(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range buildDeploymentWithHostOverlaysTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := strings.NewReader(tc.config)
+			got, gotErr := FromReader(testutils.NewVanillaTestContext(), input)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "global config env", tc.wantGlobalEnv, got.Config.Global.Env) {
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "containers config", tc.wantContainers, got.Config.Containers) {
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "resolved containers config", tc.wantResolved, got.ResolvedConfig().Containers) {
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "docker configs", tc.wantDockerConfigs, got.dockerConfigs) {
+				return
+			}
+		})
+	}
+}
+
+var buildDeploymentWithHostMatchingTests = []struct {
+	name         string
+	config       string
+	wantAllowed  []string
+	wantImages   []string
+	wantHostName string
+}{
+	{
+		name: "Host Matching - Patterns, Host Groups And Arch",
+		config: `
+global:
+  baseDir: testdata/dummy-base-dir
+  env:
+    - var: MY_ENV
+      value: global
+hostGroups:
+  - name: pis
+    hosts:
+      - pi1
+      - pi2
+  - name: fakes
+    hosts:
+      - fake*
+hosts:
+  - name: "*"
+    env:
+      - var: MY_ENV
+        value: all-hosts
+    allowedContainers:
+      - group: g1
+        container: c1
+  - hostGroup: fakes
+    env:
+      - var: MY_ENV
+        value: fakes
+    allowedContainers:
+      - group: g1
+        container: c2
+    containers:
+      - info:
+          group: g1
+          container: c1
+        image:
+          image: foo/c1:fakes
+  - arch: amd64
+    containers:
+      - info:
+          group: g1
+          container: c1
+        image:
+          image: foo/c1:amd64
+  - hostGroup: pis
+    allowedContainers:
+      - group: g1
+        container: c3
+  - name: fakehost
+    arch: arm64
+    allowedContainers:
+      - group: g1
+        container: c4
+groups:
+  - name: g1
+    order: 1
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: foo/c1
+    lifecycle:
+      order: 1
+  - info:
+      group: g1
+      container: c2
+    image:
+      image: foo/c2
+    lifecycle:
+      order: 1
+  - info:
+      group: g1
+      container: c3
+    image:
+      image: foo/c3
+    lifecycle:
+      order: 1
+  - info:
+      group: g1
+      container: c4
+    image:
+      image: foo/c4
+    lifecycle:
+      order: 1
+  - info:
+      group: g1
+      container: c5
+    placement:
+      - hostGroup: pis
+      - name: fake?ost
+        arch: amd64
+    image:
+      image: foo/c5
+    network:
+      hostName: c5-$$MY_ENV$$
+    lifecycle:
+      order: 1
+  - info:
+      group: g1
+      container: c6
+    placement:
+      - arch: arm64
+    image:
+      image: foo/c6
+    lifecycle:
+      order: 1`,
+		wantAllowed: []string{
+			"g1-c1",
+			"g1-c2",
+			"g1-c5",
+		},
+		wantImages: []string{
+			"foo/c1:amd64",
+			"foo/c2",
+			"foo/c3",
+			"foo/c4",
+			"foo/c5",
+			"foo/c6",
+		},
+		wantHostName: "c5-fakes",
+	},
+}
+
+func TestBuildDeploymentWithHostMatching(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range buildDeploymentWithHostMatchingTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := strings.NewReader(tc.config)
+			got, gotErr := FromReader(testutils.NewVanillaTestContext(), input)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
+				return
+			}
+
+			var gotAllowed []string
+			for _, ct := range got.queryAllContainers() {
+				if ct.isAllowedOnCurrentHost() {
+					gotAllowed = append(gotAllowed, ct.Name())
+				}
+			}
+			sort.Strings(gotAllowed)
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "allowed containers", tc.wantAllowed, gotAllowed) {
+				return
+			}
+
+			var gotImages []string
+			for _, ct := range got.ResolvedConfig().Containers {
+				gotImages = append(gotImages, ct.Image.Image)
+			}
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "resolved container images", tc.wantImages, gotImages) {
+				return
+			}
+
+			gotHostName := got.ResolvedConfig().Containers[4].Network.HostName
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "resolved container host name", tc.wantHostName, gotHostName) {
+				return
+			}
+		})
+	}
+}
+
+var buildDeploymentWithContainerRefsTests = []struct {
+	name    string
+	config  string
+	wantEnv []string
+}{
+	{
+		name: "Container Refs - IP, Container Name And Host Name With Defaults",
+		config: `
+global:
+  baseDir: testdata/dummy-base-dir
+ipam:
+  networks:
+    bridgeModeNetworks:
+      - name: net1
+        hostInterfaceName: docker-net1
+        cidr: 172.18.18.0/24
+        priority: 1
+        containers:
+          - ip: 172.18.18.11
+            container:
+              group: g1
+              container: app
+          - ip: 172.18.18.12
+            container:
+              group: g2
+              container: db
+groups:
+  - name: g1
+    order: 1
+  - name: g2
+    order: 2
+containers:
+  - info:
+      group: g1
+      container: app
+    image:
+      image: foo/app
+    runtime:
+      env:
+        - var: DB_IP
+          value: $$IP:net1:g2/db$$
+        - var: DB_NAME
+          value: $$CONTAINER_NAME:g2/db$$
+        - var: DB_HOST
+          value: $$HOSTNAME:g2/db$$
+        - var: CACHE_HOST
+          value: $$HOSTNAME:g2/cache$$
+        - var: SELF
+          value: $$IP:net1:g1/app$$
+        - var: CACHE_IP
+          value: $$IP:net1:g2/cache:-127.0.0.1$$
+        - var: DB_PASSWORD
+          value: pa$$$$word-$$DB_PASSWORD:-$$
+    lifecycle:
+      order: 1
+  - info:
+      group: g2
+      container: db
+    config:
+      env:
+        - var: DB_SUFFIX
+          value: postgres
+    image:
+      image: foo/db
+    network:
+      hostName: db-$$DB_SUFFIX$$.$$HOST_NAME$$
+    lifecycle:
+      order: 1
+  - info:
+      group: g2
+      container: cache
+    image:
+      image: foo/cache
+    lifecycle:
+      order: 1`,
+		wantEnv: []string{
+			"DB_IP=172.18.18.12",
+			"DB_NAME=g2-db",
+			"DB_HOST=db-postgres.fakehost",
+			"CACHE_HOST=g2-cache",
+			"SELF=172.18.18.11",
+			"CACHE_IP=127.0.0.1",
+			"DB_PASSWORD=pa$$word-",
+		},
+	},
+}
+
+func TestBuildDeploymentWithContainerRefs(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range buildDeploymentWithContainerRefsTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := strings.NewReader(tc.config)
+			got, gotErr := FromReader(testutils.NewVanillaTestContext(), input)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
+				return
+			}
+
+			gotEnv := got.dockerConfigs[config.ContainerReference{Group: "g1", Container: "app"}].ContainerConfig.Env
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "container env", tc.wantEnv, gotEnv) {
+				return
+			}
+		})
+	}
+}
+
+var buildDeploymentWithContainerEnvFilesTests = []struct {
+	name    string
+	config  string
+	wantEnv []string
+}{
+	{
+		name: "Container Env - Merge Order Of Global, Group, Env Files And Container Env",
+		config: `
+global:
+  baseDir: testdata/container-env-files
+  container:
+    env:
+      - var: FOO
+        value: from-global
+      - var: BAR
+        value: from-global
+groups:
+  - name: g1
+    order: 1
+    container:
+      env:
+        - var: BAR
+          value: from-group
+        - var: BAZ
+          value: from-group
+containers:
+  - info:
+      group: g1
+      container: app
+    image:
+      image: foo/app
+    runtime:
+      envFiles:
+        - app.env
+        - override.env
+      env:
+        - var: QUUX
+          value: from-container
+        - var: EMPTY
+          empty: true
+        - var: HOMELAB_PATH
+          value: $$HOST_NAME$$
+        - var: PATH
+          fromHost: true
+        - var: HOMELAB_TEST_UNSET_HOST_ENV_VAR
+          fromHost: true
+    lifecycle:
+      order: 1`,
+		wantEnv: []string{
+			"FOO=from-global",
+			"BAR=from-group",
+			"BAZ=from-app-env-file",
+			"QUX=from-override-env-file",
+			"QUUX=from-container",
+			"EMPTY=",
+			"HOMELAB_PATH=fakehost",
+			"PATH=" + os.Getenv("PATH"),
+		},
+	},
+}
+
+func TestBuildDeploymentWithContainerEnvFiles(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range buildDeploymentWithContainerEnvFilesTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := strings.NewReader(tc.config)
+			got, gotErr := FromReader(testutils.NewVanillaTestContext(), input)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
+				return
+			}
+
+			gotEnv := got.dockerConfigs[config.ContainerReference{Group: "g1", Container: "app"}].ContainerConfig.Env
+			if !testhelpers.CmpDiff(t, "FromReader()", tc.name, "container env", tc.wantEnv, gotEnv) {
+				return
+			}
+		})
+	}
+}
+
+var buildDeploymentFromConfigsPathTests = []struct {
+	name              string
+	configsPath       string
+	want              *config.Homelab
+	wantDockerConfigs containerDockerConfigMap
+}{
+	{
+		name:        "Valid Multi File Config",
+		configsPath: "parse-configs-valid",
+		want: &config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Env: []config.ConfigEnv{
+					{
+						Var:   "MY_GLOBAL_FOO",
+						Value: "MY_GLOBAL_BAR",
+					},
+				},
+				Container: config.GlobalContainer{
+					StopSignal:  "SIGTERM",
+					StopTimeout: 5,
+					RestartPolicy: config.ContainerRestartPolicy{
+						Mode:          "on-failure",
+						MaxRetryCount: 5,
+					},
+					DomainName: "somedomain",
+				},
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.11",
+									Container: config.ContainerReference{
+										Group:     "g1",
+										Container: "c1",
+									},
+								},
+								{
+									IP: "172.18.100.12",
+									Container: config.ContainerReference{
+										Group:     "g1",
+										Container: "c2",
+									},
+								},
+							},
+						},
+						{
+							Name:              "net2",
+							HostInterfaceName: "docker-net2",
+							CIDR:              "172.18.101.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.101.21",
+									Container: config.ContainerReference{
+										Group:     "g2",
+										Container: "c3",
+									},
+								},
+							},
+						},
+						{
+							Name:              "net-common",
+							HostInterfaceName: "docker-cmn",
+							CIDR:              "172.19.200.0/24",
+							Priority:          2,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.19.200.201",
+									Container: config.ContainerReference{
+										Group:     "g1",
+										Container: "c1",
+									},
+								},
+								{
+									IP: "172.19.200.202",
+									Container: config.ContainerReference{
+										Group:     "g1",
+										Container: "c2",
+									},
+								},
+								{
+									IP: "172.19.200.203",
+									Container: config.ContainerReference{
+										Group:     "g2",
+										Container: "c3",
+									},
+								},
+							},
+						},
+					},
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "g3-c4",
+							Container: config.ContainerReference{
+								Group:     "g3",
+								Container: "c4",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Group:     "g3",
+									Container: "c5",
+								},
+								{
+									Group:     "g3",
+									Container: "c6",
+								},
+								{
+									Group:     "g3",
+									Container: "c7",
+								},
+							},
+						},
+					},
+				},
+			},
+			Hosts: []config.Host{
+				{
+					Name: "fakehost",
+					AllowedContainers: []config.ContainerReference{
+						{
+							Group:     "g1",
+							Container: "c1",
+						},
+						{
+							Group:     "g3",
+							Container: "c4",
+						},
+					},
+				},
+				{
+					Name: "host2",
+				},
+				{
+					Name: "host3",
+					AllowedContainers: []config.ContainerReference{
+						{
+							Group:     "g2",
+							Container: "c3",
+						},
+					},
+				},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+				{
+					Name:  "g2",
+					Order: 2,
+				},
+				{
+					Name:  "g3",
+					Order: 3,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c2",
+					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz2",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 2,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g2",
+						Container: "c3",
+					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz3",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g3",
+						Container: "c4",
+					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz4",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+			},
+		},
+		wantDockerConfigs: containerDockerConfigMap{
+			config.ContainerReference{
+				Group:     "g1",
+				Container: "c1",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					Domainname:  "somedomain",
+					Image:       "abc/xyz",
+					StopTimeout: testhelpers.NewInt(5),
+				},
+				HostConfig: &dcontainer.HostConfig{
+					NetworkMode: "net1",
+					RestartPolicy: dcontainer.RestartPolicy{
+						Name:              "on-failure",
+						MaximumRetryCount: 5,
+					},
+				},
+				NetworkConfig: &dnetwork.NetworkingConfig{
+					EndpointsConfig: map[string]*dnetwork.EndpointSettings{
+						"net1": {
+							IPAMConfig: &dnetwork.EndpointIPAMConfig{
+								IPv4Address: "172.18.100.11",
+							},
+						},
+					},
+				},
+			},
+			config.ContainerReference{
+				Group:     "g1",
+				Container: "c2",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					Domainname:  "somedomain",
+					Image:       "abc/xyz2",
+					StopTimeout: testhelpers.NewInt(5),
+				},
+				HostConfig: &dcontainer.HostConfig{
+					NetworkMode: "net1",
+					RestartPolicy: dcontainer.RestartPolicy{
+						Name:              "on-failure",
+						MaximumRetryCount: 5,
+					},
+				},
+				NetworkConfig: &dnetwork.NetworkingConfig{
+					EndpointsConfig: map[string]*dnetwork.EndpointSettings{
+						"net1": {
+							IPAMConfig: &dnetwork.EndpointIPAMConfig{
+								IPv4Address: "172.18.100.12",
+							},
+						},
+					},
+				},
+			},
+			config.ContainerReference{
+				Group:     "g2",
+				Container: "c3",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					Domainname:  "somedomain",
+					Image:       "abc/xyz3",
+					StopTimeout: testhelpers.NewInt(5),
+				},
+				HostConfig: &dcontainer.HostConfig{
+					NetworkMode: "net2",
+					RestartPolicy: dcontainer.RestartPolicy{
+						Name:              "on-failure",
+						MaximumRetryCount: 5,
+					},
+				},
+				NetworkConfig: &dnetwork.NetworkingConfig{
+					EndpointsConfig: map[string]*dnetwork.EndpointSettings{
+						"net2": {
+							IPAMConfig: &dnetwork.EndpointIPAMConfig{
+								IPv4Address: "172.18.101.21",
+							},
+						},
+					},
+				},
+			},
+			config.ContainerReference{
+				Group:     "g3",
+				Container: "c4",
+			}: &containerDockerConfigs{
+				ContainerConfig: &dcontainer.Config{
+					Domainname:  "somedomain",
+					Image:       "abc/xyz4",
+					StopTimeout: testhelpers.NewInt(5),
+				},
+				HostConfig: &dcontainer.HostConfig{
+					NetworkMode: "none",
+					RestartPolicy: dcontainer.RestartPolicy{
+						Name:              "on-failure",
+						MaximumRetryCount: 5,
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestBuildDeploymentFromConfigsPath(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range buildDeploymentFromConfigsPathTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := fmt.Sprintf("%s/testdata/%s", testhelpers.Pwd(), tc.configsPath)
+			got, gotErr := FromConfigsPath(testutils.NewVanillaTestContext(), p)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromConfigsPath()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "FromConfigsPath()", tc.name, "config", tc.want, got.Config) {
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "FromConfigsPath()", tc.name, "docker configs", tc.wantDockerConfigs, got.dockerConfigs) {
+				return
+			}
+		})
+	}
+}
+
+var buildDeploymentFromConfigsPathErrorTests = []struct {
+	name        string
+	configsPath string
+	want        string
+}{
+	{
+		name:        "Non-existing configs dir path",
+		configsPath: "foo-bar",
+		want:        `os.Stat\(\) failed on homelab configs path, reason: stat [^ ]+foo-bar: no such file or directory`,
+	},
+	{
+		name:        "No configs",
+		configsPath: "parse-configs-invalid-empty-dir",
+		want:        `no homelab configs found in [^ ]+/testdata/parse-configs-invalid-empty-dir`,
+	},
+	{
+		name:        "File configs dir path",
+		configsPath: "parse-configs-invalid-with-empty-file/.empty",
+		want:        `homelab configs path [^ ]+/testdata/parse-configs-invalid-with-empty-file/.empty must be a directory`,
+	},
+	{
+		name:        "Unreadable config dir",
+		configsPath: "/root",
+		want:        `failed to read contents of directory /root, reason: open /root: permission denied`,
+	},
+	{
+		name:        "Unreadable config file",
+		configsPath: "parse-configs-invalid-unreadable-config",
+		want:        `failed to read homelab config file [^ ]+/testdata/parse-configs-invalid-unreadable-config/invalid-symlink.yaml, reason: open [^ ]+/testdata/parse-configs-invalid-unreadable-config/invalid-symlink.yaml: no such file or directory`,
+	},
+	{
+		name:        "Deep merge configs fail",
+		configsPath: "parse-configs-invalid-deepmerge-fail",
+		want:        `failed to deep merge config file [^ ]+parse-configs-invalid-deepmerge-fail/config2.yaml, reason: error due to parameter with value of primitive type: only maps and slices/arrays can be merged, which means you cannot have define the same key twice for parameters that are not maps or slices/arrays`,
+	},
+	{
+		name:        "Invalid config key",
+		configsPath: "parse-configs-invalid-config-key",
+		want:        `(?s)failed to parse homelab config, reason: yaml: unmarshal errors:.+: field someInvalidKey not found in type config\.GlobalContainer`,
+	},
+}
+
+func TestBuildDeploymentFromConfigsPathErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range buildDeploymentFromConfigsPathErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := tc.configsPath
+			if !strings.HasPrefix(tc.configsPath, "/") {
+				p = fmt.Sprintf("%s/testdata/%s", testhelpers.Pwd(), tc.configsPath)
+			}
+
+			_, gotErr := FromConfigsPath(testutils.NewVanillaTestContext(), p)
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "FromConfigsPath()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "FromConfigsPath()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
+
+var buildDeploymentFromConfigStringerTests = []struct {
+	name   string
+	config config.Homelab
+	want   string
+}{
+	{
+		name: "Valid Empty Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+		},
+		want: `Deployment{Groups:\[empty\], Networks:\[empty\]}`,
+	},
+	{
+		name: "Valid IPAM Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+						{
+							Name:              "net2",
+							HostInterfaceName: "docker-net2",
+							CIDR:              "172.18.101.0/24",
+							Priority:          1,
+						},
+					},
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net3",
+							Container: config.ContainerReference{
+								Group:     "g5",
+								Container: "ct101",
+							},
+						},
+						{
+							Name: "net4",
+							Container: config.ContainerReference{
+								Group:     "g6",
+								Container: "ct201",
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `Deployment{Groups:\[empty\], Networks:\[{Network \(Bridge\) Name: net1}, {Network \(Bridge\) Name: net2}, {Network \(Container\) Name: net3}, {Network \(Container\) Name: net4}\]}`,
+	},
+	{
+		name: "Valid Containers Without Hosts And IPAM Configs",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+				{
+					Name:  "g2",
+					Order: 2,
+				},
+				{
+					Name:  "g3",
+					Order: 3,
+				},
+				{
+					Name:  "g4",
+					Order: 4,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c2",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar2:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g2",
+						Container: "c3",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar3:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g4",
+						Container: "c4",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar4:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g4",
+						Container: "c5",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar5:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+			},
+		},
+		want: `Deployment{Groups:\[Group{Name:g1 Containers:\[Container{Name:g1-c1}, Container{Name:g1-c2}\]}, Group{Name:g2 Containers:\[Container{Name:g2-c3}\]}, Group{Name:g3 Containers:\[empty\]}, Group{Name:g4 Containers:\[Container{Name:g4-c4}, Container{Name:g4-c5}\]}\], Networks:\[empty\]}`,
+	},
+}
+
+func TestBuildDeploymentFromConfigStringer(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range buildDeploymentFromConfigStringerTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep, gotErr := FromConfig(testutils.NewVanillaTestContext(), &tc.config)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromConfig()", tc.name, gotErr)
+				return
+			}
+
+			got := dep.String()
+			if !testhelpers.RegexMatch(t, "FromConfig()", tc.name, "deployment string representation", tc.want, got) {
+				return
+			}
+
+		})
+	}
+}
+
+var buildDeploymentFromConfigErrorTests = []struct {
+	name   string
+	config config.Homelab
+	want   string
+}{
+	{
+		name:   "Empty Base Dir",
+		config: config.Homelab{},
+		want:   `homelab base directory cannot be empty`,
+	},
+	{
+		name: "Non-Existing Base Dir Path",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: "/foo/bar",
+			},
+		},
+		want: `os.Stat\(\) failed on homelab base directory path, reason: stat /foo/bar: no such file or directory`,
+	},
+	{
+		name: "Base Dir Path Points To A File",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: "testdata/dummy-base-dir/.empty",
+			},
+		},
+		want: `homelab base directory path testdata/dummy-base-dir/\.empty must be a directory`,
+	},
+	{
+		name: "Empty Global Config Env Var",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Env: []config.ConfigEnv{
+					{
+						Value: "foo-bar",
+					},
+				},
+			},
+		},
+		want: `empty env var in global config`,
+	},
+	{
+		name: "Invalid Global Config Env Var",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Env: []config.ConfigEnv{
+					{
+						Var:   "1FOO",
+						Value: "foo-bar",
+					},
+				},
+			},
+		},
+		want: `env var 1FOO must start with a letter or an underscore and contain only letters, digits, underscores, periods and hyphens in global config`,
+	},
+	{
+		name: "Duplicate Global Config Env Var",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Env: []config.ConfigEnv{
+					{
+						Var:   "FOO",
+						Value: "foo-bar",
+					},
+					{
+						Var:   "FOO2",
+						Value: "foo-bar-2",
+					},
+					{
+						Var:   "FOO",
+						Value: "foo-bar-3",
+					},
+				},
+			},
+		},
+		want: `env var FOO specified more than once in global config`,
+	},
+	{
+		name: "Global Config Env Var Without Value And ValueCommand",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Env: []config.ConfigEnv{
+					{
+						Var: "FOO",
+					},
+				},
+			},
+		},
+		want: `neither value nor valueCommand specified for env var FOO in global config`,
+	},
+	{
+		name: "Global Config Env Var With Both Value And ValueCommand",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Env: []config.ConfigEnv{
+					{
+						Var:   "FOO",
+						Value: "my-foo-bar",
+						ValueCommand: []string{
+							"/foo/bar/baz",
+						},
+					},
+				},
+			},
+		},
+		want: `exactly one of value or valueCommand must be specified for env var FOO in global config`,
+	},
+	{
+		name: "Global Config Empty Mount Def Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Type:     "bind",
+						Src:      "/foo",
+						Dst:      "/bar",
+						ReadOnly: true,
+					},
+				},
+			},
+		},
+		want: `mount name cannot be empty in global config mount defs`,
+	},
+	{
+		name: "Global Config Duplicate Mount Defs",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name: "mount-foo1",
+						Type: "bind",
+						Src:  "/foo1",
+						Dst:  "/bar1",
+					},
+					{
+						Name: "mount-foo2",
+						Type: "bind",
+						Src:  "/foo2",
+						Dst:  "/bar2",
+					},
+					{
+						Name: "mount-foo1",
+						Type: "bind",
+						Src:  "/foo3",
+						Dst:  "/bar3",
+					},
+				},
+			},
+		},
+		want: `mount name mount-foo1 defined more than once in global config mount defs`,
+	},
+	{
+		name: "Global Config Mount Def With Invalid Mount Type",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "garbage",
+						Src:  "/foo",
+						Dst:  "/bar",
+					},
+				},
+			},
+		},
+		want: `unsupported mount type garbage for mount foo in global config mount defs`,
+	},
+	{
+		name: "Global Config Mount Def With Empty Src",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "bind",
+						Dst:  "/bar",
+					},
+				},
+			},
+		},
+		want: `bind mount name foo cannot have an empty value for src in global config mount defs`,
+	},
+	{
+		name: "Global Config Tmpfs Mount Def With Non-Empty Src",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "tmpfs",
+						Src:  "/foo",
+						Dst:  "/bar",
+					},
+				},
+			},
+		},
+		want: `tmpfs mount name foo cannot have a non-empty value for src in global config mount defs`,
+	},
+	{
+		name: "Global Config Mount Def With Empty Dst",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "bind",
+						Src:  "/foo",
+					},
+				},
+			},
+		},
+		want: `mount name foo cannot have an empty value for dst in global config mount defs`,
+	},
+	{
+		name: "Global Config Bind Mount Def With Tmpfs Size",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name:      "foo",
+						Type:      "bind",
+						Src:       "/foo",
+						Dst:       "/bar",
+						TmpfsSize: 1000,
+					},
+				},
+			},
+		},
+		want: `bind mount name foo cannot specify tmpfs size in global config mount defs`,
+	},
+	{
+		name: "Global Config Tmpfs Mount Def With Negative Tmpfs Size",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name:      "foo",
+						Type:      "tmpfs",
+						Dst:       "/bar",
+						TmpfsSize: -1000,
+					},
+				},
+			},
+		},
+		want: `tmpfs mount name foo cannot specify a negative tmpfs size -1000 in global config mount defs`,
+	},
+	{
+		name: "Global Container Config Negative Stop Timeout",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					StopTimeout: -1,
+				},
+			},
+		},
+		want: `container stop timeout -1 cannot be negative in global container config`,
+	},
+	{
+		name: "Global Container Config Restart Policy MaxRetryCount Set With Non-On-Failure Mode",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					RestartPolicy: config.ContainerRestartPolicy{
+						Mode:          "always",
+						MaxRetryCount: 5,
+					},
+				},
+			},
+		},
+		want: `restart policy max retry count can be set only when the mode is on-failure in global container config`,
+	},
+	{
+		name: "Global Container Config Invalid Restart Policy Mode",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					RestartPolicy: config.ContainerRestartPolicy{
+						Mode: "garbage",
+					},
+				},
+			},
+		},
+		want: `invalid restart policy mode garbage in global container config, valid values are \[ 'no', 'always', 'on-failure', 'unless-stopped' \]`,
+	},
+	{
+		name: "Global Container Config Negative Restart Policy MaxRetryCount",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					RestartPolicy: config.ContainerRestartPolicy{
+						Mode:          "on-failure",
+						MaxRetryCount: -1,
+					},
+				},
+			},
+		},
+		want: `restart policy max retry count -1 cannot be negative in global container config`,
+	},
+	{
+		name: "Empty Global Container Config Env Var",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Env: []config.ContainerEnv{
+						{
+							Value: "foo-bar",
+						},
+					},
+				},
+			},
+		},
+		want: `empty env var in global container config`,
+	},
+	{
+		name: "Duplicate Global Container Config Env Var",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Env: []config.ContainerEnv{
+						{
+							Var:   "FOO",
+							Value: "foo-bar",
+						},
+						{
+							Var:   "FOO2",
+							Value: "foo-bar-2",
+						},
+						{
+							Var:   "FOO",
+							Value: "foo-bar-3",
+						},
+					},
+				},
+			},
+		},
+		want: `env var FOO specified more than once in global container config`,
+	},
+	{
+		name: "Global Container Config Env Var Without Value",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Env: []config.ContainerEnv{
+						{
+							Var: "FOO",
+						},
+					},
+				},
+			},
+		},
+		want: `value not specified for env var FOO in global container config`,
+	},
+	{
+		name: "Global Container Config Empty Mount Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Type:     "bind",
+							Src:      "/foo",
+							Dst:      "/bar",
+							ReadOnly: true,
+						},
+					},
+				},
+			},
+		},
+		want: `mount name cannot be empty in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Duplicate Mounts",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "mount-foo1",
+							Type: "bind",
+							Src:  "/foo1",
+							Dst:  "/bar1",
+						},
+						{
+							Name: "mount-foo2",
+							Type: "bind",
+							Src:  "/foo2",
+							Dst:  "/bar2",
+						},
+						{
+							Name: "mount-foo1",
+							Type: "bind",
+							Src:  "/foo3",
+							Dst:  "/bar3",
+						},
+					},
+				},
+			},
+		},
+		want: `mount name mount-foo1 defined more than once in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Mount With Invalid Mount Type",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "foo",
+							Type: "garbage",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
+					},
+				},
+			},
+		},
+		want: `unsupported mount type garbage for mount foo in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Mount With Empty Src",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "foo",
+							Type: "bind",
+							Dst:  "/bar",
+						},
+					},
+				},
+			},
+		},
+		want: `bind mount name foo cannot have an empty value for src in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Tmpfs Mount With Non-Empty Src",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "foo",
+							Type: "tmpfs",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
+					},
+				},
+			},
+		},
+		want: `tmpfs mount name foo cannot have a non-empty value for src in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Mount With Empty Dst",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "foo",
+							Type: "bind",
+							Src:  "/foo",
+						},
+					},
+				},
+			},
+		},
+		want: `mount name foo cannot have an empty value for dst in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Bind Mount With Tmpfs Size",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name:      "foo",
+							Type:      "bind",
+							Src:       "/foo",
+							Dst:       "/bar",
+							TmpfsSize: 10000,
+						},
+					},
+				},
+			},
+		},
+		want: `bind mount name foo cannot specify tmpfs size in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Tmpfs Mount With Negative Tmpfs Size",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name:      "foo",
+							Type:      "tmpfs",
+							Dst:       "/bar",
+							TmpfsSize: -10000,
+						},
+					},
+				},
+			},
+		},
+		want: `tmpfs mount name foo cannot specify a negative tmpfs size -10000 in global container config mounts`,
+	},
+	{
+		name: "Global Container Config Mount Def Reference Not Found",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "bind",
+						Src:  "/foo",
+						Dst:  "/bar",
+					},
+				},
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "foo2",
+						},
+					},
+				},
+			},
+		},
+		want: `mount specified by just the name foo2 not found in defs in global container config mounts`,
+	},
+	{
+		name: "Empty Global Container Config Label Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Labels: []config.Label{
+						{
+							Value: "foo-bar",
+						},
+					},
+				},
+			},
+		},
+		want: `empty label name in global container config`,
+	},
+	{
+		name: "Duplicate Global Container Config Label Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Labels: []config.Label{
+						{
+							Name:  "FOO",
+							Value: "foo-bar",
+						},
+						{
+							Name:  "FOO2",
+							Value: "foo-bar-2",
+						},
+						{
+							Name:  "FOO",
+							Value: "foo-bar-3",
+						},
+					},
+				},
+			},
+		},
+		want: `label name FOO specified more than once in global container config`,
+	},
+	{
+		name: "Global Container Config Empty Label Value",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Labels: []config.Label{
+						{
+							Name: "FOO",
+						},
+					},
+				},
+			},
+		},
+		want: `empty label value for label FOO in global container config`,
+	},
+	{
+		name: "Empty Bridge Mode Network Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `network name cannot be empty`,
+	},
+	{
+		name: "Duplicate Bridge Mode Network",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1-2",
+							CIDR:              "172.18.101.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `network net1 defined more than once in the IPAM config`,
+	},
+	{
+		name: "Empty Host Interface Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:     "net1",
+							CIDR:     "172.18.100.0/24",
+							Priority: 1,
+						},
+					},
+				},
+			},
+		},
+		want: `host interface name of network net1 cannot be empty`,
+	},
+	{
+		name: "Duplicate Network Host Interface Names",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+						{
+							Name:              "net2",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.101.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `host interface name docker-net1 of network net2 is already used by another network in the IPAM config`,
+	},
+	{
+		name: "Empty Bridge Mode Network Priority",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+						},
+					},
+				},
+			},
+		},
+		want: `network net1 cannot have a non-positive priority 0`,
+	},
+	{
+		name: "Invalid CIDR - Empty",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR  of network net1 is invalid, reason: netip\.ParsePrefix\(""\): no '/'`,
+	},
+	{
+		name: "Invalid CIDR - Unparsable",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "garbage-cidr",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR garbage-cidr of network net1 is invalid, reason: netip\.ParsePrefix\("garbage-cidr"\): no '/'`,
+	},
+	{
+		name: "Invalid CIDR - Missing /",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.16",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 172\.18\.100\.16 of network net1 is invalid, reason: netip\.ParsePrefix\("172\.18\.100\.16"\): no '/'`,
+	},
+	{
+		name: "Invalid CIDR - Wrong Prefix Length",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/33",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 172\.18\.100\.0/33 of network net1 is invalid, reason: netip\.ParsePrefix\("172\.18\.100\.0/33"\): prefix length out of range`,
+	},
+	{
+		name: "Invalid CIDR - IPv6",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "2002::1234:abcd:ffff:c0a8:101/64",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 2002::1234:abcd:ffff:c0a8:101/64 of network net1 is not an IPv4 subnet CIDR`,
+	},
+	{
+		name: "Invalid CIDR - Octets Out Of Range",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.260.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 172\.18\.260\.0/24 of network net1 is invalid, reason: netip\.ParsePrefix\("172\.18\.260\.0/24"\): ParseAddr\("172\.18\.260\.0"\): IPv4 field has value >255`,
+	},
+	{
+		name: "Invalid CIDR - Not A Network Address",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.1/25",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 172\.18\.100\.1/25 of network net1 is not the same as the network address 172\.18\.100\.0/25`,
+	},
+	{
+		name: "Invalid CIDR - Long Prefix 31",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/31",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 172\.18\.100\.0/31 of network net1 \(prefix length: 31\) cannot have a prefix length more than 30 which makes the network unusable for container IP address allocations`,
+	},
+	{
+		name: "Invalid CIDR - Long Prefix 32",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/32",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 172\.18\.100\.0/32 of network net1 \(prefix length: 32\) cannot have a prefix length more than 30 which makes the network unusable for container IP address allocations`,
+	},
+	{
+		name: "Non-RFC1918 CIDR - Public IPv4",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "11.12.13.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 11\.12\.13\.0/24 of network net1 is not within the RFC1918 private address space`,
+	},
+	{
+		name: "Non-RFC1918 CIDR - Link Local",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "169.254.10.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 169\.254\.10\.0/24 of network net1 is not within the RFC1918 private address space`,
+	},
+	{
+		name: "Non-RFC1918 CIDR - Multicast",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "224.0.0.0/26",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 224\.0\.0\.0/26 of network net1 is not within the RFC1918 private address space`,
+	},
+	{
+		name: "Overlapping CIDR",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+						{
+							Name:              "net2",
+							HostInterfaceName: "docker-net2",
+							CIDR:              "172.18.0.0/16",
+							Priority:          1,
+						},
+					},
+				},
+			},
+		},
+		want: `CIDR 172\.18\.0\.0/16 of network net2 overlaps with CIDR 172\.18\.100\.0/24 of network net1`,
+	},
+	{
+		name: "Bridge Mode Network Invalid Container Reference - Empty Group",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.11",
+									Container: config.ContainerReference{
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty group name`,
+	},
+	{
+		name: "Bridge Mode Network Invalid Container Reference - Empty Container",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.11",
+									Container: config.ContainerReference{
+										Group: "g1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty container name`,
+	},
+	{
+		name: "Invalid Container IP - Unparsable",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "garbage-ip",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} endpoint in network net1 has invalid IP garbage-ip, reason: ParseAddr\("garbage-ip"\): unable to parse IP`,
+	},
+	{
+		name: "Invalid Container IP - Too Short",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} endpoint in network net1 has invalid IP 172\.18\.100, reason: ParseAddr\("172\.18\.100"\): IPv4 address too short`,
+	},
+	{
+		name: "Invalid Container IP - Too Long",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.1.2.3.4",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} endpoint in network net1 has invalid IP 172\.18\.100\.1\.2\.3\.4, reason: ParseAddr\("172\.18\.100\.1\.2\.3\.4"\): IPv4 address too long`,
+	},
+	{
+		name: "Container IP Not Within Network CIDR",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.101.2",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} endpoint in network net1 cannot have an IP 172\.18\.101\.2 that does not belong to the network CIDR 172\.18\.100\.0/24`,
+	},
+	{
+		name: "Container IP same as Network Address",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.0",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} endpoint in network net1 cannot have an IP 172\.18\.100\.0 matching the network address 172\.18\.100\.0`,
+	},
+	{
+		name: "Container IP same as Gateway Address",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.1",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} endpoint in network net1 cannot have an IP 172\.18\.100\.1 matching the gateway address 172\.18\.100\.1`,
+	},
+	{
+		name: "Multiple Endpoints For Same Container Within A Bridge Mode Network",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.2",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+								{
+									IP: "172.18.100.3",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} cannot have multiple endpoints in network net1`,
+	},
+	{
+		name: "Duplicate Container IPs",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.2",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+								{
+									IP: "172.18.100.3",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct2",
+									},
+								},
+								{
+									IP: "172.18.100.4",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct3",
+									},
+								},
+								{
+									IP: "172.18.100.2",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct4",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `IP 172\.18\.100\.2 of container {Group:group1 Container:ct4} is already in use by another container in network net1`,
+	},
+	{
+		name: "Multiple Same Priority Bridge Mode Network Endpoints For Same Container",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.2",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+						{
+							Name:              "net2",
+							HostInterfaceName: "docker-net2",
+							CIDR:              "172.18.101.0/24",
+							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.101.2",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} cannot have multiple bridge mode network endpoints whose networks have the same priority 1`,
+	},
+	{
+		name: "Empty Container Mode Network Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Container: config.ContainerReference{
+								Group:     "some-group",
+								Container: "some-container",
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `network name cannot be empty`,
+	},
+	{
+		name: "Duplicate Container Mode Network Name",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group:     "some-group-1",
+								Container: "some-container-1",
+							},
+						},
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group:     "some-group-2",
+								Container: "some-container-2",
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `network net1 defined more than once in the IPAM config`,
+	},
+	{
+		name: "Duplicate Bridge/Container Mode Networks",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+					},
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group:     "some-group",
+								Container: "some-container",
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `network net1 defined more than once in the IPAM config`,
+	},
+	{
+		name: "Container Mode Network Invalid Container Reference - Empty Group",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Container: "some-container",
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container reference of container mode network net1 is invalid, reason: container reference cannot have an empty group name`,
+	},
+	{
+		name: "Container Mode Network Invalid Container Reference - Empty Container",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group: "some-group",
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container reference of container mode network net1 is invalid, reason: container reference cannot have an empty container name`,
+	},
+	{
+		name: "Container Mode Network Invalid Attaching Container Reference - Empty Group",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group:     "some-group",
+								Container: "some-container",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Container: "ct1",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty group name`,
+	},
+	{
+		name: "Container Mode Network Invalid Attaching Container Reference - Empty Container",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group:     "some-group",
+								Container: "some-container",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Group: "g1",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty container name`,
+	},
+	{
+		name: "Multiple Container Mode Network Stacks For Same Container Within Same Network Stack",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group:     "some-group",
+								Container: "some-container",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Group:     "group1",
+									Container: "ct1",
+								},
+								{
+									Group:     "group1",
+									Container: "ct2",
+								},
+								{
+									Group:     "group2",
+									Container: "ct3",
+								},
+								{
+									Group:     "group1",
+									Container: "ct2",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct2} is connected to multiple container mode network stacks`,
+	},
+	{
+		name: "Multiple Container Mode Network Stacks For Same Container Across Network Stacks",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					ContainerModeNetworks: []config.ContainerModeNetwork{
+						{
+							Name: "net1",
+							Container: config.ContainerReference{
+								Group:     "some-group-1",
+								Container: "some-container-1",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Group:     "group1",
+									Container: "ct1",
+								},
+							},
+						},
+						{
+							Name: "net2",
+							Container: config.ContainerReference{
+								Group:     "some-group-2",
+								Container: "some-container-2",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Group:     "group2",
+									Container: "ct2",
+								},
+								{
+									Group:     "group1",
+									Container: "ct1",
+								},
+							},
+						},
+					},
+				},
+			},
 		},
-		want: `Deployment{Groups:\[empty\], Networks:\[empty\]}`,
+		want: `container {Group:group1 Container:ct1} is connected to multiple container mode network stacks`,
 	},
 	{
-		name: "Valid IPAM Config",
+		name: "Container Connected To Both Bridge Mode and Container Mode Networks",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -1899,1731 +5178,2663 @@ var buildDeploymentFromConfigStringerTests = []struct {
 							HostInterfaceName: "docker-net1",
 							CIDR:              "172.18.100.0/24",
 							Priority:          1,
-						},
-						{
-							Name:              "net2",
-							HostInterfaceName: "docker-net2",
-							CIDR:              "172.18.101.0/24",
-							Priority:          1,
+							Containers: []config.ContainerIP{
+								{
+									IP: "172.18.100.2",
+									Container: config.ContainerReference{
+										Group:     "group1",
+										Container: "ct1",
+									},
+								},
+							},
 						},
 					},
 					ContainerModeNetworks: []config.ContainerModeNetwork{
 						{
-							Name: "net3",
+							Name: "net2",
 							Container: config.ContainerReference{
-								Group:     "g5",
-								Container: "ct101",
+								Group:     "some-group-1",
+								Container: "some-container-1",
+							},
+							AttachingContainers: []config.ContainerReference{
+								{
+									Group:     "group1",
+									Container: "ct1",
+								},
 							},
 						},
+					},
+				},
+			},
+		},
+		want: `container {Group:group1 Container:ct1} is connected to both bridge mode and container mode network stacks`,
+	},
+	{
+		name: "Empty Host Name In Hosts Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					AllowedContainers: []config.ContainerReference{
+						{
+							Group:     "g1",
+							Container: "ct1",
+						},
+					},
+				},
+			},
+		},
+		want: `host name cannot be empty in the hosts config`,
+	},
+	{
+		name: "Duplicate Host Name In Hosts Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					AllowedContainers: []config.ContainerReference{
+						{
+							Group:     "g1",
+							Container: "ct1",
+						},
 						{
-							Name: "net4",
-							Container: config.ContainerReference{
-								Group:     "g6",
-								Container: "ct201",
-							},
+							Group:     "g2",
+							Container: "ct2",
+						},
+					},
+				},
+				{
+					Name: "h1",
+					AllowedContainers: []config.ContainerReference{
+						{
+							Group:     "g3",
+							Container: "ct3",
+						},
+						{
+							Group:     "g4",
+							Container: "ct4",
+						},
+					},
+				},
+			},
+		},
+		want: `host h1 defined more than once in the hosts config`,
+	},
+	{
+		name: "Invalid Container Reference Within Host Config - Empty Group",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					AllowedContainers: []config.ContainerReference{
+						{
+							Container: "ct1",
+						},
+					},
+				},
+			},
+		},
+		want: `allowed container config within host h1 has invalid container reference, reason: container reference cannot have an empty group name`,
+	},
+	{
+		name: "Invalid Container Reference Within Host Config - Empty Container",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					AllowedContainers: []config.ContainerReference{
+						{
+							Group: "g1",
+						},
+					},
+				},
+			},
+		},
+		want: `allowed container config within host h1 has invalid container reference, reason: container reference cannot have an empty container name`,
+	},
+	{
+		name: "Duplicate Container Within Host Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					AllowedContainers: []config.ContainerReference{
+						{
+							Group:     "g1",
+							Container: "ct1",
+						},
+						{
+							Group:     "g2",
+							Container: "ct2",
+						},
+						{
+							Group:     "g3",
+							Container: "ct3",
+						},
+						{
+							Group:     "g2",
+							Container: "ct2",
 						},
 					},
 				},
 			},
 		},
-		want: `Deployment{Groups:\[empty\], Networks:\[{Network \(Bridge\) Name: net1}, {Network \(Bridge\) Name: net2}, {Network \(Container\) Name: net3}, {Network \(Container\) Name: net4}\]}`,
+		want: `container {Group:g2 Container:ct2} defined more than once in the hosts config for host h1`,
+	},
+	{
+		name: "Empty Host Group Name In Host Groups Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			HostGroups: []config.HostGroup{
+				{
+					Hosts: []string{
+						"h1",
+					},
+				},
+			},
+		},
+		want: `host group name cannot be empty in the host groups config`,
+	},
+	{
+		name: "Duplicate Host Group In Host Groups Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			HostGroups: []config.HostGroup{
+				{
+					Name: "hg1",
+					Hosts: []string{
+						"h1",
+					},
+				},
+				{
+					Name: "hg1",
+					Hosts: []string{
+						"h2",
+					},
+				},
+			},
+		},
+		want: `host group hg1 defined more than once in the host groups config`,
+	},
+	{
+		name: "Host Group Without Hosts In Host Groups Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			HostGroups: []config.HostGroup{
+				{
+					Name: "hg1",
+				},
+			},
+		},
+		want: `host group hg1 must contain at least one host in the host groups config`,
+	},
+	{
+		name: "Invalid Host Name Pattern In Host Groups Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			HostGroups: []config.HostGroup{
+				{
+					Name: "hg1",
+					Hosts: []string{
+						"h[1",
+					},
+				},
+			},
+		},
+		want: `invalid host name pattern h\[1 in host group hg1 config, reason: syntax error in pattern`,
 	},
 	{
-		name: "Valid Containers Without Hosts And IPAM Configs",
+		name: "Invalid Host Name Pattern In Hosts Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			Groups: []config.ContainerGroup{
+			Hosts: []config.Host{
 				{
-					Name:  "g1",
-					Order: 1,
+					Name: "h[1",
 				},
+			},
+		},
+		want: `invalid host name pattern h\[1 in host h\[1 config, reason: syntax error in pattern`,
+	},
+	{
+		name: "Unknown Host Group In Hosts Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
 				{
-					Name:  "g2",
-					Order: 2,
+					HostGroup: "hg1",
 				},
+			},
+		},
+		want: `host group hg1 not found in host {Name: HostGroup:hg1 Arch:} config`,
+	},
+	{
+		name: "Unsupported Host Arch In Hosts Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
 				{
-					Name:  "g3",
-					Order: 3,
+					Name: "h1",
+					Arch: "mips",
 				},
+			},
+		},
+		want: `unsupported host arch mips in host {Name:h1 HostGroup: Arch:mips} config`,
+	},
+	{
+		name: "Duplicate Host Selector In Hosts Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
 				{
-					Name:  "g4",
-					Order: 4,
+					Arch: "arm64",
 				},
-			},
-			Containers: []config.Container{
 				{
-					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c1",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
-					},
+					Arch: "arm64",
 				},
+			},
+		},
+		want: `host {Name: HostGroup: Arch:arm64} defined more than once in the hosts config`,
+	},
+	{
+		name: "Docker TLS Options Without Endpoint In Host Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
 				{
-					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c2",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar2:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+					Name: "h1",
+					Docker: config.HostDocker{
+						TLSCACert: "/certs/ca.pem",
 					},
 				},
+			},
+		},
+		want: `docker TLS options cannot be specified without the docker endpoint in host h1 config`,
+	},
+	{
+		name: "Docker Endpoint For Host Pattern In Host Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
 				{
-					Info: config.ContainerReference{
-						Group:     "g2",
-						Container: "c3",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar3:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+					Name: "pi*",
+					Docker: config.HostDocker{
+						Endpoint: "tcp://10.1.2.3:2375",
 					},
 				},
+			},
+		},
+		want: `docker endpoint can only be specified for a single host identified by its name in host pi\* config`,
+	},
+	{
+		name: "Invalid Docker Endpoint In Host Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
 				{
-					Info: config.ContainerReference{
-						Group:     "g4",
-						Container: "c4",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar4:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+					Name: "h1",
+					Docker: config.HostDocker{
+						Endpoint: "http://10.1.2.3:2375",
 					},
 				},
+			},
+		},
+		want: `docker endpoint http://10\.1\.2\.3:2375 has unsupported scheme "http", must be one of tcp or ssh in host h1 config`,
+	},
+	{
+		name: "Relative Systemd Requires Mounts For Path In Host Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
 				{
-					Info: config.ContainerReference{
-						Group:     "g4",
-						Container: "c5",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar5:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+					Name: "h1",
+					Systemd: config.HostSystemd{
+						RequiresMountsFor: []string{"mnt/nfs"},
 					},
 				},
 			},
 		},
-		want: `Deployment{Groups:\[Group{Name:g1 Containers:\[Container{Name:g1-c1}, Container{Name:g1-c2}\]}, Group{Name:g2 Containers:\[Container{Name:g2-c3}\]}, Group{Name:g3 Containers:\[empty\]}, Group{Name:g4 Containers:\[Container{Name:g4-c4}, Container{Name:g4-c5}\]}\], Networks:\[empty\]}`,
+		want: `systemd requiresMountsFor path "mnt/nfs" must be an absolute path in host h1 config`,
 	},
-}
-
-func TestBuildDeploymentFromConfigStringer(t *testing.T) {
-	t.Parallel()
-
-	for _, test := range buildDeploymentFromConfigStringerTests {
-		tc := test
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-
-			dep, gotErr := FromConfig(testutils.NewVanillaTestContext(), &tc.config)
-			if gotErr != nil {
-				testhelpers.LogErrorNotNil(t, "FromConfig()", tc.name, gotErr)
-				return
-			}
-
-			got := dep.String()
-			if !testhelpers.RegexMatch(t, "FromConfig()", tc.name, "deployment string representation", tc.want, got) {
-				return
-			}
-
-		})
-	}
-}
-
-var buildDeploymentFromConfigErrorTests = []struct {
-	name   string
-	config config.Homelab
-	want   string
-}{
 	{
-		name:   "Empty Base Dir",
-		config: config.Homelab{},
-		want:   `homelab base directory cannot be empty`,
+		name: "Invalid Host IP Selector In Host Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					HostIP: host.IPSelector{
+						Interface: "eth0",
+						CIDR:      "10.0.0.0/8",
+					},
+				},
+			},
+		},
+		want: `exactly one of interface, cidr or ip must be specified in the host IP selector in host h1 config`,
 	},
 	{
-		name: "Non-Existing Base Dir Path",
+		name: "Host IP Interface Not Found In Host Config",
 		config: config.Homelab{
 			Global: config.Global{
-				BaseDir: "/foo/bar",
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					Name: "fakehost",
+					HostIP: host.IPSelector{
+						Interface: "eth9",
+					},
+				},
 			},
 		},
-		want: `os.Stat\(\) failed on homelab base directory path, reason: stat /foo/bar: no such file or directory`,
+		want: `failed to select the host IP using host fakehost config, reason: interface eth9 with an IPv4 address not found on host fakehost`,
 	},
 	{
-		name: "Base Dir Path Points To A File",
+		name: "Empty Env Var In Host Config",
 		config: config.Homelab{
 			Global: config.Global{
-				BaseDir: "testdata/dummy-base-dir/.empty",
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					Env: []config.ConfigEnv{
+						{
+							Value: "foo",
+						},
+					},
+				},
 			},
 		},
-		want: `homelab base directory path testdata/dummy-base-dir/\.empty must be a directory`,
+		want: `empty env var in host h1 config`,
 	},
 	{
-		name: "Empty Global Config Env Var",
+		name: "Invalid Container Patch Reference Within Host Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Env: []config.ConfigEnv{
-					{
-						Value: "foo-bar",
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					Containers: []config.Container{
+						{
+							Info: config.ContainerReference{
+								Group: "g1",
+							},
+						},
 					},
 				},
 			},
 		},
-		want: `empty env var in global config`,
+		want: `container patch within host h1 has invalid container reference, reason: container reference cannot have an empty container name`,
 	},
 	{
-		name: "Duplicate Global Config Env Var",
+		name: "Duplicate Container Patch Within Host Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Env: []config.ConfigEnv{
-					{
-						Var:   "FOO",
-						Value: "foo-bar",
-					},
-					{
-						Var:   "FOO2",
-						Value: "foo-bar-2",
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					Containers: []config.Container{
+						{
+							Info: config.ContainerReference{
+								Group:     "g1",
+								Container: "ct1",
+							},
+						},
+						{
+							Info: config.ContainerReference{
+								Group:     "g1",
+								Container: "ct1",
+							},
+						},
 					},
-					{
-						Var:   "FOO",
-						Value: "foo-bar-3",
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "ct1",
 					},
 				},
 			},
 		},
-		want: `env var FOO specified more than once in global config`,
+		want: `container {Group:g1 Container:ct1} patch defined more than once in the hosts config for host h1`,
 	},
 	{
-		name: "Global Config Env Var Without Value And ValueCommand",
+		name: "Container Patch With Extends Within Host Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Env: []config.ConfigEnv{
-					{
-						Var: "FOO",
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					Containers: []config.Container{
+						{
+							Info: config.ContainerReference{
+								Group:     "g1",
+								Container: "ct1",
+							},
+							Extends: []string{
+								"t1",
+							},
+						},
+					},
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "ct1",
 					},
 				},
 			},
 		},
-		want: `neither value nor valueCommand specified for env var FOO in global config`,
+		want: `container {Group:g1 Container:ct1} patch in the hosts config for host h1 cannot specify extends`,
 	},
 	{
-		name: "Global Config Env Var With Both Value And ValueCommand",
+		name: "Undefined Container Patched Within Host Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Env: []config.ConfigEnv{
-					{
-						Var:   "FOO",
-						Value: "my-foo-bar",
-						ValueCommand: []string{
-							"/foo/bar/baz",
+			},
+			Hosts: []config.Host{
+				{
+					Name: "h1",
+					Containers: []config.Container{
+						{
+							Info: config.ContainerReference{
+								Group:     "g1",
+								Container: "ct1",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `exactly one of value or valueCommand must be specified for env var FOO in global config`,
+		want: `container {Group:g1 Container:ct1} patched in the hosts config for host h1 is not defined in the containers config`,
 	},
 	{
-		name: "Global Config Empty Mount Def Name",
+		name: "Empty Group Name In Groups Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Type:     "bind",
-						Src:      "/foo",
-						Dst:      "/bar",
-						ReadOnly: true,
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Order: 1,
 				},
 			},
 		},
-		want: `mount name cannot be empty in global config mount defs`,
+		want: `group name cannot be empty in the groups config`,
 	},
 	{
-		name: "Global Config Duplicate Mount Defs",
+		name: "Duplicate Container Group Name",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "mount-foo1",
-						Type: "bind",
-						Src:  "/foo1",
-						Dst:  "/bar1",
-					},
-					{
-						Name: "mount-foo2",
-						Type: "bind",
-						Src:  "/foo2",
-						Dst:  "/bar2",
-					},
-					{
-						Name: "mount-foo1",
-						Type: "bind",
-						Src:  "/foo3",
-						Dst:  "/bar3",
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+				{
+					Name:  "g2",
+					Order: 1,
+				},
+				{
+					Name:  "g3",
+					Order: 1,
+				},
+				{
+					Name:  "g1",
+					Order: 2,
 				},
 			},
 		},
-		want: `mount name mount-foo1 defined more than once in global config mount defs`,
+		want: `group g1 defined more than once in the groups config`,
 	},
 	{
-		name: "Global Config Mount Def With Invalid Mount Type",
+		name: "Container Group Without Order",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "garbage",
-						Src:  "/foo",
-						Dst:  "/bar",
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name: "g1",
 				},
 			},
 		},
-		want: `unsupported mount type garbage for mount foo in global config mount defs`,
+		want: `group g1 cannot have a non-positive order 0`,
 	},
 	{
-		name: "Global Config Mount Def With Empty Src",
+		name: "Container Group With Zero Order",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "bind",
-						Dst:  "/bar",
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 0,
 				},
 			},
 		},
-		want: `bind mount name foo cannot have an empty value for src in global config mount defs`,
+		want: `group g1 cannot have a non-positive order 0`,
 	},
 	{
-		name: "Global Config Tmpfs Mount Def With Non-Empty Src",
+		name: "Container Group With Negative Order",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "tmpfs",
-						Src:  "/foo",
-						Dst:  "/bar",
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: -1,
 				},
 			},
 		},
-		want: `tmpfs mount name foo cannot have a non-empty value for src in global config mount defs`,
+		want: `group g1 cannot have a non-positive order -1`,
 	},
 	{
-		name: "Global Config Mount Def With Empty Dst",
+		name: "Container Group Definition Missing",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "bind",
-						Src:  "/foo",
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
 					},
 				},
 			},
 		},
-		want: `mount name foo cannot have an empty value for dst in global config mount defs`,
+		want: `group definition missing in groups config for the container {Group:g1 Container:c1} in the containers config`,
 	},
 	{
-		name: "Global Config Bind Mount Def With Tmpfs Size",
+		name: "Duplicate Container Definition",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name:      "foo",
-						Type:      "bind",
-						Src:       "/foo",
-						Dst:       "/bar",
-						TmpfsSize: 1000,
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c2",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar2:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar3:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
 					},
 				},
 			},
 		},
-		want: `bind mount name foo cannot specify tmpfs size in global config mount defs`,
+		want: `container {Group:g1 Container:c1} defined more than once in the containers config`,
 	},
 	{
-		name: "Global Config Tmpfs Mount Def With Negative Tmpfs Size",
+		name: "Empty Container Config Env Var",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name:      "foo",
-						Type:      "tmpfs",
-						Dst:       "/bar",
-						TmpfsSize: -1000,
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Config: config.ContainerConfigOptions{
+						Env: []config.ConfigEnv{
+							{
+								Value: "foo-bar",
+							},
+						},
 					},
 				},
 			},
 		},
-		want: `tmpfs mount name foo cannot specify a negative tmpfs size -1000 in global config mount defs`,
+		want: `empty env var in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Negative Stop Timeout",
+		name: "Invalid Container Config Env Var",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					StopTimeout: -1,
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
 				},
 			},
-		},
-		want: `container stop timeout -1 cannot be negative in global container config`,
-	},
-	{
-		name: "Global Container Config Restart Policy MaxRetryCount Set With Non-On-Failure Mode",
-		config: config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					RestartPolicy: config.ContainerRestartPolicy{
-						Mode:          "always",
-						MaxRetryCount: 5,
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Config: config.ContainerConfigOptions{
+						Env: []config.ConfigEnv{
+							{
+								Var:   "FOO:BAR",
+								Value: "foo-bar",
+							},
+						},
 					},
 				},
 			},
 		},
-		want: `restart policy max retry count can be set only when the mode is on-failure in global container config`,
+		want: `env var FOO:BAR must start with a letter or an underscore and contain only letters, digits, underscores, periods and hyphens in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Invalid Restart Policy Mode",
+		name: "Duplicate Container Config Env Var",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					RestartPolicy: config.ContainerRestartPolicy{
-						Mode: "garbage",
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
 				},
 			},
-		},
-		want: `invalid restart policy mode garbage in global container config, valid values are \[ 'no', 'always', 'on-failure', 'unless-stopped' \]`,
-	},
-	{
-		name: "Global Container Config Negative Restart Policy MaxRetryCount",
-		config: config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					RestartPolicy: config.ContainerRestartPolicy{
-						Mode:          "on-failure",
-						MaxRetryCount: -1,
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Config: config.ContainerConfigOptions{
+						Env: []config.ConfigEnv{
+							{
+								Var:   "FOO",
+								Value: "foo-bar",
+							},
+							{
+								Var:   "FOO2",
+								Value: "foo-bar-2",
+							},
+							{
+								Var:   "FOO",
+								Value: "foo-bar-3",
+							},
+						},
 					},
 				},
 			},
 		},
-		want: `restart policy max retry count -1 cannot be negative in global container config`,
+		want: `env var FOO specified more than once in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Empty Global Container Config Env Var",
+		name: "Container Config Env Var Without Value And ValueCommand",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Env: []config.ContainerEnv{
-						{
-							Value: "foo-bar",
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Config: config.ContainerConfigOptions{
+						Env: []config.ConfigEnv{
+							{
+								Var: "FOO",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `empty env var in global container config`,
+		want: `neither value nor valueCommand specified for env var FOO in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Global Container Config Env Var",
+		name: "Container Config Env Var With Both Value And ValueCommand",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Env: []config.ContainerEnv{
-						{
-							Var:   "FOO",
-							Value: "foo-bar",
-						},
-						{
-							Var:   "FOO2",
-							Value: "foo-bar-2",
-						},
-						{
-							Var:   "FOO",
-							Value: "foo-bar-3",
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Config: config.ContainerConfigOptions{
+						Env: []config.ConfigEnv{
+							{
+								Var:   "FOO",
+								Value: "my-foo-bar",
+								ValueCommand: []string{
+									"/foo/bar/baz",
+								},
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `env var FOO specified more than once in global container config`,
+		want: `exactly one of value or valueCommand must be specified for env var FOO in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Env Var Without Value",
+		name: "Empty Container Placement Host Selector",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Env: []config.ContainerEnv{
-						{
-							Var: "FOO",
-						},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Placement: []config.HostSelector{
+						{},
+					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
 					},
 				},
 			},
 		},
-		want: `value not specified for env var FOO in global container config`,
+		want: `host selector must specify at least one of name, hostGroup or arch in container {Group: g1 Container:c1} config placement`,
 	},
 	{
-		name: "Global Container Config Empty Mount Name",
+		name: "Unknown Host Group In Container Placement",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Placement: []config.HostSelector{
 						{
-							Type:     "bind",
-							Src:      "/foo",
-							Dst:      "/bar",
-							ReadOnly: true,
+							HostGroup: "hg1",
 						},
 					},
+					Image: config.ContainerImage{
+						Image: "abc/xyz",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
 				},
 			},
 		},
-		want: `mount name cannot be empty in global container config mounts`,
+		want: `host group hg1 not found in container {Group: g1 Container:c1} config placement`,
 	},
 	{
-		name: "Global Container Config Duplicate Mounts",
+		name: "Empty Container Config Image",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "mount-foo1",
-							Type: "bind",
-							Src:  "/foo1",
-							Dst:  "/bar1",
-						},
-						{
-							Name: "mount-foo2",
-							Type: "bind",
-							Src:  "/foo2",
-							Dst:  "/bar2",
-						},
-						{
-							Name: "mount-foo1",
-							Type: "bind",
-							Src:  "/foo3",
-							Dst:  "/bar3",
-						},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
 					},
 				},
 			},
 		},
-		want: `mount name mount-foo1 defined more than once in global container config mounts`,
+		want: `image cannot be empty in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Mount With Invalid Mount Type",
+		name: "Container Config SkipImagePull And IgnoreImagePullFailures Both Set To True",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "foo",
-							Type: "garbage",
-							Src:  "/foo",
-							Dst:  "/bar",
-						},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image:                   "foo/bar:123",
+						SkipImagePull:           true,
+						IgnoreImagePullFailures: true,
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
 					},
 				},
 			},
 		},
-		want: `unsupported mount type garbage for mount foo in global container config mounts`,
+		want: `ignoreImagePullFailures cannot be true when skipImagePull is true in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Mount With Empty Src",
+		name: "Container Config SkipImagePull And PullImageBeforeStop Both Set To True",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "foo",
-							Type: "bind",
-							Dst:  "/bar",
-						},
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
 				},
 			},
-		},
-		want: `bind mount name foo cannot have an empty value for src in global container config mounts`,
-	},
-	{
-		name: "Global Container Config Tmpfs Mount With Non-Empty Src",
-		config: config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "foo",
-							Type: "tmpfs",
-							Src:  "/foo",
-							Dst:  "/bar",
-						},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image:               "foo/bar:123",
+						SkipImagePull:       true,
+						PullImageBeforeStop: true,
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
 					},
 				},
 			},
 		},
-		want: `tmpfs mount name foo cannot have a non-empty value for src in global container config mounts`,
+		want: `pullImageBeforeStop cannot be true when skipImagePull is true in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Mount With Empty Dst",
+		name: "Empty Container Config Label Name",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "foo",
-							Type: "bind",
-							Src:  "/foo",
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Metadata: config.ContainerMetadata{
+						Labels: []config.Label{
+							{
+								Value: "foo-bar",
+							},
 						},
 					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
 				},
 			},
 		},
-		want: `mount name foo cannot have an empty value for dst in global container config mounts`,
+		want: `empty label name in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Bind Mount With Tmpfs Size",
+		name: "Duplicate Container Config Label Name",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name:      "foo",
-							Type:      "bind",
-							Src:       "/foo",
-							Dst:       "/bar",
-							TmpfsSize: 10000,
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Metadata: config.ContainerMetadata{
+						Labels: []config.Label{
+							{
+								Name:  "FOO",
+								Value: "foo-bar",
+							},
+							{
+								Name:  "FOO2",
+								Value: "foo-bar-2",
+							},
+							{
+								Name:  "FOO",
+								Value: "foo-bar-3",
+							},
 						},
 					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
 				},
 			},
 		},
-		want: `bind mount name foo cannot specify tmpfs size in global container config mounts`,
+		want: `label name FOO specified more than once in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Tmpfs Mount With Negative Tmpfs Size",
+		name: "Container Config Empty Label Value",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name:      "foo",
-							Type:      "tmpfs",
-							Dst:       "/bar",
-							TmpfsSize: -10000,
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Metadata: config.ContainerMetadata{
+						Labels: []config.Label{
+							{
+								Name: "FOO",
+							},
 						},
 					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
 				},
 			},
 		},
-		want: `tmpfs mount name foo cannot specify a negative tmpfs size -10000 in global container config mounts`,
+		want: `empty label value for label FOO in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Mount Def Reference Not Found",
+		name: "Container Config Empty Order",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "bind",
-						Src:  "/foo",
-						Dst:  "/bar",
-					},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
 				},
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "foo2",
-						},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
 					},
 				},
 			},
 		},
-		want: `mount specified by just the name foo2 not found in defs in global container config mounts`,
+		want: `container order 0 cannot be non-positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Empty Global Container Config Label Name",
+		name: "Container Config Negative Order",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Labels: []config.Label{
-						{
-							Value: "foo-bar",
-						},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: -1,
 					},
 				},
 			},
 		},
-		want: `empty label name in global container config`,
+		want: `container order -1 cannot be non-positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Global Container Config Label Name",
+		name: "Container Config Restart Policy MaxRetryCount Set With Non-On-Failure Mode",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Labels: []config.Label{
-						{
-							Name:  "FOO",
-							Value: "foo-bar",
-						},
-						{
-							Name:  "FOO2",
-							Value: "foo-bar-2",
-						},
-						{
-							Name:  "FOO",
-							Value: "foo-bar-3",
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						RestartPolicy: config.ContainerRestartPolicy{
+							Mode:          "always",
+							MaxRetryCount: 5,
 						},
 					},
 				},
 			},
 		},
-		want: `label name FOO specified more than once in global container config`,
+		want: `restart policy max retry count can be set only when the mode is on-failure in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Global Container Config Empty Label Value",
+		name: "Container Config Invalid Restart Policy Mode",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Labels: []config.Label{
-						{
-							Name: "FOO",
-						},
-					},
-				},
 			},
-		},
-		want: `empty label value for label FOO in global container config`,
-	},
-	{
-		name: "Empty Bridge Mode Network Name",
-		config: config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						RestartPolicy: config.ContainerRestartPolicy{
+							Mode: "garbage",
 						},
 					},
 				},
 			},
 		},
-		want: `network name cannot be empty`,
+		want: `invalid restart policy mode garbage in container {Group: g1 Container:c1} config, valid values are \[ 'no', 'always', 'on-failure', 'unless-stopped' \]`,
 	},
 	{
-		name: "Duplicate Bridge Mode Network",
+		name: "Container Config Negative Restart Policy MaxRetryCount",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-						},
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1-2",
-							CIDR:              "172.18.101.0/24",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						RestartPolicy: config.ContainerRestartPolicy{
+							Mode:          "on-failure",
+							MaxRetryCount: -1,
 						},
 					},
 				},
 			},
 		},
-		want: `network net1 defined more than once in the IPAM config`,
+		want: `restart policy max retry count -1 cannot be negative in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Empty Host Interface Name",
+		name: "Container Config Negative StopTimeout",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:     "net1",
-							CIDR:     "172.18.100.0/24",
-							Priority: 1,
-						},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order:       1,
+						StopTimeout: -1,
 					},
 				},
 			},
 		},
-		want: `host interface name of network net1 cannot be empty`,
+		want: `container stop timeout -1 cannot be negative in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Network Host Interface Names",
+		name: "Container Config PrimaryUserGroup Without User",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-						},
-						{
-							Name:              "net2",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.101.0/24",
-							Priority:          1,
-						},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					User: config.ContainerUser{
+						PrimaryGroup: "my-user-group",
 					},
 				},
 			},
 		},
-		want: `host interface name docker-net1 of network net2 is already used by another network in the IPAM config`,
+		want: `container user primary group cannot be set without setting the user in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Empty Bridge Mode Network Priority",
+		name: "Container Config Device Missing Src And Dst",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Devices: config.ContainerDevice{
+							Static: []config.Device{
+								{},
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `network net1 cannot have a non-positive priority 0`,
+		want: `device src cannot be empty in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Invalid CIDR - Empty",
+		name: "Container Config Device Missing Src",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Devices: config.ContainerDevice{
+							Static: []config.Device{
+								{
+									Dst: "/dev/my-target-dev",
+								},
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR  of network net1 is invalid, reason: netip\.ParsePrefix\(""\): no '/'`,
+		want: `device src cannot be empty in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Invalid CIDR - Unparsable",
+		name: "Container Config Dynamic Device Invalid Command",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "garbage-cidr",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Devices: config.ContainerDevice{
+							DynamicCommand: []string{
+								"garbage-command",
+								"garbage-arg1",
+								"garbage-arg2",
+								"garbage-arg3",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR garbage-cidr of network net1 is invalid, reason: netip\.ParsePrefix\("garbage-cidr"\): no '/'`,
+		want: `invalid fake executor command garbage-command \["garbage-arg1" "garbage-arg2" "garbage-arg3"\]`,
 	},
 	{
-		name: "Invalid CIDR - Missing /",
+		name: "Container Config Empty Mount Name",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.16",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Type:     "bind",
+								Src:      "/foo",
+								Dst:      "/bar",
+								ReadOnly: true,
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 172\.18\.100\.16 of network net1 is invalid, reason: netip\.ParsePrefix\("172\.18\.100\.16"\): no '/'`,
+		want: `mount name cannot be empty in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid CIDR - Wrong Prefix Length",
+		name: "Container Config Duplicate Mounts Within Container Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/33",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "mount-foo1",
+								Type: "bind",
+								Src:  "/foo1",
+								Dst:  "/bar1",
+							},
+							{
+								Name: "mount-foo2",
+								Type: "bind",
+								Src:  "/foo2",
+								Dst:  "/bar2",
+							},
+							{
+								Name: "mount-foo1",
+								Type: "bind",
+								Src:  "/foo3",
+								Dst:  "/bar3",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 172\.18\.100\.0/33 of network net1 is invalid, reason: netip\.ParsePrefix\("172\.18\.100\.0/33"\): prefix length out of range`,
+		want: `mount name mount-foo1 defined more than once in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid CIDR - IPv6",
+		name: "Container Config Duplicate Mounts Within Container And Global Configs Combined",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
 						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "2002::1234:abcd:ffff:c0a8:101/64",
-							Priority:          1,
+							Name: "mount-foo2",
+							Type: "bind",
+							Src:  "/foo2-global",
+							Dst:  "/bar2-global",
+						},
+						{
+							Name: "mount-foo3",
+							Type: "bind",
+							Src:  "/foo3",
+							Dst:  "/bar3",
+						},
+					},
+				},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "mount-foo1",
+								Type: "bind",
+								Src:  "/foo1",
+								Dst:  "/bar1",
+							},
+							{
+								Name: "mount-foo2",
+								Type: "bind",
+								Src:  "/foo2",
+								Dst:  "/bar2",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 2002::1234:abcd:ffff:c0a8:101/64 of network net1 is not an IPv4 subnet CIDR`,
+		want: `mount name mount-foo2 defined more than once in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid CIDR - Octets Out Of Range",
+		name: "Container Config Mount With Invalid Mount Type",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.260.0/24",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "foo",
+								Type: "garbage",
+								Src:  "/foo",
+								Dst:  "/bar",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 172\.18\.260\.0/24 of network net1 is invalid, reason: netip\.ParsePrefix\("172\.18\.260\.0/24"\): ParseAddr\("172\.18\.260\.0"\): IPv4 field has value >255`,
+		want: `unsupported mount type garbage for mount foo in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid CIDR - Not A Network Address",
+		name: "Container Config Mount With Empty Src",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.1/25",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "foo",
+								Type: "bind",
+								Dst:  "/bar",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 172\.18\.100\.1/25 of network net1 is not the same as the network address 172\.18\.100\.0/25`,
+		want: `bind mount name foo cannot have an empty value for src in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid CIDR - Long Prefix 31",
+		name: "Container Config Tmpfs Mount With Non-Empty Src",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/31",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "foo",
+								Type: "tmpfs",
+								Src:  "/foo",
+								Dst:  "/bar",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 172\.18\.100\.0/31 of network net1 \(prefix length: 31\) cannot have a prefix length more than 30 which makes the network unusable for container IP address allocations`,
+		want: `tmpfs mount name foo cannot have a non-empty value for src in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid CIDR - Long Prefix 32",
+		name: "Container Config Mount With Empty Dst",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/32",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "foo",
+								Type: "bind",
+								Src:  "/foo",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 172\.18\.100\.0/32 of network net1 \(prefix length: 32\) cannot have a prefix length more than 30 which makes the network unusable for container IP address allocations`,
+		want: `mount name foo cannot have an empty value for dst in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Non-RFC1918 CIDR - Public IPv4",
+		name: "Container Config Bind Mount With Tmpfs Size",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "11.12.13.0/24",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name:      "foo",
+								Type:      "bind",
+								Src:       "/foo",
+								Dst:       "/bar",
+								TmpfsSize: 10000,
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 11\.12\.13\.0/24 of network net1 is not within the RFC1918 private address space`,
+		want: `bind mount name foo cannot specify tmpfs size in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Non-RFC1918 CIDR - Link Local",
+		name: "Container Config Tmpfs Mount With Negative Tmpfs Size",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "169.254.10.0/24",
-							Priority:          1,
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name:      "foo",
+								Type:      "tmpfs",
+								Dst:       "/bar",
+								TmpfsSize: -10000,
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 169\.254\.10\.0/24 of network net1 is not within the RFC1918 private address space`,
+		want: `tmpfs mount name foo cannot specify a negative tmpfs size -10000 in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Non-RFC1918 CIDR - Multicast",
+		name: "Container Config Mount Def Reference Not Found",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "224.0.0.0/26",
-							Priority:          1,
-						},
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "bind",
+						Src:  "/foo",
+						Dst:  "/bar",
 					},
 				},
 			},
-		},
-		want: `CIDR 224\.0\.0\.0/26 of network net1 is not within the RFC1918 private address space`,
-	},
-	{
-		name: "Overlapping CIDR",
-		config: config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-						},
-						{
-							Name:              "net2",
-							HostInterfaceName: "docker-net2",
-							CIDR:              "172.18.0.0/16",
-							Priority:          1,
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "foo2",
+							},
 						},
 					},
 				},
 			},
 		},
-		want: `CIDR 172\.18\.0\.0/16 of network net2 overlaps with CIDR 172\.18\.100\.0/24 of network net1`,
+		want: `mount specified by just the name foo2 not found in defs in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Bridge Mode Network Invalid Container Reference - Empty Group",
+		name: "Container Config Duplicate Mount Name Only With Global Container Config Mount",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "bind",
+						Src:  "/foo",
+						Dst:  "/bar",
+					},
+				},
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
 						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.11",
-									Container: config.ContainerReference{
-										Container: "ct1",
-									},
-								},
-							},
+							Name: "foo1",
+							Type: "bind",
+							Src:  "/foo1",
+							Dst:  "/bar1",
+						},
+						{
+							Name: "foo2",
+							Type: "bind",
+							Src:  "/foo2",
+							Dst:  "/bar2",
 						},
 					},
 				},
 			},
-		},
-		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty group name`,
-	},
-	{
-		name: "Bridge Mode Network Invalid Container Reference - Empty Container",
-		config: config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.11",
-									Container: config.ContainerReference{
-										Group: "g1",
-									},
-								},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "foo2",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty container name`,
+		want: `mount name foo2 defined more than once in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid Container IP - Unparsable",
+		name: "Container Config Duplicate Mount Name And Def With Global Container Config Mount",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
+				MountDefs: []config.Mount{
+					{
+						Name: "foo",
+						Type: "bind",
+						Src:  "/foo",
+						Dst:  "/bar",
+					},
+				},
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
 						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "garbage-ip",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+							Name: "foo1",
+							Type: "bind",
+							Src:  "/foo1",
+							Dst:  "/bar1",
+						},
+						{
+							Name: "foo2",
+							Type: "bind",
+							Src:  "/foo2",
+							Dst:  "/bar2",
+						},
+					},
+				},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
+							{
+								Name: "foo2",
+								Type: "bind",
+								Src:  "/foo-something-else",
+								Dst:  "/bar-something-else",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} endpoint in network net1 has invalid IP garbage-ip, reason: ParseAddr\("garbage-ip"\): unable to parse IP`,
+		want: `mount name foo2 defined more than once in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Invalid Container IP - Too Short",
+		name: "Container Config Published Port - Container Port Empty",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								Protocol: "tcp",
+								HostIP:   "127.0.0.1",
+								HostPort: "5001",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} endpoint in network net1 has invalid IP 172\.18\.100, reason: ParseAddr\("172\.18\.100"\): IPv4 address too short`,
+		want: `unable to convert published container port  to an integer, reason: strconv.ParseInt: parsing "": invalid syntax`,
 	},
 	{
-		name: "Invalid Container IP - Too Long",
+		name: "Container Config Published Port - Container Port Non Integer",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.1.2.3.4",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "-6365foobar9563",
+								Protocol:      "tcp",
+								HostIP:        "127.0.0.1",
+								HostPort:      "5001",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} endpoint in network net1 has invalid IP 172\.18\.100\.1\.2\.3\.4, reason: ParseAddr\("172\.18\.100\.1\.2\.3\.4"\): IPv4 address too long`,
+		want: `unable to convert published container port -6365foobar9563 to an integer, reason: strconv.ParseInt: parsing "-6365foobar9563": invalid syntax`,
 	},
 	{
-		name: "Container IP Not Within Network CIDR",
+		name: "Container Config Published Port - Container Port Negative",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.101.2",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "-1",
+								Protocol:      "tcp",
+								HostIP:        "127.0.0.1",
+								HostPort:      "5001",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} endpoint in network net1 cannot have an IP 172\.18\.101\.2 that does not belong to the network CIDR 172\.18\.100\.0/24`,
+		want: `published container port -1 cannot be non-positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container IP same as Network Address",
+		name: "Container Config Published Port - Protocol Empty",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.0",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "10001",
+								HostIP:        "127.0.0.1",
+								HostPort:      "5001",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} endpoint in network net1 cannot have an IP 172\.18\.100\.0 matching the network address 172\.18\.100\.0`,
+		want: `published container port 10001 specifies an invalid protocol  in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container IP same as Gateway Address",
+		name: "Container Config Published Port - Protocol Invalid",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.1",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "10001",
+								Protocol:      "garbage",
+								HostIP:        "127.0.0.1",
+								HostPort:      "5001",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} endpoint in network net1 cannot have an IP 172\.18\.100\.1 matching the gateway address 172\.18\.100\.1`,
+		want: `published container port 10001 specifies an invalid protocol garbage in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Multiple Endpoints For Same Container Within A Bridge Mode Network",
+		name: "Container Config Published Port - Host IP Empty",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.2",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
-								{
-									IP: "172.18.100.3",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "10001",
+								Protocol:      "tcp",
+								HostPort:      "5001",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} cannot have multiple endpoints in network net1`,
+		want: `published host IP cannot be empty for container port 10001 in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Container IPs",
+		name: "Container Config Published Port - Host IP Invalid",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.2",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
-								{
-									IP: "172.18.100.3",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct2",
-									},
-								},
-								{
-									IP: "172.18.100.4",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct3",
-									},
-								},
-								{
-									IP: "172.18.100.2",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct4",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "10001",
+								Protocol:      "tcp",
+								HostIP:        "abc.def.ghi.jkl",
+								HostPort:      "5001",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `IP 172\.18\.100\.2 of container {Group:group1 Container:ct4} is already in use by another container in network net1`,
+		want: `published host IP abc\.def\.ghi\.jkl for container port 10001 is invalid in container {Group: g1 Container:c1} config, reason: ParseAddr\("abc\.def\.ghi\.jkl"\): unexpected character \(at "abc\.def\.ghi\.jkl"\)`,
 	},
 	{
-		name: "Multiple Same Priority Bridge Mode Network Endpoints For Same Container",
+		name: "Container Config Published Port - Host Port Empty",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.2",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "10001",
+								Protocol:      "tcp",
+								HostIP:        "127.0.0.1",
 							},
 						},
-						{
-							Name:              "net2",
-							HostInterfaceName: "docker-net2",
-							CIDR:              "172.18.101.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.101.2",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
+					},
+				},
+			},
+		},
+		want: `unable to convert published host port  to an integer, reason: strconv.ParseInt: parsing "": invalid syntax`,
+	},
+	{
+		name: "Container Config Published Port - Host Port Non Integer",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "10001",
+								Protocol:      "tcp",
+								HostIP:        "127.0.0.1",
+								HostPort:      "9876garbage0123",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} cannot have multiple bridge mode network endpoints whose networks have the same priority 1`,
+		want: `unable to convert published host port 9876garbage0123 to an integer, reason: strconv.ParseInt: parsing "9876garbage0123": invalid syntax`,
 	},
 	{
-		name: "Empty Container Mode Network Name",
+		name: "Container Config Published Port - Host Port Negative",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Container: config.ContainerReference{
-								Group:     "some-group",
-								Container: "some-container",
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Network: config.ContainerNetwork{
+						PublishedPorts: []config.PublishedPort{
+							{
+								ContainerPort: "10001",
+								Protocol:      "tcp",
+								HostIP:        "127.0.0.1",
+								HostPort:      "-1",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `network name cannot be empty`,
+		want: `published host port -1 cannot be non-positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Container Mode Network Name",
+		name: "Empty Container Config Sysctl Key",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Group:     "some-group-1",
-								Container: "some-container-1",
-							},
-						},
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Group:     "some-group-2",
-								Container: "some-container-2",
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Security: config.ContainerSecurity{
+						Sysctls: []config.Sysctl{
+							{
+								Value: "foo-bar",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `network net1 defined more than once in the IPAM config`,
+		want: `empty sysctl key in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Bridge/Container Mode Networks",
+		name: "Duplicate Container Config Sysctl Key",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-						},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
 					},
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Group:     "some-group",
-								Container: "some-container",
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Security: config.ContainerSecurity{
+						Sysctls: []config.Sysctl{
+							{
+								Key:   "FOO",
+								Value: "foo-bar",
+							},
+							{
+								Key:   "FOO2",
+								Value: "foo-bar-2",
+							},
+							{
+								Key:   "FOO",
+								Value: "foo-bar-3",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `network net1 defined more than once in the IPAM config`,
+		want: `sysctl key FOO specified more than once in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Mode Network Invalid Container Reference - Empty Group",
+		name: "Container Config Empty Sysctl Value",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Container: "some-container",
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Security: config.ContainerSecurity{
+						Sysctls: []config.Sysctl{
+							{
+								Key: "FOO",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `container reference of container mode network net1 is invalid, reason: container reference cannot have an empty group name`,
+		want: `empty sysctl value for sysctl FOO in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Mode Network Invalid Container Reference - Empty Container",
+		name: "Container Lifecycle Config - Wait For Multiple Conditions",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Group: "some-group",
-							},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							Healthy: true,
+							Port:    5432,
 						},
 					},
 				},
 			},
 		},
-		want: `container reference of container mode network net1 is invalid, reason: container reference cannot have an empty container name`,
+		want: `only one of healthy, port or file can be set for the wait for condition in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Mode Network Invalid Attaching Container Reference - Empty Group",
+		name: "Container Lifecycle Config - Wait For Timeout Without Condition",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Group:     "some-group",
-								Container: "some-container",
-							},
-							AttachingContainers: []config.ContainerReference{
-								{
-									Container: "ct1",
-								},
-							},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							Timeout: "1m",
 						},
 					},
 				},
 			},
 		},
-		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty group name`,
+		want: `wait for timeout and interval can be set only along with one of healthy, port or file in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Mode Network Invalid Attaching Container Reference - Empty Container",
+		name: "Container Lifecycle Config - Wait For Port Out Of Range",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Group:     "some-group",
-								Container: "some-container",
-							},
-							AttachingContainers: []config.ContainerReference{
-								{
-									Group: "g1",
-								},
-							},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							Port: 65536,
 						},
 					},
 				},
 			},
 		},
-		want: `container IP config within network net1 has invalid container reference, reason: container reference cannot have an empty container name`,
+		want: `wait for port 65536 is out of range in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Multiple Container Mode Network Stacks For Same Container Within Same Network Stack",
+		name: "Container Lifecycle Config - Wait For Port Without Network",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net1",
-							Container: config.ContainerReference{
-								Group:     "some-group",
-								Container: "some-container",
-							},
-							AttachingContainers: []config.ContainerReference{
-								{
-									Group:     "group1",
-									Container: "ct1",
-								},
-								{
-									Group:     "group1",
-									Container: "ct2",
-								},
-								{
-									Group:     "group2",
-									Container: "ct3",
-								},
-								{
-									Group:     "group1",
-									Container: "ct2",
-								},
-							},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							Port: 80,
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct2} is connected to multiple container mode network stacks`,
+		want: `wait for port requires the container to be connected to a bridge mode network in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Multiple Container Mode Network Stacks For Same Container Across Network Stacks",
+		name: "Container Lifecycle Config - Wait For Port In Container Mode Network",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -3632,228 +7843,291 @@ var buildDeploymentFromConfigErrorTests = []struct {
 				Networks: config.Networks{
 					ContainerModeNetworks: []config.ContainerModeNetwork{
 						{
-							Name: "net1",
+							Name: "g1-c2",
 							Container: config.ContainerReference{
-								Group:     "some-group-1",
-								Container: "some-container-1",
+								Group:     "g1",
+								Container: "c2",
 							},
 							AttachingContainers: []config.ContainerReference{
 								{
-									Group:     "group1",
-									Container: "ct1",
+									Group:     "g1",
+									Container: "c1",
 								},
 							},
 						},
-						{
-							Name: "net2",
-							Container: config.ContainerReference{
-								Group:     "some-group-2",
-								Container: "some-container-2",
-							},
-							AttachingContainers: []config.ContainerReference{
-								{
-									Group:     "group2",
-									Container: "ct2",
-								},
-								{
-									Group:     "group1",
-									Container: "ct1",
-								},
-							},
+					},
+				},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 2,
+						WaitFor: config.ContainerWaitFor{
+							Port: 80,
 						},
 					},
 				},
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c2",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} is connected to multiple container mode network stacks`,
+		want: `wait for port requires the container to be connected to a bridge mode network in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Connected To Both Bridge Mode and Container Mode Networks",
+		name: "Container Lifecycle Config - Wait For Relative File",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			IPAM: config.IPAM{
-				Networks: config.Networks{
-					BridgeModeNetworks: []config.BridgeModeNetwork{
-						{
-							Name:              "net1",
-							HostInterfaceName: "docker-net1",
-							CIDR:              "172.18.100.0/24",
-							Priority:          1,
-							Containers: []config.ContainerIP{
-								{
-									IP: "172.18.100.2",
-									Container: config.ContainerReference{
-										Group:     "group1",
-										Container: "ct1",
-									},
-								},
-							},
-						},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
 					},
-					ContainerModeNetworks: []config.ContainerModeNetwork{
-						{
-							Name: "net2",
-							Container: config.ContainerReference{
-								Group:     "some-group-1",
-								Container: "some-container-1",
-							},
-							AttachingContainers: []config.ContainerReference{
-								{
-									Group:     "group1",
-									Container: "ct1",
-								},
-							},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							File: "ready",
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:group1 Container:ct1} is connected to both bridge mode and container mode network stacks`,
+		want: `wait for file ready must be an absolute path in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Empty Host Name In Hosts Config",
+		name: "Container Lifecycle Config - Wait For Invalid Timeout",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			Hosts: []config.Host{
+			Groups: []config.ContainerGroup{
 				{
-					AllowedContainers: []config.ContainerReference{
-						{
-							Group:     "g1",
-							Container: "ct1",
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							Healthy: true,
+							Timeout: "garbage",
 						},
 					},
 				},
 			},
 		},
-		want: `host name cannot be empty in the hosts config`,
+		want: `wait for timeout garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Duplicate Host Name In Hosts Config",
+		name: "Container Lifecycle Config - Wait For Non-Positive Timeout",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			Hosts: []config.Host{
+			Groups: []config.ContainerGroup{
 				{
-					Name: "h1",
-					AllowedContainers: []config.ContainerReference{
-						{
-							Group:     "g1",
-							Container: "ct1",
-						},
-						{
-							Group:     "g2",
-							Container: "ct2",
-						},
-					},
+					Name:  "g1",
+					Order: 1,
 				},
+			},
+			Containers: []config.Container{
 				{
-					Name: "h1",
-					AllowedContainers: []config.ContainerReference{
-						{
-							Group:     "g3",
-							Container: "ct3",
-						},
-						{
-							Group:     "g4",
-							Container: "ct4",
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							Healthy: true,
+							Timeout: "0s",
 						},
 					},
 				},
 			},
 		},
-		want: `host h1 defined more than once in the hosts config`,
+		want: `wait for timeout 0s must be positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Invalid Container Reference Within Host Config - Empty Group",
+		name: "Container Lifecycle Config - Wait For Invalid Interval",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			Hosts: []config.Host{
+			Groups: []config.ContainerGroup{
 				{
-					Name: "h1",
-					AllowedContainers: []config.ContainerReference{
-						{
-							Container: "ct1",
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							File:     "/ready",
+							Interval: "garbage",
 						},
 					},
 				},
 			},
 		},
-		want: `allowed container config within host h1 has invalid container reference, reason: container reference cannot have an empty group name`,
+		want: `wait for interval garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Invalid Container Reference Within Host Config - Empty Container",
+		name: "Container Lifecycle Config - Wait For Non-Positive Interval",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			Hosts: []config.Host{
+			Groups: []config.ContainerGroup{
 				{
-					Name: "h1",
-					AllowedContainers: []config.ContainerReference{
-						{
-							Group: "g1",
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						WaitFor: config.ContainerWaitFor{
+							Healthy:  true,
+							Interval: "-1s",
 						},
 					},
 				},
 			},
 		},
-		want: `allowed container config within host h1 has invalid container reference, reason: container reference cannot have an empty container name`,
+		want: `wait for interval -1s must be positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Container Within Host Config",
+		name: "Container Lifecycle Config - Start Post-Hook Without Command",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
-			Hosts: []config.Host{
+			Groups: []config.ContainerGroup{
 				{
-					Name: "h1",
-					AllowedContainers: []config.ContainerReference{
-						{
-							Group:     "g1",
-							Container: "ct1",
-						},
-						{
-							Group:     "g2",
-							Container: "ct2",
-						},
-						{
-							Group:     "g3",
-							Container: "ct3",
-						},
-						{
-							Group:     "g2",
-							Container: "ct2",
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						StartPostHook: config.ContainerHook{
+							InContainer: true,
 						},
 					},
 				},
 			},
 		},
-		want: `container {Group:g2 Container:ct2} defined more than once in the hosts config for host h1`,
+		want: `start post-hook command cannot be empty in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Empty Group Name In Groups Config",
+		name: "Container Lifecycle Config - Stop Pre-Hook Empty Command",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
 			Groups: []config.ContainerGroup{
 				{
+					Name:  "g1",
 					Order: 1,
 				},
 			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						StopPreHook: config.ContainerHook{
+							Command: []string{
+								"",
+							},
+						},
+					},
+				},
+			},
 		},
-		want: `group name cannot be empty in the groups config`,
+		want: `stop pre-hook command cannot be empty in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Container Group Name",
+		name: "Container Lifecycle Config - Stop Pre-Hook Invalid Timeout",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -3863,38 +8137,67 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Name:  "g1",
 					Order: 1,
 				},
+			},
+			Containers: []config.Container{
 				{
-					Name:  "g2",
-					Order: 1,
-				},
-				{
-					Name:  "g3",
-					Order: 1,
-				},
-				{
-					Name:  "g1",
-					Order: 2,
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						StopPreHook: config.ContainerHook{
+							Command: []string{
+								"pg_dumpall",
+							},
+							Timeout: "garbage",
+						},
+					},
 				},
 			},
 		},
-		want: `group g1 defined more than once in the groups config`,
+		want: `stop pre-hook timeout garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Container Group Without Order",
+		name: "Container Lifecycle Config - Start Post-Hook Non-Positive Timeout",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
 			Groups: []config.ContainerGroup{
 				{
-					Name: "g1",
+					Name:  "g1",
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						StartPostHook: config.ContainerHook{
+							Command: []string{
+								"warmup",
+							},
+							Timeout: "0s",
+						},
+					},
 				},
 			},
 		},
-		want: `group g1 cannot have a non-positive order 0`,
+		want: `start post-hook timeout 0s must be positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Group With Zero Order",
+		name: "Container Lifecycle Config - Stop Post-Hook Invalid Failure Policy",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -3902,14 +8205,34 @@ var buildDeploymentFromConfigErrorTests = []struct {
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
-					Order: 0,
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						StopPostHook: config.ContainerHook{
+							Command: []string{
+								"notify",
+							},
+							OnFailure: "garbage",
+						},
+					},
 				},
 			},
 		},
-		want: `group g1 cannot have a non-positive order 0`,
+		want: `invalid stop post-hook failure policy garbage in container {Group: g1 Container:c1} config, valid values are \[ 'fail', 'ignore' \]`,
 	},
 	{
-		name: "Container Group With Negative Order",
+		name: "Container Lifecycle Config - Stop Post-Hook In Container",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -3917,18 +8240,44 @@ var buildDeploymentFromConfigErrorTests = []struct {
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
-					Order: -1,
+					Order: 1,
+				},
+			},
+			Containers: []config.Container{
+				{
+					Info: config.ContainerReference{
+						Group:     "g1",
+						Container: "c1",
+					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+						StopPostHook: config.ContainerHook{
+							Command: []string{
+								"notify",
+							},
+							InContainer: true,
+						},
+					},
 				},
 			},
 		},
-		want: `group g1 cannot have a non-positive order -1`,
+		want: `stop post-hook cannot run in the container since the container is stopped by then in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Group Definition Missing",
+		name: "Container Backup Config - Relative Destination",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
@@ -3938,13 +8287,19 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
 					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+					Backup: config.ContainerBackup{
+						Destination: "backups",
+					},
 				},
 			},
 		},
-		want: `group definition missing in groups config for the container {Group:g1 Container:c1} in the containers config`,
+		want: `backup destination backups must be an absolute path in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Duplicate Container Definition",
+		name: "Container Backup Config - Invalid Compression",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -3967,37 +8322,84 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
+					Backup: config.ContainerBackup{
+						Compression: "xz",
+					},
+				},
+			},
+		},
+		want: `invalid backup compression xz in container {Group: g1 Container:c1} config, valid values are \[ 'gzip', 'zstd' \]`,
+	},
+	{
+		name: "Container Backup Config - Include Outside Data Dir",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
 				},
+			},
+			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
-						Container: "c2",
+						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar2:123",
+						Image: "foo/bar:123",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
+					Backup: config.ContainerBackup{
+						Includes: []string{
+							"../configs",
+						},
+					},
+				},
+			},
+		},
+		want: `backup include path \.\./configs must be a relative path within the container data directory in container {Group: g1 Container:c1} config`,
+	},
+	{
+		name: "Container Backup Config - Absolute Include",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
 				},
+			},
+			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar3:123",
+						Image: "foo/bar:123",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
+					Backup: config.ContainerBackup{
+						Includes: []string{
+							"/etc",
+						},
+					},
 				},
 			},
 		},
-		want: `container {Group:g1 Container:c1} defined more than once in the containers config`,
+		want: `backup include path /etc must be a relative path within the container data directory in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Empty Container Config Env Var",
+		name: "Container Backup Config - Invalid Exclude Pattern",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4020,20 +8422,18 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Config: config.ContainerConfigOptions{
-						Env: []config.ConfigEnv{
-							{
-								Value: "foo-bar",
-							},
+					Backup: config.ContainerBackup{
+						Excludes: []string{
+							"cache[",
 						},
 					},
 				},
 			},
 		},
-		want: `empty env var in container {Group: g1 Container:c1} config`,
+		want: `backup exclude pattern cache\[ is invalid in container {Group: g1 Container:c1} config, reason: syntax error in pattern`,
 	},
 	{
-		name: "Duplicate Container Config Env Var",
+		name: "Container Backup Config - Negative Retention",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4056,29 +8456,16 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Config: config.ContainerConfigOptions{
-						Env: []config.ConfigEnv{
-							{
-								Var:   "FOO",
-								Value: "foo-bar",
-							},
-							{
-								Var:   "FOO2",
-								Value: "foo-bar-2",
-							},
-							{
-								Var:   "FOO",
-								Value: "foo-bar-3",
-							},
-						},
+					Backup: config.ContainerBackup{
+						Retention: -1,
 					},
 				},
 			},
 		},
-		want: `env var FOO specified more than once in container {Group: g1 Container:c1} config`,
+		want: `backup retention -1 cannot be negative in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Env Var Without Value And ValueCommand",
+		name: "Container Backup Config - Empty Pre-Backup Hook Command",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4101,20 +8488,18 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Config: config.ContainerConfigOptions{
-						Env: []config.ConfigEnv{
-							{
-								Var: "FOO",
-							},
+					Backup: config.ContainerBackup{
+						PreBackupHook: config.ContainerHook{
+							InContainer: true,
 						},
 					},
 				},
 			},
 		},
-		want: `neither value nor valueCommand specified for env var FOO in container {Group: g1 Container:c1} config`,
+		want: `pre-backup hook command cannot be empty in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Env Var With Both Value And ValueCommand",
+		name: "Job Config - Same Name As Container",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4137,24 +8522,26 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Config: config.ContainerConfigOptions{
-						Env: []config.ConfigEnv{
-							{
-								Var:   "FOO",
-								Value: "my-foo-bar",
-								ValueCommand: []string{
-									"/foo/bar/baz",
-								},
-							},
+				},
+			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "c1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
 						},
 					},
 				},
 			},
 		},
-		want: `exactly one of value or valueCommand must be specified for env var FOO in container {Group: g1 Container:c1} config`,
+		want: `job {Group:g1 Container:c1} has the same name as a container in the containers config`,
 	},
 	{
-		name: "Empty Container Config Image",
+		name: "Job Config - Missing Group",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4171,16 +8558,32 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Group:     "g1",
 						Container: "c1",
 					},
+					Image: config.ContainerImage{
+						Image: "foo/bar:123",
+					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
 				},
 			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g2",
+							Container: "j1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
+						},
+					},
+				},
+			},
 		},
-		want: `image cannot be empty in container {Group: g1 Container:c1} config`,
+		want: `group definition missing in groups config for the job {Group:g2 Container:j1} in the jobs config`,
 	},
 	{
-		name: "Container Config SkipImagePull And IgnoreImagePullFailures Both Set To True",
+		name: "Job Config - Invalid Schedule",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4198,20 +8601,32 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image:                   "foo/bar:123",
-						SkipImagePull:           true,
-						IgnoreImagePullFailures: true,
+						Image: "foo/bar:123",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
 				},
 			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "j1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
+						},
+					},
+					Schedule: "0 25 * * *",
+				},
+			},
 		},
-		want: `ignoreImagePullFailures cannot be true when skipImagePull is true in container {Group: g1 Container:c1} config`,
+		want: `job schedule 0 25 \* \* \* is invalid in job {Group: g1 Container:j1} config, reason: value 25 out of range \[0, 23\] in the hour field 25`,
 	},
 	{
-		name: "Container Config SkipImagePull And PullImageBeforeStop Both Set To True",
+		name: "Job Config - Invalid Every Schedule",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4229,20 +8644,32 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image:               "foo/bar:123",
-						SkipImagePull:       true,
-						PullImageBeforeStop: true,
+						Image: "foo/bar:123",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
 				},
 			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "j1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
+						},
+					},
+					Schedule: "@every 10s",
+				},
+			},
 		},
-		want: `pullImageBeforeStop cannot be true when skipImagePull is true in container {Group: g1 Container:c1} config`,
+		want: `job schedule @every 10s is invalid in job {Group: g1 Container:j1} config, reason: duration 10s cannot be less than a minute`,
 	},
 	{
-		name: "Empty Container Config Label Name",
+		name: "Job Config - Invalid Timeout",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4262,23 +8689,30 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
 					},
-					Metadata: config.ContainerMetadata{
-						Labels: []config.Label{
-							{
-								Value: "foo-bar",
-							},
-						},
-					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
 				},
 			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "j1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
+						},
+					},
+					Timeout: "forever",
+				},
+			},
 		},
-		want: `empty label name in container {Group: g1 Container:c1} config`,
+		want: `job timeout forever is invalid in job {Group: g1 Container:j1} config, reason: time: invalid duration "forever"`,
 	},
 	{
-		name: "Duplicate Container Config Label Name",
+		name: "Job Config - Negative Retention",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4298,32 +8732,30 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
 					},
-					Metadata: config.ContainerMetadata{
-						Labels: []config.Label{
-							{
-								Name:  "FOO",
-								Value: "foo-bar",
-							},
-							{
-								Name:  "FOO2",
-								Value: "foo-bar-2",
-							},
-							{
-								Name:  "FOO",
-								Value: "foo-bar-3",
-							},
-						},
-					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
 				},
 			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "j1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
+						},
+					},
+					Retention: -1,
+				},
+			},
 		},
-		want: `label name FOO specified more than once in container {Group: g1 Container:c1} config`,
+		want: `job retention -1 cannot be negative in job {Group: g1 Container:j1} config`,
 	},
 	{
-		name: "Container Config Empty Label Value",
+		name: "Job Config - Restart Policy",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4343,23 +8775,34 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
 					},
-					Metadata: config.ContainerMetadata{
-						Labels: []config.Label{
-							{
-								Name: "FOO",
-							},
-						},
-					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
 				},
 			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "j1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							RestartPolicy: config.ContainerRestartPolicy{
+								Mode: "always",
+							},
+						},
+					},
+				},
+			},
 		},
-		want: `empty label value for label FOO in container {Group: g1 Container:c1} config`,
+		want: `job restart policy mode always must be 'no' in job {Group: g1 Container:j1} config`,
 	},
 	{
-		name: "Container Config Empty Order",
+		name: "Job Config - Auto Remove",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4379,13 +8822,32 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
 					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
+				},
+			},
+			Jobs: []config.Job{
+				{
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group:     "g1",
+							Container: "j1",
+						},
+						Image: config.ContainerImage{
+							Image: "foo/bar:123",
+						},
+						Lifecycle: config.ContainerLifecycle{
+							AutoRemove: true,
+						},
+					},
 				},
 			},
 		},
-		want: `container order 0 cannot be non-positive in container {Group: g1 Container:c1} config`,
+		want: `autoRemove cannot be true since the job container is removed once it exits in job {Group: g1 Container:j1} config`,
 	},
 	{
-		name: "Container Config Negative Order",
+		name: "Container Health Config - Negative Retries",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4406,15 +8868,18 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Image: "foo/bar:123",
 					},
 					Lifecycle: config.ContainerLifecycle{
-						Order: -1,
+						Order: 1,
+					},
+					Health: config.ContainerHealth{
+						Retries: -1,
 					},
 				},
 			},
 		},
-		want: `container order -1 cannot be non-positive in container {Group: g1 Container:c1} config`,
+		want: `health check retries -1 cannot be negative in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Restart Policy MaxRetryCount Set With Non-On-Failure Mode",
+		name: "Container Health Config - Invalid Interval",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4436,18 +8901,17 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
-						RestartPolicy: config.ContainerRestartPolicy{
-							Mode:          "always",
-							MaxRetryCount: 5,
-						},
+					},
+					Health: config.ContainerHealth{
+						Interval: "garbage",
 					},
 				},
 			},
 		},
-		want: `restart policy max retry count can be set only when the mode is on-failure in container {Group: g1 Container:c1} config`,
+		want: `health check interval garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Container Config Invalid Restart Policy Mode",
+		name: "Container Health Config - Invalid Timeout",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4469,17 +8933,17 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
-						RestartPolicy: config.ContainerRestartPolicy{
-							Mode: "garbage",
-						},
+					},
+					Health: config.ContainerHealth{
+						Timeout: "garbage",
 					},
 				},
 			},
 		},
-		want: `invalid restart policy mode garbage in container {Group: g1 Container:c1} config, valid values are \[ 'no', 'always', 'on-failure', 'unless-stopped' \]`,
+		want: `health check timeout garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Container Config Negative Restart Policy MaxRetryCount",
+		name: "Container Health Config - Invalid StartPeriod",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4501,18 +8965,17 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
-						RestartPolicy: config.ContainerRestartPolicy{
-							Mode:          "on-failure",
-							MaxRetryCount: -1,
-						},
+					},
+					Health: config.ContainerHealth{
+						StartPeriod: "garbage",
 					},
 				},
 			},
 		},
-		want: `restart policy max retry count -1 cannot be negative in container {Group: g1 Container:c1} config`,
+		want: `health check start period garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Container Config Negative StopTimeout",
+		name: "Container Health Config - Invalid StartInterval",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4533,16 +8996,18 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Image: "foo/bar:123",
 					},
 					Lifecycle: config.ContainerLifecycle{
-						Order:       1,
-						StopTimeout: -1,
+						Order: 1,
+					},
+					Health: config.ContainerHealth{
+						StartInterval: "garbage",
 					},
 				},
 			},
 		},
-		want: `container stop timeout -1 cannot be negative in container {Group: g1 Container:c1} config`,
+		want: `health check start interval garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Container Config PrimaryUserGroup Without User",
+		name: "Container Health Config - Invalid Unhealthy Action",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4565,16 +9030,18 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					User: config.ContainerUser{
-						PrimaryGroup: "my-user-group",
+					Health: config.ContainerHealth{
+						OnUnhealthy: config.ContainerUnhealthyPolicy{
+							Action: "reboot",
+						},
 					},
 				},
 			},
 		},
-		want: `container user primary group cannot be set without setting the user in container {Group: g1 Container:c1} config`,
+		want: `invalid health check unhealthy action reboot in container {Group: g1 Container:c1} config, valid values are \[ 'none', 'restart', 'recreate' \]`,
 	},
 	{
-		name: "Container Config Device Missing Src And Dst",
+		name: "Container Health Config - Unhealthy Backoff Without Action",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4597,20 +9064,18 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Devices: config.ContainerDevice{
-							Static: []config.Device{
-								{},
-							},
+					Health: config.ContainerHealth{
+						OnUnhealthy: config.ContainerUnhealthyPolicy{
+							Backoff: "1m",
 						},
 					},
 				},
 			},
 		},
-		want: `device src cannot be empty in container {Group: g1 Container:c1} config`,
+		want: `health check unhealthy backoff and max attempts can be set only when the unhealthy action is restart or recreate in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Device Missing Src",
+		name: "Container Health Config - Unhealthy Max Attempts With None Action",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4633,22 +9098,19 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Devices: config.ContainerDevice{
-							Static: []config.Device{
-								{
-									Dst: "/dev/my-target-dev",
-								},
-							},
+					Health: config.ContainerHealth{
+						OnUnhealthy: config.ContainerUnhealthyPolicy{
+							Action:      "none",
+							MaxAttempts: 3,
 						},
 					},
 				},
 			},
 		},
-		want: `device src cannot be empty in container {Group: g1 Container:c1} config`,
+		want: `health check unhealthy backoff and max attempts can be set only when the unhealthy action is restart or recreate in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Dynamic Device Invalid Command",
+		name: "Container Health Config - Invalid Unhealthy Backoff",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4671,23 +9133,19 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Devices: config.ContainerDevice{
-							DynamicCommand: []string{
-								"garbage-command",
-								"garbage-arg1",
-								"garbage-arg2",
-								"garbage-arg3",
-							},
+					Health: config.ContainerHealth{
+						OnUnhealthy: config.ContainerUnhealthyPolicy{
+							Action:  "restart",
+							Backoff: "garbage",
 						},
 					},
 				},
 			},
 		},
-		want: `invalid fake executor command garbage-command \["garbage-arg1" "garbage-arg2" "garbage-arg3"\]`,
+		want: `health check unhealthy backoff garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
 	},
 	{
-		name: "Container Config Empty Mount Name",
+		name: "Container Health Config - Non-Positive Unhealthy Backoff",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4710,23 +9168,19 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
-							{
-								Type:     "bind",
-								Src:      "/foo",
-								Dst:      "/bar",
-								ReadOnly: true,
-							},
+					Health: config.ContainerHealth{
+						OnUnhealthy: config.ContainerUnhealthyPolicy{
+							Action:  "recreate",
+							Backoff: "0s",
 						},
 					},
 				},
 			},
 		},
-		want: `mount name cannot be empty in container {Group: g1 Container:c1} config mounts`,
+		want: `health check unhealthy backoff 0s must be positive in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Duplicate Mounts Within Container Config",
+		name: "Container Health Config - Negative Unhealthy Max Attempts",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4749,54 +9203,22 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
-							{
-								Name: "mount-foo1",
-								Type: "bind",
-								Src:  "/foo1",
-								Dst:  "/bar1",
-							},
-							{
-								Name: "mount-foo2",
-								Type: "bind",
-								Src:  "/foo2",
-								Dst:  "/bar2",
-							},
-							{
-								Name: "mount-foo1",
-								Type: "bind",
-								Src:  "/foo3",
-								Dst:  "/bar3",
-							},
+					Health: config.ContainerHealth{
+						OnUnhealthy: config.ContainerUnhealthyPolicy{
+							Action:      "restart",
+							MaxAttempts: -1,
 						},
 					},
 				},
 			},
 		},
-		want: `mount name mount-foo1 defined more than once in container {Group: g1 Container:c1} config mounts`,
+		want: `health check unhealthy max attempts -1 cannot be negative in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Duplicate Mounts Within Container And Global Configs Combined",
+		name: "Container ShmSize Invalid Unit",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "mount-foo2",
-							Type: "bind",
-							Src:  "/foo2-global",
-							Dst:  "/bar2-global",
-						},
-						{
-							Name: "mount-foo3",
-							Type: "bind",
-							Src:  "/foo3",
-							Dst:  "/bar3",
-						},
-					},
-				},
 			},
 			Groups: []config.ContainerGroup{
 				{
@@ -4816,29 +9238,16 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
-							{
-								Name: "mount-foo1",
-								Type: "bind",
-								Src:  "/foo1",
-								Dst:  "/bar1",
-							},
-							{
-								Name: "mount-foo2",
-								Type: "bind",
-								Src:  "/foo2",
-								Dst:  "/bar2",
-							},
-						},
+					Runtime: config.ContainerRuntime{
+						ShmSize: "1foobar",
 					},
 				},
 			},
 		},
-		want: `mount name mount-foo2 defined more than once in container {Group: g1 Container:c1} config mounts`,
+		want: `invalid shmSize 1foobar in container {Group: g1 Container:c1} config, reason: invalid suffix: 'foobar'`,
 	},
 	{
-		name: "Container Config Mount With Invalid Mount Type",
+		name: "Container ShmSize Invalid Value",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4861,23 +9270,16 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
-							{
-								Name: "foo",
-								Type: "garbage",
-								Src:  "/foo",
-								Dst:  "/bar",
-							},
-						},
+					Runtime: config.ContainerRuntime{
+						ShmSize: "garbage",
 					},
 				},
 			},
 		},
-		want: `unsupported mount type garbage for mount foo in container {Group: g1 Container:c1} config mounts`,
+		want: `invalid shmSize garbage in container {Group: g1 Container:c1} config, reason: invalid size: 'garbage'`,
 	},
 	{
-		name: "Container Config Mount With Empty Src",
+		name: "Empty Container Env Var",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4900,22 +9302,20 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
 							{
-								Name: "foo",
-								Type: "bind",
-								Dst:  "/bar",
+								Value: "foo-bar",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `bind mount name foo cannot have an empty value for src in container {Group: g1 Container:c1} config mounts`,
+		want: `empty env var in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Tmpfs Mount With Non-Empty Src",
+		name: "Duplicate Container Env Var",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4938,23 +9338,29 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
 							{
-								Name: "foo",
-								Type: "tmpfs",
-								Src:  "/foo",
-								Dst:  "/bar",
+								Var:   "FOO",
+								Value: "foo-bar",
+							},
+							{
+								Var:   "FOO2",
+								Value: "foo-bar-2",
+							},
+							{
+								Var:   "FOO",
+								Value: "foo-bar-3",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `tmpfs mount name foo cannot have a non-empty value for src in container {Group: g1 Container:c1} config mounts`,
+		want: `env var FOO specified more than once in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Mount With Empty Dst",
+		name: "Container Env Var Without Value",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -4977,25 +9383,23 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
 							{
-								Name: "foo",
-								Type: "bind",
-								Src:  "/foo",
+								Var: "FOO",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `mount name foo cannot have an empty value for dst in container {Group: g1 Container:c1} config mounts`,
+		want: `value not specified for env var FOO in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Bind Mount With Tmpfs Size",
+		name: "Container Env Var With Both Value And Empty",
 		config: config.Homelab{
 			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
+				BaseDir: "testdata/container-env-files",
 			},
 			Groups: []config.ContainerGroup{
 				{
@@ -5007,7 +9411,7 @@ var buildDeploymentFromConfigErrorTests = []struct {
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
-						Container: "c1",
+						Container: "app",
 					},
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
@@ -5015,27 +9419,25 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
 							{
-								Name:      "foo",
-								Type:      "bind",
-								Src:       "/foo",
-								Dst:       "/bar",
-								TmpfsSize: 10000,
+								Var:   "FOO",
+								Value: "bar",
+								Empty: true,
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `bind mount name foo cannot specify tmpfs size in container {Group: g1 Container:c1} config mounts`,
+		want: `exactly one of value, empty or fromHost must be specified for env var FOO in container {Group: g1 Container:app} config`,
 	},
 	{
-		name: "Container Config Tmpfs Mount With Negative Tmpfs Size",
+		name: "Container Env Var With Both Empty And From Host",
 		config: config.Homelab{
 			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
+				BaseDir: "testdata/container-env-files",
 			},
 			Groups: []config.ContainerGroup{
 				{
@@ -5047,7 +9449,7 @@ var buildDeploymentFromConfigErrorTests = []struct {
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
-						Container: "c1",
+						Container: "app",
 					},
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
@@ -5055,34 +9457,25 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
 							{
-								Name:      "foo",
-								Type:      "tmpfs",
-								Dst:       "/bar",
-								TmpfsSize: -10000,
+								Var:      "FOO",
+								Empty:    true,
+								FromHost: true,
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `tmpfs mount name foo cannot specify a negative tmpfs size -10000 in container {Group: g1 Container:c1} config mounts`,
+		want: `exactly one of value, empty or fromHost must be specified for env var FOO in container {Group: g1 Container:app} config`,
 	},
 	{
-		name: "Container Config Mount Def Reference Not Found",
+		name: "Container Empty Env File Path",
 		config: config.Homelab{
 			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "bind",
-						Src:  "/foo",
-						Dst:  "/bar",
-					},
-				},
+				BaseDir: "testdata/container-env-files",
 			},
 			Groups: []config.ContainerGroup{
 				{
@@ -5094,7 +9487,7 @@ var buildDeploymentFromConfigErrorTests = []struct {
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
-						Container: "c1",
+						Container: "app",
 					},
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
@@ -5102,47 +9495,21 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
-							{
-								Name: "foo2",
-							},
+					Runtime: config.ContainerRuntime{
+						EnvFiles: []string{
+							"",
 						},
 					},
 				},
 			},
 		},
-		want: `mount specified by just the name foo2 not found in defs in container {Group: g1 Container:c1} config mounts`,
+		want: `empty env file path in container {Group: g1 Container:app} config`,
 	},
 	{
-		name: "Container Config Duplicate Mount Name Only With Global Container Config Mount",
+		name: "Container Env File Not Found",
 		config: config.Homelab{
 			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "bind",
-						Src:  "/foo",
-						Dst:  "/bar",
-					},
-				},
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "foo1",
-							Type: "bind",
-							Src:  "/foo1",
-							Dst:  "/bar1",
-						},
-						{
-							Name: "foo2",
-							Type: "bind",
-							Src:  "/foo2",
-							Dst:  "/bar2",
-						},
-					},
-				},
+				BaseDir: "testdata/container-env-files",
 			},
 			Groups: []config.ContainerGroup{
 				{
@@ -5154,7 +9521,7 @@ var buildDeploymentFromConfigErrorTests = []struct {
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
-						Container: "c1",
+						Container: "app",
 					},
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
@@ -5162,47 +9529,21 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
-							{
-								Name: "foo2",
-							},
+					Runtime: config.ContainerRuntime{
+						EnvFiles: []string{
+							"missing.env",
 						},
 					},
 				},
 			},
 		},
-		want: `mount name foo2 defined more than once in container {Group: g1 Container:c1} config mounts`,
+		want: `failed to open env file missing\.env in container {Group: g1 Container:app} config, reason: open testdata/container-env-files/g1/app/configs/missing\.env: no such file or directory`,
 	},
 	{
-		name: "Container Config Duplicate Mount Name And Def With Global Container Config Mount",
+		name: "Container Invalid Env File",
 		config: config.Homelab{
 			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
-				MountDefs: []config.Mount{
-					{
-						Name: "foo",
-						Type: "bind",
-						Src:  "/foo",
-						Dst:  "/bar",
-					},
-				},
-				Container: config.GlobalContainer{
-					Mounts: []config.Mount{
-						{
-							Name: "foo1",
-							Type: "bind",
-							Src:  "/foo1",
-							Dst:  "/bar1",
-						},
-						{
-							Name: "foo2",
-							Type: "bind",
-							Src:  "/foo2",
-							Dst:  "/bar2",
-						},
-					},
-				},
+				BaseDir: "testdata/container-env-files",
 			},
 			Groups: []config.ContainerGroup{
 				{
@@ -5214,7 +9555,7 @@ var buildDeploymentFromConfigErrorTests = []struct {
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
-						Container: "c1",
+						Container: "app",
 					},
 					Image: config.ContainerImage{
 						Image: "foo/bar:123",
@@ -5222,147 +9563,175 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Filesystem: config.ContainerFilesystem{
-						Mounts: []config.Mount{
-							{
-								Name: "foo2",
-								Type: "bind",
-								Src:  "/foo-something-else",
-								Dst:  "/bar-something-else",
-							},
+					Runtime: config.ContainerRuntime{
+						EnvFiles: []string{
+							"invalid.env",
 						},
 					},
 				},
 			},
 		},
-		want: `mount name foo2 defined more than once in container {Group: g1 Container:c1} config mounts`,
+		want: `failed to parse env file invalid\.env in container {Group: g1 Container:app} config, reason: line 1: expected VAR=VALUE`,
 	},
 	{
-		name: "Container Config Published Port - Container Port Empty",
+		name: "Empty Group Config Env Var",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "global-mount",
+							Type: "bind",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
+					},
+				},
 			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
 					Order: 1,
-				},
-			},
-			Containers: []config.Container{
-				{
-					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c1",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
-					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
+					Config: config.ContainerConfigOptions{
+						Env: []config.ConfigEnv{
 							{
-								Protocol: "tcp",
-								HostIP:   "127.0.0.1",
-								HostPort: "5001",
+								Value: "foo-bar",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `unable to convert published container port  to an integer, reason: strconv.ParseInt: parsing "": invalid syntax`,
+		want: `empty env var in group g1 config`,
 	},
 	{
-		name: "Container Config Published Port - Container Port Non Integer",
+		name: "Invalid Group Container Restart Policy",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "global-mount",
+							Type: "bind",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
+					},
+				},
 			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
 					Order: 1,
+					Container: config.GroupContainer{
+						RestartPolicy: config.ContainerRestartPolicy{
+							Mode: "foobar",
+						},
+					},
 				},
 			},
-			Containers: []config.Container{
-				{
-					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c1",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+		},
+		want: `invalid restart policy mode foobar in group g1 config, valid values are .+`,
+	},
+	{
+		name: "Group Container Env Without Value",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "global-mount",
+							Type: "bind",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
 					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
+				},
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+					Container: config.GroupContainer{
+						Env: []config.ContainerEnv{
 							{
-								ContainerPort: "-6365foobar9563",
-								Protocol:      "tcp",
-								HostIP:        "127.0.0.1",
-								HostPort:      "5001",
+								Var: "FOO",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `unable to convert published container port -6365foobar9563 to an integer, reason: strconv.ParseInt: parsing "-6365foobar9563": invalid syntax`,
+		want: `value not specified for env var FOO in group g1 config`,
 	},
 	{
-		name: "Container Config Published Port - Container Port Negative",
+		name: "Group Container Mount Redefines Global Container Mount",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "global-mount",
+							Type: "bind",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
+					},
+				},
 			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
 					Order: 1,
-				},
-			},
-			Containers: []config.Container{
-				{
-					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c1",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
-					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
+					Container: config.GroupContainer{
+						Mounts: []config.Mount{
 							{
-								ContainerPort: "-1",
-								Protocol:      "tcp",
-								HostIP:        "127.0.0.1",
-								HostPort:      "5001",
+								Name: "global-mount",
+								Type: "bind",
+								Src:  "/foo2",
+								Dst:  "/bar2",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `published container port -1 cannot be non-positive in container {Group: g1 Container:c1} config`,
+		want: `mount name global-mount defined more than once in group g1 config mounts`,
 	},
 	{
-		name: "Container Config Published Port - Protocol Empty",
+		name: "Container Mount Redefines Group Container Mount",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "global-mount",
+							Type: "bind",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
+					},
+				},
 			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
 					Order: 1,
+					Container: config.GroupContainer{
+						Mounts: []config.Mount{
+							{
+								Name: "group-mount",
+								Type: "bind",
+								Src:  "/foo2",
+								Dst:  "/bar2",
+							},
+						},
+					},
 				},
 			},
 			Containers: []config.Container{
@@ -5372,66 +9741,60 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
 							{
-								ContainerPort: "10001",
-								HostIP:        "127.0.0.1",
-								HostPort:      "5001",
+								Name: "group-mount",
+								Type: "bind",
+								Src:  "/foo3",
+								Dst:  "/bar3",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `published container port 10001 specifies an invalid protocol  in container {Group: g1 Container:c1} config`,
+		want: `mount name group-mount defined more than once in container {Group: g1 Container:c1} config mounts`,
 	},
 	{
-		name: "Container Config Published Port - Protocol Invalid",
+		name: "Group Container Empty Label Value",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					Mounts: []config.Mount{
+						{
+							Name: "global-mount",
+							Type: "bind",
+							Src:  "/foo",
+							Dst:  "/bar",
+						},
+					},
+				},
 			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
 					Order: 1,
-				},
-			},
-			Containers: []config.Container{
-				{
-					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c1",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
-					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
+					Container: config.GroupContainer{
+						Labels: []config.Label{
 							{
-								ContainerPort: "10001",
-								Protocol:      "garbage",
-								HostIP:        "127.0.0.1",
-								HostPort:      "5001",
+								Name: "foo",
 							},
 						},
 					},
 				},
 			},
 		},
-		want: `published container port 10001 specifies an invalid protocol garbage in container {Group: g1 Container:c1} config`,
+		want: `empty label value for label foo in group g1 config`,
 	},
 	{
-		name: "Container Config Published Port - Host IP Empty",
+		name: "Container Template - Empty Name",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5442,34 +9805,33 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "",
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
+					Extends: []string{
+						"t1",
+					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
-							{
-								ContainerPort: "10001",
-								Protocol:      "tcp",
-								HostPort:      "5001",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `published host IP cannot be empty for container port 10001 in container {Group: g1 Container:c1} config`,
+		want: `container template name cannot be empty in the container templates config`,
 	},
 	{
-		name: "Container Config Published Port - Host IP Invalid",
+		name: "Container Template - Duplicate Name",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5480,35 +9842,36 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "t1",
+				},
+				{
+					Name: "t1",
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
+					Extends: []string{
+						"t1",
+					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
-							{
-								ContainerPort: "10001",
-								Protocol:      "tcp",
-								HostIP:        "abc.def.ghi.jkl",
-								HostPort:      "5001",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `published host IP abc\.def\.ghi\.jkl for container port 10001 is invalid in container {Group: g1 Container:c1} config, reason: ParseAddr\("abc\.def\.ghi\.jkl"\): unexpected character \(at "abc\.def\.ghi\.jkl"\)`,
+		want: `container template t1 defined more than once in the container templates config`,
 	},
 	{
-		name: "Container Config Published Port - Host Port Empty",
+		name: "Container Template - Info Specified",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5519,34 +9882,38 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "t1",
+					Container: config.Container{
+						Info: config.ContainerReference{
+							Group: "g1",
+						},
+					},
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
+					Extends: []string{
+						"t1",
+					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
-							{
-								ContainerPort: "10001",
-								Protocol:      "tcp",
-								HostIP:        "127.0.0.1",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `unable to convert published host port  to an integer, reason: strconv.ParseInt: parsing "": invalid syntax`,
+		want: `container template t1 cannot specify the container info`,
 	},
 	{
-		name: "Container Config Published Port - Host Port Non Integer",
+		name: "Container Template - Missing Template",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5557,35 +9924,34 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "t1",
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
+					Extends: []string{
+						"t1",
+						"t2",
+					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
-							{
-								ContainerPort: "10001",
-								Protocol:      "tcp",
-								HostIP:        "127.0.0.1",
-								HostPort:      "9876garbage0123",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `unable to convert published host port 9876garbage0123 to an integer, reason: strconv.ParseInt: parsing "9876garbage0123": invalid syntax`,
+		want: `container template t2 not found, extended in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Config Published Port - Host Port Negative",
+		name: "Container Template - Missing Template Within Template",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5596,35 +9962,38 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "t1",
+					Container: config.Container{
+						Extends: []string{
+							"t3",
+						},
+					},
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
+					Extends: []string{
+						"t1",
+					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Network: config.ContainerNetwork{
-						PublishedPorts: []config.PublishedPort{
-							{
-								ContainerPort: "10001",
-								Protocol:      "tcp",
-								HostIP:        "127.0.0.1",
-								HostPort:      "-1",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `published host port -1 cannot be non-positive in container {Group: g1 Container:c1} config`,
+		want: `container template t3 not found, extended in container template t1`,
 	},
 	{
-		name: "Empty Container Config Sysctl Key",
+		name: "Container Template - Cyclic Extends",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5635,40 +10004,30 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
-			Containers: []config.Container{
+			ContainerTemplates: []config.ContainerTemplate{
 				{
-					Info: config.ContainerReference{
-						Group:     "g1",
-						Container: "c1",
-					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+					Name: "t1",
+					Container: config.Container{
+						Extends: []string{
+							"t2",
+						},
 					},
-					Security: config.ContainerSecurity{
-						Sysctls: []config.Sysctl{
-							{
-								Value: "foo-bar",
-							},
+				},
+				{
+					Name: "t2",
+					Container: config.Container{
+						Extends: []string{
+							"t3",
 						},
 					},
 				},
-			},
-		},
-		want: `empty sysctl key in container {Group: g1 Container:c1} config`,
-	},
-	{
-		name: "Duplicate Container Config Sysctl Key",
-		config: config.Homelab{
-			Global: config.Global{
-				BaseDir: testhelpers.HomelabBaseDir(),
-			},
-			Groups: []config.ContainerGroup{
 				{
-					Name:  "g1",
-					Order: 1,
+					Name: "t3",
+					Container: config.Container{
+						Extends: []string{
+							"t1",
+						},
+					},
 				},
 			},
 			Containers: []config.Container{
@@ -5677,35 +10036,22 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Group:     "g1",
 						Container: "c1",
 					},
+					Extends: []string{
+						"t1",
+					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Security: config.ContainerSecurity{
-						Sysctls: []config.Sysctl{
-							{
-								Key:   "FOO",
-								Value: "foo-bar",
-							},
-							{
-								Key:   "FOO2",
-								Value: "foo-bar-2",
-							},
-							{
-								Key:   "FOO",
-								Value: "foo-bar-3",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `sysctl key FOO specified more than once in container {Group: g1 Container:c1} config`,
+		want: `container template t1 has a cyclic extends chain t1 -> t2 -> t3 -> t1`,
 	},
 	{
-		name: "Container Config Empty Sysctl Value",
+		name: "Container Template - Unset Without Extends",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5716,32 +10062,35 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "t1",
+					Container: config.Container{
+						Image: config.ContainerImage{
+							Image: "abc/xyz",
+						},
+					},
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+					Unset: []string{
+						"image.image",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Security: config.ContainerSecurity{
-						Sysctls: []config.Sysctl{
-							{
-								Key: "FOO",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `empty sysctl value for sysctl FOO in container {Group: g1 Container:c1} config`,
+		want: `unset can be used only along with extends in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Health Config - Negative Retries",
+		name: "Container Template - Unknown Unset Field",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5752,28 +10101,38 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "t1",
+					Container: config.Container{
+						Image: config.ContainerImage{
+							Image: "abc/xyz",
+						},
+					},
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+					Extends: []string{
+						"t1",
+					},
+					Unset: []string{
+						"lifecycle.autoRemoved",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Health: config.ContainerHealth{
-						Retries: -1,
-					},
 				},
 			},
 		},
-		want: `health check retries -1 cannot be negative in container {Group: g1 Container:c1} config`,
+		want: `unset field lifecycle.autoRemoved is not a field of the container config in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Health Config - Invalid Interval",
+		name: "Container Template - Unset Info",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5784,32 +10143,54 @@ var buildDeploymentFromConfigErrorTests = []struct {
 					Order: 1,
 				},
 			},
+			ContainerTemplates: []config.ContainerTemplate{
+				{
+					Name: "t1",
+					Container: config.Container{
+						Image: config.ContainerImage{
+							Image: "abc/xyz",
+						},
+					},
+				},
+			},
 			Containers: []config.Container{
 				{
 					Info: config.ContainerReference{
 						Group:     "g1",
 						Container: "c1",
 					},
-					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+					Extends: []string{
+						"t1",
+					},
+					Unset: []string{
+						"info",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Health: config.ContainerHealth{
-						Interval: "garbage",
-					},
 				},
 			},
 		},
-		want: `health check interval garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
+		want: `field info cannot be unset in container {Group: g1 Container:c1} config`,
 	},
 	{
-		name: "Container Health Config - Invalid Timeout",
+		name: "Container Ref To Unknown Container",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
@@ -5823,25 +10204,42 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
+					},
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
+							{
+								Var:   "MY_ENV",
+								Value: "$$CONTAINER_NAME:g1/c9$$",
+							},
+						},
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Health: config.ContainerHealth{
-						Timeout: "garbage",
-					},
 				},
 			},
 		},
-		want: `health check timeout garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
+		want: `failed to resolve \$\$CONTAINER_NAME:g1/c9\$\$, reason: container g1/c9 not found in the containers config in field runtime.env\[0\].value in container \{Group: g1 Container:c1\} config`,
 	},
 	{
-		name: "Container Health Config - Invalid StartPeriod",
+		name: "Container Ref With Invalid Container Reference",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
@@ -5855,25 +10253,42 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
+					},
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
+							{
+								Var:   "MY_ENV",
+								Value: "$$HOSTNAME:c1$$",
+							},
+						},
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Health: config.ContainerHealth{
-						StartPeriod: "garbage",
-					},
 				},
 			},
 		},
-		want: `health check start period garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
+		want: `failed to resolve \$\$HOSTNAME:c1\$\$, reason: container reference c1 must be of the form <group>/<container> in field runtime.env\[0\].value in container \{Group: g1 Container:c1\} config`,
 	},
 	{
-		name: "Container Health Config - Invalid StartInterval",
+		name: "Container IP Ref Without Network",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
@@ -5887,25 +10302,42 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
+					},
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
+							{
+								Var:   "MY_ENV",
+								Value: "$$IP:g1/c1$$",
+							},
+						},
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Health: config.ContainerHealth{
-						StartInterval: "garbage",
-					},
 				},
 			},
 		},
-		want: `health check start interval garbage is invalid in container {Group: g1 Container:c1} config, reason: time: invalid duration "garbage"`,
+		want: `failed to resolve \$\$IP:g1/c1\$\$, reason: IP reference g1/c1 must be of the form <network>:<group>/<container> in field runtime.env\[0\].value in container \{Group: g1 Container:c1\} config`,
 	},
 	{
-		name: "Container ShmSize Invalid Unit",
+		name: "Container IP Ref To Unknown Network",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
@@ -5919,25 +10351,42 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
+					},
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
+							{
+								Var:   "MY_ENV",
+								Value: "$$IP:net9:g1/c1$$",
+							},
+						},
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Runtime: config.ContainerRuntime{
-						ShmSize: "1foobar",
-					},
 				},
 			},
 		},
-		want: `invalid shmSize 1foobar in container {Group: g1 Container:c1} config, reason: invalid suffix: 'foobar'`,
+		want: `failed to resolve \$\$IP:net9:g1/c1\$\$, reason: network net9 not found in field runtime.env\[0\].value in container \{Group: g1 Container:c1\} config`,
 	},
 	{
-		name: "Container ShmSize Invalid Value",
+		name: "Container IP Ref To Container Not In Network",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			IPAM: config.IPAM{
+				Networks: config.Networks{
+					BridgeModeNetworks: []config.BridgeModeNetwork{
+						{
+							Name:              "net1",
+							HostInterfaceName: "docker-net1",
+							CIDR:              "172.18.100.0/24",
+							Priority:          1,
+						},
+					},
+				},
+			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
@@ -5951,21 +10400,59 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz",
+					},
+					Runtime: config.ContainerRuntime{
+						Env: []config.ContainerEnv{
+							{
+								Var:   "MY_ENV",
+								Value: "$$IP:net1:g1/c1$$",
+							},
+						},
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Runtime: config.ContainerRuntime{
-						ShmSize: "garbage",
+				},
+			},
+		},
+		want: `failed to resolve \$\$IP:net1:g1/c1\$\$, reason: container g1/c1 has no IP in network net1 in field runtime.env\[0\].value in container \{Group: g1 Container:c1\} config`,
+	},
+	{
+		name: "Unresolved Config Env In Global Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+				Container: config.GlobalContainer{
+					DomainName: "$$HOMELAB_BASE$$.lan",
+				},
+			},
+		},
+		want: `unresolved config env placeholder \$\$HOMELAB_BASE\$\$ in field container\.domainName in global config`,
+	},
+	{
+		name: "Unresolved Config Env In Group Config",
+		config: config.Homelab{
+			Global: config.Global{
+				BaseDir: testhelpers.HomelabBaseDir(),
+			},
+			Groups: []config.ContainerGroup{
+				{
+					Name:  "g1",
+					Order: 1,
+					Container: config.GroupContainer{
+						DNSServers: []string{
+							"1.1.1.1",
+							"$$DNS_SERVER$$",
+						},
 					},
 				},
 			},
 		},
-		want: `invalid shmSize garbage in container {Group: g1 Container:c1} config, reason: invalid size: 'garbage'`,
+		want: `unresolved config env placeholder \$\$DNS_SERVER\$\$ in field container\.dnsServers\[1\] in group g1 config`,
 	},
 	{
-		name: "Empty Container Env Var",
+		name: "Unresolved Config Env In Container Config",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -5983,25 +10470,28 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+						Image: "abc/xyz",
 					},
-					Runtime: config.ContainerRuntime{
-						Env: []config.ContainerEnv{
+					Filesystem: config.ContainerFilesystem{
+						Mounts: []config.Mount{
 							{
-								Value: "foo-bar",
+								Name: "data",
+								Type: "bind",
+								Src:  "$$CONTAINR_DATA_DIR$$/data",
+								Dst:  "/data",
 							},
 						},
 					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
 				},
 			},
 		},
-		want: `empty env var in container {Group: g1 Container:c1} config`,
+		want: `unresolved config env placeholder \$\$CONTAINR_DATA_DIR\$\$ in field fs\.mounts\[0\]\.src in container \{Group: g1 Container:c1\} config`,
 	},
 	{
-		name: "Duplicate Container Env Var",
+		name: "OS Env In Container Config Without Opt In",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
@@ -6019,38 +10509,43 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
-					},
-					Lifecycle: config.ContainerLifecycle{
-						Order: 1,
+						Image: "abc/xyz",
 					},
 					Runtime: config.ContainerRuntime{
-						Env: []config.ContainerEnv{
-							{
-								Var:   "FOO",
-								Value: "foo-bar",
-							},
-							{
-								Var:   "FOO2",
-								Value: "foo-bar-2",
-							},
-							{
-								Var:   "FOO",
-								Value: "foo-bar-3",
-							},
+						Args: []string{
+							"--path=$$ENV:PATH$$",
 						},
 					},
+					Lifecycle: config.ContainerLifecycle{
+						Order: 1,
+					},
 				},
 			},
 		},
-		want: `env var FOO specified more than once in container {Group: g1 Container:c1} config`,
+		want: `unresolved config env placeholder \$\$ENV:PATH\$\$ in field runtime\.args\[0\] in container \{Group: g1 Container:c1\} config`,
 	},
 	{
-		name: "Container Env Var Without Value",
+		name: "Container Config Env - Unresolved Placeholder Overridden By Host",
 		config: config.Homelab{
 			Global: config.Global{
 				BaseDir: testhelpers.HomelabBaseDir(),
 			},
+			Hosts: []config.Host{
+				{
+					Name: "fakehost",
+					Containers: []config.Container{
+						{
+							Info: config.ContainerReference{
+								Group:     "g1",
+								Container: "c1",
+							},
+							Image: config.ContainerImage{
+								Image: "abc/xyz",
+							},
+						},
+					},
+				},
+			},
 			Groups: []config.ContainerGroup{
 				{
 					Name:  "g1",
@@ -6064,22 +10559,15 @@ var buildDeploymentFromConfigErrorTests = []struct {
 						Container: "c1",
 					},
 					Image: config.ContainerImage{
-						Image: "foo/bar:123",
+						Image: "abc/xyz:$$MY_TAG$$",
 					},
 					Lifecycle: config.ContainerLifecycle{
 						Order: 1,
 					},
-					Runtime: config.ContainerRuntime{
-						Env: []config.ContainerEnv{
-							{
-								Var: "FOO",
-							},
-						},
-					},
 				},
 			},
 		},
-		want: `value not specified for env var FOO in container {Group: g1 Container:c1} config`,
+		want: `unresolved config env placeholder \$\$MY_TAG\$\$ in field image\.image in container \{Group: g1 Container:c1\} config`,
 	},
 }
 
@@ -0,0 +1,134 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	dnetwork "github.com/docker/docker/api/types/network"
+)
+
+// ExportFormat is the format used while exporting the deployment.
+type ExportFormat string
+
+const (
+	// ExportFormatCompose exports the deployment as a docker compose file.
+	ExportFormatCompose ExportFormat = "compose"
+	// ExportFormatDockerRun exports the deployment as a shell script
+	// invoking the docker CLI.
+	ExportFormatDockerRun ExportFormat = "docker-run"
+	// ExportFormatJSON exports the deployment as the docker API payloads
+	// used for creating the networks and the containers.
+	ExportFormatJSON ExportFormat = "json"
+)
+
+// ExportFormats lists all the supported export formats.
+var ExportFormats = []ExportFormat{
+	ExportFormatCompose,
+	ExportFormatDockerRun,
+	ExportFormatJSON,
+}
+
+type exportedDeployment struct {
+	Host       string               `json:"host"`
+	Networks   []*exportedNetwork   `json:"networks"`
+	Containers []*exportedContainer `json:"containers"`
+}
+
+type exportedNetwork struct {
+	Name          string                 `json:"name"`
+	CreateOptions dnetwork.CreateOptions `json:"createOptions"`
+}
+
+type exportedContainer struct {
+	Name              string                     `json:"name"`
+	ContainerConfig   *dcontainer.Config         `json:"containerConfig"`
+	HostConfig        *dcontainer.HostConfig     `json:"hostConfig"`
+	NetworkConfig     *dnetwork.NetworkingConfig `json:"networkConfig,omitempty"`
+	SecondaryNetworks []*exportedEndpoint        `json:"secondaryNetworks,omitempty"`
+}
+
+type exportedEndpoint struct {
+	Network string `json:"network"`
+	IP      string `json:"ip"`
+}
+
+// ParseExportFormat returns the export format matching the specified
+// string.
+func ParseExportFormat(format string) (ExportFormat, error) {
+	for _, f := range ExportFormats {
+		if string(f) == format {
+			return f, nil
+		}
+	}
+	formats := make([]string, 0, len(ExportFormats))
+	for _, f := range ExportFormats {
+		formats = append(formats, string(f))
+	}
+	return "", fmt.Errorf("unsupported export format %s, must be one of: %s", format, strings.Join(formats, ", "))
+}
+
+// Export renders the docker configs of all the containers allowed to run
+// on the host the deployment was built for, along with the networks they
+// are connected to, in the specified format. The exported deployment can
+// be used to recreate the containers without homelab.
+func (d *Deployment) Export(ctx context.Context, format ExportFormat) (string, error) {
+	exp := d.exportedDeployment()
+	switch format {
+	case ExportFormatCompose:
+		return exportCompose(exp)
+	case ExportFormatDockerRun:
+		return exportDockerRun(exp), nil
+	case ExportFormatJSON:
+		res, err := json.MarshalIndent(exp, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to export the deployment as json, reason: %w", err)
+		}
+		return string(res) + "\n", nil
+	}
+	return "", fmt.Errorf("unsupported export format %s", format)
+}
+
+func (d *Deployment) exportedDeployment() *exportedDeployment {
+	res := &exportedDeployment{
+		Host:       d.hostName,
+		Networks:   []*exportedNetwork{},
+		Containers: []*exportedContainer{},
+	}
+
+	networks := map[string]*Network{}
+	for _, ct := range d.AllowedContainers() {
+		dc := d.dockerConfigs[ct.config.Info]
+		ec := &exportedContainer{
+			Name:            ct.Name(),
+			ContainerConfig: dc.ContainerConfig,
+			HostConfig:      dc.HostConfig,
+			NetworkConfig:   dc.NetworkConfig,
+		}
+		for i, ep := range ct.endpoints {
+			if ep.network.Mode() != NetworkModeBridge {
+				continue
+			}
+			networks[ep.network.Name()] = ep.network
+			if i > 0 {
+				ec.SecondaryNetworks = append(ec.SecondaryNetworks, &exportedEndpoint{
+					Network: ep.network.Name(),
+					IP:      ep.ip,
+				})
+			}
+		}
+		res.Containers = append(res.Containers, ec)
+	}
+
+	for _, name := range d.NetworksOrder {
+		if n, found := networks[name]; found {
+			res.Networks = append(res.Networks, &exportedNetwork{
+				Name:          name,
+				CreateOptions: n.createOptions(),
+			})
+		}
+	}
+	return res
+}
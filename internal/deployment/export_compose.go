@@ -0,0 +1,357 @@
+package deployment
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	composeProjectPrefix = "homelab"
+)
+
+var (
+	composeProjectNameInvalidRegex = regexp.MustCompile(`[^a-z0-9_-]+`)
+)
+
+type composeFile struct {
+	Name     string                     `yaml:"name"`
+	Services map[string]*composeService `yaml:"services"`
+	Networks map[string]*composeNetwork `yaml:"networks,omitempty"`
+}
+
+type composeService struct {
+	ContainerName   string                            `yaml:"container_name"`
+	Image           string                            `yaml:"image"`
+	Hostname        string                            `yaml:"hostname,omitempty"`
+	DomainName      string                            `yaml:"domainname,omitempty"`
+	User            string                            `yaml:"user,omitempty"`
+	Tty             bool                              `yaml:"tty,omitempty"`
+	Environment     []string                          `yaml:"environment,omitempty"`
+	Entrypoint      []string                          `yaml:"entrypoint,omitempty"`
+	Command         []string                          `yaml:"command,omitempty"`
+	Healthcheck     *composeHealthcheck               `yaml:"healthcheck,omitempty"`
+	Labels          map[string]string                 `yaml:"labels,omitempty"`
+	StopSignal      string                            `yaml:"stop_signal,omitempty"`
+	StopGracePeriod string                            `yaml:"stop_grace_period,omitempty"`
+	NetworkMode     string                            `yaml:"network_mode,omitempty"`
+	Networks        map[string]*composeServiceNetwork `yaml:"networks,omitempty"`
+	Ports           []*composePort                    `yaml:"ports,omitempty"`
+	Volumes         []*composeVolume                  `yaml:"volumes,omitempty"`
+	Tmpfs           []string                          `yaml:"tmpfs,omitempty"`
+	ReadOnly        bool                              `yaml:"read_only,omitempty"`
+	Devices         []string                          `yaml:"devices,omitempty"`
+	Restart         string                            `yaml:"restart,omitempty"`
+	CapAdd          []string                          `yaml:"cap_add,omitempty"`
+	CapDrop         []string                          `yaml:"cap_drop,omitempty"`
+	Privileged      bool                              `yaml:"privileged,omitempty"`
+	Sysctls         map[string]string                 `yaml:"sysctls,omitempty"`
+	DNS             []string                          `yaml:"dns,omitempty"`
+	DNSOpt          []string                          `yaml:"dns_opt,omitempty"`
+	DNSSearch       []string                          `yaml:"dns_search,omitempty"`
+	ExtraHosts      []string                          `yaml:"extra_hosts,omitempty"`
+	GroupAdd        []string                          `yaml:"group_add,omitempty"`
+	ShmSize         string                            `yaml:"shm_size,omitempty"`
+}
+
+type composeHealthcheck struct {
+	Test          []string `yaml:"test,omitempty"`
+	Disable       bool     `yaml:"disable,omitempty"`
+	Interval      string   `yaml:"interval,omitempty"`
+	Timeout       string   `yaml:"timeout,omitempty"`
+	StartPeriod   string   `yaml:"start_period,omitempty"`
+	StartInterval string   `yaml:"start_interval,omitempty"`
+	Retries       int      `yaml:"retries,omitempty"`
+}
+
+type composeServiceNetwork struct {
+	IPv4Address string `yaml:"ipv4_address,omitempty"`
+	Priority    int    `yaml:"priority,omitempty"`
+}
+
+type composePort struct {
+	Target    int    `yaml:"target"`
+	Published string `yaml:"published"`
+	HostIP    string `yaml:"host_ip,omitempty"`
+	Protocol  string `yaml:"protocol"`
+}
+
+type composeVolume struct {
+	Type     string              `yaml:"type"`
+	Source   string              `yaml:"source,omitempty"`
+	Target   string              `yaml:"target"`
+	ReadOnly bool                `yaml:"read_only,omitempty"`
+	Tmpfs    *composeVolumeTmpfs `yaml:"tmpfs,omitempty"`
+}
+
+type composeVolumeTmpfs struct {
+	Size int64 `yaml:"size"`
+}
+
+type composeNetwork struct {
+	Name       string            `yaml:"name"`
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `yaml:"driver_opts,omitempty"`
+	EnableIPv6 bool              `yaml:"enable_ipv6"`
+	Internal   bool              `yaml:"internal,omitempty"`
+	Attachable bool              `yaml:"attachable,omitempty"`
+	IPAM       *composeIPAM      `yaml:"ipam,omitempty"`
+}
+
+type composeIPAM struct {
+	Driver string               `yaml:"driver,omitempty"`
+	Config []*composeIPAMConfig `yaml:"config,omitempty"`
+}
+
+type composeIPAMConfig struct {
+	Subnet  string `yaml:"subnet,omitempty"`
+	Gateway string `yaml:"gateway,omitempty"`
+}
+
+// exportCompose renders the exported deployment as a docker compose
+// file. The docker compose interpolation is avoided by escaping all the
+// '$' characters, and the settings that cannot be expressed in docker
+// compose are listed as comments at the top.
+func exportCompose(exp *exportedDeployment) (string, error) {
+	cf := composeFile{
+		Name:     composeProjectName(exp.Host),
+		Services: map[string]*composeService{},
+		Networks: map[string]*composeNetwork{},
+	}
+	for _, n := range exp.Networks {
+		cf.Networks[n.Name] = composeNetworkFromOptions(n)
+	}
+
+	services := map[string]struct{}{}
+	for _, ct := range exp.Containers {
+		services[ct.Name] = struct{}{}
+	}
+	var unsupported []string
+	for _, ct := range exp.Containers {
+		svc, u := composeServiceFromContainer(ct, services)
+		cf.Services[ct.Name] = svc
+		unsupported = append(unsupported, u...)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Homelab deployment for host %s.\n", exp.Host))
+	if len(unsupported) > 0 {
+		sb.WriteString("# Settings that cannot be expressed in docker compose:\n")
+		for _, u := range unsupported {
+			sb.WriteString(fmt.Sprintf("#   - %s\n", u))
+		}
+	}
+	sb.WriteString(utils.PrettyPrintYAML(cf))
+	return sb.String(), nil
+}
+
+func composeProjectName(hostName string) string {
+	name := composeProjectNameInvalidRegex.ReplaceAllString(strings.ToLower(hostName), "-")
+	name = strings.Trim(name, "-")
+	if len(name) == 0 {
+		return composeProjectPrefix
+	}
+	return fmt.Sprintf("%s-%s", composeProjectPrefix, name)
+}
+
+func composeNetworkFromOptions(n *exportedNetwork) *composeNetwork {
+	opts := &n.CreateOptions
+	res := &composeNetwork{
+		Name:       n.Name,
+		Driver:     opts.Driver,
+		DriverOpts: opts.Options,
+		Internal:   opts.Internal,
+		Attachable: opts.Attachable,
+	}
+	if opts.EnableIPv6 != nil {
+		res.EnableIPv6 = *opts.EnableIPv6
+	}
+	if opts.IPAM != nil {
+		res.IPAM = &composeIPAM{Driver: opts.IPAM.Driver}
+		for _, c := range opts.IPAM.Config {
+			res.IPAM.Config = append(res.IPAM.Config, &composeIPAMConfig{
+				Subnet:  c.Subnet,
+				Gateway: c.Gateway,
+			})
+		}
+	}
+	return res
+}
+
+func composeServiceFromContainer(ct *exportedContainer, services map[string]struct{}) (*composeService, []string) {
+	cc := ct.ContainerConfig
+	hc := ct.HostConfig
+	var unsupported []string
+
+	svc := &composeService{
+		ContainerName: ct.Name,
+		Image:         composeEscape(cc.Image),
+		Hostname:      cc.Hostname,
+		DomainName:    cc.Domainname,
+		User:          cc.User,
+		Tty:           cc.Tty,
+		Environment:   composeEscapeList(cc.Env),
+		Entrypoint:    composeEscapeList(cc.Entrypoint),
+		Command:       composeEscapeList(cc.Cmd),
+		Healthcheck:   composeHealthcheckFromConfig(cc.Healthcheck),
+		StopSignal:    cc.StopSignal,
+		ReadOnly:      hc.ReadonlyRootfs,
+		CapAdd:        hc.CapAdd,
+		CapDrop:       hc.CapDrop,
+		Privileged:    hc.Privileged,
+		Sysctls:       hc.Sysctls,
+		DNS:           hc.DNS,
+		DNSOpt:        hc.DNSOptions,
+		DNSSearch:     hc.DNSSearch,
+		ExtraHosts:    hc.ExtraHosts,
+		GroupAdd:      hc.GroupAdd,
+	}
+	if len(cc.Labels) > 0 {
+		svc.Labels = map[string]string{}
+		for k, v := range cc.Labels {
+			svc.Labels[k] = composeEscape(v)
+		}
+	}
+	if cc.StopTimeout != nil {
+		svc.StopGracePeriod = fmt.Sprintf("%ds", *cc.StopTimeout)
+	}
+
+	// The container mode networks refer to the service when the target
+	// container is part of the export, allowing docker compose to start
+	// the target container first.
+	mode := string(hc.NetworkMode)
+	if target, found := strings.CutPrefix(mode, "container:"); found {
+		if _, exported := services[target]; exported {
+			svc.NetworkMode = fmt.Sprintf("service:%s", target)
+		} else {
+			svc.NetworkMode = mode
+		}
+	} else if mode == "none" {
+		svc.NetworkMode = mode
+	} else if len(mode) > 0 {
+		// docker compose connects the networks in the descending order
+		// of their priority, hence the primary network is assigned the
+		// highest priority.
+		svc.Networks = map[string]*composeServiceNetwork{
+			mode: {
+				Priority: len(ct.SecondaryNetworks) + 1,
+			},
+		}
+		if ct.NetworkConfig != nil {
+			svc.Networks[mode].IPv4Address = endpointIPv4(ct.NetworkConfig.EndpointsConfig[mode])
+		}
+		for i, ep := range ct.SecondaryNetworks {
+			svc.Networks[ep.Network] = &composeServiceNetwork{
+				IPv4Address: ep.IP,
+				Priority:    len(ct.SecondaryNetworks) - i,
+			}
+		}
+	}
+
+	for _, p := range sortedPorts(hc.PortBindings) {
+		target, err := strconv.Atoi(p.Port())
+		if err != nil {
+			unsupported = append(unsupported, fmt.Sprintf("%s: published container port %s", ct.Name, p))
+			continue
+		}
+		for _, b := range hc.PortBindings[p] {
+			svc.Ports = append(svc.Ports, &composePort{
+				Target:    target,
+				Published: b.HostPort,
+				HostIP:    b.HostIP,
+				Protocol:  p.Proto(),
+			})
+		}
+	}
+
+	for _, b := range hc.Binds {
+		parts := strings.Split(b, ":")
+		v := &composeVolume{
+			Type:   "bind",
+			Source: composeEscape(parts[0]),
+		}
+		if len(parts) > 1 {
+			v.Target = composeEscape(parts[1])
+		}
+		if len(parts) > 2 && parts[2] == "ro" {
+			v.ReadOnly = true
+		}
+		svc.Volumes = append(svc.Volumes, v)
+	}
+	for _, m := range hc.Mounts {
+		v := &composeVolume{
+			Type:     string(m.Type),
+			Source:   composeEscape(m.Source),
+			Target:   composeEscape(m.Target),
+			ReadOnly: m.ReadOnly,
+		}
+		if m.TmpfsOptions != nil && m.TmpfsOptions.SizeBytes > 0 {
+			v.Tmpfs = &composeVolumeTmpfs{Size: m.TmpfsOptions.SizeBytes}
+		}
+		svc.Volumes = append(svc.Volumes, v)
+	}
+	for _, dst := range sortedKeys(hc.Tmpfs) {
+		if opts := hc.Tmpfs[dst]; len(opts) > 0 {
+			svc.Tmpfs = append(svc.Tmpfs, fmt.Sprintf("%s:%s", dst, opts))
+		} else {
+			svc.Tmpfs = append(svc.Tmpfs, dst)
+		}
+	}
+	for _, d := range hc.Devices {
+		svc.Devices = append(svc.Devices, fmt.Sprintf("%s:%s:%s", d.PathOnHost, d.PathInContainer, d.CgroupPermissions))
+	}
+
+	if rp := hc.RestartPolicy; len(rp.Name) > 0 {
+		svc.Restart = string(rp.Name)
+		if rp.MaximumRetryCount > 0 {
+			svc.Restart = fmt.Sprintf("%s:%d", rp.Name, rp.MaximumRetryCount)
+		}
+	}
+	if hc.AutoRemove {
+		unsupported = append(unsupported, fmt.Sprintf("%s: auto remove", ct.Name))
+	}
+	if hc.ShmSize > 0 {
+		svc.ShmSize = strconv.FormatInt(hc.ShmSize, 10)
+	}
+	return svc, unsupported
+}
+
+func composeHealthcheckFromConfig(h *dcontainer.HealthConfig) *composeHealthcheck {
+	if h == nil {
+		return nil
+	}
+	res := &composeHealthcheck{
+		Retries:       h.Retries,
+		Interval:      durationString(h.Interval),
+		Timeout:       durationString(h.Timeout),
+		StartPeriod:   durationString(h.StartPeriod),
+		StartInterval: durationString(h.StartInterval),
+	}
+	if len(h.Test) > 0 && h.Test[0] == "NONE" {
+		res.Disable = true
+	} else {
+		res.Test = composeEscapeList(h.Test)
+	}
+	return res
+}
+
+// composeEscape escapes the '$' characters to prevent docker compose
+// from interpolating the value.
+func composeEscape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+func composeEscapeList(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(list))
+	for _, s := range list {
+		res = append(res, composeEscape(s))
+	}
+	return res
+}
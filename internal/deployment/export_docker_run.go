@@ -0,0 +1,338 @@
+package deployment
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	dmount "github.com/docker/docker/api/types/mount"
+	dnetwork "github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+var (
+	shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+)
+
+// shellCmd represents a shell command along with its flags and
+// positional args.
+type shellCmd struct {
+	cmd   []string
+	flags [][]string
+	args  []string
+}
+
+// exportDockerRun renders the exported deployment as a shell script
+// which creates the networks and the containers using the docker CLI,
+// following the same sequence of steps used while starting the
+// containers.
+func exportDockerRun(exp *exportedDeployment) string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString(fmt.Sprintf("# Homelab deployment for host %s.\n", exp.Host))
+	sb.WriteString("set -eu\n")
+
+	for _, n := range exp.Networks {
+		sb.WriteString(fmt.Sprintf("\n# Network %s.\n", n.Name))
+		sb.WriteString(fmt.Sprintf("docker network inspect %s >/dev/null 2>&1 || ", shellQuote(n.Name)))
+		writeShellCmd(&sb, &shellCmd{
+			cmd:   []string{"docker", "network", "create"},
+			flags: networkCreateFlags(n),
+			args:  []string{n.Name},
+		})
+	}
+
+	for _, ct := range exp.Containers {
+		sb.WriteString(fmt.Sprintf("\n# Container %s.\n", ct.Name))
+		sb.WriteString(fmt.Sprintf("docker container rm --force %s >/dev/null 2>&1 || true\n", shellQuote(ct.Name)))
+		writeShellCmd(&sb, containerCreateCmd(ct))
+		for _, ep := range ct.SecondaryNetworks {
+			writeShellCmd(&sb, &shellCmd{
+				cmd:  []string{"docker", "network", "connect", "--ip", ep.IP},
+				args: []string{ep.Network, ct.Name},
+			})
+		}
+		writeShellCmd(&sb, &shellCmd{
+			cmd:  []string{"docker", "container", "start"},
+			args: []string{ct.Name},
+		})
+	}
+	return sb.String()
+}
+
+func networkCreateFlags(n *exportedNetwork) [][]string {
+	opts := &n.CreateOptions
+	var res [][]string
+	if len(opts.Driver) > 0 {
+		res = append(res, []string{"--driver", opts.Driver})
+	}
+	if opts.IPAM != nil {
+		for _, c := range opts.IPAM.Config {
+			if len(c.Subnet) > 0 {
+				res = append(res, []string{"--subnet", c.Subnet})
+			}
+			if len(c.Gateway) > 0 {
+				res = append(res, []string{"--gateway", c.Gateway})
+			}
+		}
+	}
+	if opts.EnableIPv6 != nil && *opts.EnableIPv6 {
+		res = append(res, []string{"--ipv6"})
+	}
+	if opts.Internal {
+		res = append(res, []string{"--internal"})
+	}
+	if opts.Attachable {
+		res = append(res, []string{"--attachable"})
+	}
+	for _, k := range sortedKeys(opts.Options) {
+		res = append(res, []string{"--opt", fmt.Sprintf("%s=%s", k, opts.Options[k])})
+	}
+	return res
+}
+
+func containerCreateCmd(ct *exportedContainer) *shellCmd {
+	cc := ct.ContainerConfig
+	hc := ct.HostConfig
+	res := [][]string{{"--name", ct.Name}}
+
+	addStr := func(flag, val string) {
+		if len(val) > 0 {
+			res = append(res, []string{flag, val})
+		}
+	}
+	addList := func(flag string, vals []string) {
+		for _, v := range vals {
+			res = append(res, []string{flag, v})
+		}
+	}
+	addBool := func(flag string, val bool) {
+		if val {
+			res = append(res, []string{flag})
+		}
+	}
+
+	addStr("--hostname", cc.Hostname)
+	addStr("--domainname", cc.Domainname)
+	addStr("--user", cc.User)
+	addBool("--tty", cc.Tty)
+	addList("--env", cc.Env)
+	// The docker CLI only accepts a single entrypoint element, hence the
+	// rest are passed as the leading args.
+	args := cc.Cmd
+	if len(cc.Entrypoint) > 0 {
+		addStr("--entrypoint", cc.Entrypoint[0])
+		args = append(append([]string{}, cc.Entrypoint[1:]...), cc.Cmd...)
+	}
+	res = append(res, healthCheckFlags(cc.Healthcheck)...)
+	for _, k := range sortedKeys(cc.Labels) {
+		res = append(res, []string{"--label", fmt.Sprintf("%s=%s", k, cc.Labels[k])})
+	}
+	addStr("--stop-signal", cc.StopSignal)
+	if cc.StopTimeout != nil {
+		res = append(res, []string{"--stop-timeout", strconv.Itoa(*cc.StopTimeout)})
+	}
+
+	addStr("--network", string(hc.NetworkMode))
+	if ct.NetworkConfig != nil {
+		for _, ep := range ct.NetworkConfig.EndpointsConfig {
+			addStr("--ip", endpointIPv4(ep))
+		}
+	}
+	for _, p := range sortedPorts(hc.PortBindings) {
+		for _, b := range hc.PortBindings[p] {
+			res = append(res, []string{"--publish", publishSpec(p, b)})
+		}
+	}
+	for _, p := range sortedPortSet(cc.ExposedPorts) {
+		if _, found := hc.PortBindings[p]; !found {
+			res = append(res, []string{"--expose", string(p)})
+		}
+	}
+
+	addList("--volume", hc.Binds)
+	for _, m := range hc.Mounts {
+		res = append(res, []string{"--mount", mountSpecArg(&m)})
+	}
+	for _, dst := range sortedKeys(hc.Tmpfs) {
+		if opts := hc.Tmpfs[dst]; len(opts) > 0 {
+			res = append(res, []string{"--tmpfs", fmt.Sprintf("%s:%s", dst, opts)})
+		} else {
+			res = append(res, []string{"--tmpfs", dst})
+		}
+	}
+	addBool("--read-only", hc.ReadonlyRootfs)
+	for _, d := range hc.Devices {
+		res = append(res, []string{"--device", fmt.Sprintf("%s:%s:%s", d.PathOnHost, d.PathInContainer, d.CgroupPermissions)})
+	}
+
+	if rp := hc.RestartPolicy; len(rp.Name) > 0 {
+		if rp.MaximumRetryCount > 0 {
+			res = append(res, []string{"--restart", fmt.Sprintf("%s:%d", rp.Name, rp.MaximumRetryCount)})
+		} else {
+			res = append(res, []string{"--restart", string(rp.Name)})
+		}
+	}
+	addBool("--rm", hc.AutoRemove)
+	addList("--cap-add", hc.CapAdd)
+	addList("--cap-drop", hc.CapDrop)
+	addBool("--privileged", hc.Privileged)
+	for _, k := range sortedKeys(hc.Sysctls) {
+		res = append(res, []string{"--sysctl", fmt.Sprintf("%s=%s", k, hc.Sysctls[k])})
+	}
+	addList("--dns", hc.DNS)
+	addList("--dns-option", hc.DNSOptions)
+	addList("--dns-search", hc.DNSSearch)
+	addList("--add-host", hc.ExtraHosts)
+	addList("--group-add", hc.GroupAdd)
+	if hc.ShmSize > 0 {
+		res = append(res, []string{"--shm-size", strconv.FormatInt(hc.ShmSize, 10)})
+	}
+
+	return &shellCmd{
+		cmd:   []string{"docker", "container", "create"},
+		flags: res,
+		args:  append([]string{cc.Image}, args...),
+	}
+}
+
+// healthCheckFlags returns the docker CLI health check flags. The docker
+// CLI runs the health check command using the shell, hence the exec form
+// of the command is converted into an equivalent shell command.
+func healthCheckFlags(h *dcontainer.HealthConfig) [][]string {
+	if h == nil {
+		return nil
+	}
+	var res [][]string
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "NONE":
+			res = append(res, []string{"--no-healthcheck"})
+		case "CMD-SHELL":
+			res = append(res, []string{"--health-cmd", strings.Join(h.Test[1:], " ")})
+		case "CMD":
+			res = append(res, []string{"--health-cmd", shellJoin(h.Test[1:])})
+		}
+	}
+	if h.Interval != 0 {
+		res = append(res, []string{"--health-interval", h.Interval.String()})
+	}
+	if h.Timeout != 0 {
+		res = append(res, []string{"--health-timeout", h.Timeout.String()})
+	}
+	if h.StartPeriod != 0 {
+		res = append(res, []string{"--health-start-period", h.StartPeriod.String()})
+	}
+	if h.StartInterval != 0 {
+		res = append(res, []string{"--health-start-interval", h.StartInterval.String()})
+	}
+	if h.Retries != 0 {
+		res = append(res, []string{"--health-retries", strconv.Itoa(h.Retries)})
+	}
+	return res
+}
+
+func publishSpec(p nat.Port, b nat.PortBinding) string {
+	spec := fmt.Sprintf("%s:%s", b.HostPort, p)
+	if len(b.HostIP) == 0 {
+		return spec
+	}
+	if strings.Contains(b.HostIP, ":") {
+		return fmt.Sprintf("[%s]:%s", b.HostIP, spec)
+	}
+	return fmt.Sprintf("%s:%s", b.HostIP, spec)
+}
+
+func mountSpecArg(m *dmount.Mount) string {
+	parts := []string{fmt.Sprintf("type=%s", m.Type)}
+	if len(m.Source) > 0 {
+		parts = append(parts, fmt.Sprintf("source=%s", m.Source))
+	}
+	parts = append(parts, fmt.Sprintf("destination=%s", m.Target))
+	if m.ReadOnly {
+		parts = append(parts, "readonly")
+	}
+	if m.TmpfsOptions != nil && m.TmpfsOptions.SizeBytes > 0 {
+		parts = append(parts, fmt.Sprintf("tmpfs-size=%d", m.TmpfsOptions.SizeBytes))
+	}
+	return strings.Join(parts, ",")
+}
+
+// writeShellCmd writes the shell command with each of the flags and the
+// positional args on separate lines.
+func writeShellCmd(sb *strings.Builder, c *shellCmd) {
+	sb.WriteString(shellJoin(c.cmd))
+	if len(c.flags) == 0 {
+		sb.WriteString(" ")
+		sb.WriteString(shellJoin(c.args))
+		sb.WriteString("\n")
+		return
+	}
+	for _, f := range c.flags {
+		sb.WriteString(" \\\n  ")
+		sb.WriteString(shellJoin(f))
+	}
+	if len(c.args) > 0 {
+		sb.WriteString(" \\\n  ")
+		sb.WriteString(shellJoin(c.args))
+	}
+	sb.WriteString("\n")
+}
+
+func shellJoin(args []string) string {
+	res := make([]string, 0, len(args))
+	for _, a := range args {
+		res = append(res, shellQuote(a))
+	}
+	return strings.Join(res, " ")
+}
+
+func shellQuote(s string) string {
+	if shellSafeRegex.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func sortedKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedPorts(m nat.PortMap) []nat.Port {
+	res := make([]nat.Port, 0, len(m))
+	for p := range m {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	return res
+}
+
+func sortedPortSet(s nat.PortSet) []nat.Port {
+	res := make([]nat.Port, 0, len(s))
+	for p := range s {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	return res
+}
+
+// endpointIPv4 returns the IPv4 address configured for the endpoint, if
+// any.
+func endpointIPv4(e *dnetwork.EndpointSettings) string {
+	if e == nil || e.IPAMConfig == nil {
+		return ""
+	}
+	return e.IPAMConfig.IPv4Address
+}
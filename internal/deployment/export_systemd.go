@@ -0,0 +1,521 @@
+package deployment
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+)
+
+// SystemdUnitStyle is the style of the exported systemd units.
+type SystemdUnitStyle string
+
+// SystemdUnitScope determines whether the exported systemd units manage
+// individual containers or entire groups.
+type SystemdUnitScope string
+
+const (
+	// SystemdUnitStyleService exports service units invoking the homelab
+	// CLI for starting and stopping the containers.
+	SystemdUnitStyleService SystemdUnitStyle = "service"
+	// SystemdUnitStyleQuadlet exports podman quadlet container and
+	// network units rendered from the docker configs.
+	SystemdUnitStyleQuadlet SystemdUnitStyle = "quadlet"
+
+	// SystemdUnitScopeContainer exports one unit per container.
+	SystemdUnitScopeContainer SystemdUnitScope = "container"
+	// SystemdUnitScopeGroup exports one unit per group.
+	SystemdUnitScopeGroup SystemdUnitScope = "group"
+
+	systemdUnitPrefix = "homelab"
+)
+
+// SystemdExportOptions represents the options for exporting the systemd
+// units.
+type SystemdExportOptions struct {
+	Style SystemdUnitStyle
+	Scope SystemdUnitScope
+	// HomelabCmd is the homelab CLI command line (i.e. the path to the
+	// binary followed by any global flags) invoked by the service units.
+	HomelabCmd []string
+}
+
+// SystemdUnit represents an exported systemd unit file.
+type SystemdUnit struct {
+	FileName string
+	Content  string
+}
+
+type systemdUnitFile struct {
+	comments []string
+	sections []*systemdSection
+}
+
+type systemdSection struct {
+	name    string
+	entries []string
+}
+
+// ParseSystemdUnitStyle returns the systemd unit style matching the
+// specified string.
+func ParseSystemdUnitStyle(style string) (SystemdUnitStyle, error) {
+	switch s := SystemdUnitStyle(style); s {
+	case SystemdUnitStyleService, SystemdUnitStyleQuadlet:
+		return s, nil
+	}
+	return "", fmt.Errorf("unsupported systemd unit style %s, must be one of: %s, %s", style, SystemdUnitStyleService, SystemdUnitStyleQuadlet)
+}
+
+// ParseSystemdUnitScope returns the systemd unit scope matching the
+// specified string.
+func ParseSystemdUnitScope(scope string) (SystemdUnitScope, error) {
+	switch s := SystemdUnitScope(scope); s {
+	case SystemdUnitScopeContainer, SystemdUnitScopeGroup:
+		return s, nil
+	}
+	return "", fmt.Errorf("unsupported systemd unit scope %s, must be one of: %s, %s", scope, SystemdUnitScopeContainer, SystemdUnitScopeGroup)
+}
+
+// ExportSystemdUnits exports the systemd units for the containers allowed
+// to run on the host the deployment was built for. The units are ordered
+// using the group and the container order, and the containers using the
+// network stack of another container require the unit of that container.
+func (d *Deployment) ExportSystemdUnits(opts *SystemdExportOptions) ([]*SystemdUnit, error) {
+	containers := d.allowedContainersList()
+	switch {
+	case opts.Style == SystemdUnitStyleQuadlet && opts.Scope == SystemdUnitScopeGroup:
+		return nil, fmt.Errorf("quadlet systemd units can only be exported per container")
+	case opts.Style == SystemdUnitStyleQuadlet:
+		return d.quadletUnits(containers), nil
+	case len(opts.HomelabCmd) == 0:
+		return nil, fmt.Errorf("homelab command cannot be empty while exporting the systemd service units")
+	case opts.Scope == SystemdUnitScopeGroup:
+		return d.groupServiceUnits(containers, opts.HomelabCmd), nil
+	default:
+		return d.containerServiceUnits(containers, opts.HomelabCmd), nil
+	}
+}
+
+func (d *Deployment) allowedContainersList() ContainerList {
+	allowed := containerMap{}
+	for ref, ct := range d.queryAllContainers() {
+		if ct.isAllowedOnCurrentHost() {
+			allowed[ref] = ct
+		}
+	}
+	return containerMapToList(allowed)
+}
+
+func (d *Deployment) containerServiceUnits(containers ContainerList, homelabCmd []string) []*SystemdUnit {
+	var res []*SystemdUnit
+	prev := ""
+	for _, ct := range containers {
+		name := containerServiceName(&ct.config.Info)
+		u := d.newSystemdUnitFile(fmt.Sprintf("Homelab container %s", ct.Name()))
+		unit := u.section("Unit")
+		unit.add("Requires", "docker.service")
+		unit.add("After", "docker.service network-online.target")
+		unit.add("Wants", "network-online.target")
+		if len(prev) > 0 {
+			unit.add("After", prev)
+		}
+		for _, dep := range d.containerDependencies(ct, containers) {
+			unit.add("Requires", containerServiceName(dep))
+			unit.add("After", containerServiceName(dep))
+		}
+		d.addRequiresMountsFor(unit)
+
+		ref := fmt.Sprintf("%s/%s", ct.config.Info.Group, ct.config.Info.Container)
+		svc := u.section("Service")
+		svc.add("Type", "oneshot")
+		svc.add("RemainAfterExit", "yes")
+		svc.add("ExecStart", systemdJoin(append(append([]string{}, homelabCmd...), "containers", "start", ref)))
+		svc.add("ExecStop", systemdJoin(append(append([]string{}, homelabCmd...), "containers", "stop", ref)))
+		u.section("Install").add("WantedBy", "multi-user.target")
+
+		res = append(res, &SystemdUnit{FileName: name, Content: u.String()})
+		prev = name
+	}
+	return res
+}
+
+func (d *Deployment) groupServiceUnits(containers ContainerList, homelabCmd []string) []*SystemdUnit {
+	var groups []string
+	deps := map[string][]string{}
+	for _, ct := range containers {
+		g := ct.config.Info.Group
+		if len(groups) == 0 || groups[len(groups)-1] != g {
+			groups = append(groups, g)
+		}
+		for _, dep := range d.containerDependencies(ct, containers) {
+			if dep.Group != g && !slices.Contains(deps[g], dep.Group) {
+				deps[g] = append(deps[g], dep.Group)
+			}
+		}
+	}
+
+	var res []*SystemdUnit
+	prev := ""
+	for _, g := range groups {
+		name := groupServiceName(g)
+		u := d.newSystemdUnitFile(fmt.Sprintf("Homelab group %s", g))
+		unit := u.section("Unit")
+		unit.add("Requires", "docker.service")
+		unit.add("After", "docker.service network-online.target")
+		unit.add("Wants", "network-online.target")
+		if len(prev) > 0 {
+			unit.add("After", prev)
+		}
+		for _, dep := range deps[g] {
+			unit.add("Requires", groupServiceName(dep))
+			unit.add("After", groupServiceName(dep))
+		}
+		d.addRequiresMountsFor(unit)
+
+		svc := u.section("Service")
+		svc.add("Type", "oneshot")
+		svc.add("RemainAfterExit", "yes")
+		svc.add("ExecStart", systemdJoin(append(append([]string{}, homelabCmd...), "groups", "start", g)))
+		svc.add("ExecStop", systemdJoin(append(append([]string{}, homelabCmd...), "groups", "stop", g)))
+		u.section("Install").add("WantedBy", "multi-user.target")
+
+		res = append(res, &SystemdUnit{FileName: name, Content: u.String()})
+		prev = name
+	}
+	return res
+}
+
+func (d *Deployment) quadletUnits(containers ContainerList) []*SystemdUnit {
+	exp := d.exportedDeployment()
+	var res []*SystemdUnit
+	for _, n := range exp.Networks {
+		res = append(res, d.quadletNetworkUnit(n))
+	}
+
+	exported := map[string]*exportedContainer{}
+	for _, ec := range exp.Containers {
+		exported[ec.Name] = ec
+	}
+	prev := ""
+	for _, ct := range containers {
+		u := d.quadletContainerUnit(ct, exported[ct.Name()], containers, prev)
+		res = append(res, &SystemdUnit{
+			FileName: fmt.Sprintf("%s-%s.container", systemdUnitPrefix, ct.Name()),
+			Content:  u.String(),
+		})
+		prev = containerServiceName(&ct.config.Info)
+	}
+	return res
+}
+
+func (d *Deployment) quadletNetworkUnit(n *exportedNetwork) *SystemdUnit {
+	opts := &n.CreateOptions
+	u := d.newSystemdUnitFile(fmt.Sprintf("Homelab network %s", n.Name))
+	u.section("Unit")
+	nw := u.section("Network")
+	nw.add("NetworkName", n.Name)
+	nw.add("Driver", opts.Driver)
+	if opts.IPAM != nil {
+		for _, c := range opts.IPAM.Config {
+			nw.add("Subnet", c.Subnet)
+			nw.add("Gateway", c.Gateway)
+		}
+	}
+	if opts.Internal {
+		nw.add("Internal", "true")
+	}
+	for _, k := range sortedKeys(opts.Options) {
+		nw.add("Options", systemdQuote(fmt.Sprintf("%s=%s", k, opts.Options[k])))
+	}
+	return &SystemdUnit{
+		FileName: fmt.Sprintf("%s-%s.network", systemdUnitPrefix, n.Name),
+		Content:  u.String(),
+	}
+}
+
+func (d *Deployment) quadletContainerUnit(ct *Container, ec *exportedContainer, containers ContainerList, prev string) *systemdUnitFile {
+	cc := ec.ContainerConfig
+	hc := ec.HostConfig
+	u := d.newSystemdUnitFile(fmt.Sprintf("Homelab container %s", ct.Name()))
+	unit := u.section("Unit")
+	if len(prev) > 0 {
+		unit.add("After", prev)
+	}
+	for _, dep := range d.containerDependencies(ct, containers) {
+		unit.add("Requires", containerServiceName(dep))
+		unit.add("After", containerServiceName(dep))
+	}
+	d.addRequiresMountsFor(unit)
+
+	c := u.section("Container")
+	var podmanArgs []string
+	c.add("ContainerName", ec.Name)
+	c.add("Image", systemdEscape(cc.Image))
+	c.add("HostName", cc.Hostname)
+	if len(cc.Domainname) > 0 {
+		podmanArgs = append(podmanArgs, "--domainname", cc.Domainname)
+	}
+	if len(cc.User) > 0 {
+		user, group, _ := strings.Cut(cc.User, ":")
+		c.add("User", user)
+		c.add("Group", group)
+	}
+	if cc.Tty {
+		podmanArgs = append(podmanArgs, "--tty")
+	}
+	for _, e := range cc.Env {
+		c.add("Environment", systemdQuote(e))
+	}
+	args := cc.Cmd
+	if len(cc.Entrypoint) > 0 {
+		c.add("Entrypoint", systemdEscape(cc.Entrypoint[0]))
+		args = append(append([]string{}, cc.Entrypoint[1:]...), cc.Cmd...)
+	}
+	if len(args) > 0 {
+		c.add("Exec", systemdJoin(args))
+	}
+	d.addQuadletHealthCheck(u, c, cc.Healthcheck)
+	for _, k := range sortedKeys(cc.Labels) {
+		c.add("Label", systemdQuote(fmt.Sprintf("%s=%s", k, cc.Labels[k])))
+	}
+	if len(cc.StopSignal) > 0 {
+		podmanArgs = append(podmanArgs, "--stop-signal", cc.StopSignal)
+	}
+	if cc.StopTimeout != nil {
+		c.add("StopTimeout", strconv.Itoa(*cc.StopTimeout))
+	}
+
+	mode := string(hc.NetworkMode)
+	if strings.HasPrefix(mode, "container:") || mode == "none" {
+		c.add("Network", mode)
+	} else if len(mode) > 0 {
+		primary := fmt.Sprintf("%s-%s.network", systemdUnitPrefix, mode)
+		if ec.NetworkConfig != nil {
+			if ip := endpointIPv4(ec.NetworkConfig.EndpointsConfig[mode]); len(ip) > 0 {
+				primary = fmt.Sprintf("%s:ip=%s", primary, ip)
+			}
+		}
+		c.add("Network", primary)
+		for _, ep := range ec.SecondaryNetworks {
+			c.add("Network", fmt.Sprintf("%s-%s.network:ip=%s", systemdUnitPrefix, ep.Network, ep.IP))
+		}
+	}
+	for _, p := range sortedPorts(hc.PortBindings) {
+		for _, b := range hc.PortBindings[p] {
+			c.add("PublishPort", publishSpec(p, b))
+		}
+	}
+
+	for _, b := range hc.Binds {
+		c.add("Volume", systemdQuote(b))
+	}
+	for _, m := range hc.Mounts {
+		c.add("Mount", systemdQuote(mountSpecArg(&m)))
+	}
+	for _, dst := range sortedKeys(hc.Tmpfs) {
+		if opts := hc.Tmpfs[dst]; len(opts) > 0 {
+			c.add("Tmpfs", fmt.Sprintf("%s:%s", dst, opts))
+		} else {
+			c.add("Tmpfs", dst)
+		}
+	}
+	if hc.ReadonlyRootfs {
+		c.add("ReadOnly", "true")
+	}
+	for _, dev := range hc.Devices {
+		c.add("AddDevice", fmt.Sprintf("%s:%s:%s", dev.PathOnHost, dev.PathInContainer, dev.CgroupPermissions))
+	}
+	for _, capability := range hc.CapAdd {
+		c.add("AddCapability", capability)
+	}
+	for _, capability := range hc.CapDrop {
+		c.add("DropCapability", capability)
+	}
+	if hc.Privileged {
+		podmanArgs = append(podmanArgs, "--privileged")
+	}
+	for _, k := range sortedKeys(hc.Sysctls) {
+		c.add("Sysctl", fmt.Sprintf("%s=%s", k, hc.Sysctls[k]))
+	}
+	for _, dns := range hc.DNS {
+		c.add("DNS", dns)
+	}
+	for _, opt := range hc.DNSOptions {
+		c.add("DNSOption", opt)
+	}
+	for _, s := range hc.DNSSearch {
+		c.add("DNSSearch", s)
+	}
+	for _, h := range hc.ExtraHosts {
+		c.add("AddHost", h)
+	}
+	for _, g := range hc.GroupAdd {
+		c.add("GroupAdd", g)
+	}
+	if hc.ShmSize > 0 {
+		c.add("ShmSize", strconv.FormatInt(hc.ShmSize, 10))
+	}
+	if len(podmanArgs) > 0 {
+		c.add("PodmanArgs", systemdJoin(podmanArgs))
+	}
+
+	// The restart policy is managed by systemd, since quadlet always
+	// removes the container once it exits.
+	switch rp := hc.RestartPolicy; rp.Name {
+	case dcontainer.RestartPolicyAlways, dcontainer.RestartPolicyUnlessStopped:
+		u.section("Service").add("Restart", "always")
+	case dcontainer.RestartPolicyOnFailure:
+		u.section("Service").add("Restart", "on-failure")
+		if rp.MaximumRetryCount > 0 {
+			unit.add("StartLimitBurst", strconv.Itoa(rp.MaximumRetryCount))
+		}
+	}
+	u.section("Install").add("WantedBy", "multi-user.target")
+	return u
+}
+
+func (d *Deployment) addQuadletHealthCheck(u *systemdUnitFile, c *systemdSection, h *dcontainer.HealthConfig) {
+	if h == nil {
+		return
+	}
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "NONE":
+			c.add("HealthCmd", "none")
+		case "CMD-SHELL":
+			c.add("HealthCmd", systemdQuote(strings.Join(h.Test[1:], " ")))
+		case "CMD":
+			c.add("HealthCmd", systemdQuote(shellJoin(h.Test[1:])))
+		}
+	}
+	c.add("HealthInterval", durationString(h.Interval))
+	c.add("HealthTimeout", durationString(h.Timeout))
+	c.add("HealthStartPeriod", durationString(h.StartPeriod))
+	if h.Retries != 0 {
+		c.add("HealthRetries", strconv.Itoa(h.Retries))
+	}
+	if h.StartInterval != 0 {
+		u.comments = append(u.comments, "The health check start interval is not supported by podman.")
+	}
+}
+
+// containerDependencies returns the containers whose network stack is
+// used by the specified container, and are allowed on the host.
+func (d *Deployment) containerDependencies(ct *Container, containers ContainerList) []*config.ContainerReference {
+	var res []*config.ContainerReference
+	for _, ep := range ct.endpoints {
+		if ep.network.Mode() != NetworkModeContainer {
+			continue
+		}
+		target := &ep.network.containerModeInfo.container
+		for _, other := range containers {
+			if other.config.Info == *target {
+				res = append(res, target)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func (d *Deployment) addRequiresMountsFor(unit *systemdSection) {
+	if paths := d.hostSystemd.RequiresMountsFor; len(paths) > 0 {
+		unit.add("RequiresMountsFor", systemdJoin(paths))
+		unit.add("After", "remote-fs.target")
+	}
+}
+
+func (d *Deployment) newSystemdUnitFile(desc string) *systemdUnitFile {
+	u := &systemdUnitFile{
+		comments: []string{fmt.Sprintf("%s for host %s, generated by homelab.", desc, d.hostName)},
+	}
+	u.section("Unit").add("Description", desc)
+	return u
+}
+
+func (u *systemdUnitFile) section(name string) *systemdSection {
+	for _, s := range u.sections {
+		if s.name == name {
+			return s
+		}
+	}
+	s := &systemdSection{name: name}
+	u.sections = append(u.sections, s)
+	return s
+}
+
+// add adds the entry to the section, skipping the empty values and the
+// duplicate entries.
+func (s *systemdSection) add(key, val string) {
+	e := fmt.Sprintf("%s=%s", key, val)
+	if len(val) > 0 && !slices.Contains(s.entries, e) {
+		s.entries = append(s.entries, e)
+	}
+}
+
+func (u *systemdUnitFile) String() string {
+	var sb strings.Builder
+	for _, c := range u.comments {
+		sb.WriteString(fmt.Sprintf("# %s\n", c))
+	}
+	for i, s := range u.sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("[%s]\n", s.name))
+		for _, e := range s.entries {
+			sb.WriteString(e)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// mergedHostSystemdConfig merges the systemd configs of all the host
+// configs applicable to the current host.
+func mergedHostSystemdConfig(hostConfigs []*config.Host) config.HostSystemd {
+	res := config.HostSystemd{}
+	for _, h := range hostConfigs {
+		for _, p := range h.Systemd.RequiresMountsFor {
+			if !slices.Contains(res.RequiresMountsFor, p) {
+				res.RequiresMountsFor = append(res.RequiresMountsFor, p)
+			}
+		}
+	}
+	return res
+}
+
+func containerServiceName(ref *config.ContainerReference) string {
+	return fmt.Sprintf("%s-%s.service", systemdUnitPrefix, containerName(ref))
+}
+
+func groupServiceName(group string) string {
+	return fmt.Sprintf("%s-group-%s.service", systemdUnitPrefix, group)
+}
+
+// systemdEscape escapes the systemd specifiers and the environment
+// variable references within the value.
+func systemdEscape(s string) string {
+	return strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+}
+
+// systemdQuote escapes and quotes the value as a single word within a
+// systemd command line or list.
+func systemdQuote(s string) string {
+	s = systemdEscape(s)
+	if len(s) > 0 && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func systemdJoin(args []string) string {
+	res := make([]string, 0, len(args))
+	for _, a := range args {
+		res = append(res, systemdQuote(a))
+	}
+	return strings.Join(res, " ")
+}
@@ -0,0 +1,732 @@
+package deployment
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+)
+
+const exportTestConfig = `
+global:
+  baseDir: testdata/dummy-base-dir
+  container:
+    stopTimeout: 10
+ipam:
+  networks:
+    bridgeModeNetworks:
+      - name: net1
+        hostInterfaceName: docker-net1
+        cidr: 172.18.10.0/24
+        priority: 1
+        containers:
+          - ip: 172.18.10.11
+            container:
+              group: g1
+              container: c1
+          - ip: 172.18.10.13
+            container:
+              group: g2
+              container: c3
+      - name: net2
+        hostInterfaceName: docker-net2
+        cidr: 172.18.20.0/24
+        priority: 2
+        containers:
+          - ip: 172.18.20.11
+            container:
+              group: g1
+              container: c1
+      - name: net3
+        hostInterfaceName: docker-net3
+        cidr: 172.18.30.0/24
+        priority: 1
+        containers:
+          - ip: 172.18.30.14
+            container:
+              group: g2
+              container: c4
+    containerModeNetworks:
+      - name: g1-c1
+        container:
+          group: g1
+          container: c1
+        attachingContainers:
+          - group: g1
+            container: c2
+hosts:
+  - name: fakehost
+    systemd:
+      requiresMountsFor:
+        - /mnt/nfs/data
+    allowedContainers:
+      - group: g1
+        container: c1
+      - group: g1
+        container: c2
+      - group: g2
+        container: c3
+  - name: otherhost
+    allowedContainers:
+      - group: g2
+        container: c4
+groups:
+  - name: g1
+    order: 1
+  - name: g2
+    order: 2
+containers:
+  - info:
+      group: g1
+      container: c1
+    image:
+      image: abc/app:1.0
+    metadata:
+      labels:
+        - name: app.role
+          value: frontend
+    lifecycle:
+      order: 1
+      restartPolicy:
+        mode: on-failure
+        maxRetryCount: 3
+    fs:
+      mounts:
+        - name: data
+          type: bind
+          src: /data/c1
+          dst: /data
+        - name: config
+          type: bind
+          src: /configs/c1
+          dst: /config
+          readOnly: true
+        - name: cache
+          type: tmpfs
+          dst: /cache
+          tmpfsSize: 1048576
+    network:
+      hostName: c1
+      publishedPorts:
+        - containerPort: 80
+          proto: tcp
+          hostIp: 127.0.0.1
+          hostPort: 8080
+        - containerPort: 53
+          proto: udp
+          hostIp: 0.0.0.0
+          hostPort: 53
+    health:
+      cmd:
+        - curl
+        - -f
+        - http://localhost/health check
+      retries: 3
+      interval: 30s
+    runtime:
+      entrypoint:
+        - /bin/app
+        - --mode
+      args:
+        - serve
+        - --title=My App
+      env:
+        - var: PRICE
+          value: 5$$$$
+        - var: EMPTY
+          empty: true
+  - info:
+      group: g1
+      container: c2
+    image:
+      image: abc/sidecar
+    lifecycle:
+      order: 2
+  - info:
+      group: g2
+      container: c3
+    image:
+      image: abc/worker
+    lifecycle:
+      order: 1
+      autoRemove: true
+    security:
+      capAdd:
+        - NET_ADMIN
+    runtime:
+      shmSize: 128m
+  - info:
+      group: g2
+      container: c4
+    image:
+      image: abc/other
+    lifecycle:
+      order: 2
+`
+
+var deploymentExportTests = []struct {
+	name   string
+	format ExportFormat
+	want   string
+}{
+	{
+		name:   "Deployment Export - Compose",
+		format: ExportFormatCompose,
+		want: `# Homelab deployment for host fakehost.
+# Settings that cannot be expressed in docker compose:
+#   - g2-c3: auto remove
+name: homelab-fakehost
+services:
+  g1-c1:
+    container_name: g1-c1
+    image: abc/app:1.0
+    hostname: c1
+    environment:
+      - PRICE=5$$$$
+      - EMPTY=
+    entrypoint:
+      - /bin/app
+      - --mode
+    command:
+      - serve
+      - --title=My App
+    healthcheck:
+      test:
+        - CMD
+        - curl
+        - -f
+        - http://localhost/health check
+      interval: 30s
+      retries: 3
+    labels:
+      app.role: frontend
+    stop_grace_period: 10s
+    networks:
+      net1:
+        ipv4_address: 172.18.10.11
+        priority: 2
+      net2:
+        ipv4_address: 172.18.20.11
+        priority: 1
+    ports:
+      - target: 53
+        published: "53"
+        host_ip: 0.0.0.0
+        protocol: udp
+      - target: 80
+        published: "8080"
+        host_ip: 127.0.0.1
+        protocol: tcp
+    volumes:
+      - type: bind
+        source: /data/c1
+        target: /data
+      - type: bind
+        source: /configs/c1
+        target: /config
+        read_only: true
+      - type: tmpfs
+        target: /cache
+        tmpfs:
+          size: 1048576
+    restart: on-failure:3
+  g1-c2:
+    container_name: g1-c2
+    image: abc/sidecar
+    stop_grace_period: 10s
+    network_mode: service:g1-c1
+  g2-c3:
+    container_name: g2-c3
+    image: abc/worker
+    stop_grace_period: 10s
+    networks:
+      net1:
+        ipv4_address: 172.18.10.13
+        priority: 1
+    cap_add:
+      - NET_ADMIN
+    shm_size: "134217728"
+networks:
+  net1:
+    name: net1
+    driver: bridge
+    driver_opts:
+      com.docker.network.bridge.enable_icc: "true"
+      com.docker.network.bridge.enable_ip_masquerade: "true"
+      com.docker.network.bridge.host_binding_ipv4: 172.18.10.1
+      com.docker.network.bridge.mtu: "1500"
+      com.docker.network.bridge.name: docker-net1
+    enable_ipv6: false
+    ipam:
+      driver: default
+      config:
+        - subnet: 172.18.10.0/24
+          gateway: 172.18.10.1
+  net2:
+    name: net2
+    driver: bridge
+    driver_opts:
+      com.docker.network.bridge.enable_icc: "true"
+      com.docker.network.bridge.enable_ip_masquerade: "true"
+      com.docker.network.bridge.host_binding_ipv4: 172.18.20.1
+      com.docker.network.bridge.mtu: "1500"
+      com.docker.network.bridge.name: docker-net2
+    enable_ipv6: false
+    ipam:
+      driver: default
+      config:
+        - subnet: 172.18.20.0/24
+          gateway: 172.18.20.1
+`,
+	},
+	{
+		name:   "Deployment Export - Docker Run",
+		format: ExportFormatDockerRun,
+		want: `#!/bin/sh
+# Homelab deployment for host fakehost.
+set -eu
+
+# Network net1.
+docker network inspect net1 >/dev/null 2>&1 || docker network create \
+  --driver bridge \
+  --subnet 172.18.10.0/24 \
+  --gateway 172.18.10.1 \
+  --opt com.docker.network.bridge.enable_icc=true \
+  --opt com.docker.network.bridge.enable_ip_masquerade=true \
+  --opt com.docker.network.bridge.host_binding_ipv4=172.18.10.1 \
+  --opt com.docker.network.bridge.mtu=1500 \
+  --opt com.docker.network.bridge.name=docker-net1 \
+  net1
+
+# Network net2.
+docker network inspect net2 >/dev/null 2>&1 || docker network create \
+  --driver bridge \
+  --subnet 172.18.20.0/24 \
+  --gateway 172.18.20.1 \
+  --opt com.docker.network.bridge.enable_icc=true \
+  --opt com.docker.network.bridge.enable_ip_masquerade=true \
+  --opt com.docker.network.bridge.host_binding_ipv4=172.18.20.1 \
+  --opt com.docker.network.bridge.mtu=1500 \
+  --opt com.docker.network.bridge.name=docker-net2 \
+  net2
+
+# Container g1-c1.
+docker container rm --force g1-c1 >/dev/null 2>&1 || true
+docker container create \
+  --name g1-c1 \
+  --hostname c1 \
+  --env 'PRICE=5$$' \
+  --env EMPTY= \
+  --entrypoint /bin/app \
+  --health-cmd 'curl -f '\''http://localhost/health check'\''' \
+  --health-interval 30s \
+  --health-retries 3 \
+  --label app.role=frontend \
+  --stop-timeout 10 \
+  --network net1 \
+  --ip 172.18.10.11 \
+  --publish 0.0.0.0:53:53/udp \
+  --publish 127.0.0.1:8080:80/tcp \
+  --volume /data/c1:/data \
+  --volume /configs/c1:/config:ro \
+  --mount type=tmpfs,destination=/cache,tmpfs-size=1048576 \
+  --restart on-failure:3 \
+  abc/app:1.0 --mode serve '--title=My App'
+docker network connect --ip 172.18.20.11 net2 g1-c1
+docker container start g1-c1
+
+# Container g1-c2.
+docker container rm --force g1-c2 >/dev/null 2>&1 || true
+docker container create \
+  --name g1-c2 \
+  --stop-timeout 10 \
+  --network container:g1-c1 \
+  abc/sidecar
+docker container start g1-c2
+
+# Container g2-c3.
+docker container rm --force g2-c3 >/dev/null 2>&1 || true
+docker container create \
+  --name g2-c3 \
+  --stop-timeout 10 \
+  --network net1 \
+  --ip 172.18.10.13 \
+  --rm \
+  --cap-add NET_ADMIN \
+  --shm-size 134217728 \
+  abc/worker
+docker container start g2-c3
+`,
+	},
+}
+
+func TestDeploymentExport(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range deploymentExportTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutils.NewVanillaTestContext()
+			dep, gotErr := FromReader(ctx, strings.NewReader(exportTestConfig))
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
+				return
+			}
+
+			got, gotErr := dep.Export(ctx, tc.format)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "Deployment.Export()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "Deployment.Export()", tc.name, "exported deployment", tc.want, got) {
+				return
+			}
+		})
+	}
+}
+
+func TestDeploymentExportJSON(t *testing.T) {
+	t.Parallel()
+
+	tc := "Deployment Export - JSON"
+	ctx := testutils.NewVanillaTestContext()
+	dep, gotErr := FromReader(ctx, strings.NewReader(exportTestConfig))
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "FromReader()", tc, gotErr)
+		return
+	}
+
+	out, gotErr := dep.Export(ctx, ExportFormatJSON)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "Deployment.Export()", tc, gotErr)
+		return
+	}
+
+	got := exportedDeployment{}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		testhelpers.LogErrorNotNil(t, "json.Unmarshal()", tc, err)
+		return
+	}
+
+	// The exported docker configs must match the ones used while starting
+	// the containers.
+	want := dep.exportedDeployment()
+	if !testhelpers.CmpDiff(t, "Deployment.Export()", tc, "exported deployment", want, &got) {
+		return
+	}
+}
+
+var deploymentExportSystemdTests = []struct {
+	name string
+	opts *SystemdExportOptions
+	want []*SystemdUnit
+}{
+	{
+		name: "Deployment Export Systemd - Service Per Container",
+		opts: &SystemdExportOptions{
+			Style:      SystemdUnitStyleService,
+			Scope:      SystemdUnitScopeContainer,
+			HomelabCmd: []string{"/usr/local/bin/homelab", "--configs-dir", "/etc/homelab configs"},
+		},
+		want: []*SystemdUnit{
+			{
+				FileName: "homelab-g1-c1.service",
+				Content: `# Homelab container g1-c1 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab container g1-c1
+Requires=docker.service
+After=docker.service network-online.target
+Wants=network-online.target
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab --configs-dir "/etc/homelab configs" containers start g1/c1
+ExecStop=/usr/local/bin/homelab --configs-dir "/etc/homelab configs" containers stop g1/c1
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+			{
+				FileName: "homelab-g1-c2.service",
+				Content: `# Homelab container g1-c2 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab container g1-c2
+Requires=docker.service
+After=docker.service network-online.target
+Wants=network-online.target
+After=homelab-g1-c1.service
+Requires=homelab-g1-c1.service
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab --configs-dir "/etc/homelab configs" containers start g1/c2
+ExecStop=/usr/local/bin/homelab --configs-dir "/etc/homelab configs" containers stop g1/c2
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+			{
+				FileName: "homelab-g2-c3.service",
+				Content: `# Homelab container g2-c3 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab container g2-c3
+Requires=docker.service
+After=docker.service network-online.target
+Wants=network-online.target
+After=homelab-g1-c2.service
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab --configs-dir "/etc/homelab configs" containers start g2/c3
+ExecStop=/usr/local/bin/homelab --configs-dir "/etc/homelab configs" containers stop g2/c3
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+		},
+	},
+	{
+		name: "Deployment Export Systemd - Service Per Group",
+		opts: &SystemdExportOptions{
+			Style:      SystemdUnitStyleService,
+			Scope:      SystemdUnitScopeGroup,
+			HomelabCmd: []string{"/usr/local/bin/homelab"},
+		},
+		want: []*SystemdUnit{
+			{
+				FileName: "homelab-group-g1.service",
+				Content: `# Homelab group g1 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab group g1
+Requires=docker.service
+After=docker.service network-online.target
+Wants=network-online.target
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab groups start g1
+ExecStop=/usr/local/bin/homelab groups stop g1
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+			{
+				FileName: "homelab-group-g2.service",
+				Content: `# Homelab group g2 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab group g2
+Requires=docker.service
+After=docker.service network-online.target
+Wants=network-online.target
+After=homelab-group-g1.service
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/homelab groups start g2
+ExecStop=/usr/local/bin/homelab groups stop g2
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+		},
+	},
+	{
+		name: "Deployment Export Systemd - Quadlet Per Container",
+		opts: &SystemdExportOptions{
+			Style: SystemdUnitStyleQuadlet,
+			Scope: SystemdUnitScopeContainer,
+		},
+		want: []*SystemdUnit{
+			{
+				FileName: "homelab-net1.network",
+				Content: `# Homelab network net1 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab network net1
+
+[Network]
+NetworkName=net1
+Driver=bridge
+Subnet=172.18.10.0/24
+Gateway=172.18.10.1
+Options=com.docker.network.bridge.enable_icc=true
+Options=com.docker.network.bridge.enable_ip_masquerade=true
+Options=com.docker.network.bridge.host_binding_ipv4=172.18.10.1
+Options=com.docker.network.bridge.mtu=1500
+Options=com.docker.network.bridge.name=docker-net1
+`,
+			},
+			{
+				FileName: "homelab-net2.network",
+				Content: `# Homelab network net2 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab network net2
+
+[Network]
+NetworkName=net2
+Driver=bridge
+Subnet=172.18.20.0/24
+Gateway=172.18.20.1
+Options=com.docker.network.bridge.enable_icc=true
+Options=com.docker.network.bridge.enable_ip_masquerade=true
+Options=com.docker.network.bridge.host_binding_ipv4=172.18.20.1
+Options=com.docker.network.bridge.mtu=1500
+Options=com.docker.network.bridge.name=docker-net2
+`,
+			},
+			{
+				FileName: "homelab-g1-c1.container",
+				Content: `# Homelab container g1-c1 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab container g1-c1
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+StartLimitBurst=3
+
+[Container]
+ContainerName=g1-c1
+Image=abc/app:1.0
+HostName=c1
+Environment=PRICE=5$$$$
+Environment=EMPTY=
+Entrypoint=/bin/app
+Exec=--mode serve "--title=My App"
+HealthCmd="curl -f 'http://localhost/health check'"
+HealthInterval=30s
+HealthRetries=3
+Label=app.role=frontend
+StopTimeout=10
+Network=homelab-net1.network:ip=172.18.10.11
+Network=homelab-net2.network:ip=172.18.20.11
+PublishPort=0.0.0.0:53:53/udp
+PublishPort=127.0.0.1:8080:80/tcp
+Volume=/data/c1:/data
+Volume=/configs/c1:/config:ro
+Mount=type=tmpfs,destination=/cache,tmpfs-size=1048576
+
+[Service]
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+			{
+				FileName: "homelab-g1-c2.container",
+				Content: `# Homelab container g1-c2 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab container g1-c2
+After=homelab-g1-c1.service
+Requires=homelab-g1-c1.service
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+
+[Container]
+ContainerName=g1-c2
+Image=abc/sidecar
+StopTimeout=10
+Network=container:g1-c1
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+			{
+				FileName: "homelab-g2-c3.container",
+				Content: `# Homelab container g2-c3 for host fakehost, generated by homelab.
+[Unit]
+Description=Homelab container g2-c3
+After=homelab-g1-c2.service
+RequiresMountsFor=/mnt/nfs/data
+After=remote-fs.target
+
+[Container]
+ContainerName=g2-c3
+Image=abc/worker
+StopTimeout=10
+Network=homelab-net1.network:ip=172.18.10.13
+AddCapability=NET_ADMIN
+ShmSize=134217728
+
+[Install]
+WantedBy=multi-user.target
+`,
+			},
+		},
+	},
+}
+
+func TestDeploymentExportSystemdUnits(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range deploymentExportSystemdTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep, gotErr := FromReader(testutils.NewVanillaTestContext(), strings.NewReader(exportTestConfig))
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromReader()", tc.name, gotErr)
+				return
+			}
+
+			got, gotErr := dep.ExportSystemdUnits(tc.opts)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "Deployment.ExportSystemdUnits()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "Deployment.ExportSystemdUnits()", tc.name, "systemd units", tc.want, got) {
+				return
+			}
+		})
+	}
+}
+
+func TestDeploymentExportSystemdUnitsQuadletPerGroup(t *testing.T) {
+	t.Parallel()
+
+	tc := "Deployment Export Systemd - Quadlet Per Group"
+	dep, gotErr := FromReader(testutils.NewVanillaTestContext(), strings.NewReader(exportTestConfig))
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "FromReader()", tc, gotErr)
+		return
+	}
+
+	_, gotErr = dep.ExportSystemdUnits(&SystemdExportOptions{
+		Style: SystemdUnitStyleQuadlet,
+		Scope: SystemdUnitScopeGroup,
+	})
+	if gotErr == nil {
+		testhelpers.LogErrorNil(t, "Deployment.ExportSystemdUnits()", tc, "quadlet systemd units can only be exported per container")
+		return
+	}
+
+	if !testhelpers.RegexMatch(t, "Deployment.ExportSystemdUnits()", tc, "gotErr error string", "quadlet systemd units can only be exported per container", gotErr.Error()) {
+		return
+	}
+}
@@ -0,0 +1,263 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	defaultHealBackoff     = 30 * time.Second
+	defaultHealMaxAttempts = 3
+	// The backoff isn't doubled any further once it reaches this limit.
+	maxDoubledHealBackoff = 24 * time.Hour
+)
+
+// HealAction is the action taken to heal a container once its health
+// check reports it as unhealthy.
+type HealAction uint8
+
+const (
+	// HealActionNone leaves the unhealthy container alone.
+	HealActionNone HealAction = iota
+	// HealActionRestart restarts the existing container.
+	HealActionRestart
+	// HealActionRecreate purges the container and starts it afresh.
+	HealActionRecreate
+)
+
+func (h HealAction) String() string {
+	switch h {
+	case HealActionNone:
+		return "None"
+	case HealActionRestart:
+		return "Restart"
+	case HealActionRecreate:
+		return "Recreate"
+	default:
+		panic("Invalid scenario in HealAction stringer, possibly indicating a bug in the code")
+	}
+}
+
+func healActionFromString(action string) (HealAction, error) {
+	switch action {
+	case "", "none":
+		return HealActionNone, nil
+	case "restart":
+		return HealActionRestart, nil
+	case "recreate":
+		return HealActionRecreate, nil
+	default:
+		return HealActionNone, fmt.Errorf("invalid heal action string: %s", action)
+	}
+}
+
+func healActionValidValues() string {
+	return "[ 'none', 'restart', 'recreate' ]"
+}
+
+// HealResult is the outcome of healing a container.
+type HealResult uint8
+
+const (
+	// HealResultNone indicates the container required no healing.
+	HealResultNone HealResult = iota
+	// HealResultHealed indicates a heal attempt was made on the
+	// unhealthy container.
+	HealResultHealed
+	// HealResultBackoff indicates the unhealthy container is not healed
+	// yet since the backoff after the previous heal attempt is pending.
+	HealResultBackoff
+	// HealResultCrashLooping indicates the container is no longer healed
+	// since it turned unhealthy again after all the heal attempts.
+	HealResultCrashLooping
+)
+
+func (h HealResult) String() string {
+	switch h {
+	case HealResultNone:
+		return "None"
+	case HealResultHealed:
+		return "Healed"
+	case HealResultBackoff:
+		return "Backoff"
+	case HealResultCrashLooping:
+		return "CrashLooping"
+	default:
+		panic("Invalid scenario in HealResult stringer, possibly indicating a bug in the code")
+	}
+}
+
+// HealState tracks the heal attempts of the containers keyed by the
+// container name, and is retained across the heal invocations to honor
+// the backoff and detect the crash loops.
+type HealState map[string]*ContainerHealState
+
+// ContainerHealState tracks the heal attempts of a container made
+// without the container turning healthy in between.
+type ContainerHealState struct {
+	// ContainerID is the ID of the docker container that was healed.
+	// The state is discarded once the container is recreated other than
+	// by healing, for instance by starting it again.
+	ContainerID  string    `json:"containerId"`
+	Attempts     int       `json:"attempts"`
+	LastAttempt  time.Time `json:"lastAttempt"`
+	CrashLooping bool      `json:"crashLooping,omitempty"`
+}
+
+// ReadHealState reads the heal state from the file, and returns an empty
+// state if the file doesn't exist.
+func ReadHealState(path string) (HealState, error) {
+	state := HealState{}
+	if err := readStateFile(path, "heal", &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Write writes the heal state to the file, replacing it atomically.
+func (h HealState) Write(path string) error {
+	return writeStateFile(path, "heal", h)
+}
+
+// NextHealAttempt returns the time after which the next heal attempt of
+// the container is allowed, and false if the container has no pending
+// heal attempts.
+func (h HealState) NextHealAttempt(c *Container) (time.Time, bool) {
+	st, found := h[c.Name()]
+	if !found || st.Attempts == 0 || st.CrashLooping {
+		return time.Time{}, false
+	}
+	return st.LastAttempt.Add(c.healBackoff(st.Attempts)), true
+}
+
+// HealAction returns the action taken to heal the container once its
+// health check reports it as unhealthy.
+func (c *Container) HealAction() HealAction {
+	a, err := healActionFromString(c.config.Health.OnUnhealthy.Action)
+	if err != nil {
+		panic(fmt.Sprintf("unable to convert heal action %s setting, reason: %v, possibly indicating a bug in the code", c.config.Health.OnUnhealthy.Action, err))
+	}
+	return a
+}
+
+// Heal restarts or recreates the running container as per its unhealthy
+// policy if its health check reports it as unhealthy. The heal attempts
+// are tracked in the state, backing off exponentially between the
+// attempts. The container is considered crash looping and is no longer
+// healed once it turns unhealthy again after the maximum heal attempts,
+// until it stays healthy or is recreated other than by healing.
+func (c *Container) Heal(ctx context.Context, dc *docker.Client, state HealState, now time.Time) (HealResult, error) {
+	action := c.HealAction()
+	if action == HealActionNone || !c.isAllowedOnCurrentHost() {
+		return HealResultNone, nil
+	}
+
+	name := c.Name()
+	st, err := dc.GetContainerState(ctx, name)
+	if err != nil {
+		return HealResultNone, utils.LogToErrorAndReturn(ctx, "Failed to heal container %s, reason:%v", name, err)
+	}
+	// The containers that aren't running are left to the docker restart
+	// policy.
+	if st != docker.ContainerStateRunning {
+		return HealResultNone, nil
+	}
+	info, err := dc.InspectContainer(ctx, name)
+	if err != nil {
+		return HealResultNone, utils.LogToErrorAndReturn(ctx, "Failed to heal container %s, reason:%v", name, err)
+	}
+
+	ctState := state[name]
+	if ctState != nil && ctState.ContainerID != info.ID {
+		log(ctx).Debugf("Discarding the heal state of container %s since it was recreated", name)
+		delete(state, name)
+		ctState = nil
+	}
+
+	health := ""
+	if info.State != nil && info.State.Health != nil {
+		health = info.State.Health.Status
+	}
+	switch health {
+	case dtypes.Unhealthy:
+	case dtypes.Healthy:
+		// The attempts are reset only once the container stays healthy
+		// for the backoff, which otherwise would fail to detect the
+		// containers turning unhealthy soon after every heal attempt.
+		if ctState != nil && !now.Before(ctState.LastAttempt.Add(c.healBackoff(ctState.Attempts))) {
+			log(ctx).Infof("Container %s is healthy again after %d heal attempt(s)", name, ctState.Attempts)
+			delete(state, name)
+		}
+		return HealResultNone, nil
+	default:
+		return HealResultNone, nil
+	}
+
+	if ctState == nil {
+		ctState = &ContainerHealState{ContainerID: info.ID}
+		state[name] = ctState
+	}
+	maxAttempts := c.healMaxAttempts()
+	if ctState.Attempts >= maxAttempts {
+		if !ctState.CrashLooping {
+			ctState.CrashLooping = true
+			log(ctx).Errorf("Container %s is crash looping since it is still unhealthy after %d heal attempt(s), no longer healing it", name, ctState.Attempts)
+		}
+		return HealResultCrashLooping, nil
+	}
+	if ctState.Attempts > 0 && now.Before(ctState.LastAttempt.Add(c.healBackoff(ctState.Attempts))) {
+		log(ctx).Debugf("Not healing unhealthy container %s yet since the backoff after the previous heal attempt is pending", name)
+		return HealResultBackoff, nil
+	}
+
+	ctState.Attempts++
+	ctState.LastAttempt = now
+	switch action {
+	case HealActionRestart:
+		log(ctx).Infof("Restarting container %s since it is unhealthy, heal attempt %d of %d", name, ctState.Attempts, maxAttempts)
+		if err := dc.RestartContainer(ctx, name); err != nil {
+			return HealResultHealed, utils.LogToErrorAndReturn(ctx, "Failed to heal container %s, reason:%v", name, err)
+		}
+	case HealActionRecreate:
+		log(ctx).Infof("Recreating container %s since it is unhealthy, heal attempt %d of %d", name, ctState.Attempts, maxAttempts)
+		// Start logs the failures already.
+		if _, err := c.Start(ctx, dc); err != nil {
+			return HealResultHealed, err
+		}
+		// Track the recreated container, so that its state isn't
+		// discarded the next time.
+		info, err := dc.InspectContainer(ctx, name)
+		if err != nil {
+			return HealResultHealed, utils.LogToErrorAndReturn(ctx, "Failed to heal container %s, reason:%v", name, err)
+		}
+		ctState.ContainerID = info.ID
+	default:
+		panic(fmt.Sprintf("Invalid heal action %s, possibly indicating a bug in the code", action))
+	}
+	return HealResultHealed, nil
+}
+
+// healBackoff returns the delay after the specified number of heal
+// attempts, doubled after every attempt until it reaches a day.
+func (c *Container) healBackoff(attempts int) time.Duration {
+	backoff := defaultHealBackoff
+	if b := c.config.Health.OnUnhealthy.Backoff; len(b) > 0 {
+		backoff = utils.MustParseDuration(b)
+	}
+	for i := 1; i < attempts && backoff < maxDoubledHealBackoff; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
+
+func (c *Container) healMaxAttempts() int {
+	if m := c.config.Health.OnUnhealthy.MaxAttempts; m > 0 {
+		return m
+	}
+	return defaultHealMaxAttempts
+}
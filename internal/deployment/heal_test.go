@@ -0,0 +1,455 @@
+package deployment
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+var healTestNow = time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+var containerHealTests = []struct {
+	name            string
+	policy          config.ContainerUnhealthyPolicy
+	state           docker.ContainerState
+	health          string
+	prevState       *ContainerHealState
+	staleID         bool
+	want            HealResult
+	wantState       *ContainerHealState
+	wantRestarts    int
+	wantNewInstance bool
+}{
+	{
+		name:   "Container Heal - No Policy",
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		want:   HealResultNone,
+	},
+	{
+		name: "Container Heal - Restart Unhealthy",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "restart",
+		},
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		want:   HealResultHealed,
+		wantState: &ContainerHealState{
+			Attempts:    1,
+			LastAttempt: healTestNow,
+		},
+		wantRestarts: 1,
+	},
+	{
+		name: "Container Heal - Recreate Unhealthy",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "recreate",
+		},
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		want:   HealResultHealed,
+		wantState: &ContainerHealState{
+			Attempts:    1,
+			LastAttempt: healTestNow,
+		},
+		wantNewInstance: true,
+	},
+	{
+		name: "Container Heal - Restart Healthy",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "restart",
+		},
+		state:  docker.ContainerStateRunning,
+		health: "healthy",
+		want:   HealResultNone,
+	},
+	{
+		name: "Container Heal - Restart Without Health Check",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "restart",
+		},
+		state: docker.ContainerStateRunning,
+		want:  HealResultNone,
+	},
+	{
+		name: "Container Heal - Restart Exited",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "restart",
+		},
+		state:  docker.ContainerStateExited,
+		health: "unhealthy",
+		want:   HealResultNone,
+	},
+	{
+		name: "Container Heal - Backoff Pending",
+		policy: config.ContainerUnhealthyPolicy{
+			Action:  "restart",
+			Backoff: "1m",
+		},
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		prevState: &ContainerHealState{
+			Attempts:    1,
+			LastAttempt: healTestNow.Add(-59 * time.Second),
+		},
+		want: HealResultBackoff,
+		wantState: &ContainerHealState{
+			Attempts:    1,
+			LastAttempt: healTestNow.Add(-59 * time.Second),
+		},
+	},
+	{
+		name: "Container Heal - Backoff Doubled",
+		policy: config.ContainerUnhealthyPolicy{
+			Action:      "restart",
+			Backoff:     "1m",
+			MaxAttempts: 5,
+		},
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		prevState: &ContainerHealState{
+			Attempts:    2,
+			LastAttempt: healTestNow.Add(-90 * time.Second),
+		},
+		want: HealResultBackoff,
+		wantState: &ContainerHealState{
+			Attempts:    2,
+			LastAttempt: healTestNow.Add(-90 * time.Second),
+		},
+	},
+	{
+		name: "Container Heal - Backoff Elapsed",
+		policy: config.ContainerUnhealthyPolicy{
+			Action:      "restart",
+			Backoff:     "1m",
+			MaxAttempts: 5,
+		},
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		prevState: &ContainerHealState{
+			Attempts:    2,
+			LastAttempt: healTestNow.Add(-2 * time.Minute),
+		},
+		want: HealResultHealed,
+		wantState: &ContainerHealState{
+			Attempts:    3,
+			LastAttempt: healTestNow,
+		},
+		wantRestarts: 1,
+	},
+	{
+		name: "Container Heal - Crash Looping",
+		policy: config.ContainerUnhealthyPolicy{
+			Action:      "restart",
+			MaxAttempts: 2,
+		},
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		prevState: &ContainerHealState{
+			Attempts:    2,
+			LastAttempt: healTestNow.Add(-time.Hour),
+		},
+		want: HealResultCrashLooping,
+		wantState: &ContainerHealState{
+			Attempts:     2,
+			LastAttempt:  healTestNow.Add(-time.Hour),
+			CrashLooping: true,
+		},
+	},
+	{
+		name: "Container Heal - Healthy Within Backoff",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "restart",
+		},
+		state:  docker.ContainerStateRunning,
+		health: "healthy",
+		prevState: &ContainerHealState{
+			Attempts:    2,
+			LastAttempt: healTestNow.Add(-59 * time.Second),
+		},
+		want: HealResultNone,
+		wantState: &ContainerHealState{
+			Attempts:    2,
+			LastAttempt: healTestNow.Add(-59 * time.Second),
+		},
+	},
+	{
+		name: "Container Heal - Healthy After Backoff",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "restart",
+		},
+		state:  docker.ContainerStateRunning,
+		health: "healthy",
+		prevState: &ContainerHealState{
+			Attempts:     3,
+			LastAttempt:  healTestNow.Add(-2 * time.Minute),
+			CrashLooping: true,
+		},
+		want: HealResultNone,
+	},
+	{
+		name: "Container Heal - Recreated Since Last Attempt",
+		policy: config.ContainerUnhealthyPolicy{
+			Action: "restart",
+		},
+		state:  docker.ContainerStateRunning,
+		health: "unhealthy",
+		prevState: &ContainerHealState{
+			Attempts:     3,
+			LastAttempt:  healTestNow.Add(-time.Minute),
+			CrashLooping: true,
+		},
+		staleID: true,
+		want:    HealResultHealed,
+		wantState: &ContainerHealState{
+			Attempts:    1,
+			LastAttempt: healTestNow,
+		},
+		wantRestarts: 1,
+	},
+}
+
+func TestContainerHeal(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerHealTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			fakeDocker := fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+				Containers: []*fakedocker.FakeContainerInitInfo{
+					{
+						Name:   "g1-c1",
+						Image:  "abc/xyz",
+						State:  tc.state,
+						Health: tc.health,
+					},
+				},
+				Networks: []*fakedocker.FakeNetworkInitInfo{
+					{
+						Name: "g1-bridge",
+					},
+					{
+						Name: "proxy-bridge",
+					},
+				},
+				ValidImagesForPull: utils.StringSet{
+					"abc/xyz": {},
+				},
+			})
+			ctx := testutils.NewTestContext(&testutils.TestContextInfo{
+				Logger:     testutils.NewCapturingTestLogger(zzzlog.LvlDebug, buf),
+				DockerHost: fakeDocker,
+			})
+			conf := buildSingleContainerConfig(reconcileTestContainer, "abc/xyz")
+			conf.Containers[0].Health.OnUnhealthy = tc.policy
+			dep, gotErr := FromConfig(ctx, &conf)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromConfig()", tc.name, gotErr)
+				return
+			}
+
+			dc := docker.NewClient(ctx)
+			defer dc.Close()
+
+			ct, gotErr := dep.queryContainer(reconcileTestContainer)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "deployment.queryContainer()", tc.name, gotErr)
+				return
+			}
+
+			id := fakeDocker.ContainerID("g1-c1")
+			state := HealState{}
+			if tc.prevState != nil {
+				prev := *tc.prevState
+				prev.ContainerID = id
+				if tc.staleID {
+					prev.ContainerID = "stale-id"
+				}
+				state["g1-c1"] = &prev
+			}
+
+			got, gotErr := ct.Heal(ctx, dc, state, healTestNow)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNilWithOutput(t, "Container.Heal()", tc.name, buf, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "Container.Heal()", tc.name, "heal result", tc.want.String(), got.String()) {
+				return
+			}
+
+			newID := fakeDocker.ContainerID("g1-c1")
+			if tc.wantNewInstance == (newID == id) {
+				testhelpers.LogCustomWithOutput(t, "Container.Heal()", tc.name, buf, "container was not recreated as expected")
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Container.Heal()", tc.name, "container restart count", tc.wantRestarts, fakeDocker.ContainerRestartCount("g1-c1")) {
+				return
+			}
+
+			want := HealState{}
+			if tc.wantState != nil {
+				wantState := *tc.wantState
+				wantState.ContainerID = newID
+				want["g1-c1"] = &wantState
+			}
+			if !testhelpers.CmpDiff(t, "Container.Heal()", tc.name, "heal state", want, state) {
+				return
+			}
+		})
+	}
+}
+
+var containerHealErrorTests = []struct {
+	name      string
+	initInfo  *fakedocker.FakeDockerHostInitInfo
+	want      string
+	wantState bool
+}{
+	{
+		name: "Container Heal - Inspect Failure",
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			FailContainerInspect: utils.StringSet{
+				"g1-c1": {},
+			},
+		},
+		want: `Failed to heal container g1-c1, reason:failed to retrieve the container state, reason: failed to inspect container g1-c1 on the fake docker host`,
+	},
+	{
+		name: "Container Heal - Restart Failure",
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			FailContainerRestart: utils.StringSet{
+				"g1-c1": {},
+			},
+		},
+		want:      `Failed to heal container g1-c1, reason:failed to restart the container, reason: failed to restart container g1-c1 on the fake docker host`,
+		wantState: true,
+	},
+}
+
+func TestContainerHealErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerHealErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			tc.initInfo.Containers = []*fakedocker.FakeContainerInitInfo{
+				{
+					Name:   "g1-c1",
+					Image:  "abc/xyz",
+					State:  docker.ContainerStateRunning,
+					Health: "unhealthy",
+				},
+			}
+			ctx := testutils.NewTestContext(&testutils.TestContextInfo{
+				Logger:     testutils.NewCapturingTestLogger(zzzlog.LvlDebug, buf),
+				DockerHost: fakedocker.NewFakeDockerHost(tc.initInfo),
+			})
+			conf := buildSingleContainerConfig(reconcileTestContainer, "abc/xyz")
+			conf.Containers[0].Health.OnUnhealthy.Action = "restart"
+			dep, gotErr := FromConfig(ctx, &conf)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromConfig()", tc.name, gotErr)
+				return
+			}
+
+			dc := docker.NewClient(ctx)
+			defer dc.Close()
+
+			ct, gotErr := dep.queryContainer(reconcileTestContainer)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "deployment.queryContainer()", tc.name, gotErr)
+				return
+			}
+
+			state := HealState{}
+			_, gotErr = ct.Heal(ctx, dc, state, healTestNow)
+			if gotErr == nil {
+				testhelpers.LogErrorNilWithOutput(t, "Container.Heal()", tc.name, buf, tc.want)
+				return
+			}
+			if !testhelpers.RegexMatchWithOutput(t, "Container.Heal()", tc.name, buf, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+
+			// The failed heal attempts count towards the backoff too.
+			_, gotState := state["g1-c1"]
+			if !testhelpers.CmpDiff(t, "Container.Heal()", tc.name, "heal state recorded", tc.wantState, gotState) {
+				return
+			}
+		})
+	}
+}
+
+func TestHealStateReadWrite(t *testing.T) {
+	t.Parallel()
+
+	tc := "Heal State - Read Write"
+	path := filepath.Join(t.TempDir(), "state", "heal.json")
+
+	got, gotErr := ReadHealState(path)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "ReadHealState()", tc, gotErr)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "ReadHealState()", tc, "missing heal state", HealState{}, got) {
+		return
+	}
+
+	want := HealState{
+		"g1-c1": {
+			ContainerID:  "abcd",
+			Attempts:     2,
+			LastAttempt:  healTestNow,
+			CrashLooping: true,
+		},
+	}
+	if gotErr := want.Write(path); gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "HealState.Write()", tc, gotErr)
+		return
+	}
+	got, gotErr = ReadHealState(path)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "ReadHealState()", tc, gotErr)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "ReadHealState()", tc, "heal state", want, got) {
+		return
+	}
+}
+
+func TestHealStateReadErrors(t *testing.T) {
+	t.Parallel()
+
+	tc := "Heal State - Invalid File"
+	path := filepath.Join(t.TempDir(), "heal.json")
+	if err := os.WriteFile(path, []byte("garbage"), 0o600); err != nil {
+		t.Fatalf("failed to write the heal state file, reason: %v", err)
+	}
+
+	_, gotErr := ReadHealState(path)
+	want := `failed to parse the heal state file .+/heal\.json, reason: invalid character 'g' looking for beginning of value`
+	if gotErr == nil {
+		testhelpers.LogErrorNil(t, "ReadHealState()", tc, want)
+		return
+	}
+	if !testhelpers.RegexMatch(t, "ReadHealState()", tc, "gotErr error string", want, gotErr.Error()) {
+		return
+	}
+}
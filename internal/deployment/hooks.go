@@ -0,0 +1,203 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tuxdudehomelab/homelab/internal/cmdexec"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/config/env"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	defaultHookTimeout = 60 * time.Second
+
+	hookEnvHook          = "HOMELAB_HOOK"
+	hookEnvGroup         = "HOMELAB_GROUP"
+	hookEnvContainer     = "HOMELAB_CONTAINER"
+	hookEnvContainerName = "HOMELAB_CONTAINER_NAME"
+	hookEnvImage         = "HOMELAB_CONTAINER_IMAGE"
+	hookEnvIP            = "HOMELAB_CONTAINER_IP"
+)
+
+// hookFailurePolicy determines how the failure of a lifecycle hook is
+// handled.
+type hookFailurePolicy uint8
+
+const (
+	// hookFailurePolicyFail fails the lifecycle operation.
+	hookFailurePolicyFail hookFailurePolicy = iota
+	// hookFailurePolicyIgnore logs the failure and carries on with the
+	// lifecycle operation.
+	hookFailurePolicyIgnore
+)
+
+func hookFailurePolicyFromString(policy string) (hookFailurePolicy, error) {
+	switch policy {
+	case "", "fail":
+		return hookFailurePolicyFail, nil
+	case "ignore":
+		return hookFailurePolicyIgnore, nil
+	default:
+		return hookFailurePolicyFail, fmt.Errorf("invalid hook failure policy string: %s", policy)
+	}
+}
+
+func hookFailurePolicyValidValues() string {
+	return "[ 'fail', 'ignore' ]"
+}
+
+// containerHook is a lifecycle hook of the container along with the
+// name it is referred to by in the logs and the env, and its timeout
+// (if any).
+type containerHook struct {
+	name    string
+	envName string
+	config  *config.ContainerHook
+	timeout time.Duration
+}
+
+func newContainerHook(name, envName string, conf *config.ContainerHook) *containerHook {
+	timeout := defaultHookTimeout
+	if len(conf.Timeout) > 0 {
+		timeout = utils.MustParseDuration(conf.Timeout)
+	}
+	return &containerHook{
+		name:    name,
+		envName: envName,
+		config:  conf,
+		timeout: timeout,
+	}
+}
+
+// startPreHook returns the start pre-hook of the container, which runs
+// on the host without any timeout.
+func (c *Container) startPreHook() *containerHook {
+	return &containerHook{
+		name:    "start pre-hook",
+		envName: "startPreHook",
+		config: &config.ContainerHook{
+			Command: c.config.Lifecycle.StartPreHook,
+		},
+	}
+}
+
+func (c *Container) startPostHook() *containerHook {
+	return newContainerHook("start post-hook", "startPostHook", &c.config.Lifecycle.StartPostHook)
+}
+
+func (c *Container) stopPreHook() *containerHook {
+	return newContainerHook("stop pre-hook", "stopPreHook", &c.config.Lifecycle.StopPreHook)
+}
+
+func (c *Container) stopPostHook() *containerHook {
+	return newContainerHook("stop post-hook", "stopPostHook", &c.config.Lifecycle.StopPostHook)
+}
+
+// runHook runs the lifecycle hook of the container if configured, either
+// on the host or within the container, and handles its failure as per
+// the failure policy of the hook.
+func (c *Container) runHook(ctx context.Context, dc *docker.Client, hook *containerHook) error {
+	if len(hook.config.Command) == 0 {
+		return nil
+	}
+	if err := c.validateHooksOnHost(ctx, hook); err != nil {
+		return err
+	}
+
+	hookCtx, cancel := context.WithCancel(ctx)
+	if hook.timeout > 0 {
+		hookCtx, cancel = context.WithTimeout(ctx, hook.timeout)
+	}
+	defer cancel()
+
+	log(ctx).Infof("Output from %s for container %s >>>", hook.name, c.Name())
+	hookEnv := c.hookEnv(hook)
+	var err error
+	if hook.config.InContainer {
+		var out string
+		out, err = dc.ExecInContainer(hookCtx, c.Name(), hook.config.Command, hookEnv)
+		log(ctx).Printf("%s", strings.TrimSpace(out))
+	} else {
+		// The output is logged while the command runs.
+		_, err = cmdexec.MustExecutor(ctx).RunWithOptions(
+			hookCtx,
+			&cmdexec.RunOptions{
+				Env:          hookEnv,
+				Dir:          c.hookWorkingDir(),
+				StreamOutput: true,
+			},
+			hook.config.Command[0],
+			hook.config.Command[1:]...)
+	}
+	if err == nil {
+		return nil
+	}
+	if errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", hook.timeout)
+	}
+
+	policy, perr := hookFailurePolicyFromString(hook.config.OnFailure)
+	if perr != nil {
+		panic(fmt.Sprintf("unable to convert hook failure policy %s setting, reason: %v, possibly indicating a bug in the code", hook.config.OnFailure, perr))
+	}
+	if policy == hookFailurePolicyIgnore {
+		log(ctx).Warnf("Ignoring - The %s for container %s failed, reason: %v", hook.name, c.Name(), err)
+		return nil
+	}
+	return fmt.Errorf("encountered error while running the %s for container %s, reason: %w", hook.name, c.Name(), err)
+}
+
+// validateHooksOnHost returns an error if any of the hooks runs on the
+// host while the container is managed on a remote host, since the hooks
+// running on the host would otherwise run on the local host instead.
+func (c *Container) validateHooksOnHost(ctx context.Context, hooks ...*containerHook) error {
+	h := host.MustHostInfo(ctx)
+	if !h.Remote {
+		return nil
+	}
+	for _, hook := range hooks {
+		if len(hook.config.Command) > 0 && !hook.config.InContainer {
+			return fmt.Errorf("%s for container %s runs on the host, which is not supported while managing the remote host %s", hook.name, c.Name(), h.HostName)
+		}
+	}
+	return nil
+}
+
+// hookEnv returns the env variables describing the container passed to
+// the lifecycle hook.
+func (c *Container) hookEnv(hook *containerHook) []string {
+	res := []string{
+		fmt.Sprintf("%s=%s", hookEnvHook, hook.envName),
+		fmt.Sprintf("%s=%s", hookEnvGroup, c.config.Info.Group),
+		fmt.Sprintf("%s=%s", hookEnvContainer, c.config.Info.Container),
+		fmt.Sprintf("%s=%s", hookEnvContainerName, c.Name()),
+		fmt.Sprintf("%s=%s", hookEnvImage, c.imageReference()),
+	}
+	if len(c.endpoints) > 0 && len(c.endpoints[0].ip) > 0 {
+		res = append(res, fmt.Sprintf("%s=%s", hookEnvIP, c.endpoints[0].ip))
+	}
+	return res
+}
+
+// hookWorkingDir returns the working directory of the hooks run on the
+// host, i.e. the CONTAINER_SCRIPTS_DIR of the container if it exists,
+// or else the working directory of the homelab process.
+func (c *Container) hookWorkingDir() string {
+	dir, err := filepath.Abs(env.ContainerScriptsDir(containerBaseDir(c.globalConfig.BaseDir, c.config.Info)))
+	if err != nil {
+		return ""
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return dir
+}
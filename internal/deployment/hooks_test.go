@@ -0,0 +1,453 @@
+package deployment
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/cmdexec/fakecmdexec"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+)
+
+var hookTestEnv = []string{
+	"HOMELAB_GROUP=g1",
+	"HOMELAB_CONTAINER=c1",
+	"HOMELAB_CONTAINER_NAME=g1-c1",
+	"HOMELAB_CONTAINER_IMAGE=abc/xyz",
+	"HOMELAB_CONTAINER_IP=172.18.101.11",
+}
+
+func hookTestEnvFor(hook string) []string {
+	return append([]string{fmt.Sprintf("HOMELAB_HOOK=%s", hook)}, hookTestEnv...)
+}
+
+func hookTestRunningContainer() []*fakedocker.FakeContainerInitInfo {
+	return []*fakedocker.FakeContainerInitInfo{
+		{
+			Name:  "g1-c1",
+			Image: "abc/xyz",
+			State: docker.ContainerStateRunning,
+		},
+	}
+}
+
+var containerHookTests = []struct {
+	name      string
+	lifecycle config.ContainerLifecycle
+	stop      bool
+	execInfo  *fakecmdexec.FakeExecutorInitInfo
+	initInfo  *fakedocker.FakeDockerHostInitInfo
+	// scriptsDir creates the CONTAINER_SCRIPTS_DIR of the container, the
+	// working directory of the hooks run on the host.
+	scriptsDir bool
+	want       string
+	wantExecs  []*fakedocker.FakeExecutedCmd
+	wantRuns   []*fakecmdexec.FakeRun
+}{
+	{
+		name: "Container Hooks - Start Post-Hook On Host",
+		lifecycle: config.ContainerLifecycle{
+			StartPostHook: config.ContainerHook{
+				Command: []string{
+					"warmup",
+					"--all",
+				},
+			},
+		},
+		execInfo: &fakecmdexec.FakeExecutorInitInfo{
+			ValidCmds: []fakecmdexec.FakeValidCmdInfo{
+				{
+					Cmd: []string{
+						"warmup",
+						"--all",
+					},
+					Output: "Warmed up the cache\nWarmed up the index\n",
+				},
+			},
+		},
+		initInfo:   &fakedocker.FakeDockerHostInitInfo{},
+		scriptsDir: true,
+		want: `Starting container g1-c1
+Output from start post-hook for container g1-c1 >>>
+Warmed up the cache
+Warmed up the index`,
+		wantRuns: []*fakecmdexec.FakeRun{
+			{
+				Cmd: []string{
+					"warmup",
+					"--all",
+				},
+				Env: hookTestEnvFor("startPostHook"),
+				Dir: "g1/c1/scripts",
+			},
+		},
+	},
+	{
+		name: "Container Hooks - Start Post-Hook In Container",
+		lifecycle: config.ContainerLifecycle{
+			StartPostHook: config.ContainerHook{
+				Command: []string{
+					"warmup",
+				},
+				InContainer: true,
+			},
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			ContainerExecs: map[string][]*fakedocker.FakeContainerExec{
+				"g1-c1": {
+					{
+						Cmd: []string{
+							"warmup",
+						},
+						Output: "Warmed up the cache from within\n",
+					},
+				},
+			},
+		},
+		want: `Starting container g1-c1
+Output from start post-hook for container g1-c1 >>>
+Warmed up the cache from within`,
+		wantExecs: []*fakedocker.FakeExecutedCmd{
+			{
+				Cmd: []string{
+					"warmup",
+				},
+				Env: hookTestEnvFor("startPostHook"),
+			},
+		},
+	},
+	{
+		name: "Container Hooks - Start Post-Hook Failure Ignored",
+		lifecycle: config.ContainerLifecycle{
+			StartPostHook: config.ContainerHook{
+				Command: []string{
+					"warmup",
+				},
+				InContainer: true,
+				OnFailure:   "ignore",
+			},
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{},
+		want: `Output from start post-hook for container g1-c1 >>>
+warmup: command not found
+Ignoring - The start post-hook for container g1-c1 failed, reason: command \["warmup"\] in the container g1-c1 exited with code 127`,
+		wantExecs: []*fakedocker.FakeExecutedCmd{
+			{
+				Cmd: []string{
+					"warmup",
+				},
+				Env: hookTestEnvFor("startPostHook"),
+			},
+		},
+	},
+	{
+		name: "Container Hooks - Stop Pre-Hook In Container And Stop Post-Hook On Host",
+		lifecycle: config.ContainerLifecycle{
+			StopPreHook: config.ContainerHook{
+				Command: []string{
+					"pg_dumpall",
+					"-f",
+					"/backup/dump.sql",
+				},
+				InContainer: true,
+				Timeout:     "5m",
+			},
+			StopPostHook: config.ContainerHook{
+				Command: []string{
+					"notify",
+				},
+			},
+		},
+		stop: true,
+		execInfo: &fakecmdexec.FakeExecutorInitInfo{
+			ValidCmds: []fakecmdexec.FakeValidCmdInfo{
+				{
+					Cmd: []string{
+						"notify",
+					},
+					Output: "Notified",
+				},
+			},
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			Containers: hookTestRunningContainer(),
+			ContainerExecs: map[string][]*fakedocker.FakeContainerExec{
+				"g1-c1": {
+					{
+						Cmd: []string{
+							"pg_dumpall",
+							"-f",
+							"/backup/dump.sql",
+						},
+						Output: "Dumped the databases",
+					},
+				},
+			},
+		},
+		want: `Output from stop pre-hook for container g1-c1 >>>
+Dumped the databases
+Stopping container g1-c1
+Output from stop post-hook for container g1-c1 >>>
+Notified`,
+		wantRuns: []*fakecmdexec.FakeRun{
+			{
+				Cmd: []string{
+					"notify",
+				},
+				Env: hookTestEnvFor("stopPostHook"),
+			},
+		},
+		wantExecs: []*fakedocker.FakeExecutedCmd{
+			{
+				Cmd: []string{
+					"pg_dumpall",
+					"-f",
+					"/backup/dump.sql",
+				},
+				Env: hookTestEnvFor("stopPreHook"),
+			},
+		},
+	},
+}
+
+func TestContainerHooks(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerHookTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			baseDir := testhelpers.HomelabBaseDir()
+			wantRuns := tc.wantRuns
+			if tc.scriptsDir {
+				baseDir = t.TempDir()
+				if err := os.MkdirAll(filepath.Join(baseDir, "g1", "c1", "scripts"), 0o750); err != nil {
+					t.Fatalf("failed to create the scripts dir, reason: %v", err)
+				}
+				wantRuns = nil
+				for _, r := range tc.wantRuns {
+					run := *r
+					run.Dir = filepath.Join(baseDir, r.Dir)
+					wantRuns = append(wantRuns, &run)
+				}
+			}
+
+			buf := new(bytes.Buffer)
+			_, ct, dc, ctx := newSingleTestContainer(t, tc.name, waitTestContainer, hookTestContextInfo(tc.execInfo, buf), tc.initInfo, hookTestConfig(baseDir, tc.lifecycle))
+			if ct == nil {
+				return
+			}
+			defer dc.Close()
+
+			var gotErr error
+			if tc.stop {
+				_, gotErr = ct.Stop(ctx, dc)
+			} else {
+				_, gotErr = ct.Start(ctx, dc)
+			}
+			if gotErr != nil {
+				testhelpers.LogErrorNotNilWithOutput(t, "Container.Start/Stop()", tc.name, buf, gotErr)
+				return
+			}
+			want := fmt.Sprintf(`(?s).*%s.*`, tc.want)
+			if !testhelpers.RegexMatch(t, "Container.Start/Stop()", tc.name, "log output", want, buf.String()) {
+				return
+			}
+
+			gotExecs := fakedocker.FakeDockerHostFromContext(ctx).ExecutedCmds("g1-c1")
+			if !testhelpers.CmpDiff(t, "Container.Start/Stop()", tc.name, "executed commands", tc.wantExecs, gotExecs) {
+				return
+			}
+
+			gotRuns := fakecmdexec.FakeExecutorFromContext(ctx).Runs()
+			if !testhelpers.CmpDiff(t, "Container.Start/Stop()", tc.name, "executor runs", wantRuns, gotRuns) {
+				return
+			}
+		})
+	}
+}
+
+var containerHookErrorTests = []struct {
+	name      string
+	lifecycle config.ContainerLifecycle
+	stop      bool
+	remote    bool
+	execInfo  *fakecmdexec.FakeExecutorInitInfo
+	initInfo  *fakedocker.FakeDockerHostInitInfo
+	want      string
+}{
+	{
+		name: "Container Hooks - Start Post-Hook On Host Failure",
+		lifecycle: config.ContainerLifecycle{
+			StartPostHook: config.ContainerHook{
+				Command: []string{
+					"warmup",
+				},
+			},
+		},
+		execInfo: &fakecmdexec.FakeExecutorInitInfo{
+			ErrorCmds: []fakecmdexec.FakeErrorCmdInfo{
+				{
+					Cmd: []string{
+						"warmup",
+					},
+					Err: fmt.Errorf("warmup command not found"),
+				},
+			},
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{},
+		want:     `Failed to start container g1-c1, reason:encountered error while running the start post-hook for container g1-c1, reason: warmup command not found`,
+	},
+	{
+		name: "Container Hooks - Stop Pre-Hook On Host Timeout",
+		lifecycle: config.ContainerLifecycle{
+			StopPreHook: config.ContainerHook{
+				Command: []string{
+					"pg_dumpall",
+				},
+				Timeout: "50ms",
+			},
+		},
+		stop: true,
+		execInfo: &fakecmdexec.FakeExecutorInitInfo{
+			ValidCmds: []fakecmdexec.FakeValidCmdInfo{
+				{
+					Cmd: []string{
+						"pg_dumpall",
+					},
+					Output: "Dumped the databases",
+					Delay:  time.Minute,
+				},
+			},
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			Containers: hookTestRunningContainer(),
+		},
+		want: `Failed to stop container g1-c1, reason:encountered error while running the stop pre-hook for container g1-c1, reason: timed out after 50ms`,
+	},
+	{
+		name: "Container Hooks - Stop Pre-Hook In Container Failure",
+		lifecycle: config.ContainerLifecycle{
+			StopPreHook: config.ContainerHook{
+				Command: []string{
+					"pg_dumpall",
+				},
+				InContainer: true,
+			},
+		},
+		stop: true,
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			Containers: hookTestRunningContainer(),
+			ContainerExecs: map[string][]*fakedocker.FakeContainerExec{
+				"g1-c1": {
+					{
+						Cmd: []string{
+							"pg_dumpall",
+						},
+						Output:   "pg_dumpall: connection refused",
+						ExitCode: 1,
+					},
+				},
+			},
+		},
+		want: `Failed to stop container g1-c1, reason:encountered error while running the stop pre-hook for container g1-c1, reason: command \["pg_dumpall"\] in the container g1-c1 exited with code 1`,
+	},
+	{
+		name: "Container Hooks - Start Pre-Hook On Remote Host",
+		lifecycle: config.ContainerLifecycle{
+			StartPreHook: []string{
+				"warmup",
+			},
+		},
+		remote:   true,
+		initInfo: &fakedocker.FakeDockerHostInitInfo{},
+		want:     `Failed to start container g1-c1, reason:start pre-hook for container g1-c1 runs on the host, which is not supported while managing the remote host fakehost`,
+	},
+	{
+		name: "Container Hooks - Stop Post-Hook On Remote Host",
+		lifecycle: config.ContainerLifecycle{
+			StopPostHook: config.ContainerHook{
+				Command: []string{
+					"cleanup",
+				},
+			},
+		},
+		stop:   true,
+		remote: true,
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			Containers: hookTestRunningContainer(),
+		},
+		want: `Failed to stop container g1-c1, reason:stop post-hook for container g1-c1 runs on the host, which is not supported while managing the remote host fakehost`,
+	},
+}
+
+func TestContainerHooksErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerHookErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			_, ct, dc, ctx := newSingleTestContainer(t, tc.name, waitTestContainer, hookTestContextInfo(tc.execInfo, buf), tc.initInfo, hookTestConfig(testhelpers.HomelabBaseDir(), tc.lifecycle))
+			if ct == nil {
+				return
+			}
+			defer dc.Close()
+			if tc.remote {
+				h := *host.MustHostInfo(ctx)
+				h.Remote = true
+				ctx = host.WithHostInfo(ctx, &h)
+			}
+
+			var gotErr error
+			if tc.stop {
+				_, gotErr = ct.Stop(ctx, dc)
+			} else {
+				_, gotErr = ct.Start(ctx, dc)
+			}
+			if gotErr == nil {
+				testhelpers.LogErrorNilWithOutput(t, "Container.Start/Stop()", tc.name, buf, tc.want)
+				return
+			}
+			if !testhelpers.RegexMatchWithOutput(t, "Container.Start/Stop()", tc.name, buf, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+			if tc.remote && tc.stop {
+				testhelpers.CmpDiff(t, "Container.Stop()", tc.name, "container state", docker.ContainerStateRunning, fakedocker.FakeDockerHostFromContext(ctx).GetContainerState(ct.Name()))
+			}
+		})
+	}
+}
+
+func hookTestContextInfo(execInfo *fakecmdexec.FakeExecutorInitInfo, buf *bytes.Buffer) *testutils.TestContextInfo {
+	ctxInfo := &testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+	}
+	if execInfo != nil {
+		ctxInfo.Executor = fakecmdexec.NewFakeExecutor(execInfo)
+	}
+	return ctxInfo
+}
+
+// hookTestConfig returns the update for the single container config using
+// the base directory and the lifecycle with the hooks, retaining the start
+// order of the container.
+func hookTestConfig(baseDir string, lifecycle config.ContainerLifecycle) func(*config.Homelab) {
+	return func(conf *config.Homelab) {
+		conf.Global.BaseDir = baseDir
+		lifecycle.Order = conf.Containers[0].Lifecycle.Order
+		conf.Containers[0].Lifecycle = lifecycle
+	}
+}
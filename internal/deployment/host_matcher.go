@@ -0,0 +1,104 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+)
+
+type hostMatcher struct {
+	host       *host.HostInfo
+	hostGroups map[string][]string
+}
+
+func newHostMatcher(h *host.HostInfo) *hostMatcher {
+	return &hostMatcher{
+		host:       h,
+		hostGroups: make(map[string][]string),
+	}
+}
+
+func (m *hostMatcher) matches(sel *config.HostSelector) bool {
+	if len(sel.Name) > 0 && !matchesHostNamePattern(sel.Name, m.host.HostName) {
+		return false
+	}
+	if len(sel.HostGroup) > 0 && !m.inHostGroup(sel.HostGroup) {
+		return false
+	}
+	if len(sel.Arch) > 0 && sel.Arch != m.host.Arch {
+		return false
+	}
+	return true
+}
+
+func (m *hostMatcher) matchesAny(sels []config.HostSelector) bool {
+	for i := range sels {
+		if m.matches(&sels[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *hostMatcher) inHostGroup(group string) bool {
+	for _, pattern := range m.hostGroups[group] {
+		if matchesHostNamePattern(pattern, m.host.HostName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *hostMatcher) validateSelector(sel *config.HostSelector, location string) error {
+	if len(sel.Name) == 0 && len(sel.HostGroup) == 0 && len(sel.Arch) == 0 {
+		return fmt.Errorf("host selector must specify at least one of name, hostGroup or arch in %s", location)
+	}
+	if err := validateHostNamePattern(sel.Name, location); err != nil {
+		return err
+	}
+	if len(sel.HostGroup) > 0 {
+		if _, found := m.hostGroups[sel.HostGroup]; !found {
+			return fmt.Errorf("host group %s not found in %s", sel.HostGroup, location)
+		}
+	}
+	if len(sel.Arch) > 0 && !host.IsSupportedArch(sel.Arch) {
+		return fmt.Errorf("unsupported host arch %s in %s", sel.Arch, location)
+	}
+	return nil
+}
+
+// withSelectedHostIP returns a context with the host info updated using
+// the host IP selector from the last host config applicable to the host
+// which specifies one.
+func withSelectedHostIP(ctx context.Context, hostConfigs []*config.Host) (context.Context, error) {
+	for i := len(hostConfigs) - 1; i >= 0; i-- {
+		sel := &hostConfigs[i].HostIP
+		if sel.IsEmpty() {
+			continue
+		}
+		h, err := host.MustHostInfo(ctx).WithSelectedIP(ctx, sel)
+		if err != nil {
+			sel := config.HostSelector{Name: hostConfigs[i].Name, HostGroup: hostConfigs[i].HostGroup, Arch: hostConfigs[i].Arch}
+			return nil, fmt.Errorf("failed to select the host IP using host %s config, reason: %w", hostSelectorName(&sel), err)
+		}
+		return host.WithHostInfo(ctx, h), nil
+	}
+	return ctx, nil
+}
+
+func validateHostNamePattern(pattern string, location string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid host name pattern %s in %s, reason: %w", pattern, location, err)
+	}
+	return nil
+}
+
+func matchesHostNamePattern(pattern string, hostName string) bool {
+	// The pattern has already been validated, hence the error can
+	// safely be ignored here.
+	matched, _ := path.Match(pattern, hostName)
+	return matched
+}
@@ -0,0 +1,181 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+)
+
+const (
+	// ImageLockFileName is the name of the image lock file within the
+	// configs directory.
+	ImageLockFileName = "homelab.lock"
+)
+
+var (
+	imageLockKey = ctxKeyImageLock{}
+)
+
+type ctxKeyImageLock struct{}
+
+// ImageLock pins the images of the containers and the jobs to their
+// digests, keyed by the image names as specified in the config.
+type ImageLock struct {
+	Images map[string]string `json:"images"`
+}
+
+// ReadImageLock reads the image lock from the file, and returns an empty
+// lock if the file doesn't exist.
+func ReadImageLock(path string) (*ImageLock, error) {
+	lock := &ImageLock{}
+	if err := readStateFile(path, "image lock", lock); err != nil {
+		return nil, err
+	}
+	if lock.Images == nil {
+		lock.Images = map[string]string{}
+	}
+	return lock, nil
+}
+
+// Write writes the image lock to the file, replacing it atomically.
+func (l *ImageLock) Write(path string) error {
+	return writeStateFile(path, "image lock", l)
+}
+
+// WithImageLock returns a context with the image lock, which pins the
+// images of the containers and the jobs in the deployments built using
+// the context.
+func WithImageLock(ctx context.Context, lock *ImageLock) context.Context {
+	return context.WithValue(ctx, imageLockKey, lock)
+}
+
+func imageLockFromContext(ctx context.Context) (*ImageLock, bool) {
+	lock, ok := ctx.Value(imageLockKey).(*ImageLock)
+	return lock, ok && lock != nil
+}
+
+// LockImages resolves the digests of the images of all the containers and
+// the jobs irrespective of the host they are allowed on, which are not
+// yet in the lock. The entries for the images no longer used are removed
+// from the lock.
+func (d *Deployment) LockImages(ctx context.Context, dc *docker.Client, lock *ImageLock) error {
+	images := d.lockableImages()
+	for _, img := range images {
+		if _, found := lock.Images[img]; found {
+			continue
+		}
+		digest, err := dc.ImageDigest(ctx, img)
+		if err != nil {
+			return err
+		}
+		lock.Images[img] = digest
+		log(ctx).Infof("Locked image %s to %s", img, digest)
+	}
+
+	for img := range lock.Images {
+		if !slices.Contains(images, img) {
+			log(ctx).Infof("Removing unused image %s from the lock", img)
+			delete(lock.Images, img)
+		}
+	}
+	return nil
+}
+
+// UpdateImageLock resolves the digests of the images again, updating the
+// lock. Only the image of the specified container or job is updated if
+// the container reference is not nil.
+func (d *Deployment) UpdateImageLock(ctx context.Context, dc *docker.Client, lock *ImageLock, cRef *config.ContainerReference) error {
+	images := d.lockableImages()
+	if cRef != nil {
+		ct, err := d.queryContainerOrJob(*cRef)
+		if err != nil {
+			return err
+		}
+		if !ct.isImageLockable() {
+			return fmt.Errorf("image %s of the container %s cannot be locked", ct.config.Image.Image, ct.Name())
+		}
+		images = []string{ct.config.Image.Image}
+	}
+
+	for _, img := range images {
+		digest, err := dc.ImageDigest(ctx, img)
+		if err != nil {
+			return err
+		}
+		prev, found := lock.Images[img]
+		lock.Images[img] = digest
+		switch {
+		case !found:
+			log(ctx).Infof("Locked image %s to %s", img, digest)
+		case prev != digest:
+			log(ctx).Infof("Updated image %s from %s to %s", img, prev, digest)
+		default:
+			log(ctx).Infof("Image %s is already up to date at %s", img, digest)
+		}
+	}
+	return nil
+}
+
+// applyImageLock pins the images of the containers and the jobs to the
+// digests in the image lock from the context, if any.
+func (d *Deployment) applyImageLock(ctx context.Context) {
+	lock, found := imageLockFromContext(ctx)
+	if !found {
+		return
+	}
+	for _, ct := range d.lockableContainers() {
+		ct.imageDigest = lock.Images[ct.config.Image.Image]
+	}
+}
+
+// lockableContainers returns the containers and the job containers whose
+// images can be pinned to a digest.
+func (d *Deployment) lockableContainers() ContainerList {
+	var res ContainerList
+	for _, ct := range d.queryAllContainers() {
+		if ct.isImageLockable() {
+			res = append(res, ct)
+		}
+	}
+	for _, j := range d.jobs {
+		if j.container.isImageLockable() {
+			res = append(res, j.container)
+		}
+	}
+	return res
+}
+
+// lockableImages returns the sorted unique images which can be pinned to
+// a digest.
+func (d *Deployment) lockableImages() []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, ct := range d.lockableContainers() {
+		img := ct.config.Image.Image
+		if !seen[img] {
+			seen[img] = true
+			res = append(res, img)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (d *Deployment) queryContainerOrJob(cRef config.ContainerReference) (*Container, error) {
+	if j, found := d.jobs[cRef]; found {
+		return j.container, nil
+	}
+	return d.queryContainer(cRef)
+}
+
+// isImageLockable returns whether the image can be pinned to a digest.
+// The images which are not pulled, and the ones already referring to a
+// digest are left as is.
+func (c *Container) isImageLockable() bool {
+	return !c.config.Image.SkipImagePull && !strings.Contains(c.config.Image.Image, "@")
+}
@@ -0,0 +1,231 @@
+package deployment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	imageLockTestImage   = "registry.local:5000/abc/xyz:1.0"
+	imageLockTestDigest1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	imageLockTestDigest2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+var imageLockTestRef = config.ContainerReference{
+	Group:     "g1",
+	Container: "c1",
+}
+
+func TestImageLockReadWrite(t *testing.T) {
+	t.Parallel()
+
+	tc := "Image Lock - Read Write"
+	path := filepath.Join(t.TempDir(), "configs", ImageLockFileName)
+
+	got, err := ReadImageLock(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadImageLock()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "ReadImageLock()", tc, "missing lock", &ImageLock{Images: map[string]string{}}, got) {
+		return
+	}
+
+	want := &ImageLock{
+		Images: map[string]string{
+			imageLockTestImage: imageLockTestDigest1,
+		},
+	}
+	if err := want.Write(path); err != nil {
+		testhelpers.LogErrorNotNil(t, "ImageLock.Write()", tc, err)
+		return
+	}
+	got, err = ReadImageLock(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadImageLock()", tc, err)
+		return
+	}
+	testhelpers.CmpDiff(t, "ReadImageLock()", tc, "lock", want, got)
+}
+
+func TestDeploymentLockAndUpdateImages(t *testing.T) {
+	t.Parallel()
+
+	tc := "Image Lock - Lock And Update"
+	buf := new(bytes.Buffer)
+	dep, dc, ctx := newImageLockTestDeployment(t, tc, nil, buf)
+	if dep == nil {
+		return
+	}
+	defer dc.Close()
+
+	lock := &ImageLock{
+		Images: map[string]string{
+			"unused/image:latest": imageLockTestDigest2,
+		},
+	}
+	if err := dep.LockImages(ctx, dc, lock); err != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Deployment.LockImages()", tc, buf, err)
+		return
+	}
+	want := map[string]string{
+		imageLockTestImage: imageLockTestDigest1,
+	}
+	if !testhelpers.CmpDiff(t, "Deployment.LockImages()", tc, "locked images", want, lock.Images) {
+		return
+	}
+
+	// Locking again leaves the locked images untouched even if the
+	// registry has moved on.
+	fakedocker.FakeDockerHostFromContext(ctx).SetImageDigest(imageLockTestImage, imageLockTestDigest2)
+	if err := dep.LockImages(ctx, dc, lock); err != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Deployment.LockImages()", tc, buf, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "Deployment.LockImages()", tc, "relocked images", want, lock.Images) {
+		return
+	}
+
+	if err := dep.UpdateImageLock(ctx, dc, lock, &imageLockTestRef); err != nil {
+		testhelpers.LogErrorNotNilWithOutput(t, "Deployment.UpdateImageLock()", tc, buf, err)
+		return
+	}
+	want[imageLockTestImage] = imageLockTestDigest2
+	if !testhelpers.CmpDiff(t, "Deployment.UpdateImageLock()", tc, "updated images", want, lock.Images) {
+		return
+	}
+
+	wantOutput := fmt.Sprintf(`(?s).*Locked image %s to %s.*Removing unused image unused/image:latest from the lock.*Updated image %s from %s to %s.*`, imageLockTestImage, imageLockTestDigest1, imageLockTestImage, imageLockTestDigest1, imageLockTestDigest2)
+	testhelpers.RegexMatch(t, "Deployment.UpdateImageLock()", tc, "log output", wantOutput, buf.String())
+}
+
+var imageLockErrorTests = []struct {
+	name    string
+	digests map[string]string
+	ref     *config.ContainerReference
+	want    string
+}{
+	{
+		name:    "Image Lock - Image Not In Registry",
+		digests: map[string]string{},
+		want:    `failed to resolve the digest of the image registry\.local:5000/abc/xyz:1\.0, reason: image registry\.local:5000/abc/xyz:1\.0 not found in the registry on the fake docker host`,
+	},
+	{
+		name: "Image Lock - Update Unknown Container",
+		ref: &config.ContainerReference{
+			Group:     "g1",
+			Container: "c2",
+		},
+		want: `container \{g1 c2\} not found`,
+	},
+	{
+		name: "Image Lock - Update Unknown Group",
+		ref: &config.ContainerReference{
+			Group:     "g2",
+			Container: "c1",
+		},
+		want: `group g2 not found`,
+	},
+}
+
+func TestDeploymentUpdateImageLockErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range imageLockErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			dep, dc, ctx := newImageLockTestDeployment(t, tc.name, tc.digests, buf)
+			if dep == nil {
+				return
+			}
+			defer dc.Close()
+
+			gotErr := dep.UpdateImageLock(ctx, dc, &ImageLock{Images: map[string]string{}}, tc.ref)
+			if gotErr == nil {
+				testhelpers.LogErrorNilWithOutput(t, "Deployment.UpdateImageLock()", tc.name, buf, tc.want)
+				return
+			}
+			testhelpers.RegexMatchWithOutput(t, "Deployment.UpdateImageLock()", tc.name, buf, "gotErr error string", tc.want, gotErr.Error())
+		})
+	}
+}
+
+func TestContainerStartWithImageLock(t *testing.T) {
+	t.Parallel()
+
+	tc := "Image Lock - Container Start"
+	pinned := fmt.Sprintf("registry.local:5000/abc/xyz@%s", imageLockTestDigest1)
+	lock := &ImageLock{
+		Images: map[string]string{
+			imageLockTestImage: imageLockTestDigest1,
+		},
+	}
+	ctx := testutils.NewTestContext(&testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, new(bytes.Buffer)),
+		DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+			// Only the pinned image can be pulled, failing the start if
+			// the tag was used instead.
+			ValidImagesForPull: utils.StringSet{
+				pinned: {},
+			},
+		}),
+	})
+	ctx = WithImageLock(ctx, lock)
+	conf := buildSingleContainerConfig(imageLockTestRef, imageLockTestImage)
+	dep, err := FromConfig(ctx, &conf)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "FromConfig()", tc, err)
+		return
+	}
+	ct, err := dep.queryContainer(imageLockTestRef)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "Deployment.queryContainer()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "Container.imageReference()", tc, "image reference", pinned, ct.imageReference()) {
+		return
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+	if _, err := ct.Start(ctx, dc); err != nil {
+		testhelpers.LogErrorNotNil(t, "Container.Start()", tc, err)
+	}
+}
+
+func newImageLockTestDeployment(t *testing.T, tc string, digests map[string]string, buf *bytes.Buffer) (*Deployment, *docker.Client, context.Context) {
+	t.Helper()
+
+	if digests == nil {
+		digests = map[string]string{
+			imageLockTestImage: imageLockTestDigest1,
+		}
+	}
+	ctx := testutils.NewTestContext(&testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+		DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+			ImageDigests: digests,
+		}),
+	})
+	conf := buildSingleContainerConfig(imageLockTestRef, imageLockTestImage)
+	dep, err := FromConfig(ctx, &conf)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "FromConfig()", tc, err)
+		return nil, nil, nil
+	}
+	return dep, docker.NewClient(ctx), ctx
+}
@@ -0,0 +1,328 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	defaultJobTimeout   = time.Hour
+	defaultJobRetention = 10
+	// Only the last lines of the job container logs are retained.
+	jobLogsTailLines = 100
+	// The job container is removed within this time even if the job was
+	// interrupted.
+	jobPurgeTimeout = 2 * time.Minute
+)
+
+// JobRunStatus is the outcome of a job run.
+type JobRunStatus string
+
+const (
+	// JobRunStatusSucceeded indicates the job container exited with a
+	// zero exit code.
+	JobRunStatusSucceeded JobRunStatus = "succeeded"
+	// JobRunStatusFailed indicates the job container could not be run,
+	// or exited with a non-zero exit code.
+	JobRunStatusFailed JobRunStatus = "failed"
+	// JobRunStatusTimedOut indicates the job container was killed since
+	// it didn't exit within the timeout.
+	JobRunStatusTimedOut JobRunStatus = "timedOut"
+	// JobRunStatusRunning indicates the job was claimed using
+	// ClaimDueJob and is still running. It is replaced by the result of
+	// the run once recorded.
+	JobRunStatusRunning JobRunStatus = "running"
+)
+
+// Job is a one-shot container run on demand or on a schedule, which is
+// removed once it exits.
+type Job struct {
+	config    *config.Job
+	container *Container
+	schedule  *jobSchedule
+	timeout   time.Duration
+	retention int
+	// Name of the host the job is run on.
+	hostName string
+	// Time the job was claimed at using ClaimDueJob, if any.
+	claimedAt time.Time
+}
+
+type JobList []*Job
+type jobMap map[config.ContainerReference]*Job
+
+func newJob(conf *config.Job, container *Container, hostName string) *Job {
+	j := &Job{
+		config:    conf,
+		container: container,
+		timeout:   defaultJobTimeout,
+		retention: defaultJobRetention,
+		hostName:  hostName,
+	}
+	if len(conf.Schedule) > 0 {
+		s, err := parseJobSchedule(conf.Schedule)
+		if err != nil {
+			panic(fmt.Sprintf("unable to parse the job schedule %s, reason: %v, possibly indicating a bug in the code", conf.Schedule, err))
+		}
+		j.schedule = s
+	}
+	if len(conf.Timeout) > 0 {
+		j.timeout = utils.MustParseDuration(conf.Timeout)
+	}
+	if conf.Retention > 0 {
+		j.retention = conf.Retention
+	}
+	return j
+}
+
+// Name returns the name of the job container.
+func (j *Job) Name() string {
+	return j.container.Name()
+}
+
+// IsAllowedOnCurrentHost returns whether the job is allowed to run on
+// the current host.
+func (j *Job) IsAllowedOnCurrentHost() bool {
+	return j.container.isAllowedOnCurrentHost()
+}
+
+// IsDue returns whether the scheduled job is due to run at the specified
+// time as per the runs recorded in the state. The jobs without a
+// schedule are never due, while the scheduled jobs that never ran are
+// due right away. The missed runs are caught up with a single run.
+func (j *Job) IsDue(state JobsState, now time.Time) bool {
+	if j.schedule == nil {
+		return false
+	}
+	last := state.lastRun(j)
+	if last == nil {
+		return true
+	}
+	next := j.schedule.next(last.StartedAt.In(now.Location()))
+	return !next.IsZero() && !next.After(now)
+}
+
+// Run runs the job container until it exits or times out, and returns
+// the result to be recorded using RecordJobRun. The job container is
+// removed once it exits, after collecting its logs. An error is returned
+// unless the job succeeded.
+func (j *Job) Run(ctx context.Context, dc *docker.Client) (*JobRunResult, error) {
+	name := j.Name()
+	if !j.IsAllowedOnCurrentHost() {
+		return nil, utils.LogToErrorAndReturn(ctx, "Failed to run job %s, reason:job is not allowed to run on the current host", name)
+	}
+
+	log(ctx).Infof("Running job %s", name)
+	res := &JobRunResult{StartedAt: time.Now()}
+	err := j.run(ctx, dc, res)
+	res.FinishedAt = time.Now()
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	if err != nil {
+		return res, utils.LogToErrorAndReturn(ctx, "Failed to run job %s, reason:%v", name, err)
+	}
+	log(ctx).Infof("Job %s succeeded in %s", name, res.FinishedAt.Sub(res.StartedAt).Round(time.Millisecond))
+	log(ctx).InfoEmpty()
+	return res, nil
+}
+
+func (j *Job) run(ctx context.Context, dc *docker.Client, res *JobRunResult) error {
+	res.Status = JobRunStatusFailed
+	c := j.container
+	name := c.Name()
+
+	// Remove the job container irrespective of the outcome, while
+	// retaining the error which failed the job. The container is removed
+	// even if the context was cancelled, for instance when the job is
+	// interrupted.
+	purge := func(err error) error {
+		purgeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobPurgeTimeout)
+		defer cancel()
+		if _, perr := c.purgeInternal(purgeCtx, dc); perr != nil && err == nil {
+			return perr
+		}
+		return err
+	}
+
+	if err := c.startInternal(ctx, dc); err != nil {
+		return purge(err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+	exitCode, err := dc.WaitContainerExit(waitCtx, name)
+	if err != nil {
+		if !errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+			return purge(err)
+		}
+		res.Status = JobRunStatusTimedOut
+		log(ctx).Warnf("Killing job %s since it didn't exit within %s", name, j.timeout)
+		if err := dc.KillContainer(ctx, name); err != nil {
+			return purge(err)
+		}
+		if exitCode, err = dc.WaitContainerExit(ctx, name); err != nil {
+			return purge(err)
+		}
+	}
+	res.ExitCode = int(exitCode)
+
+	logs, err := dc.GetContainerLogs(ctx, name, jobLogsTailLines)
+	if err != nil {
+		return purge(err)
+	}
+	res.Logs = logs
+	log(ctx).Infof("Output from job %s >>>", name)
+	log(ctx).Printf("%s", strings.TrimSpace(logs))
+
+	if err := purge(nil); err != nil {
+		return err
+	}
+	switch {
+	case res.Status == JobRunStatusTimedOut:
+		return fmt.Errorf("timed out after %s", j.timeout)
+	case exitCode != 0:
+		return fmt.Errorf("exited with code %d", exitCode)
+	}
+	res.Status = JobRunStatusSucceeded
+	return nil
+}
+
+func (j *Job) String() string {
+	return fmt.Sprintf("Job{Name:%s}", j.Name())
+}
+
+// JobRunResult is the outcome of a job run.
+type JobRunResult struct {
+	StartedAt  time.Time    `json:"startedAt"`
+	FinishedAt time.Time    `json:"finishedAt"`
+	Status     JobRunStatus `json:"status"`
+	ExitCode   int          `json:"exitCode"`
+	Logs       string       `json:"logs,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// JobsState tracks the most recent runs of the jobs keyed by the host
+// and the job name (i.e. <host>/<job>), and is retained across the
+// invocations to determine the jobs due to run. The state file can hence
+// be shared by the invocations managing different hosts.
+type JobsState map[string]*JobState
+
+// JobState tracks the most recent runs of a job, oldest first.
+type JobState struct {
+	Runs []*JobRunResult `json:"runs"`
+}
+
+// ReadJobsState reads the jobs state from the file, and returns an empty
+// state if the file doesn't exist.
+func ReadJobsState(path string) (JobsState, error) {
+	state := JobsState{}
+	if err := readStateFile(path, "jobs", &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Write writes the jobs state to the file, replacing it atomically.
+func (s JobsState) Write(path string) error {
+	return writeStateFile(path, "jobs", s)
+}
+
+// RecordJobRun records the result of the job run in the jobs state file,
+// retaining only the most recent runs of the job. The state is read again
+// while holding a lock on the file, so that the runs recorded by the other
+// invocations sharing the file are retained. The running entry recorded
+// while claiming the job using ClaimDueJob, if any, is replaced.
+func RecordJobRun(path string, j *Job, res *JobRunResult) error {
+	unlock, err := lockStateFile(path, "jobs")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := ReadJobsState(path)
+	if err != nil {
+		return err
+	}
+	state.record(j, res)
+	return state.Write(path)
+}
+
+// ClaimDueJob claims the job to be run if it is due at the specified time,
+// by recording a running entry in the jobs state file while holding a
+// lock on the file. This prevents the other invocations sharing the state
+// file from running the job meanwhile. Returns false if the job isn't due.
+func ClaimDueJob(path string, j *Job, now time.Time) (bool, error) {
+	unlock, err := lockStateFile(path, "jobs")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	state, err := ReadJobsState(path)
+	if err != nil {
+		return false, err
+	}
+	if !j.IsDue(state, now) {
+		return false, nil
+	}
+	state.record(j, &JobRunResult{StartedAt: now, Status: JobRunStatusRunning})
+	if err := state.Write(path); err != nil {
+		return false, err
+	}
+	j.claimedAt = now
+	return true, nil
+}
+
+func (s JobsState) lastRun(j *Job) *JobRunResult {
+	st, found := s[j.stateKey()]
+	if !found || len(st.Runs) == 0 {
+		return nil
+	}
+	return st.Runs[len(st.Runs)-1]
+}
+
+func (s JobsState) record(j *Job, res *JobRunResult) {
+	st, found := s[j.stateKey()]
+	if !found {
+		st = &JobState{}
+		s[j.stateKey()] = st
+	}
+	if !j.claimedAt.IsZero() {
+		// The running entry recorded while claiming the job is replaced
+		// by the result.
+		st.Runs = slices.DeleteFunc(st.Runs, func(r *JobRunResult) bool {
+			return r.Status == JobRunStatusRunning && r.StartedAt.Equal(j.claimedAt)
+		})
+		j.claimedAt = time.Time{}
+	}
+	st.Runs = append(st.Runs, res)
+	if len(st.Runs) > j.retention {
+		st.Runs = st.Runs[len(st.Runs)-j.retention:]
+	}
+}
+
+func (j *Job) stateKey() string {
+	return fmt.Sprintf("%s/%s", j.hostName, j.Name())
+}
+
+func jobMapToList(jobs jobMap) JobList {
+	res := make(JobList, 0, len(jobs))
+	for _, j := range jobs {
+		res = append(res, j)
+	}
+	sort.Slice(res, func(i, k int) bool {
+		return res[i].Name() < res[k].Name()
+	})
+	return res
+}
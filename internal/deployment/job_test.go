@@ -0,0 +1,457 @@
+package deployment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+)
+
+var jobTestRef = config.ContainerReference{
+	Group:     "g1",
+	Container: "j1",
+}
+
+var jobRunTests = []struct {
+	name         string
+	timeout      string
+	exit         *fakedocker.FakeContainerExit
+	wantStatus   JobRunStatus
+	wantExitCode int
+	wantLogs     string
+	wantErr      string
+	wantOutput   string
+}{
+	{
+		name: "Job Run - Succeeded",
+		exit: &fakedocker.FakeContainerExit{
+			ExitCode: 0,
+			Logs:     "backup complete\n",
+		},
+		wantStatus:   JobRunStatusSucceeded,
+		wantExitCode: 0,
+		wantLogs:     "backup complete\n",
+		wantOutput: `Running job g1-j1
+.*Starting container g1-j1
+Output from job g1-j1 >>>
+backup complete
+Removing container g1-j1
+Job g1-j1 succeeded in .+`,
+	},
+	{
+		name: "Job Run - Failed",
+		exit: &fakedocker.FakeContainerExit{
+			ExitCode: 2,
+			Logs:     "disk full\n",
+		},
+		wantStatus:   JobRunStatusFailed,
+		wantExitCode: 2,
+		wantLogs:     "disk full\n",
+		wantErr:      `Failed to run job g1-j1, reason:exited with code 2`,
+		wantOutput: `Running job g1-j1
+.*Starting container g1-j1
+Output from job g1-j1 >>>
+disk full
+Removing container g1-j1
+Failed to run job g1-j1, reason:exited with code 2`,
+	},
+	{
+		name:         "Job Run - Timed Out",
+		timeout:      "50ms",
+		wantStatus:   JobRunStatusTimedOut,
+		wantExitCode: 137,
+		wantErr:      `Failed to run job g1-j1, reason:timed out after 50ms`,
+		wantOutput: `Running job g1-j1
+.*Starting container g1-j1
+.*Killing job g1-j1 since it didn't exit within 50ms
+.*Removing container g1-j1
+Failed to run job g1-j1, reason:timed out after 50ms`,
+	},
+}
+
+func TestJobRun(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range jobRunTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			initInfo := &fakedocker.FakeDockerHostInitInfo{}
+			if tc.exit != nil {
+				initInfo.ExitOnStart = map[string]*fakedocker.FakeContainerExit{
+					"g1-j1": tc.exit,
+				}
+			}
+			buf := new(bytes.Buffer)
+			dep, _, dc, ctx := newSingleTestContainer(t, tc.name, jobTestRef, &testutils.TestContextInfo{
+				Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+			}, initInfo, jobTestConfig(config.Job{Timeout: tc.timeout}))
+			if dep == nil {
+				return
+			}
+			job := dep.jobs[jobTestRef]
+			defer dc.Close()
+
+			res, gotErr := job.Run(ctx, dc)
+			if len(tc.wantErr) == 0 && gotErr != nil {
+				testhelpers.LogErrorNotNilWithOutput(t, "Job.Run()", tc.name, buf, gotErr)
+				return
+			}
+			if len(tc.wantErr) > 0 {
+				if gotErr == nil {
+					testhelpers.LogErrorNilWithOutput(t, "Job.Run()", tc.name, buf, tc.wantErr)
+					return
+				}
+				if !testhelpers.RegexMatchWithOutput(t, "Job.Run()", tc.name, buf, "gotErr error string", tc.wantErr, gotErr.Error()) {
+					return
+				}
+			}
+
+			if !testhelpers.CmpDiff(t, "Job.Run()", tc.name, "status", tc.wantStatus, res.Status) {
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Job.Run()", tc.name, "exit code", tc.wantExitCode, res.ExitCode) {
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Job.Run()", tc.name, "logs", tc.wantLogs, res.Logs) {
+				return
+			}
+			path := filepath.Join(t.TempDir(), "jobs.json")
+			if err := RecordJobRun(path, job, res); err != nil {
+				testhelpers.LogErrorNotNil(t, "RecordJobRun()", tc.name, err)
+				return
+			}
+			state, err := ReadJobsState(path)
+			if err != nil {
+				testhelpers.LogErrorNotNil(t, "ReadJobsState()", tc.name, err)
+				return
+			}
+			if !testhelpers.CmpDiff(t, "RecordJobRun()", tc.name, "recorded runs", []*JobRunResult{res}, state["fakehost/g1-j1"].Runs) {
+				return
+			}
+			want := fmt.Sprintf(`(?s).*%s.*`, tc.wantOutput)
+			if !testhelpers.RegexMatch(t, "Job.Run()", tc.name, "log output", want, buf.String()) {
+				return
+			}
+
+			st, err := dc.GetContainerState(ctx, job.Name())
+			if err != nil {
+				testhelpers.LogErrorNotNil(t, "docker.Client.GetContainerState()", tc.name, err)
+				return
+			}
+			if !testhelpers.CmpDiff(t, "Job.Run()", tc.name, "job container state", docker.ContainerStateNotFound, st) {
+				return
+			}
+		})
+	}
+}
+
+func TestJobRunRetention(t *testing.T) {
+	t.Parallel()
+
+	tc := "Job Run - Retention"
+	initInfo := &fakedocker.FakeDockerHostInitInfo{
+		ExitOnStart: map[string]*fakedocker.FakeContainerExit{
+			"g1-j1": {},
+		},
+	}
+	dep, _, dc, ctx := newSingleTestContainer(t, tc, jobTestRef, &testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, new(bytes.Buffer)),
+	}, initInfo, jobTestConfig(config.Job{Retention: 2}))
+	if dep == nil {
+		return
+	}
+	job := dep.jobs[jobTestRef]
+	defer dc.Close()
+
+	// The runs recorded by the other invocations sharing the state file
+	// are retained.
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	other := &JobState{
+		Runs: []*JobRunResult{
+			{
+				Status: JobRunStatusSucceeded,
+			},
+		},
+	}
+	if err := (JobsState{"fakehost/g1-j2": other}).Write(path); err != nil {
+		testhelpers.LogErrorNotNil(t, "JobsState.Write()", tc, err)
+		return
+	}
+
+	var want []*JobRunResult
+	for i := 0; i < 3; i++ {
+		res, err := job.Run(ctx, dc)
+		if err != nil {
+			testhelpers.LogErrorNotNil(t, "Job.Run()", tc, err)
+			return
+		}
+		if err := RecordJobRun(path, job, res); err != nil {
+			testhelpers.LogErrorNotNil(t, "RecordJobRun()", tc, err)
+			return
+		}
+		want = append(want, res)
+	}
+	state, err := ReadJobsState(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadJobsState()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "RecordJobRun()", tc, "recorded runs", want[1:], state["fakehost/g1-j1"].Runs) {
+		return
+	}
+	testhelpers.CmpDiff(t, "RecordJobRun()", tc, "other job runs", other, state["fakehost/g1-j2"])
+}
+
+func TestJobRunInterrupted(t *testing.T) {
+	t.Parallel()
+
+	tc := "Job Run - Interrupted"
+	buf := new(bytes.Buffer)
+	dep, _, dc, ctx := newSingleTestContainer(t, tc, jobTestRef, &testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+	}, &fakedocker.FakeDockerHostInitInfo{}, jobTestConfig(config.Job{}))
+	if dep == nil {
+		return
+	}
+	job := dep.jobs[jobTestRef]
+	defer dc.Close()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	res, gotErr := job.Run(runCtx, dc)
+	if gotErr == nil {
+		testhelpers.LogErrorNilWithOutput(t, "Job.Run()", tc, buf, "context canceled")
+		return
+	}
+	if !testhelpers.CmpDiff(t, "Job.Run()", tc, "status", JobRunStatusFailed, res.Status) {
+		return
+	}
+
+	// The job container is removed even though the job was interrupted.
+	st, err := dc.GetContainerState(ctx, job.Name())
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "docker.Client.GetContainerState()", tc, err)
+		return
+	}
+	testhelpers.CmpDiff(t, "Job.Run()", tc, "job container state", docker.ContainerStateNotFound, st)
+}
+
+var jobIsDueTests = []struct {
+	name     string
+	schedule string
+	lastRun  time.Time
+	now      time.Time
+	want     bool
+}{
+	{
+		name: "Job Is Due - No Schedule",
+		now:  time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC),
+		want: false,
+	},
+	{
+		name:     "Job Is Due - Never Ran",
+		schedule: "@daily",
+		now:      time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC),
+		want:     true,
+	},
+	{
+		name:     "Job Is Due - Cron Due",
+		schedule: "30 2 * * *",
+		lastRun:  time.Date(2024, 4, 30, 2, 30, 0, 0, time.UTC),
+		now:      time.Date(2024, 5, 1, 2, 30, 10, 0, time.UTC),
+		want:     true,
+	},
+	{
+		name:     "Job Is Due - Cron Not Due",
+		schedule: "30 2 * * *",
+		lastRun:  time.Date(2024, 5, 1, 2, 30, 0, 0, time.UTC),
+		now:      time.Date(2024, 5, 2, 2, 29, 0, 0, time.UTC),
+		want:     false,
+	},
+	{
+		name:     "Job Is Due - Missed Runs",
+		schedule: "@hourly",
+		lastRun:  time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC),
+		now:      time.Date(2024, 5, 3, 7, 45, 0, 0, time.UTC),
+		want:     true,
+	},
+	{
+		name:     "Job Is Due - Every Due",
+		schedule: "@every 90m",
+		lastRun:  time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC),
+		now:      time.Date(2024, 5, 1, 3, 30, 0, 0, time.UTC),
+		want:     true,
+	},
+	{
+		name:     "Job Is Due - Every Not Due",
+		schedule: "@every 90m",
+		lastRun:  time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC),
+		now:      time.Date(2024, 5, 1, 3, 29, 0, 0, time.UTC),
+		want:     false,
+	},
+}
+
+func TestJobIsDue(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range jobIsDueTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep, _, dc, _ := newSingleTestContainer(t, tc.name, jobTestRef, &testutils.TestContextInfo{
+				Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, new(bytes.Buffer)),
+			}, &fakedocker.FakeDockerHostInitInfo{}, jobTestConfig(config.Job{Schedule: tc.schedule}))
+			if dep == nil {
+				return
+			}
+			job := dep.jobs[jobTestRef]
+			defer dc.Close()
+
+			state := JobsState{}
+			if !tc.lastRun.IsZero() {
+				state[job.stateKey()] = &JobState{
+					Runs: []*JobRunResult{
+						{
+							StartedAt:  tc.lastRun,
+							FinishedAt: tc.lastRun.Add(time.Minute),
+							Status:     JobRunStatusSucceeded,
+						},
+					},
+				}
+			}
+			testhelpers.CmpDiff(t, "Job.IsDue()", tc.name, "due", tc.want, job.IsDue(state, tc.now))
+		})
+	}
+}
+
+func TestClaimDueJob(t *testing.T) {
+	t.Parallel()
+
+	tc := "Job - Claim Due Job"
+	initInfo := &fakedocker.FakeDockerHostInitInfo{
+		ExitOnStart: map[string]*fakedocker.FakeContainerExit{
+			"g1-j1": {},
+		},
+	}
+	dep, _, dc, ctx := newSingleTestContainer(t, tc, jobTestRef, &testutils.TestContextInfo{
+		Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, new(bytes.Buffer)),
+	}, initInfo, jobTestConfig(config.Job{Schedule: "@hourly"}))
+	if dep == nil {
+		return
+	}
+	job := dep.jobs[jobTestRef]
+	defer dc.Close()
+
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	now := time.Now()
+	claimed, err := ClaimDueJob(path, job, now)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ClaimDueJob()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "ClaimDueJob()", tc, "claimed", true, claimed) {
+		return
+	}
+	state, err := ReadJobsState(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadJobsState()", tc, err)
+		return
+	}
+	want := []*JobRunResult{{StartedAt: now, Status: JobRunStatusRunning}}
+	if !testhelpers.CmpDiff(t, "ClaimDueJob()", tc, "recorded runs", want, state["fakehost/g1-j1"].Runs) {
+		return
+	}
+
+	// The other invocations sharing the state file cannot claim the job
+	// while it is running.
+	claimed, err = ClaimDueJob(path, job, now)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ClaimDueJob()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "ClaimDueJob()", tc, "claimed again", false, claimed) {
+		return
+	}
+
+	res, err := job.Run(ctx, dc)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "Job.Run()", tc, err)
+		return
+	}
+	if err := RecordJobRun(path, job, res); err != nil {
+		testhelpers.LogErrorNotNil(t, "RecordJobRun()", tc, err)
+		return
+	}
+	state, err = ReadJobsState(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadJobsState()", tc, err)
+		return
+	}
+	testhelpers.CmpDiff(t, "RecordJobRun()", tc, "recorded runs", []*JobRunResult{res}, state["fakehost/g1-j1"].Runs)
+}
+
+func TestJobsStateReadWrite(t *testing.T) {
+	t.Parallel()
+
+	tc := "Jobs State - Read Write"
+	path := filepath.Join(t.TempDir(), "state", "jobs.json")
+
+	got, err := ReadJobsState(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadJobsState()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "ReadJobsState()", tc, "missing state", JobsState{}, got) {
+		return
+	}
+
+	started := time.Date(2024, 5, 1, 2, 30, 0, 0, time.UTC)
+	want := JobsState{
+		"fakehost/g1-j1": &JobState{
+			Runs: []*JobRunResult{
+				{
+					StartedAt:  started,
+					FinishedAt: started.Add(time.Minute),
+					Status:     JobRunStatusFailed,
+					ExitCode:   1,
+					Logs:       "oops\n",
+					Error:      "exited with code 1",
+				},
+			},
+		},
+	}
+	if err := want.Write(path); err != nil {
+		testhelpers.LogErrorNotNil(t, "JobsState.Write()", tc, err)
+		return
+	}
+	got, err = ReadJobsState(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadJobsState()", tc, err)
+		return
+	}
+	testhelpers.CmpDiff(t, "ReadJobsState()", tc, "state", want, got)
+}
+
+// jobTestConfig returns the update converting the single container config
+// into the job config.
+func jobTestConfig(j config.Job) func(*config.Homelab) {
+	return func(conf *config.Homelab) {
+		j.Container = conf.Containers[0]
+		conf.Jobs = []config.Job{j}
+		conf.Containers = nil
+	}
+}
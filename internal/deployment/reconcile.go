@@ -0,0 +1,145 @@
+package deployment
+
+import (
+	"context"
+	"reflect"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+)
+
+// ContainerDrift describes how the state of a container on the docker
+// host drifted from the deployment.
+type ContainerDrift uint8
+
+const (
+	// ContainerDriftNone indicates the container is in the expected state.
+	ContainerDriftNone ContainerDrift = iota
+	// ContainerDriftMissing indicates the container doesn't exist.
+	ContainerDriftMissing
+	// ContainerDriftStopped indicates the container exists but isn't
+	// running.
+	ContainerDriftStopped
+	// ContainerDriftUnhealthy indicates the container is running but its
+	// health check reports it as unhealthy.
+	ContainerDriftUnhealthy
+)
+
+func (d ContainerDrift) String() string {
+	switch d {
+	case ContainerDriftNone:
+		return "None"
+	case ContainerDriftMissing:
+		return "Missing"
+	case ContainerDriftStopped:
+		return "Stopped"
+	case ContainerDriftUnhealthy:
+		return "Unhealthy"
+	default:
+		panic("Invalid scenario in ContainerDrift stringer, possibly indicating a bug in the code")
+	}
+}
+
+// AllowedContainers returns the containers allowed to run on the host
+// the deployment was built for, in the order they are started.
+func (d *Deployment) AllowedContainers() ContainerList {
+	return containerMapToList(d.allowedContainerMap())
+}
+
+// CreateMissingNetworks creates the bridge mode networks that the
+// containers allowed to run on the host are connected to, if they don't
+// exist on the docker host already. The created networks are returned.
+func (d *Deployment) CreateMissingNetworks(ctx context.Context, dc *docker.Client) (NetworkList, error) {
+	used := map[string]bool{}
+	for _, ct := range d.allowedContainerMap() {
+		for _, ep := range ct.endpoints {
+			if ep.network.Mode() == NetworkModeBridge {
+				used[ep.network.Name()] = true
+			}
+		}
+	}
+
+	var res NetworkList
+	for _, name := range d.NetworksOrder {
+		if !used[name] {
+			continue
+		}
+		n := d.Networks[name]
+		created, err := n.Create(ctx, dc)
+		if err != nil {
+			return res, err
+		}
+		if created {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+// ChangedContainers returns the containers allowed to run on the host
+// whose docker configs or network endpoints differ from the ones in the
+// previous deployment. The containers that were not allowed to run on
+// the host in the previous deployment are not included.
+func (d *Deployment) ChangedContainers(prev *Deployment) ContainerList {
+	prevAllowed := prev.allowedContainerMap()
+	changed := containerMap{}
+	for ref, ct := range d.allowedContainerMap() {
+		prevCt, found := prevAllowed[ref]
+		if !found {
+			continue
+		}
+		if !reflect.DeepEqual(d.dockerConfigs[ref], prev.dockerConfigs[ref]) || !ct.endpoints.equal(prevCt.endpoints) {
+			changed[ref] = ct
+		}
+	}
+	return containerMapToList(changed)
+}
+
+func (d *Deployment) allowedContainerMap() containerMap {
+	res := containerMap{}
+	for ref, ct := range d.queryAllContainers() {
+		if ct.isAllowedOnCurrentHost() {
+			res[ref] = ct
+		}
+	}
+	return res
+}
+
+// Drift returns how the state of the container on the docker host
+// drifted from the deployment.
+func (c *Container) Drift(ctx context.Context, dc *docker.Client) (ContainerDrift, error) {
+	st, err := dc.GetContainerState(ctx, c.Name())
+	if err != nil {
+		return ContainerDriftNone, err
+	}
+
+	switch st {
+	case docker.ContainerStateNotFound:
+		return ContainerDriftMissing, nil
+	case docker.ContainerStateCreated, docker.ContainerStateExited, docker.ContainerStateDead:
+		return ContainerDriftStopped, nil
+	case docker.ContainerStateRunning:
+		health, err := dc.GetContainerHealth(ctx, c.Name())
+		if err != nil {
+			return ContainerDriftNone, err
+		}
+		if health == dtypes.Unhealthy {
+			return ContainerDriftUnhealthy, nil
+		}
+	}
+	// The paused and restarting containers are managed by docker, and
+	// the containers being removed are in a transient state.
+	return ContainerDriftNone, nil
+}
+
+func (n networkEndpointList) equal(other networkEndpointList) bool {
+	if len(n) != len(other) {
+		return false
+	}
+	for i := range n {
+		if n[i].network.Name() != other[i].network.Name() || n[i].ip != other[i].ip {
+			return false
+		}
+	}
+	return true
+}
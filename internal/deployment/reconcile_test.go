@@ -0,0 +1,313 @@
+package deployment
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+var reconcileTestContainer = config.ContainerReference{
+	Group:     "g1",
+	Container: "c1",
+}
+
+var containerDriftTests = []struct {
+	name       string
+	containers []*fakedocker.FakeContainerInitInfo
+	want       ContainerDrift
+}{
+	{
+		name: "Container Drift - Missing",
+		want: ContainerDriftMissing,
+	},
+	{
+		name: "Container Drift - Running",
+		containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:  "g1-c1",
+				Image: "abc/xyz",
+				State: docker.ContainerStateRunning,
+			},
+		},
+		want: ContainerDriftNone,
+	},
+	{
+		name: "Container Drift - Running Healthy",
+		containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:   "g1-c1",
+				Image:  "abc/xyz",
+				State:  docker.ContainerStateRunning,
+				Health: "healthy",
+			},
+		},
+		want: ContainerDriftNone,
+	},
+	{
+		name: "Container Drift - Running Unhealthy",
+		containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:   "g1-c1",
+				Image:  "abc/xyz",
+				State:  docker.ContainerStateRunning,
+				Health: "unhealthy",
+			},
+		},
+		want: ContainerDriftUnhealthy,
+	},
+	{
+		name: "Container Drift - Exited",
+		containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:  "g1-c1",
+				Image: "abc/xyz",
+				State: docker.ContainerStateExited,
+			},
+		},
+		want: ContainerDriftStopped,
+	},
+	{
+		name: "Container Drift - Created",
+		containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:  "g1-c1",
+				Image: "abc/xyz",
+				State: docker.ContainerStateCreated,
+			},
+		},
+		want: ContainerDriftStopped,
+	},
+	{
+		name: "Container Drift - Restarting",
+		containers: []*fakedocker.FakeContainerInitInfo{
+			{
+				Name:  "g1-c1",
+				Image: "abc/xyz",
+				State: docker.ContainerStateRestarting,
+			},
+		},
+		want: ContainerDriftNone,
+	},
+}
+
+func TestContainerDrift(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerDriftTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutils.NewTestContext(&testutils.TestContextInfo{
+				DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+					Containers: tc.containers,
+				}),
+			})
+			conf := buildSingleContainerConfig(reconcileTestContainer, "abc/xyz")
+			dep, gotErr := FromConfig(ctx, &conf)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromConfig()", tc.name, gotErr)
+				return
+			}
+
+			dc := docker.NewClient(ctx)
+			defer dc.Close()
+
+			ct, gotErr := dep.queryContainer(reconcileTestContainer)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "deployment.queryContainer()", tc.name, gotErr)
+				return
+			}
+
+			got, gotErr := ct.Drift(ctx, dc)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "Container.Drift()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "Container.Drift()", tc.name, "container drift", tc.want.String(), got.String()) {
+				return
+			}
+		})
+	}
+}
+
+func TestContainerDriftErrors(t *testing.T) {
+	t.Parallel()
+
+	tc := "Container Drift - Inspect Failure"
+	ctx := testutils.NewTestContext(&testutils.TestContextInfo{
+		DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+			Containers: []*fakedocker.FakeContainerInitInfo{
+				{
+					Name:  "g1-c1",
+					Image: "abc/xyz",
+					State: docker.ContainerStateRunning,
+				},
+			},
+			FailContainerInspect: utils.StringSet{
+				"g1-c1": {},
+			},
+		}),
+	})
+	conf := buildSingleContainerConfig(reconcileTestContainer, "abc/xyz")
+	dep, gotErr := FromConfig(ctx, &conf)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "FromConfig()", tc, gotErr)
+		return
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	ct, gotErr := dep.queryContainer(reconcileTestContainer)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "deployment.queryContainer()", tc, gotErr)
+		return
+	}
+
+	_, gotErr = ct.Drift(ctx, dc)
+	want := `failed to retrieve the container state, reason: failed to inspect container g1-c1 on the fake docker host`
+	if gotErr == nil {
+		testhelpers.LogErrorNil(t, "Container.Drift()", tc, want)
+		return
+	}
+
+	if !testhelpers.RegexMatch(t, "Container.Drift()", tc, "gotErr error string", want, gotErr.Error()) {
+		return
+	}
+}
+
+func TestDeploymentCreateMissingNetworks(t *testing.T) {
+	t.Parallel()
+
+	tc := "Deployment Create Missing Networks"
+	buf := new(bytes.Buffer)
+	ctx := testutils.NewTestContext(&testutils.TestContextInfo{
+		Logger: testutils.NewCapturingTestLogger(zzzlog.LvlDebug, buf),
+		DockerHost: fakedocker.NewFakeDockerHost(&fakedocker.FakeDockerHostInitInfo{
+			Networks: []*fakedocker.FakeNetworkInitInfo{
+				{
+					Name: "g1-bridge",
+				},
+			},
+		}),
+	})
+	conf := buildSingleContainerConfig(reconcileTestContainer, "abc/xyz")
+	dep, gotErr := FromConfig(ctx, &conf)
+	if gotErr != nil {
+		testhelpers.LogErrorNotNil(t, "FromConfig()", tc, gotErr)
+		return
+	}
+
+	dc := docker.NewClient(ctx)
+	defer dc.Close()
+
+	for _, want := range [][]string{{"proxy-bridge"}, nil} {
+		created, gotErr := dep.CreateMissingNetworks(ctx, dc)
+		if gotErr != nil {
+			testhelpers.LogErrorNotNilWithOutput(t, "Deployment.CreateMissingNetworks()", tc, buf, gotErr)
+			return
+		}
+
+		var got []string
+		for _, n := range created {
+			got = append(got, n.Name())
+		}
+		if !testhelpers.CmpDiff(t, "Deployment.CreateMissingNetworks()", tc, "created networks", want, got) {
+			return
+		}
+	}
+}
+
+var deploymentChangedContainersTests = []struct {
+	name   string
+	update func(*config.Homelab)
+	want   []string
+}{
+	{
+		name:   "Deployment Changed Containers - Unchanged",
+		update: func(*config.Homelab) {},
+	},
+	{
+		name: "Deployment Changed Containers - Image",
+		update: func(conf *config.Homelab) {
+			conf.Containers[0].Image.Image = "abc/xyz:2.0"
+		},
+		want: []string{"g1-c1"},
+	},
+	{
+		name: "Deployment Changed Containers - Secondary Network IP",
+		update: func(conf *config.Homelab) {
+			conf.IPAM.Networks.BridgeModeNetworks[1].Containers[0].IP = "172.18.201.12"
+		},
+		want: []string{"g1-c1"},
+	},
+	{
+		name: "Deployment Changed Containers - Newly Added Container",
+		update: func(conf *config.Homelab) {
+			conf.Groups = append(conf.Groups, config.ContainerGroup{
+				Name:  "g2",
+				Order: 2,
+			})
+			conf.Containers = append(conf.Containers, config.Container{
+				Info: config.ContainerReference{
+					Group:     "g2",
+					Container: "c2",
+				},
+				Image: config.ContainerImage{
+					Image: "abc/xyz2",
+				},
+				Lifecycle: config.ContainerLifecycle{
+					Order: 1,
+				},
+			})
+			conf.Hosts[0].AllowedContainers = append(conf.Hosts[0].AllowedContainers, config.ContainerReference{
+				Group:     "g2",
+				Container: "c2",
+			})
+		},
+	},
+}
+
+func TestDeploymentChangedContainers(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range deploymentChangedContainersTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutils.NewVanillaTestContext()
+			prevConf := buildSingleContainerConfig(reconcileTestContainer, "abc/xyz")
+			prev, gotErr := FromConfig(ctx, &prevConf)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromConfig()", tc.name, gotErr)
+				return
+			}
+
+			conf := buildSingleContainerConfig(reconcileTestContainer, "abc/xyz")
+			tc.update(&conf)
+			dep, gotErr := FromConfig(ctx, &conf)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "FromConfig()", tc.name, gotErr)
+				return
+			}
+
+			var got []string
+			for _, ct := range dep.ChangedContainers(prev) {
+				got = append(got, ct.Name())
+			}
+			if !testhelpers.CmpDiff(t, "Deployment.ChangedContainers()", tc.name, "changed containers", tc.want, got) {
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package deployment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// The next run of a cron schedule is looked up only within this
+	// many years, which covers all the valid schedules including the
+	// ones running only on the 29th of February.
+	maxScheduleLookAheadYears = 5
+)
+
+var scheduleAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// scheduleField is a field of the cron expression along with the range
+// of its valid values.
+type scheduleField struct {
+	name string
+	min  int
+	max  int
+}
+
+var scheduleFields = []scheduleField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	// 7 is accepted as Sunday in addition to 0.
+	{name: "day of week", min: 0, max: 7},
+}
+
+// jobSchedule is the parsed schedule of a job, either a fixed interval
+// between the runs or a cron expression.
+type jobSchedule struct {
+	every time.Duration
+	// The bit sets of the values matching each of the cron fields.
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	// Whether the day of month and the day of week fields are
+	// restricted, i.e. not *.
+	domRestricted bool
+	dowRestricted bool
+}
+
+// parseJobSchedule parses the schedule which is either a cron expression
+// with the five fields (minute, hour, day of month, month and day of
+// week), one of the @ aliases like @daily, or @every followed by a
+// duration.
+func parseJobSchedule(schedule string) (*jobSchedule, error) {
+	schedule = strings.TrimSpace(schedule)
+	if every, found := strings.CutPrefix(schedule, "@every "); found {
+		d, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration, reason: %w", err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("duration %s cannot be less than a minute", d)
+		}
+		return &jobSchedule{every: d}, nil
+	}
+	if alias, found := scheduleAliases[schedule]; found {
+		schedule = alias
+	} else if strings.HasPrefix(schedule, "@") {
+		return nil, fmt.Errorf("unknown schedule %s", schedule)
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("expected %d fields in the cron expression, but found %d instead", len(scheduleFields), len(fields))
+	}
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		var err error
+		bits[i], err = parseScheduleField(f, &scheduleFields[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &jobSchedule{
+		minutes:       bits[0],
+		hours:         bits[1],
+		daysOfMonth:   bits[2],
+		months:        bits[3],
+		daysOfWeek:    bits[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}
+	// Treat Sunday as 0 alone.
+	if s.daysOfWeek&(1<<7) != 0 {
+		s.daysOfWeek = s.daysOfWeek&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parseScheduleField parses the comma separated list of values, ranges
+// and steps in the cron field into the bit set of the matching values.
+func parseScheduleField(field string, sf *scheduleField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %s in the %s field %s", stepStr, sf.name, field)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rng == "*":
+			lo, hi = sf.min, sf.max
+		case strings.Contains(rng, "-"):
+			loStr, hiStr, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseScheduleValue(loStr, field, sf); err != nil {
+				return 0, err
+			}
+			if hi, err = parseScheduleValue(hiStr, field, sf); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %s in the %s field %s", rng, sf.name, field)
+			}
+		default:
+			var err error
+			if lo, err = parseScheduleValue(rng, field, sf); err != nil {
+				return 0, err
+			}
+			hi = lo
+			// A single value with a step applies until the end of the
+			// range, for instance 5/15 for the minutes.
+			if hasStep {
+				hi = sf.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseScheduleValue(value, field string, sf *scheduleField) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %s in the %s field %s", value, sf.name, field)
+	}
+	if v < sf.min || v > sf.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in the %s field %s", v, sf.min, sf.max, sf.name, field)
+	}
+	return v, nil
+}
+
+// next returns the first time after t when the job is scheduled to run,
+// or the zero time if the cron expression never matches.
+func (s *jobSchedule) next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxScheduleLookAheadYears, 0, 0)
+	for t.Before(limit) {
+		if !hasBit(s.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !hasBit(s.hours, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !hasBit(s.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay returns whether the day of t matches the schedule. As with
+// cron, the day matches either of the day of month and the day of week
+// fields when both are restricted.
+func (s *jobSchedule) matchesDay(t time.Time) bool {
+	dom := hasBit(s.daysOfMonth, t.Day())
+	dow := hasBit(s.daysOfWeek, int(t.Weekday()))
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func hasBit(bits uint64, v int) bool {
+	return bits&(1<<v) != 0
+}
@@ -0,0 +1,166 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+var jobScheduleNextTests = []struct {
+	name     string
+	schedule string
+	from     time.Time
+	want     time.Time
+}{
+	{
+		name:     "Job Schedule Next - Every Minute",
+		schedule: "* * * * *",
+		from:     time.Date(2024, 5, 1, 2, 30, 45, 0, time.UTC),
+		want:     time.Date(2024, 5, 1, 2, 31, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Daily At Fixed Time",
+		schedule: "30 2 * * *",
+		from:     time.Date(2024, 5, 1, 2, 30, 0, 0, time.UTC),
+		want:     time.Date(2024, 5, 2, 2, 30, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Steps",
+		schedule: "*/15 * * * *",
+		from:     time.Date(2024, 5, 1, 2, 46, 0, 0, time.UTC),
+		want:     time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Step From Value",
+		schedule: "5/20 * * * *",
+		from:     time.Date(2024, 5, 1, 2, 26, 0, 0, time.UTC),
+		want:     time.Date(2024, 5, 1, 2, 45, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Lists And Ranges",
+		schedule: "0 9-17/4,22 * * 1-5",
+		from:     time.Date(2024, 5, 3, 22, 0, 0, 0, time.UTC),
+		want:     time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Sunday As Seven",
+		schedule: "0 0 * * 7",
+		from:     time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		want:     time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Day Of Month Or Day Of Week",
+		schedule: "0 0 15 * 1",
+		from:     time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC),
+		want:     time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Leap Day",
+		schedule: "0 0 29 2 *",
+		from:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		want:     time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Never",
+		schedule: "0 0 31 2 *",
+		from:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		want:     time.Time{},
+	},
+	{
+		name:     "Job Schedule Next - Monthly",
+		schedule: "@monthly",
+		from:     time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		want:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Weekly",
+		schedule: "@weekly",
+		from:     time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		want:     time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		name:     "Job Schedule Next - Every Duration",
+		schedule: "@every 1h30m",
+		from:     time.Date(2024, 5, 1, 2, 10, 20, 0, time.UTC),
+		want:     time.Date(2024, 5, 1, 3, 40, 20, 0, time.UTC),
+	},
+}
+
+func TestJobScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range jobScheduleNextTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := parseJobSchedule(tc.schedule)
+			if err != nil {
+				testhelpers.LogErrorNotNil(t, "parseJobSchedule()", tc.name, err)
+				return
+			}
+			testhelpers.CmpDiff(t, "jobSchedule.next()", tc.name, "next run", tc.want, s.next(tc.from))
+		})
+	}
+}
+
+var parseJobScheduleErrorTests = []struct {
+	name     string
+	schedule string
+	want     string
+}{
+	{
+		name:     "Parse Job Schedule - Too Few Fields",
+		schedule: "0 2 * *",
+		want:     `expected 5 fields in the cron expression, but found 4 instead`,
+	},
+	{
+		name:     "Parse Job Schedule - Unknown Alias",
+		schedule: "@fortnightly",
+		want:     `unknown schedule @fortnightly`,
+	},
+	{
+		name:     "Parse Job Schedule - Invalid Value",
+		schedule: "0 2 * * mon",
+		want:     `invalid value mon in the day of week field mon`,
+	},
+	{
+		name:     "Parse Job Schedule - Out Of Range",
+		schedule: "60 2 * * *",
+		want:     `value 60 out of range \[0, 59\] in the minute field 60`,
+	},
+	{
+		name:     "Parse Job Schedule - Reversed Range",
+		schedule: "0 2 20-10 * *",
+		want:     `invalid range 20-10 in the day of month field 20-10`,
+	},
+	{
+		name:     "Parse Job Schedule - Invalid Step",
+		schedule: "*/0 * * * *",
+		want:     `invalid step 0 in the minute field \*/0`,
+	},
+	{
+		name:     "Parse Job Schedule - Invalid Duration",
+		schedule: "@every often",
+		want:     `invalid duration, reason: time: invalid duration "often"`,
+	},
+}
+
+func TestParseJobScheduleErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range parseJobScheduleErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := parseJobSchedule(tc.schedule)
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "parseJobSchedule()", tc.name, tc.want)
+				return
+			}
+			testhelpers.RegexMatch(t, "parseJobSchedule()", tc.name, "gotErr error string", tc.want, gotErr.Error())
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package deployment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// readStateFile parses the JSON state file describing the kind of state
+// into the state, which is left untouched if the file doesn't exist.
+func readStateFile(path, kind string, state any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the %s state file %s, reason: %w", kind, path, err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("failed to parse the %s state file %s, reason: %w", kind, path, err)
+	}
+	return nil
+}
+
+// writeStateFile writes the state as JSON to the state file describing
+// the kind of state, replacing it atomically.
+func writeStateFile(path, kind string, state any) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize the %s state, reason: %w", kind, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create the directory for the %s state file %s, reason: %w", kind, path, err)
+	}
+
+	tmp := fmt.Sprintf("%s.tmp", path)
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the %s state file %s, reason: %w", kind, path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write the %s state file %s, reason: %w", kind, path, err)
+	}
+	return nil
+}
+
+// lockStateFile takes an exclusive lock on the state file describing the
+// kind of state, blocking until the lock is acquired. The lock is taken
+// on a separate lock file, since the state file itself is replaced when
+// written. The returned function releases the lock.
+func lockStateFile(path, kind string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the directory for the %s state file %s, reason: %w", kind, path, err)
+	}
+	lockPath := fmt.Sprintf("%s.lock", path)
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the lock file %s for the %s state, reason: %w", lockPath, kind, err)
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock the %s state file %s, reason: %w", kind, path, err)
+	}
+	return func() {
+		_ = unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
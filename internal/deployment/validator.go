@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"net/netip"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/docker/go-units"
@@ -14,11 +17,12 @@ import (
 	"github.com/tuxdudehomelab/homelab/internal/config"
 	"github.com/tuxdudehomelab/homelab/internal/config/env"
 	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/dotenv"
 	"github.com/tuxdudehomelab/homelab/internal/host"
 	"github.com/tuxdudehomelab/homelab/internal/utils"
 )
 
-func validateGlobalConfig(ctx context.Context, parentEnv *env.ConfigEnvManager, conf *config.Global) (*env.ConfigEnvManager, error) {
+func validateGlobalConfig(ctx context.Context, parentEnv *env.ConfigEnvManager, conf *config.Global, hostConfigs []*config.Host) (*env.ConfigEnvManager, error) {
 	if err := validateBaseDir(conf.BaseDir); err != nil {
 		return nil, err
 	}
@@ -27,10 +31,27 @@ func validateGlobalConfig(ctx context.Context, parentEnv *env.ConfigEnvManager,
 	if err != nil {
 		return nil, err
 	}
+	for _, hostConfig := range hostConfigs {
+		// The host config env has already been validated while validating
+		// the hosts config, and it overrides the global config env.
+		hostEnvMap, hostEnvOrder, _ := validateConfigEnv(hostConfig.Env, "")
+		for _, v := range hostEnvOrder {
+			if _, found := newEnvMap[v]; !found {
+				newEnvOrder = append(newEnvOrder, v)
+			}
+			newEnvMap[v] = hostEnvMap[v]
+		}
+	}
 
 	// Apply the config env prior to validating other info within the global config.
 	env := parentEnv.NewGlobalConfigEnvManager(ctx, conf.BaseDir, newEnvMap, newEnvOrder)
+	if conf.AllowOSEnv {
+		env = env.WithOSEnv()
+	}
 	conf.ApplyConfigEnv(env)
+	if err := env.Err(); err != nil {
+		return nil, fmt.Errorf("%w in global config", err)
+	}
 
 	if err := validateMountsConfig(conf.MountDefs, nil, nil, "global config mount defs"); err != nil {
 		return nil, err
@@ -64,6 +85,9 @@ func validateConfigEnv(conf []config.ConfigEnv, location string) (env.EnvMap, en
 		if len(e.Var) == 0 {
 			return nil, nil, fmt.Errorf("empty env var in %s", location)
 		}
+		if !env.IsValidVarName(e.Var) {
+			return nil, nil, fmt.Errorf("env var %s must start with a letter or an underscore and contain only letters, digits, underscores, periods and hyphens in %s", e.Var, location)
+		}
 		if _, found := envs[e.Var]; found {
 			return nil, nil, fmt.Errorf("env var %s specified more than once in %s", e.Var, location)
 		}
@@ -97,13 +121,50 @@ func validateContainerEnv(conf []config.ContainerEnv, location string) error {
 		}
 		envs[e.Var] = struct{}{}
 
-		if len(e.Value) == 0 {
+		numSet := 0
+		for _, set := range []bool{len(e.Value) > 0, e.Empty, e.FromHost} {
+			if set {
+				numSet++
+			}
+		}
+		if numSet == 0 {
 			return fmt.Errorf("value not specified for env var %s in %s", e.Var, location)
 		}
+		if numSet > 1 {
+			return fmt.Errorf("exactly one of value, empty or fromHost must be specified for env var %s in %s", e.Var, location)
+		}
 	}
 	return nil
 }
 
+// readContainerEnvFiles reads the env vars from the env files, resolving
+// the relative paths against the container configs directory.
+func readContainerEnvFiles(envFiles []string, configsDir string, location string) ([]config.ContainerEnv, error) {
+	var res []config.ContainerEnv
+	for _, f := range envFiles {
+		if len(f) == 0 {
+			return nil, fmt.Errorf("empty env file path in %s", location)
+		}
+		p := f
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(configsDir, p)
+		}
+		file, err := os.Open(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open env file %s in %s, reason: %w", f, location, err)
+		}
+		entries, err := dotenv.Parse(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env file %s in %s, reason: %w", f, location, err)
+		}
+		for _, e := range entries {
+			res = append(res, config.ContainerEnv{Var: e.Var, Value: e.Value})
+		}
+	}
+	return res, nil
+}
+
 func validateLabelsConfig(conf []config.Label, location string) error {
 	labels := utils.StringSet{}
 	for _, l := range conf {
@@ -260,9 +321,132 @@ func validateHealthConfig(conf *config.ContainerHealth, location string) error {
 			return fmt.Errorf("health check start interval %s is invalid in %s, reason: %w", conf.StartInterval, location, err)
 		}
 	}
+	return validateUnhealthyPolicy(&conf.OnUnhealthy, location)
+}
+
+func validateWaitForConfig(conf *config.ContainerWaitFor, endpoints networkEndpointList, location string) error {
+	conditions := 0
+	if conf.Healthy {
+		conditions++
+	}
+	if conf.Port != 0 {
+		conditions++
+	}
+	if len(conf.File) > 0 {
+		conditions++
+	}
+	if conditions > 1 {
+		return fmt.Errorf("only one of healthy, port or file can be set for the wait for condition in %s", location)
+	}
+	if conditions == 0 && (len(conf.Timeout) > 0 || len(conf.Interval) > 0) {
+		return fmt.Errorf("wait for timeout and interval can be set only along with one of healthy, port or file in %s", location)
+	}
+	if conf.Port < 0 || conf.Port > 65535 {
+		return fmt.Errorf("wait for port %d is out of range in %s", conf.Port, location)
+	}
+	// The port is probed using the IP of the container in its primary
+	// network, which only the containers in a bridge mode network have.
+	if conf.Port != 0 && (len(endpoints) == 0 || endpoints[0].network.mode != NetworkModeBridge) {
+		return fmt.Errorf("wait for port requires the container to be connected to a bridge mode network in %s", location)
+	}
+	if len(conf.File) > 0 && !filepath.IsAbs(conf.File) {
+		return fmt.Errorf("wait for file %s must be an absolute path in %s", conf.File, location)
+	}
+	if len(conf.Timeout) > 0 {
+		timeout, err := time.ParseDuration(conf.Timeout)
+		if err != nil {
+			return fmt.Errorf("wait for timeout %s is invalid in %s, reason: %w", conf.Timeout, location, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("wait for timeout %s must be positive in %s", conf.Timeout, location)
+		}
+	}
+	if len(conf.Interval) > 0 {
+		interval, err := time.ParseDuration(conf.Interval)
+		if err != nil {
+			return fmt.Errorf("wait for interval %s is invalid in %s, reason: %w", conf.Interval, location, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("wait for interval %s must be positive in %s", conf.Interval, location)
+		}
+	}
+	return nil
+}
+
+func validateHookConfig(conf *config.ContainerHook, hook string, location string) error {
+	if len(conf.Command) == 0 {
+		if conf.InContainer || len(conf.Timeout) > 0 || len(conf.OnFailure) > 0 {
+			return fmt.Errorf("%s command cannot be empty in %s", hook, location)
+		}
+		return nil
+	}
+	if len(conf.Command[0]) == 0 {
+		return fmt.Errorf("%s command cannot be empty in %s", hook, location)
+	}
+	if len(conf.Timeout) > 0 {
+		timeout, err := time.ParseDuration(conf.Timeout)
+		if err != nil {
+			return fmt.Errorf("%s timeout %s is invalid in %s, reason: %w", hook, conf.Timeout, location, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("%s timeout %s must be positive in %s", hook, conf.Timeout, location)
+		}
+	}
+	if _, err := hookFailurePolicyFromString(conf.OnFailure); err != nil {
+		return fmt.Errorf("invalid %s failure policy %s in %s, valid values are %s", hook, conf.OnFailure, location, hookFailurePolicyValidValues())
+	}
 	return nil
 }
 
+func validateUnhealthyPolicy(conf *config.ContainerUnhealthyPolicy, location string) error {
+	action, err := healActionFromString(conf.Action)
+	if err != nil {
+		return fmt.Errorf("invalid health check unhealthy action %s in %s, valid values are %s", conf.Action, location, healActionValidValues())
+	}
+	if action == HealActionNone && (len(conf.Backoff) > 0 || conf.MaxAttempts != 0) {
+		return fmt.Errorf("health check unhealthy backoff and max attempts can be set only when the unhealthy action is restart or recreate in %s", location)
+	}
+	if len(conf.Backoff) > 0 {
+		backoff, err := time.ParseDuration(conf.Backoff)
+		if err != nil {
+			return fmt.Errorf("health check unhealthy backoff %s is invalid in %s, reason: %w", conf.Backoff, location, err)
+		}
+		if backoff <= 0 {
+			return fmt.Errorf("health check unhealthy backoff %s must be positive in %s", conf.Backoff, location)
+		}
+	}
+	if conf.MaxAttempts < 0 {
+		return fmt.Errorf("health check unhealthy max attempts %d cannot be negative in %s", conf.MaxAttempts, location)
+	}
+	return nil
+}
+
+func validateBackupConfig(conf *config.ContainerBackup, location string) error {
+	if len(conf.Destination) > 0 && !filepath.IsAbs(conf.Destination) {
+		return fmt.Errorf("backup destination %s must be an absolute path in %s", conf.Destination, location)
+	}
+	if _, err := backupCompressionFromString(conf.Compression); err != nil {
+		return fmt.Errorf("invalid backup compression %s in %s, valid values are %s", conf.Compression, location, backupCompressionValidValues())
+	}
+	for _, inc := range conf.Includes {
+		if !filepath.IsLocal(inc) {
+			return fmt.Errorf("backup include path %s must be a relative path within the container data directory in %s", inc, location)
+		}
+	}
+	for _, exc := range conf.Excludes {
+		if len(exc) == 0 {
+			return fmt.Errorf("backup exclude pattern cannot be empty in %s", location)
+		}
+		if _, err := filepath.Match(exc, ""); err != nil {
+			return fmt.Errorf("backup exclude pattern %s is invalid in %s, reason: %w", exc, location, err)
+		}
+	}
+	if conf.Retention < 0 {
+		return fmt.Errorf("backup retention %d cannot be negative in %s", conf.Retention, location)
+	}
+	return validateHookConfig(&conf.PreBackupHook, "pre-backup hook", location)
+}
+
 func validateGlobalContainerConfig(conf *config.GlobalContainer, globalMountDefs []config.Mount) error {
 	if conf.StopTimeout < 0 {
 		return fmt.Errorf("container stop timeout %d cannot be negative in global container config", conf.StopTimeout)
@@ -456,40 +640,154 @@ func validateIPAMConfig(ctx context.Context, conf *config.IPAM) (NetworkMap, map
 	return networks, containerEndpoints, nil
 }
 
-func validateHostsConfig(ctx context.Context, hosts []config.Host) (containerSet, error) {
-	currentHost := host.MustHostInfo(ctx)
+func validateHostGroupsConfig(ctx context.Context, hostGroups []config.HostGroup) (*hostMatcher, error) {
+	matcher := newHostMatcher(host.MustHostInfo(ctx))
+	for _, hg := range hostGroups {
+		if len(hg.Name) == 0 {
+			return nil, fmt.Errorf("host group name cannot be empty in the host groups config")
+		}
+		if _, found := matcher.hostGroups[hg.Name]; found {
+			return nil, fmt.Errorf("host group %s defined more than once in the host groups config", hg.Name)
+		}
+		if len(hg.Hosts) == 0 {
+			return nil, fmt.Errorf("host group %s must contain at least one host in the host groups config", hg.Name)
+		}
+		loc := fmt.Sprintf("host group %s config", hg.Name)
+		for _, h := range hg.Hosts {
+			if len(h) == 0 {
+				return nil, fmt.Errorf("host name cannot be empty in %s", loc)
+			}
+			if err := validateHostNamePattern(h, loc); err != nil {
+				return nil, err
+			}
+		}
+		matcher.hostGroups[hg.Name] = hg.Hosts
+	}
+	return matcher, nil
+}
+
+func validateHostsConfig(matcher *hostMatcher, hosts []config.Host, containersConfig []config.Container) (containerSet, []*config.Host, error) {
 	hostNames := utils.StringSet{}
 	allowedContainers := containerSet{}
-	for _, h := range hosts {
-		if len(h.Name) == 0 {
-			return nil, fmt.Errorf("host name cannot be empty in the hosts config")
+	var currentHostConfigs []*config.Host
+
+	definedContainers := containerSet{}
+	for _, ct := range containersConfig {
+		definedContainers[ct.Info] = true
+	}
+
+	for i, h := range hosts {
+		sel := config.HostSelector{Name: h.Name, HostGroup: h.HostGroup, Arch: h.Arch}
+		if len(h.Name) == 0 && len(h.HostGroup) == 0 && len(h.Arch) == 0 {
+			return nil, nil, fmt.Errorf("host name cannot be empty in the hosts config")
 		}
-		if _, found := hostNames[h.Name]; found {
-			return nil, fmt.Errorf("host %s defined more than once in the hosts config", h.Name)
+		hostName := hostSelectorName(&sel)
+		if _, found := hostNames[hostName]; found {
+			return nil, nil, fmt.Errorf("host %s defined more than once in the hosts config", hostName)
 		}
-		hostNames[h.Name] = struct{}{}
+		hostNames[hostName] = struct{}{}
+
+		loc := fmt.Sprintf("host %s config", hostName)
+		if err := matcher.validateSelector(&sel, loc); err != nil {
+			return nil, nil, err
+		}
+		if _, _, err := validateConfigEnv(h.Env, loc); err != nil {
+			return nil, nil, err
+		}
+		if !h.HostIP.IsEmpty() {
+			if err := h.HostIP.Validate(); err != nil {
+				return nil, nil, fmt.Errorf("%w in %s", err, loc)
+			}
+		}
+		if err := validateHostDockerConfig(&h, loc); err != nil {
+			return nil, nil, err
+		}
+		if err := validateHostSystemdConfig(&h.Systemd, loc); err != nil {
+			return nil, nil, err
+		}
+		isCurrentHost := matcher.matches(&sel)
 
 		containers := make(map[config.ContainerReference]bool)
 		for _, ct := range h.AllowedContainers {
 			err := validateContainerReference(&ct)
 			if err != nil {
-				return nil, fmt.Errorf("allowed container config within host %s has invalid container reference, reason: %w", h.Name, err)
+				return nil, nil, fmt.Errorf("allowed container config within host %s has invalid container reference, reason: %w", hostName, err)
 			}
 			if containers[ct] {
-				return nil, fmt.Errorf("container {Group:%s Container:%s} defined more than once in the hosts config for host %s", ct.Group, ct.Container, h.Name)
+				return nil, nil, fmt.Errorf("container {Group:%s Container:%s} defined more than once in the hosts config for host %s", ct.Group, ct.Container, hostName)
 			}
 			containers[ct] = true
-			if h.Name == currentHost.HostName {
+			if isCurrentHost {
 				allowedContainers[ct] = true
 			}
 		}
+
+		patches := make(map[config.ContainerReference]bool)
+		for _, ct := range h.Containers {
+			err := validateContainerReference(&ct.Info)
+			if err != nil {
+				return nil, nil, fmt.Errorf("container patch within host %s has invalid container reference, reason: %w", hostName, err)
+			}
+			if patches[ct.Info] {
+				return nil, nil, fmt.Errorf("container {Group:%s Container:%s} patch defined more than once in the hosts config for host %s", ct.Info.Group, ct.Info.Container, hostName)
+			}
+			patches[ct.Info] = true
+			if len(ct.Extends) > 0 {
+				return nil, nil, fmt.Errorf("container {Group:%s Container:%s} patch in the hosts config for host %s cannot specify extends", ct.Info.Group, ct.Info.Container, hostName)
+			}
+			if err := config.ValidateUnset(ct.Unset); err != nil {
+				return nil, nil, fmt.Errorf("%w in the container {Group:%s Container:%s} patch in the hosts config for host %s", err, ct.Info.Group, ct.Info.Container, hostName)
+			}
+			if !definedContainers[ct.Info] {
+				return nil, nil, fmt.Errorf("container {Group:%s Container:%s} patched in the hosts config for host %s is not defined in the containers config", ct.Info.Group, ct.Info.Container, hostName)
+			}
+		}
+
+		if isCurrentHost {
+			currentHostConfigs = append(currentHostConfigs, &hosts[i])
+		}
+	}
+	return allowedContainers, currentHostConfigs, nil
+}
+
+func validateHostDockerConfig(h *config.Host, location string) error {
+	d := &h.Docker
+	if len(d.Endpoint) == 0 {
+		if len(d.TLSCACert) > 0 || len(d.TLSCert) > 0 || len(d.TLSKey) > 0 {
+			return fmt.Errorf("docker TLS options cannot be specified without the docker endpoint in %s", location)
+		}
+		return nil
+	}
+	if len(h.HostGroup) > 0 || len(h.Arch) > 0 || strings.ContainsAny(h.Name, `*?[\`) {
+		return fmt.Errorf("docker endpoint can only be specified for a single host identified by its name in %s", location)
+	}
+	if err := docker.ValidateRemoteEndpoint(d.RemoteEndpoint()); err != nil {
+		return fmt.Errorf("%w in %s", err, location)
+	}
+	return nil
+}
+
+func validateHostSystemdConfig(s *config.HostSystemd, location string) error {
+	for _, p := range s.RequiresMountsFor {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("systemd requiresMountsFor path %q must be an absolute path in %s", p, location)
+		}
 	}
-	return allowedContainers, nil
+	return nil
 }
 
-func validateGroupsConfig(groups []config.ContainerGroup) (ContainerGroupMap, error) {
+// hostSelectorName returns the name used to refer to the host config
+// with the specified selector within the error messages.
+func hostSelectorName(sel *config.HostSelector) string {
+	if len(sel.HostGroup) == 0 && len(sel.Arch) == 0 {
+		return sel.Name
+	}
+	return fmt.Sprintf("%+v", *sel)
+}
+
+func validateGroupsConfig(ctx context.Context, parentEnv *env.ConfigEnvManager, groups []config.ContainerGroup, globalConfig *config.Global) (ContainerGroupMap, error) {
 	containerGroups := ContainerGroupMap{}
-	for _, g := range groups {
+	for i, g := range groups {
 		if len(g.Name) == 0 {
 			return nil, fmt.Errorf("group name cannot be empty in the groups config")
 		}
@@ -500,13 +798,151 @@ func validateGroupsConfig(groups []config.ContainerGroup) (ContainerGroupMap, er
 			return nil, fmt.Errorf("group %s cannot have a non-positive order %d", g.Name, g.Order)
 		}
 
-		containerGroups[g.Name] = NewContainerGroup(&g)
+		loc := fmt.Sprintf("group %s config", g.Name)
+		groupConfigEnvMap, groupConfigEnvOrder, err := validateConfigEnv(g.Config.Env, loc)
+		if err != nil {
+			return nil, err
+		}
+		groupEnv := parentEnv.NewGroupConfigEnvManager(ctx, containerGroupBaseDir(globalConfig.BaseDir, config.ContainerReference{Group: g.Name}), groupConfigEnvMap, groupConfigEnvOrder)
+		// Apply the config env on the original config to retain the
+		// updated group config after ApplyConfigEnv().
+		groups[i].ApplyConfigEnv(groupEnv)
+		if err := groupEnv.Err(); err != nil {
+			return nil, fmt.Errorf("%w in %s", err, loc)
+		}
+
+		if err := validateGroupContainerConfig(&groups[i].Container, globalConfig, loc); err != nil {
+			return nil, err
+		}
+
+		cg := NewContainerGroup(&groups[i])
+		cg.configEnv = groupEnv
+		containerGroups[g.Name] = cg
 	}
 	return containerGroups, nil
 }
 
-func validateContainersConfig(ctx context.Context, parentEnv *env.ConfigEnvManager, containersConfig []config.Container, groups ContainerGroupMap, globalConfig *config.Global, containerEndpoints map[config.ContainerReference]networkEndpointList, allowedContainers containerSet) error {
+func validateGroupContainerConfig(conf *config.GroupContainer, globalConfig *config.Global, location string) error {
+	if err := validateContainerRestartPolicy(&conf.RestartPolicy, location); err != nil {
+		return err
+	}
+	if err := validateContainerEnv(conf.Env, location); err != nil {
+		return err
+	}
+	if err := validateMountsConfig(conf.Mounts, globalConfig.Container.Mounts, globalConfig.MountDefs, fmt.Sprintf("%s mounts", location)); err != nil {
+		return err
+	}
+	if err := validateLabelsConfig(conf.Labels, location); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateContainerTemplatesConfig(templates []config.ContainerTemplate) (map[string]*config.ContainerTemplate, error) {
+	result := make(map[string]*config.ContainerTemplate)
+	for i, t := range templates {
+		if len(t.Name) == 0 {
+			return nil, fmt.Errorf("container template name cannot be empty in the container templates config")
+		}
+		if _, found := result[t.Name]; found {
+			return nil, fmt.Errorf("container template %s defined more than once in the container templates config", t.Name)
+		}
+		if len(t.Info.Group) > 0 || len(t.Info.Container) > 0 {
+			return nil, fmt.Errorf("container template %s cannot specify the container info", t.Name)
+		}
+		result[t.Name] = &templates[i]
+	}
+	return result, nil
+}
+
+func resolveContainersConfig(templatesConfig []config.ContainerTemplate, containersConfig []config.Container, hostConfigs []*config.Host) ([]config.Container, error) {
+	templates, err := validateContainerTemplatesConfig(templatesConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]config.Container, 0, len(containersConfig))
+	for _, ct := range containersConfig {
+		resolved := ct
+		loc := fmt.Sprintf("container {Group: %s Container:%s} config", ct.Info.Group, ct.Info.Container)
+		if err := validateUnsetConfig(&ct, loc); err != nil {
+			return nil, err
+		}
+		if len(ct.Extends) > 0 {
+			resolved, err = resolveContainerExtends(&ct, loc, templates, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if patches := hostContainerPatches(hostConfigs, ct.Info); len(patches) > 0 {
+			if len(ct.Extends) == 0 {
+				// Avoid sharing the slices with the original config.
+				resolved = config.Container{}
+				config.MergeContainer(&resolved, &ct)
+			}
+			for _, patch := range patches {
+				config.MergeContainer(&resolved, patch)
+			}
+		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
+
+// validateUnsetConfig validates the fields to unset, which can be used
+// only along with extends.
+func validateUnsetConfig(ct *config.Container, loc string) error {
+	if len(ct.Unset) == 0 {
+		return nil
+	}
+	if len(ct.Extends) == 0 {
+		return fmt.Errorf("unset can be used only along with extends in %s", loc)
+	}
+	if err := config.ValidateUnset(ct.Unset); err != nil {
+		return fmt.Errorf("%w in %s", err, loc)
+	}
+	return nil
+}
+
+func hostContainerPatches(hostConfigs []*config.Host, ct config.ContainerReference) []*config.Container {
+	var patches []*config.Container
+	for _, h := range hostConfigs {
+		for i := range h.Containers {
+			if h.Containers[i].Info == ct {
+				patches = append(patches, &h.Containers[i])
+			}
+		}
+	}
+	return patches
+}
+
+func resolveContainerExtends(ct *config.Container, loc string, templates map[string]*config.ContainerTemplate, chain []string) (config.Container, error) {
+	if err := validateUnsetConfig(ct, loc); err != nil {
+		return config.Container{}, err
+	}
+	resolved := config.Container{}
+	for _, name := range ct.Extends {
+		t, found := templates[name]
+		if !found {
+			return config.Container{}, fmt.Errorf("container template %s not found, extended in %s", name, loc)
+		}
+		if slices.Contains(chain, name) {
+			return config.Container{}, fmt.Errorf("container template %s has a cyclic extends chain %s -> %s", name, strings.Join(chain, " -> "), name)
+		}
+		tResolved, err := resolveContainerExtends(&t.Container, fmt.Sprintf("container template %s", name), templates, append(slices.Clone(chain), name))
+		if err != nil {
+			return config.Container{}, err
+		}
+		config.MergeContainer(&resolved, &tResolved)
+	}
+	config.MergeContainer(&resolved, ct)
+	resolved.Extends = nil
+	return resolved, nil
+}
+
+func validateContainersConfig(ctx context.Context, containersConfig []config.Container, writtenConfig []*config.Container, groups ContainerGroupMap, globalConfig *config.Global, networks NetworkMap, containerEndpoints map[config.ContainerReference]networkEndpointList, matcher *hostMatcher, allowedContainers containerSet) error {
 	exec := cmdexec.MustExecutor(ctx)
+	refs := newContainerRefs(ctx, containersConfig, groups, globalConfig, networks, containerEndpoints)
 	for i, ct := range containersConfig {
 		g, found := groups[ct.Info.Group]
 		if !found {
@@ -517,82 +953,221 @@ func validateContainersConfig(ctx context.Context, parentEnv *env.ConfigEnvManag
 		}
 
 		loc := fmt.Sprintf("container {Group: %s Container:%s} config", ct.Info.Group, ct.Info.Container)
-		ctConfigEnvMap, ctConfigEnvOrder, err := validateConfigEnv(ct.Config.Env, loc)
+		envFileEnv, err := validateContainerConfig(ctx, &ct, writtenConfig[i], g, globalConfig, refs, exec, matcher, loc)
 		if err != nil {
 			return err
 		}
-		ctEnv := parentEnv.NewContainerConfigEnvManager(ctx, containerGroupBaseDir(globalConfig.BaseDir, ct.Info), containerBaseDir(globalConfig.BaseDir, ct.Info), ctConfigEnvMap, ctConfigEnvOrder)
-		ct.ApplyConfigEnv(ctEnv)
-		if err := ct.ApplyCmdExecutor(exec); err != nil {
-			return err
-		}
 
-		if len(ct.Image.Image) == 0 {
-			return fmt.Errorf("image cannot be empty in %s", loc)
+		g.addContainer(&ct, globalConfig, containerEndpoints[ct.Info], envFileEnv, allowedContainers[ct.Info] || matcher.matchesAny(ct.Placement))
+		// This is needed to store the updated container config after
+		// ApplyConfigEnv().
+		containersConfig[i] = ct
+	}
+
+	return nil
+}
+
+func resolveJobsConfig(templatesConfig []config.ContainerTemplate, jobsConfig []config.Job) ([]config.Job, error) {
+	templates, err := validateContainerTemplatesConfig(templatesConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]config.Job, 0, len(jobsConfig))
+	for _, j := range jobsConfig {
+		resolved := j
+		loc := fmt.Sprintf("job {Group: %s Container:%s} config", j.Info.Group, j.Info.Container)
+		if err := validateUnsetConfig(&j.Container, loc); err != nil {
+			return nil, err
 		}
-		if ct.Image.SkipImagePull {
-			if ct.Image.IgnoreImagePullFailures {
-				return fmt.Errorf("ignoreImagePullFailures cannot be true when skipImagePull is true in %s", loc)
-			}
-			if ct.Image.PullImageBeforeStop {
-				return fmt.Errorf("pullImageBeforeStop cannot be true when skipImagePull is true in %s", loc)
+		if len(j.Extends) > 0 {
+			resolved.Container, err = resolveContainerExtends(&j.Container, loc, templates, nil)
+			if err != nil {
+				return nil, err
 			}
 		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
 
-		if err := validateLabelsConfig(ct.Metadata.Labels, loc); err != nil {
-			return err
+func validateJobsConfig(ctx context.Context, jobsConfig []config.Job, writtenConfig []*config.Container, containersConfig []config.Container, groups ContainerGroupMap, globalConfig *config.Global, networks NetworkMap, containerEndpoints map[config.ContainerReference]networkEndpointList, matcher *hostMatcher, allowedContainers containerSet) (jobMap, error) {
+	exec := cmdexec.MustExecutor(ctx)
+	refs := newContainerRefs(ctx, containersConfig, groups, globalConfig, networks, containerEndpoints)
+	jobs := jobMap{}
+	for i, j := range jobsConfig {
+		g, found := groups[j.Info.Group]
+		if !found {
+			return nil, fmt.Errorf("group definition missing in groups config for the job {Group:%s Container:%s} in the jobs config", j.Info.Group, j.Info.Container)
+		}
+		if _, found := g.containers[j.Info]; found {
+			return nil, fmt.Errorf("job {Group:%s Container:%s} has the same name as a container in the containers config", j.Info.Group, j.Info.Container)
+		}
+		if _, found := jobs[j.Info]; found {
+			return nil, fmt.Errorf("job {Group:%s Container:%s} defined more than once in the jobs config", j.Info.Group, j.Info.Container)
 		}
 
-		if ct.Lifecycle.Order <= 0 {
-			return fmt.Errorf("container order %d cannot be non-positive in %s", ct.Lifecycle.Order, loc)
+		loc := fmt.Sprintf("job {Group: %s Container:%s} config", j.Info.Group, j.Info.Container)
+		if len(j.Schedule) > 0 {
+			if _, err := parseJobSchedule(j.Schedule); err != nil {
+				return nil, fmt.Errorf("job schedule %s is invalid in %s, reason: %w", j.Schedule, loc, err)
+			}
 		}
-		if err := validateContainerRestartPolicy(&ct.Lifecycle.RestartPolicy, loc); err != nil {
-			return err
+		if len(j.Timeout) > 0 {
+			timeout, err := time.ParseDuration(j.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("job timeout %s is invalid in %s, reason: %w", j.Timeout, loc, err)
+			}
+			if timeout <= 0 {
+				return nil, fmt.Errorf("job timeout %s must be positive in %s", j.Timeout, loc)
+			}
 		}
-		if ct.Lifecycle.StopTimeout < 0 {
-			return fmt.Errorf("container stop timeout %d cannot be negative in %s", ct.Lifecycle.StopTimeout, loc)
+		if j.Retention < 0 {
+			return nil, fmt.Errorf("job retention %d cannot be negative in %s", j.Retention, loc)
 		}
-
-		if len(ct.User.PrimaryGroup) > 0 && len(ct.User.User) == 0 {
-			return fmt.Errorf("container user primary group cannot be set without setting the user in %s", loc)
+		if j.Lifecycle.AutoRemove {
+			return nil, fmt.Errorf("autoRemove cannot be true since the job container is removed once it exits in %s", loc)
 		}
-
-		if err := validateDevicesConfig(ct.Filesystem.Devices.Static, loc); err != nil {
-			return err
+		if mode := j.Lifecycle.RestartPolicy.Mode; len(mode) > 0 && mode != "no" {
+			return nil, fmt.Errorf("job restart policy mode %s must be 'no' in %s", mode, loc)
 		}
-
-		if err := validateMountsConfig(ct.Filesystem.Mounts, globalConfig.Container.Mounts, globalConfig.MountDefs, fmt.Sprintf("%s mounts", loc)); err != nil {
-			return err
+		// The job container must not be restarted by docker irrespective
+		// of the group and global restart policies, and the order is
+		// irrelevant for the jobs.
+		j.Lifecycle.RestartPolicy.Mode = "no"
+		if j.Lifecycle.Order == 0 {
+			j.Lifecycle.Order = 1
 		}
 
-		if err := validatePublishedPortsConfig(ct.Network.PublishedPorts, loc); err != nil {
-			return err
+		envFileEnv, err := validateContainerConfig(ctx, &j.Container, writtenConfig[i], g, globalConfig, refs, exec, matcher, loc)
+		if err != nil {
+			return nil, err
 		}
 
-		if err := validateSysctlsConfig(ct.Security.Sysctls, loc); err != nil {
-			return err
-		}
+		// This is needed to store the updated job config after
+		// ApplyConfigEnv().
+		jobsConfig[i] = j
+		jc := &jobsConfig[i]
+		ct := newContainer(g, &jc.Container, globalConfig, containerEndpoints[jc.Info], envFileEnv, allowedContainers[jc.Info] || matcher.matchesAny(jc.Placement))
+		jobs[jc.Info] = newJob(jc, ct, host.MustHostInfo(ctx).HostName)
+	}
+	return jobs, nil
+}
 
-		if err := validateHealthConfig(&ct.Health, loc); err != nil {
-			return err
+// validateContainerConfig applies the config env to the container config
+// and validates it, returning the env read from the container env files.
+// The config env is also applied to the container config as written in
+// the config, if specified.
+func validateContainerConfig(ctx context.Context, ct *config.Container, written *config.Container, g *ContainerGroup, globalConfig *config.Global, refs *containerRefs, exec cmdexec.Executor, matcher *hostMatcher, loc string) ([]config.ContainerEnv, error) {
+	ctEnv, err := containerConfigEnv(ctx, g, ct, globalConfig)
+	if err != nil {
+		return nil, err
+	}
+	ctEnv = ctEnv.WithContainerRefs(refs)
+	ct.ApplyConfigEnv(ctEnv)
+	if written != nil {
+		written.ApplyConfigEnv(ctEnv)
+	}
+	if err := ctEnv.Err(); err != nil {
+		return nil, fmt.Errorf("%w in %s", err, loc)
+	}
+	if err := ct.ApplyCmdExecutor(exec); err != nil {
+		return nil, err
+	}
+
+	for _, sel := range ct.Placement {
+		if err := matcher.validateSelector(&sel, fmt.Sprintf("%s placement", loc)); err != nil {
+			return nil, err
 		}
+	}
 
-		if len(ct.Runtime.ShmSize) > 0 {
-			if _, err := units.RAMInBytes(ct.Runtime.ShmSize); err != nil {
-				return fmt.Errorf("invalid shmSize %s in %s, reason: %w", ct.Runtime.ShmSize, loc, err)
-			}
+	if len(ct.Image.Image) == 0 {
+		return nil, fmt.Errorf("image cannot be empty in %s", loc)
+	}
+	if ct.Image.SkipImagePull {
+		if ct.Image.IgnoreImagePullFailures {
+			return nil, fmt.Errorf("ignoreImagePullFailures cannot be true when skipImagePull is true in %s", loc)
 		}
-		if err := validateContainerEnv(ct.Runtime.Env, loc); err != nil {
-			return err
+		if ct.Image.PullImageBeforeStop {
+			return nil, fmt.Errorf("pullImageBeforeStop cannot be true when skipImagePull is true in %s", loc)
 		}
+	}
 
-		g.addContainer(&ct, globalConfig, containerEndpoints[ct.Info], allowedContainers[ct.Info])
-		// This is needed to store the updated container config after
-		// ApplyConfigEnv().
-		containersConfig[i] = ct
+	if err := validateLabelsConfig(ct.Metadata.Labels, loc); err != nil {
+		return nil, err
 	}
 
-	return nil
+	if ct.Lifecycle.Order <= 0 {
+		return nil, fmt.Errorf("container order %d cannot be non-positive in %s", ct.Lifecycle.Order, loc)
+	}
+	if err := validateContainerRestartPolicy(&ct.Lifecycle.RestartPolicy, loc); err != nil {
+		return nil, err
+	}
+	if ct.Lifecycle.StopTimeout < 0 {
+		return nil, fmt.Errorf("container stop timeout %d cannot be negative in %s", ct.Lifecycle.StopTimeout, loc)
+	}
+	if err := validateWaitForConfig(&ct.Lifecycle.WaitFor, refs.endpoints[ct.Info], loc); err != nil {
+		return nil, err
+	}
+	if err := validateHookConfig(&ct.Lifecycle.StartPostHook, "start post-hook", loc); err != nil {
+		return nil, err
+	}
+	if err := validateHookConfig(&ct.Lifecycle.StopPreHook, "stop pre-hook", loc); err != nil {
+		return nil, err
+	}
+	if err := validateHookConfig(&ct.Lifecycle.StopPostHook, "stop post-hook", loc); err != nil {
+		return nil, err
+	}
+	if ct.Lifecycle.StopPostHook.InContainer {
+		return nil, fmt.Errorf("stop post-hook cannot run in the container since the container is stopped by then in %s", loc)
+	}
+
+	if len(ct.User.PrimaryGroup) > 0 && len(ct.User.User) == 0 {
+		return nil, fmt.Errorf("container user primary group cannot be set without setting the user in %s", loc)
+	}
+
+	if err := validateDevicesConfig(ct.Filesystem.Devices.Static, loc); err != nil {
+		return nil, err
+	}
+
+	if err := validateMountsConfig(ct.Filesystem.Mounts, slices.Concat(globalConfig.Container.Mounts, g.config.Container.Mounts), globalConfig.MountDefs, fmt.Sprintf("%s mounts", loc)); err != nil {
+		return nil, err
+	}
+
+	if err := validatePublishedPortsConfig(ct.Network.PublishedPorts, loc); err != nil {
+		return nil, err
+	}
+
+	if err := validateSysctlsConfig(ct.Security.Sysctls, loc); err != nil {
+		return nil, err
+	}
+
+	if err := validateHealthConfig(&ct.Health, loc); err != nil {
+		return nil, err
+	}
+
+	if err := validateBackupConfig(&ct.Backup, loc); err != nil {
+		return nil, err
+	}
+
+	if len(ct.Runtime.ShmSize) > 0 {
+		if _, err := units.RAMInBytes(ct.Runtime.ShmSize); err != nil {
+			return nil, fmt.Errorf("invalid shmSize %s in %s, reason: %w", ct.Runtime.ShmSize, loc, err)
+		}
+	}
+	if err := validateContainerEnv(ct.Runtime.Env, loc); err != nil {
+		return nil, err
+	}
+	return readContainerEnvFiles(ct.Runtime.EnvFiles, env.ContainerConfigsDir(containerBaseDir(globalConfig.BaseDir, ct.Info)), loc)
+}
+
+func containerConfigEnv(ctx context.Context, g *ContainerGroup, ct *config.Container, globalConfig *config.Global) (*env.ConfigEnvManager, error) {
+	loc := fmt.Sprintf("container {Group: %s Container:%s} config", ct.Info.Group, ct.Info.Container)
+	ctConfigEnvMap, ctConfigEnvOrder, err := validateConfigEnv(ct.Config.Env, loc)
+	if err != nil {
+		return nil, err
+	}
+	return g.configEnv.NewContainerConfigEnvManager(ctx, containerGroupBaseDir(globalConfig.BaseDir, ct.Info), containerBaseDir(globalConfig.BaseDir, ct.Info), ctConfigEnvMap, ctConfigEnvOrder), nil
 }
 
 func validateContainerReference(ref *config.ContainerReference) error {
@@ -0,0 +1,172 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/tuxdudehomelab/homelab/internal/docker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+const (
+	defaultWaitForTimeout  = 60 * time.Second
+	defaultWaitForInterval = 1 * time.Second
+)
+
+var errNotReady = errors.New("container is not ready")
+
+// waitForReady blocks until the started container satisfies its wait for
+// condition if any, failing if the container turns unhealthy, stops
+// running or doesn't turn ready within the timeout.
+func (c *Container) waitForReady(ctx context.Context, dc *docker.Client) error {
+	desc := c.waitForDescription()
+	if len(desc) == 0 {
+		return nil
+	}
+
+	timeout := defaultWaitForTimeout
+	if t := c.config.Lifecycle.WaitFor.Timeout; len(t) > 0 {
+		timeout = utils.MustParseDuration(t)
+	}
+	interval := defaultWaitForInterval
+	if i := c.config.Lifecycle.WaitFor.Interval; len(i) > 0 {
+		interval = utils.MustParseDuration(i)
+	}
+
+	log(ctx).Infof("Waiting up to %s for container %s %s", timeout, c.Name(), desc)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		err := c.checkReady(waitCtx, dc, interval)
+		if err == nil {
+			log(ctx).Infof("Container %s is ready", c.Name())
+			return nil
+		}
+		if !errors.Is(err, errNotReady) {
+			return c.waitForError(ctx, dc, desc, err)
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return c.waitForError(ctx, dc, desc, fmt.Errorf("timed out after %s", timeout))
+		case <-time.After(interval):
+		}
+	}
+}
+
+// validateWaitForOnHost returns an error if the container waits for a
+// port while it is managed on a remote host, since the port is probed
+// from the local host which cannot reach the container IP.
+func (c *Container) validateWaitForOnHost(ctx context.Context) error {
+	h := host.MustHostInfo(ctx)
+	if h.Remote && c.config.Lifecycle.WaitFor.Port != 0 {
+		return fmt.Errorf("wait for port for container %s is not supported while managing the remote host %s", c.Name(), h.HostName)
+	}
+	return nil
+}
+
+func (c *Container) waitForDescription() string {
+	w := c.config.Lifecycle.WaitFor
+	switch {
+	case w.Healthy:
+		return "to be healthy"
+	case w.Port != 0:
+		return fmt.Sprintf("to accept connections on port %d", w.Port)
+	case len(w.File) > 0:
+		return fmt.Sprintf("to create the file %s", w.File)
+	default:
+		return ""
+	}
+}
+
+// checkReady returns nil if the container is ready, an error wrapping
+// errNotReady if the container is not ready yet, or any other error if
+// the container cannot turn ready anymore.
+func (c *Container) checkReady(ctx context.Context, dc *docker.Client, interval time.Duration) error {
+	info, err := dc.InspectContainer(ctx, c.Name())
+	if err != nil {
+		return err
+	}
+	if info.State == nil || info.State.Status != "running" {
+		st := "unknown"
+		if info.State != nil {
+			st = info.State.Status
+		}
+		return fmt.Errorf("container is no longer running, current state: %s", st)
+	}
+
+	w := c.config.Lifecycle.WaitFor
+	switch {
+	case w.Healthy:
+		if info.State.Health == nil {
+			return fmt.Errorf("container has no health check")
+		}
+		switch info.State.Health.Status {
+		case dtypes.Healthy:
+			return nil
+		case dtypes.Unhealthy:
+			return fmt.Errorf("container is unhealthy")
+		default:
+			return errNotReady
+		}
+	case w.Port != 0:
+		ip := c.inspectedIP(&info)
+		if len(ip) == 0 {
+			return fmt.Errorf("container has no IP address")
+		}
+		d := net.Dialer{Timeout: interval}
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(w.Port)))
+		if err != nil {
+			return fmt.Errorf("%w, reason: %v", errNotReady, err)
+		}
+		_ = conn.Close()
+		return nil
+	case len(w.File) > 0:
+		exists, err := dc.ContainerPathExists(ctx, c.Name(), w.File)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errNotReady
+		}
+		return nil
+	default:
+		panic("Invalid wait for condition, possibly indicating a bug in the code")
+	}
+}
+
+// inspectedIP returns the IP address of the container in its primary
+// network as reported by docker.
+func (c *Container) inspectedIP(info *dtypes.ContainerJSON) string {
+	if info.NetworkSettings == nil || len(c.endpoints) == 0 {
+		return ""
+	}
+	if ep, found := info.NetworkSettings.Networks[c.endpoints[0].network.Name()]; found && ep != nil {
+		return ep.IPAddress
+	}
+	return ""
+}
+
+// waitForError returns the error for the container failing to turn
+// ready, along with the results of its recent health checks if any.
+func (c *Container) waitForError(ctx context.Context, dc *docker.Client, desc string, reason error) error {
+	err := fmt.Errorf("container %s failed waiting %s, reason: %w", c.Name(), desc, reason)
+
+	info, inspectErr := dc.InspectContainer(ctx, c.Name())
+	if inspectErr != nil || info.State == nil || info.State.Health == nil || len(info.State.Health.Log) == 0 {
+		return err
+	}
+	var sb strings.Builder
+	for _, r := range info.State.Health.Log {
+		sb.WriteString(fmt.Sprintf("\n[exit code: %d] %s", r.ExitCode, strings.TrimSpace(r.Output)))
+	}
+	return fmt.Errorf("%w, health log:%s", err, sb.String())
+}
@@ -0,0 +1,246 @@
+package deployment
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/tuxdude/zzzlog"
+	"github.com/tuxdudehomelab/homelab/internal/config"
+	"github.com/tuxdudehomelab/homelab/internal/docker/fakedocker"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+	"github.com/tuxdudehomelab/homelab/internal/testutils"
+	"github.com/tuxdudehomelab/homelab/internal/utils"
+)
+
+var waitTestContainer = config.ContainerReference{
+	Group:     "g1",
+	Container: "c1",
+}
+
+var containerStartWaitForTests = []struct {
+	name     string
+	waitFor  config.ContainerWaitFor
+	initInfo *fakedocker.FakeDockerHostInitInfo
+	// listen starts a TCP listener on the container IP, whose port is
+	// waited for.
+	listen bool
+	want   string
+}{
+	{
+		name: "Container Start Wait For - Healthy",
+		waitFor: config.ContainerWaitFor{
+			Healthy: true,
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			HealthOnStart: map[string]*fakedocker.FakeContainerHealth{
+				"g1-c1": {
+					Status: "healthy",
+				},
+			},
+		},
+		want: `Starting container g1-c1
+Waiting up to 1m0s for container g1-c1 to be healthy
+Container g1-c1 is ready`,
+	},
+	{
+		name: "Container Start Wait For - File",
+		waitFor: config.ContainerWaitFor{
+			File:    "/run/app/ready",
+			Timeout: "10s",
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			ContainerFiles: map[string]utils.StringSet{
+				"g1-c1": {
+					"/run/app/ready": {},
+				},
+			},
+		},
+		want: `Starting container g1-c1
+Waiting up to 10s for container g1-c1 to create the file /run/app/ready
+Container g1-c1 is ready`,
+	},
+	{
+		name: "Container Start Wait For - Port",
+		waitFor: config.ContainerWaitFor{
+			Interval: "10ms",
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			ContainerIPAddresses: map[string]string{
+				"g1-c1": "127.0.0.1",
+			},
+		},
+		listen: true,
+		want: `Starting container g1-c1
+Waiting up to 1m0s for container g1-c1 to accept connections on port \d+
+Container g1-c1 is ready`,
+	},
+}
+
+func TestContainerStartWaitFor(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerStartWaitForTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			waitFor := tc.waitFor
+			if tc.listen {
+				l, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatalf("failed to listen, reason: %v", err)
+				}
+				defer l.Close()
+				waitFor.Port = l.Addr().(*net.TCPAddr).Port
+			}
+
+			buf := new(bytes.Buffer)
+			_, ct, dc, ctx := newSingleTestContainer(t, tc.name, waitTestContainer, &testutils.TestContextInfo{
+				Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+			}, tc.initInfo, func(conf *config.Homelab) {
+				conf.Containers[0].Lifecycle.WaitFor = waitFor
+			})
+			if ct == nil {
+				return
+			}
+			defer dc.Close()
+
+			_, gotErr := ct.Start(ctx, dc)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNilWithOutput(t, "Container.Start()", tc.name, buf, gotErr)
+				return
+			}
+			want := fmt.Sprintf(`(?s).*%s.*`, tc.want)
+			if !testhelpers.RegexMatch(t, "Container.Start()", tc.name, "log output", want, buf.String()) {
+				return
+			}
+		})
+	}
+}
+
+var containerStartWaitForErrorTests = []struct {
+	name     string
+	waitFor  config.ContainerWaitFor
+	remote   bool
+	initInfo *fakedocker.FakeDockerHostInitInfo
+	want     string
+}{
+	{
+		name: "Container Start Wait For - Unhealthy",
+		waitFor: config.ContainerWaitFor{
+			Healthy: true,
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			HealthOnStart: map[string]*fakedocker.FakeContainerHealth{
+				"g1-c1": {
+					Status: "unhealthy",
+					Log: []string{
+						"pg_isready: no response\n",
+						"pg_isready: connection refused\n",
+					},
+				},
+			},
+		},
+		want: `Failed to start container g1-c1, reason:container g1-c1 failed waiting to be healthy, reason: container is unhealthy, health log:
+\[exit code: 1\] pg_isready: no response
+\[exit code: 1\] pg_isready: connection refused`,
+	},
+	{
+		name: "Container Start Wait For - No Health Check",
+		waitFor: config.ContainerWaitFor{
+			Healthy: true,
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{},
+		want:     `Failed to start container g1-c1, reason:container g1-c1 failed waiting to be healthy, reason: container has no health check`,
+	},
+	{
+		name: "Container Start Wait For - Healthy Timeout",
+		waitFor: config.ContainerWaitFor{
+			Healthy:  true,
+			Timeout:  "50ms",
+			Interval: "10ms",
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			HealthOnStart: map[string]*fakedocker.FakeContainerHealth{
+				"g1-c1": {
+					Status: "starting",
+					Log: []string{
+						"still warming up",
+					},
+				},
+			},
+		},
+		want: `Failed to start container g1-c1, reason:container g1-c1 failed waiting to be healthy, reason: timed out after 50ms, health log:
+\[exit code: 0\] still warming up`,
+	},
+	{
+		name: "Container Start Wait For - File Timeout",
+		waitFor: config.ContainerWaitFor{
+			File:     "/run/app/ready",
+			Timeout:  "50ms",
+			Interval: "10ms",
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{},
+		want:     `Failed to start container g1-c1, reason:container g1-c1 failed waiting to create the file /run/app/ready, reason: timed out after 50ms`,
+	},
+	{
+		name: "Container Start Wait For - Port Without IP",
+		waitFor: config.ContainerWaitFor{
+			Port: 5432,
+		},
+		initInfo: &fakedocker.FakeDockerHostInitInfo{
+			ContainerIPAddresses: map[string]string{
+				"g1-c1": "",
+			},
+		},
+		want: `Failed to start container g1-c1, reason:container g1-c1 failed waiting to accept connections on port 5432, reason: container has no IP address`,
+	},
+	{
+		name: "Container Start Wait For - Port On Remote Host",
+		waitFor: config.ContainerWaitFor{
+			Port: 5432,
+		},
+		remote:   true,
+		initInfo: &fakedocker.FakeDockerHostInitInfo{},
+		want:     `Failed to start container g1-c1, reason:wait for port for container g1-c1 is not supported while managing the remote host fakehost`,
+	},
+}
+
+func TestContainerStartWaitForErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range containerStartWaitForErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			_, ct, dc, ctx := newSingleTestContainer(t, tc.name, waitTestContainer, &testutils.TestContextInfo{
+				Logger: testutils.NewCapturingVanillaTestLogger(zzzlog.LvlInfo, buf),
+			}, tc.initInfo, func(conf *config.Homelab) {
+				conf.Containers[0].Lifecycle.WaitFor = tc.waitFor
+			})
+			if ct == nil {
+				return
+			}
+			defer dc.Close()
+			if tc.remote {
+				h := *host.MustHostInfo(ctx)
+				h.Remote = true
+				ctx = host.WithHostInfo(ctx, &h)
+			}
+
+			_, gotErr := ct.Start(ctx, dc)
+			if gotErr == nil {
+				testhelpers.LogErrorNilWithOutput(t, "Container.Start()", tc.name, buf, tc.want)
+				return
+			}
+			if !testhelpers.RegexMatchWithOutput(t, "Container.Start()", tc.name, buf, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
@@ -6,8 +6,11 @@ import (
 
 	dtypes "github.com/docker/docker/api/types"
 	dcontainer "github.com/docker/docker/api/types/container"
+	devents "github.com/docker/docker/api/types/events"
 	dimage "github.com/docker/docker/api/types/image"
 	dnetwork "github.com/docker/docker/api/types/network"
+	dregistry "github.com/docker/docker/api/types/registry"
+	dsystem "github.com/docker/docker/api/types/system"
 	dclient "github.com/docker/docker/client"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -16,18 +19,35 @@ type APIClient interface {
 	Close() error
 
 	ContainerCreate(ctx context.Context, config *dcontainer.Config, hostConfig *dcontainer.HostConfig, networkingConfig *dnetwork.NetworkingConfig, platform *ocispec.Platform, containerName string) (dcontainer.CreateResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config dcontainer.ExecAttachOptions) (dtypes.HijackedResponse, error)
+	ContainerExecCreate(ctx context.Context, containerName string, options dcontainer.ExecOptions) (dtypes.IDResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (dcontainer.ExecInspect, error)
 	ContainerInspect(ctx context.Context, containerName string) (dtypes.ContainerJSON, error)
 	ContainerKill(ctx context.Context, containerName, signal string) error
+	ContainerLogs(ctx context.Context, containerName string, options dcontainer.LogsOptions) (io.ReadCloser, error)
 	ContainerRemove(ctx context.Context, containerName string, options dcontainer.RemoveOptions) error
+	ContainerRestart(ctx context.Context, containerName string, options dcontainer.StopOptions) error
 	ContainerStart(ctx context.Context, containerName string, options dcontainer.StartOptions) error
+	ContainerStatPath(ctx context.Context, containerName, path string) (dcontainer.PathStat, error)
 	ContainerStop(ctx context.Context, containerName string, options dcontainer.StopOptions) error
+	ContainerWait(ctx context.Context, containerName string, condition dcontainer.WaitCondition) (<-chan dcontainer.WaitResponse, <-chan error)
+	CopyFromContainer(ctx context.Context, containerName, srcPath string) (io.ReadCloser, dcontainer.PathStat, error)
+	CopyToContainer(ctx context.Context, containerName, dstPath string, content io.Reader, options dcontainer.CopyToContainerOptions) error
 
+	DistributionInspect(ctx context.Context, imageRef, encodedRegistryAuth string) (dregistry.DistributionInspect, error)
+
+	Events(ctx context.Context, options devents.ListOptions) (<-chan devents.Message, <-chan error)
+
+	ImageInspectWithRaw(ctx context.Context, imageID string) (dtypes.ImageInspect, []byte, error)
 	ImageList(ctx context.Context, options dimage.ListOptions) ([]dimage.Summary, error)
 	ImagePull(ctx context.Context, refStr string, options dimage.PullOptions) (io.ReadCloser, error)
 
+	Info(ctx context.Context) (dsystem.Info, error)
+
 	NetworkConnect(ctx context.Context, networkName, containerName string, config *dnetwork.EndpointSettings) error
 	NetworkCreate(ctx context.Context, networkName string, options dnetwork.CreateOptions) (dnetwork.CreateResponse, error)
 	NetworkDisconnect(ctx context.Context, networkName, containerName string, force bool) error
+	NetworkInspect(ctx context.Context, networkID string, options dnetwork.InspectOptions) (dnetwork.Inspect, error)
 	NetworkList(ctx context.Context, options dnetwork.ListOptions) ([]dnetwork.Summary, error)
 	NetworkRemove(ctx context.Context, networkName string) error
 }
@@ -1,14 +1,18 @@
 package docker
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 
+	dtypes "github.com/docker/docker/api/types"
 	dcontainer "github.com/docker/docker/api/types/container"
+	devents "github.com/docker/docker/api/types/events"
 	dfilters "github.com/docker/docker/api/types/filters"
 	dimage "github.com/docker/docker/api/types/image"
 	dnetwork "github.com/docker/docker/api/types/network"
@@ -20,6 +24,7 @@ import (
 	"golang.org/x/sys/unix"
 
 	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/moby/term"
 )
 
@@ -98,6 +103,20 @@ func (d *Client) PullImage(ctx context.Context, imageName string) error {
 	return nil
 }
 
+// ImageDigest resolves the image to the digest of its manifest in the
+// registry, without pulling the image.
+func (d *Client) ImageDigest(ctx context.Context, imageName string) (string, error) {
+	info, err := d.client.DistributionInspect(ctx, imageName, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the digest of the image %s, reason: %w", imageName, err)
+	}
+	digest := info.Descriptor.Digest.String()
+	if len(digest) == 0 {
+		return "", fmt.Errorf("registry returned an empty digest for the image %s", imageName)
+	}
+	return digest, nil
+}
+
 func (d *Client) QueryLocalImage(ctx context.Context, imageName string) (bool, string) {
 	filter := dfilters.NewArgs()
 	filter.Add("reference", imageName)
@@ -161,6 +180,20 @@ func (d *Client) StopContainer(ctx context.Context, containerName string) error
 	return nil
 }
 
+// RestartContainer stops the container and starts it again, retaining
+// the container itself unlike recreating it.
+func (d *Client) RestartContainer(ctx context.Context, containerName string) error {
+	log(ctx).Debugf("Restarting container %s ...", containerName)
+	err := d.client.ContainerRestart(ctx, containerName, dcontainer.StopOptions{})
+	if err != nil {
+		log(ctx).Debugf("err: %s", reflect.TypeOf(err))
+		return fmt.Errorf("failed to restart the container, reason: %w", err)
+	}
+
+	log(ctx).Debugf("Container %s restarted successfully", containerName)
+	return nil
+}
+
 func (d *Client) KillContainer(ctx context.Context, containerName string) error {
 	log(ctx).Debugf("Killing container %s ...", containerName)
 	err := d.client.ContainerKill(ctx, containerName, unix.SignalName(unix.SIGKILL))
@@ -196,6 +229,181 @@ func (d *Client) GetContainerState(ctx context.Context, containerName string) (C
 	return containerStateFromString(c.State.Status), nil
 }
 
+// GetContainerHealth returns the health status of the container, i.e.
+// one of starting, healthy or unhealthy. The returned status is empty if
+// the container has no health check.
+func (d *Client) GetContainerHealth(ctx context.Context, containerName string) (string, error) {
+	c, err := d.client.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the container health, reason: %w", err)
+	}
+	if c.State == nil || c.State.Health == nil {
+		return "", nil
+	}
+	return c.State.Health.Status, nil
+}
+
+// ContainerPathExists returns true if the path exists within the
+// filesystem of the container.
+func (d *Client) ContainerPathExists(ctx context.Context, containerName string, path string) (bool, error) {
+	_, err := d.client.ContainerStatPath(ctx, containerName, path)
+	if dclient.IsErrNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat the path %s in the container, reason: %w", path, err)
+	}
+	return true, nil
+}
+
+// ExecInContainer runs the command within the running container with
+// the additional env variables, and returns the combined stdout and
+// stderr output of the command. The command is abandoned once the
+// context is done, and an error is returned if the command exits with a
+// non-zero exit code.
+func (d *Client) ExecInContainer(ctx context.Context, containerName string, cmd []string, env []string) (string, error) {
+	exec, err := d.client.ContainerExecCreate(ctx, containerName, dcontainer.ExecOptions{
+		Cmd:          cmd,
+		Env:          env,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create the exec for the command %q in the container %s, reason: %w", cmd, containerName, err)
+	}
+
+	resp, err := d.client.ContainerExecAttach(ctx, exec.ID, dcontainer.ExecAttachOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to attach to the exec for the command %q in the container %s, reason: %w", cmd, containerName, err)
+	}
+	defer resp.Close()
+
+	var out bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(&out, &out, resp.Reader)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Closing the connection unblocks the copy.
+		resp.Close()
+		<-done
+		return out.String(), fmt.Errorf("command %q in the container %s did not complete, reason: %w", cmd, containerName, ctx.Err())
+	}
+	if err != nil {
+		return out.String(), fmt.Errorf("failed to read the output of the command %q in the container %s, reason: %w", cmd, containerName, err)
+	}
+
+	info, err := d.client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return out.String(), fmt.Errorf("failed to inspect the exec for the command %q in the container %s, reason: %w", cmd, containerName, err)
+	}
+	if info.ExitCode != 0 {
+		return out.String(), fmt.Errorf("command %q in the container %s exited with code %d", cmd, containerName, info.ExitCode)
+	}
+	return out.String(), nil
+}
+
+// WaitContainerExit blocks until the container is no longer running, and
+// returns its exit code.
+func (d *Client) WaitContainerExit(ctx context.Context, containerName string) (int64, error) {
+	respCh, errCh := d.client.ContainerWait(ctx, containerName, dcontainer.WaitConditionNotRunning)
+	select {
+	case resp := <-respCh:
+		if resp.Error != nil {
+			return 0, fmt.Errorf("failed waiting for the container %s to exit, reason: %s", containerName, resp.Error.Message)
+		}
+		return resp.StatusCode, nil
+	case err := <-errCh:
+		return 0, fmt.Errorf("failed waiting for the container %s to exit, reason: %w", containerName, err)
+	}
+}
+
+// GetContainerLogs returns the combined stdout and stderr logs of the
+// container, limited to the last tailLines lines.
+func (d *Client) GetContainerLogs(ctx context.Context, containerName string, tailLines int) (string, error) {
+	info, err := d.InspectContainer(ctx, containerName)
+	if err != nil {
+		return "", err
+	}
+	r, err := d.client.ContainerLogs(ctx, containerName, dcontainer.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(tailLines),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the logs of the container %s, reason: %w", containerName, err)
+	}
+	defer r.Close()
+
+	var out bytes.Buffer
+	// The logs are multiplexed only when the container isn't attached
+	// to a tty.
+	if info.Config != nil && info.Config.Tty {
+		_, err = io.Copy(&out, r)
+	} else {
+		_, err = stdcopy.StdCopy(&out, &out, r)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the logs of the container %s, reason: %w", containerName, err)
+	}
+	return out.String(), nil
+}
+
+// CopyFromContainer returns a tar archive of the path within the
+// filesystem of the container. The entries within the archive are
+// prefixed with the base name of the path.
+func (d *Client) CopyFromContainer(ctx context.Context, containerName string, path string) (io.ReadCloser, error) {
+	r, _, err := d.client.CopyFromContainer(ctx, containerName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy the path %s from the container %s, reason: %w", path, containerName, err)
+	}
+	return r, nil
+}
+
+// CopyToContainer extracts the tar archive into the directory at the
+// path within the filesystem of the container.
+func (d *Client) CopyToContainer(ctx context.Context, containerName string, path string, content io.Reader) error {
+	err := d.client.CopyToContainer(ctx, containerName, path, content, dcontainer.CopyToContainerOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to copy to the path %s in the container %s, reason: %w", path, containerName, err)
+	}
+	return nil
+}
+
+// InspectContainer returns the low-level information about the container.
+func (d *Client) InspectContainer(ctx context.Context, containerName string) (dtypes.ContainerJSON, error) {
+	c, err := d.client.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return dtypes.ContainerJSON{}, fmt.Errorf("failed to inspect the container %s, reason: %w", containerName, err)
+	}
+	return c, nil
+}
+
+// ImageConfig returns the config of the locally available image, i.e.
+// the defaults applied to the containers created using the image.
+func (d *Client) ImageConfig(ctx context.Context, imageName string) (*dcontainer.Config, error) {
+	img, _, err := d.client.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect the image %s, reason: %w", imageName, err)
+	}
+	if img.Config == nil {
+		return &dcontainer.Config{}, nil
+	}
+	return img.Config, nil
+}
+
+// InspectNetwork returns the low-level information about the network.
+func (d *Client) InspectNetwork(ctx context.Context, networkName string) (dnetwork.Inspect, error) {
+	n, err := d.client.NetworkInspect(ctx, networkName, dnetwork.InspectOptions{})
+	if err != nil {
+		return dnetwork.Inspect{}, fmt.Errorf("failed to inspect the network %s, reason: %w", networkName, err)
+	}
+	return n, nil
+}
+
 func (d *Client) CreateNetwork(ctx context.Context, networkName string, options dnetwork.CreateOptions) error {
 	log(ctx).Debugf("Creating network %s ...", networkName)
 	resp, err := d.client.NetworkCreate(ctx, networkName, options)
@@ -265,6 +473,18 @@ func (d *Client) DisconnectContainerFromNetwork(ctx context.Context, containerNa
 	return nil
 }
 
+// WatchEvents subscribes to the container and network events on the
+// docker host until the context is canceled. The error channel receives
+// an error when the subscription ends.
+func (d *Client) WatchEvents(ctx context.Context) (<-chan devents.Message, <-chan error) {
+	filter := dfilters.NewArgs()
+	filter.Add("type", string(devents.ContainerEventType))
+	filter.Add("type", string(devents.NetworkEventType))
+	return d.client.Events(ctx, devents.ListOptions{
+		Filters: filter,
+	})
+}
+
 func (d *Client) ContainerPurgeKillAttempts() uint32 {
 	return d.containerPurgeKillAttempts
 }
@@ -7,10 +7,12 @@ import (
 var (
 	dockerAPIClientKey            = ctxKeyAPIClient{}
 	containerPurgeKillAttemptsKey = ctxKeyContainerPurgeKillAttempts{}
+	remoteAPIClientsKey           = ctxKeyRemoteAPIClients{}
 )
 
 type ctxKeyAPIClient struct{}
 type ctxKeyContainerPurgeKillAttempts struct{}
+type ctxKeyRemoteAPIClients struct{}
 
 func APIClientFromContext(ctx context.Context) (APIClient, bool) {
 	client, ok := ctx.Value(dockerAPIClientKey).(APIClient)
@@ -37,3 +39,18 @@ func getContainerPurgeKillAttempts(ctx context.Context) (uint32, bool) {
 func WithContainerPurgeKillAttempts(ctx context.Context, attempts uint32) context.Context {
 	return context.WithValue(ctx, containerPurgeKillAttemptsKey, attempts)
 }
+
+func remoteAPIClientFromContext(ctx context.Context, endpoint string) (APIClient, bool) {
+	clients, ok := ctx.Value(remoteAPIClientsKey).(map[string]APIClient)
+	if !ok {
+		return nil, false
+	}
+	client, ok := clients[endpoint]
+	return client, ok
+}
+
+// WithRemoteAPIClients returns a context with the docker API clients to
+// use for the remote docker endpoints, keyed by the endpoint.
+func WithRemoteAPIClients(ctx context.Context, clients map[string]APIClient) context.Context {
+	return context.WithValue(ctx, remoteAPIClientsKey, clients)
+}
@@ -1,11 +1,20 @@
 package fakedocker
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/sasha-s/go-deadlock"
 	"github.com/tuxdudehomelab/homelab/internal/docker"
@@ -13,14 +22,25 @@ import (
 
 	dtypes "github.com/docker/docker/api/types"
 	dcontainer "github.com/docker/docker/api/types/container"
+	devents "github.com/docker/docker/api/types/events"
 	dimage "github.com/docker/docker/api/types/image"
 	dnetwork "github.com/docker/docker/api/types/network"
+	dregistry "github.com/docker/docker/api/types/registry"
+	dsystem "github.com/docker/docker/api/types/system"
 	derrdefs "github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	digest "github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+const (
+	fakeEventsBufferSize = 1000
+	fakeWaitPollInterval = 5 * time.Millisecond
+)
+
 type FakeDockerHost struct {
 	mu                   deadlock.RWMutex
+	eventSubscribers     map[*fakeEventSubscriber]struct{}
 	containers           fakeContainerMap
 	networks             fakeNetworkMap
 	images               fakeImageMap
@@ -29,6 +49,7 @@ type FakeDockerHost struct {
 	failContainerInspect utils.StringSet
 	failContainerKill    utils.StringSet
 	failContainerRemove  utils.StringSet
+	failContainerRestart utils.StringSet
 	failContainerStart   utils.StringSet
 	failContainerStop    utils.StringSet
 	validImagesForPull   utils.StringSet
@@ -38,13 +59,27 @@ type FakeDockerHost struct {
 	failNetworkCreate    utils.StringSet
 	failNetworkRemove    utils.StringSet
 	failNetworkConnect   utils.StringSet
+	containerFiles       map[string]utils.StringSet
+	containerIPAddresses map[string]string
+	healthOnStart        map[string]*FakeContainerHealth
+	containerExecs       map[string][]*FakeContainerExec
+	execs                map[string]*fakeExecInfo
+	executedCmds         map[string][]*FakeExecutedCmd
+	exitOnStart          map[string]*FakeContainerExit
+	imageDigests         map[string]string
+	hostName             string
 }
 
 type fakeContainerInfo struct {
 	name                 string
 	id                   string
 	state                docker.ContainerState
+	health               string
+	healthLog            []string
+	exitCode             int
+	logs                 string
 	containerStopIssued  bool
+	restartCount         int
 	pendingRequiredStops int
 	pendingRequiredKills int
 	containerConfig      *dcontainer.Config
@@ -59,20 +94,75 @@ type fakeNetworkInfo struct {
 }
 
 type fakeImageInfo struct {
-	name string
-	id   string
+	name   string
+	id     string
+	config *dcontainer.Config
 }
 
+type fakeEventSubscriber struct {
+	types    utils.StringSet
+	messages chan devents.Message
+}
+
+// FakeContainerInitInfo represents a container that already exists on
+// the fake docker host. Health, Config, HostConfig and NetworkConfig are
+// optional and returned while inspecting the container.
 type FakeContainerInitInfo struct {
 	Name               string
 	Image              string
 	State              docker.ContainerState
+	Health             string
 	RequiredExtraStops int
 	RequiredExtraKills int
+	Config             *dcontainer.Config
+	HostConfig         *dcontainer.HostConfig
+	NetworkConfig      *dnetwork.NetworkingConfig
 }
 
+type fakeExecInfo struct {
+	containerName string
+	output        string
+	exitCode      int
+}
+
+// FakeContainerExec represents a command that can be executed within a
+// container on the fake docker host, along with its combined output and
+// exit code.
+type FakeContainerExec struct {
+	Cmd      []string
+	Output   string
+	ExitCode int
+}
+
+// FakeExecutedCmd represents a command executed within a container on
+// the fake docker host, along with the env variables passed to it.
+type FakeExecutedCmd struct {
+	Cmd []string
+	Env []string
+}
+
+// FakeContainerExit represents a container on the fake docker host that
+// exits on its own with the exit code once started, after writing the
+// logs.
+type FakeContainerExit struct {
+	ExitCode int
+	Logs     string
+}
+
+// FakeContainerHealth represents the health check results reported by
+// a container on the fake docker host. Log lists the output of the
+// health checks, oldest first.
+type FakeContainerHealth struct {
+	Status string
+	Log    []string
+}
+
+// FakeNetworkInitInfo represents a network that already exists on the
+// fake docker host. Options is optional and returned while inspecting
+// the network.
 type FakeNetworkInitInfo struct {
-	Name string
+	Name    string
+	Options *dnetwork.CreateOptions
 }
 
 type fakeContainerMap map[string]*fakeContainerInfo
@@ -83,11 +173,13 @@ type FakeDockerHostInitInfo struct {
 	Containers           []*FakeContainerInitInfo
 	Networks             []*FakeNetworkInitInfo
 	ExistingImages       utils.StringSet
+	ImageConfigs         map[string]*dcontainer.Config
 	WarnContainerCreate  utils.StringSet
 	FailContainerCreate  utils.StringSet
 	FailContainerInspect utils.StringSet
 	FailContainerKill    utils.StringSet
 	FailContainerRemove  utils.StringSet
+	FailContainerRestart utils.StringSet
 	FailContainerStart   utils.StringSet
 	FailContainerStop    utils.StringSet
 	ValidImagesForPull   utils.StringSet
@@ -97,6 +189,29 @@ type FakeDockerHostInitInfo struct {
 	FailNetworkCreate    utils.StringSet
 	FailNetworkRemove    utils.StringSet
 	FailNetworkConnect   utils.StringSet
+	// ContainerFiles lists the paths that exist within the containers,
+	// keyed by the container name.
+	ContainerFiles map[string]utils.StringSet
+	// ContainerIPAddresses overrides the IP address of the containers in
+	// all their networks while inspecting them, keyed by the container
+	// name.
+	ContainerIPAddresses map[string]string
+	// HealthOnStart is the health reported by the containers once they
+	// are started, keyed by the container name.
+	HealthOnStart map[string]*FakeContainerHealth
+	// ContainerExecs lists the commands that can be executed within the
+	// containers, keyed by the container name. Any other commands exit
+	// with the exit code 127.
+	ContainerExecs map[string][]*FakeContainerExec
+	// ExitOnStart lists the containers that exit on their own once
+	// started, keyed by the container name.
+	ExitOnStart map[string]*FakeContainerExit
+	// ImageDigests are the digests of the images in the registry, keyed
+	// by the image name. The other images are not found in the registry.
+	ImageDigests map[string]string
+	// HostName is the name reported by the fake docker host, defaulting
+	// to FakeHost.
+	HostName string
 }
 
 type wrappedReader func(p []byte) (int, error)
@@ -118,6 +233,7 @@ func NewEmptyFakeDockerHost() *FakeDockerHost {
 
 func NewFakeDockerHost(initInfo *FakeDockerHostInitInfo) *FakeDockerHost {
 	f := &FakeDockerHost{
+		eventSubscribers:     map[*fakeEventSubscriber]struct{}{},
 		containers:           fakeContainerMap{},
 		networks:             fakeNetworkMap{},
 		images:               fakeImageMap{},
@@ -126,6 +242,7 @@ func NewFakeDockerHost(initInfo *FakeDockerHostInitInfo) *FakeDockerHost {
 		failContainerInspect: utils.StringSet{},
 		failContainerKill:    utils.StringSet{},
 		failContainerRemove:  utils.StringSet{},
+		failContainerRestart: utils.StringSet{},
 		failContainerStart:   utils.StringSet{},
 		failContainerStop:    utils.StringSet{},
 		validImagesForPull:   utils.StringSet{},
@@ -135,28 +252,58 @@ func NewFakeDockerHost(initInfo *FakeDockerHostInitInfo) *FakeDockerHost {
 		failNetworkCreate:    utils.StringSet{},
 		failNetworkRemove:    utils.StringSet{},
 		failNetworkConnect:   utils.StringSet{},
+		containerFiles:       map[string]utils.StringSet{},
+		containerIPAddresses: map[string]string{},
+		healthOnStart:        map[string]*FakeContainerHealth{},
+		containerExecs:       map[string][]*FakeContainerExec{},
+		execs:                map[string]*fakeExecInfo{},
+		executedCmds:         map[string][]*FakeExecutedCmd{},
+		exitOnStart:          map[string]*FakeContainerExit{},
+		imageDigests:         map[string]string{},
+		hostName:             "FakeHost",
 	}
 	if initInfo == nil {
 		return f
 	}
 
+	if len(initInfo.HostName) > 0 {
+		f.hostName = initInfo.HostName
+	}
+
 	for _, ct := range initInfo.Containers {
-		ctInfo := newFakeContainerInfo(
-			ct.Name,
-			&dcontainer.Config{Image: ct.Image},
-			&dcontainer.HostConfig{},
-			&dnetwork.NetworkingConfig{})
+		cConfig := &dcontainer.Config{}
+		if ct.Config != nil {
+			cConfig = ct.Config
+		}
+		if len(ct.Image) > 0 {
+			cConfig.Image = ct.Image
+		}
+		hConfig := &dcontainer.HostConfig{}
+		if ct.HostConfig != nil {
+			hConfig = ct.HostConfig
+		}
+		nConfig := &dnetwork.NetworkingConfig{}
+		if ct.NetworkConfig != nil {
+			nConfig = ct.NetworkConfig
+		}
+		ctInfo := newFakeContainerInfo(ct.Name, cConfig, hConfig, nConfig)
 		ctInfo.state = ct.State
+		ctInfo.health = ct.Health
 		ctInfo.pendingRequiredStops = ct.RequiredExtraStops
 		ctInfo.pendingRequiredKills = ct.RequiredExtraKills
 		f.containers[ct.Name] = ctInfo
 	}
 	for _, n := range initInfo.Networks {
 		f.networks[n.Name] = newFakeNetworkInfo(n.Name)
+		f.networks[n.Name].options = n.Options
 	}
 	for img := range initInfo.ExistingImages {
 		f.images[img] = newFakeImageInfo(img)
 	}
+	for img, conf := range initInfo.ImageConfigs {
+		f.images[img] = newFakeImageInfo(img)
+		f.images[img].config = conf
+	}
 	for c := range initInfo.WarnContainerCreate {
 		f.warnContainerCreate[c] = struct{}{}
 	}
@@ -172,6 +319,9 @@ func NewFakeDockerHost(initInfo *FakeDockerHostInitInfo) *FakeDockerHost {
 	for c := range initInfo.FailContainerRemove {
 		f.failContainerRemove[c] = struct{}{}
 	}
+	for c := range initInfo.FailContainerRestart {
+		f.failContainerRestart[c] = struct{}{}
+	}
 	for c := range initInfo.FailContainerStart {
 		f.failContainerStart[c] = struct{}{}
 	}
@@ -199,6 +349,24 @@ func NewFakeDockerHost(initInfo *FakeDockerHostInitInfo) *FakeDockerHost {
 	for n := range initInfo.FailNetworkConnect {
 		f.failNetworkConnect[n] = struct{}{}
 	}
+	for c, files := range initInfo.ContainerFiles {
+		f.containerFiles[c] = files
+	}
+	for c, ip := range initInfo.ContainerIPAddresses {
+		f.containerIPAddresses[c] = ip
+	}
+	for c, h := range initInfo.HealthOnStart {
+		f.healthOnStart[c] = h
+	}
+	for c, e := range initInfo.ContainerExecs {
+		f.containerExecs[c] = e
+	}
+	for c, e := range initInfo.ExitOnStart {
+		f.exitOnStart[c] = e
+	}
+	for i, d := range initInfo.ImageDigests {
+		f.imageDigests[i] = d
+	}
 	return f
 }
 
@@ -256,6 +424,7 @@ func (f *FakeDockerHost) ContainerCreate(ctx context.Context, cConfig *dcontaine
 	ct := newFakeContainerInfo(containerName, cConfig, hConfig, nConfig)
 	f.containers[containerName] = ct
 	resp.ID = ct.id
+	f.emitContainerEvent(ct, devents.ActionCreate)
 
 	if _, found := f.warnContainerCreate[containerName]; found {
 		resp.Warnings = []string{
@@ -268,6 +437,74 @@ func (f *FakeDockerHost) ContainerCreate(ctx context.Context, cConfig *dcontaine
 	return resp, nil
 }
 
+func (f *FakeDockerHost) ContainerExecAttach(ctx context.Context, execID string, config dcontainer.ExecAttachOptions) (dtypes.HijackedResponse, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	exec, found := f.execs[execID]
+	if !found {
+		return dtypes.HijackedResponse{}, derrdefs.NotFound(fmt.Errorf("exec %s not found on the fake docker host", execID))
+	}
+
+	var out bytes.Buffer
+	if _, err := stdcopy.NewStdWriter(&out, stdcopy.Stdout).Write([]byte(exec.output)); err != nil {
+		return dtypes.HijackedResponse{}, err
+	}
+	conn, peer := net.Pipe()
+	_ = peer.Close()
+	return dtypes.HijackedResponse{
+		Conn:   conn,
+		Reader: bufio.NewReader(&out),
+	}, nil
+}
+
+func (f *FakeDockerHost) ContainerExecCreate(ctx context.Context, containerName string, options dcontainer.ExecOptions) (dtypes.IDResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ct, found := f.containers[containerName]
+	if !found {
+		return dtypes.IDResponse{}, derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+	if ct.state != docker.ContainerStateRunning {
+		return dtypes.IDResponse{}, derrdefs.Conflict(fmt.Errorf("container %s is not running on the fake docker host", containerName))
+	}
+
+	exec := &fakeExecInfo{
+		containerName: containerName,
+		output:        fmt.Sprintf("%s: command not found\n", options.Cmd[0]),
+		exitCode:      127,
+	}
+	for _, e := range f.containerExecs[containerName] {
+		if reflect.DeepEqual(e.Cmd, options.Cmd) {
+			exec.output = e.Output
+			exec.exitCode = e.ExitCode
+			break
+		}
+	}
+	id := randomSHA256ID()
+	f.execs[id] = exec
+	f.executedCmds[containerName] = append(f.executedCmds[containerName], &FakeExecutedCmd{
+		Cmd: options.Cmd,
+		Env: options.Env,
+	})
+	return dtypes.IDResponse{ID: id}, nil
+}
+
+func (f *FakeDockerHost) ContainerExecInspect(ctx context.Context, execID string) (dcontainer.ExecInspect, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	exec, found := f.execs[execID]
+	if !found {
+		return dcontainer.ExecInspect{}, derrdefs.NotFound(fmt.Errorf("exec %s not found on the fake docker host", execID))
+	}
+	return dcontainer.ExecInspect{
+		ExecID:   execID,
+		ExitCode: exec.exitCode,
+	}, nil
+}
+
 func (f *FakeDockerHost) ContainerInspect(ctx context.Context, containerName string) (dtypes.ContainerJSON, error) {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -281,14 +518,33 @@ func (f *FakeDockerHost) ContainerInspect(ctx context.Context, containerName str
 		return dtypes.ContainerJSON{}, fmt.Errorf("failed to inspect container %s on the fake docker host", containerName)
 	}
 
-	return dtypes.ContainerJSON{
+	res := dtypes.ContainerJSON{
 		ContainerJSONBase: &dtypes.ContainerJSONBase{
-			ID:    ct.id,
-			State: fakeDockerContainerState(ct.state),
-			Image: ct.containerConfig.Image,
-			Name:  ct.name,
+			ID:           ct.id,
+			State:        fakeDockerContainerState(ct.state, ct.health, ct.healthLog),
+			Image:        ct.containerConfig.Image,
+			Name:         ct.name,
+			RestartCount: ct.restartCount,
+			HostConfig:   ct.hostConfig,
 		},
-	}, nil
+		Config:          ct.containerConfig,
+		NetworkSettings: &dtypes.NetworkSettings{},
+	}
+	if ct.networkConfig != nil && ct.networkConfig.EndpointsConfig != nil {
+		res.NetworkSettings.Networks = map[string]*dnetwork.EndpointSettings{}
+		for n, ep := range ct.networkConfig.EndpointsConfig {
+			// Report the IP address assigned to the container, without
+			// modifying the endpoint settings it was created with.
+			e := *ep
+			if ip, found := f.containerIPAddresses[containerName]; found {
+				e.IPAddress = ip
+			} else if e.IPAMConfig != nil {
+				e.IPAddress = e.IPAMConfig.IPv4Address
+			}
+			res.NetworkSettings.Networks[n] = &e
+		}
+	}
+	return res, nil
 }
 
 func (f *FakeDockerHost) ContainerKill(ctx context.Context, containerName, signal string) error {
@@ -310,6 +566,10 @@ func (f *FakeDockerHost) ContainerKill(ctx context.Context, containerName, signa
 			}
 
 			ct.state = docker.ContainerStateExited
+			// Exit code of the processes killed by SIGKILL.
+			ct.exitCode = 137
+			f.emitContainerEvent(ct, devents.ActionKill)
+			f.emitContainerEvent(ct, devents.ActionDie)
 		}
 		return nil
 	case docker.ContainerStateCreated, docker.ContainerStateExited, docker.ContainerStateDead, docker.ContainerStateRemoving:
@@ -323,7 +583,28 @@ func (f *FakeDockerHost) ContainerKill(ctx context.Context, containerName, signa
 	}
 }
 
+func (f *FakeDockerHost) ContainerLogs(ctx context.Context, containerName string, options dcontainer.LogsOptions) (io.ReadCloser, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	ct, found := f.containers[containerName]
+	if !found {
+		return nil, derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+
+	var out bytes.Buffer
+	if _, err := stdcopy.NewStdWriter(&out, stdcopy.Stdout).Write([]byte(ct.logs)); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&out), nil
+}
+
 func (f *FakeDockerHost) ContainerRemove(ctx context.Context, containerName string, options dcontainer.RemoveOptions) error {
+	// The requests fail once the context is done, like the real client.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -339,6 +620,7 @@ func (f *FakeDockerHost) ContainerRemove(ctx context.Context, containerName stri
 		}
 
 		delete(f.containers, containerName)
+		f.emitContainerEvent(ct, devents.ActionDestroy)
 		return nil
 	case docker.ContainerStateRunning, docker.ContainerStatePaused, docker.ContainerStateRestarting, docker.ContainerStateRemoving:
 		return fmt.Errorf("container in state %s on the fake docker host cannot be removed", ct.state)
@@ -351,6 +633,47 @@ func (f *FakeDockerHost) ContainerRemove(ctx context.Context, containerName stri
 	}
 }
 
+func (f *FakeDockerHost) ContainerRestart(ctx context.Context, containerName string, options dcontainer.StopOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ct, found := f.containers[containerName]
+	if !found {
+		return derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+
+	switch ct.state {
+	case docker.ContainerStateRunning, docker.ContainerStateCreated, docker.ContainerStateExited:
+		if _, found := f.failContainerRestart[containerName]; found {
+			return fmt.Errorf("failed to restart container %s on the fake docker host", containerName)
+		}
+
+		if ct.state == docker.ContainerStateRunning {
+			f.emitContainerEvent(ct, devents.ActionDie)
+			f.emitContainerEvent(ct, devents.ActionStop)
+		}
+		ct.state = docker.ContainerStateRunning
+		ct.restartCount++
+		// The health check starts over once the container restarts.
+		if len(ct.health) > 0 {
+			ct.health = dtypes.Starting
+			ct.healthLog = nil
+		}
+		f.applyHealthOnStart(ct)
+		f.emitContainerEvent(ct, devents.ActionStart)
+		f.emitContainerEvent(ct, devents.ActionRestart)
+		return nil
+	case docker.ContainerStatePaused, docker.ContainerStateRestarting, docker.ContainerStateDead, docker.ContainerStateRemoving:
+		return fmt.Errorf("container in state %s on the fake docker host cannot be restarted", ct.state)
+	case docker.ContainerStateUnknown:
+		panic("ContainerRestart invoked on a container in an unknown state on the fake docker host, possibly indicating a bug")
+	case docker.ContainerStateNotFound:
+		panic("ContainerRestart invoked on a container in a not found state on the fake docker host, possibly indicating a bug")
+	default:
+		panic(fmt.Sprintf("ContainerRestart invoked on a container in %s state on the fake docker host, possibly indicating a bug", ct.state))
+	}
+}
+
 func (f *FakeDockerHost) ContainerStart(ctx context.Context, containerName string, options dcontainer.StartOptions) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -360,8 +683,10 @@ func (f *FakeDockerHost) ContainerStart(ctx context.Context, containerName strin
 		return derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
 	}
 
-	if ct.state != docker.ContainerStateCreated {
-		return fmt.Errorf("container %s is not in created state that is required to start the container, but rather in state %s on the fake docker host", containerName, ct.state)
+	// Like the docker daemon, the stopped containers can be started
+	// again in addition to the newly created ones.
+	if ct.state != docker.ContainerStateCreated && ct.state != docker.ContainerStateExited {
+		return fmt.Errorf("container %s is not in created or exited state that is required to start the container, but rather in state %s on the fake docker host", containerName, ct.state)
 	}
 
 	if _, found := f.failContainerStart[containerName]; found {
@@ -369,10 +694,39 @@ func (f *FakeDockerHost) ContainerStart(ctx context.Context, containerName strin
 	}
 
 	ct.state = docker.ContainerStateRunning
+	f.applyHealthOnStart(ct)
+	f.emitContainerEvent(ct, devents.ActionStart)
+	if e, found := f.exitOnStart[containerName]; found {
+		ct.state = docker.ContainerStateExited
+		ct.exitCode = e.ExitCode
+		ct.logs = e.Logs
+		f.emitContainerEvent(ct, devents.ActionDie)
+	}
 	return nil
 }
 
+func (f *FakeDockerHost) ContainerStatPath(ctx context.Context, containerName, path string) (dcontainer.PathStat, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if _, found := f.containers[containerName]; !found {
+		return dcontainer.PathStat{}, derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+	if _, found := f.containerFiles[containerName][path]; !found {
+		return dcontainer.PathStat{}, derrdefs.NotFound(fmt.Errorf("path %s not found in container %s on the fake docker host", path, containerName))
+	}
+	return dcontainer.PathStat{
+		Name: filepath.Base(path),
+		Mode: 0o644,
+	}, nil
+}
+
 func (f *FakeDockerHost) ContainerStop(ctx context.Context, containerName string, options dcontainer.StopOptions) error {
+	// The requests fail once the context is done, like the real client.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -392,6 +746,8 @@ func (f *FakeDockerHost) ContainerStop(ctx context.Context, containerName string
 			ct.pendingRequiredStops--
 		} else {
 			ct.state = docker.ContainerStateExited
+			f.emitContainerEvent(ct, devents.ActionDie)
+			f.emitContainerEvent(ct, devents.ActionStop)
 		}
 		return nil
 	// Created, Exited can be no-op stopped. Possibly Dead and Removing too.
@@ -408,6 +764,162 @@ func (f *FakeDockerHost) ContainerStop(ctx context.Context, containerName string
 	}
 }
 
+func (f *FakeDockerHost) ContainerWait(ctx context.Context, containerName string, condition dcontainer.WaitCondition) (<-chan dcontainer.WaitResponse, <-chan error) {
+	respCh := make(chan dcontainer.WaitResponse, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			f.mu.RLock()
+			ct, found := f.containers[containerName]
+			var st docker.ContainerState
+			var exitCode int
+			if found {
+				st = ct.state
+				exitCode = ct.exitCode
+			}
+			f.mu.RUnlock()
+
+			if !found {
+				errCh <- derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+				return
+			}
+			if st != docker.ContainerStateRunning && st != docker.ContainerStatePaused && st != docker.ContainerStateRestarting {
+				respCh <- dcontainer.WaitResponse{StatusCode: int64(exitCode)}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			case <-time.After(fakeWaitPollInterval):
+			}
+		}
+	}()
+	return respCh, errCh
+}
+
+func (f *FakeDockerHost) CopyFromContainer(ctx context.Context, containerName, srcPath string) (io.ReadCloser, dcontainer.PathStat, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if _, found := f.containers[containerName]; !found {
+		return nil, dcontainer.PathStat{}, derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+
+	// The source path is treated as a directory holding the paths under
+	// it, which are archived as empty files with the base name of the
+	// source path as the prefix.
+	srcPath = filepath.Clean(srcPath)
+	base := filepath.Base(srcPath)
+	var files []string
+	for p := range f.containerFiles[containerName] {
+		if rel, found := strings.CutPrefix(p, srcPath+"/"); found {
+			files = append(files, base+"/"+rel)
+		}
+	}
+	sort.Strings(files)
+
+	var out bytes.Buffer
+	tw := tar.NewWriter(&out)
+	if err := tw.WriteHeader(&tar.Header{Name: base + "/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		return nil, dcontainer.PathStat{}, err
+	}
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: file, Typeflag: tar.TypeReg, Mode: 0o644}); err != nil {
+			return nil, dcontainer.PathStat{}, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, dcontainer.PathStat{}, err
+	}
+	return io.NopCloser(&out), dcontainer.PathStat{Name: base, Mode: 0o755}, nil
+}
+
+func (f *FakeDockerHost) CopyToContainer(ctx context.Context, containerName, dstPath string, content io.Reader, options dcontainer.CopyToContainerOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, found := f.containers[containerName]; !found {
+		return derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+
+	files := utils.StringSet{}
+	tr := tar.NewReader(content)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the archive copied to container %s on the fake docker host, reason: %w", containerName, err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			files[filepath.Join(dstPath, hdr.Name)] = struct{}{}
+		}
+	}
+	if f.containerFiles[containerName] == nil {
+		f.containerFiles[containerName] = utils.StringSet{}
+	}
+	for p := range files {
+		f.containerFiles[containerName][p] = struct{}{}
+	}
+	return nil
+}
+
+func (f *FakeDockerHost) DistributionInspect(ctx context.Context, imageRef, encodedRegistryAuth string) (dregistry.DistributionInspect, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	d, found := f.imageDigests[imageRef]
+	if !found {
+		return dregistry.DistributionInspect{}, derrdefs.NotFound(fmt.Errorf("image %s not found in the registry on the fake docker host", imageRef))
+	}
+	return dregistry.DistributionInspect{
+		Descriptor: ocispec.Descriptor{
+			Digest: digest.Digest(d),
+		},
+	}, nil
+}
+
+func (f *FakeDockerHost) Events(ctx context.Context, options devents.ListOptions) (<-chan devents.Message, <-chan error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := &fakeEventSubscriber{
+		types:    utils.StringSet{},
+		messages: make(chan devents.Message, fakeEventsBufferSize),
+	}
+	for _, t := range options.Filters.Get("type") {
+		sub.types[t] = struct{}{}
+	}
+	f.eventSubscribers[sub] = struct{}{}
+
+	errs := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		delete(f.eventSubscribers, sub)
+		errs <- ctx.Err()
+	}()
+	return sub.messages, errs
+}
+
+func (f *FakeDockerHost) ImageInspectWithRaw(ctx context.Context, imageID string) (dtypes.ImageInspect, []byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	img, found := f.images[imageID]
+	if !found {
+		return dtypes.ImageInspect{}, nil, derrdefs.NotFound(fmt.Errorf("image %s not found on the fake docker host", imageID))
+	}
+	return dtypes.ImageInspect{
+		ID:     img.id,
+		Config: img.config,
+	}, nil, nil
+}
+
 func (f *FakeDockerHost) ImageList(ctx context.Context, options dimage.ListOptions) ([]dimage.Summary, error) {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -468,6 +980,17 @@ func (f *FakeDockerHost) ImagePull(ctx context.Context, imageName string, option
 	})), nil
 }
 
+func (f *FakeDockerHost) Info(ctx context.Context) (dsystem.Info, error) {
+	return dsystem.Info{
+		Name:          f.hostName,
+		OSType:        "linux",
+		Architecture:  "x86_64",
+		NCPU:          32,
+		KernelVersion: "6.1.0-76-fake",
+		ServerVersion: "27.3.1",
+	}, nil
+}
+
 func (f *FakeDockerHost) NetworkConnect(ctx context.Context, networkName, containerName string, config *dnetwork.EndpointSettings) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -502,6 +1025,7 @@ func (f *FakeDockerHost) NetworkCreate(ctx context.Context, networkName string,
 	n.options = &options
 	f.networks[networkName] = n
 	resp.ID = n.id
+	f.emitEvent(devents.NetworkEventType, devents.ActionCreate, n.id, n.name)
 	if _, found := f.warnNetworkCreate[networkName]; found {
 		resp.Warning = fmt.Sprintf("warning generated during network create for network %s on the fake docker host", networkName)
 	}
@@ -512,6 +1036,29 @@ func (f *FakeDockerHost) NetworkDisconnect(ctx context.Context, networkName, con
 	panic("NetworkDisconnect unimplemented")
 }
 
+func (f *FakeDockerHost) NetworkInspect(ctx context.Context, networkID string, options dnetwork.InspectOptions) (dnetwork.Inspect, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n, found := f.networks[networkID]
+	if !found {
+		return dnetwork.Inspect{}, derrdefs.NotFound(fmt.Errorf("network %s not found on the fake docker host", networkID))
+	}
+	res := dnetwork.Inspect{
+		Name:  n.name,
+		ID:    n.id,
+		Scope: "local",
+	}
+	if n.options != nil {
+		res.Driver = n.options.Driver
+		res.Options = n.options.Options
+		if n.options.IPAM != nil {
+			res.IPAM = *n.options.IPAM
+		}
+	}
+	return res, nil
+}
+
 func (f *FakeDockerHost) NetworkList(ctx context.Context, options dnetwork.ListOptions) ([]dnetwork.Summary, error) {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -543,7 +1090,8 @@ func (f *FakeDockerHost) NetworkRemove(ctx context.Context, networkName string)
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	if _, found := f.networks[networkName]; !found {
+	n, found := f.networks[networkName]
+	if !found {
 		return derrdefs.NotFound(fmt.Errorf("network %s not found on the fake docker host", networkName))
 	}
 	if _, found := f.failNetworkRemove[networkName]; found {
@@ -551,6 +1099,7 @@ func (f *FakeDockerHost) NetworkRemove(ctx context.Context, networkName string)
 	}
 
 	delete(f.networks, networkName)
+	f.emitEvent(devents.NetworkEventType, devents.ActionDestroy, n.id, n.name)
 	return nil
 }
 
@@ -558,15 +1107,88 @@ func (f *FakeDockerHost) ForceRemoveContainer(containerName string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	_, found := f.containers[containerName]
+	ct, found := f.containers[containerName]
 	if !found {
 		return derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
 	}
 
+	if ct.state == docker.ContainerStateRunning {
+		f.emitContainerEvent(ct, devents.ActionKill)
+		f.emitContainerEvent(ct, devents.ActionDie)
+	}
 	delete(f.containers, containerName)
+	f.emitContainerEvent(ct, devents.ActionDestroy)
 	return nil
 }
 
+// ExitContainer simulates the running container exiting on its own, for
+// instance due to a crash.
+func (f *FakeDockerHost) ExitContainer(containerName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ct, found := f.containers[containerName]
+	if !found {
+		return derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+	if ct.state != docker.ContainerStateRunning {
+		return fmt.Errorf("container in state %s on the fake docker host cannot exit", ct.state)
+	}
+
+	ct.state = docker.ContainerStateExited
+	f.emitContainerEvent(ct, devents.ActionDie)
+	return nil
+}
+
+// SetContainerHealth simulates the health check of the container
+// reporting the specified health status.
+func (f *FakeDockerHost) SetContainerHealth(containerName string, health string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ct, found := f.containers[containerName]
+	if !found {
+		return derrdefs.NotFound(fmt.Errorf("container %s not found on the fake docker host", containerName))
+	}
+
+	ct.health = health
+	f.emitContainerEvent(ct, devents.Action(fmt.Sprintf("%s: %s", devents.ActionHealthStatus, health)))
+	return nil
+}
+
+// SetImageDigest updates the digest of the image in the registry, as if a
+// new version of the image was pushed.
+func (f *FakeDockerHost) SetImageDigest(imageName string, imageDigest string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.imageDigests[imageName] = imageDigest
+}
+
+// ContainerID returns the ID of the container, or an empty string if the
+// container doesn't exist.
+func (f *FakeDockerHost) ContainerID(containerName string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if ct, found := f.containers[containerName]; found {
+		return ct.id
+	}
+	return ""
+}
+
+// ContainerRestartCount returns the number of times the container was
+// restarted, or zero if the container doesn't exist.
+func (f *FakeDockerHost) ContainerRestartCount(containerName string) int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if ct, found := f.containers[containerName]; found {
+		return ct.restartCount
+	}
+	return 0
+}
+
 func (f *FakeDockerHost) GetContainerState(containerName string) docker.ContainerState {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -588,8 +1210,90 @@ func (f *FakeDockerHost) ContainerStopIssued(containerName string) bool {
 	return false
 }
 
-func fakeDockerContainerState(state docker.ContainerState) *dtypes.ContainerState {
+// ExecutedCmds returns the commands executed within the container so
+// far, oldest first.
+func (f *FakeDockerHost) ExecutedCmds(containerName string) []*FakeExecutedCmd {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.executedCmds[containerName]
+}
+
+// ContainerFiles returns the sorted paths that exist within the
+// container.
+func (f *FakeDockerHost) ContainerFiles(containerName string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var res []string
+	for p := range f.containerFiles[containerName] {
+		res = append(res, p)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// applyHealthOnStart updates the health of the container just started,
+// if requested. Must be invoked with the lock held.
+func (f *FakeDockerHost) applyHealthOnStart(ct *fakeContainerInfo) {
+	if h, found := f.healthOnStart[ct.name]; found {
+		ct.health = h.Status
+		ct.healthLog = h.Log
+	}
+}
+
+// emitContainerEvent publishes the container event to the subscribers.
+// Must be invoked with the lock held.
+func (f *FakeDockerHost) emitContainerEvent(ct *fakeContainerInfo, action devents.Action) {
+	f.emitEvent(devents.ContainerEventType, action, ct.id, ct.name)
+}
+
+// emitEvent publishes the event to the subscribers interested in the
+// event type. Must be invoked with the lock held.
+func (f *FakeDockerHost) emitEvent(eventType devents.Type, action devents.Action, id string, name string) {
+	msg := devents.Message{
+		Type:   eventType,
+		Action: action,
+		Actor: devents.Actor{
+			ID: id,
+			Attributes: map[string]string{
+				"name": name,
+			},
+		},
+		Scope: "local",
+	}
+	for sub := range f.eventSubscribers {
+		if len(sub.types) > 0 {
+			if _, found := sub.types[string(eventType)]; !found {
+				continue
+			}
+		}
+		select {
+		case sub.messages <- msg:
+		default:
+			// Drop the event rather than blocking the fake docker
+			// host when the subscriber isn't keeping up.
+		}
+	}
+}
+
+func fakeDockerContainerState(state docker.ContainerState, health string, healthLog []string) *dtypes.ContainerState {
 	st := &dtypes.ContainerState{}
+	if len(health) > 0 {
+		st.Health = &dtypes.Health{
+			Status: health,
+		}
+		exitCode := 0
+		if health == dtypes.Unhealthy {
+			exitCode = 1
+		}
+		for _, out := range healthLog {
+			st.Health.Log = append(st.Health.Log, &dtypes.HealthcheckResult{
+				ExitCode: exitCode,
+				Output:   out,
+			})
+		}
+	}
 	switch state {
 	case docker.ContainerStateCreated:
 		st.Status = "created"
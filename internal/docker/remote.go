@@ -0,0 +1,230 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	dsystem "github.com/docker/docker/api/types/system"
+	dclient "github.com/docker/docker/client"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+)
+
+const (
+	endpointSchemeTCP = "tcp"
+	endpointSchemeSSH = "ssh"
+
+	// The host is unused while talking to the docker daemon over ssh, but
+	// the docker client requires a valid one nevertheless.
+	sshDummyDaemonHost = "http://docker.example.com"
+)
+
+// RemoteEndpoint represents the endpoint of a remote docker daemon.
+type RemoteEndpoint struct {
+	// Endpoint is either tcp://<host>:<port> or ssh://[user@]<host>[:port].
+	Endpoint string
+	// TLS CA certificate, certificate and key paths used only with tcp
+	// endpoints.
+	TLSCACert string
+	TLSCert   string
+	TLSKey    string
+}
+
+// ValidateRemoteEndpoint validates the remote docker daemon endpoint.
+func ValidateRemoteEndpoint(ep *RemoteEndpoint) error {
+	u, err := url.Parse(ep.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid docker endpoint %s, reason: %w", ep.Endpoint, err)
+	}
+	if len(u.Hostname()) == 0 {
+		return fmt.Errorf("docker endpoint %s must specify the host", ep.Endpoint)
+	}
+	hasTLS := len(ep.TLSCACert) > 0 || len(ep.TLSCert) > 0 || len(ep.TLSKey) > 0
+	switch u.Scheme {
+	case endpointSchemeTCP:
+		if (len(ep.TLSCert) > 0) != (len(ep.TLSKey) > 0) {
+			return fmt.Errorf("docker endpoint %s must specify both the TLS cert and key, or neither", ep.Endpoint)
+		}
+	case endpointSchemeSSH:
+		if hasTLS {
+			return fmt.Errorf("docker endpoint %s cannot specify TLS options since they are only supported with tcp endpoints", ep.Endpoint)
+		}
+	default:
+		return fmt.Errorf("docker endpoint %s has unsupported scheme %q, must be one of tcp or ssh", ep.Endpoint, u.Scheme)
+	}
+	return nil
+}
+
+// NewRemoteAPIClient returns a docker API client for the remote docker
+// daemon at the specified endpoint.
+func NewRemoteAPIClient(ctx context.Context, ep *RemoteEndpoint) (APIClient, error) {
+	if err := ValidateRemoteEndpoint(ep); err != nil {
+		return nil, err
+	}
+	if client, found := remoteAPIClientFromContext(ctx, ep.Endpoint); found {
+		return client, nil
+	}
+	u, _ := url.Parse(ep.Endpoint)
+
+	opts := []dclient.Opt{dclient.WithAPIVersionNegotiation()}
+	if u.Scheme == endpointSchemeSSH {
+		opts = append(opts,
+			dclient.WithHost(sshDummyDaemonHost),
+			dclient.WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return newSSHConn(u)
+			}))
+	} else {
+		opts = append(opts, dclient.WithHost(ep.Endpoint))
+		if len(ep.TLSCACert) > 0 || len(ep.TLSCert) > 0 {
+			opts = append(opts, dclient.WithTLSClientConfig(ep.TLSCACert, ep.TLSCert, ep.TLSKey))
+		}
+	}
+
+	d, err := dclient.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new docker API client for endpoint %s, reason: %w", ep.Endpoint, err)
+	}
+	return d, nil
+}
+
+// NewRemoteHostInfo returns the host info of the remote host based on the
+// docker daemon info. The host IP is the swarm node address if available,
+// or else is resolved from the endpoint.
+func NewRemoteHostInfo(ctx context.Context, client APIClient, ep *RemoteEndpoint) (*host.HostInfo, error) {
+	info, err := client.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the docker daemon info from endpoint %s, reason: %w", ep.Endpoint, err)
+	}
+
+	addr := info.Swarm.NodeAddr
+	if len(addr) == 0 {
+		u, err := url.Parse(ep.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid docker endpoint %s, reason: %w", ep.Endpoint, err)
+		}
+		addr = u.Hostname()
+	}
+	ip, err := resolveHostIP(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine the IP of the host at docker endpoint %s, reason: %w", ep.Endpoint, err)
+	}
+
+	return hostInfoFromDockerInfo(&info, ip)
+}
+
+func hostInfoFromDockerInfo(info *dsystem.Info, ip netip.Addr) (*host.HostInfo, error) {
+	arch := dockerArchToGoArch(info.Architecture)
+	if !host.IsSupportedArch(arch) {
+		return nil, fmt.Errorf("docker daemon on host %s has unsupported arch %s", info.Name, info.Architecture)
+	}
+	base := host.HostInfo{
+		NumCPUs: info.NCPU,
+		OS:      info.OSType,
+		Kernel:  info.KernelVersion,
+	}
+	res := host.NewSyntheticHostInfo(&base, info.Name, arch, ip)
+	res.Remote = true
+	return res, nil
+}
+
+func dockerArchToGoArch(arch string) string {
+	switch arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	default:
+		return arch
+	}
+}
+
+func resolveHostIP(ctx context.Context, addr string) (netip.Addr, error) {
+	if ip, err := netip.ParseAddr(addr); err == nil {
+		return ip, nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip4", addr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if len(ips) == 0 {
+		return netip.Addr{}, fmt.Errorf("no IPv4 addresses found for %s", addr)
+	}
+	return ips[0].Unmap(), nil
+}
+
+// sshConn is a net.Conn talking to the remote docker daemon through the
+// stdin and stdout of `docker system dial-stdio` run over ssh.
+type sshConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func sshArgs(u *url.URL) []string {
+	var args []string
+	if len(u.Port()) > 0 {
+		args = append(args, "-p", u.Port())
+	}
+	dest := u.Hostname()
+	if u.User != nil && len(u.User.Username()) > 0 {
+		dest = fmt.Sprintf("%s@%s", u.User.Username(), dest)
+	}
+	return append(args, "-T", "--", dest, "docker", "system", "dial-stdio")
+}
+
+func newSSHConn(u *url.URL) (net.Conn, error) {
+	cmd := exec.Command("ssh", sshArgs(u)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run ssh %s, reason: %w", strings.Join(cmd.Args[1:], " "), err)
+	}
+	return &sshConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+func (c *sshConn) Read(p []byte) (int, error) {
+	return c.stdout.Read(p)
+}
+
+func (c *sshConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+func (c *sshConn) Close() error {
+	_ = c.stdin.Close()
+	_ = c.cmd.Process.Kill()
+	_ = c.cmd.Wait()
+	return nil
+}
+
+func (c *sshConn) LocalAddr() net.Addr {
+	return &net.UnixAddr{Name: "ssh-local", Net: "unix"}
+}
+
+func (c *sshConn) RemoteAddr() net.Addr {
+	return &net.UnixAddr{Name: "ssh-remote", Net: "unix"}
+}
+
+func (c *sshConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *sshConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *sshConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
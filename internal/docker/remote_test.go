@@ -0,0 +1,253 @@
+package docker
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	dsystem "github.com/docker/docker/api/types/system"
+	"github.com/tuxdudehomelab/homelab/internal/host"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+var validateRemoteEndpointTests = []struct {
+	name string
+	ep   RemoteEndpoint
+}{
+	{
+		name: "Validate Remote Endpoint - TCP",
+		ep: RemoteEndpoint{
+			Endpoint: "tcp://10.1.2.3:2375",
+		},
+	},
+	{
+		name: "Validate Remote Endpoint - TCP With TLS",
+		ep: RemoteEndpoint{
+			Endpoint:  "tcp://pi2.lan:2376",
+			TLSCACert: "/certs/ca.pem",
+			TLSCert:   "/certs/cert.pem",
+			TLSKey:    "/certs/key.pem",
+		},
+	},
+	{
+		name: "Validate Remote Endpoint - SSH",
+		ep: RemoteEndpoint{
+			Endpoint: "ssh://foo@pi2.lan:2222",
+		},
+	},
+}
+
+func TestValidateRemoteEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range validateRemoteEndpointTests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if gotErr := ValidateRemoteEndpoint(&tc.ep); gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "ValidateRemoteEndpoint()", tc.name, gotErr)
+			}
+		})
+	}
+}
+
+var validateRemoteEndpointErrorTests = []struct {
+	name string
+	ep   RemoteEndpoint
+	want string
+}{
+	{
+		name: "Validate Remote Endpoint - Unsupported Scheme",
+		ep: RemoteEndpoint{
+			Endpoint: "unix:///var/run/docker.sock",
+		},
+		want: `docker endpoint unix:///var/run/docker.sock must specify the host`,
+	},
+	{
+		name: "Validate Remote Endpoint - Unsupported Scheme With Host",
+		ep: RemoteEndpoint{
+			Endpoint: "http://pi2.lan:2375",
+		},
+		want: `docker endpoint http://pi2\.lan:2375 has unsupported scheme "http", must be one of tcp or ssh`,
+	},
+	{
+		name: "Validate Remote Endpoint - TLS Cert Without Key",
+		ep: RemoteEndpoint{
+			Endpoint: "tcp://pi2.lan:2376",
+			TLSCert:  "/certs/cert.pem",
+		},
+		want: `docker endpoint tcp://pi2\.lan:2376 must specify both the TLS cert and key, or neither`,
+	},
+	{
+		name: "Validate Remote Endpoint - SSH With TLS",
+		ep: RemoteEndpoint{
+			Endpoint:  "ssh://pi2.lan",
+			TLSCACert: "/certs/ca.pem",
+		},
+		want: `docker endpoint ssh://pi2\.lan cannot specify TLS options since they are only supported with tcp endpoints`,
+	},
+}
+
+func TestValidateRemoteEndpointErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range validateRemoteEndpointErrorTests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := ValidateRemoteEndpoint(&tc.ep)
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "ValidateRemoteEndpoint()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "ValidateRemoteEndpoint()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
+
+var hostInfoFromDockerInfoTests = []struct {
+	name string
+	info dsystem.Info
+	ip   string
+	want *host.HostInfo
+}{
+	{
+		name: "Host Info From Docker Info - arm64",
+		info: dsystem.Info{
+			Name:          "Pi2",
+			OSType:        "linux",
+			Architecture:  "aarch64",
+			NCPU:          4,
+			KernelVersion: "6.6.31+rpt-rpi-v8",
+		},
+		ip: "10.1.2.3",
+		want: &host.HostInfo{
+			HostName:              "pi2",
+			HumanFriendlyHostName: "Pi2",
+			IP:                    netip.MustParseAddr("10.1.2.3"),
+			NumCPUs:               4,
+			OS:                    "linux",
+			Arch:                  "arm64",
+			DockerPlatform:        "linux/arm64/v8",
+			Kernel:                "6.6.31+rpt-rpi-v8",
+			Synthetic:             true,
+			Remote:                true,
+		},
+	},
+	{
+		name: "Host Info From Docker Info - amd64",
+		info: dsystem.Info{
+			Name:         "nas",
+			OSType:       "linux",
+			Architecture: "x86_64",
+			NCPU:         16,
+		},
+		ip: "10.1.2.4",
+		want: &host.HostInfo{
+			HostName:              "nas",
+			HumanFriendlyHostName: "nas",
+			IP:                    netip.MustParseAddr("10.1.2.4"),
+			NumCPUs:               16,
+			OS:                    "linux",
+			Arch:                  "amd64",
+			DockerPlatform:        "linux/amd64",
+			Synthetic:             true,
+			Remote:                true,
+		},
+	},
+}
+
+func TestHostInfoFromDockerInfo(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range hostInfoFromDockerInfoTests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := hostInfoFromDockerInfo(&tc.info, netip.MustParseAddr(tc.ip))
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "hostInfoFromDockerInfo()", tc.name, gotErr)
+				return
+			}
+
+			// netip.Addr cannot be compared using cmp, hence compare the
+			// string representations instead.
+			if !testhelpers.CmpDiff(t, "hostInfoFromDockerInfo()", tc.name, "host info", fmt.Sprintf("%+v", *tc.want), fmt.Sprintf("%+v", *got)) {
+				return
+			}
+		})
+	}
+}
+
+func TestHostInfoFromDockerInfoUnsupportedArch(t *testing.T) {
+	t.Parallel()
+
+	tc := "Host Info From Docker Info - Unsupported Arch"
+	want := `docker daemon on host pi1 has unsupported arch armv7l`
+
+	info := dsystem.Info{
+		Name:         "pi1",
+		OSType:       "linux",
+		Architecture: "armv7l",
+	}
+	_, gotErr := hostInfoFromDockerInfo(&info, netip.MustParseAddr("10.1.2.3"))
+	if gotErr == nil {
+		testhelpers.LogErrorNil(t, "hostInfoFromDockerInfo()", tc, want)
+		return
+	}
+	testhelpers.RegexMatch(t, "hostInfoFromDockerInfo()", tc, "gotErr error string", want, gotErr.Error())
+}
+
+var sshArgsTests = []struct {
+	name     string
+	endpoint string
+	want     []string
+}{
+	{
+		name:     "SSH Args - Host Only",
+		endpoint: "ssh://pi2.lan",
+		want: []string{
+			"-T",
+			"--",
+			"pi2.lan",
+			"docker",
+			"system",
+			"dial-stdio",
+		},
+	},
+	{
+		name:     "SSH Args - User And Port",
+		endpoint: "ssh://foo@pi2.lan:2222",
+		want: []string{
+			"-p",
+			"2222",
+			"-T",
+			"--",
+			"foo@pi2.lan",
+			"docker",
+			"system",
+			"dial-stdio",
+		},
+	},
+}
+
+func TestSSHArgs(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range sshArgsTests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.endpoint)
+			if err != nil {
+				testhelpers.LogErrorNotNil(t, "url.Parse()", tc.name, err)
+				return
+			}
+			testhelpers.CmpDiff(t, "sshArgs()", tc.name, "ssh args", tc.want, sshArgs(u))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package dotenv parses env files in the dotenv format.
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Entry is a single env var and its value read from an env file.
+type Entry struct {
+	Var   string
+	Value string
+}
+
+var (
+	varNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// Parse parses the env file contents in the dotenv format, returning the
+// env vars in the order they appear.
+//
+// Each non-empty line which isn't a comment (i.e. starting with #) is of
+// the form [export ]VAR=VALUE. Unquoted values are trimmed and end at an
+// inline comment (i.e. a # preceded by whitespace). Single quoted values
+// are used as-is, while double quoted values support the \n, \t, \", \\
+// and \$ escapes and can span multiple lines.
+func Parse(r io.Reader) ([]Entry, error) {
+	var res []Entry
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		startLine := lineNum
+		line = strings.TrimPrefix(line, "export ")
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected VAR=VALUE", startLine)
+		}
+		name = strings.TrimSpace(name)
+		if !varNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("line %d: invalid env var name %q", startLine, name)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// Double quoted values can span multiple lines.
+			for !hasClosingQuote(value[1:]) && scanner.Scan() {
+				lineNum++
+				value += "\n" + scanner.Text()
+			}
+			v, err := unquoteDouble(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", startLine, err)
+			}
+			value = v
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quoted value", startLine)
+			}
+			value = value[1 : end+1]
+		default:
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		res = append(res, Entry{Var: name, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func hasClosingQuote(s string) bool {
+	escaped := false
+	for _, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			return true
+		}
+	}
+	return false
+}
+
+func unquoteDouble(s string) (string, error) {
+	var res strings.Builder
+	escaped := false
+	for _, c := range s[1:] {
+		if escaped {
+			switch c {
+			case 'n':
+				res.WriteRune('\n')
+			case 't':
+				res.WriteRune('\t')
+			case '"', '\\', '$':
+				res.WriteRune(c)
+			default:
+				res.WriteRune('\\')
+				res.WriteRune(c)
+			}
+			escaped = false
+			continue
+		}
+		switch c {
+		case '\\':
+			escaped = true
+		case '"':
+			return res.String(), nil
+		default:
+			res.WriteRune(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated double quoted value")
+}
@@ -0,0 +1,126 @@
+package dotenv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+var parseTests = []struct {
+	name  string
+	input string
+	want  []Entry
+}{
+	{
+		name:  "Parse - Empty",
+		input: "",
+		want:  nil,
+	},
+	{
+		name: "Parse - Unquoted",
+		input: `
+# A comment.
+FOO=foo
+  BAR = bar baz  
+export BAZ=baz
+EMPTY=
+WITH_COMMENT=abc # A trailing comment.
+WITH_HASH=abc#def
+`,
+		want: []Entry{
+			{Var: "FOO", Value: "foo"},
+			{Var: "BAR", Value: "bar baz"},
+			{Var: "BAZ", Value: "baz"},
+			{Var: "EMPTY", Value: ""},
+			{Var: "WITH_COMMENT", Value: "abc"},
+			{Var: "WITH_HASH", Value: "abc#def"},
+		},
+	},
+	{
+		name: "Parse - Quoted",
+		input: `SINGLE='single $FOO \n # not a comment'
+DOUBLE="double \"quoted\"\t\$FOO\\bar" # A comment.
+EMPTY_DOUBLE=""
+MULTI_LINE="line1
+line2"
+AFTER=after
+`,
+		want: []Entry{
+			{Var: "SINGLE", Value: `single $FOO \n # not a comment`},
+			{Var: "DOUBLE", Value: "double \"quoted\"\t$FOO\\bar"},
+			{Var: "EMPTY_DOUBLE", Value: ""},
+			{Var: "MULTI_LINE", Value: "line1\nline2"},
+			{Var: "AFTER", Value: "after"},
+		},
+	},
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range parseTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := Parse(strings.NewReader(tc.input))
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "Parse()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "Parse()", tc.name, "entries", tc.want, got) {
+				return
+			}
+		})
+	}
+}
+
+var parseErrorTests = []struct {
+	name  string
+	input string
+	want  string
+}{
+	{
+		name:  "Parse - Missing Value",
+		input: "FOO=foo\nBAR\n",
+		want:  `line 2: expected VAR=VALUE`,
+	},
+	{
+		name:  "Parse - Invalid Var Name",
+		input: "1FOO=foo\n",
+		want:  `line 1: invalid env var name "1FOO"`,
+	},
+	{
+		name:  "Parse - Unterminated Single Quote",
+		input: "FOO='foo\n",
+		want:  `line 1: unterminated single quoted value`,
+	},
+	{
+		name:  "Parse - Unterminated Double Quote",
+		input: "FOO=foo\nBAR=\"bar\nbaz\n",
+		want:  `line 2: unterminated double quoted value`,
+	},
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range parseErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := Parse(strings.NewReader(tc.input))
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "Parse()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "Parse()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
@@ -10,10 +10,16 @@ const (
 	FakeHostName              = "fakehost"
 	FakeHumanFriendlyHostName = "FakeHost"
 	FakeHostIP                = "10.76.77.78"
+	FakeHostIPv6              = "fd00:76:77::78"
+	FakeHostInterface         = "eth0"
+	FakeHostOtherInterface    = "wlan0"
+	FakeHostOtherIP           = "192.168.76.78"
 	FakeHostNumCPUs           = 32
 	FakeHostOS                = "linux"
 	FakeHostArch              = "amd64"
 	FakeHostDockerPlatform    = "linux/amd64"
+	FakeHostTimezone          = "America/Los_Angeles"
+	FakeHostKernel            = "6.1.0-76-fake"
 )
 
 func NewFakeHostInfo() *host.HostInfo {
@@ -21,9 +27,23 @@ func NewFakeHostInfo() *host.HostInfo {
 		HostName:              FakeHostName,
 		HumanFriendlyHostName: FakeHumanFriendlyHostName,
 		IP:                    netip.MustParseAddr(FakeHostIP),
-		NumCPUs:               FakeHostNumCPUs,
-		OS:                    FakeHostOS,
-		Arch:                  FakeHostArch,
-		DockerPlatform:        FakeHostDockerPlatform,
+		IPv6:                  netip.MustParseAddr(FakeHostIPv6),
+		Interfaces: []host.Interface{
+			{
+				Name: FakeHostInterface,
+				IPv4: netip.MustParseAddr(FakeHostIP),
+				IPv6: netip.MustParseAddr(FakeHostIPv6),
+			},
+			{
+				Name: FakeHostOtherInterface,
+				IPv4: netip.MustParseAddr(FakeHostOtherIP),
+			},
+		},
+		NumCPUs:        FakeHostNumCPUs,
+		OS:             FakeHostOS,
+		Arch:           FakeHostArch,
+		DockerPlatform: FakeHostDockerPlatform,
+		Timezone:       FakeHostTimezone,
+		Kernel:         FakeHostKernel,
 	}
 }
@@ -3,7 +3,6 @@ package host
 import (
 	"context"
 	"fmt"
-	"net"
 	"net/netip"
 	"os"
 	"runtime"
@@ -14,48 +13,104 @@ type HostInfo struct {
 	HostName              string
 	HumanFriendlyHostName string
 	IP                    netip.Addr
+	IPv6                  netip.Addr
+	Interfaces            []Interface
 	NumCPUs               int
 	OS                    string
 	Arch                  string
 	DockerPlatform        string
+	Timezone              string
+	Kernel                string
+	// Synthetic indicates the host info describes a host other than the
+	// local host, and hence nothing can be looked up on the local host
+	// on its behalf.
+	Synthetic bool
+	// Remote indicates the host is managed through its remote docker
+	// daemon, and hence no commands can be run on the host itself.
+	Remote bool
 }
 
 const (
 	osLinux   = "linux"
 	archAmd64 = "amd64"
 	archArm64 = "arm64"
+
+	etcTimezone  = "/etc/timezone"
+	etcLocaltime = "/etc/localtime"
 )
 
 func NewHostInfo(ctx context.Context) *HostInfo {
+	ifaces := systemInterfaces(ctx)
 	res := HostInfo{
 		HumanFriendlyHostName: systemHostName(ctx),
-		IP:                    interfaceIP(ctx),
+		IP:                    defaultIP(ctx, ifaces),
+		Interfaces:            ifaces,
 		NumCPUs:               runtime.NumCPU(),
 		OS:                    runtime.GOOS,
 		Arch:                  runtime.GOARCH,
 		DockerPlatform:        archToDockerPlatform(runtime.GOARCH),
+		Timezone:              systemTimezone(ctx),
+		Kernel:                systemKernel(ctx),
 	}
 	res.HostName = strings.ToLower(res.HumanFriendlyHostName)
+	if iface := res.interfaceWithIP(res.IP); iface != nil {
+		res.IPv6 = iface.IPv6
+	}
 
 	log(ctx).Debugf("Host name: %s", res.HostName)
 	log(ctx).Debugf("Human Friendly Host name: %s", res.HumanFriendlyHostName)
 	log(ctx).Debugf("Host IP: %s", res.IP)
+	log(ctx).Debugf("Host IPv6: %s", res.IPv6)
+	for _, iface := range res.Interfaces {
+		log(ctx).Debugf("Interface %s IPv4: %s IPv6: %s", iface.Name, iface.IPv4, iface.IPv6)
+	}
 	log(ctx).Debugf("Num CPUs = %d", res.NumCPUs)
 	log(ctx).Debugf("OS = %s", res.OS)
 	log(ctx).Debugf("Arch = %s", res.Arch)
 	log(ctx).Debugf("Docker Platform = %s", res.DockerPlatform)
+	log(ctx).Debugf("Timezone = %s", res.Timezone)
+	log(ctx).Debugf("Kernel = %s", res.Kernel)
 	log(ctx).DebugEmpty()
 
 	if res.OS != osLinux {
 		log(ctx).Fatalf("Only linux OS is supported, found OS: %s", res.OS)
 	}
-	if res.Arch != archAmd64 && res.Arch != archArm64 {
+	if !IsSupportedArch(res.Arch) {
 		log(ctx).Fatalf("Only amd64 and arm64 platforms are supported, found Arch: %s", res.Arch)
 	}
 
 	return &res
 }
 
+// NewSyntheticHostInfo returns a copy of the base host info representing
+// the specified host instead. Arch and IP are retained from the base host
+// info when they are unspecified, while the interfaces of the host are
+// unknown.
+func NewSyntheticHostInfo(base *HostInfo, hostName string, arch string, ip netip.Addr) *HostInfo {
+	res := *base
+	res.Synthetic = true
+	res.Interfaces = nil
+	res.HumanFriendlyHostName = hostName
+	res.HostName = strings.ToLower(hostName)
+	if len(arch) > 0 {
+		res.Arch = arch
+		res.DockerPlatform = archToDockerPlatform(arch)
+	}
+	if ip.IsValid() {
+		res.IP = ip
+		res.IPv6 = netip.Addr{}
+		if ip.Is6() {
+			res.IPv6 = ip
+		}
+	}
+	return &res
+}
+
+// IsSupportedArch returns true if the specified architecture is supported.
+func IsSupportedArch(arch string) bool {
+	return arch == archAmd64 || arch == archArm64
+}
+
 func systemHostName(ctx context.Context) string {
 	res, err := os.Hostname()
 	if err != nil {
@@ -64,18 +119,25 @@ func systemHostName(ctx context.Context) string {
 	return res
 }
 
-func interfaceIP(ctx context.Context) netip.Addr {
-	conn, err := net.Dial("udp", "10.1.1.1:1234")
-	if err != nil {
-		log(ctx).Fatalf("Unable to determine the current machine's IP, %v", err)
+// systemTimezone determines the IANA name of the timezone of the host,
+// preferring TZ if set, followed by /etc/timezone and the target of the
+// /etc/localtime symlink.
+func systemTimezone(ctx context.Context) string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); len(tz) > 0 {
+		return tz
 	}
-	defer conn.Close()
-
-	ip, ok := netip.AddrFromSlice(conn.LocalAddr().(*net.UDPAddr).IP)
-	if !ok {
-		log(ctx).Fatalf("Unable to convert host IP %s of net.IP type to netip.Addr type")
+	if b, err := os.ReadFile(etcTimezone); err == nil {
+		if tz := strings.TrimSpace(string(b)); len(tz) > 0 {
+			return tz
+		}
+	}
+	if target, err := os.Readlink(etcLocaltime); err == nil {
+		if _, tz, found := strings.Cut(target, "zoneinfo/"); found && len(tz) > 0 {
+			return tz
+		}
 	}
-	return ip
+	log(ctx).Debugf("Unable to determine the timezone of the host, assuming UTC")
+	return "UTC"
 }
 
 func archToDockerPlatform(arch string) string {
@@ -0,0 +1,16 @@
+package host
+
+import (
+	"context"
+
+	"golang.org/x/sys/unix"
+)
+
+func systemKernel(ctx context.Context) string {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		log(ctx).Warnf("Unable to determine the kernel version of the host, reason: %v", err)
+		return ""
+	}
+	return unix.ByteSliceToString(uts.Release[:])
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package host
+
+import (
+	"context"
+)
+
+// systemKernel is unsupported on the platforms other than linux, and
+// hence the kernel version is left empty.
+func systemKernel(ctx context.Context) string {
+	return ""
+}
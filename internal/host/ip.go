@@ -0,0 +1,186 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Interface represents the IP addresses of a network interface on the
+// host.
+type Interface struct {
+	Name string
+	IPv4 netip.Addr
+	IPv6 netip.Addr
+}
+
+// IPSelector selects the host IP. Exactly one of the interface name, the
+// CIDR to match the interface IPs against, or the literal IP must be
+// specified.
+type IPSelector struct {
+	Interface string `yaml:"interface,omitempty" json:"interface,omitempty"`
+	CIDR      string `yaml:"cidr,omitempty" json:"cidr,omitempty"`
+	IP        string `yaml:"ip,omitempty" json:"ip,omitempty"`
+}
+
+// IsEmpty returns true if the selector does not specify anything.
+func (s *IPSelector) IsEmpty() bool {
+	return len(s.Interface) == 0 && len(s.CIDR) == 0 && len(s.IP) == 0
+}
+
+// Validate validates the selector.
+func (s *IPSelector) Validate() error {
+	count := 0
+	for _, v := range []string{s.Interface, s.CIDR, s.IP} {
+		if len(v) > 0 {
+			count++
+		}
+	}
+	if count != 1 {
+		return fmt.Errorf("exactly one of interface, cidr or ip must be specified in the host IP selector")
+	}
+	if len(s.CIDR) > 0 {
+		if _, err := netip.ParsePrefix(s.CIDR); err != nil {
+			return fmt.Errorf("invalid host IP selector cidr %s, reason: %w", s.CIDR, err)
+		}
+	}
+	if len(s.IP) > 0 {
+		if _, err := netip.ParseAddr(s.IP); err != nil {
+			return fmt.Errorf("invalid host IP selector ip %s, reason: %w", s.IP, err)
+		}
+	}
+	return nil
+}
+
+// WithSelectedIP returns a copy of the host info with the IP (and the
+// IPv6) chosen using the selector. Selectors relying on the interfaces are
+// ignored when the interfaces of the host are unknown (i.e. for synthetic
+// and remote hosts).
+func (h *HostInfo) WithSelectedIP(ctx context.Context, sel *IPSelector) (*HostInfo, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
+	res := *h
+	if len(sel.IP) > 0 {
+		res.IP = netip.MustParseAddr(sel.IP)
+		if iface := h.interfaceWithIP(res.IP); iface != nil {
+			res.IPv6 = iface.IPv6
+		}
+		return &res, nil
+	}
+
+	if h.Interfaces == nil {
+		log(ctx).Debugf("Ignoring the host IP selector since the interfaces of host %s are unknown", h.HostName)
+		return &res, nil
+	}
+
+	var iface *Interface
+	if len(sel.Interface) > 0 {
+		iface = h.interfaceByName(sel.Interface)
+		if iface == nil || !iface.IPv4.IsValid() {
+			return nil, fmt.Errorf("interface %s with an IPv4 address not found on host %s", sel.Interface, h.HostName)
+		}
+	} else {
+		prefix := netip.MustParsePrefix(sel.CIDR)
+		for i := range h.Interfaces {
+			if prefix.Contains(h.Interfaces[i].IPv4) || prefix.Contains(h.Interfaces[i].IPv6) {
+				iface = &h.Interfaces[i]
+				break
+			}
+		}
+		if iface == nil {
+			return nil, fmt.Errorf("no interface with an IP within %s found on host %s", sel.CIDR, h.HostName)
+		}
+	}
+
+	if iface.IPv4.IsValid() {
+		res.IP = iface.IPv4
+	} else {
+		res.IP = iface.IPv6
+	}
+	res.IPv6 = iface.IPv6
+	return &res, nil
+}
+
+func (h *HostInfo) interfaceByName(name string) *Interface {
+	for i := range h.Interfaces {
+		if h.Interfaces[i].Name == name {
+			return &h.Interfaces[i]
+		}
+	}
+	return nil
+}
+
+func (h *HostInfo) interfaceWithIP(ip netip.Addr) *Interface {
+	for i := range h.Interfaces {
+		if h.Interfaces[i].IPv4 == ip || h.Interfaces[i].IPv6 == ip {
+			return &h.Interfaces[i]
+		}
+	}
+	return nil
+}
+
+func systemInterfaces(ctx context.Context) []Interface {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		log(ctx).Warnf("Unable to list the network interfaces on the host, reason: %v", err)
+		return []Interface{}
+	}
+
+	res := []Interface{}
+	for _, i := range ifaces {
+		if i.Flags&net.FlagUp == 0 || i.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := i.Addrs()
+		if err != nil {
+			log(ctx).Warnf("Unable to list the addresses of the network interface %s, reason: %v", i.Name, err)
+			continue
+		}
+		iface := Interface{Name: i.Name}
+		for _, a := range addrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip, ok := netip.AddrFromSlice(ipNet.IP)
+			if !ok || !ip.IsGlobalUnicast() {
+				continue
+			}
+			ip = ip.Unmap()
+			if ip.Is4() && !iface.IPv4.IsValid() {
+				iface.IPv4 = ip
+			} else if ip.Is6() && !iface.IPv6.IsValid() {
+				iface.IPv6 = ip
+			}
+		}
+		if iface.IPv4.IsValid() || iface.IPv6.IsValid() {
+			res = append(res, iface)
+		}
+	}
+	return res
+}
+
+// defaultIP determines the IP of the interface used for the default route,
+// falling back to the first interface with an IPv4 address on hosts
+// without a default route.
+func defaultIP(ctx context.Context, ifaces []Interface) netip.Addr {
+	conn, err := net.Dial("udp", "10.1.1.1:1234")
+	if err == nil {
+		defer conn.Close()
+		if ip, ok := netip.AddrFromSlice(conn.LocalAddr().(*net.UDPAddr).IP); ok {
+			return ip.Unmap()
+		}
+	}
+
+	for _, iface := range ifaces {
+		if iface.IPv4.IsValid() {
+			log(ctx).Debugf("Unable to determine the IP used for the default route, falling back to the IP of interface %s", iface.Name)
+			return iface.IPv4
+		}
+	}
+	log(ctx).Fatalf("Unable to determine the current machine's IP, %v", err)
+	return netip.Addr{}
+}
@@ -0,0 +1,188 @@
+package host
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	l "github.com/tuxdudehomelab/homelab/internal/log"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+func newTestHostInfo() *HostInfo {
+	return &HostInfo{
+		HostName: "h1",
+		IP:       netip.MustParseAddr("10.1.1.1"),
+		Interfaces: []Interface{
+			{
+				Name: "eth0",
+				IPv4: netip.MustParseAddr("10.1.1.1"),
+				IPv6: netip.MustParseAddr("fd00::1"),
+			},
+			{
+				Name: "eth1",
+				IPv4: netip.MustParseAddr("192.168.1.1"),
+			},
+			{
+				Name: "wg0",
+				IPv6: netip.MustParseAddr("fd01::1"),
+			},
+		},
+	}
+}
+
+var withSelectedIPTests = []struct {
+	name      string
+	sel       IPSelector
+	synthetic bool
+	wantIP    string
+	wantIPv6  string
+}{
+	{
+		name: "Host Info - With Selected IP - Interface",
+		sel: IPSelector{
+			Interface: "eth1",
+		},
+		wantIP:   "192.168.1.1",
+		wantIPv6: "invalid IP",
+	},
+	{
+		name: "Host Info - With Selected IP - IPv4 CIDR",
+		sel: IPSelector{
+			CIDR: "10.0.0.0/8",
+		},
+		wantIP:   "10.1.1.1",
+		wantIPv6: "fd00::1",
+	},
+	{
+		name: "Host Info - With Selected IP - IPv6 CIDR",
+		sel: IPSelector{
+			CIDR: "fd01::/64",
+		},
+		wantIP:   "fd01::1",
+		wantIPv6: "fd01::1",
+	},
+	{
+		name: "Host Info - With Selected IP - Literal IP",
+		sel: IPSelector{
+			IP: "172.16.1.1",
+		},
+		wantIP:   "172.16.1.1",
+		wantIPv6: "invalid IP",
+	},
+	{
+		name: "Host Info - With Selected IP - Unknown Interfaces",
+		sel: IPSelector{
+			Interface: "eth1",
+		},
+		synthetic: true,
+		wantIP:    "10.1.1.1",
+		wantIPv6:  "invalid IP",
+	},
+}
+
+func TestWithSelectedIP(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range withSelectedIPTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := l.WithLogger(context.Background(), newTestLogger())
+			h := newTestHostInfo()
+			if tc.synthetic {
+				h = NewSyntheticHostInfo(h, "h2", "", netip.Addr{})
+			}
+			got, gotErr := h.WithSelectedIP(ctx, &tc.sel)
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "WithSelectedIP()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "WithSelectedIP()", tc.name, "IP", tc.wantIP, got.IP.String()) {
+				return
+			}
+			if !testhelpers.CmpDiff(t, "WithSelectedIP()", tc.name, "IPv6", tc.wantIPv6, got.IPv6.String()) {
+				return
+			}
+		})
+	}
+}
+
+var withSelectedIPErrorTests = []struct {
+	name string
+	sel  IPSelector
+	want string
+}{
+	{
+		name: "Host Info - With Selected IP - Empty Selector",
+		sel:  IPSelector{},
+		want: `exactly one of interface, cidr or ip must be specified in the host IP selector`,
+	},
+	{
+		name: "Host Info - With Selected IP - Multiple Selectors",
+		sel: IPSelector{
+			Interface: "eth0",
+			IP:        "10.1.1.1",
+		},
+		want: `exactly one of interface, cidr or ip must be specified in the host IP selector`,
+	},
+	{
+		name: "Host Info - With Selected IP - Invalid CIDR",
+		sel: IPSelector{
+			CIDR: "10.0.0.0",
+		},
+		want: `invalid host IP selector cidr 10\.0\.0\.0, reason: netip\.ParsePrefix\("10\.0\.0\.0"\): no '/'`,
+	},
+	{
+		name: "Host Info - With Selected IP - Invalid IP",
+		sel: IPSelector{
+			IP: "10.1.1",
+		},
+		want: `invalid host IP selector ip 10\.1\.1, reason: ParseAddr\("10\.1\.1"\): IPv4 address too short`,
+	},
+	{
+		name: "Host Info - With Selected IP - Interface Not Found",
+		sel: IPSelector{
+			Interface: "eth2",
+		},
+		want: `interface eth2 with an IPv4 address not found on host h1`,
+	},
+	{
+		name: "Host Info - With Selected IP - Interface Without IPv4",
+		sel: IPSelector{
+			Interface: "wg0",
+		},
+		want: `interface wg0 with an IPv4 address not found on host h1`,
+	},
+	{
+		name: "Host Info - With Selected IP - CIDR Not Matched",
+		sel: IPSelector{
+			CIDR: "172.16.0.0/12",
+		},
+		want: `no interface with an IP within 172\.16\.0\.0/12 found on host h1`,
+	},
+}
+
+func TestWithSelectedIPErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range withSelectedIPErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := l.WithLogger(context.Background(), newTestLogger())
+			_, gotErr := newTestHostInfo().WithSelectedIP(ctx, &tc.sel)
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "WithSelectedIP()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "WithSelectedIP()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
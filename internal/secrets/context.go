@@ -0,0 +1,45 @@
+package secrets
+
+import "context"
+
+var (
+	keyFileKey = ctxKeyKeyFile{}
+	maskedKey  = ctxKeyMasked{}
+)
+
+type ctxKeyKeyFile struct{}
+
+type ctxKeyMasked struct{}
+
+func KeyFileFromContext(ctx context.Context) (string, bool) {
+	path, ok := ctx.Value(keyFileKey).(string)
+	return path, ok && len(path) > 0
+}
+
+func WithKeyFile(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, keyFileKey, path)
+}
+
+// KeyFromContext reads the secrets key from the key file specified in the
+// context.
+func KeyFromContext(ctx context.Context) (*Key, error) {
+	path, found := KeyFileFromContext(ctx)
+	if !found {
+		return nil, errNoKeyFile
+	}
+	log(ctx).Debugf("Using secrets key file: %s", path)
+	return ReadKeyFile(path)
+}
+
+// MaskedFromContext returns true if the encrypted values within the homelab
+// configs must be masked rather than decrypted.
+func MaskedFromContext(ctx context.Context) bool {
+	masked, ok := ctx.Value(maskedKey).(bool)
+	return ok && masked
+}
+
+// WithMasked returns a context that requests the encrypted values within
+// the homelab configs to be masked rather than decrypted.
+func WithMasked(ctx context.Context) context.Context {
+	return context.WithValue(ctx, maskedKey, true)
+}
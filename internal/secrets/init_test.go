@@ -0,0 +1,5 @@
+package secrets
+
+// This updates the current directory to the homelab repo base
+// directory so that the tests find the right path to testdata.
+import _ "github.com/tuxdudehomelab/homelab/internal/testinit"
@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+const (
+	// EncryptedPrefix is the prefix used to identify an inline encrypted
+	// string value within the homelab configs.
+	EncryptedPrefix = "enc:"
+
+	keySize = 32
+)
+
+// Key is the symmetric key used for encrypting and decrypting the secrets
+// within the homelab configs.
+type Key struct {
+	aead cipher.AEAD
+}
+
+// NewRandomKey generates and returns a new random key along with its
+// encoded representation that can be stored in a key file.
+func NewRandomKey() (*Key, string, error) {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate a random secrets key, reason: %w", err)
+	}
+	k, err := newKey(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	return k, base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// ReadKeyFile reads the key stored in the specified key file.
+func ReadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key file, reason: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("secrets key file %s does not contain a valid base64 encoded key, reason: %w", path, err)
+	}
+	if len(raw) != keySize {
+		return nil, fmt.Errorf("secrets key in %s must be %d bytes long, found %d bytes", path, keySize, len(raw))
+	}
+	return newKey(raw)
+}
+
+// WriteNewKeyFile generates a new random key and writes it to the
+// specified path. An existing file is never overwritten.
+func WriteNewKeyFile(path string) (*Key, error) {
+	k, encoded, err := NewRandomKey()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("secrets key file %s already exists", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secrets key file, reason: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(encoded + "\n"); err != nil {
+		return nil, fmt.Errorf("failed to write secrets key file %s, reason: %w", path, err)
+	}
+	return k, nil
+}
+
+func newKey(raw []byte) (*Key, error) {
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the secrets cipher, reason: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the secrets AEAD, reason: %w", err)
+	}
+	return &Key{aead: aead}, nil
+}
+
+// Encrypt encrypts the plaintext and returns the base64 encoded ciphertext
+// (without the encrypted prefix).
+func (k *Key) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce for encryption, reason: %w", err)
+	}
+	sealed := k.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the base64 encoded ciphertext which may optionally be
+// prefixed with the encrypted prefix.
+func (k *Key) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, EncryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("encrypted value is not valid base64, reason: %w", err)
+	}
+	ns := k.aead.NonceSize()
+	if len(data) < ns {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+	plaintext, err := k.aead.Open(nil, data[:ns], data[ns:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value, possibly due to the wrong secrets key, reason: %w", err)
+	}
+	return string(plaintext), nil
+}
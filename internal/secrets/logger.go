@@ -0,0 +1,7 @@
+package secrets
+
+import l "github.com/tuxdudehomelab/homelab/internal/log"
+
+var (
+	log = l.Log
+)
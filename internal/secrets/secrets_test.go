@@ -0,0 +1,403 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tuxdude/zzzlog"
+	l "github.com/tuxdudehomelab/homelab/internal/log"
+	"github.com/tuxdudehomelab/homelab/internal/testhelpers"
+)
+
+const (
+	testKeyFile      = "testdata/secrets/key"
+	testOtherKeyFile = "testdata/secrets/other-key"
+)
+
+var decryptConfigTests = []struct {
+	name    string
+	config  string
+	keyFile string
+	masked  bool
+	want    string
+}{
+	{
+		name: "Decrypt Config - No Secrets",
+		config: `global:
+  baseDir: /abc
+`,
+		want: `global:
+  baseDir: /abc
+`,
+	},
+	{
+		name: "Decrypt Config - Encrypted Tag",
+		config: `containers:
+  - image:
+      image: !encrypted hOeodQv4xy+lZ5Desqc8QYuwIdiCY6mBjJjidx97NgY6n4M=
+`,
+		keyFile: testKeyFile,
+		want: `containers:
+  - image:
+      image: abc/xyz
+`,
+	},
+	{
+		name: "Decrypt Config - Encrypted Prefix",
+		config: `ipam:
+  # Comment that is retained.
+  ip: enc:Zd5VMFI4p0Sd7o1aNSifEN04pi+aJGvLHufblNG7W0Pm/adsNyncpjA=
+`,
+		keyFile: testKeyFile,
+		want: `ipam:
+  # Comment that is retained.
+  ip: 172.18.100.11
+`,
+	},
+	{
+		name: "Decrypt Config - Masked",
+		config: `image: !encrypted hOeodQv4xy+lZ5Desqc8QYuwIdiCY6mBjJjidx97NgY6n4M=
+env:
+  - var: FOO
+    value: enc:cyAwFo6zYmfQhyk+u+ItRgkJz1YIAySTJdhftPVyKRLP4XzCzppMeLfseHYJYQ==
+  - var: BAR
+    value: bar
+`,
+		masked: true,
+		want: `image: <masked>
+env:
+  - var: FOO
+    value: <masked>
+  - var: BAR
+    value: bar
+`,
+	},
+}
+
+func TestDecryptConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range decryptConfigTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := newTestContext()
+			if len(tc.keyFile) > 0 {
+				ctx = WithKeyFile(ctx, tc.keyFile)
+			}
+			if tc.masked {
+				ctx = WithMasked(ctx)
+			}
+
+			got, gotErr := DecryptConfig(ctx, []byte(tc.config))
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "DecryptConfig()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "DecryptConfig()", tc.name, "config", tc.want, string(got)) {
+				return
+			}
+		})
+	}
+}
+
+var decryptConfigErrorTests = []struct {
+	name    string
+	config  string
+	keyFile string
+	want    string
+}{
+	{
+		name: "Decrypt Config - No Key File",
+		config: `image: !encrypted hOeodQv4xy+lZ5Desqc8QYuwIdiCY6mBjJjidx97NgY6n4M=
+`,
+		want: `homelab config contains encrypted values, reason: no secrets key file has been configured in the homelab CLI config`,
+	},
+	{
+		name: "Decrypt Config - Non Existing Key File",
+		config: `image: enc:hOeodQv4xy+lZ5Desqc8QYuwIdiCY6mBjJjidx97NgY6n4M=
+`,
+		keyFile: "testdata/secrets/non-existing-key",
+		want:    `homelab config contains encrypted values, reason: failed to read secrets key file, reason: open testdata/secrets/non-existing-key: no such file or directory`,
+	},
+	{
+		name: "Decrypt Config - Wrong Key",
+		config: `image: !encrypted hOeodQv4xy+lZ5Desqc8QYuwIdiCY6mBjJjidx97NgY6n4M=
+`,
+		keyFile: testOtherKeyFile,
+		want:    `failed to decrypt the value at line 1, reason: failed to decrypt value, possibly due to the wrong secrets key, reason: cipher: message authentication failed`,
+	},
+	{
+		name: "Decrypt Config - Invalid Base64",
+		config: `foo:
+  image: enc:%%%
+`,
+		keyFile: testKeyFile,
+		want:    `failed to decrypt the value at line 2, reason: encrypted value is not valid base64, reason: illegal base64 data at input byte 0`,
+	},
+	{
+		name: "Decrypt Config - Too Short",
+		config: `image: enc:YWJj
+`,
+		keyFile: testKeyFile,
+		want:    `failed to decrypt the value at line 1, reason: encrypted value is too short`,
+	},
+	{
+		name: "Decrypt Config - Plaintext Secret Tag",
+		config: `env:
+  - var: FOO
+    value: !secret 1234
+`,
+		keyFile: testKeyFile,
+		want:    `the value at line 3 is tagged with !secret but is not encrypted, encrypt it using the secrets encrypt command first`,
+	},
+	{
+		name: "Decrypt Config - Invalid Yaml",
+		config: `image: [enc:abc
+`,
+		keyFile: testKeyFile,
+		want:    `failed to parse yaml, reason: yaml: .+`,
+	},
+}
+
+func TestDecryptConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range decryptConfigErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := newTestContext()
+			if len(tc.keyFile) > 0 {
+				ctx = WithKeyFile(ctx, tc.keyFile)
+			}
+
+			_, gotErr := DecryptConfig(ctx, []byte(tc.config))
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "DecryptConfig()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "DecryptConfig()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
+
+var normalizeConfigTests = []struct {
+	name   string
+	config string
+	want   string
+}{
+	{
+		name: "Normalize Config - No Tags",
+		config: `image: enc:abc
+`,
+		want: `image: enc:abc
+`,
+	},
+	{
+		name: "Normalize Config - Encrypted Tag",
+		config: `image: !encrypted abc
+env:
+  - var: FOO
+    value: bar
+`,
+		want: `image: enc:abc
+env:
+  - var: FOO
+    value: bar
+`,
+	},
+}
+
+func TestNormalizeConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range normalizeConfigTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := NormalizeConfig([]byte(tc.config))
+			if gotErr != nil {
+				testhelpers.LogErrorNotNil(t, "NormalizeConfig()", tc.name, gotErr)
+				return
+			}
+
+			if !testhelpers.CmpDiff(t, "NormalizeConfig()", tc.name, "config", tc.want, string(got)) {
+				return
+			}
+		})
+	}
+}
+
+var normalizeConfigErrorTests = []struct {
+	name   string
+	config string
+	want   string
+}{
+	{
+		name: "Normalize Config - Plaintext Secret Tag",
+		config: `image: !encrypted abc
+env:
+  - var: FOO
+    value: !secret bar
+`,
+		want: `the value at line 4 is tagged with !secret but is not encrypted, encrypt it using the secrets encrypt command first`,
+	},
+}
+
+func TestNormalizeConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range normalizeConfigErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := NormalizeConfig([]byte(tc.config))
+			if gotErr == nil {
+				testhelpers.LogErrorNil(t, "NormalizeConfig()", tc.name, tc.want)
+				return
+			}
+
+			if !testhelpers.RegexMatch(t, "NormalizeConfig()", tc.name, "gotErr error string", tc.want, gotErr.Error()) {
+				return
+			}
+		})
+	}
+}
+
+func TestSecretsFileRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tc := "Secrets File - Encrypt Rotate Decrypt Round Trip"
+	config := `# Top level comment.
+global:
+  env:
+    - var: PASSWORD
+      # The password.
+      value: !secret my-password
+    - var: TOKEN
+      value: enc:cyAwFo6zYmfQhyk+u+ItRgkJz1YIAySTJdhftPVyKRLP4XzCzppMeLfseHYJYQ==
+    - var: PLAIN
+      value: plain-value
+`
+	want := `# Top level comment.
+global:
+  env:
+    - var: PASSWORD
+      # The password.
+      value: !secret my-password
+    - var: TOKEN
+      value: !secret my-secret-password
+    - var: PLAIN
+      value: plain-value
+`
+
+	key, err := ReadKeyFile(testKeyFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadKeyFile()", tc, err)
+		return
+	}
+	otherKey, err := ReadKeyFile(testOtherKeyFile)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadKeyFile()", tc, err)
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		testhelpers.LogErrorNotNil(t, "os.WriteFile()", tc, err)
+		return
+	}
+
+	if !secretsFileOp(t, tc, "EncryptFile()", func() (bool, error) { return EncryptFile(path, key) }) {
+		return
+	}
+	if !secretsFileOp(t, tc, "RotateFile()", func() (bool, error) { return RotateFile(path, key, otherKey) }) {
+		return
+	}
+
+	// The rotated file must no longer be decryptable using the old key.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "os.ReadFile()", tc, err)
+		return
+	}
+	ctx := WithKeyFile(newTestContext(), testKeyFile)
+	if _, err := DecryptConfig(ctx, data); err == nil {
+		testhelpers.LogErrorNil(t, "DecryptConfig()", tc, "message authentication failed")
+		return
+	}
+
+	if !secretsFileOp(t, tc, "DecryptFile()", func() (bool, error) { return DecryptFile(path, otherKey) }) {
+		return
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, "os.ReadFile()", tc, err)
+		return
+	}
+	if !testhelpers.CmpDiff(t, "DecryptFile()", tc, "config", want, string(got)) {
+		return
+	}
+}
+
+func secretsFileOp(t *testing.T, tc string, method string, fn func() (bool, error)) bool {
+	t.Helper()
+
+	changed, err := fn()
+	if err != nil {
+		testhelpers.LogErrorNotNil(t, method, tc, err)
+		return false
+	}
+	if !changed {
+		testhelpers.LogCustom(t, method, tc, "expected the file to be modified")
+		return false
+	}
+	return true
+}
+
+func TestWriteNewKeyFile(t *testing.T) {
+	t.Parallel()
+
+	tc := "Write New Key File - Existing File"
+	want := `secrets key file .+ already exists`
+
+	path := filepath.Join(t.TempDir(), "key")
+	if _, err := WriteNewKeyFile(path); err != nil {
+		testhelpers.LogErrorNotNil(t, "WriteNewKeyFile()", tc, err)
+		return
+	}
+	if _, err := ReadKeyFile(path); err != nil {
+		testhelpers.LogErrorNotNil(t, "ReadKeyFile()", tc, err)
+		return
+	}
+
+	_, gotErr := WriteNewKeyFile(path)
+	if gotErr == nil {
+		testhelpers.LogErrorNil(t, "WriteNewKeyFile()", tc, want)
+		return
+	}
+	if !testhelpers.RegexMatch(t, "WriteNewKeyFile()", tc, "gotErr error string", want, gotErr.Error()) {
+		return
+	}
+}
+
+func newTestContext() context.Context {
+	config := zzzlog.NewConsoleLoggerConfig()
+	config.SkipCallerInfo = true
+	config.PanicInFatal = true
+	config.Dest = new(bytes.Buffer)
+	return l.WithLogger(context.Background(), zzzlog.NewLogger(config))
+}
@@ -0,0 +1,263 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EncryptedTag is the custom YAML tag used to identify an inline
+	// encrypted string value within the homelab configs.
+	EncryptedTag = "!encrypted"
+	// SecretTag is the custom YAML tag used to identify a plaintext
+	// string value that needs to be encrypted by the secrets commands.
+	SecretTag = "!secret"
+	// MaskedValue is the value that replaces the encrypted values within
+	// the homelab config when the secrets are masked.
+	MaskedValue = "<masked>"
+
+	strTag = "!!str"
+)
+
+var (
+	errNoKeyFile = errors.New("no secrets key file has been configured in the homelab CLI config")
+)
+
+type scalarTransformer func(n *yaml.Node) (bool, error)
+
+// DecryptConfig decrypts all the encrypted values within the specified
+// homelab config and returns the plaintext config. The secrets key is only
+// read from the key file if the config contains encrypted values. If the
+// context requests the secrets to be masked, the encrypted values are
+// replaced with MaskedValue instead, without reading the secrets key.
+// Values tagged as secrets that have not been encrypted yet are rejected.
+func DecryptConfig(ctx context.Context, data []byte) ([]byte, error) {
+	if !hasSecrets(data) {
+		return data, nil
+	}
+
+	masked := MaskedFromContext(ctx)
+	var key *Key
+	out, changed, err := transform(data, func(n *yaml.Node) (bool, error) {
+		switch {
+		case n.Tag == SecretTag:
+			return false, plaintextSecretError(n)
+		case isEncrypted(n) && masked:
+			setPlaintext(n, MaskedValue, strTag)
+			return true, nil
+		case isEncrypted(n):
+			if key == nil {
+				var err error
+				key, err = KeyFromContext(ctx)
+				if err != nil {
+					return false, fmt.Errorf("homelab config contains encrypted values, reason: %w", err)
+				}
+			}
+			plaintext, err := key.Decrypt(n.Value)
+			if err != nil {
+				return false, fmt.Errorf("failed to decrypt the value at line %d, reason: %w", n.Line, err)
+			}
+			setPlaintext(n, plaintext, strTag)
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return data, nil
+	}
+	return out, nil
+}
+
+// NormalizeConfig converts the encrypted values using the custom YAML tag
+// within the homelab config into their plain string form using the
+// encrypted prefix. The normalized config can then be merged with other
+// configs without losing the information about the encrypted values.
+// Values tagged as secrets that have not been encrypted yet are rejected.
+func NormalizeConfig(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(EncryptedTag)) && !bytes.Contains(data, []byte(SecretTag)) {
+		return data, nil
+	}
+
+	out, changed, err := transform(data, func(n *yaml.Node) (bool, error) {
+		switch n.Tag {
+		case EncryptedTag:
+			n.Tag = strTag
+			n.Value = EncryptedPrefix + n.Value
+			n.Style = 0
+			return true, nil
+		case SecretTag:
+			return false, plaintextSecretError(n)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return data, nil
+	}
+	return out, nil
+}
+
+// EncryptFile encrypts all the values tagged as secrets within the
+// specified config file using the key, and rewrites the file in place.
+// Returns true if the file was modified.
+func EncryptFile(path string, key *Key) (bool, error) {
+	return transformFile(path, func(n *yaml.Node) (bool, error) {
+		if n.Tag != SecretTag {
+			return false, nil
+		}
+		ciphertext, err := key.Encrypt(n.Value)
+		if err != nil {
+			return false, err
+		}
+		n.Tag = EncryptedTag
+		n.Value = ciphertext
+		n.Style = 0
+		return true, nil
+	})
+}
+
+// DecryptFile decrypts all the encrypted values within the specified
+// config file using the key, and rewrites the file in place with the
+// plaintext values tagged as secrets. Returns true if the file was
+// modified.
+func DecryptFile(path string, key *Key) (bool, error) {
+	return transformFile(path, func(n *yaml.Node) (bool, error) {
+		if !isEncrypted(n) {
+			return false, nil
+		}
+		plaintext, err := key.Decrypt(n.Value)
+		if err != nil {
+			return false, fmt.Errorf("failed to decrypt the value at line %d, reason: %w", n.Line, err)
+		}
+		setPlaintext(n, plaintext, SecretTag)
+		return true, nil
+	})
+}
+
+// RotateFile re-encrypts all the encrypted values within the specified
+// config file using the new key, and rewrites the file in place.
+// Returns true if the file was modified.
+func RotateFile(path string, oldKey *Key, newKey *Key) (bool, error) {
+	return transformFile(path, func(n *yaml.Node) (bool, error) {
+		if !isEncrypted(n) {
+			return false, nil
+		}
+		plaintext, err := oldKey.Decrypt(n.Value)
+		if err != nil {
+			return false, fmt.Errorf("failed to decrypt the value at line %d, reason: %w", n.Line, err)
+		}
+		ciphertext, err := newKey.Encrypt(plaintext)
+		if err != nil {
+			return false, err
+		}
+		if n.Tag != EncryptedTag {
+			ciphertext = EncryptedPrefix + ciphertext
+		}
+		n.Value = ciphertext
+		return true, nil
+	})
+}
+
+func transformFile(path string, fn scalarTransformer) (bool, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("os.Stat() failed on config file %s, reason: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file %s, reason: %w", path, err)
+	}
+
+	out, changed, err := transform(data, fn)
+	if err != nil {
+		return false, fmt.Errorf("failed to process config file %s, reason: %w", path, err)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	err = os.WriteFile(path, out, stat.Mode().Perm())
+	if err != nil {
+		return false, fmt.Errorf("failed to write config file %s, reason: %w", path, err)
+	}
+	return true, nil
+}
+
+func transform(data []byte, fn scalarTransformer) ([]byte, bool, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, false, fmt.Errorf("failed to parse yaml, reason: %w", err)
+	}
+	if root.Kind == 0 {
+		return data, false, nil
+	}
+
+	changed, err := walk(&root, fn)
+	if err != nil {
+		return nil, false, err
+	}
+	if !changed {
+		return data, false, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, false, fmt.Errorf("failed to serialize yaml, reason: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, false, fmt.Errorf("failed to serialize yaml, reason: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+func walk(n *yaml.Node, fn scalarTransformer) (bool, error) {
+	if n.Kind == yaml.ScalarNode {
+		return fn(n)
+	}
+
+	changed := false
+	for _, c := range n.Content {
+		ch, err := walk(c, fn)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || ch
+	}
+	return changed, nil
+}
+
+func hasSecrets(data []byte) bool {
+	return bytes.Contains(data, []byte(EncryptedPrefix)) ||
+		bytes.Contains(data, []byte(EncryptedTag)) ||
+		bytes.Contains(data, []byte(SecretTag))
+}
+
+func isEncrypted(n *yaml.Node) bool {
+	return n.Tag == EncryptedTag || (n.ShortTag() == strTag && strings.HasPrefix(n.Value, EncryptedPrefix))
+}
+
+func plaintextSecretError(n *yaml.Node) error {
+	return fmt.Errorf("the value at line %d is tagged with %s but is not encrypted, encrypt it using the secrets encrypt command first", n.Line, SecretTag)
+}
+
+func setPlaintext(n *yaml.Node, plaintext string, tag string) {
+	n.Tag = tag
+	n.Value = plaintext
+	n.Style = 0
+	if strings.Contains(plaintext, "\n") {
+		n.Style = yaml.LiteralStyle
+	}
+}
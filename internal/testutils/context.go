@@ -13,6 +13,7 @@ import (
 	"github.com/tuxdudehomelab/homelab/internal/host/fakehost"
 	"github.com/tuxdudehomelab/homelab/internal/inspect"
 	"github.com/tuxdudehomelab/homelab/internal/log"
+	"github.com/tuxdudehomelab/homelab/internal/secrets"
 	"github.com/tuxdudehomelab/homelab/internal/user"
 	"github.com/tuxdudehomelab/homelab/internal/user/fakeuser"
 )
@@ -23,7 +24,9 @@ type TestContextInfo struct {
 	Version                    *version.VersionInfo
 	Executor                   cmdexec.Executor
 	DockerHost                 docker.APIClient
+	RemoteDockerHosts          map[string]docker.APIClient
 	ContainerPurgeKillAttempts uint32
+	SecretsKeyFile             string
 	UseRealUserInfo            bool
 	UseRealHostInfo            bool
 	UseRealExecutor            bool
@@ -63,6 +66,12 @@ func NewTestContext(info *TestContextInfo) context.Context {
 	if info.DockerHost != nil {
 		ctx = docker.WithAPIClient(ctx, info.DockerHost)
 	}
+	if info.RemoteDockerHosts != nil {
+		ctx = docker.WithRemoteAPIClients(ctx, info.RemoteDockerHosts)
+	}
+	if len(info.SecretsKeyFile) > 0 {
+		ctx = secrets.WithKeyFile(ctx, info.SecretsKeyFile)
+	}
 	if info.ContainerPurgeKillAttempts != 0 {
 		ctx = docker.WithContainerPurgeKillAttempts(ctx, info.ContainerPurgeKillAttempts)
 	}
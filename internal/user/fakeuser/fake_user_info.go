@@ -2,6 +2,7 @@ package fakeuser
 
 import (
 	osuser "os/user"
+	"slices"
 
 	"github.com/tuxdudehomelab/homelab/internal/user"
 )
@@ -17,10 +18,14 @@ const (
 	FakeUserOtherGroupsID1   = "44445"
 	FakeUserOtherGroupsName2 = "fakegroup3"
 	FakeUserOtherGroupsID2   = "44446"
+	FakeHostDockerGroupName  = "docker"
+	FakeHostDockerGroupID    = "44447"
+	FakeHostRenderGroupName  = "render"
+	FakeHostRenderGroupID    = "44448"
 )
 
 func NewFakeUserInfo() *user.UserInfo {
-	return &user.UserInfo{
+	res := &user.UserInfo{
 		User: osuser.User{
 			Uid:      FakeUserID,
 			Gid:      FakeUserPrimaryGroupID,
@@ -47,4 +52,27 @@ func NewFakeUserInfo() *user.UserInfo {
 			},
 		},
 	}
+	res.LookupGroup = fakeLookupGroup(append(
+		slices.Clone(res.AllGroups),
+		osuser.Group{
+			Gid:  FakeHostDockerGroupID,
+			Name: FakeHostDockerGroupName,
+		},
+		osuser.Group{
+			Gid:  FakeHostRenderGroupID,
+			Name: FakeHostRenderGroupName,
+		},
+	))
+	return res
+}
+
+func fakeLookupGroup(groups []osuser.Group) func(string) (*osuser.Group, error) {
+	return func(name string) (*osuser.Group, error) {
+		for _, g := range groups {
+			if g.Name == name {
+				return &g, nil
+			}
+		}
+		return nil, osuser.UnknownGroupError(name)
+	}
 }
@@ -9,6 +9,8 @@ type UserInfo struct {
 	User         user.User
 	PrimaryGroup user.Group
 	AllGroups    []user.Group
+	// LookupGroup looks up any group on the host by its name.
+	LookupGroup func(name string) (*user.Group, error)
 }
 
 var (
@@ -43,6 +45,7 @@ func NewUserInfo(ctx context.Context) *UserInfo {
 		User:         *u,
 		PrimaryGroup: *pg,
 		AllGroups:    groups,
+		LookupGroup:  user.LookupGroup,
 	}
 
 }